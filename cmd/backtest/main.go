@@ -0,0 +1,88 @@
+package main
+
+// main.go — scores the match prediction model against every historical series.
+//
+// Series are replayed in rating order with a fresh Glicko-2 engine: each series and
+// each of its maps is predicted from the ratings and mode residuals built so far, then
+// its result is folded in. Nothing is written; the report goes to stdout (or JSON with
+// -json). The baselines are what a constant 0.5 prediction would score.
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+func main() {
+	rating := services.DefaultRatingConfig()
+	cfg := services.DefaultPredictionConfig()
+	flag.Float64Var(&rating.CarryOver, "carry-over", rating.CarryOver, "fraction of a franchise's prior-era rating kept at the start of a new era")
+	flag.Float64Var(&rating.Tau, "tau", rating.Tau, "Glicko-2 system constant (volatility change)")
+	flag.Float64Var(&cfg.MapLogitScale, "map-scale", cfg.MapLogitScale, "map log-odds as a fraction of series log-odds")
+	flag.Float64Var(&cfg.ModeShrinkage, "mode-shrinkage", cfg.ModeShrinkage, "pseudo-maps shrinking each team's mode residual toward zero")
+	flag.Float64Var(&cfg.ModeWeight, "mode-weight", cfg.ModeWeight, "map log-odds per unit of mode residual difference")
+	bins := flag.Int("bins", 10, "number of calibration bins")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	if *bins < 1 {
+		log.Fatalf("-bins must be at least 1, got %d", *bins)
+	}
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+	database.RequireSchema()
+	db := database.DB
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	matches, err := store.NewGormRatingStore(db).ListRatableMatches(ctx, false)
+	if err != nil {
+		log.Fatalf("loading series: %v", err)
+	}
+	maps, err := store.NewGormPredictionStore(db).ListAllMaps(ctx)
+	if err != nil {
+		log.Fatalf("loading maps: %v", err)
+	}
+
+	report := services.Backtest(matches, maps, rating, cfg, *bins)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatalf("encoding report: %v", err)
+		}
+		return
+	}
+	printCard("Series", report.Series)
+	printCard("Maps", report.Maps)
+	for _, mode := range []string{"hp", "snd", "control"} {
+		printCard("Maps ("+mode+")", report.ByMode[mode])
+	}
+}
+
+func printCard(title string, c services.ScoreCard) {
+	fmt.Printf("== %s: %d predictions\n", title, c.Count)
+	if c.Count == 0 {
+		fmt.Println()
+		return
+	}
+	fmt.Printf("   Brier    %.4f  (baseline %.4f)\n", c.Brier, c.BaselineBrier)
+	fmt.Printf("   Log loss %.4f  (baseline %.4f)\n", c.LogLoss, c.BaselineLogLoss)
+	fmt.Printf("   Accuracy %.1f%%\n", 100*c.Accuracy)
+	fmt.Println("   Calibration   count  predicted  observed")
+	for _, b := range c.Calibration {
+		fmt.Printf("   %.2f-%.2f  %7d  %9.3f  %8.3f\n", b.Lower, b.Upper, b.Count, b.MeanPredicted, b.ObservedRate)
+	}
+	fmt.Println()
+}
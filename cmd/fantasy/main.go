@@ -0,0 +1,87 @@
+package main
+
+// main.go — fantasy scoring job.
+//
+// By default every tournament with at least one fantasy league is rescored from
+// player_map_stats, replacing its fantasy_player_scores rows, so running it again
+// after stats are corrected brings every league's standings up to date. -tournament
+// limits the run to one tournament.
+//
+// With -price the tournament's salary pool is rebuilt from player form instead
+// (needs -tournament). -rules points at a JSON file replacing
+// services.DefaultFantasyRuleSet (same shape as its JSON tags: "default" plus
+// optional "by_game_code" overrides).
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+func main() {
+	tournamentID := flag.Uint("tournament", 0, "only score (or price) this tournament")
+	price := flag.Bool("price", false, "rebuild the tournament's salary pool instead of scoring")
+	rulesFile := flag.String("rules", "", "JSON file replacing the default fantasy scoring rules")
+	flag.Parse()
+
+	if *price && *tournamentID == 0 {
+		log.Fatal("-price needs -tournament")
+	}
+
+	cfg := services.DefaultFantasyConfig()
+	if *rulesFile != "" {
+		raw, err := os.ReadFile(*rulesFile)
+		if err != nil {
+			log.Fatalf("reading -rules: %v", err)
+		}
+		var rules services.FantasyRuleSet
+		if err := json.Unmarshal(raw, &rules); err != nil {
+			log.Fatalf("parsing -rules: %v", err)
+		}
+		cfg.Rules = rules
+	}
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+	database.RequireSchema()
+	db := database.DB
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	tournaments := store.NewGormTournamentStore(db)
+	svc := services.NewFantasyService(store.NewGormFantasyStore(db), tournaments, cfg)
+	start := time.Now()
+
+	switch {
+	case *price:
+		tournament, err := tournaments.GetByID(ctx, int(*tournamentID))
+		if err != nil {
+			log.Fatalf("loading tournament %d: %v", *tournamentID, err)
+		}
+		n, err := svc.PriceTournament(ctx, tournament)
+		if err != nil {
+			log.Fatalf("pricing failed: %v", err)
+		}
+		log.Printf("==> Fantasy: %d players priced for %s in %s", n, tournament.Name, time.Since(start).Round(time.Millisecond))
+	case *tournamentID != 0:
+		n, err := svc.ScoreTournament(ctx, *tournamentID)
+		if err != nil {
+			log.Fatalf("scoring failed: %v", err)
+		}
+		log.Printf("==> Fantasy: %d players scored for tournament %d in %s", n, *tournamentID, time.Since(start).Round(time.Millisecond))
+	default:
+		n, err := svc.ScoreAll(ctx)
+		if err != nil {
+			log.Fatalf("scoring failed: %v", err)
+		}
+		log.Printf("==> Fantasy: %d tournaments scored in %s", n, time.Since(start).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+// main.go — fake live feeder for exercising the ingestion API and the SSE stream locally.
+//
+// It plays out a random series for an existing match and pushes map scores and player
+// stat lines to /ingest the way a real feed would, one tick per -interval:
+//
+//	INGEST_API_KEY=dev go run ./cmd/livefeed -match 123 -team1 4 -team2 7 \
+//	    -players1 11,12,13,14 -players2 21,22,23,24
+//
+// Watch the result with: curl -N localhost:8080/api/v1/matches/123/live
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+)
+
+// seriesModes is the CDL best-of-five mode order; longer series repeat it.
+var seriesModes = []string{"hp", "snd", "control", "hp", "snd"}
+
+var mapNames = []string{"Skyline", "Rewind", "Protocol", "Vault", "Hacienda", "Red Card"}
+
+// modeTarget is the score that ends a map in each mode: Hardpoint points, SnD and
+// Control rounds.
+var modeTarget = map[string]int{"hp": 250, "snd": 6, "control": 3}
+
+// step is one push: a map score and, optionally, the stat lines as of that moment.
+type step struct {
+	MapNumber int
+	Map       services.LiveMapUpdate
+	Stats     []services.LiveStatLine
+}
+
+type seriesConfig struct {
+	BestOf   int
+	Team1    uint
+	Team2    uint
+	Players1 []uint
+	Players2 []uint
+}
+
+// playSeries scripts a whole series, map by map, until one side has a majority.
+func playSeries(rng *rand.Rand, cfg seriesConfig) []step {
+	var steps []step
+	need := cfg.BestOf/2 + 1
+	won1, won2 := 0, 0
+	for n := 1; won1 < need && won2 < need; n++ {
+		mode := seriesModes[(n-1)%len(seriesModes)]
+		mapSteps := playMap(rng, cfg, n, mode)
+		steps = append(steps, mapSteps...)
+		if last := mapSteps[len(mapSteps)-1].Map; last.Score1 > last.Score2 {
+			won1++
+		} else {
+			won2++
+		}
+	}
+	return steps
+}
+
+func playMap(rng *rand.Rand, cfg seriesConfig, number int, mode string) []step {
+	u := services.LiveMapUpdate{MapName: mapNames[rng.Intn(len(mapNames))], Mode: mode}
+	lines := map[uint]*services.LiveStatLine{}
+	for _, p := range cfg.Players1 {
+		lines[p] = &services.LiveStatLine{PlayerID: p, TeamID: cfg.Team1}
+	}
+	for _, p := range cfg.Players2 {
+		lines[p] = &services.LiveStatLine{PlayerID: p, TeamID: cfg.Team2}
+	}
+
+	target := modeTarget[mode]
+	var steps []step
+	for u.Score1 < target && u.Score2 < target {
+		gain := 1
+		if mode == "hp" {
+			gain = 5 + 5*rng.Intn(5)
+		}
+		if rng.Intn(2) == 0 {
+			u.Score1 = min(u.Score1+gain, target)
+		} else {
+			u.Score2 = min(u.Score2+gain, target)
+		}
+		u.DurationSec += 30 + rng.Intn(60)
+		for _, l := range lines {
+			kills := rng.Intn(4)
+			l.Kills += kills
+			l.Deaths += rng.Intn(4)
+			l.Damage += 100*kills + rng.Intn(150)
+			l.Assists += rng.Intn(2)
+			if mode == "hp" {
+				l.HillTime += rng.Intn(20)
+			}
+		}
+		u.Final = u.Score1 >= target || u.Score2 >= target
+		steps = append(steps, step{MapNumber: number, Map: u, Stats: snapshotLines(lines)})
+	}
+	return steps
+}
+
+func snapshotLines(lines map[uint]*services.LiveStatLine) []services.LiveStatLine {
+	out := make([]services.LiveStatLine, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, *l)
+	}
+	return out
+}
+
+func parseIDs(s string) ([]uint, error) {
+	var ids []uint
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad id %q", part)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+func put(client *http.Client, url, key string, body any) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+key)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct{ Error string }
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("%s: %d %s", url, resp.StatusCode, e.Error)
+	}
+	return nil
+}
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8080/api/v1", "API base URL")
+	key := flag.String("key", os.Getenv("INGEST_API_KEY"), "ingest key (defaults to $INGEST_API_KEY)")
+	matchID := flag.Int("match", 0, "match ID to feed")
+	team1 := flag.Uint("team1", 0, "the match's team1_id")
+	team2 := flag.Uint("team2", 0, "the match's team2_id")
+	players1 := flag.String("players1", "", "comma-separated player IDs for team1 (omit to send scores only)")
+	players2 := flag.String("players2", "", "comma-separated player IDs for team2")
+	bestOf := flag.Int("best-of", 5, "series length")
+	interval := flag.Duration("interval", 2*time.Second, "delay between pushes")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed, for repeatable series")
+	flag.Parse()
+
+	if *matchID <= 0 || *team1 == 0 || *team2 == 0 {
+		log.Fatal("-match, -team1 and -team2 are required")
+	}
+	if *key == "" {
+		log.Fatal("-key or INGEST_API_KEY is required")
+	}
+	p1, err := parseIDs(*players1)
+	if err != nil {
+		log.Fatalf("-players1: %v", err)
+	}
+	p2, err := parseIDs(*players2)
+	if err != nil {
+		log.Fatalf("-players2: %v", err)
+	}
+
+	steps := playSeries(rand.New(rand.NewSource(*seed)), seriesConfig{
+		BestOf: *bestOf, Team1: *team1, Team2: *team2, Players1: p1, Players2: p2,
+	})
+	log.Printf("==> Feeding match %d: %d updates (seed %d)", *matchID, len(steps), *seed)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	base := strings.TrimRight(*baseURL, "/")
+	for i, s := range steps {
+		mapURL := fmt.Sprintf("%s/ingest/matches/%d/maps/%d", base, *matchID, s.MapNumber)
+		if err := put(client, mapURL, *key, s.Map); err != nil {
+			log.Fatalf("map update: %v", err)
+		}
+		if len(s.Stats) > 0 {
+			if err := put(client, mapURL+"/stats", *key, map[string]any{"stats": s.Stats}); err != nil {
+				log.Fatalf("stats update: %v", err)
+			}
+		}
+		log.Printf("map %d %s %d-%d%s", s.MapNumber, s.Map.Mode, s.Map.Score1, s.Map.Score2,
+			map[bool]string{true: " (final)"}[s.Map.Final])
+		if i < len(steps)-1 {
+			time.Sleep(*interval)
+		}
+	}
+	log.Println("==> Series complete")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaySeries(t *testing.T) {
+	cfg := seriesConfig{BestOf: 5, Team1: 1, Team2: 2, Players1: []uint{11, 12}, Players2: []uint{21, 22}}
+	for seed := int64(0); seed < 20; seed++ {
+		steps := playSeries(rand.New(rand.NewSource(seed)), cfg)
+		require.NotEmpty(t, steps)
+
+		won := map[bool]int{}
+		prev := steps[0]
+		for i, s := range steps {
+			assert.LessOrEqual(t, s.MapNumber, cfg.BestOf)
+			assert.Len(t, s.Stats, 4)
+			if i > 0 && s.MapNumber == prev.MapNumber {
+				assert.False(t, prev.Map.Final, "no update after a map is final")
+				assert.GreaterOrEqual(t, s.Map.Score1, prev.Map.Score1)
+				assert.GreaterOrEqual(t, s.Map.Score2, prev.Map.Score2)
+			}
+			if s.Map.Final {
+				assert.NotEqual(t, s.Map.Score1, s.Map.Score2)
+				assert.Equal(t, modeTarget[s.Map.Mode], max(s.Map.Score1, s.Map.Score2))
+				won[s.Map.Score1 > s.Map.Score2]++
+			}
+			prev = s
+		}
+		assert.True(t, steps[len(steps)-1].Map.Final)
+		assert.Equal(t, 3, max(won[true], won[false]), "series ends as soon as one side has three maps")
+		assert.Less(t, min(won[true], won[false]), 3)
+	}
+}
+
+func TestParseIDs(t *testing.T) {
+	ids, err := parseIDs("11, 12,,13")
+	require.NoError(t, err)
+	assert.Equal(t, []uint{11, 12, 13}, ids)
+	_, err = parseIDs("11,x")
+	assert.Error(t, err)
+}
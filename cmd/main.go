@@ -17,7 +17,7 @@ func main() {
 
 	database.ConnectDatabase()
 	defer database.CloseDatabase()
-	database.AutoMigrate()
+	database.RequireSchema()
 
 	r := gin.New()
 	r.Use(gin.Recovery())
@@ -0,0 +1,125 @@
+package main
+
+// main.go — schema migrations.
+//
+// Applies and rolls back the versioned SQL migrations embedded from
+// internal/database/migrations. The server and every job refuse to start while a
+// migration is pending, so deploys run "migrate up" first.
+//
+//	go run ./cmd/migrate status          # every version, applied or pending
+//	go run ./cmd/migrate up [-to N]      # apply pending migrations (up to version N)
+//	go run ./cmd/migrate down [-steps N] # roll back the newest N (default 1)
+//	go run ./cmd/migrate redo            # roll back the newest and apply it again
+//
+// A database created by AutoMigrate before migrations existed adopts the baseline
+// with "up": its statements are all IF NOT EXISTS, and 0002 adds whatever tables and
+// columns it is missing.
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate status | up [-to N] | down [-steps N] | redo")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd := os.Args[1]
+	flags := flag.NewFlagSet(cmd, flag.ExitOnError)
+	to := flags.Int("to", 0, "up: stop after this version")
+	steps := flags.Int("steps", 1, "down: how many migrations to roll back")
+	_ = flags.Parse(os.Args[2:])
+	switch cmd {
+	case "status", "up", "down", "redo":
+	default:
+		usage()
+	}
+	if *steps < 1 {
+		log.Fatal("-steps must be at least 1")
+	}
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+	m, err := database.NewMigrator(database.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	switch cmd {
+	case "status":
+		err = printStatus(ctx, m)
+	case "up":
+		err = up(ctx, m, *to)
+	case "down":
+		err = down(ctx, m, *steps)
+	case "redo":
+		var ran []database.Migration
+		if ran, err = m.Down(ctx, 1); err == nil && len(ran) > 0 {
+			fmt.Printf("==> rolled back %04d_%s\n", ran[0].Version, ran[0].Name)
+			err = up(ctx, m, ran[0].Version)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printStatus(ctx context.Context, m *database.Migrator) error {
+	states, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, s := range states {
+		state := "pending"
+		if s.AppliedAt != nil {
+			state = "applied " + s.AppliedAt.Format(time.DateTime)
+		} else {
+			pending++
+		}
+		switch {
+		case s.Changed:
+			state += " (CHANGED since applied)"
+		case s.Missing:
+			state += " (not in this build)"
+		}
+		fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+	}
+	fmt.Printf("==> %d pending, latest is %04d\n", pending, m.Latest())
+	return nil
+}
+
+func up(ctx context.Context, m *database.Migrator, to int) error {
+	ran, err := m.Up(ctx, to)
+	for _, mig := range ran {
+		fmt.Printf("==> applied %04d_%s\n", mig.Version, mig.Name)
+	}
+	if err == nil && len(ran) == 0 {
+		fmt.Println("==> schema is up to date")
+	}
+	return err
+}
+
+func down(ctx context.Context, m *database.Migrator, steps int) error {
+	ran, err := m.Down(ctx, steps)
+	for _, mig := range ran {
+		fmt.Printf("==> rolled back %04d_%s\n", mig.Version, mig.Name)
+	}
+	if err == nil && len(ran) == 0 {
+		fmt.Println("==> nothing to roll back")
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package main
+
+// main.go — notification worker.
+//
+// Every -interval it turns new decided matches, transfers and @mentions into
+// notifications for the users who follow (or were mentioned by) them, queues those
+// on each user's enabled channels and sends whatever deliveries are due, retrying
+// failures with exponential backoff. Each pass also rolls up the player and team
+// totals of tournaments live ingestion has queued, rates newly decided series
+// (the same update as cmd/ratings -incremental) and scores pick'em picks on
+// matches decided since the last one. -once runs a single pass and exits.
+//
+// Email is sent through SMTP_ADDR as SMTP_FROM (optionally authenticating with
+// SMTP_USERNAME / SMTP_PASSWORD) and is skipped when those are unset. Webhooks are
+// always on; -allow-private-webhooks lets them reach local stand-ins.
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/notify"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+func main() {
+	once := flag.Bool("once", false, "run one pass and exit")
+	interval := flag.Duration("interval", time.Minute, "time between passes")
+	allowPrivate := flag.Bool("allow-private-webhooks", false, "let webhooks reach loopback and private addresses")
+	flag.Parse()
+
+	siteURL := os.Getenv("SITE_URL")
+	if siteURL == "" {
+		siteURL = "https://cdlytics.com"
+	}
+
+	channels := []notify.Channel{notify.NewWebhook(10*time.Second, *allowPrivate)}
+	if smtp := notify.SMTPFromEnv(siteURL); smtp != nil {
+		channels = append(channels, smtp)
+	} else {
+		log.Println("SMTP_ADDR / SMTP_FROM not set; email delivery disabled")
+	}
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+	database.RequireSchema()
+
+	worker := services.NewNotificationWorker(store.NewGormNotificationStore(database.DB), services.DefaultNotifyWorkerConfig(), channels...)
+	live := services.NewLiveService(store.NewGormLiveStore(database.DB), nil, nil)
+	ratings := services.NewRatingService(store.NewGormRatingStore(database.DB), store.NewGormSeasonStore(database.DB), services.DefaultRatingConfig())
+	pickem := services.NewPickemService(store.NewGormPickemStore(database.DB), nil, nil, services.DefaultPickemConfig())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		passCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		rolled, rollupErr := live.RollupTournaments(passCtx)
+		rated, rateErr := ratings.UpdateIncremental(passCtx)
+		scored, scoreErr := pickem.ScorePicks(passCtx)
+		run, err := worker.RunOnce(passCtx)
+		cancel()
+		if rollupErr != nil {
+			log.Printf("tournament rollup failed: %v", rollupErr)
+		} else if rolled > 0 {
+			log.Printf("==> Rollup: %d tournaments rebuilt", rolled)
+		}
+		if rateErr != nil {
+			log.Printf("team rating update failed: %v", rateErr)
+		} else if rated > 0 {
+			log.Printf("==> Ratings: %d series rated", rated)
+		}
+		if scoreErr != nil {
+			log.Printf("pick'em scoring failed: %v", scoreErr)
+		} else if scored > 0 {
+			log.Printf("==> Pick'em: %d picks scored", scored)
+		}
+		if err != nil {
+			log.Printf("notify pass failed: %v", err)
+		} else if run != (services.NotifyRun{}) {
+			log.Printf("==> Notify: %d created, %d queued, %d sent, %d retrying, %d failed",
+				run.Created, run.Queued, run.Sent, run.Retried, run.Failed)
+		}
+		if *once {
+			if err != nil || scoreErr != nil || rollupErr != nil || rateErr != nil {
+				os.Exit(1)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*interval):
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+// main.go — data patch runner.
+//
+// Applies declarative patch files (YAML or JSON, see store.Patch) to the database in
+// file-name order, each in its own transaction. Patches name matches and teams by
+// tournament slug, team name and date instead of row IDs, so the same file works on
+// any copy of the data. Every changed row is recorded in change_log under the
+// "patch" actor, and applied patches are recorded in applied_patches: re-running a
+// directory skips what's already in, and a patch edited after it was applied is
+// refused.
+//
+// Arguments are patch files or directories of them (default database/patches).
+// -dry-run prints the diff each patch would make and rolls it back; since nothing
+// is kept, a patch that depends on an earlier unapplied one can fail in a dry run.
+//
+//	go run ./cmd/patch -dry-run database/patches/0007-rename.yaml
+//	go run ./cmd/patch
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "print what each patch would change and roll it back")
+	flag.Parse()
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		paths = []string{"database/patches"}
+	}
+	files, err := patchFiles(paths)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		log.Fatal("no patch files found")
+	}
+
+	// Parse everything first so a typo in the last file doesn't leave the run half done.
+	patches := make([]*store.Patch, 0, len(files))
+	seen := map[string]string{}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			log.Fatal(err)
+		}
+		p, err := services.ParsePatch(f, data)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if prev, ok := seen[p.ID]; ok {
+			log.Fatalf("patch id %q is used by both %s and %s", p.ID, prev, f)
+		}
+		seen[p.ID] = f
+		patches = append(patches, p)
+	}
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+	database.RequireSchema()
+
+	runner := services.NewPatchService(store.NewGormPatchStore(database.DB))
+	var applied, skipped, changes int
+	for _, p := range patches {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		run, err := runner.Run(ctx, p, *dryRun)
+		cancel()
+		if err != nil {
+			log.Fatalf("patch failed: %v", err)
+		}
+		if run.Skipped {
+			skipped++
+			fmt.Printf("==> %s: already applied\n", run.ID)
+			continue
+		}
+		applied++
+		changes += len(run.Changes)
+		verb := "applied"
+		if run.DryRun {
+			verb = "would change"
+		}
+		fmt.Printf("==> %s: %s %d rows\n", run.ID, verb, len(run.Changes))
+		for _, c := range run.Changes {
+			for _, line := range services.DiffChange(c) {
+				fmt.Println("  " + line)
+			}
+		}
+		for _, note := range run.Notes {
+			fmt.Println("  note: " + note)
+		}
+	}
+
+	summary := fmt.Sprintf("==> Patches: %d applied, %d already applied, %d rows changed", applied, skipped, changes)
+	if *dryRun {
+		summary += " (dry run, nothing kept)"
+	}
+	fmt.Println(summary)
+}
+
+// patchFiles expands directories to the patch files directly inside them and sorts
+// the lot by file name, which is the order patches are applied in.
+func patchFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			switch strings.ToLower(filepath.Ext(e.Name())) {
+			case ".yaml", ".yml", ".json":
+				if !e.IsDir() {
+					files = append(files, filepath.Join(path, e.Name()))
+				}
+			}
+		}
+	}
+	slices.SortFunc(files, func(a, b string) int { return strings.Compare(filepath.Base(a), filepath.Base(b)) })
+	return slices.Compact(files), nil
+}
@@ -0,0 +1,97 @@
+package main
+
+// main.go — recomputes Glicko-2 team ratings and player performance ratings.
+//
+// By default every team rating is rebuilt from scratch (team_ratings and
+// team_rating_history are replaced in one transaction). With -incremental only series
+// that have no rating history yet are processed; if one of them predates the last rated
+// series the run falls back to a full rebuild so the chronology stays correct.
+// cmd/notify runs the incremental update on every pass, so series decided by admin
+// edits, patches or live ingestion are rated within a pass; an edit to a series that
+// is already rated (a corrected winner, a merged team) needs a full run.
+//
+// With -players the per-map player performance rating is recomputed instead and rolled
+// up into player_match_stats / player_tournament_stats. -weights points at a JSON file
+// overriding any of services.DefaultPerformanceWeights (same field names as its JSON tags).
+// The run also stores the era baselines and weights it used; admin edits, patches and
+// live ingestion re-rate the matches they touch against those, so new lines are rated
+// between runs. Re-run -players to put every line on fresh baselines.
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+func main() {
+	incremental := flag.Bool("incremental", false, "only rate series that have no rating history yet")
+	carryOver := flag.Float64("carry-over", services.DefaultRatingConfig().CarryOver,
+		"fraction of a franchise's prior-era rating kept at the start of a new era (0 disables)")
+	tau := flag.Float64("tau", services.DefaultRatingConfig().Tau, "Glicko-2 system constant (volatility change)")
+	players := flag.Bool("players", false, "recompute player performance ratings instead of team ratings")
+	weightsFile := flag.String("weights", "", "JSON file overriding the default performance rating weights (with -players)")
+	flag.Parse()
+
+	if *carryOver < 0 || *carryOver > 1 {
+		log.Fatalf("-carry-over must be between 0 and 1, got %v", *carryOver)
+	}
+
+	weights := services.DefaultPerformanceWeights()
+	if *weightsFile != "" {
+		raw, err := os.ReadFile(*weightsFile)
+		if err != nil {
+			log.Fatalf("reading -weights: %v", err)
+		}
+		if err := json.Unmarshal(raw, &weights); err != nil {
+			log.Fatalf("parsing -weights: %v", err)
+		}
+	}
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+	database.RequireSchema()
+	db := database.DB
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	if *players {
+		log.Println("==> Ratings: player performance recompute")
+		svc := services.NewPerformanceRatingService(store.NewGormPerformanceStore(db), weights)
+		n, err := svc.Recompute(ctx)
+		if err != nil {
+			log.Fatalf("performance rating update failed: %v", err)
+		}
+		log.Printf("==> Ratings: %d player maps rated in %s", n, time.Since(start).Round(time.Millisecond))
+		return
+	}
+
+	cfg := services.DefaultRatingConfig()
+	cfg.CarryOver = *carryOver
+	cfg.Tau = *tau
+	svc := services.NewRatingService(store.NewGormRatingStore(db), store.NewGormSeasonStore(db), cfg)
+
+	var (
+		n   int
+		err error
+	)
+	if *incremental {
+		log.Println("==> Ratings: incremental update")
+		n, err = svc.UpdateIncremental(ctx)
+	} else {
+		log.Println("==> Ratings: full recompute")
+		n, err = svc.Recompute(ctx)
+	}
+	if err != nil {
+		log.Fatalf("rating update failed: %v", err)
+	}
+	log.Printf("==> Ratings: %d series rated in %s", n, time.Since(start).Round(time.Millisecond))
+}
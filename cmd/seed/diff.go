@@ -0,0 +1,467 @@
+package main
+
+// diff.go — how the seeder compares CSV data with the database.
+//
+// A phase describes the rows its CSVs imply and the slice of a table it owns (the
+// scope: e.g. every match with a breaking_point_match_id). syncRows loads the owned
+// rows, pairs them with the CSV rows by natural key and records the difference in
+// the phase's report: inserts, updates (column by column, before and after) and
+// orphans — owned rows that no CSV row produces any more. Only the listed columns
+// are compared or written, so values filled in elsewhere (ratings, abbreviations,
+// tournament metadata) are never overwritten. Orphans are reported and kept unless
+// the run has -prune; tables the seeder derives wholesale always drop them.
+//
+// Writes to the change-logged tables (matches, match_maps, player_map_stats, teams)
+// are recorded in change_log under the seed actor. A row whose latest change was an
+// admin correction or a data patch is neither updated nor deleted: the seeder
+// reports it as a conflict and leaves it be. Orphans other tables still point at
+// (a match with picks or a discussion thread) are kept and reported too.
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+type orphanPolicy int
+
+const (
+	orphansReport orphanPolicy = iota // kept and reported; deleted with -prune
+	orphansIgnore                     // not computed: other phases add rows to the scope too
+	orphansDelete                     // always deleted: the table is derived, not curated
+)
+
+// tableSync describes one table's share of a phase.
+type tableSync[T any] struct {
+	table   string
+	key     func(*T) string
+	columns []string // columns the CSV owns; the rest are only written on insert
+	scope   func(*gorm.DB) *gorm.DB
+	orphans orphanPolicy
+	// referenced returns, with a reason, the rows among ids that other tables point
+	// at; orphans among them are kept and reported instead of deleted.
+	referenced func(tx *gorm.DB, ids []any) (map[uint]string, error)
+}
+
+// rowPlan is a computed, not yet applied, table diff.
+type rowPlan[T any] struct {
+	sync      tableSync[T]
+	schema    *schema.Schema
+	diff      *tableDiff
+	changes   *store.SeedLog // nil unless the table is change-logged
+	entity    string
+	rows      map[string]*T // every owned row by key, inserts included once applied
+	inserts   []*T
+	updates   []rowUpdate[T]
+	stale     []*T
+	staleKeys map[*T]string
+}
+
+type rowUpdate[T any] struct {
+	row    *T
+	values map[string]any
+}
+
+// syncRows diffs desired against the owned rows and applies the result: inserts,
+// updates, then deletes. It returns every row in scope by key, so callers can pick
+// up the IDs of what they seeded.
+func syncRows[T any](tx *gorm.DB, pd *phaseDiff, s tableSync[T], desired []T) (map[string]*T, error) {
+	p, err := planRows(tx, pd, s, desired)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.apply(tx); err != nil {
+		return nil, err
+	}
+	if err := p.prune(tx); err != nil {
+		return nil, err
+	}
+	return p.rows, nil
+}
+
+// planRows computes the diff and records it in pd without writing anything.
+// When a key repeats in desired the first row wins, as it did with FirstOrCreate.
+func planRows[T any](tx *gorm.DB, pd *phaseDiff, s tableSync[T], desired []T) (*rowPlan[T], error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	diff := pd.table(s.table)
+	p := &rowPlan[T]{sync: s, schema: stmt.Schema, diff: diff, rows: map[string]*T{}}
+	if entity, ok := store.SeedEntity(s.table); ok {
+		p.changes, p.entity = pd.changeLog(), entity
+	}
+	fields := make([]*schema.Field, len(s.columns))
+	for i, col := range s.columns {
+		if fields[i] = p.schema.LookUpField(col); fields[i] == nil {
+			return nil, fmt.Errorf("%s has no column %q", s.table, col)
+		}
+	}
+
+	q := tx.Model(new(T))
+	if s.scope != nil {
+		q = s.scope(q)
+	}
+	var existing []T
+	if err := q.Order(p.schema.PrioritizedPrimaryField.DBName).Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("loading %s: %w", s.table, err)
+	}
+	var orphans []string
+	staleKeys := map[*T]string{}
+	for i := range existing {
+		row := &existing[i]
+		k := s.key(row)
+		if _, dup := p.rows[k]; !dup {
+			p.rows[k] = row
+		} else if s.orphans != orphansIgnore {
+			// Two owned rows for one key: the later one is stale either way.
+			orphans = append(orphans, k+" (duplicate)")
+			staleKeys[row] = k + " (duplicate)"
+		}
+	}
+
+	ctx := tx.Statement.Context
+	var changes []rowChange
+	seen := make(map[string]bool, len(desired))
+	for i := range desired {
+		want := &desired[i]
+		k := s.key(want)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		have, ok := p.rows[k]
+		if !ok {
+			p.inserts = append(p.inserts, want)
+			p.rows[k] = want
+			diff.Inserts = append(diff.Inserts, k)
+			continue
+		}
+		change := rowChange{Key: k, Changes: map[string][2]any{}}
+		values := map[string]any{}
+		for _, f := range fields {
+			before, _ := f.ValueOf(ctx, reflect.ValueOf(have).Elem())
+			after, _ := f.ValueOf(ctx, reflect.ValueOf(want).Elem())
+			if !sameValue(f, before, after) {
+				change.Changes[f.DBName] = [2]any{deref(before), deref(after)}
+				values[f.DBName] = after
+			}
+		}
+		if len(values) > 0 {
+			p.updates = append(p.updates, rowUpdate[T]{row: have, values: values})
+			changes = append(changes, change)
+		}
+	}
+
+	// Updates to rows an admin or a patch changed last are reported, not applied.
+	overridden := map[uint]string{}
+	for _, ids := range p.chunkIDs(ctx, p.updateRows()) {
+		found, err := p.overridden(tx, ids)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(overridden, found)
+	}
+	updates := p.updates[:0]
+	for i, u := range p.updates {
+		if actor, ok := overridden[p.rowID(ctx, u.row)]; ok {
+			diff.Conflicts = append(diff.Conflicts, rowConflict{Key: changes[i].Key, Actor: actor, Changes: changes[i].Changes})
+			continue
+		}
+		updates = append(updates, u)
+		diff.Updates = append(diff.Updates, changes[i])
+	}
+	p.updates = updates
+
+	if s.orphans == orphansIgnore {
+		return p, nil
+	}
+	for k, row := range p.rows {
+		if !seen[k] {
+			orphans = append(orphans, k)
+			staleKeys[row] = k
+		}
+	}
+	slices.Sort(orphans)
+	if s.orphans != orphansDelete && !pd.prune {
+		diff.Orphans = append(diff.Orphans, orphans...)
+		return p, nil
+	}
+	for row, k := range staleKeys {
+		p.stale = append(p.stale, row)
+		delete(p.rows, k)
+	}
+	slices.SortFunc(p.stale, func(a, b *T) int {
+		return cmp.Or(strings.Compare(staleKeys[a], staleKeys[b]), cmp.Compare(p.rowID(ctx, a), p.rowID(ctx, b)))
+	})
+	p.staleKeys = staleKeys
+	diff.Deletes = append(diff.Deletes, orphans...)
+	err := p.keepStale(tx, p.overridden, func(key, actor string) {
+		diff.Conflicts = append(diff.Conflicts, rowConflict{Key: key, Actor: actor})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.referenced != nil {
+		if err := p.keepReferenced(tx, s.referenced); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// overridden returns the rows among ids whose latest change-log entry is an admin
+// correction or a data patch, with its actor; none for a table that isn't logged.
+func (p *rowPlan[T]) overridden(tx *gorm.DB, ids []any) (map[uint]string, error) {
+	if p.changes == nil {
+		return nil, nil
+	}
+	return p.changes.Overridden(tx, p.entity, ids)
+}
+
+// keepReferenced takes the stale rows check finds references to off the delete
+// list and reports them as orphans, with the reason.
+func (p *rowPlan[T]) keepReferenced(tx *gorm.DB, check func(*gorm.DB, []any) (map[uint]string, error)) error {
+	return p.keepStale(tx, check, func(key, why string) {
+		p.diff.Orphans = append(p.diff.Orphans, key+" (has "+why+")")
+	})
+}
+
+// keepStale takes the stale rows check returns off the delete list, handing each
+// one's key and what check said about it to report.
+func (p *rowPlan[T]) keepStale(tx *gorm.DB, check func(*gorm.DB, []any) (map[uint]string, error), report func(key, note string)) error {
+	ctx := tx.Statement.Context
+	found := map[uint]string{}
+	for _, ids := range p.chunkIDs(ctx, p.stale) {
+		got, err := check(tx, ids)
+		if err != nil {
+			return fmt.Errorf("checking %s orphans: %w", p.sync.table, err)
+		}
+		maps.Copy(found, got)
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	stale := p.stale[:0]
+	for _, row := range p.stale {
+		note, ok := found[p.rowID(ctx, row)]
+		if !ok {
+			stale = append(stale, row)
+			continue
+		}
+		key := p.staleKeys[row]
+		if i := slices.Index(p.diff.Deletes, key); i >= 0 {
+			p.diff.Deletes = slices.Delete(p.diff.Deletes, i, i+1)
+		}
+		report(key, note)
+	}
+	p.stale = stale
+	return nil
+}
+
+// apply writes the inserts and updates, recording them in the change log.
+func (p *rowPlan[T]) apply(tx *gorm.DB) error {
+	ctx := tx.Statement.Context
+	if len(p.inserts) > 0 {
+		// gorm writes a column's default in place of a zero value, so note which new
+		// rows want the zero and put it back after the insert.
+		zeroed := map[*schema.Field][]*T{}
+		for _, col := range p.sync.columns {
+			f := p.schema.LookUpField(col)
+			if f.DefaultValueInterface == nil || reflect.ValueOf(f.DefaultValueInterface).IsZero() {
+				continue
+			}
+			for _, row := range p.inserts {
+				if _, zero := f.ValueOf(ctx, reflect.ValueOf(row).Elem()); zero {
+					zeroed[f] = append(zeroed[f], row)
+				}
+			}
+		}
+		if err := tx.Omit(clause.Associations).CreateInBatches(p.inserts, 500).Error; err != nil {
+			return fmt.Errorf("inserting %s: %w", p.sync.table, err)
+		}
+		for f, rows := range zeroed {
+			zero := reflect.Zero(f.FieldType).Interface()
+			for _, ids := range p.chunkIDs(ctx, rows) {
+				err := tx.Model(new(T)).Where(clause.IN{Column: p.schema.PrioritizedPrimaryField.DBName, Values: ids}).
+					Update(f.DBName, zero).Error
+				if err != nil {
+					return fmt.Errorf("inserting %s: %w", p.sync.table, err)
+				}
+			}
+			for _, row := range rows {
+				_ = f.Set(ctx, reflect.ValueOf(row).Elem(), zero)
+			}
+		}
+		if p.changes != nil {
+			for _, ids := range p.chunkIDs(ctx, p.inserts) {
+				if err := p.changes.Created(tx, p.entity, ids); err != nil {
+					return fmt.Errorf("inserting %s: %w", p.sync.table, err)
+				}
+			}
+		}
+	}
+	rows := p.updateRows()
+	for i, ids := range p.chunkIDs(ctx, rows) {
+		chunk := p.updates[i*1000 : i*1000+len(ids)]
+		err := p.track(tx, ids, func() error {
+			for _, u := range chunk {
+				if err := tx.Model(u.row).Omit(clause.Associations).Updates(u.values).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("updating %s: %w", p.sync.table, err)
+		}
+	}
+	return nil
+}
+
+// prune deletes the orphans planRows decided to drop.
+func (p *rowPlan[T]) prune(tx *gorm.DB) error {
+	for _, ids := range p.chunkIDs(tx.Statement.Context, p.stale) {
+		err := p.track(tx, ids, func() error {
+			return tx.Where(clause.IN{Column: p.schema.PrioritizedPrimaryField.DBName, Values: ids}).Delete(new(T)).Error
+		})
+		if err != nil {
+			return fmt.Errorf("deleting %s: %w", p.sync.table, err)
+		}
+	}
+	return nil
+}
+
+// track runs write, recording what it did to the rows with the given IDs when the
+// table is change-logged.
+func (p *rowPlan[T]) track(tx *gorm.DB, ids []any, write func() error) error {
+	if p.changes == nil {
+		return write()
+	}
+	return p.changes.Track(tx, p.entity, ids, write)
+}
+
+func (p *rowPlan[T]) updateRows() []*T {
+	rows := make([]*T, len(p.updates))
+	for i, u := range p.updates {
+		rows[i] = u.row
+	}
+	return rows
+}
+
+// ownedIDs returns the primary keys of every row in scope, orphans included.
+func (p *rowPlan[T]) ownedIDs(tx *gorm.DB) []any {
+	rows := make([]*T, 0, len(p.rows)+len(p.stale))
+	for _, row := range p.rows {
+		rows = append(rows, row)
+	}
+	rows = append(rows, p.stale...)
+	var ids []any
+	for _, chunk := range p.chunkIDs(tx.Statement.Context, rows) {
+		ids = append(ids, chunk...)
+	}
+	return ids
+}
+
+// chunkIDs returns the rows' primary keys in order, in chunks of 1000.
+func (p *rowPlan[T]) chunkIDs(ctx context.Context, rows []*T) [][]any {
+	var chunks [][]any
+	for start := 0; start < len(rows); start += 1000 {
+		end := min(start+1000, len(rows))
+		ids := make([]any, 0, end-start)
+		for _, row := range rows[start:end] {
+			id, _ := p.schema.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(row).Elem())
+			ids = append(ids, id)
+		}
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
+
+// rowID is a row's primary key; every change-logged table has a uint one.
+func (p *rowPlan[T]) rowID(ctx context.Context, row *T) uint {
+	id, _ := p.schema.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(row).Elem())
+	n, _ := id.(uint)
+	return n
+}
+
+// ensureRow finds the row matching query, creating dest when there is none and
+// recording the insert. It stands in for FirstOrCreate where rows are created on
+// demand (players first seen in a stats file, fallback tournaments, ...).
+func ensureRow[T any](db *gorm.DB, pd *phaseDiff, table, key string, dest *T, query string, args ...any) error {
+	res := db.Where(query, args...).Limit(1).Find(dest)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+	if err := db.Omit(clause.Associations).Create(dest).Error; err != nil {
+		return fmt.Errorf("inserting %s %s: %w", table, key, err)
+	}
+	if entity, ok := store.SeedEntity(table); ok {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(dest); err != nil {
+			return err
+		}
+		id, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, reflect.ValueOf(dest).Elem())
+		if err := pd.changeLog().Created(db, entity, []any{id}); err != nil {
+			return fmt.Errorf("inserting %s %s: %w", table, key, err)
+		}
+	}
+	pd.table(table).Inserts = append(pd.table(table).Inserts, key)
+	return nil
+}
+
+var decimalType = regexp.MustCompile(`(?i)^(?:decimal|numeric)\(\s*\d+\s*,\s*(\d+)\s*\)$`)
+
+// sameValue compares a stored value with a CSV one the way the column stores
+// it: decimals at their scale, timestamps at Postgres's microsecond precision.
+func sameValue(f *schema.Field, stored, csv any) bool {
+	a, b := deref(stored), deref(csv)
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	switch av := a.(type) {
+	case time.Time:
+		bv, ok := b.(time.Time)
+		return ok && av.Truncate(time.Microsecond).Equal(bv.Truncate(time.Microsecond))
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return false
+		}
+		if m := decimalType.FindStringSubmatch(string(f.DataType)); m != nil {
+			scale, _ := strconv.Atoi(m[1])
+			pow := math.Pow10(scale)
+			return math.Round(av*pow) == math.Round(bv*pow)
+		}
+		return math.Abs(av-bv) < 1e-9
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// deref unwraps pointer values so nil and set pointers compare and print sensibly.
+func deref(v any) any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer {
+		return v
+	}
+	if rv.IsNil() {
+		return nil
+	}
+	return rv.Elem().Interface()
+}
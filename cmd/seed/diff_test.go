@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestSameValue_DecimalComparedAtScale(t *testing.T) {
+	f := &schema.Field{DataType: "decimal(6,3)"}
+	assert.True(t, sameValue(f, 1.2345, 1.2349), "both round to 1.235")
+	assert.False(t, sameValue(f, 1.234, 1.235))
+	rating := 0.9
+	assert.True(t, sameValue(f, &rating, 0.9), "pointers compare by value")
+	assert.False(t, sameValue(f, (*float64)(nil), 0.9))
+	assert.True(t, sameValue(f, (*float64)(nil), (*float64)(nil)))
+}
+
+func TestSameValue_TimeComparedAtMicroseconds(t *testing.T) {
+	f := &schema.Field{DataType: schema.Time}
+	stored := time.Date(2024, 1, 12, 18, 0, 0, 123456000, time.UTC)
+	csv := stored.Add(789 * time.Nanosecond).In(time.FixedZone("EST", -5*3600))
+	assert.True(t, sameValue(f, stored, csv))
+	assert.False(t, sameValue(f, stored, csv.Add(time.Microsecond)))
+}
+
+func TestSeedReportPrint(t *testing.T) {
+	pd := &phaseDiff{Phase: "phase 2: era_finals"}
+	matches := pd.table("matches")
+	matches.Inserts = append(matches.Inserts, "bp:101")
+	matches.Updates = append(matches.Updates, rowChange{Key: "bp:102", Changes: map[string][2]any{
+		"team2_score": {1, 3},
+		"format":      {nil, "bo5"},
+	}})
+	matches.Orphans = append(matches.Orphans, "bp:99")
+	failed := &phaseDiff{Phase: "phase 3: enriched", Error: "boom"}
+	failed.table("matches").Inserts = []string{"enriched:x"}
+
+	var out bytes.Buffer
+	r := &seedReport{DryRun: true, Phases: []*phaseDiff{pd, failed}}
+	r.print(&out)
+
+	assert.Contains(t, out.String(), `~ bp:102: format null -> "bo5", team2_score 1 -> 3`)
+	assert.Contains(t, out.String(), "? bp:99")
+	assert.Contains(t, out.String(), "phase 3: enriched — FAILED, rolled back: boom")
+	assert.Contains(t, out.String(),
+		"==> Seed diff: 1 inserted, 1 updated, 0 deleted, 1 orphans kept (dry run, nothing changed); -prune deletes orphans",
+		"a failed phase's changes are rolled back and not counted")
+}
+
+// matchFixture creates the season, teams and tournament the match tests seed into.
+func matchFixture(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	mkSeason(t, db, 1, "BO6")
+	mkTeam(t, db, 1, "OpTic Texas", "OTX", nil)
+	mkTeam(t, db, 2, "Atlanta FaZe", "ATL", nil)
+	mkTournament(t, db, 1, 1)
+}
+
+// bpMatch is a match with a BreakingPoint ID, as phase 2 builds them.
+func bpMatch(bp, team1Score int) models.Match {
+	return models.Match{
+		TournamentID: 1, Team1ID: 1, Team2ID: 2, Team1Score: team1Score, BreakingPointMatchID: &bp,
+		MatchDate: time.Date(2025, 1, 10, 18, 0, 0, 0, time.UTC),
+	}
+}
+
+func mkBPMatch(t *testing.T, db *gorm.DB, bp, team1Score int) uint {
+	t.Helper()
+	m := bpMatch(bp, team1Score)
+	require.NoError(t, db.Create(&m).Error)
+	return m.ID
+}
+
+var bpMatchSync = tableSync[models.Match]{
+	table:   "matches",
+	key:     matchKey,
+	columns: []string{"team1_score"},
+	scope:   func(q *gorm.DB) *gorm.DB { return q.Where("breaking_point_match_id IS NOT NULL") },
+}
+
+func seedChanges(t *testing.T, db *gorm.DB, entity string, id uint) []models.ChangeLog {
+	t.Helper()
+	var changes []models.ChangeLog
+	require.NoError(t, db.Where("entity = ? AND entity_key = ?", entity, fmt.Sprint(id)).Order("id").Find(&changes).Error)
+	return changes
+}
+
+func TestPlanRows_ReportsOrphansAndDuplicates(t *testing.T) {
+	db := rosterTx(t)
+	matchFixture(t, db)
+	first := mkBPMatch(t, db, 1, 0)
+	mkBPMatch(t, db, 1, 0) // a second row for bp:1
+	mkBPMatch(t, db, 2, 0)
+
+	pd := &phaseDiff{}
+	p, err := planRows(db, pd, bpMatchSync, []models.Match{bpMatch(1, 3), bpMatch(4, 0), bpMatch(1, 2)})
+	require.NoError(t, err)
+
+	diff := pd.table("matches")
+	assert.Equal(t, []string{"bp:4"}, diff.Inserts)
+	assert.Equal(t, []rowChange{{Key: "bp:1", Changes: map[string][2]any{"team1_score": {0, 3}}}}, diff.Updates,
+		"the first row for a key wins, on both sides")
+	assert.Equal(t, []string{"bp:1 (duplicate)", "bp:2"}, diff.Orphans)
+	assert.Empty(t, diff.Deletes, "orphans are only reported without -prune")
+	assert.Empty(t, p.stale)
+	assert.Equal(t, first, p.rows["bp:1"].ID)
+
+	var scores []int
+	require.NoError(t, db.Model(&models.Match{}).Order("id").Pluck("team1_score", &scores).Error)
+	assert.Equal(t, []int{0, 0, 0}, scores, "planning writes nothing")
+}
+
+func TestSyncMatches_PruneKeepsReferencedMatches(t *testing.T) {
+	db := rosterTx(t)
+	matchFixture(t, db)
+	mkPlayer(t, db, 1, "Shotzzy")
+	mkBPMatch(t, db, 1, 3)
+	orphan := mkBPMatch(t, db, 2, 3)
+	mkMap(t, db, orphan, 1, true)
+	mkMapStat(t, db, orphan, 1, 1, 1)
+	withThread := mkBPMatch(t, db, 3, 3)
+	require.NoError(t, db.Create(&models.MatchThread{MatchID: withThread}).Error)
+
+	pd := &phaseDiff{prune: true}
+	_, err := syncMatches(db, pd, []models.Match{bpMatch(1, 3)}, newMatchChildren(),
+		bpMatchSync.columns, bpMatchSync.scope)
+	require.NoError(t, err)
+
+	var left []uint
+	require.NoError(t, db.Model(&models.Match{}).Order("id").Pluck("id", &left).Error)
+	assert.NotContains(t, left, orphan, "an orphan nothing points at is pruned")
+	assert.Contains(t, left, withThread, "a match with a thread is never deleted")
+	var lines int64
+	require.NoError(t, db.Model(&models.PlayerMapStats{}).Where("match_id = ?", orphan).Count(&lines).Error)
+	assert.Zero(t, lines)
+
+	matches := pd.table("matches")
+	assert.Equal(t, []string{"bp:2"}, matches.Deletes)
+	assert.Equal(t, []string{"bp:3 (has a thread)"}, matches.Orphans)
+	assert.Equal(t, []string{"bp:2/map 1"}, pd.table("match_maps").Deletes)
+
+	changes := seedChanges(t, db, models.ChangeMatch, orphan)
+	require.Len(t, changes, 1)
+	assert.Equal(t, models.ChangeDelete, changes[0].Action)
+	assert.Equal(t, store.SeedActor, changes[0].Actor)
+	assert.Equal(t, 1, pd.changes.TouchedMatches())
+	require.NoError(t, pd.changes.Rebuild(db), "rebuilding a deleted match's tournament")
+}
+
+func TestSyncMatches_KeepsAdminCorrections(t *testing.T) {
+	db := rosterTx(t)
+	matchFixture(t, db)
+	corrected := mkBPMatch(t, db, 1, 1)
+	dropped := mkBPMatch(t, db, 2, 3)
+	for _, id := range []uint{corrected, dropped} {
+		require.NoError(t, db.Create(&models.ChangeLog{
+			Entity: models.ChangeMatch, EntityKey: fmt.Sprint(id), Action: models.ChangeUpdate, Actor: "alice",
+		}).Error)
+	}
+
+	pd := &phaseDiff{prune: true}
+	_, err := syncMatches(db, pd, []models.Match{bpMatch(1, 3), bpMatch(3, 3)}, newMatchChildren(),
+		bpMatchSync.columns, bpMatchSync.scope)
+	require.NoError(t, err)
+
+	var m models.Match
+	require.NoError(t, db.First(&m, corrected).Error)
+	assert.Equal(t, 1, m.Team1Score, "an admin correction is not overwritten")
+	require.NoError(t, db.First(&m, dropped).Error, "nor is the corrected row deleted")
+
+	matches := pd.table("matches")
+	assert.Empty(t, matches.Updates)
+	assert.Empty(t, matches.Deletes)
+	assert.Equal(t, []rowConflict{
+		{Key: "bp:1", Actor: "alice", Changes: map[string][2]any{"team1_score": {1, 3}}},
+		{Key: "bp:2", Actor: "alice"},
+	}, matches.Conflicts)
+	assert.Equal(t, []string{"bp:3"}, matches.Inserts)
+
+	var inserted models.Match
+	require.NoError(t, db.Where("breaking_point_match_id = ?", 3).First(&inserted).Error)
+	changes := seedChanges(t, db, models.ChangeMatch, inserted.ID)
+	require.Len(t, changes, 1)
+	assert.Equal(t, models.ChangeCreate, changes[0].Action)
+	assert.Equal(t, store.SeedActor, changes[0].Actor)
+	require.NoError(t, pd.changes.Rebuild(db))
+
+	// Once the seeder has written the row again, it owns it again.
+	require.NoError(t, db.Create(&models.ChangeLog{
+		Entity: models.ChangeMatch, EntityKey: fmt.Sprint(corrected), Action: models.ChangeUpdate, Actor: store.SeedActor,
+	}).Error)
+	pd = &phaseDiff{}
+	_, err = syncMatches(db, pd, []models.Match{bpMatch(1, 3), bpMatch(3, 3)}, newMatchChildren(),
+		bpMatchSync.columns, bpMatchSync.scope)
+	require.NoError(t, err)
+	require.NoError(t, db.First(&m, corrected).Error)
+	assert.Equal(t, 3, m.Team1Score)
+	assert.Len(t, seedChanges(t, db, models.ChangeMatch, corrected), 3, "the admin's, the seeder's and this update")
+}
+
+func TestApply_RestoresZeroValuesOverDefaults(t *testing.T) {
+	db := rosterTx(t)
+	pd := &phaseDiff{}
+	rows, err := syncRows(db, pd, tableSync[models.Team]{
+		table:   "teams",
+		key:     func(tm *models.Team) string { return tm.Name },
+		columns: []string{"name", "is_active", "source"},
+		scope:   func(q *gorm.DB) *gorm.DB { return q.Where("source = ?", "diff_test") },
+	}, []models.Team{
+		{Name: "Disbanded", IsActive: false, Source: "diff_test"},
+		{Name: "Active", IsActive: true, Source: "diff_test"},
+	})
+	require.NoError(t, err)
+
+	var disbanded models.Team
+	require.NoError(t, db.First(&disbanded, rows["Disbanded"].ID).Error)
+	assert.False(t, disbanded.IsActive, "is_active defaults to true; false must survive the insert")
+	assert.False(t, rows["Disbanded"].IsActive)
+
+	changes := seedChanges(t, db, models.ChangeTeam, disbanded.ID)
+	require.Len(t, changes, 1)
+	assert.JSONEq(t, "false", jsonField(t, changes[0].After, "is_active"), "the change log records the restored value")
+}
+
+// jsonField returns one field of a change-log snapshot as raw JSON.
+func jsonField(t *testing.T, row []byte, field string) string {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(row, &fields))
+	return string(fields[field])
+}
+
+func TestSeedRun_DryRunRollsBack(t *testing.T) {
+	if rosterTestDB == nil {
+		t.Skip("postgres test container unavailable (is Docker running?)")
+	}
+	run := newSeedRun(rosterTestDB, true, false)
+	run.phase("phase 1", func(tx *gorm.DB, pd *phaseDiff) error {
+		mkSeason(t, tx, 901, "DRY")
+		return nil
+	})
+	run.phase("phase 2", func(tx *gorm.DB, pd *phaseDiff) error {
+		var n int64
+		require.NoError(t, tx.Model(&models.Season{}).Where("id = ?", 901).Count(&n).Error)
+		assert.EqualValues(t, 1, n, "a later phase sees what an earlier one wrote")
+		mkSeason(t, tx, 902, "FAIL")
+		return errors.New("boom")
+	})
+	run.phase("phase 3", func(tx *gorm.DB, pd *phaseDiff) error {
+		t.Error("phases after a failed one are skipped")
+		return nil
+	})
+	assert.False(t, run.finish(""))
+	assert.Equal(t, "boom", run.report.Phases[1].Error)
+	assert.Len(t, run.report.Phases, 2)
+
+	var n int64
+	require.NoError(t, rosterTestDB.Model(&models.Season{}).Where("id IN ?", []uint{901, 902}).Count(&n).Error)
+	assert.Zero(t, n, "a dry run leaves nothing behind")
+}
+
+// A phase's rebuild adds per-tournament player_tournament_stats rows for the
+// matches it seeds; the season totals read from the summary rows stay as the CSVs
+// have them, on the first run and on a reseed.
+func TestSeedRun_ReseedKeepsSeasonTotals(t *testing.T) {
+	db := rosterTx(t)
+	matchFixture(t, db)
+	mkPlayer(t, db, 1, "Shotzzy")
+	cfg := seasonStatCfg{GameCode: "BO6", Name: "Black Ops 6 2024-25", StartYear: 2024}
+
+	seed := func() []store.KDRow {
+		run := newSeedRun(db, false, false)
+		run.phase("matches", func(tx *gorm.DB, pd *phaseDiff) error {
+			children := newMatchChildren()
+			children.maps["bp:1"] = []models.MatchMap{{MapNumber: 1, Mode: "Hardpoint", Played: true}}
+			children.mapStats["bp:1"] = []models.PlayerMapStats{{MapNumber: 1, PlayerID: 1, TeamID: 1, Kills: 30, Deaths: 20}}
+			_, err := syncMatches(tx, pd, []models.Match{bpMatch(1, 3)}, children, bpMatchSync.columns, bpMatchSync.scope)
+			return err
+		})
+		run.phase("season stats", func(tx *gorm.DB, pd *phaseDiff) error {
+			summary, err := ensureSummaryTournament(tx, pd, cfg, 1)
+			if err != nil {
+				return err
+			}
+			return syncSeasonStats(tx, pd, cfg, summary.ID, []string{"team_id", "total_kills", "total_deaths", "overall_maps"},
+				[]models.PlayerTournamentStats{{PlayerID: 1, TeamID: 1, TotalKills: 300, TotalDeaths: 250, OverallMaps: 20}})
+		})
+		require.False(t, run.failed, "%+v", run.report.Phases)
+		rows, err := store.NewGormStatsStore(db).GetAllKDRows(context.Background(), 10, "1")
+		require.NoError(t, err)
+		return rows
+	}
+
+	first := seed()
+	require.Len(t, first, 1)
+	assert.Equal(t, 300, first[0].SeasonKills)
+	assert.Equal(t, 250, first[0].SeasonDeaths)
+
+	var tournamentRows int64
+	require.NoError(t, db.Model(&models.PlayerTournamentStats{}).Where("tournament_id = ?", 1).Count(&tournamentRows).Error)
+	assert.EqualValues(t, 1, tournamentRows, "the rebuild wrote the tournament's own row")
+
+	assert.Equal(t, first, seed(), "a reseed leaves the season totals alone")
+}
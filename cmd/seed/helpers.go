@@ -207,7 +207,7 @@ func rawRoundToDBRound(raw string) string {
 	}
 }
 
-func resolvePlayer(tag string, lookup map[string]uint, db *gorm.DB) uint {
+func resolvePlayer(tag string, lookup map[string]uint, db *gorm.DB, pd *phaseDiff) uint {
 	tag = strings.TrimSpace(tag)
 	if tag == "" {
 		return 0
@@ -223,12 +223,15 @@ func resolvePlayer(tag string, lookup map[string]uint, db *gorm.DB) uint {
 		}
 	}
 	p := models.Player{Gamertag: tag}
-	db.Where("gamertag = ?", tag).FirstOrCreate(&p)
+	if err := ensureRow(db, pd, "players", tag, &p, "gamertag = ?", tag); err != nil {
+		pd.fail(err)
+		return 0
+	}
 	lookup[tag] = p.ID
 	return p.ID
 }
 
-func ensureUnknownTeam(db *gorm.DB, name string, teamLookup map[string]uint) uint {
+func ensureUnknownTeam(db *gorm.DB, pd *phaseDiff, name string, teamLookup map[string]uint) uint {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return 0
@@ -245,7 +248,10 @@ func ensureUnknownTeam(db *gorm.DB, name string, teamLookup map[string]uint) uin
 		NeedsManualReview:  true,
 		Source:             "enriched_csv",
 	}
-	db.Where("name = ? AND source = ?", name, "enriched_csv").FirstOrCreate(&t)
+	if err := ensureRow(db, pd, "teams", name, &t, "name = ? AND source = ?", name, "enriched_csv"); err != nil {
+		pd.fail(err)
+		return 0
+	}
 	teamLookup[name] = t.ID
 	return t.ID
 }
@@ -273,7 +279,7 @@ func findTournamentForMatch(ranges []eventRange, bySlug map[string]uint, gameCod
 
 var fallbackTournamentIDs = map[uint]uint{}
 
-func ensureFallbackTournament(db *gorm.DB, seasonID uint, gameCode string) uint {
+func ensureFallbackTournament(db *gorm.DB, pd *phaseDiff, seasonID uint, gameCode string) uint {
 	if id, ok := fallbackTournamentIDs[seasonID]; ok {
 		return id
 	}
@@ -284,14 +290,17 @@ func ensureFallbackTournament(db *gorm.DB, seasonID uint, gameCode string) uint
 		Slug:           slug,
 		TournamentType: "unknown",
 	}
-	db.Where("slug = ? AND season_id = ?", slug, seasonID).FirstOrCreate(&t)
+	if err := ensureRow(db, pd, "tournaments", slug+" ("+gameCode+")", &t, "slug = ? AND season_id = ?", slug, seasonID); err != nil {
+		pd.fail(err)
+		return 0
+	}
 	fallbackTournamentIDs[seasonID] = t.ID
 	return t.ID
 }
 
 var unaffiliatedTeamID uint
 
-func ensureUnaffiliatedTeam(db *gorm.DB, teamLookup map[string]uint) uint {
+func ensureUnaffiliatedTeam(db *gorm.DB, pd *phaseDiff, teamLookup map[string]uint) uint {
 	if unaffiliatedTeamID != 0 {
 		return unaffiliatedTeamID
 	}
@@ -300,7 +309,10 @@ func ensureUnaffiliatedTeam(db *gorm.DB, teamLookup map[string]uint) uint {
 		return id
 	}
 	t := models.Team{Name: "Unaffiliated", Abbreviation: "UNK", Source: "system"}
-	db.Where("name = ?", "Unaffiliated").FirstOrCreate(&t)
+	if err := ensureRow(db, pd, "teams", t.Name, &t, "name = ?", "Unaffiliated"); err != nil {
+		pd.fail(err)
+		return 0
+	}
 	unaffiliatedTeamID = t.ID
 	teamLookup["Unaffiliated"] = t.ID
 	return t.ID
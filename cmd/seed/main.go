@@ -3,11 +3,27 @@ package main
 // main.go — entry point for the CDL database seeder.
 // This file only orchestrates the phases. All logic lives in the phase files.
 //
+// The seeder is incremental: each phase compares what its CSVs imply with the rows
+// it owns, keyed on natural keys (BreakingPoint match ID, team name + game, gamertag,
+// ...), and applies the inserts and updates in its own transaction. Rows it owns that
+// no CSV produces any more are reported as orphans, and deleted with -prune. Every
+// write to matches, maps, stat lines and teams is recorded in change_log under the
+// "seed" actor; rows an admin or a data patch changed since are reported as
+// conflicts and left alone. Each phase rebuilds the derived stats of the matches it
+// touched, and team ratings are recomputed at the end if any changed. The diff is
+// printed at the end, and -report writes it as JSON; -dry-run computes it and rolls
+// everything back.
+//
+//	go run ./cmd/seed -dry-run -report seed-diff.json
+//	go run ./cmd/seed
+//
 // Seeder file structure:
 //   config.go          — static config vars (which CSVs belong to which era)
 //   types.go           — all CSV row structs and internal helper types
 //   helpers.go         — shared utilities: date parsers, atoi/atof, DB resolution helpers
 //   csv_readers.go     — one reader function per CSV file type
+//   diff.go            — natural-key diff of CSV rows against the database (syncRows)
+//   report.go          — per-phase transactions, -dry-run and the diff report
 //   phase1_foundation.go — Franchises → CDL Teams → Non-CDL Teams → Players → Seasons → Tournaments
 //   phase2_era_finals.go — Match + MatchMap + PlayerMapStats + PlayerMatchStats from era_finals/
 //   phase3_enriched.go   — Same tables, EWC 2024/2025 + Major 1 2023 wiki data
@@ -15,22 +31,33 @@ package main
 //   phase5_transfers.go  — PlayerTransfer + unresolved_transfer_teams.csv report
 //   phase6_bracket_patches.go — bracket_round + bracket_position backfill
 //   phase7_rosters.go    — season-aware TeamRoster stints inferred from player_map_stats
+//   phase8_points_rules.go — default per-era CDL points schema (season_points_rules)
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
 
 	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
 )
 
 func main() {
 	reset := flag.Bool("reset", false, "truncate all seeder-owned tables before seeding (clean re-seed from empty)")
 	autoYes := flag.Bool("yes", false, "skip the interactive confirmation for -reset (for non-interactive runs)")
+	dryRun := flag.Bool("dry-run", false, "compute and print the diff, then roll everything back")
+	prune := flag.Bool("prune", false, "delete orphaned rows (seeder-owned rows no CSV produces) instead of only reporting them")
+	reportPath := flag.String("report", "", "also write the diff as JSON to this file")
 	flag.Parse()
+	if *reset && *dryRun {
+		log.Fatal("-reset can't be combined with -dry-run")
+	}
 
 	database.ConnectDatabase()
-	database.AutoMigrate()
+	database.RequireSchema()
 	db := database.DB
 
 	if *reset {
@@ -42,47 +69,107 @@ func main() {
 		}
 	}
 
-	log.Println("==> Cleanup: removing bad player records")
-	cleanupBadPlayers(db)
+	run := newSeedRun(db, *dryRun, *prune)
+	var (
+		teamLookup, playerLookup       map[string]uint
+		seasonByCode, tournamentBySlug map[string]uint
+		eventRanges                    []eventRange
+	)
 
 	log.Println("==> Phase 1: Foundation (franchises, teams, players, seasons, tournaments)")
-	franchiseMap := seedFranchises(db)
-	teamLookup := seedCDLTeams(db, franchiseMap)
-	mergeInto(teamLookup, seedNonCDLTeams(db))
-	playerLookup := seedPlayers(db)
-	seasonByCode := seedSeasons(db)
-	tournamentBySlug, eventRanges := seedTournaments(db, seasonByCode)
+	run.phase("phase 1: foundation", func(tx *gorm.DB, pd *phaseDiff) (err error) {
+		if err = cleanupBadPlayers(tx, pd); err != nil {
+			return err
+		}
+		franchiseMap, err := seedFranchises(tx, pd)
+		if err != nil {
+			return err
+		}
+		if teamLookup, err = seedCDLTeams(tx, pd, franchiseMap); err != nil {
+			return err
+		}
+		nonCDL, err := seedNonCDLTeams(tx, pd)
+		if err != nil {
+			return err
+		}
+		mergeInto(teamLookup, nonCDL)
+		if playerLookup, err = seedPlayers(tx, pd); err != nil {
+			return err
+		}
+		if seasonByCode, err = seedSeasons(tx, pd); err != nil {
+			return err
+		}
+		tournamentBySlug, eventRanges, err = seedTournaments(tx, pd, seasonByCode)
+		return err
+	})
 
 	log.Println("==> Phase 2: era_finals match data (series, maps, player stats)")
-	matchByBPID := seedEraFinals(db, teamLookup, playerLookup, seasonByCode, tournamentBySlug, eventRanges)
-	_ = matchByBPID
+	run.phase("phase 2: era_finals", func(tx *gorm.DB, pd *phaseDiff) error {
+		_, err := seedEraFinals(tx, pd, teamLookup, playerLookup, seasonByCode, tournamentBySlug, eventRanges)
+		return err
+	})
 
 	log.Println("==> Phase 3: Enriched match data (EWC 2024/2025, Major 1 2023 wiki)")
-	seedEnrichedMatches(db, teamLookup, playerLookup, tournamentBySlug)
+	run.phase("phase 3: enriched", func(tx *gorm.DB, pd *phaseDiff) error {
+		return seedEnrichedMatches(tx, pd, teamLookup, playerLookup, tournamentBySlug)
+	})
 
 	log.Println("==> Phase 4: Season aggregate player stats")
-	for _, cfg := range seasonStatConfigs {
-		seasonID := seasonByCode[cfg.GameCode]
-		if seasonID == 0 {
-			continue
-		}
-		// Eras with a pre-aggregated CSV load from it; those without one (e.g. BO6)
-		// derive their season totals from the per-map stats already seeded.
-		if cfg.PlayerFile == "" {
-			seedDerivedSeasonStats(db, cfg, seasonID)
-			continue
+	run.phase("phase 4: season stats", func(tx *gorm.DB, pd *phaseDiff) error {
+		for _, cfg := range seasonStatConfigs {
+			seasonID := seasonByCode[cfg.GameCode]
+			if seasonID == 0 {
+				continue
+			}
+			// Eras with a pre-aggregated CSV load from it; those without one (e.g. BO6)
+			// derive their season totals from the per-map stats already seeded.
+			var err error
+			if cfg.PlayerFile == "" {
+				err = seedDerivedSeasonStats(tx, pd, cfg, seasonID)
+			} else {
+				err = seedSeasonStats(tx, pd, cfg, seasonID, teamLookup, playerLookup)
+			}
+			if err != nil {
+				return err
+			}
 		}
-		seedSeasonStats(db, cfg, seasonID, teamLookup, playerLookup)
-	}
+		return nil
+	})
 
 	log.Println("==> Phase 5: Transfers (all 5 eras)")
-	seedTransfers(db, teamLookup, playerLookup)
+	run.phase("phase 5: transfers", func(tx *gorm.DB, pd *phaseDiff) error {
+		report, err := seedTransfers(tx, pd, teamLookup, playerLookup)
+		if err == nil && !*dryRun {
+			writeTransferReport(report)
+		}
+		return err
+	})
 
 	log.Println("==> Phase 6: Bracket patches (bracket_round + bracket_position, all eras)")
-	seedBracketPatches(db, teamLookup, tournamentBySlug)
+	run.phase("phase 6: bracket patches", func(tx *gorm.DB, pd *phaseDiff) error {
+		return seedBracketPatches(tx, pd, teamLookup, tournamentBySlug)
+	})
 
 	log.Println("==> Phase 7: Roster inference (season-aware stints from player_map_stats)")
-	seedRosters(db)
+	run.phase("phase 7: rosters", seedRosters)
 
+	log.Println("==> Phase 8: Default points rules (per era)")
+	run.phase("phase 8: points rules", func(tx *gorm.DB, pd *phaseDiff) error {
+		return seedPointsRules(tx, pd, seasonByCode)
+	})
+
+	if run.rerate {
+		log.Println("==> Team ratings (match results changed)")
+		run.phase("team ratings", func(tx *gorm.DB, pd *phaseDiff) error {
+			svc := services.NewRatingService(store.NewGormRatingStore(tx), store.NewGormSeasonStore(tx), services.DefaultRatingConfig())
+			n, err := svc.Recompute(context.Background())
+			log.Printf("[team ratings] %d series rated", n)
+			return err
+		})
+	}
+
+	if !run.finish(*reportPath) {
+		os.Exit(1)
+	}
 	log.Println("==> Seeding complete.")
 }
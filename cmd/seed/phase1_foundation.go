@@ -2,27 +2,60 @@ package main
 
 import (
 	"log"
+	"slices"
 	"time"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"gorm.io/gorm"
 )
 
-func cleanupBadPlayers(db *gorm.DB) {
+func cleanupBadPlayers(db *gorm.DB, pd *phaseDiff) error {
 	for _, tag := range badGamertags {
 		var p models.Player
 		if err := db.Where("gamertag = ?", tag).First(&p).Error; err != nil {
 			continue
 		}
-		db.Where("player_id = ?", p.ID).Delete(&models.PlayerMapStats{})
-		db.Where("player_id = ?", p.ID).Delete(&models.PlayerMatchStats{})
-		db.Where("player_id = ?", p.ID).Delete(&models.PlayerTournamentStats{})
-		db.Delete(&p)
+		deletes := []struct {
+			entity string
+			model  any
+		}{
+			{models.ChangeStatLine, &models.PlayerMapStats{}},
+			{"", &models.PlayerMatchStats{}},
+			{models.ChangePlayerTotals, &models.PlayerTournamentStats{}},
+		}
+		for _, d := range deletes {
+			if err := trackedDelete(db, pd, d.entity, d.model, "player_id = ?", p.ID); err != nil {
+				return err
+			}
+		}
+		if err := trackedDelete(db, pd, models.ChangePlayer, &models.Player{}, "id = ?", p.ID); err != nil {
+			return err
+		}
+		pd.table("players").Deletes = append(pd.table("players").Deletes, tag)
 		log.Printf("Removed bad player: %s (id=%d)", tag, p.ID)
 	}
+	return nil
+}
+
+// trackedDelete deletes the rows of model matching query, recording them in the
+// change log under entity when it is set.
+func trackedDelete(db *gorm.DB, pd *phaseDiff, entity string, model any, query string, args ...any) error {
+	del := func() error { return db.Where(query, args...).Delete(model).Error }
+	if entity == "" {
+		return del()
+	}
+	var rowIDs []uint
+	if err := db.Model(model).Where(query, args...).Pluck("id", &rowIDs).Error; err != nil {
+		return err
+	}
+	ids := make([]any, len(rowIDs))
+	for i, id := range rowIDs {
+		ids[i] = id
+	}
+	return pd.changeLog().Track(db, entity, ids, del)
 }
 
-func seedFranchises(db *gorm.DB) map[string]uint {
+func seedFranchises(db *gorm.DB, pd *phaseDiff) (map[string]uint, error) {
 	rows := readBrandingCSV("database/cdl_team_branding_by_season.csv")
 
 	type candidate struct {
@@ -42,22 +75,35 @@ func seedFranchises(db *gorm.DB) map[string]uint {
 		}
 	}
 
-	franchiseMap := map[string]uint{}
+	franchises := make([]models.Franchise, 0, len(best))
 	for key, c := range best {
-		f := models.Franchise{FranchiseKey: key, Name: c.name, IsActive: c.active}
-		db.Where("franchise_key = ?", key).FirstOrCreate(&f)
-		if c.active {
-			db.Model(&f).Update("name", c.name)
-		}
-		franchiseMap[key] = f.ID
+		franchises = append(franchises, models.Franchise{FranchiseKey: key, Name: c.name, IsActive: c.active})
+	}
+	synced, err := syncRows(db, pd, tableSync[models.Franchise]{
+		table:   "franchises",
+		key:     func(f *models.Franchise) string { return f.FranchiseKey },
+		columns: []string{"name", "is_active"},
+	}, franchises)
+	if err != nil {
+		return nil, err
+	}
+
+	franchiseMap := map[string]uint{}
+	for key := range best {
+		franchiseMap[key] = synced[key].ID
 	}
 	log.Printf("Franchises seeded: %d", len(franchiseMap))
-	return franchiseMap
+	return franchiseMap, nil
 }
 
-func seedCDLTeams(db *gorm.DB, franchiseMap map[string]uint) map[string]uint {
+func seedCDLTeams(db *gorm.DB, pd *phaseDiff, franchiseMap map[string]uint) (map[string]uint, error) {
 	rows := readBrandingCSV("database/cdl_team_branding_by_season.csv")
-	return seedCDLTeamRows(db, rows, franchiseMap)
+	return seedCDLTeamRows(db, pd, rows, franchiseMap)
+}
+
+// cdlTeamLabel is a CDL team row's natural key in reports: one row per (name, game).
+func cdlTeamLabel(name, gameCode string) string {
+	return name + " (" + gameCode + ")"
 }
 
 // seedCDLTeamRows is the testable core of seedCDLTeams. Each branding row is one
@@ -67,14 +113,11 @@ func seedCDLTeams(db *gorm.DB, franchiseMap map[string]uint) map[string]uint {
 // CW/VG/MW2 rows collapsed into one, and Carolina Royal Ravens' MW3/BO6 into one,
 // which both broke the era list and leaked the latest season's roster onto older
 // eras. The returned lookup carries a game-aware key per era plus a bare-name key
-// (last era wins) for callers without game context.
-func seedCDLTeamRows(db *gorm.DB, rows []brandingRow, franchiseMap map[string]uint) map[string]uint {
-	lookup := map[string]uint{}
-
+// (last era wins) for callers without game context. Abbreviations are only set on
+// insert; they're curated by hand afterwards.
+func seedCDLTeamRows(db *gorm.DB, pd *phaseDiff, rows []brandingRow, franchiseMap map[string]uint) (map[string]uint, error) {
+	teams := make([]models.Team, 0, len(rows))
 	for _, r := range rows {
-		franchiseID := franchiseMap[r.FranchiseKey]
-		isActive := r.ValidTo == ""
-
 		var validFrom, validTo *time.Time
 		if t := parseFlexDate(r.ValidFrom); !t.IsZero() {
 			validFrom = &t
@@ -92,32 +135,44 @@ func seedCDLTeamRows(db *gorm.DB, rows []brandingRow, franchiseMap map[string]ui
 			DoNotMerge:         false,
 			ValidFrom:          validFrom,
 			ValidTo:            validTo,
-			IsActive:           isActive,
+			IsActive:           r.ValidTo == "",
 			Source:             "branding_csv",
 		}
-		if franchiseID != 0 {
+		if franchiseID := franchiseMap[r.FranchiseKey]; franchiseID != 0 {
 			t.FranchiseID = &franchiseID
 		}
+		teams = append(teams, t)
+	}
 
-		db.Where("name = ? AND game_code = ? AND source = ?", r.CanonicalTeamName, r.GameCode, "branding_csv").FirstOrCreate(&t)
+	synced, err := syncRows(db, pd, tableSync[models.Team]{
+		table:   "teams",
+		key:     func(t *models.Team) string { return cdlTeamLabel(t.Name, t.GameCode) },
+		columns: []string{"franchise_id", "is_cdl_franchise", "team_classification", "valid_from", "valid_to", "is_active"},
+		scope:   func(q *gorm.DB) *gorm.DB { return q.Where("source = ?", "branding_csv") },
+	}, teams)
+	if err != nil {
+		return nil, err
+	}
 
-		lookup[teamKey(r.CanonicalTeamName, r.GameCode)] = t.ID
-		lookup[r.CanonicalTeamName] = t.ID // bare fallback (last era wins)
+	lookup := map[string]uint{}
+	for _, r := range rows {
+		id := synced[cdlTeamLabel(r.CanonicalTeamName, r.GameCode)].ID
+		lookup[teamKey(r.CanonicalTeamName, r.GameCode)] = id
+		lookup[r.CanonicalTeamName] = id // bare fallback (last era wins)
 		if r.RawTeamName != r.CanonicalTeamName && r.RawTeamName != "" {
-			lookup[teamKey(r.RawTeamName, r.GameCode)] = t.ID
-			lookup[r.RawTeamName] = t.ID
+			lookup[teamKey(r.RawTeamName, r.GameCode)] = id
+			lookup[r.RawTeamName] = id
 		}
 	}
 	log.Printf("CDL teams seeded: %d lookup entries", len(lookup))
-	return lookup
+	return lookup, nil
 }
 
-func seedNonCDLTeams(db *gorm.DB) map[string]uint {
+func seedNonCDLTeams(db *gorm.DB, pd *phaseDiff) (map[string]uint, error) {
 	rows := readNonCDLCSV("database/non_cdl_team_aliases_clean.csv")
-	lookup := map[string]uint{}
-
+	teams := make([]models.Team, 0, len(rows))
 	for _, r := range rows {
-		t := models.Team{
+		teams = append(teams, models.Team{
 			Name:               r.CanonicalTeamName,
 			Abbreviation:       makeAbbr(r.CanonicalTeamName),
 			IsCDLFranchise:     false,
@@ -125,54 +180,73 @@ func seedNonCDLTeams(db *gorm.DB) map[string]uint {
 			DoNotMerge:         r.DoNotMerge,
 			NeedsManualReview:  r.NeedsManualReview,
 			Source:             "non_cdl_alias",
-		}
-		db.Where("name = ? AND source = ?", r.CanonicalTeamName, "non_cdl_alias").FirstOrCreate(&t)
-		lookup[r.CanonicalTeamName] = t.ID
+		})
+	}
+
+	synced, err := syncRows(db, pd, tableSync[models.Team]{
+		table:   "teams",
+		key:     func(t *models.Team) string { return t.Name },
+		columns: []string{"team_classification", "do_not_merge", "needs_manual_review"},
+		scope:   func(q *gorm.DB) *gorm.DB { return q.Where("source = ?", "non_cdl_alias") },
+	}, teams)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := map[string]uint{}
+	for _, r := range rows {
+		id := synced[r.CanonicalTeamName].ID
+		lookup[r.CanonicalTeamName] = id
 		if r.RawTeamName != r.CanonicalTeamName && r.RawTeamName != "" {
-			lookup[r.RawTeamName] = t.ID
+			lookup[r.RawTeamName] = id
 		}
 	}
 	log.Printf("Non-CDL teams seeded: %d lookup entries", len(lookup))
-	return lookup
+	return lookup, nil
 }
 
-func seedPlayers(db *gorm.DB) map[string]uint {
+// seedPlayers only ever inserts: players carry nothing from the alias CSV but the
+// gamertag, and later phases create players first seen in stats files, so a player
+// missing from the CSV isn't an orphan.
+func seedPlayers(db *gorm.DB, pd *phaseDiff) (map[string]uint, error) {
 	rows := readPlayerAliasCSV("database/player_aliases_clean.csv")
-	lookup := map[string]uint{}
 
+	var players []models.Player
 	for _, r := range rows {
-		tag := r.CanonicalPlayerName
-		if tag == "" {
-			continue
+		if tag := r.CanonicalPlayerName; tag != "" && !slices.Contains(badGamertags, tag) {
+			players = append(players, models.Player{Gamertag: tag})
 		}
-		isBad := false
-		for _, bad := range badGamertags {
-			if tag == bad {
-				isBad = true
-				break
-			}
-		}
-		if isBad {
+	}
+	synced, err := syncRows(db, pd, tableSync[models.Player]{
+		table:   "players",
+		key:     func(p *models.Player) string { return p.Gamertag },
+		orphans: orphansIgnore,
+	}, players)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := map[string]uint{}
+	for _, r := range rows {
+		tag := r.CanonicalPlayerName
+		if tag == "" || slices.Contains(badGamertags, tag) {
 			continue
 		}
-		if existing, done := lookup[tag]; done {
-			if r.PlayerName != tag && r.PlayerName != "" {
-				lookup[r.PlayerName] = existing
-			}
-			continue
+		p := synced[tag]
+		if _, done := lookup[tag]; !done {
+			lookup[tag] = p.ID
 		}
-		p := models.Player{Gamertag: tag}
-		db.Where("gamertag = ?", tag).FirstOrCreate(&p)
-		lookup[tag] = p.ID
 		if r.PlayerName != tag && r.PlayerName != "" {
-			lookup[r.PlayerName] = p.ID
+			if _, done := lookup[r.PlayerName]; !done {
+				lookup[r.PlayerName] = p.ID
+			}
 		}
 	}
 	log.Printf("Players seeded: %d lookup entries", len(lookup))
-	return lookup
+	return lookup, nil
 }
 
-func seedSeasons(db *gorm.DB) map[string]uint {
+func seedSeasons(db *gorm.DB, pd *phaseDiff) (map[string]uint, error) {
 	type cfg struct {
 		Code      string
 		Name      string
@@ -186,28 +260,45 @@ func seedSeasons(db *gorm.DB) map[string]uint {
 		{"VG", "Vanguard 2021-22", "Vanguard", 2021},
 		{"CW", "Black Ops Cold War 2020-21", "Black Ops Cold War", 2020},
 	}
-	byCode := map[string]uint{}
+	seasons := make([]models.Season, 0, len(cfgs))
 	for _, c := range cfgs {
-		s := models.Season{
+		seasons = append(seasons, models.Season{
 			Name:      c.Name,
 			GameTitle: c.GameTitle,
 			GameCode:  c.Code,
 			StartDate: time.Date(c.Year, 9, 1, 0, 0, 0, 0, time.UTC),
 			IsActive:  c.Code == "BO6",
-		}
-		db.Where("name = ?", c.Name).FirstOrCreate(&s)
-		db.Model(&s).Update("game_code", c.Code)
-		byCode[c.Code] = s.ID
+		})
+	}
+	synced, err := syncRows(db, pd, tableSync[models.Season]{
+		table:   "seasons",
+		key:     func(s *models.Season) string { return s.Name },
+		columns: []string{"game_title", "game_code", "start_date", "is_active"},
+	}, seasons)
+	if err != nil {
+		return nil, err
+	}
+
+	byCode := map[string]uint{}
+	for _, c := range cfgs {
+		byCode[c.Code] = synced[c.Name].ID
 	}
 	log.Printf("Seasons seeded: %d", len(byCode))
-	return byCode
+	return byCode, nil
 }
 
-func seedTournaments(db *gorm.DB, seasonByCode map[string]uint) (map[string]uint, []eventRange) {
+// seedTournaments owns every tournament except the ones later phases create: the
+// per-season "-unmatched" fallbacks and "-season-stats" summaries.
+func seedTournaments(db *gorm.DB, pd *phaseDiff, seasonByCode map[string]uint) (map[string]uint, []eventRange, error) {
 	rows := readEventAliasCSV("database/event_aliases_clean.csv")
-	bySlug := map[string]uint{}
-	var ranges []eventRange
+	codeBySeason := map[uint]string{}
+	for code, id := range seasonByCode {
+		codeBySeason[id] = code
+	}
+	label := func(t *models.Tournament) string { return t.Slug + " (" + codeBySeason[t.SeasonID] + ")" }
 
+	var tournaments []models.Tournament
+	var ranges []eventRange
 	for _, r := range rows {
 		if r.EventSlug == "" {
 			continue
@@ -222,17 +313,15 @@ func seedTournaments(db *gorm.DB, seasonByCode map[string]uint) (map[string]uint
 			endDatePtr = &t
 		}
 
-		t := models.Tournament{
-			SeasonID:         seasonID,
-			Name:             r.CanonicalEventName,
-			Slug:             r.EventSlug,
-			TournamentType:   r.EventType,
-			StartDate:        startDate,
-			EndDate:          endDatePtr,
-			SourceURL: r.SourceURL,
-		}
-		db.Where("slug = ? AND season_id = ?", r.EventSlug, seasonID).FirstOrCreate(&t)
-		bySlug[r.EventSlug] = t.ID
+		tournaments = append(tournaments, models.Tournament{
+			SeasonID:       seasonID,
+			Name:           r.CanonicalEventName,
+			Slug:           r.EventSlug,
+			TournamentType: r.EventType,
+			StartDate:      startDate,
+			EndDate:        endDatePtr,
+			SourceURL:      r.SourceURL,
+		})
 
 		if !startDate.IsZero() {
 			end := startDate.AddDate(0, 0, 30)
@@ -247,6 +336,23 @@ func seedTournaments(db *gorm.DB, seasonByCode map[string]uint) (map[string]uint
 			})
 		}
 	}
+
+	synced, err := syncRows(db, pd, tableSync[models.Tournament]{
+		table:   "tournaments",
+		key:     label,
+		columns: []string{"name", "tournament_type", "start_date", "end_date", "breaking_point_url"},
+		scope: func(q *gorm.DB) *gorm.DB {
+			return q.Where("slug NOT LIKE ? AND slug NOT LIKE ?", "%-unmatched", "%-season-stats")
+		},
+	}, tournaments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bySlug := map[string]uint{}
+	for i := range tournaments {
+		bySlug[tournaments[i].Slug] = synced[label(&tournaments[i])].ID
+	}
 	log.Printf("Tournaments seeded: %d slugs", len(bySlug))
-	return bySlug, ranges
+	return bySlug, ranges, nil
 }
@@ -40,7 +40,8 @@ func TestSeedCDLTeamRows_SplitsRoyalRavensPerGameEra(t *testing.T) {
 		{GameCode: "BO6", RawTeamName: "Carolina Royal Ravens", CanonicalTeamName: "Carolina Royal Ravens", FranchiseKey: "royal-ravens", ValidFrom: "2024-12-06", ValidTo: "2025-08-31"},
 	}
 
-	lookup := seedCDLTeamRows(db, rows, map[string]uint{"royal-ravens": franchiseID})
+	lookup, err := seedCDLTeamRows(db, &phaseDiff{}, rows, map[string]uint{"royal-ravens": franchiseID})
+	require.NoError(t, err)
 
 	var teams []models.Team
 	require.NoError(t, db.Where("franchise_id = ?", franchiseID).Find(&teams).Error)
@@ -65,7 +66,11 @@ func TestSeedCDLTeamRows_SplitsRoyalRavensPerGameEra(t *testing.T) {
 		resolveTeamID(lookup, "Carolina Royal Ravens", "MW3"),
 		resolveTeamID(lookup, "Carolina Royal Ravens", "BO6"),
 		"MW3 and BO6 must be separate Carolina Royal Ravens rows")
-	seedCDLTeamRows(db, rows, map[string]uint{"royal-ravens": franchiseID})
+	pd := &phaseDiff{}
+	_, err = seedCDLTeamRows(db, pd, rows, map[string]uint{"royal-ravens": franchiseID})
+	require.NoError(t, err)
+	require.Empty(t, pd.table("teams").Inserts, "re-seeding must not insert anything")
+	require.Empty(t, pd.table("teams").Updates, "re-seeding must not update anything")
 	var count int64
 	require.NoError(t, db.Model(&models.Team{}).Where("franchise_id = ?", franchiseID).Count(&count).Error)
 	require.EqualValues(t, 5, count, "second seed run must not duplicate era rows")
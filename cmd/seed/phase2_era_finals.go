@@ -6,30 +6,36 @@ package main
 // It writes Match, MatchMap, PlayerMapStats, and PlayerMatchStats records.
 // PlayerMatchStats is computed by summing the per-map stats — it's the aggregate
 // the existing player profile "Matches" tab queries.
+//
+// The phase owns every match with a breaking_point_match_id and their maps and stat
+// lines; syncMatches below is shared with phase 3, which owns the enriched matches.
 
 import (
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // seedEraFinals iterates all 5 eras and seeds the full match hierarchy.
 // Returns bp_match_id → DB match.ID for downstream cross-referencing.
 func seedEraFinals(
 	db *gorm.DB,
+	pd *phaseDiff,
 	teamLookup map[string]uint,
 	playerLookup map[string]uint,
 	seasonByCode map[string]uint,
 	tournamentBySlug map[string]uint,
 	eventRanges []eventRange,
-) map[int]uint {
+) (map[int]uint, error) {
 
-	matchByBPID := map[int]uint{}
+	var matches []models.Match
+	children := newMatchChildren()
 
 	for _, era := range eraFinalsConfigs {
-		log.Printf("[%s] Seeding era_finals…", era.GameCode)
+		log.Printf("[%s] Reading era_finals…", era.GameCode)
 
 		seriesRows := readSeriesCSV(era.SeriesFile)
 		mapRows := readMapCSV(era.MapsFile)
@@ -55,27 +61,25 @@ func seedEraFinals(
 		}
 
 		seasonID := seasonByCode[era.GameCode]
-		seriesSeeded := 0
-
-		// Collect child records across all matches in this era, then batch insert once.
-		var matchMapsBatch []models.MatchMap
-		var playerMapStatsBatch []models.PlayerMapStats
-		var playerMatchStatsBatch []models.PlayerMatchStats
+		seriesSeen, mapsSeen, statsSeen := 0, 0, 0
 
 		for _, s := range seriesRows {
 			matchTime := parseISOTime(s.MatchDatetime)
 			team1ID := resolveTeamID(teamLookup, s.TeamAName, era.GameCode)
 			team2ID := resolveTeamID(teamLookup, s.TeamBName, era.GameCode)
 			if team1ID == 0 || team2ID == 0 {
-				log.Printf("[%s] WARN: unresolved team in match %d (%q vs %q)", era.GameCode, s.MatchID, s.TeamAName, s.TeamBName)
+				log.Printf("[%s] WARN: unresolved team in match %d (%q vs %q) — skipping", era.GameCode, s.MatchID, s.TeamAName, s.TeamBName)
+				continue
 			}
 			var winnerID *uint
 			if wid := resolveTeamID(teamLookup, s.WinnerName, era.GameCode); wid != 0 {
 				winnerID = &wid
 			}
+			// findTournamentForMatch compares calendar days, so a match that ran after
+			// the CSV end-time still lands in its event rather than the fallback.
 			tournamentID := findTournamentForMatch(eventRanges, tournamentBySlug, era.GameCode, matchTime)
 			if tournamentID == 0 {
-				tournamentID = ensureFallbackTournament(db, seasonID, era.GameCode)
+				tournamentID = ensureFallbackTournament(db, pd, seasonID, era.GameCode)
 			}
 
 			bpID := s.MatchID
@@ -92,40 +96,16 @@ func seedEraFinals(
 				LiquipediaURL:        s.SourceURL,
 				BracketRound:         rawRoundToDBRound(s.RoundName),
 			}
-			// Match is kept as FirstOrCreate — we need m.ID immediately for child rows.
-			db.Where("breaking_point_match_id = ?", bpID).FirstOrCreate(&m)
-			// If a previous seeder run placed this match in the fallback tournament
-			// (because findTournamentForMatch used strict timestamp comparison and
-			// the match ran after the CSV end-time), correct the tournament now.
-			if tournamentID != 0 && m.TournamentID != tournamentID {
-				db.Model(&m).Update("tournament_id", tournamentID)
-				m.TournamentID = tournamentID
-			}
-			if team1ID != 0 && m.Team1ID != team1ID {
-				log.Printf("[%s] correcting team1_id match %d: %d→%d", era.GameCode, bpID, m.Team1ID, team1ID)
-				db.Model(&m).Update("team1_id", team1ID)
-				m.Team1ID = team1ID
-			}
-			if team2ID != 0 && m.Team2ID != team2ID {
-				log.Printf("[%s] correcting team2_id match %d: %d→%d", era.GameCode, bpID, m.Team2ID, team2ID)
-				db.Model(&m).Update("team2_id", team2ID)
-				m.Team2ID = team2ID
-			}
-			if winnerID != nil && m.WinnerID != nil && *m.WinnerID != *winnerID {
-				log.Printf("[%s] correcting winner_id match %d: %d→%d", era.GameCode, bpID, *m.WinnerID, *winnerID)
-				db.Model(&m).Update("winner_id", winnerID)
-				m.WinnerID = winnerID
-			}
-			matchByBPID[s.MatchID] = m.ID
-			seriesSeeded++
+			matches = append(matches, m)
+			key := matchKey(&m)
+			seriesSeen++
 
 			for _, mr := range mapsByMatchID[s.MatchID] {
 				var mapWinnerID *uint
 				if wid := resolveTeamID(teamLookup, mr.WinnerName, era.GameCode); wid != 0 {
 					mapWinnerID = &wid
 				}
-				matchMapsBatch = append(matchMapsBatch, models.MatchMap{
-					MatchID:     m.ID,
+				children.maps[key] = append(children.maps[key], models.MatchMap{
 					MapNumber:   mr.MapNumber,
 					MapName:     mr.MapName,
 					Mode:        mr.ModeName,
@@ -136,6 +116,7 @@ func seedEraFinals(
 					DurationSec: mr.DurationMin*60 + mr.DurationSec,
 					Source:      mr.SourceType,
 				})
+				mapsSeen++
 			}
 
 			matchTeams := matchTeamByID[s.MatchID]
@@ -151,7 +132,7 @@ func seedEraFinals(
 			matchAggs := map[uint]*matchAgg{}
 
 			for _, st := range statsByMatchID[s.MatchID] {
-				playerID := resolvePlayer(st.PlayerTag, playerLookup, db)
+				playerID := resolvePlayer(st.PlayerTag, playerLookup, db, pd)
 				if playerID == 0 {
 					continue
 				}
@@ -163,8 +144,7 @@ func seedEraFinals(
 				}
 				teamID := resolveTeamID(teamLookup, teamName, era.GameCode)
 
-				playerMapStatsBatch = append(playerMapStatsBatch, models.PlayerMapStats{
-					MatchID:              m.ID,
+				children.mapStats[key] = append(children.mapStats[key], models.PlayerMapStats{
 					MapNumber:            st.MapNumber,
 					PlayerID:             playerID,
 					TeamID:               teamID,
@@ -189,6 +169,7 @@ func seedEraFinals(
 					DataQualityNote:      st.DataQualityNote,
 					Source:               st.SourceType,
 				})
+				statsSeen++
 
 				if _, ok := matchAggs[playerID]; !ok {
 					matchAggs[playerID] = &matchAgg{PlayerID: playerID, TeamID: teamID}
@@ -206,8 +187,7 @@ func seedEraFinals(
 				if agg.Deaths > 0 {
 					kd = float64(agg.Kills) / float64(agg.Deaths)
 				}
-				playerMatchStatsBatch = append(playerMatchStatsBatch, models.PlayerMatchStats{
-					MatchID:      m.ID,
+				children.matchStats[key] = append(children.matchStats[key], models.PlayerMatchStats{
 					PlayerID:     agg.PlayerID,
 					TeamID:       agg.TeamID,
 					MapsPlayed:   agg.Maps,
@@ -220,17 +200,196 @@ func seedEraFinals(
 			}
 		}
 
-		if len(matchMapsBatch) > 0 {
-			db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(matchMapsBatch, 500)
+		log.Printf("[%s] series=%d  maps=%d  playerStats=%d", era.GameCode, seriesSeen, mapsSeen, statsSeen)
+	}
+
+	synced, err := syncMatches(db, pd, matches, children,
+		[]string{"tournament_id", "team1_id", "team2_id", "match_date", "format", "team1_score", "team2_score", "winner_id", "liquipedia_url"},
+		func(q *gorm.DB) *gorm.DB { return q.Where("breaking_point_match_id IS NOT NULL") })
+	if err != nil {
+		return nil, err
+	}
+	matchByBPID := map[int]uint{}
+	for _, m := range synced {
+		if m.BreakingPointMatchID != nil {
+			matchByBPID[*m.BreakingPointMatchID] = m.ID
+		}
+	}
+	return matchByBPID, nil
+}
+
+// matchKey is a match's natural key: its BreakingPoint ID, or the dedup key the
+// enriched and bracket phases store in liquipedia_url.
+func matchKey(m *models.Match) string {
+	switch {
+	case m.BreakingPointMatchID != nil:
+		return fmt.Sprintf("bp:%d", *m.BreakingPointMatchID)
+	case strings.HasPrefix(m.LiquipediaURL, "enriched:"), strings.HasPrefix(m.LiquipediaURL, "bracket_patch:"):
+		return m.LiquipediaURL
+	}
+	return fmt.Sprintf("match %d", m.ID)
+}
+
+// matchChildren holds a phase's maps and stat lines by match key until the matches
+// have IDs.
+type matchChildren struct {
+	maps       map[string][]models.MatchMap
+	mapStats   map[string][]models.PlayerMapStats
+	matchStats map[string][]models.PlayerMatchStats
+}
+
+func newMatchChildren() *matchChildren {
+	return &matchChildren{
+		maps:       map[string][]models.MatchMap{},
+		mapStats:   map[string][]models.PlayerMapStats{},
+		matchStats: map[string][]models.PlayerMatchStats{},
+	}
+}
+
+// Columns the match CSVs own in each child table. Ratings, ADR and KDA are filled
+// in by cmd/ratings and the admin rebuilds, and are left alone.
+var (
+	matchMapColumns = []string{"map_name", "mode", "score1", "score2", "winner_id", "played", "duration_sec", "source"}
+	mapStatColumns  = []string{
+		"team_id", "kills", "deaths", "kd_ratio", "damage", "assists", "bp_rating", "hill_time",
+		"snd_rounds", "plant_count", "defuse_count", "snipe_count", "first_blood_count", "first_death_count",
+		"zone_tier_capture_count", "ctl_attack_rounds", "ctl_defense_rounds", "non_traded_kills",
+		"highest_streak", "data_quality_note", "source",
+	}
+	matchStatColumns = []string{"team_id", "maps_played", "total_kills", "total_deaths", "total_assists", "total_damage", "kd_ratio"}
+)
+
+// syncMatches syncs the matches in scope and then their maps, player map stats and
+// player match stats. Children of every owned match are in scope, so maps and stat
+// lines of orphaned matches are orphans too, and go before the matches when pruned.
+// An orphan with picks or a thread is kept whole, and one whose maps or stat lines
+// were kept (an admin changed them) stays with them.
+// bracket_round is only written on insert: phase 6 owns it afterwards.
+func syncMatches(
+	db *gorm.DB,
+	pd *phaseDiff,
+	matches []models.Match,
+	children *matchChildren,
+	columns []string,
+	scope func(*gorm.DB) *gorm.DB,
+) (map[string]*models.Match, error) {
+	plan, err := planRows(db, pd, tableSync[models.Match]{
+		table:      "matches",
+		key:        matchKey,
+		columns:    columns,
+		scope:      scope,
+		referenced: matchReferences(userMatchRefs),
+	}, matches)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.apply(db); err != nil {
+		return nil, err
+	}
+
+	owned := plan.ownedIDs(db)
+	labels := make(map[uint]string, len(owned))
+	for _, m := range plan.rows {
+		labels[m.ID] = matchKey(m)
+	}
+	for _, m := range plan.stale {
+		labels[m.ID] = matchKey(m)
+	}
+	label := func(matchID uint) string {
+		if l, ok := labels[matchID]; ok {
+			return l
+		}
+		return fmt.Sprintf("match %d", matchID)
+	}
+	childScope := func(q *gorm.DB) *gorm.DB { return q.Where("match_id IN ?", owned) }
+
+	var maps []models.MatchMap
+	var mapStats []models.PlayerMapStats
+	var matchStats []models.PlayerMatchStats
+	for key, m := range plan.rows {
+		for _, mm := range children.maps[key] {
+			mm.MatchID = m.ID
+			maps = append(maps, mm)
 		}
-		if len(playerMapStatsBatch) > 0 {
-			db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(playerMapStatsBatch, 500)
+		for _, st := range children.mapStats[key] {
+			st.MatchID = m.ID
+			mapStats = append(mapStats, st)
 		}
-		if len(playerMatchStatsBatch) > 0 {
-			db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(playerMatchStatsBatch, 500)
+		for _, st := range children.matchStats[key] {
+			st.MatchID = m.ID
+			matchStats = append(matchStats, st)
 		}
+	}
 
-		log.Printf("[%s] series=%d  maps=%d  playerStats=%d", era.GameCode, seriesSeeded, len(matchMapsBatch), len(playerMapStatsBatch))
+	if _, err := syncRows(db, pd, tableSync[models.MatchMap]{
+		table:   "match_maps",
+		key:     func(mm *models.MatchMap) string { return fmt.Sprintf("%s/map %d", label(mm.MatchID), mm.MapNumber) },
+		columns: matchMapColumns,
+		scope:   childScope,
+	}, maps); err != nil {
+		return nil, err
+	}
+	if _, err := syncRows(db, pd, tableSync[models.PlayerMapStats]{
+		table: "player_map_stats",
+		key: func(st *models.PlayerMapStats) string {
+			return fmt.Sprintf("%s/map %d/player %d", label(st.MatchID), st.MapNumber, st.PlayerID)
+		},
+		columns: mapStatColumns,
+		scope:   childScope,
+	}, mapStats); err != nil {
+		return nil, err
+	}
+	if _, err := syncRows(db, pd, tableSync[models.PlayerMatchStats]{
+		table: "player_match_stats",
+		key: func(st *models.PlayerMatchStats) string {
+			return fmt.Sprintf("%s/player %d", label(st.MatchID), st.PlayerID)
+		},
+		columns: matchStatColumns,
+		scope:   childScope,
+	}, matchStats); err != nil {
+		return nil, err
+	}
+
+	if err := plan.keepReferenced(db, matchReferences(allMatchRefs)); err != nil {
+		return nil, err
+	}
+	if err := plan.prune(db); err != nil {
+		return nil, err
+	}
+	return plan.rows, nil
+}
+
+// matchRef is a table with a foreign key to matches, and how an orphan report
+// names its rows.
+type matchRef struct{ table, label string }
+
+// Picks and threads are user data the seeder never deletes; the rest are a match's
+// own maps and stat lines.
+var (
+	userMatchRefs = []matchRef{{"picks", "picks"}, {"match_threads", "a thread"}}
+	allMatchRefs  = append([]matchRef{
+		{"match_maps", "maps"}, {"player_map_stats", "stat lines"}, {"player_match_stats", "stat lines"},
+	}, userMatchRefs...)
+)
+
+// matchReferences returns a tableSync.referenced check over refs.
+func matchReferences(refs []matchRef) func(*gorm.DB, []any) (map[uint]string, error) {
+	return func(tx *gorm.DB, ids []any) (map[uint]string, error) {
+		found := map[uint]string{}
+		for _, ref := range refs {
+			var matchIDs []uint
+			if err := tx.Table(ref.table).Distinct("match_id").Where("match_id IN ?", ids).Pluck("match_id", &matchIDs).Error; err != nil {
+				return nil, err
+			}
+			for _, id := range matchIDs {
+				switch {
+				case found[id] == "":
+					found[id] = ref.label
+				case !strings.Contains(found[id], ref.label):
+					found[id] += ", " + ref.label
+				}
+			}
+		}
+		return found, nil
 	}
-	return matchByBPID
 }
@@ -4,21 +4,22 @@ package main
 // These events aren't in era_finals because they either had different data sources or
 // different formats (group stages, international teams). Rows sourced from
 // "bo6_season_stats_breakingpoint" are skipped — era_finals already covers those.
+// The phase owns the matches keyed "enriched:<series id>" and their children.
 
 import (
 	"log"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 func seedEnrichedMatches(
 	db *gorm.DB,
+	pd *phaseDiff,
 	teamLookup map[string]uint,
 	playerLookup map[string]uint,
 	tournamentBySlug map[string]uint,
-) {
+) error {
 	seriesRows := readEnrichedSeriesCSV("database/enriched_series_matches.csv")
 	mapRows := readEnrichedMapCSV("database/enriched_match_maps.csv")
 	statRows := readEnrichedStatCSV("database/enriched_player_map_stats.csv")
@@ -32,10 +33,9 @@ func seedEnrichedMatches(
 		statsByID[sr.SeriesMatchID] = append(statsByID[sr.SeriesMatchID], sr)
 	}
 
-	seriesSeeded := 0
-	var matchMapsBatch []models.MatchMap
-	var playerMapStatsBatch []models.PlayerMapStats
-	var playerMatchStatsBatch []models.PlayerMatchStats
+	seriesSeen, mapsSeen, statsSeen := 0, 0, 0
+	var matches []models.Match
+	children := newMatchChildren()
 
 	for _, s := range seriesRows {
 		if s.Source == "bo6_season_stats_breakingpoint" {
@@ -51,10 +51,10 @@ func seedEnrichedMatches(
 		team1ID := resolveTeamID(teamLookup, s.Team1Canonical, s.GameCode)
 		team2ID := resolveTeamID(teamLookup, s.Team2Canonical, s.GameCode)
 		if team1ID == 0 {
-			team1ID = ensureUnknownTeam(db, s.Team1Canonical, teamLookup)
+			team1ID = ensureUnknownTeam(db, pd, s.Team1Canonical, teamLookup)
 		}
 		if team2ID == 0 {
-			team2ID = ensureUnknownTeam(db, s.Team2Canonical, teamLookup)
+			team2ID = ensureUnknownTeam(db, pd, s.Team2Canonical, teamLookup)
 		}
 
 		var winnerID *uint
@@ -76,24 +76,15 @@ func seedEnrichedMatches(
 			LiquipediaURL: dedupKey,
 			BracketRound:  rawRoundToDBRound(s.RoundName),
 		}
-		// Match is kept as FirstOrCreate — we need m.ID immediately for child rows.
-		db.Where("liquipedia_url = ?", dedupKey).FirstOrCreate(&m)
-		// If previously seeded before PST timezone parsing was supported, match_date
-		// may be zero in the DB — correct it now that parseFlexDateCtx handles it.
-		if !matchDate.IsZero() && m.MatchDate.IsZero() {
-			log.Printf("[enriched] correcting match_date for %s: 0001-01-01 → %s", s.SeriesMatchID, matchDate.UTC().Format("2006-01-02"))
-			db.Model(&m).Update("match_date", matchDate)
-			m.MatchDate = matchDate
-		}
-		seriesSeeded++
+		matches = append(matches, m)
+		seriesSeen++
 
 		for _, mr := range mapsByID[s.SeriesMatchID] {
 			var mapWinnerID *uint
 			if wid := resolveTeamID(teamLookup, mr.MapWinner, mr.GameCode); wid != 0 {
 				mapWinnerID = &wid
 			}
-			matchMapsBatch = append(matchMapsBatch, models.MatchMap{
-				MatchID:     m.ID,
+			children.maps[dedupKey] = append(children.maps[dedupKey], models.MatchMap{
 				MapNumber:   mr.MapNumber,
 				MapName:     mr.MapName,
 				Mode:        mr.Mode,
@@ -104,6 +95,7 @@ func seedEnrichedMatches(
 				DurationSec: parseDurationString(mr.Duration),
 				Source:      mr.Source,
 			})
+			mapsSeen++
 		}
 
 		type enrichedAgg struct {
@@ -116,17 +108,16 @@ func seedEnrichedMatches(
 		enrichedAggs := map[uint]*enrichedAgg{}
 
 		for _, st := range statsByID[s.SeriesMatchID] {
-			playerID := resolvePlayer(st.Player, playerLookup, db)
+			playerID := resolvePlayer(st.Player, playerLookup, db, pd)
 			if playerID == 0 {
 				continue
 			}
 			teamID := resolveTeamID(teamLookup, st.Team, st.GameCode)
 			if teamID == 0 {
-				teamID = ensureUnknownTeam(db, st.Team, teamLookup)
+				teamID = ensureUnknownTeam(db, pd, st.Team, teamLookup)
 			}
 
-			playerMapStatsBatch = append(playerMapStatsBatch, models.PlayerMapStats{
-				MatchID:         m.ID,
+			children.mapStats[dedupKey] = append(children.mapStats[dedupKey], models.PlayerMapStats{
 				MapNumber:       st.MapNumber,
 				PlayerID:        playerID,
 				TeamID:          teamID,
@@ -141,6 +132,7 @@ func seedEnrichedMatches(
 				DataQualityNote: st.DataQualityNote,
 				Source:          st.Source,
 			})
+			statsSeen++
 
 			if _, ok := enrichedAggs[playerID]; !ok {
 				enrichedAggs[playerID] = &enrichedAgg{PlayerID: playerID, TeamID: teamID}
@@ -156,8 +148,7 @@ func seedEnrichedMatches(
 			if agg.Deaths > 0 {
 				kd = float64(agg.Kills) / float64(agg.Deaths)
 			}
-			playerMatchStatsBatch = append(playerMatchStatsBatch, models.PlayerMatchStats{
-				MatchID:     m.ID,
+			children.matchStats[dedupKey] = append(children.matchStats[dedupKey], models.PlayerMatchStats{
 				PlayerID:    agg.PlayerID,
 				TeamID:      agg.TeamID,
 				MapsPlayed:  agg.Maps,
@@ -168,15 +159,10 @@ func seedEnrichedMatches(
 		}
 	}
 
-	if len(matchMapsBatch) > 0 {
-		db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(matchMapsBatch, 500)
-	}
-	if len(playerMapStatsBatch) > 0 {
-		db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(playerMapStatsBatch, 500)
-	}
-	if len(playerMatchStatsBatch) > 0 {
-		db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(playerMatchStatsBatch, 500)
-	}
+	log.Printf("[enriched] series=%d  maps=%d  playerStats=%d", seriesSeen, mapsSeen, statsSeen)
 
-	log.Printf("[enriched] series=%d  maps=%d  playerStats=%d", seriesSeeded, len(matchMapsBatch), len(playerMapStatsBatch))
+	_, err := syncMatches(db, pd, matches, children,
+		[]string{"tournament_id", "team1_id", "team2_id", "match_date", "format", "team1_score", "team2_score", "winner_id"},
+		func(q *gorm.DB) *gorm.DB { return q.Where("liquipedia_url LIKE ?", "enriched:%") })
+	return err
 }
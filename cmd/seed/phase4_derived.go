@@ -13,20 +13,19 @@ package main
 // (kills/deaths/assists/damage + K/D, KDA, maps) are derivable here; the HP/SND/CTL
 // mode splits have no per-map source and are left at zero.
 //
-// The aggregation runs as one server-side INSERT ... SELECT so the same statement can
-// be used verbatim as a targeted prod backfill, and it is idempotent
-// (ON CONFLICT DO NOTHING) so re-running a full seed is safe.
+// The aggregation runs server-side as one SELECT, and its rows are synced like any
+// CSV's, so a changed map stat shows up as an update to the season row.
 
 import (
+	"fmt"
 	"log"
-	"time"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"gorm.io/gorm"
 )
 
 // derivedSeasonStatsSQL aggregates player_map_stats for one season into
-// player_tournament_stats rows pointed at the given summary tournament.
+// player_tournament_stats rows for the given summary tournament.
 // Params (in order): seasonID, tournamentID.
 //   - dom_team: each player's team for the season = the team they played the most
 //     maps for (ties broken by lower team_id for determinism).
@@ -60,34 +59,35 @@ totals AS (
 	GROUP BY player_id
 	HAVING SUM(kills) > 0 OR SUM(deaths) > 0
 )
-INSERT INTO player_tournament_stats
-	(player_id, team_id, tournament_id, total_kills, total_deaths, total_assists,
-	 total_damage, kd_ratio, kda_ratio, overall_maps)
-SELECT t.player_id, dt.team_id, ?, t.k, t.d, t.a, t.dmg,
-	CASE WHEN t.d > 0 THEN ROUND(t.k::decimal / t.d, 3) ELSE 0 END,
-	CASE WHEN t.d > 0 THEN ROUND((t.k + t.a)::decimal / t.d, 3) ELSE 0 END,
-	t.maps
+SELECT t.player_id, dt.team_id, ?::bigint AS tournament_id,
+	t.k AS total_kills, t.d AS total_deaths, t.a AS total_assists, t.dmg AS total_damage,
+	CASE WHEN t.d > 0 THEN ROUND(t.k::decimal / t.d, 3) ELSE 0 END AS kd_ratio,
+	CASE WHEN t.d > 0 THEN ROUND((t.k + t.a)::decimal / t.d, 3) ELSE 0 END AS kda_ratio,
+	t.maps AS overall_maps
 FROM totals t
-JOIN dom_team dt ON dt.player_id = t.player_id
-ON CONFLICT (player_id, tournament_id) DO NOTHING`
+JOIN dom_team dt ON dt.player_id = t.player_id`
+
+// derivedSeasonStatColumns are the player_tournament_stats columns the roll-up fills.
+var derivedSeasonStatColumns = []string{
+	"team_id", "total_kills", "total_deaths", "total_assists", "total_damage", "kd_ratio", "kda_ratio", "overall_maps",
+}
 
 // seedDerivedSeasonStats populates player_tournament_stats for an era that has no
 // pre-aggregated CSV, by rolling up its per-map stats. It mirrors the season-summary
 // tournament that seedSeasonStats creates so all eras share one representation.
-func seedDerivedSeasonStats(db *gorm.DB, cfg seasonStatCfg, seasonID uint) {
-	summaryTournament := models.Tournament{
-		SeasonID:       seasonID,
-		Name:           cfg.Name + " — Season Stats",
-		Slug:           cfg.GameCode + "-season-stats",
-		TournamentType: "season_summary",
-		StartDate:      time.Date(cfg.StartYear, 6, 1, 0, 0, 0, 0, time.UTC),
+func seedDerivedSeasonStats(db *gorm.DB, pd *phaseDiff, cfg seasonStatCfg, seasonID uint) error {
+	summaryTournament, err := ensureSummaryTournament(db, pd, cfg, seasonID)
+	if err != nil {
+		return err
 	}
-	db.Where("slug = ? AND season_id = ?", summaryTournament.Slug, seasonID).FirstOrCreate(&summaryTournament)
 
-	res := db.Exec(derivedSeasonStatsSQL, seasonID, summaryTournament.ID)
-	if res.Error != nil {
-		log.Printf("[%s] derived season stats failed: %v", cfg.GameCode, res.Error)
-		return
+	var rows []models.PlayerTournamentStats
+	if err := db.Raw(derivedSeasonStatsSQL, seasonID, summaryTournament.ID).Scan(&rows).Error; err != nil {
+		return fmt.Errorf("[%s] derived season stats: %w", cfg.GameCode, err)
+	}
+	if err := syncSeasonStats(db, pd, cfg, summaryTournament.ID, derivedSeasonStatColumns, rows); err != nil {
+		return err
 	}
-	log.Printf("[%s] season stats derived from map data: %d rows", cfg.GameCode, res.RowsAffected)
+	log.Printf("[%s] season stats derived from map data: %d rows", cfg.GameCode, len(rows))
+	return nil
 }
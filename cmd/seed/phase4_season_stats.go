@@ -8,6 +8,7 @@ package main
 
 import (
 	"encoding/csv"
+	"fmt"
 	"log"
 	"os"
 	"strings"
@@ -15,14 +16,13 @@ import (
 
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
-func seedSeasonStats(db *gorm.DB, cfg seasonStatCfg, seasonID uint, teamLookup map[string]uint, playerLookup map[string]uint) {
+func seedSeasonStats(db *gorm.DB, pd *phaseDiff, cfg seasonStatCfg, seasonID uint, teamLookup map[string]uint, playerLookup map[string]uint) error {
 	f, err := os.Open(cfg.PlayerFile)
 	if err != nil {
 		log.Printf("[%s] skipping season stats: %v", cfg.GameCode, err)
-		return
+		return nil
 	}
 	defer f.Close()
 
@@ -30,7 +30,7 @@ func seedSeasonStats(db *gorm.DB, cfg seasonStatCfg, seasonID uint, teamLookup m
 	reader.LazyQuotes = true
 	records, err := reader.ReadAll()
 	if err != nil || len(records) < 2 {
-		return
+		return nil
 	}
 
 	headers := normalizeHeaders(records[0])
@@ -53,16 +53,10 @@ func seedSeasonStats(db *gorm.DB, cfg seasonStatCfg, seasonID uint, teamLookup m
 		return ""
 	}
 
-	// A virtual "Season Stats" tournament holds all aggregate rows.
-	// It's not a real event — just a DB container so PlayerTournamentStats has a tournament_id.
-	summaryTournament := models.Tournament{
-		SeasonID:       seasonID,
-		Name:           cfg.Name + " — Season Stats",
-		Slug:           cfg.GameCode + "-season-stats",
-		TournamentType: "season_summary",
-		StartDate:      time.Date(cfg.StartYear, 6, 1, 0, 0, 0, 0, time.UTC),
+	summaryTournament, err := ensureSummaryTournament(db, pd, cfg, seasonID)
+	if err != nil {
+		return err
 	}
-	db.Where("slug = ? AND season_id = ?", summaryTournament.Slug, seasonID).FirstOrCreate(&summaryTournament)
 
 	var statsBatch []models.PlayerTournamentStats
 
@@ -82,13 +76,13 @@ func seedSeasonStats(db *gorm.DB, cfg seasonStatCfg, seasonID uint, teamLookup m
 			continue
 		}
 
-		playerID := resolvePlayer(gamertag, playerLookup, db)
+		playerID := resolvePlayer(gamertag, playerLookup, db, pd)
 		if playerID == 0 {
 			continue
 		}
 		teamID := dominantTeam(db, playerID, seasonID)
 		if teamID == 0 {
-			teamID = ensureUnaffiliatedTeam(db, teamLookup)
+			teamID = ensureUnaffiliatedTeam(db, pd, teamLookup)
 		}
 
 		rank := atoi(get(rec, "rank"))
@@ -118,8 +112,46 @@ func seedSeasonStats(db *gorm.DB, cfg seasonStatCfg, seasonID uint, teamLookup m
 		})
 	}
 
-	if len(statsBatch) > 0 {
-		db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(statsBatch, 500)
+	if err := syncSeasonStats(db, pd, cfg, summaryTournament.ID, seasonStatColumns, statsBatch); err != nil {
+		return err
 	}
 	log.Printf("[%s] season stats seeded: %d rows", cfg.GameCode, len(statsBatch))
+	return nil
+}
+
+// seasonStatColumns are the player_tournament_stats columns the CSVs fill.
+var seasonStatColumns = []string{
+	"team_id", "rank", "total_kills", "total_deaths", "kd_ratio", "overall_maps",
+	"hp_kills", "hp_deaths", "hp_kd_ratio", "hp_k_per_map", "hp_maps",
+	"snd_kills", "snd_deaths", "snd_kd_ratio", "snd_k_per_map", "snd_maps",
+	"control_kd_ratio", "control_k_per_map", "control_captures", "control_maps",
+}
+
+// ensureSummaryTournament finds or creates the season's virtual "Season Stats"
+// tournament. It's not a real event — just a DB container so PlayerTournamentStats
+// has a tournament_id.
+func ensureSummaryTournament(db *gorm.DB, pd *phaseDiff, cfg seasonStatCfg, seasonID uint) (models.Tournament, error) {
+	t := models.Tournament{
+		SeasonID:       seasonID,
+		Name:           cfg.Name + " — Season Stats",
+		Slug:           cfg.GameCode + "-season-stats",
+		TournamentType: "season_summary",
+		StartDate:      time.Date(cfg.StartYear, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	err := ensureRow(db, pd, "tournaments", t.Slug+" ("+cfg.GameCode+")", &t, "slug = ? AND season_id = ?", t.Slug, seasonID)
+	return t, err
+}
+
+// syncSeasonStats syncs one season's summary rows; the season owns every row of
+// its summary tournament.
+func syncSeasonStats(db *gorm.DB, pd *phaseDiff, cfg seasonStatCfg, tournamentID uint, columns []string, rows []models.PlayerTournamentStats) error {
+	_, err := syncRows(db, pd, tableSync[models.PlayerTournamentStats]{
+		table: "player_tournament_stats",
+		key: func(st *models.PlayerTournamentStats) string {
+			return fmt.Sprintf("%s/player %d", cfg.GameCode, st.PlayerID)
+		},
+		columns: columns,
+		scope:   func(q *gorm.DB) *gorm.DB { return q.Where("tournament_id = ?", tournamentID) },
+	}, rows)
+	return err
 }
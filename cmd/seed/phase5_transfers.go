@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/csv"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -12,22 +13,30 @@ import (
 	"gorm.io/gorm"
 )
 
-func seedTransfers(db *gorm.DB, teamLookup map[string]uint, playerLookup map[string]uint) {
+// transferColumns are the player_transfers columns the CSVs own; the natural key is
+// (player, date, raw from-team, raw to-team).
+var transferColumns = []string{"from_team_id", "to_team_id", "transfer_type", "role", "game_code", "season"}
+
+// seedTransfers syncs the transfers of every configured era and returns the team
+// resolution report for writeTransferReport.
+func seedTransfers(db *gorm.DB, pd *phaseDiff, teamLookup map[string]uint, playerLookup map[string]uint) (map[string]*unresolvedTeamEntry, error) {
 	report := map[string]*unresolvedTeamEntry{}
-	total := 0
+	var transfers []models.PlayerTransfer
+	var gameCodes []string
 
 	for _, cfg := range transferConfigs {
+		gameCodes = append(gameCodes, cfg.GameCode)
 		rows := readTransferCSV(cfg.File)
 		for _, r := range rows {
-			playerID := resolvePlayer(r.Player, playerLookup, db)
+			playerID := resolvePlayer(r.Player, playerLookup, db, pd)
 			if playerID == 0 {
 				log.Printf("[transfers/%s] WARN: unknown player %q — skipping", cfg.GameCode, r.Player)
 				continue
 			}
 
 			transferDate := parseTransferDate(r.Date)
-			fromID := resolveTransferTeam(r.FromTeam, teamLookup, db, report, transferDate)
-			toID := resolveTransferTeam(r.ToTeam, teamLookup, db, report, transferDate)
+			fromID := resolveTransferTeam(r.FromTeam, teamLookup, db, pd, report, transferDate)
+			toID := resolveTransferTeam(r.ToTeam, teamLookup, db, pd, report, transferDate)
 
 			var fromPtr, toPtr *uint
 			if fromID != 0 {
@@ -37,7 +46,7 @@ func seedTransfers(db *gorm.DB, teamLookup map[string]uint, playerLookup map[str
 				toPtr = &toID
 			}
 
-			xfer := models.PlayerTransfer{
+			transfers = append(transfers, models.PlayerTransfer{
 				PlayerID:        playerID,
 				FromTeamID:      fromPtr,
 				ToTeamID:        toPtr,
@@ -48,23 +57,30 @@ func seedTransfers(db *gorm.DB, teamLookup map[string]uint, playerLookup map[str
 				Season:          cfg.Season,
 				RawFromTeamName: r.FromTeam,
 				RawToTeamName:   r.ToTeam,
-			}
-			db.Where(
-				"player_id = ? AND transfer_date = ? AND raw_from_team_name = ? AND raw_to_team_name = ?",
-				playerID, transferDate, r.FromTeam, r.ToTeam,
-			).FirstOrCreate(&xfer)
-			total++
+			})
 		}
 	}
 
-	log.Printf("Transfers seeded: %d rows", total)
-	writeTransferReport(report)
+	_, err := syncRows(db, pd, tableSync[models.PlayerTransfer]{
+		table: "player_transfers",
+		key: func(x *models.PlayerTransfer) string {
+			return fmt.Sprintf("player %d/%s/%s -> %s", x.PlayerID, x.TransferDate.UTC().Format("2006-01-02"), x.RawFromTeamName, x.RawToTeamName)
+		},
+		columns: transferColumns,
+		scope:   func(q *gorm.DB) *gorm.DB { return q.Where("game_code IN ?", gameCodes) },
+	}, transfers)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Transfers seeded: %d rows", len(transfers))
+	return report, nil
 }
 
 func resolveTransferTeam(
 	rawName string,
 	teamLookup map[string]uint,
 	db *gorm.DB,
+	pd *phaseDiff,
 	report map[string]*unresolvedTeamEntry,
 	date time.Time,
 ) uint {
@@ -96,7 +112,10 @@ func resolveTransferTeam(
 		NeedsManualReview:  true,
 		Source:             "transfer_csv",
 	}
-	db.Where("name = ? AND source = ?", rawName, "transfer_csv").FirstOrCreate(&t)
+	if err := ensureRow(db, pd, "teams", rawName, &t, "name = ? AND source = ?", rawName, "transfer_csv"); err != nil {
+		pd.fail(err)
+		return 0
+	}
 	teamLookup[rawName] = t.ID
 	recordResolution(report, rawName, date, "auto_created", true)
 	return t.ID
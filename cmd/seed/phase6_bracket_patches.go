@@ -13,8 +13,17 @@ package main
 // For each row this phase either:
 //   UPDATE — finds an existing match by (tournament, team pair, scores) and
 //            sets bracket_round + bracket_position to the CSV values.
-//   INSERT — if no match is found, inserts it with a stable dedup key so
-//            re-runs are safe.
+//   INSERT — if no match is found, inserts a stub match keyed
+//            "bracket_patch:<slug>:<round>:<position>" so re-runs are safe.
+// Stubs no CSV row produces any more (usually because phases 2/3 now supply the
+// real match) are deleted, unless something points at them — picks, a thread, maps
+// entered by an admin — in which case they're kept and reported as orphans.
+//
+// The EWC group stages come from enriched_series_matches.csv instead: each group
+// match keeps its round (opening_match, winners_match, …) and takes its group as
+// bracket_position (A=1 … D=4), the layout services/bracket.go draws groups from.
+// This covers the era_finals copies of EWC 2025 series as well as the enriched
+// rows. Group rows that name no existing match are skipped, never stubbed.
 //
 // bracket_edges (nextMatchId relationships for SVG connector lines) are out of
 // scope here and will be handled in a later phase once all bracket data is complete.
@@ -22,6 +31,7 @@ package main
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"gorm.io/gorm"
@@ -47,66 +57,168 @@ var bracketPatchCSVs = []string{
 	"database/cdl_major_brackets.csv",
 }
 
+// ewcGroupCSV holds the EWC series, group-stage rows tagged with group_name.
+const ewcGroupCSV = "database/enriched_series_matches.csv"
+
+// ewcGroupRounds are the group-stage rounds every EWC group plays; their matches are
+// told apart by bracket_position, the group number.
+var ewcGroupRounds = map[string]bool{
+	"opening_match":     true,
+	"winners_match":     true,
+	"elimination_match": true,
+	"decider_match":     true,
+}
+
+// bracketStubColumns are the columns a stub match takes from its CSV row.
+var bracketStubColumns = []string{
+	"tournament_id", "team1_id", "team2_id", "match_date", "team1_score", "team2_score",
+	"winner_id", "bracket_round", "bracket_position",
+}
+
 func seedBracketPatches(
 	db *gorm.DB,
+	pd *phaseDiff,
 	teamLookup map[string]uint,
 	tournamentBySlug map[string]uint,
-) {
-	// Remove any stub matches that a previous Phase 6 run inserted because it
-	// couldn't find the correct Phase 2 match (due to the tournament-date bug).
-	// These stubs have no match_maps or player stats, so deleting them is safe.
-	// Phase 2 now corrects tournament_id in-place, so on re-seed Phase 6 will
-	// find and UPDATE the real match instead of inserting a new stub.
-	result := db.Where("liquipedia_url LIKE ?", "bracket_patch:%").Delete(&models.Match{})
-	log.Printf("[bracket_patches] purged %d stale bracket-patch stub matches", result.RowsAffected)
-
+) error {
 	// Bracket rows carry no game_code, but teams are now split per (name, game)
 	// era — so a multi-era name like "London Royal Ravens" must resolve via the
 	// game of the row's tournament, else it binds to the wrong era's team row and
 	// fails to match the Phase 2 match. Build tournament_id → game_code once.
-	tourGame := tournamentGameCodes(db)
+	tourGame, err := tournamentGameCodes(db)
+	if err != nil {
+		return err
+	}
 
-	var totalUpdated, totalInserted, totalSkipped int
+	var stubs []models.Match
+	var totalUpdated, totalSkipped int
 
 	for _, path := range bracketPatchCSVs {
-		u, i, s := applyBracketCSV(db, teamLookup, tournamentBySlug, tourGame, path)
+		u, s, st, err := applyBracketRows(db, pd, teamLookup, tournamentBySlug, tourGame, readBracketCSV(path), true)
+		if err != nil {
+			return err
+		}
 		totalUpdated += u
-		totalInserted += i
 		totalSkipped += s
+		stubs = append(stubs, st...)
+	}
+	u, s, _, err := applyBracketRows(db, pd, teamLookup, tournamentBySlug, tourGame, ewcGroupRows(readEnrichedSeriesCSV(ewcGroupCSV)), false)
+	if err != nil {
+		return err
+	}
+	totalUpdated += u
+	totalSkipped += s
+
+	_, err = syncRows(db, pd, tableSync[models.Match]{
+		table:      "matches",
+		key:        matchKey,
+		columns:    bracketStubColumns,
+		scope:      func(q *gorm.DB) *gorm.DB { return q.Where("liquipedia_url LIKE ?", "bracket_patch:%") },
+		orphans:    orphansDelete,
+		referenced: matchReferences(allMatchRefs),
+	}, stubs)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("[bracket_patches] total: updated=%d  inserted=%d  skipped=%d",
-		totalUpdated, totalInserted, totalSkipped)
+	log.Printf("[bracket_patches] total: matched=%d  stubs=%d  skipped=%d",
+		totalUpdated, len(stubs), totalSkipped)
+	return nil
 }
 
 // tournamentGameCodes maps each tournament_id to its season's game_code so
 // bracket rows (which lack a game_code column) can resolve teams to the right era.
-func tournamentGameCodes(db *gorm.DB) map[uint]string {
+func tournamentGameCodes(db *gorm.DB) (map[uint]string, error) {
 	type row struct {
 		ID       uint
 		GameCode string
 	}
 	var rows []row
-	db.Table("tournaments").
+	err := db.Table("tournaments").
 		Select("tournaments.id AS id, seasons.game_code AS game_code").
 		Joins("JOIN seasons ON seasons.id = tournaments.season_id").
-		Scan(&rows)
+		Scan(&rows).Error
 	out := make(map[uint]string, len(rows))
 	for _, r := range rows {
 		out[r.ID] = r.GameCode
 	}
-	return out
+	return out, err
 }
 
-func applyBracketCSV(
+// setBracket writes a match's bracket fields, unless an admin or a patch changed the
+// match last; then it reports the conflict instead.
+func setBracket(db *gorm.DB, pd *phaseDiff, m *models.Match, changes map[string][2]any) error {
+	t := pd.table("matches")
+	ids := []any{m.ID}
+	overridden, err := pd.changeLog().Overridden(db, models.ChangeMatch, ids)
+	if err != nil {
+		return err
+	}
+	if actor, ok := overridden[m.ID]; ok {
+		t.Conflicts = append(t.Conflicts, rowConflict{Key: matchKey(m), Actor: actor, Changes: changes})
+		return nil
+	}
+	values := map[string]any{}
+	for col, c := range changes {
+		values[col] = c[1]
+	}
+	err = pd.changeLog().Track(db, models.ChangeMatch, ids, func() error {
+		return db.Model(m).Updates(values).Error
+	})
+	if err != nil {
+		return err
+	}
+	t.Updates = append(t.Updates, rowChange{Key: matchKey(m), Changes: changes})
+	return nil
+}
+
+// ewcGroupRows turns the group-stage rows of the enriched series CSV into bracket
+// rows whose position is the group number. Playoff rows are left out.
+func ewcGroupRows(series []enrichedSeriesRow) []cwBracketRow {
+	var rows []cwBracketRow
+	for _, s := range series {
+		group := ewcGroupNumber(s.GroupName)
+		round := rawRoundToDBRound(s.RoundName)
+		if group == 0 || !ewcGroupRounds[round] {
+			continue
+		}
+		rows = append(rows, cwBracketRow{
+			TournamentSlug:  s.EventSlug,
+			SourceRoundName: s.RoundName,
+			CanonicalRound:  round,
+			Position:        group,
+			Team1Name:       s.Team1Canonical,
+			Team2Name:       s.Team2Canonical,
+			Team1Score:      s.Team1MapWins,
+			Team2Score:      s.Team2MapWins,
+			WinnerName:      s.WinnerCanonical,
+			MatchDate:       s.MatchDatetime,
+		})
+	}
+	return rows
+}
+
+// ewcGroupNumber maps a group_name ("A", "Group B") to its number, 0 for anything else.
+func ewcGroupNumber(name string) int {
+	name = strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "Group")))
+	if len(name) != 1 || name[0] < 'A' || name[0] > 'H' {
+		return 0
+	}
+	return int(name[0]-'A') + 1
+}
+
+// applyBracketRows sets the bracket fields of the matches the rows name. With stub
+// set it returns stub matches for rows that name no existing match; otherwise those
+// rows are skipped.
+func applyBracketRows(
 	db *gorm.DB,
+	pd *phaseDiff,
 	teamLookup map[string]uint,
 	tournamentBySlug map[string]uint,
 	tourGame map[uint]string,
-	path string,
-) (updated, inserted, skipped int) {
-	rows := readBracketCSV(path)
-
+	rows []cwBracketRow,
+	stub bool,
+) (matched, skipped int, stubs []models.Match, err error) {
 	for _, r := range rows {
 		dbSlug := r.TournamentSlug
 		if alias, ok := bracketSlugAliases[r.TournamentSlug]; ok {
@@ -129,36 +241,53 @@ func applyBracketCSV(
 			continue
 		}
 
-		// Match by team pair + scores in either orientation.
+		// Match by team pair + scores in either orientation, never against a stub.
 		// Scores are more reliable than dates because source data sometimes has
 		// off-by-one-day differences due to timezone handling in the era_finals seeder.
-		var existing models.Match
-		err := db.Where(`
+		var existing []models.Match
+		err = db.Where(`
 			tournament_id = ? AND (
 				(team1_id = ? AND team2_id = ? AND team1_score = ? AND team2_score = ?) OR
 				(team1_id = ? AND team2_id = ? AND team1_score = ? AND team2_score = ?)
-			)`,
+			) AND (liquipedia_url IS NULL OR liquipedia_url NOT LIKE ?)`,
 			tournamentID,
 			team1ID, team2ID, r.Team1Score, r.Team2Score,
 			team2ID, team1ID, r.Team2Score, r.Team1Score,
-		).First(&existing).Error
-
-		if err == nil {
-			db.Model(&existing).Updates(map[string]interface{}{
-				"bracket_round":    r.CanonicalRound,
-				"bracket_position": r.Position,
-			})
-			updated++
+			"bracket_patch:%",
+		).Order("id").Limit(1).Find(&existing).Error
+		if err != nil {
+			return
+		}
+
+		if len(existing) == 1 {
+			m := &existing[0]
+			changes := map[string][2]any{}
+			if m.BracketRound != r.CanonicalRound {
+				changes["bracket_round"] = [2]any{m.BracketRound, r.CanonicalRound}
+			}
+			if m.BracketPosition != r.Position {
+				changes["bracket_position"] = [2]any{m.BracketPosition, r.Position}
+			}
+			if len(changes) > 0 {
+				if err = setBracket(db, pd, m, changes); err != nil {
+					return
+				}
+			}
+			matched++
 			continue
 		}
 
-		// Not found — insert with a stable dedup key so re-runs are idempotent.
+		if !stub {
+			log.Printf("[bracket_patches] WARN: no match for %s %s %s vs %s (%d-%d) — skipping",
+				dbSlug, r.CanonicalRound, r.Team1Name, r.Team2Name, r.Team1Score, r.Team2Score)
+			skipped++
+			continue
+		}
 		var winnerID *uint
 		if wid := resolveTeamID(teamLookup, r.WinnerName, gameCode); wid != 0 {
 			winnerID = &wid
 		}
-		dedupKey := fmt.Sprintf("bracket_patch:%s:%s:%d", dbSlug, r.CanonicalRound, r.Position)
-		m := models.Match{
+		stubs = append(stubs, models.Match{
 			TournamentID:    tournamentID,
 			Team1ID:         team1ID,
 			Team2ID:         team2ID,
@@ -168,10 +297,8 @@ func applyBracketCSV(
 			WinnerID:        winnerID,
 			BracketRound:    r.CanonicalRound,
 			BracketPosition: r.Position,
-			LiquipediaURL:   dedupKey,
-		}
-		db.Where("liquipedia_url = ?", dedupKey).FirstOrCreate(&m)
-		inserted++
+			LiquipediaURL:   fmt.Sprintf("bracket_patch:%s:%s:%d", dbSlug, r.CanonicalRound, r.Position),
+		})
 	}
 
 	return
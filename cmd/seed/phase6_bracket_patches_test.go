@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEWCGroupRows(t *testing.T) {
+	rows := ewcGroupRows([]enrichedSeriesRow{
+		{EventSlug: "esports-world-cup-2024", GroupName: "A", RoundName: "Opening Match", Team1Canonical: "OpTic Texas", Team2Canonical: "Carolina Royal Ravens", Team1MapWins: 3, Team2MapWins: 1},
+		{EventSlug: "esports-world-cup-2024", GroupName: "Group C", RoundName: "Decider Match", Team1Canonical: "Toronto Koi", Team2Canonical: "Boston Breach", Team1MapWins: 2, Team2MapWins: 3},
+		{EventSlug: "esports-world-cup-2024", RoundName: "Semifinal", Team1Canonical: "OpTic Texas", Team2Canonical: "Toronto Koi"},
+		{EventSlug: "esports-world-cup-2024", GroupName: "Z", RoundName: "Opening Match"},
+	})
+	require.Len(t, rows, 2, "playoff rows and unknown groups are left out")
+	assert.Equal(t, "opening_match", rows[0].CanonicalRound)
+	assert.Equal(t, 1, rows[0].Position)
+	assert.Equal(t, "decider_match", rows[1].CanonicalRound)
+	assert.Equal(t, 3, rows[1].Position)
+	assert.Equal(t, 2, rows[1].Team1Score)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
@@ -42,16 +43,12 @@ func inferRosterStints(db *gorm.DB) ([]rosterStint, error) {
 	return stints, err
 }
 
-func seedRosters(db *gorm.DB) {
+// seedRosters syncs team_rosters with the inferred stints. The table is derived
+// wholesale from player_map_stats, so stints that no longer appear are deleted.
+func seedRosters(db *gorm.DB, pd *phaseDiff) error {
 	stints, err := inferRosterStints(db)
 	if err != nil {
-		log.Printf("roster inference failed: %v", err)
-		return
-	}
-
-	if err := db.Exec("DELETE FROM team_rosters").Error; err != nil {
-		log.Printf("failed to clear team_rosters: %v", err)
-		return
+		return fmt.Errorf("roster inference failed: %w", err)
 	}
 
 	rosters := make([]models.TeamRoster, 0, len(stints))
@@ -72,11 +69,17 @@ func seedRosters(db *gorm.DB) {
 		rosters = append(rosters, r)
 	}
 
-	if len(rosters) > 0 {
-		if err := db.CreateInBatches(rosters, 500).Error; err != nil {
-			log.Printf("failed to insert team_rosters: %v", err)
-			return
-		}
+	_, err = syncRows(db, pd, tableSync[models.TeamRoster]{
+		table: "team_rosters",
+		key: func(r *models.TeamRoster) string {
+			return fmt.Sprintf("team %d/player %d/season %d", r.TeamID, r.PlayerID, r.SeasonID)
+		},
+		columns: []string{"start_date", "end_date", "is_starter"},
+		orphans: orphansDelete,
+	}, rosters)
+	if err != nil {
+		return err
 	}
 	log.Printf("rosters inferred: %d stints", len(rosters))
+	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/corbynfang/CDL-Website/internal/database"
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -58,18 +59,8 @@ func runRosterTests(m *testing.M) int {
 		return m.Run()
 	}
 
-	if err = db.AutoMigrate(
-		&models.Franchise{},
-		&models.Season{},
-		&models.Team{},
-		&models.Player{},
-		&models.Tournament{},
-		&models.Match{},
-		&models.MatchMap{},
-		&models.PlayerMapStats{},
-		&models.TeamRoster{},
-	); err != nil {
-		log.Println("gorm: automigrate failed:", err)
+	if err = database.Migrate(db); err != nil {
+		log.Println("migrate failed:", err)
 		return m.Run()
 	}
 
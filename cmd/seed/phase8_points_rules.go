@@ -0,0 +1,70 @@
+package main
+
+// phase8_points_rules.go — default CDL points schema per era (season_points_rules
+// rows with no season_id). A season that needs different rules gets its own rows
+// with season_id set; those replace the defaults and are never touched here.
+//
+// Defaults: every series won at a qualifier is worth qualifierWinPoints, and each
+// Major pays out by final placement from majorPlacementPoints. Champs awards none.
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+)
+
+const qualifierWinPoints = 10
+
+// majorPlacementPoints is {from, to, points}; tied placements share a band.
+var majorPlacementPoints = [][3]int{
+	{1, 1, 100},
+	{2, 2, 80},
+	{3, 3, 65},
+	{4, 4, 50},
+	{5, 6, 40},
+	{7, 8, 30},
+	{9, 10, 20},
+	{11, 12, 10},
+}
+
+func defaultPointsRules(gameCode string) []models.SeasonPointsRule {
+	rules := []models.SeasonPointsRule{{
+		GameCode:       gameCode,
+		TournamentType: "qualifier",
+		Basis:          models.PointsBasisMatchWin,
+		Points:         qualifierWinPoints,
+	}}
+	for _, p := range majorPlacementPoints {
+		rules = append(rules, models.SeasonPointsRule{
+			GameCode:       gameCode,
+			TournamentType: "major_tournament",
+			Basis:          models.PointsBasisPlacement,
+			PlacementFrom:  p[0],
+			PlacementTo:    p[1],
+			Points:         p[2],
+		})
+	}
+	return rules
+}
+
+func seedPointsRules(db *gorm.DB, pd *phaseDiff, seasonByCode map[string]uint) error {
+	var rules []models.SeasonPointsRule
+	for code := range seasonByCode {
+		rules = append(rules, defaultPointsRules(code)...)
+	}
+	_, err := syncRows(db, pd, tableSync[models.SeasonPointsRule]{
+		table: "season_points_rules",
+		key: func(r *models.SeasonPointsRule) string {
+			return fmt.Sprintf("%s/%s/%s/%d", r.GameCode, r.TournamentType, r.Basis, r.PlacementFrom)
+		},
+		columns: []string{"placement_to", "points"},
+		scope:   func(q *gorm.DB) *gorm.DB { return q.Where("season_id IS NULL") },
+	}, rules)
+	if err != nil {
+		return err
+	}
+	log.Printf("Points rules seeded: %d", len(rules))
+	return nil
+}
@@ -0,0 +1,268 @@
+package main
+
+// report.go — phase transactions and the diff report.
+//
+// Every phase runs in its own transaction and records what it changed in a
+// phaseDiff. A failed phase is rolled back and the phases after it are skipped,
+// since each one builds on the last. Writes to the change-logged tables (matches,
+// maps, stat lines, teams, players, rosters, transfers, season totals) go into
+// change_log under the seed actor, and a phase ends by rebuilding the derived stats
+// of the matches it touched. With -dry-run the whole run happens inside one
+// transaction that is rolled back at the end (each phase in a savepoint), so later
+// phases still see what earlier ones would have written and the report is exact.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
+)
+
+// reportDetail is how many keys per table and kind the printed report lists;
+// -report writes all of them.
+const reportDetail = 10
+
+// seedReport is the diff of one seeder run, phase by phase.
+type seedReport struct {
+	DryRun bool         `json:"dry_run"`
+	Prune  bool         `json:"prune"`
+	Phases []*phaseDiff `json:"phases"`
+}
+
+type phaseDiff struct {
+	Phase  string       `json:"phase"`
+	Error  string       `json:"error,omitempty"`
+	Tables []*tableDiff `json:"tables"`
+
+	prune   bool
+	err     error
+	changes *store.SeedLog
+}
+
+// tableDiff lists rows by natural key. Deletes are orphans that were removed;
+// Orphans are the ones kept. Conflicts are updates and deletes that were skipped
+// because someone else changed the row last.
+type tableDiff struct {
+	Table     string        `json:"table"`
+	Inserts   []string      `json:"inserts"`
+	Updates   []rowChange   `json:"updates"`
+	Deletes   []string      `json:"deletes"`
+	Orphans   []string      `json:"orphans"`
+	Conflicts []rowConflict `json:"conflicts"`
+}
+
+// rowChange maps each changed column to its [before, after] values.
+type rowChange struct {
+	Key     string            `json:"key"`
+	Changes map[string][2]any `json:"changes"`
+}
+
+// rowConflict is a row whose latest change-log entry is an admin correction or a
+// data patch, with the update the seeder left unapplied (none for a kept orphan).
+type rowConflict struct {
+	Key     string            `json:"key"`
+	Actor   string            `json:"actor"`
+	Changes map[string][2]any `json:"changes,omitempty"`
+}
+
+func (pd *phaseDiff) table(name string) *tableDiff {
+	for _, t := range pd.Tables {
+		if t.Table == name {
+			return t
+		}
+	}
+	t := &tableDiff{
+		Table: name, Inserts: []string{}, Updates: []rowChange{}, Deletes: []string{}, Orphans: []string{},
+		Conflicts: []rowConflict{},
+	}
+	pd.Tables = append(pd.Tables, t)
+	return t
+}
+
+// changeLog is where the phase records its writes to change-logged tables.
+func (pd *phaseDiff) changeLog() *store.SeedLog {
+	if pd.changes == nil {
+		pd.changes = store.NewSeedLog("seed: " + pd.Phase)
+	}
+	return pd.changes
+}
+
+// fail records the first error from a helper that can't return one; the phase is
+// rolled back when it finishes.
+func (pd *phaseDiff) fail(err error) {
+	if err != nil && pd.err == nil {
+		pd.err = err
+	}
+}
+
+type seedRun struct {
+	db     *gorm.DB
+	report seedReport
+	failed bool
+	rerate bool // a phase changed match rows, so team ratings are stale
+}
+
+func newSeedRun(db *gorm.DB, dryRun, prune bool) *seedRun {
+	if dryRun {
+		db = db.Begin()
+	}
+	return &seedRun{db: db, report: seedReport{DryRun: dryRun, Prune: prune}}
+}
+
+// phase runs fn in a transaction of its own, rebuilds the derived stats of the
+// matches it touched and adds its diff to the report.
+func (r *seedRun) phase(name string, fn func(tx *gorm.DB, pd *phaseDiff) error) {
+	if r.failed {
+		return
+	}
+	pd := &phaseDiff{Phase: name, Tables: []*tableDiff{}, prune: r.report.Prune}
+	r.report.Phases = append(r.report.Phases, pd)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx, pd); err != nil {
+			return err
+		}
+		if pd.err != nil {
+			return pd.err
+		}
+		if pd.changes == nil || pd.changes.TouchedMatches() == 0 {
+			return nil
+		}
+		log.Printf("[%s] rebuilding derived stats for %d matches", name, pd.changes.TouchedMatches())
+		if err := pd.changes.Rebuild(tx); err != nil {
+			return fmt.Errorf("rebuilding derived stats: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		pd.Error = err.Error()
+		r.failed = true
+		log.Printf("[%s] failed, phase rolled back: %v", name, err)
+		return
+	}
+	if pd.changes != nil && pd.changes.TouchedMatches() > 0 {
+		r.rerate = true
+	}
+}
+
+// finish rolls back a dry run, prints the report and writes it as JSON when asked.
+// It reports whether every phase succeeded.
+func (r *seedRun) finish(reportPath string) bool {
+	if r.report.DryRun {
+		r.db.Rollback()
+	}
+	r.report.print(os.Stdout)
+	if reportPath != "" {
+		if err := r.report.write(reportPath); err != nil {
+			log.Printf("WARN: could not write report: %v", err)
+		} else {
+			log.Printf("Seed report written: %s", reportPath)
+		}
+	}
+	return !r.failed
+}
+
+func (r *seedReport) write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (r *seedReport) print(w io.Writer) {
+	var inserts, updates, deletes, orphans, conflicts int
+	for _, pd := range r.Phases {
+		status := ""
+		if pd.Error != "" {
+			status = " — FAILED, rolled back: " + pd.Error
+		}
+		fmt.Fprintf(w, "==> %s%s\n", pd.Phase, status)
+		for _, t := range pd.Tables {
+			line := fmt.Sprintf("  %-24s +%d  ~%d  -%d  orphans %d", t.Table, len(t.Inserts), len(t.Updates), len(t.Deletes), len(t.Orphans))
+			if len(t.Conflicts) > 0 {
+				line += fmt.Sprintf("  conflicts %d", len(t.Conflicts))
+			}
+			fmt.Fprintln(w, line)
+			for i, u := range t.Updates {
+				if i == reportDetail {
+					fmt.Fprintf(w, "    ~ … and %d more\n", len(t.Updates)-i)
+					break
+				}
+				fmt.Fprintf(w, "    ~ %s: %s\n", u.Key, formatChanges(u.Changes))
+			}
+			printKeys(w, "-", t.Deletes)
+			printKeys(w, "?", t.Orphans)
+			for i, c := range t.Conflicts {
+				if i == reportDetail {
+					fmt.Fprintf(w, "    ! … and %d more\n", len(t.Conflicts)-i)
+					break
+				}
+				kept := "not deleted"
+				if c.Changes != nil {
+					kept = "not applied: " + formatChanges(c.Changes)
+				}
+				fmt.Fprintf(w, "    ! %s: changed by %s, %s\n", c.Key, c.Actor, kept)
+			}
+			if pd.Error == "" {
+				inserts += len(t.Inserts)
+				updates += len(t.Updates)
+				deletes += len(t.Deletes)
+				orphans += len(t.Orphans)
+				conflicts += len(t.Conflicts)
+			}
+		}
+	}
+	summary := fmt.Sprintf("==> Seed diff: %d inserted, %d updated, %d deleted, %d orphans kept", inserts, updates, deletes, orphans)
+	if conflicts > 0 {
+		summary += fmt.Sprintf(", %d rows left as an admin or patch changed them", conflicts)
+	}
+	if r.DryRun {
+		summary += " (dry run, nothing changed)"
+	}
+	if orphans > 0 && !r.Prune {
+		summary += "; -prune deletes orphans"
+	}
+	fmt.Fprintln(w, summary)
+}
+
+func printKeys(w io.Writer, mark string, keys []string) {
+	for i, k := range keys {
+		if i == reportDetail {
+			fmt.Fprintf(w, "    %s … and %d more\n", mark, len(keys)-i)
+			return
+		}
+		fmt.Fprintf(w, "    %s %s\n", mark, k)
+	}
+}
+
+func formatChanges(changes map[string][2]any) string {
+	cols := make([]string, 0, len(changes))
+	for col := range changes {
+		cols = append(cols, col)
+	}
+	slices.Sort(cols)
+	parts := make([]string, len(cols))
+	for i, col := range cols {
+		parts[i] = fmt.Sprintf("%s %s -> %s", col, formatValue(changes[col][0]), formatValue(changes[col][1]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
@@ -2,11 +2,12 @@ package main
 
 // reset.go — guarded "reset then seed" path.
 //
-// The seeder is additive (FirstOrCreate / OnConflict DoNothing), so re-running it
-// over an existing database leaves stale rows in place — most importantly the old
-// merged team rows and every fact already pointed at their IDs (match_maps,
-// player_map_stats, player_match_stats, rosters, ...). Re-pointing those in place
-// is fragile; the reliable fix is a clean re-seed from empty.
+// A normal run syncs the database with the CSVs by natural key (see diff.go), but a
+// row whose natural key itself changed — the old merged team rows, say, and every
+// fact already pointed at their IDs (match_maps, player_map_stats,
+// player_match_stats, rosters, ...) — shows up as an orphan plus a new row rather
+// than being re-pointed. When that's more than -prune can untangle, the reliable
+// fix is a clean re-seed from empty.
 //
 // resetSeedTables wipes every table the seeder owns and resets their identity
 // sequences, so a subsequent seed run produces correct per-era team rows and links.
@@ -45,6 +46,10 @@ var resetTables = []string{
 	"players",
 	"seasons",
 	"franchises",
+	// The change log names rows by ID, and the IDs restart: old entries would
+	// describe (and, as admin changes, protect) whatever row gets the ID next.
+	"change_log",
+	"pending_rollups",
 }
 
 // resetSeedTables truncates every seeder-owned table in a single statement and
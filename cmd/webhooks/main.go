@@ -0,0 +1,68 @@
+package main
+
+// main.go — outbound webhook worker.
+//
+// Every -interval it records match.completed, map.completed, transfer.created and
+// tournament.updated events for whatever changed in the last day into the
+// webhook_events outbox, queues them for each active subscription that wants them
+// and sends due deliveries, signed with the subscription's secret. Failures are
+// retried with exponential backoff and every attempt is logged. -once runs a single
+// pass and exits; -allow-private lets subscriptions reach local stand-ins.
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/notify"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+func main() {
+	once := flag.Bool("once", false, "run one pass and exit")
+	interval := flag.Duration("interval", 30*time.Second, "time between passes")
+	allowPrivate := flag.Bool("allow-private", false, "let deliveries reach loopback and private addresses")
+	flag.Parse()
+
+	database.ConnectDatabase()
+	defer database.CloseDatabase()
+	database.RequireSchema()
+
+	worker := services.NewWebhookWorker(
+		store.NewGormWebhookStore(database.DB),
+		notify.NewPoster(10*time.Second, *allowPrivate),
+		services.DefaultWebhookWorkerConfig(),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		passCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+		run, err := worker.RunOnce(passCtx)
+		cancel()
+		if err != nil {
+			log.Printf("webhook pass failed: %v", err)
+		} else if run != (services.WebhookRun{}) {
+			log.Printf("==> Webhooks: %d events, %d queued, %d sent, %d retrying, %d failed",
+				run.Collected, run.Queued, run.Sent, run.Retried, run.Failed)
+		}
+		if *once {
+			if err != nil {
+				os.Exit(1)
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(*interval):
+		}
+	}
+}
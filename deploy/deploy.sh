@@ -69,6 +69,37 @@ NEW_TASK_ARN=$(aws ecs register-task-definition \
 
 echo "==> New task definition: $NEW_TASK_ARN"
 
+# The new image refuses to start on a schema it doesn't expect, so apply pending
+# migrations before rolling the service onto it.
+echo "==> Running schema migrations (one-off ECS task)..."
+MIGRATE_TASK_ARN=$(aws ecs run-task \
+  --region "$REGION" \
+  --cluster "$ECS_CLUSTER" \
+  --task-definition "$NEW_TASK_ARN" \
+  --launch-type FARGATE \
+  --network-configuration "awsvpcConfiguration={subnets=[${SUBNETS}],securityGroups=[${TASK_SG}],assignPublicIp=ENABLED}" \
+  --overrides "{\"containerOverrides\":[{\"name\":\"cdl-api\",\"command\":[\"./migrate\",\"up\"]}]}" \
+  --query "tasks[0].taskArn" \
+  --output text)
+
+aws ecs wait tasks-stopped \
+  --region "$REGION" \
+  --cluster "$ECS_CLUSTER" \
+  --tasks "$MIGRATE_TASK_ARN"
+
+EXIT_CODE=$(aws ecs describe-tasks \
+  --region "$REGION" \
+  --cluster "$ECS_CLUSTER" \
+  --tasks "$MIGRATE_TASK_ARN" \
+  --query "tasks[0].containers[0].exitCode" \
+  --output text)
+
+if [ "$EXIT_CODE" != "0" ]; then
+  echo "ERROR: Migrations exited with code $EXIT_CODE — check CloudWatch logs at /ecs/cdl-api"
+  exit 1
+fi
+echo "==> Schema is up to date."
+
 echo "==> Updating ECS service..."
 aws ecs update-service \
   --region "$REGION" \
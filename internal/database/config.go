@@ -6,7 +6,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/corbynfang/CDL-Website/internal/models"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -80,37 +79,3 @@ func CloseDatabase() {
 	}
 	sqlDB.Close()
 }
-
-func AutoMigrate() {
-	err := DB.AutoMigrate(
-		&models.Franchise{},
-		&models.Season{},
-		&models.Team{},
-		&models.Player{},
-		&models.TeamRoster{},
-		&models.Tournament{},
-		&models.Match{},
-		&models.MatchMap{},
-		&models.PlayerMapStats{},
-		&models.PlayerMatchStats{},
-		&models.PlayerTournamentStats{},
-		&models.TeamTournamentStats{},
-		&models.Coach{},
-		&models.PlayerTransfer{},
-		&models.User{},
-		&models.MatchThread{},
-		&models.ThreadPost{},
-	)
-
-	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
-	}
-
-	DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm")
-	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_players_gamertag_trgm
-		ON players USING gin (gamertag gin_trgm_ops)`)
-	DB.Exec(`CREATE INDEX IF NOT EXISTS idx_tournaments_season_type
-		ON tournaments (season_id, tournament_type)`)
-
-	log.Println("Database migration completed")
-}
@@ -0,0 +1,334 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The schema is versioned by the SQL files in migrations/: NNNN_name.up.sql and a
+// matching NNNN_name.down.sql per version, applied in version order and recorded in
+// schema_migrations with a checksum of the up script. A migration runs in one
+// transaction unless its up script starts with "-- migrate:no-transaction" (for
+// statements like CREATE INDEX CONCURRENTLY). Applied migrations are history: edit
+// one and the schema counts as changed until it is put back.
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var ErrSchemaBehind = errors.New("database schema is behind")
+var ErrMigrationChanged = errors.New("applied migration has changed")
+
+// noTransaction marks a migration that must run outside a transaction.
+const noTransaction = "-- migrate:no-transaction"
+
+// migrationLock is the advisory lock key held while migrating, so two deploys can't
+// run the same migration at once.
+const migrationLock = 4_207_116
+
+const createSchemaMigrations = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    bigint PRIMARY KEY,
+	name       varchar(200) NOT NULL,
+	checksum   varchar(64) NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`
+
+// Migration is one schema version.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+	NoTx     bool
+}
+
+// SchemaMigration is a row of schema_migrations.
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string { return "schema_migrations" }
+
+// MigrationState is one version as status reports it. Changed means it was applied
+// from a different up script; Missing means it was applied but this build has no
+// such file (the database is ahead of the code).
+type MigrationState struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	Changed   bool
+	Missing   bool
+}
+
+var migrationName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// loadMigrations reads every migration in dir, checking each version has both halves
+// and a single name.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.up.sql or NNNN_name.down.sql", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		if version == 0 {
+			return nil, fmt.Errorf("migration %s: versions start at 1", e.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(data)
+			sum := sha256.Sum256(data)
+			mig.Checksum = hex.EncodeToString(sum[:])
+			mig.NoTx = strings.HasPrefix(strings.TrimSpace(mig.Up), noTransaction)
+		} else {
+			mig.Down = string(data)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Checksum == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Migrator applies and rolls back the embedded migrations.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest is the newest version this build knows.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int]SchemaMigration, error) {
+	if err := db.Exec(createSchemaMigrations).Error; err != nil {
+		return nil, err
+	}
+	var rows []SchemaMigration
+	if err := db.Order("version ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]SchemaMigration, len(rows))
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// Status lists every known and every applied version, oldest first.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationState, error) {
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := MigrationState{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &row.AppliedAt
+			s.Changed = row.Checksum != mig.Checksum
+			delete(applied, mig.Version)
+		}
+		states = append(states, s)
+	}
+	for _, row := range applied {
+		states = append(states, MigrationState{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt, Missing: true})
+	}
+	slices.SortFunc(states, func(a, b MigrationState) int { return a.Version - b.Version })
+	return states, nil
+}
+
+// Check fails with ErrSchemaBehind when a known migration hasn't been applied and
+// with ErrMigrationChanged when one was applied from a different script. A database
+// ahead of this build passes: older code keeps running during a deploy.
+func (m *Migrator) Check(ctx context.Context) error {
+	states, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []string
+	for _, s := range states {
+		switch {
+		case s.Changed:
+			return fmt.Errorf("%w: %04d_%s", ErrMigrationChanged, s.Version, s.Name)
+		case s.AppliedAt == nil:
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s not applied; run cmd/migrate up", ErrSchemaBehind, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up applies pending migrations in order, up to and including target (0 for all),
+// and returns the ones it ran.
+func (m *Migrator) Up(ctx context.Context, target int) ([]Migration, error) {
+	var ran []Migration
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if row, ok := applied[mig.Version]; ok && row.Checksum != mig.Checksum {
+				return fmt.Errorf("%w: %04d_%s", ErrMigrationChanged, mig.Version, mig.Name)
+			}
+		}
+		for _, mig := range m.migrations {
+			if target > 0 && mig.Version > target {
+				break
+			}
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			err := m.run(ctx, conn, mig, mig.Up, func(tx *gorm.DB) error {
+				return tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down rolls back the newest steps applied migrations and returns them, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var ran []Migration
+	err := m.locked(ctx, func(conn *gorm.DB) error {
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+		for _, v := range versions[:min(steps, len(versions))] {
+			i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == v })
+			if i < 0 {
+				return fmt.Errorf("migration %04d_%s was applied by a newer build; roll it back from there", v, applied[v].Name)
+			}
+			mig := m.migrations[i]
+			err := m.run(ctx, conn, mig, mig.Down, func(tx *gorm.DB) error {
+				return tx.Where("version = ?", mig.Version).Delete(&SchemaMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rolling back %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// locked runs fn on one connection holding the migration advisory lock.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLock).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLock)
+		return fn(conn)
+	})
+}
+
+// run executes one script and its schema_migrations bookkeeping, together in a
+// transaction unless the migration opts out. Scripts go straight to the driver so
+// "?" and "@" in SQL aren't taken for parameters.
+func (m *Migrator) run(ctx context.Context, conn *gorm.DB, mig Migration, script string, record func(tx *gorm.DB) error) error {
+	if mig.NoTx {
+		if _, err := conn.Statement.ConnPool.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		return record(conn)
+	}
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if _, err := tx.Statement.ConnPool.ExecContext(ctx, script); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+// Migrate applies every pending migration, for tests and first-time setup.
+func Migrate(db *gorm.DB) error {
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up(context.Background(), 0)
+	return err
+}
+
+// RequireSchema stops the process unless every migration this build knows has been
+// applied, so code never runs against a schema it doesn't expect.
+func RequireSchema() {
+	m, err := NewMigrator(DB)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := m.Check(ctx); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Database schema at version %d", m.Latest())
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_rename_vod.up.sql":      {Data: []byte("-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY x ON t (c);")},
+		"m/0002_rename_vod.down.sql":    {Data: []byte("DROP INDEX x;")},
+		"m/0001_baseline.up.sql":        {Data: []byte("CREATE TABLE t (c int);")},
+		"m/0001_baseline.down.sql":      {Data: []byte("DROP TABLE t;")},
+		"m/0010_later_version.up.sql":   {Data: []byte("SELECT 1;")},
+		"m/0010_later_version.down.sql": {Data: []byte("SELECT 1;")},
+	}
+	migrations, err := loadMigrations(fsys, "m")
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, []int{1, 2, 10}, []int{migrations[0].Version, migrations[1].Version, migrations[2].Version})
+	assert.Equal(t, "baseline", migrations[0].Name)
+	assert.False(t, migrations[0].NoTx)
+	assert.True(t, migrations[1].NoTx)
+	assert.Len(t, migrations[0].Checksum, 64)
+	assert.NotEqual(t, migrations[0].Checksum, migrations[2].Checksum)
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing down": {"m/0001_a.up.sql": {Data: []byte("SELECT 1;")}},
+		"missing up":   {"m/0001_a.down.sql": {Data: []byte("SELECT 1;")}},
+		"two names": {
+			"m/0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"m/0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"bad name":  {"m/add_column.sql": {Data: []byte("SELECT 1;")}},
+		"version 0": {"m/0000_a.up.sql": {Data: []byte("SELECT 1;")}, "m/0000_a.down.sql": {Data: []byte("SELECT 1;")}},
+	} {
+		_, err := loadMigrations(fsys, "m")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := NewMigrator(nil)
+	require.NoError(t, err)
+	require.NotZero(t, m.Latest())
+	assert.Equal(t, 1, m.migrations[0].Version, "the baseline comes first")
+}
@@ -0,0 +1,19 @@
+-- Drops every table the baseline created, dependents first.
+
+DROP TABLE IF EXISTS "thread_posts";
+DROP TABLE IF EXISTS "match_threads";
+DROP TABLE IF EXISTS "users";
+DROP TABLE IF EXISTS "player_transfers";
+DROP TABLE IF EXISTS "coaches";
+DROP TABLE IF EXISTS "team_tournament_stats";
+DROP TABLE IF EXISTS "player_tournament_stats";
+DROP TABLE IF EXISTS "player_match_stats";
+DROP TABLE IF EXISTS "player_map_stats";
+DROP TABLE IF EXISTS "match_maps";
+DROP TABLE IF EXISTS "matches";
+DROP TABLE IF EXISTS "tournaments";
+DROP TABLE IF EXISTS "team_rosters";
+DROP TABLE IF EXISTS "players";
+DROP TABLE IF EXISTS "teams";
+DROP TABLE IF EXISTS "seasons";
+DROP TABLE IF EXISTS "franchises";
@@ -0,0 +1,369 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations. Every
+-- statement is IF NOT EXISTS so databases created by AutoMigrate adopt it as is.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS "franchises" (
+	"id" bigserial,
+	"franchise_key" varchar(100) NOT NULL,
+	"name" varchar(200) NOT NULL,
+	"is_active" boolean DEFAULT true,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_franchises_franchise_key" ON "franchises" ("franchise_key");
+
+CREATE TABLE IF NOT EXISTS "seasons" (
+	"id" bigserial,
+	"name" varchar(100) NOT NULL,
+	"game_title" varchar(100) NOT NULL,
+	"game_code" varchar(10),
+	"start_date" timestamptz,
+	"end_date" timestamptz,
+	"is_active" boolean DEFAULT false,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "teams" (
+	"id" bigserial,
+	"name" varchar(200) NOT NULL,
+	"abbreviation" varchar(10) NOT NULL,
+	"city" varchar(100),
+	"logo_url" text,
+	"primary_color" varchar(7),
+	"secondary_color" varchar(7),
+	"founded_date" timestamptz,
+	"is_active" boolean DEFAULT true,
+	"franchise_id" bigint,
+	"game_code" varchar(10),
+	"is_cdl_franchise" boolean DEFAULT false,
+	"team_classification" varchar(60),
+	"do_not_merge" boolean DEFAULT false,
+	"valid_from" timestamptz,
+	"valid_to" timestamptz,
+	"needs_manual_review" boolean DEFAULT false,
+	"source" varchar(100),
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_teams_franchise" FOREIGN KEY ("franchise_id") REFERENCES "franchises"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_teams_franchise_id" ON "teams" ("franchise_id");
+CREATE INDEX IF NOT EXISTS "idx_teams_is_cdl_franchise" ON "teams" ("is_cdl_franchise");
+
+CREATE TABLE IF NOT EXISTS "players" (
+	"id" bigserial,
+	"gamertag" varchar(100) NOT NULL,
+	"first_name" varchar(100),
+	"last_name" varchar(100),
+	"country" varchar(3),
+	"birthdate" timestamptz,
+	"role" varchar(50),
+	"is_active" boolean DEFAULT true,
+	"liquipedia_url" text,
+	"twitter_handle" varchar(100),
+	"avatar_url" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_players_gamertag" ON "players" ("gamertag");
+
+CREATE TABLE IF NOT EXISTS "team_rosters" (
+	"id" bigserial,
+	"team_id" bigint,
+	"player_id" bigint,
+	"season_id" bigint,
+	"role" varchar(50),
+	"start_date" timestamptz,
+	"end_date" timestamptz,
+	"is_starter" boolean DEFAULT true,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_team_rosters_player" FOREIGN KEY ("player_id") REFERENCES "players"("id"),
+	CONSTRAINT "fk_team_rosters_season" FOREIGN KEY ("season_id") REFERENCES "seasons"("id"),
+	CONSTRAINT "fk_team_rosters_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_rosters_season_id" ON "team_rosters" ("season_id");
+CREATE INDEX IF NOT EXISTS "idx_team_rosters_player_id" ON "team_rosters" ("player_id");
+CREATE INDEX IF NOT EXISTS "idx_team_rosters_team_id" ON "team_rosters" ("team_id");
+
+CREATE TABLE IF NOT EXISTS "tournaments" (
+	"id" bigserial,
+	"season_id" bigint,
+	"name" varchar(200) NOT NULL,
+	"slug" varchar(200),
+	"tournament_type" varchar(50),
+	"start_date" timestamptz,
+	"end_date" timestamptz,
+	"prize_pool" decimal(12,2),
+	"location" varchar(200),
+	"country" varchar(3),
+	"is_lan" boolean DEFAULT false,
+	"logo_url" varchar(500),
+	"tournament_format" varchar(50),
+	"liquipedia_url" text,
+	"breaking_point_url" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_tournaments_season" FOREIGN KEY ("season_id") REFERENCES "seasons"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_tournaments_slug" ON "tournaments" ("slug");
+CREATE INDEX IF NOT EXISTS "idx_tournaments_season_id" ON "tournaments" ("season_id");
+
+CREATE TABLE IF NOT EXISTS "matches" (
+	"id" bigserial,
+	"tournament_id" bigint,
+	"team1_id" bigint,
+	"team2_id" bigint,
+	"match_date" timestamptz,
+	"match_type" varchar(50),
+	"format" varchar(20),
+	"team1_score" bigint DEFAULT 0,
+	"team2_score" bigint DEFAULT 0,
+	"winner_id" bigint,
+	"duration_mins" bigint,
+	"vod_url" text,
+	"liquipedia_url" text,
+	"breaking_point_match_id" bigint,
+	"bracket_round" varchar(50),
+	"bracket_position" bigint DEFAULT 0,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_matches_team1" FOREIGN KEY ("team1_id") REFERENCES "teams"("id"),
+	CONSTRAINT "fk_matches_team2" FOREIGN KEY ("team2_id") REFERENCES "teams"("id"),
+	CONSTRAINT "fk_matches_tournament" FOREIGN KEY ("tournament_id") REFERENCES "tournaments"("id"),
+	CONSTRAINT "fk_matches_winner" FOREIGN KEY ("winner_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_matches_team2_id" ON "matches" ("team2_id");
+CREATE INDEX IF NOT EXISTS "idx_matches_team1_id" ON "matches" ("team1_id");
+CREATE INDEX IF NOT EXISTS "idx_matches_tournament_id" ON "matches" ("tournament_id");
+
+CREATE TABLE IF NOT EXISTS "match_maps" (
+	"id" bigserial,
+	"match_id" bigint NOT NULL,
+	"map_number" bigint NOT NULL,
+	"map_name" varchar(100),
+	"mode" varchar(50),
+	"score1" bigint DEFAULT 0,
+	"score2" bigint DEFAULT 0,
+	"winner_id" bigint,
+	"played" boolean,
+	"duration_sec" bigint DEFAULT 0,
+	"source" varchar(50),
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_match_maps_match" FOREIGN KEY ("match_id") REFERENCES "matches"("id"),
+	CONSTRAINT "fk_match_maps_winner" FOREIGN KEY ("winner_id") REFERENCES "teams"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_match_map_unique" ON "match_maps" ("match_id","map_number");
+
+CREATE TABLE IF NOT EXISTS "player_map_stats" (
+	"id" bigserial,
+	"match_id" bigint NOT NULL,
+	"map_number" bigint NOT NULL,
+	"player_id" bigint NOT NULL,
+	"team_id" bigint NOT NULL,
+	"kills" bigint DEFAULT 0,
+	"deaths" bigint DEFAULT 0,
+	"kd_ratio" decimal(6,3) DEFAULT 0,
+	"damage" bigint DEFAULT 0,
+	"assists" bigint DEFAULT 0,
+	"bp_rating" decimal(10,6) DEFAULT 0,
+	"hill_time" bigint DEFAULT 0,
+	"snd_rounds" bigint DEFAULT 0,
+	"plant_count" bigint DEFAULT 0,
+	"defuse_count" bigint DEFAULT 0,
+	"snipe_count" bigint DEFAULT 0,
+	"first_blood_count" bigint DEFAULT 0,
+	"first_death_count" bigint DEFAULT 0,
+	"zone_tier_capture_count" bigint DEFAULT 0,
+	"ctl_attack_rounds" bigint DEFAULT 0,
+	"ctl_defense_rounds" bigint DEFAULT 0,
+	"non_traded_kills" bigint DEFAULT 0,
+	"highest_streak" bigint DEFAULT 0,
+	"data_quality_note" varchar(200),
+	"source" varchar(50),
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_player_map_stats_match" FOREIGN KEY ("match_id") REFERENCES "matches"("id"),
+	CONSTRAINT "fk_player_map_stats_player" FOREIGN KEY ("player_id") REFERENCES "players"("id"),
+	CONSTRAINT "fk_player_map_stats_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_player_map_stats_team_id" ON "player_map_stats" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_player_map_stats_player_id" ON "player_map_stats" ("player_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_player_map_stat_unique" ON "player_map_stats" ("match_id","map_number","player_id");
+
+CREATE TABLE IF NOT EXISTS "player_match_stats" (
+	"id" bigserial,
+	"match_id" bigint,
+	"player_id" bigint,
+	"team_id" bigint,
+	"maps_played" bigint DEFAULT 0,
+	"total_kills" bigint DEFAULT 0,
+	"total_deaths" bigint DEFAULT 0,
+	"total_assists" bigint DEFAULT 0,
+	"total_damage" bigint DEFAULT 0,
+	"kd_ratio" decimal(4,2) DEFAULT 0,
+	"kda_ratio" decimal(4,2) DEFAULT 0,
+	"adr" decimal(6,2) DEFAULT 0,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_player_match_stats_match" FOREIGN KEY ("match_id") REFERENCES "matches"("id"),
+	CONSTRAINT "fk_player_match_stats_player" FOREIGN KEY ("player_id") REFERENCES "players"("id"),
+	CONSTRAINT "fk_player_match_stats_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_player_match_stats_team_id" ON "player_match_stats" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_player_match_stats_player_id" ON "player_match_stats" ("player_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_player_match_stat_unique" ON "player_match_stats" ("match_id","player_id");
+
+CREATE TABLE IF NOT EXISTS "player_tournament_stats" (
+	"id" bigserial,
+	"player_id" bigint,
+	"team_id" bigint,
+	"tournament_id" bigint,
+	"total_kills" bigint,
+	"total_deaths" bigint,
+	"total_assists" bigint,
+	"total_damage" bigint,
+	"kd_ratio" decimal,
+	"kda_ratio" decimal,
+	"rank" bigint,
+	"overall_plus_minus" bigint DEFAULT 0,
+	"overall_maps" bigint DEFAULT 0,
+	"snd_kills" bigint DEFAULT 0,
+	"snd_deaths" bigint DEFAULT 0,
+	"snd_kd_ratio" decimal DEFAULT 0,
+	"snd_plus_minus" bigint DEFAULT 0,
+	"snd_k_per_map" decimal DEFAULT 0,
+	"snd_first_kills" bigint DEFAULT 0,
+	"snd_maps" bigint DEFAULT 0,
+	"hp_kills" bigint DEFAULT 0,
+	"hp_deaths" bigint DEFAULT 0,
+	"hp_kd_ratio" decimal DEFAULT 0,
+	"hp_plus_minus" bigint DEFAULT 0,
+	"hp_k_per_map" decimal DEFAULT 0,
+	"hp_time_milliseconds" bigint DEFAULT 0,
+	"hp_maps" bigint DEFAULT 0,
+	"control_kills" bigint DEFAULT 0,
+	"control_deaths" bigint DEFAULT 0,
+	"control_kd_ratio" decimal DEFAULT 0,
+	"control_plus_minus" bigint DEFAULT 0,
+	"control_k_per_map" decimal DEFAULT 0,
+	"control_captures" bigint DEFAULT 0,
+	"control_maps" bigint DEFAULT 0,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_player_tournament_stats_player" FOREIGN KEY ("player_id") REFERENCES "players"("id"),
+	CONSTRAINT "fk_player_tournament_stats_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id"),
+	CONSTRAINT "fk_player_tournament_stats_tournament" FOREIGN KEY ("tournament_id") REFERENCES "tournaments"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_player_tournament_stats_tournament_id" ON "player_tournament_stats" ("tournament_id");
+CREATE INDEX IF NOT EXISTS "idx_player_tournament_stats_team_id" ON "player_tournament_stats" ("team_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_player_tournament_stat_unique" ON "player_tournament_stats" ("player_id","tournament_id");
+
+CREATE TABLE IF NOT EXISTS "team_tournament_stats" (
+	"id" bigserial,
+	"tournament_id" bigint,
+	"team_id" bigint,
+	"placement" bigint,
+	"matches_played" bigint DEFAULT 0,
+	"matches_won" bigint DEFAULT 0,
+	"matches_lost" bigint DEFAULT 0,
+	"maps_played" bigint DEFAULT 0,
+	"maps_won" bigint DEFAULT 0,
+	"maps_lost" bigint DEFAULT 0,
+	"prize_money" decimal(10,2) DEFAULT 0,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_team_tournament_stats_team" FOREIGN KEY ("team_id") REFERENCES "teams"("id"),
+	CONSTRAINT "fk_team_tournament_stats_tournament" FOREIGN KEY ("tournament_id") REFERENCES "tournaments"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_tournament_stats_team_id" ON "team_tournament_stats" ("team_id");
+CREATE INDEX IF NOT EXISTS "idx_team_tournament_stats_tournament_id" ON "team_tournament_stats" ("tournament_id");
+
+CREATE TABLE IF NOT EXISTS "coaches" (
+	"id" bigserial,
+	"name" varchar(100) NOT NULL,
+	"team_id" bigint,
+	"season_id" bigint,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "player_transfers" (
+	"id" bigserial,
+	"player_id" bigint,
+	"from_team_id" bigint,
+	"to_team_id" bigint,
+	"transfer_date" timestamptz,
+	"transfer_type" varchar(50),
+	"role" varchar(50),
+	"game_code" varchar(10),
+	"season" varchar(50),
+	"description" varchar(500),
+	"raw_from_team_name" varchar(200),
+	"raw_to_team_name" varchar(200),
+	"created_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_player_transfers_from_team" FOREIGN KEY ("from_team_id") REFERENCES "teams"("id"),
+	CONSTRAINT "fk_player_transfers_player" FOREIGN KEY ("player_id") REFERENCES "players"("id"),
+	CONSTRAINT "fk_player_transfers_to_team" FOREIGN KEY ("to_team_id") REFERENCES "teams"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_player_transfers_to_team_id" ON "player_transfers" ("to_team_id");
+CREATE INDEX IF NOT EXISTS "idx_player_transfers_from_team_id" ON "player_transfers" ("from_team_id");
+CREATE INDEX IF NOT EXISTS "idx_player_transfers_player_id" ON "player_transfers" ("player_id");
+
+CREATE TABLE IF NOT EXISTS "users" (
+	"id" bigserial,
+	"supabase_uid" varchar(36) NOT NULL,
+	"username" varchar(30) NOT NULL,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	"deleted_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_username" ON "users" ("username");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_users_supabase_uid" ON "users" ("supabase_uid");
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "match_threads" (
+	"id" bigserial,
+	"match_id" bigint NOT NULL,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_match_threads_match" FOREIGN KEY ("match_id") REFERENCES "matches"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_match_threads_match_id" ON "match_threads" ("match_id");
+
+CREATE TABLE IF NOT EXISTS "thread_posts" (
+	"id" bigserial,
+	"thread_id" bigint NOT NULL,
+	"user_id" bigint NOT NULL,
+	"body" text NOT NULL,
+	"edited" boolean DEFAULT false,
+	"deleted_at" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_thread_posts_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_thread_posts_user_id" ON "thread_posts" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_thread_posts_thread_id" ON "thread_posts" ("thread_id");
+CREATE INDEX IF NOT EXISTS "idx_thread_posts_deleted_at" ON "thread_posts" ("deleted_at");
+
+CREATE INDEX IF NOT EXISTS "idx_players_gamertag_trgm" ON "players" USING gin ("gamertag" gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "idx_tournaments_season_type" ON "tournaments" ("season_id","tournament_type");
@@ -0,0 +1,44 @@
+-- Drops what 0002 added, dependents first.
+
+DROP TABLE IF EXISTS "season_points_rules";
+DROP TABLE IF EXISTS "team_rating_history";
+DROP TABLE IF EXISTS "team_ratings";
+DROP TABLE IF EXISTS "applied_patches";
+DROP TABLE IF EXISTS "change_log";
+DROP TABLE IF EXISTS "webhook_attempts";
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhook_events";
+DROP TABLE IF EXISTS "webhook_subscriptions";
+DROP TABLE IF EXISTS "notification_deliveries";
+DROP TABLE IF EXISTS "notification_preferences";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "fantasy_player_scores";
+DROP TABLE IF EXISTS "fantasy_prices";
+DROP TABLE IF EXISTS "fantasy_lineup_players";
+DROP TABLE IF EXISTS "fantasy_members";
+DROP TABLE IF EXISTS "fantasy_leagues";
+DROP TABLE IF EXISTS "picks";
+DROP TABLE IF EXISTS "follows";
+DROP TABLE IF EXISTS "moderation_log";
+DROP TABLE IF EXISTS "post_reports";
+DROP TABLE IF EXISTS "post_mentions";
+DROP TABLE IF EXISTS "post_reactions";
+
+DROP INDEX IF EXISTS "idx_thread_posts_hidden_at";
+DROP INDEX IF EXISTS "idx_thread_posts_parent_id";
+ALTER TABLE "player_tournament_stats"
+	DROP COLUMN IF EXISTS "rating";
+ALTER TABLE "player_match_stats"
+	DROP COLUMN IF EXISTS "rating";
+ALTER TABLE "player_map_stats"
+	DROP COLUMN IF EXISTS "rating";
+ALTER TABLE "thread_posts"
+	DROP COLUMN IF EXISTS "parent_id",
+	DROP COLUMN IF EXISTS "depth",
+	DROP COLUMN IF EXISTS "hidden_at";
+ALTER TABLE "match_threads"
+	DROP COLUMN IF EXISTS "locked";
+ALTER TABLE "users"
+	DROP COLUMN IF EXISTS "role",
+	DROP COLUMN IF EXISTS "muted_until",
+	DROP COLUMN IF EXISTS "banned_until";
@@ -0,0 +1,360 @@
+-- Tables and columns added after the baseline, while AutoMigrate still managed the
+-- schema: ratings, points rules, thread replies and moderation, follows, pick'em,
+-- fantasy, notifications, webhooks, the change log and applied patches. Written
+-- with IF NOT EXISTS like the baseline, so a database AutoMigrate already brought up
+-- to date adopts it as is and an older one gets what it's missing.
+
+ALTER TABLE "users"
+	ADD COLUMN IF NOT EXISTS "role" varchar(16) NOT NULL DEFAULT 'user',
+	ADD COLUMN IF NOT EXISTS "muted_until" timestamptz,
+	ADD COLUMN IF NOT EXISTS "banned_until" timestamptz;
+
+ALTER TABLE "match_threads"
+	ADD COLUMN IF NOT EXISTS "locked" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "thread_posts"
+	ADD COLUMN IF NOT EXISTS "parent_id" bigint,
+	ADD COLUMN IF NOT EXISTS "depth" bigint DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS "hidden_at" timestamptz;
+CREATE INDEX IF NOT EXISTS "idx_thread_posts_hidden_at" ON "thread_posts" ("hidden_at");
+CREATE INDEX IF NOT EXISTS "idx_thread_posts_parent_id" ON "thread_posts" ("parent_id");
+
+ALTER TABLE "player_map_stats"
+	ADD COLUMN IF NOT EXISTS "rating" decimal(8,4) DEFAULT 0;
+
+ALTER TABLE "player_match_stats"
+	ADD COLUMN IF NOT EXISTS "rating" decimal(8,4) DEFAULT 0;
+
+ALTER TABLE "player_tournament_stats"
+	ADD COLUMN IF NOT EXISTS "rating" decimal(8,4) DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "post_reactions" (
+	"id" bigserial,
+	"post_id" bigint NOT NULL,
+	"user_id" bigint NOT NULL,
+	"emoji" varchar(32) NOT NULL,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_reactions_user_id" ON "post_reactions" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_reaction" ON "post_reactions" ("post_id","user_id","emoji");
+
+CREATE TABLE IF NOT EXISTS "post_mentions" (
+	"id" bigserial,
+	"post_id" bigint NOT NULL,
+	"user_id" bigint NOT NULL,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_mentions_user_id" ON "post_mentions" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_mention" ON "post_mentions" ("post_id","user_id");
+
+CREATE TABLE IF NOT EXISTS "post_reports" (
+	"id" bigserial,
+	"post_id" bigint NOT NULL,
+	"reporter_id" bigint NOT NULL,
+	"reason" varchar(500) NOT NULL,
+	"status" varchar(16) NOT NULL DEFAULT 'open',
+	"resolved_by" bigint,
+	"resolved_at" timestamptz,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_post_reports_post" FOREIGN KEY ("post_id") REFERENCES "thread_posts"("id"),
+	CONSTRAINT "fk_post_reports_reporter" FOREIGN KEY ("reporter_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_post_reports_status" ON "post_reports" ("status");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_post_report" ON "post_reports" ("post_id","reporter_id");
+
+CREATE TABLE IF NOT EXISTS "moderation_log" (
+	"id" bigserial,
+	"moderator_id" bigint NOT NULL,
+	"action" varchar(32) NOT NULL,
+	"target_type" varchar(16) NOT NULL,
+	"target_id" bigint NOT NULL,
+	"reason" varchar(500),
+	"detail" varchar(200),
+	"created_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_moderation_log_moderator" FOREIGN KEY ("moderator_id") REFERENCES "users"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_moderation_log_moderator_id" ON "moderation_log" ("moderator_id");
+CREATE INDEX IF NOT EXISTS "idx_moderation_log_created_at" ON "moderation_log" ("created_at");
+CREATE INDEX IF NOT EXISTS "idx_moderation_target" ON "moderation_log" ("target_type","target_id");
+
+CREATE TABLE IF NOT EXISTS "follows" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"target_type" varchar(16) NOT NULL,
+	"target_id" bigint NOT NULL,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_follow" ON "follows" ("user_id","target_type","target_id");
+
+CREATE TABLE IF NOT EXISTS "picks" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"match_id" bigint NOT NULL,
+	"winner_id" bigint NOT NULL,
+	"team1_score" bigint,
+	"team2_score" bigint,
+	"points" bigint,
+	"scored_at" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_picks_match" FOREIGN KEY ("match_id") REFERENCES "matches"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_picks_match_id" ON "picks" ("match_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_pick_user_match" ON "picks" ("user_id","match_id");
+
+CREATE TABLE IF NOT EXISTS "fantasy_leagues" (
+	"id" bigserial,
+	"name" varchar(100) NOT NULL,
+	"tournament_id" bigint NOT NULL,
+	"owner_id" bigint NOT NULL,
+	"invite_code" varchar(12) NOT NULL,
+	"salary_cap" bigint NOT NULL,
+	"roster_size" bigint NOT NULL,
+	"lock_at" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_fantasy_leagues_tournament" FOREIGN KEY ("tournament_id") REFERENCES "tournaments"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_fantasy_leagues_invite_code" ON "fantasy_leagues" ("invite_code");
+CREATE INDEX IF NOT EXISTS "idx_fantasy_leagues_owner_id" ON "fantasy_leagues" ("owner_id");
+CREATE INDEX IF NOT EXISTS "idx_fantasy_leagues_tournament_id" ON "fantasy_leagues" ("tournament_id");
+
+CREATE TABLE IF NOT EXISTS "fantasy_members" (
+	"id" bigserial,
+	"league_id" bigint NOT NULL,
+	"user_id" bigint NOT NULL,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_fantasy_member" ON "fantasy_members" ("league_id","user_id");
+CREATE INDEX IF NOT EXISTS "idx_fantasy_members_user_id" ON "fantasy_members" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "fantasy_lineup_players" (
+	"id" bigserial,
+	"member_id" bigint NOT NULL,
+	"player_id" bigint NOT NULL,
+	"salary" bigint,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_fantasy_lineup_players_player" FOREIGN KEY ("player_id") REFERENCES "players"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_fantasy_lineup" ON "fantasy_lineup_players" ("member_id","player_id");
+
+CREATE TABLE IF NOT EXISTS "fantasy_prices" (
+	"id" bigserial,
+	"tournament_id" bigint NOT NULL,
+	"player_id" bigint NOT NULL,
+	"team_id" bigint,
+	"salary" bigint,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_fantasy_price" ON "fantasy_prices" ("tournament_id","player_id");
+
+CREATE TABLE IF NOT EXISTS "fantasy_player_scores" (
+	"id" bigserial,
+	"tournament_id" bigint NOT NULL,
+	"player_id" bigint NOT NULL,
+	"maps" bigint,
+	"points" decimal,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_fantasy_score" ON "fantasy_player_scores" ("tournament_id","player_id");
+
+CREATE TABLE IF NOT EXISTS "notifications" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"kind" varchar(16) NOT NULL,
+	"ref_id" bigint NOT NULL,
+	"title" varchar(200) NOT NULL,
+	"body" varchar(500),
+	"link" varchar(200),
+	"read_at" timestamptz,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_notification_user_created" ON "notifications" ("user_id","created_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_notification_ref" ON "notifications" ("user_id","kind","ref_id");
+
+CREATE TABLE IF NOT EXISTS "notification_preferences" (
+	"id" bigserial,
+	"user_id" bigint NOT NULL,
+	"matches" boolean NOT NULL,
+	"mentions" boolean NOT NULL,
+	"transfers" boolean NOT NULL,
+	"email_enabled" boolean NOT NULL,
+	"email" varchar(254),
+	"webhook_enabled" boolean NOT NULL,
+	"webhook_url" varchar(500),
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_notification_preferences_user_id" ON "notification_preferences" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "notification_deliveries" (
+	"id" bigserial,
+	"notification_id" bigint NOT NULL,
+	"channel" varchar(16) NOT NULL,
+	"status" varchar(16) NOT NULL,
+	"attempts" bigint NOT NULL,
+	"next_attempt_at" timestamptz,
+	"last_error" varchar(500),
+	"sent_at" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_notification_delivery" ON "notification_deliveries" ("notification_id","channel");
+CREATE INDEX IF NOT EXISTS "idx_delivery_due" ON "notification_deliveries" ("status","next_attempt_at");
+
+CREATE TABLE IF NOT EXISTS "webhook_subscriptions" (
+	"id" bigserial,
+	"url" varchar(500) NOT NULL,
+	"description" varchar(200),
+	"events" varchar(200) NOT NULL,
+	"secret" varchar(100) NOT NULL,
+	"active" boolean NOT NULL,
+	"created_by" bigint NOT NULL,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_events" (
+	"id" bigserial,
+	"type" varchar(32) NOT NULL,
+	"dedupe_key" varchar(200) NOT NULL,
+	"payload" jsonb NOT NULL,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_webhook_events_dedupe_key" ON "webhook_events" ("dedupe_key");
+CREATE INDEX IF NOT EXISTS "idx_webhook_events_type" ON "webhook_events" ("type");
+CREATE INDEX IF NOT EXISTS "idx_webhook_events_created_at" ON "webhook_events" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+	"id" bigserial,
+	"subscription_id" bigint NOT NULL,
+	"event_id" bigint NOT NULL,
+	"status" varchar(16) NOT NULL,
+	"attempts" bigint NOT NULL,
+	"next_attempt_at" timestamptz,
+	"last_status_code" bigint,
+	"last_error" varchar(500),
+	"sent_at" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_webhook_deliveries_event" FOREIGN KEY ("event_id") REFERENCES "webhook_events"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_webhook_delivery" ON "webhook_deliveries" ("subscription_id","event_id");
+CREATE INDEX IF NOT EXISTS "idx_webhook_delivery_due" ON "webhook_deliveries" ("status","next_attempt_at");
+
+CREATE TABLE IF NOT EXISTS "webhook_attempts" (
+	"id" bigserial,
+	"delivery_id" bigint NOT NULL,
+	"attempt" bigint NOT NULL,
+	"status_code" bigint,
+	"error" varchar(500),
+	"duration_ms" bigint,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id"),
+	CONSTRAINT "fk_webhook_deliveries_log" FOREIGN KEY ("delivery_id") REFERENCES "webhook_deliveries"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_webhook_attempts_delivery_id" ON "webhook_attempts" ("delivery_id");
+
+CREATE TABLE IF NOT EXISTS "change_log" (
+	"id" bigserial,
+	"entity" varchar(16) NOT NULL,
+	"entity_key" varchar(64) NOT NULL,
+	"action" varchar(16) NOT NULL,
+	"before" jsonb,
+	"after" jsonb,
+	"actor_id" bigint,
+	"actor" varchar(64) NOT NULL,
+	"reason" varchar(500),
+	"revert_of" bigint,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_change_log_actor_id" ON "change_log" ("actor_id");
+CREATE INDEX IF NOT EXISTS "idx_change_entity" ON "change_log" ("entity","entity_key");
+CREATE INDEX IF NOT EXISTS "idx_change_log_created_at" ON "change_log" ("created_at");
+
+CREATE TABLE IF NOT EXISTS "applied_patches" (
+	"id" varchar(100),
+	"checksum" varchar(64) NOT NULL,
+	"description" varchar(500),
+	"changes" bigint,
+	"applied_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "team_ratings" (
+	"id" bigserial,
+	"team_id" bigint NOT NULL,
+	"franchise_id" bigint,
+	"game_code" varchar(10),
+	"season_id" bigint,
+	"rating" decimal,
+	"rd" decimal,
+	"volatility" decimal,
+	"matches_played" bigint DEFAULT 0,
+	"wins" bigint DEFAULT 0,
+	"losses" bigint DEFAULT 0,
+	"last_match_id" bigint,
+	"last_match_date" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_ratings_season_id" ON "team_ratings" ("season_id");
+CREATE INDEX IF NOT EXISTS "idx_team_ratings_franchise_id" ON "team_ratings" ("franchise_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_team_ratings_team_id" ON "team_ratings" ("team_id");
+
+CREATE TABLE IF NOT EXISTS "team_rating_history" (
+	"id" bigserial,
+	"team_id" bigint NOT NULL,
+	"match_id" bigint NOT NULL,
+	"opponent_id" bigint,
+	"season_id" bigint,
+	"game_code" varchar(10),
+	"sequence" bigint,
+	"match_date" timestamptz,
+	"won" boolean,
+	"rating_before" decimal,
+	"rating_after" decimal,
+	"rd_before" decimal,
+	"rd_after" decimal,
+	"volatility" decimal,
+	"created_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_team_rating_history_sequence" ON "team_rating_history" ("sequence");
+CREATE INDEX IF NOT EXISTS "idx_team_rating_history_season_id" ON "team_rating_history" ("season_id");
+CREATE INDEX IF NOT EXISTS "idx_team_rating_history_match_id" ON "team_rating_history" ("match_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_team_rating_match" ON "team_rating_history" ("team_id","match_id");
+
+CREATE TABLE IF NOT EXISTS "season_points_rules" (
+	"id" bigserial,
+	"season_id" bigint,
+	"game_code" varchar(10),
+	"tournament_type" varchar(50) NOT NULL,
+	"basis" varchar(20) NOT NULL,
+	"placement_from" bigint DEFAULT 0,
+	"placement_to" bigint DEFAULT 0,
+	"points" bigint NOT NULL,
+	"created_at" timestamptz,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_season_points_rules_game_code" ON "season_points_rules" ("game_code");
+CREATE INDEX IF NOT EXISTS "idx_season_points_rules_season_id" ON "season_points_rules" ("season_id");
@@ -0,0 +1,13 @@
+-- The per-era (and mode) stat averages and weights of the last full performance
+-- rating run, so a rebuilt match's lines can be rated against the same scale
+-- without re-reading every line.
+
+CREATE TABLE IF NOT EXISTS "performance_baselines" (
+	"game_code" varchar(10) NOT NULL,
+	"mode" varchar(16) NOT NULL,
+	"stat" varchar(32) NOT NULL,
+	"average" double precision NOT NULL,
+	"weight" double precision NOT NULL,
+	"updated_at" timestamptz,
+	PRIMARY KEY ("game_code","mode","stat")
+);
@@ -0,0 +1,8 @@
+-- Tournaments whose totals live ingestion has left for the notify worker to roll
+-- up, so a push only rebuilds the match it writes to.
+
+CREATE TABLE IF NOT EXISTS "pending_rollups" (
+	"tournament_id" bigint,
+	"queued_at" timestamptz,
+	PRIMARY KEY ("tournament_id")
+);
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Admin data-editing endpoints for matches, maps and player stat lines, and the change
+// log they write. Handlers only resolve the caller; AdminService checks the admin role
+// and recomputes aggregates. Writes take an optional "reason" that is kept with every
+// change they make.
+
+// adminError maps AdminService errors to responses; notFound names the missing thing
+// for 404s and failed describes the action for 500s.
+func adminError(c *gin.Context, err error, notFound, failed string) {
+	switch {
+	case errors.Is(err, services.ErrAdminRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMatchInUse), errors.Is(err, services.ErrChangeConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidMatch), errors.Is(err, services.ErrInvalidMap),
+		errors.Is(err, services.ErrInvalidStatLine), errors.Is(err, services.ErrInvalidReason),
+		errors.Is(err, services.ErrInvalidChangeFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound + " not found"})
+	default:
+		log.Printf("admin error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + failed})
+	}
+}
+
+// adminMapPath reads :id and :number (and :player when withPlayer is set), writing
+// the error response if it can't.
+func adminMapPath(c *gin.Context, withPlayer bool) (matchID uint, mapNumber int, playerID uint, ok bool) {
+	id, err := validateID(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match ID"})
+		return 0, 0, 0, false
+	}
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid map number"})
+		return 0, 0, 0, false
+	}
+	if withPlayer {
+		player, err := validateID(c.Param("player"))
+		if err != nil || player <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid player ID"})
+			return 0, 0, 0, false
+		}
+		playerID = uint(player)
+	}
+	return uint(id), number, playerID, true
+}
+
+func (h *Handler) AdminCreateMatch(c *gin.Context) {
+	var body services.AdminMatchInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	match, err := h.admin.CreateMatch(ctx, user, body)
+	if err != nil {
+		adminError(c, err, "match", "create match")
+		return
+	}
+	c.JSON(http.StatusCreated, match)
+}
+
+func (h *Handler) AdminUpdateMatch(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match ID"})
+		return
+	}
+	var body services.AdminMatchInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	match, err := h.admin.UpdateMatch(ctx, user, uint(id), body)
+	if err != nil {
+		adminError(c, err, "match", "update match")
+		return
+	}
+	c.JSON(http.StatusOK, match)
+}
+
+func (h *Handler) AdminDeleteMatch(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match ID"})
+		return
+	}
+	var body reasonBody
+	_ = c.ShouldBindJSON(&body) // the reason is optional
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	if err := h.admin.DeleteMatch(ctx, user, uint(id), body.Reason); err != nil {
+		adminError(c, err, "match", "delete match")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "deleted": true})
+}
+
+func (h *Handler) AdminSaveMap(c *gin.Context) {
+	matchID, number, _, ok := adminMapPath(c, false)
+	if !ok {
+		return
+	}
+	var body services.AdminMapInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	result, err := h.admin.SaveMap(ctx, user, matchID, number, body)
+	if err != nil {
+		adminError(c, err, "match", "save map")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) AdminDeleteMap(c *gin.Context) {
+	matchID, number, _, ok := adminMapPath(c, false)
+	if !ok {
+		return
+	}
+	var body reasonBody
+	_ = c.ShouldBindJSON(&body) // the reason is optional
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	match, err := h.admin.DeleteMap(ctx, user, matchID, number, body.Reason)
+	if err != nil {
+		adminError(c, err, "map", "delete map")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"match": match})
+}
+
+func (h *Handler) AdminSaveStatLine(c *gin.Context) {
+	matchID, number, playerID, ok := adminMapPath(c, true)
+	if !ok {
+		return
+	}
+	var body services.AdminStatLineInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	line, err := h.admin.SaveStatLine(ctx, user, matchID, number, playerID, body)
+	if err != nil {
+		adminError(c, err, "match", "save stat line")
+		return
+	}
+	c.JSON(http.StatusOK, line)
+}
+
+func (h *Handler) AdminDeleteStatLine(c *gin.Context) {
+	matchID, number, playerID, ok := adminMapPath(c, true)
+	if !ok {
+		return
+	}
+	var body reasonBody
+	_ = c.ShouldBindJSON(&body) // the reason is optional
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	if err := h.admin.DeleteStatLine(ctx, user, matchID, number, playerID, body.Reason); err != nil {
+		adminError(c, err, "stat line", "delete stat line")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"match_id": matchID, "map_number": number, "player_id": playerID, "deleted": true})
+}
+
+// ListChanges pages through the change log, newest first. Filters: ?entity=, ?key=
+// (with entity), ?actor_id=, ?since= and ?until= (RFC 3339).
+func (h *Handler) ListChanges(c *gin.Context) {
+	h.listChanges(c, c.Query("entity"), c.Query("key"))
+}
+
+// GetChangeHistory is the change log of one row, e.g. /admin/changes/map/123:2.
+func (h *Handler) GetChangeHistory(c *gin.Context) {
+	h.listChanges(c, c.Param("entity"), c.Param("key"))
+}
+
+func (h *Handler) listChanges(c *gin.Context, entity, key string) {
+	filter := store.ChangeFilter{Entity: entity, EntityKey: key}
+	if v := c.Query("actor_id"); v != "" {
+		id, err := validateID(v)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid actor_id"})
+			return
+		}
+		filter.ActorID = uint(id)
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": p.name + " must be RFC 3339"})
+				return
+			}
+			*p.dst = t
+		}
+	}
+	page, limit, _ := parsePagination(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	changes, total, err := h.admin.ListChanges(ctx, user, filter, page, limit)
+	if err != nil {
+		adminError(c, err, "change", "fetch changes")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": changes, "pagination": buildMeta(page, limit, int(total))})
+}
+
+// RevertChange takes {"reason": ...} and answers with the change the revert recorded;
+// 409 when the row has been written since.
+func (h *Handler) RevertChange(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid change ID"})
+		return
+	}
+	var body reasonBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	change, err := h.admin.RevertChange(ctx, user, uint(id), body.Reason)
+	if err != nil {
+		adminError(c, err, "change", "revert change")
+		return
+	}
+	c.JSON(http.StatusOK, change)
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminMatches_RequireAuth(t *testing.T) {
+	t.Setenv("SUPABASE_JWT_SECRET", testJWTSecret)
+	r := newTestRouter(New(nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/matches", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAdminMatches_EditRebuildsStats(t *testing.T) {
+	setupPGTx(t)
+	pgMatchEnv(t)
+	require.NoError(t, database.DB.Create(&models.Player{ID: 40, Gamertag: "Shotzzy"}).Error)
+	require.NoError(t, database.DB.Create(&models.TeamRoster{
+		TeamID: 1, PlayerID: 40, SeasonID: 1, StartDate: time.Now().AddDate(0, -1, 0),
+	}).Error)
+
+	adminToken := signJWT(t, "uid-data-admin")
+	fanToken := signJWT(t, "uid-data-fan")
+	r := newTestRouter(New(database.DB))
+	for _, tc := range []struct{ token, username string }{{adminToken, "DataAdmin"}, {fanToken, "DataFan"}} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": tc.username}), tc.token))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	require.NoError(t, database.DB.Model(&models.User{}).Where("username = ?", "DataAdmin").Update("role", models.RoleAdmin).Error)
+
+	create := map[string]any{"tournament_id": 1, "team1_id": 1, "team2_id": 2, "format": "BO3", "match_date": time.Now()}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/admin/matches", jsonBody(t, create), fanToken))
+	assert.Equal(t, http.StatusForbidden, w.Code, "admins only")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/admin/matches", jsonBody(t, create), adminToken))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var match map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &match))
+	matchID := uint(match["id"].(float64))
+	base := fmt.Sprintf("/api/v1/admin/matches/%d", matchID)
+
+	for n := 1; n <= 2; n++ {
+		body := map[string]any{"map_name": "Skyline", "mode": "hp", "played": true, "score_1": 250, "score_2": 200}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPut, fmt.Sprintf("%s/maps/%d", base, n), jsonBody(t, body), adminToken))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, base+"/maps/3", jsonBody(t, map[string]any{"mode": "snd", "played": true, "score_1": 6, "score_2": 2}), adminToken))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the series is already 2-0")
+
+	var saved models.Match
+	require.NoError(t, database.DB.First(&saved, matchID).Error)
+	assert.Equal(t, 2, saved.Team1Score)
+	require.NotNil(t, saved.WinnerID)
+	assert.Equal(t, uint(1), *saved.WinnerID)
+
+	for n, kills := range map[int]int{1: 30, 2: 20} {
+		line := map[string]any{"team_id": 1, "kills": kills, "deaths": 20, "damage": 4000}
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPut, fmt.Sprintf("%s/maps/%d/stats/40", base, n), jsonBody(t, line), adminToken))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, base+"/maps/1/stats/40", jsonBody(t, map[string]any{"team_id": 2, "kills": 1}), adminToken))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "a player stays on one side")
+
+	var pms models.PlayerMatchStats
+	require.NoError(t, database.DB.Where("match_id = ? AND player_id = ?", matchID, 40).First(&pms).Error)
+	assert.Equal(t, 50, pms.TotalKills)
+	assert.Equal(t, 40, pms.TotalDeaths)
+	var pts models.PlayerTournamentStats
+	require.NoError(t, database.DB.Where("tournament_id = 1 AND player_id = 40").First(&pts).Error)
+	assert.Equal(t, 50, pts.TotalKills)
+	var tts models.TeamTournamentStats
+	require.NoError(t, database.DB.Where("tournament_id = 1 AND team_id = 1").First(&tts).Error)
+	assert.Equal(t, 1, tts.MatchesWon)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodDelete, base+"/maps/2/stats/40", nil, adminToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	var after models.PlayerMatchStats
+	require.NoError(t, database.DB.Where("match_id = ? AND player_id = ?", matchID, 40).First(&after).Error)
+	assert.Equal(t, 30, after.TotalKills, "aggregates follow the deleted line")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodDelete, base, nil, adminToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	var left int64
+	database.DB.Model(&models.PlayerTournamentStats{}).Where("tournament_id = 1 AND player_id = 40").Count(&left)
+	assert.Zero(t, left)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodDelete, base, nil, adminToken))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminChanges_HistoryAndRevert(t *testing.T) {
+	setupPGTx(t)
+	pgMatchEnv(t)
+	pgMatch(t, 1)
+	require.NoError(t, database.DB.Create(&models.Player{ID: 40, Gamertag: "Shotzzy"}).Error)
+	require.NoError(t, database.DB.Create(&models.TeamRoster{
+		TeamID: 1, PlayerID: 40, SeasonID: 1, StartDate: time.Now().AddDate(0, -1, 0),
+	}).Error)
+
+	token := signJWT(t, "uid-history-admin")
+	r := newTestRouter(New(database.DB))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": "HistoryAdmin"}), token))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, database.DB.Model(&models.User{}).Where("username = ?", "HistoryAdmin").Update("role", models.RoleAdmin).Error)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/admin/matches/1/maps/1", jsonBody(t, map[string]any{"mode": "hp", "score_1": 120, "score_2": 80}), token))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	for _, body := range []map[string]any{
+		{"team_id": 1, "kills": 25, "deaths": 20},
+		{"team_id": 1, "kills": 30, "deaths": 20, "reason": "official box score"},
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/admin/matches/1/maps/1/stats/40", jsonBody(t, body), token))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/admin/changes/stat_line/1:1:40", nil, token))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var history map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &history))
+	require.Len(t, history["data"], 2)
+	latest := history["data"].([]any)[0].(map[string]any)
+	assert.Equal(t, models.ChangeUpdate, latest["action"])
+	assert.Equal(t, "HistoryAdmin", latest["actor"])
+	assert.Equal(t, "official box score", latest["reason"])
+	assert.EqualValues(t, 25, latest["before"].(map[string]any)["kills"])
+	assert.EqualValues(t, 30, latest["after"].(map[string]any)["kills"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/admin/changes?entity=player", nil, token))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	revert := fmt.Sprintf("/api/v1/admin/changes/%d/revert", int(latest["id"].(float64)))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, revert, jsonBody(t, map[string]string{"reason": "box score was for map 2"}), token))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var line models.PlayerMapStats
+	require.NoError(t, database.DB.Where("match_id = 1 AND map_number = 1 AND player_id = 40").First(&line).Error)
+	assert.Equal(t, 25, line.Kills)
+	var pms models.PlayerMatchStats
+	require.NoError(t, database.DB.Where("match_id = 1 AND player_id = 40").First(&pms).Error)
+	assert.Equal(t, 25, pms.TotalKills, "aggregates follow the revert")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, revert, jsonBody(t, map[string]string{"reason": "again"}), token))
+	assert.Equal(t, http.StatusConflict, w.Code, "the line has changed since")
+}
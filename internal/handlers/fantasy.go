@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Fantasy endpoints. Every route needs a signed-in user; everything under
+// /fantasy/leagues/:id is limited to the league's members.
+
+// fantasyError maps FantasyService errors to responses; notFound names the missing
+// thing for 404s and failed describes the action for 500s.
+func fantasyError(c *gin.Context, err error, notFound, failed string) {
+	switch {
+	case errors.Is(err, services.ErrNotLeagueMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLineupLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidLeague), errors.Is(err, services.ErrInvalidLineup):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound + " not found"})
+	default:
+		log.Printf("fantasy error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + failed})
+	}
+}
+
+// leagueID reads /fantasy/leagues/:id, writing the error response if it can't.
+func leagueID(c *gin.Context) (int, bool) {
+	id, err := validateID(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid league ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *Handler) ListFantasyLeagues(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	leagues, err := h.fantasy.ListLeagues(ctx, user.ID)
+	if err != nil {
+		fantasyError(c, err, "league", "fetch leagues")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": leagues})
+}
+
+func (h *Handler) CreateFantasyLeague(c *gin.Context) {
+	var body services.FantasyLeagueInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and tournament_id are required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	league, err := h.fantasy.CreateLeague(ctx, user.ID, body)
+	if err != nil {
+		fantasyError(c, err, "tournament", "create league")
+		return
+	}
+	c.JSON(http.StatusCreated, league)
+}
+
+func (h *Handler) JoinFantasyLeague(c *gin.Context) {
+	var body struct {
+		InviteCode string `json:"invite_code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invite_code is required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	league, err := h.fantasy.JoinLeague(ctx, user.ID, body.InviteCode)
+	if err != nil {
+		fantasyError(c, err, "league", "join league")
+		return
+	}
+	c.JSON(http.StatusOK, league)
+}
+
+func (h *Handler) GetFantasyLeague(c *gin.Context) {
+	id, ok := leagueID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	detail, err := h.fantasy.GetLeague(ctx, user.ID, id)
+	if err != nil {
+		fantasyError(c, err, "league", "fetch league")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, detail)
+}
+
+func (h *Handler) GetFantasyPool(c *gin.Context) {
+	id, ok := leagueID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	pool, err := h.fantasy.GetPool(ctx, user.ID, id)
+	if err != nil {
+		fantasyError(c, err, "league", "fetch players")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": pool})
+}
+
+func (h *Handler) SetFantasyLineup(c *gin.Context) {
+	id, ok := leagueID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		PlayerIDs []uint `json:"player_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "player_ids is required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	lineup, err := h.fantasy.SetLineup(ctx, user.ID, id, body.PlayerIDs)
+	if err != nil {
+		fantasyError(c, err, "league", "save lineup")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": lineup})
+}
+
+func (h *Handler) GetFantasyStandings(c *gin.Context) {
+	id, ok := leagueID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	standings, err := h.fantasy.GetStandings(ctx, user.ID, id)
+	if err != nil {
+		fantasyError(c, err, "league", "fetch standings")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": standings})
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFantasyRoutes_RequireAuth(t *testing.T) {
+	t.Setenv("SUPABASE_JWT_SECRET", testJWTSecret)
+	r := newTestRouter(New(nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/fantasy/leagues", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestFantasy_LeagueLineupScoreStandings(t *testing.T) {
+	setupPGTx(t)
+	pgSeason(t)
+	pgTeams(t)
+	start := time.Now().Add(24 * time.Hour)
+	require.NoError(t, database.DB.Create(&models.Tournament{
+		ID: 2, SeasonID: 1, Name: "CDL Major 2 2025", Slug: "cdl-major-2-2025",
+		TournamentType: "major", StartDate: start,
+	}).Error)
+	require.NoError(t, database.DB.Create(&models.Match{
+		ID: 1, TournamentID: 2, Team1ID: 1, Team2ID: 2, Format: "BO5", MatchDate: start,
+	}).Error)
+	for id := uint(1); id <= 4; id++ {
+		require.NoError(t, database.DB.Create(&models.Player{ID: id, Gamertag: fmt.Sprintf("Player%d", id)}).Error)
+		require.NoError(t, database.DB.Create(&models.TeamRoster{TeamID: 1 + (id-1)/2, PlayerID: id, SeasonID: 1, StartDate: time.Now()}).Error)
+	}
+
+	ownerToken := signJWT(t, "uid-fantasy-owner")
+	friendToken := signJWT(t, "uid-fantasy-friend")
+	r := newTestRouter(New(database.DB))
+	for _, tc := range []struct{ token, username string }{{ownerToken, "LeagueOwner"}, {friendToken, "LeagueFriend"}} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": tc.username}), tc.token))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/fantasy/leagues", jsonBody(t, map[string]any{"name": "Office league", "tournament_id": 2}), ownerToken))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var league map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &league))
+	leaguePath := fmt.Sprintf("/api/v1/fantasy/leagues/%d", int(league["id"].(float64)))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, leaguePath+"/standings", nil, friendToken))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/fantasy/leagues/join", jsonBody(t, map[string]string{"invite_code": league["invite_code"].(string)}), friendToken))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, leaguePath+"/players", nil, friendToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	var pool map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &pool))
+	assert.Len(t, pool["data"], 4)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, leaguePath+"/lineup", jsonBody(t, map[string]any{"player_ids": []uint{1, 2, 3, 4}}), friendToken))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.NoError(t, database.DB.Create(&models.PlayerMapStats{MatchID: 1, MapNumber: 1, PlayerID: 1, TeamID: 1, Kills: 25, Deaths: 20}).Error)
+	fs := services.NewFantasyService(store.NewGormFantasyStore(database.DB), store.NewGormTournamentStore(database.DB), services.DefaultFantasyConfig())
+	n, err := fs.ScoreAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, leaguePath+"/standings", nil, ownerToken))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var standings map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &standings))
+	rows := standings["data"].([]any)
+	require.Len(t, rows, 2)
+	top := rows[0].(map[string]any)
+	assert.Equal(t, "LeagueFriend", top["username"])
+	assert.EqualValues(t, 15, top["points"])
+
+	require.NoError(t, database.DB.Model(&models.FantasyLeague{}).Where("id = ?", league["id"]).Update("lock_at", time.Now().Add(-time.Minute)).Error)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, leaguePath+"/lineup", jsonBody(t, map[string]any{"player_ids": []uint{1, 2, 3, 4}}), ownerToken))
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// parseFollowTarget reads /me/follows/:type/:id, writing the error response if it can't.
+func parseFollowTarget(c *gin.Context) (string, uint, bool) {
+	targetType, err := services.ParseFollowType(c.Param("type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", 0, false
+	}
+	id, err := validateID(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return "", 0, false
+	}
+	return targetType, uint(id), true
+}
+
+// Follow starts following a player, team or franchise: PUT /me/follows/teams/12.
+func (h *Handler) Follow(c *gin.Context) {
+	targetType, targetID, ok := parseFollowTarget(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	if err := h.follows.Follow(ctx, user.ID, targetType, targetID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": targetType + " not found"})
+			return
+		}
+		log.Printf("Follow error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to follow"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"target_type": targetType, "target_id": targetID, "following": true})
+}
+
+func (h *Handler) Unfollow(c *gin.Context) {
+	targetType, targetID, ok := parseFollowTarget(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	if err := h.follows.Unfollow(ctx, user.ID, targetType, targetID); err != nil {
+		log.Printf("Unfollow error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unfollow"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"target_type": targetType, "target_id": targetID, "following": false})
+}
+
+func (h *Handler) GetFollows(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	follows, err := h.follows.ListFollows(ctx, user.ID)
+	if err != nil {
+		log.Printf("GetFollows error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch follows"})
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": follows})
+}
+
+// GetFeed pages through matches, transfers and thread activity for what the caller follows.
+func (h *Handler) GetFeed(c *gin.Context) {
+	page, limit, _ := parsePagination(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	items, total, err := h.follows.GetFeed(ctx, user.ID, page, limit)
+	if err != nil {
+		log.Printf("GetFeed error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch feed"})
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": items, "pagination": buildMeta(page, limit, int(total))})
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeRoutes_RequireAuth(t *testing.T) {
+	t.Setenv("SUPABASE_JWT_SECRET", testJWTSecret)
+	r := newTestRouter(New(nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/me/feed", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestFollows_FeedShowsFollowedTeamMatch(t *testing.T) {
+	setupPGTx(t)
+	pgMatchEnv(t)
+	pgMatch(t, 1)
+
+	token := signJWT(t, "uid-follow-fan")
+	r := newTestRouter(New(database.DB))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": "FollowFan"}), token))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/me/follows/coaches/1", nil, token))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/me/follows/teams/999", nil, token))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	for range 2 {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/me/follows/teams/1", nil, token))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/me/follows", nil, token))
+	require.Equal(t, http.StatusOK, w.Code)
+	var follows map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &follows))
+	assert.Len(t, follows["data"], 1, "following twice keeps one follow")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/me/feed", nil, token))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var feed map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &feed))
+	items := feed["data"].([]any)
+	require.Len(t, items, 1)
+	item := items[0].(map[string]any)
+	assert.Equal(t, "match", item["type"])
+	assert.EqualValues(t, 1, item["match"].(map[string]any)["id"])
+	assert.EqualValues(t, 1, feed["pagination"].(map[string]any)["total"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodDelete, "/api/v1/me/follows/teams/1", nil, token))
+	require.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/me/feed", nil, token))
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &feed))
+	assert.Empty(t, feed["data"])
+}
@@ -5,21 +5,42 @@ package handlers
 //
 // Handler file structure:
 //   handlers.go   — this file: Handler struct, New constructor, HTTP helpers
-//   seasons.go    — GetSeasons, GetSeason, GetActiveSeason
-//   teams.go      — GetTeams, GetTeam, GetTeamPlayers, GetTeamStats
+//   seasons.go    — GetSeasons, GetSeason, GetActiveSeason, GetSeasonStandings
+//   teams.go      — GetTeams, GetTeam, GetTeamPlayers, GetTeamStats, GetTeamMapPool,
+//                   GetTeamForm, GetTeamLineups
 //   franchises.go — GetFranchises, GetFranchise
 //   players.go    — GetPlayers, GetPlayer, GetPlayerStats, GetPlayerKDStats,
-//                   GetPlayerMatches, GetPlayerFranchiseCareer
-//   matches.go    — GetMatch
+//                   GetPlayerMatches, GetPlayerFranchiseCareer, GetPlayerComparison,
+//                   GetPlayerForm
+//   matches.go    — GetMatch, GetMatchPrediction
+//   live.go       — IngestMap, IngestMapStats, GetMatchLive
+//   headtohead.go — GetHeadToHead
 //   tournaments.go— GetTournaments, GetTournamentBySlug, GetTournament, GetTournamentBracket,
 //                   GetTournamentMatches, GetTournamentTeams, GetTournamentStats
 //   transfers.go  — GetTransfers
-//   stats.go      — GetTopKDPlayers, GetAllPlayersKDStats
+//   stats.go      — GetTopKDPlayers, GetAllPlayersKDStats, GetLeaderboard
+//   ratings.go    — GetTeamRatingHistory, GetRankings
+//   follows.go    — Follow, Unfollow, GetFollows, GetFeed
+//   pickem.go     — SubmitPick, GetMyPicks, GetPickemLeaderboard
+//   fantasy.go    — ListFantasyLeagues, CreateFantasyLeague, JoinFantasyLeague, GetFantasyLeague,
+//                   GetFantasyPool, SetFantasyLineup, GetFantasyStandings
+//   notifications.go — GetNotifications, MarkNotificationRead, MarkAllNotificationsRead,
+//                   GetNotificationPreferences, UpdateNotificationPreferences
+//   webhooks.go   — ListWebhooks, CreateWebhook, DeleteWebhook, GetWebhookDeliveries,
+//                   ReplayWebhook
+//   admin.go      — AdminCreateMatch, AdminUpdateMatch, AdminDeleteMatch, AdminSaveMap,
+//                   AdminDeleteMap, AdminSaveStatLine, AdminDeleteStatLine
+//                   ListChanges, GetChangeHistory, RevertChange
 
 import (
+	"context"
+	"errors"
 	"math"
+	"net/http"
 	"strconv"
 
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/pubsub"
 	"github.com/corbynfang/CDL-Website/internal/services"
 	"github.com/corbynfang/CDL-Website/internal/store"
 	"github.com/gin-gonic/gin"
@@ -38,6 +59,16 @@ type Handler struct {
 	stats       *services.StatsService
 	users       *services.UserService
 	threads     *services.ThreadService
+	ratings     *services.RatingService
+	standings   *services.StandingsService
+	predictions *services.PredictionService
+	live        *services.LiveService
+	follows     *services.FollowService
+	pickem      *services.PickemService
+	fantasy     *services.FantasyService
+	inbox       *services.NotificationService
+	webhooks    *services.WebhookService
+	admin       *services.AdminService
 }
 
 func New(db *gorm.DB) *Handler {
@@ -51,6 +82,20 @@ func New(db *gorm.DB) *Handler {
 	statsStore := store.NewGormStatsStore(db)
 	userStore := store.NewGormUserStore(db)
 	threadStore := store.NewGormThreadStore(db)
+	ratingStore := store.NewGormRatingStore(db)
+	standingsStore := store.NewGormStandingsStore(db)
+	predictionStore := store.NewGormPredictionStore(db)
+	liveStore := store.NewGormLiveStore(db)
+	followStore := store.NewGormFollowStore(db)
+	pickemStore := store.NewGormPickemStore(db)
+	fantasyStore := store.NewGormFantasyStore(db)
+	notificationStore := store.NewGormNotificationStore(db)
+	webhookStore := store.NewGormWebhookStore(db)
+	adminStore := store.NewGormAdminStore(db)
+
+	hub := pubsub.NewMemoryHub(pubsub.DefaultBuffer)
+	guard := services.NewSpamGuard(services.DefaultSpamConfig(), services.BlocklistFromEnv())
+	matches := services.NewMatchService(matchStore, teamStore)
 
 	return &Handler{
 		db:          db,
@@ -58,13 +103,37 @@ func New(db *gorm.DB) *Handler {
 		teams:       services.NewTeamService(teamStore, seasonStore),
 		seasons:     services.NewSeasonService(seasonStore),
 		franchises:  services.NewFranchiseService(franchiseStore),
-		matches:     services.NewMatchService(matchStore),
+		matches:     matches,
 		tournaments: services.NewTournamentService(tournamentStore),
 		transfers:   services.NewTransferService(transferStore),
 		stats:       services.NewStatsService(statsStore),
 		users:       services.NewUserService(userStore),
-		threads:     services.NewThreadService(threadStore),
+		threads:     services.NewThreadService(threadStore, hub, guard),
+		ratings:     services.NewRatingService(ratingStore, seasonStore, services.DefaultRatingConfig()),
+		standings:   services.NewStandingsService(standingsStore, seasonStore),
+		predictions: services.NewPredictionService(predictionStore, matchStore, services.DefaultRatingConfig(), services.DefaultPredictionConfig()),
+		live:        services.NewLiveService(liveStore, matches, hub),
+		follows:     services.NewFollowService(followStore, services.DefaultFeedConfig()),
+		pickem:      services.NewPickemService(pickemStore, seasonStore, tournamentStore, services.DefaultPickemConfig()),
+		fantasy:     services.NewFantasyService(fantasyStore, tournamentStore, services.DefaultFantasyConfig()),
+		inbox:       services.NewNotificationService(notificationStore),
+		webhooks:    services.NewWebhookService(webhookStore),
+		admin:       services.NewAdminService(adminStore, tournamentStore, teamStore),
+	}
+}
+
+// currentUser resolves the signed-in caller, writing the error response if it can't.
+func (h *Handler) currentUser(ctx context.Context, c *gin.Context) (*models.User, bool) {
+	user, err := h.users.GetBySupabaseUID(ctx, c.GetString("supabase_uid"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "complete profile setup first"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user"})
+		}
+		return nil, false
 	}
+	return user, true
 }
 
 func validateID(id string) (int, error) {
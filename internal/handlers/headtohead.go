@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// parseH2HSide reads one side of a head-to-head query: franchise_<x> (a franchise
+// key, unioning every era team) takes precedence over team_<x> (a team ID).
+func parseH2HSide(c *gin.Context, suffix string) (services.HeadToHeadSide, bool) {
+	if key := c.Query("franchise_" + suffix); key != "" {
+		return services.HeadToHeadSide{FranchiseKey: key}, true
+	}
+	id, err := validateID(c.Query("team_" + suffix))
+	if err != nil || id <= 0 {
+		return services.HeadToHeadSide{}, false
+	}
+	return services.HeadToHeadSide{TeamID: id}, true
+}
+
+func (h *Handler) GetHeadToHead(c *gin.Context) {
+	a, okA := parseH2HSide(c, "a")
+	b, okB := parseH2HSide(c, "b")
+	if !okA || !okB {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "team_a/franchise_a and team_b/franchise_b are required"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	result, err := h.matches.GetHeadToHead(ctx, a, b)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidHeadToHead):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrFranchiseNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Franchise not found"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		default:
+			log.Printf("GetHeadToHead error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch head-to-head"})
+		}
+		return
+	}
+	longCacheHeaders(c)
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// liveHeartbeat is how often an idle stream gets a comment line, so proxies keep it
+// open and dead clients are noticed.
+const liveHeartbeat = 15 * time.Second
+
+// liveParams reads the match ID and map number shared by the ingestion routes.
+func liveParams(c *gin.Context) (matchID, mapNumber int, ok bool) {
+	matchID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return 0, 0, false
+	}
+	mapNumber, err = strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid map number"})
+		return 0, 0, false
+	}
+	return matchID, mapNumber, true
+}
+
+func liveError(c *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidLiveUpdate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+	default:
+		log.Printf("%s error: %v", op, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save live update"})
+	}
+}
+
+func (h *Handler) IngestMap(c *gin.Context) {
+	matchID, mapNumber, ok := liveParams(c)
+	if !ok {
+		return
+	}
+	var body services.LiveMapUpdate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	ev, err := h.live.UpdateMap(ctx, matchID, mapNumber, body)
+	if err != nil {
+		liveError(c, "IngestMap", err)
+		return
+	}
+	c.JSON(http.StatusOK, ev)
+}
+
+func (h *Handler) IngestMapStats(c *gin.Context) {
+	matchID, mapNumber, ok := liveParams(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Stats []services.LiveStatLine `json:"stats" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	ev, err := h.live.UpdateMapStats(ctx, matchID, mapNumber, body.Stats)
+	if err != nil {
+		liveError(c, "IngestMapStats", err)
+		return
+	}
+	c.JSON(http.StatusOK, ev)
+}
+
+// GetMatchLive streams a match as Server-Sent Events: one snapshot event carrying the
+// GetMatch payload, then an update event per ingested change.
+func (h *Handler) GetMatchLive(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	detail, events, unsubscribe, err := h.live.Subscribe(ctx, id)
+	cancel()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+			return
+		}
+		log.Printf("GetMatchLive error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch match"})
+		return
+	}
+	defer unsubscribe()
+
+	streamEvents(c, services.LiveEventSnapshot, services.LiveEvent{Type: services.LiveEventSnapshot, Detail: detail}, events)
+}
+
+// streamEvents turns the response into a Server-Sent Events stream: it sends first as
+// an event named firstName, then relays each JSON payload from events under the name in
+// its "type" field, until the client leaves or the channel is closed.
+func streamEvents(c *gin.Context, firstName string, first any, events <-chan []byte) {
+	// The server's WriteTimeout would cut every stream short; the heartbeat takes over.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	noCacheHeaders(c)
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent(firstName, first)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	done := c.Request.Context().Done()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case payload, ok := <-events:
+			if !ok {
+				return false
+			}
+			var head struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(payload, &head); err != nil || head.Type == "" {
+				head.Type = "message"
+			}
+			c.SSEvent(head.Type, string(payload))
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case <-done:
+			return false
+		}
+	})
+}
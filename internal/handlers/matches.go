@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"errors"
+	"log"
 	"net/http"
 	"time"
 
@@ -31,3 +32,26 @@ func (h *Handler) GetMatch(c *gin.Context) {
 	longCacheHeaders(c)
 	c.JSON(http.StatusOK, detail)
 }
+
+func (h *Handler) GetMatchPrediction(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	prediction, err := h.predictions.Predict(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+			return
+		}
+		log.Printf("GetMatchPrediction error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute prediction"})
+		return
+	}
+	shortCacheHeaders(c)
+	c.JSON(http.StatusOK, prediction)
+}
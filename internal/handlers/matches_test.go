@@ -116,3 +116,58 @@ func TestGetMatch_EmptyStatArrays(t *testing.T) {
 	assert.Len(t, team1Stats, 0)
 	assert.Len(t, team2Stats, 0)
 }
+
+func TestGetHeadToHead_MissingSide(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(nil, "team_a=1")
+	h.GetHeadToHead(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, errBody(t, w.Body.Bytes()), "team_b")
+}
+
+func TestGetHeadToHead_InvalidTeamID(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(nil, "team_a=abc&team_b=2")
+	h.GetHeadToHead(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetMatchPrediction_InvalidID(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "abc"}}, "")
+	h.GetMatchPrediction(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid match ID", errBody(t, w.Body.Bytes()))
+}
+
+func TestGetMatchPrediction_NotFound(t *testing.T) {
+	mock := setupMockDB(t)
+	mock.ExpectQuery(`FROM matches m`).
+		WillReturnRows(sqlmock.NewRows([]string{"match_id"}))
+
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "999"}}, "")
+	h.GetMatchPrediction(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Match not found", errBody(t, w.Body.Bytes()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetMatchLive_InvalidID(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "abc"}}, "")
+	h.GetMatchLive(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid match ID", errBody(t, w.Body.Bytes()))
+}
+
+func TestIngestMap_InvalidMapNumber(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "1"}, {Key: "number", Value: "first"}}, "")
+	h.IngestMap(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid map number", errBody(t, w.Body.Bytes()))
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Moderation endpoints. Handlers only resolve the caller; ThreadService checks roles,
+// so a plain user calling any /mod route gets 403 from the service.
+
+// moderationError maps ThreadService moderation errors to responses; notFound names
+// the missing thing for 404s and failed describes the action for 500s.
+func moderationError(c *gin.Context, err error, notFound, failed string) {
+	switch {
+	case errors.Is(err, services.ErrForbidden), errors.Is(err, services.ErrAdminRequired),
+		errors.Is(err, services.ErrOutranked), errors.Is(err, services.ErrBanned):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyReported), errors.Is(err, services.ErrReportClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidReason), errors.Is(err, services.ErrInvalidDuration),
+		errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrInvalidReportStatus),
+		errors.Is(err, services.ErrInvalidReportAction):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound + " not found"})
+	default:
+		log.Printf("moderation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + failed})
+	}
+}
+
+type reasonBody struct {
+	Reason string `json:"reason"`
+}
+
+// ReportPost lets any signed-in user flag a post for the moderator queue.
+func (h *Handler) ReportPost(c *gin.Context) {
+	postID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return
+	}
+	var body reasonBody
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	report, err := h.threads.ReportPost(ctx, user.ID, uint(postID), body.Reason)
+	if err != nil {
+		moderationError(c, err, "post", "report post")
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// ListReports is the moderator queue; ?status= defaults to open, "all" lists everything.
+func (h *Handler) ListReports(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ReportOpen)
+	if status == "all" {
+		status = ""
+	}
+	page, limit, _ := parsePagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	reports, total, err := h.threads.ListReports(ctx, user.ID, status, page, limit)
+	if err != nil {
+		moderationError(c, err, "report", "fetch reports")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": reports, "pagination": buildMeta(page, limit, int(total))})
+}
+
+// ResolveReport closes an open report with action "hide" or "dismiss".
+func (h *Handler) ResolveReport(c *gin.Context) {
+	reportID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid report ID"})
+		return
+	}
+	var body struct {
+		Action string `json:"action" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	if err := h.threads.ResolveReport(ctx, user.ID, uint(reportID), body.Action, body.Reason); err != nil {
+		moderationError(c, err, "report", "resolve report")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "report resolved"})
+}
+
+func (h *Handler) HidePost(c *gin.Context)    { h.setPostHidden(c, true) }
+func (h *Handler) RestorePost(c *gin.Context) { h.setPostHidden(c, false) }
+
+func (h *Handler) setPostHidden(c *gin.Context, hidden bool) {
+	postID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return
+	}
+	var body reasonBody
+	_ = c.ShouldBindJSON(&body) // the reason is optional
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	if hidden {
+		err = h.threads.HidePost(ctx, user.ID, uint(postID), body.Reason)
+	} else {
+		err = h.threads.RestorePost(ctx, user.ID, uint(postID), body.Reason)
+	}
+	if err != nil {
+		moderationError(c, err, "post", "update post")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"post_id": postID, "hidden": hidden})
+}
+
+func (h *Handler) LockThread(c *gin.Context)   { h.setThreadLock(c, true) }
+func (h *Handler) UnlockThread(c *gin.Context) { h.setThreadLock(c, false) }
+
+func (h *Handler) setThreadLock(c *gin.Context, locked bool) {
+	matchID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match ID"})
+		return
+	}
+	var body reasonBody
+	_ = c.ShouldBindJSON(&body) // the reason is optional
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	thread, err := h.threads.SetThreadLock(ctx, user.ID, uint(matchID), locked, body.Reason)
+	if err != nil {
+		moderationError(c, err, "thread", "update thread")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"thread_id": thread.ID, "match_id": thread.MatchID, "locked": thread.Locked})
+}
+
+type sanctionFunc func(ctx context.Context, moderatorID, userID uint, d time.Duration, reason string) (*time.Time, error)
+
+// MuteUser and BanUser take {"minutes": n, "reason": ...}; 0 minutes lifts the sanction.
+func (h *Handler) MuteUser(c *gin.Context) { h.sanctionUser(c, h.threads.MuteUser) }
+func (h *Handler) BanUser(c *gin.Context)  { h.sanctionUser(c, h.threads.BanUser) }
+
+func (h *Handler) sanctionUser(c *gin.Context, sanction sanctionFunc) {
+	userID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var body struct {
+		Minutes *int   `json:"minutes" binding:"required"`
+		Reason  string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minutes is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	until, err := sanction(ctx, user.ID, uint(userID), time.Duration(*body.Minutes)*time.Minute, body.Reason)
+	if err != nil {
+		moderationError(c, err, "user", "update user")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "until": until})
+}
+
+// SetUserRole is admin-only: {"role": "user"|"moderator"|"admin", "reason": ...}.
+func (h *Handler) SetUserRole(c *gin.Context) {
+	userID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var body struct {
+		Role   string `json:"role" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	if err := h.threads.SetRole(ctx, user.ID, uint(userID), body.Role, body.Reason); err != nil {
+		moderationError(c, err, "user", "update role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": body.Role})
+}
+
+// GetModerationLog pages through the audit trail, newest first.
+func (h *Handler) GetModerationLog(c *gin.Context) {
+	page, limit, _ := parsePagination(c)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	entries, total, err := h.threads.ListModerationLog(ctx, user.ID, page, limit)
+	if err != nil {
+		moderationError(c, err, "entry", "fetch moderation log")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": entries, "pagination": buildMeta(page, limit, int(total))})
+}
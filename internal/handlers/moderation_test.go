@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModRoutes_RequireAuth(t *testing.T) {
+	t.Setenv("SUPABASE_JWT_SECRET", testJWTSecret)
+	r := newTestRouter(New(nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/mod/reports", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestModeration_ReportHideLockAudit(t *testing.T) {
+	setupPGTx(t)
+	pgMatchEnv(t)
+	pgMatch(t, 1)
+
+	posterToken := signJWT(t, "uid-mod-poster")
+	fanToken := signJWT(t, "uid-mod-fan")
+	modToken := signJWT(t, "uid-mod-mod")
+	r := newTestRouter(New(database.DB))
+	for _, tc := range []struct{ token, username string }{{posterToken, "ModPoster"}, {fanToken, "ModFan"}, {modToken, "ModMod"}} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": tc.username}), tc.token))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	require.NoError(t, database.DB.Model(&models.User{}).Where("username = ?", "ModMod").Update("role", models.RoleModerator).Error)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/matches/1/thread/posts", jsonBody(t, map[string]string{"body": "buy cheap camos"}), posterToken))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var post map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &post))
+	postID := int(post["id"].(float64))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, postPath(postID)+"/report", jsonBody(t, map[string]string{"reason": "spam"}), fanToken))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, postPath(postID)+"/report", jsonBody(t, map[string]string{"reason": "spam"}), fanToken))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/mod/reports", nil, fanToken))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/mod/reports", nil, modToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	var queue map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &queue))
+	reports := queue["data"].([]any)
+	require.Len(t, reports, 1)
+	reportID := int(reports[0].(map[string]any)["id"].(float64))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, fmt.Sprintf("/api/v1/mod/reports/%d/resolve", reportID), jsonBody(t, map[string]string{"action": "hide", "reason": "spam"}), modToken))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/matches/1/thread", nil))
+	var thread map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &thread))
+	assert.Empty(t, thread["data"], "hidden posts are not listed")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/mod/matches/1/thread/lock", nil, modToken))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/matches/1/thread/posts", jsonBody(t, map[string]string{"body": "why was I hidden"}), posterToken))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/mod/log", nil, modToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	var log map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &log))
+	entries := log["data"].([]any)
+	require.Len(t, entries, 2)
+	assert.Equal(t, models.ModLockThread, entries[0].(map[string]any)["action"])
+	assert.Equal(t, models.ModHidePost, entries[1].(map[string]any)["action"])
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetNotifications pages through the caller's notifications; ?unread=true shows only unread ones.
+func (h *Handler) GetNotifications(c *gin.Context) {
+	page, limit, _ := parsePagination(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	items, total, unread, err := h.inbox.List(ctx, user.ID, c.Query("unread") == "true", page, limit)
+	if err != nil {
+		log.Printf("GetNotifications error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notifications"})
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": items, "unread": unread, "pagination": buildMeta(page, limit, int(total))})
+}
+
+func (h *Handler) MarkNotificationRead(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	if err := h.inbox.MarkRead(ctx, user.ID, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+			return
+		}
+		log.Printf("MarkNotificationRead error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update notification"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "read": true})
+}
+
+func (h *Handler) MarkAllNotificationsRead(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	n, err := h.inbox.MarkAllRead(ctx, user.ID)
+	if err != nil {
+		log.Printf("MarkAllNotificationsRead error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": n})
+}
+
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	pref, err := h.inbox.GetPreferences(ctx, user.ID)
+	if err != nil {
+		log.Printf("GetNotificationPreferences error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch preferences"})
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, pref)
+}
+
+func (h *Handler) UpdateNotificationPreferences(c *gin.Context) {
+	var body services.NotificationPreferencesInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	pref, err := h.inbox.UpdatePreferences(ctx, user.ID, body)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPreferences) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("UpdateNotificationPreferences error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications_RequireAuth(t *testing.T) {
+	t.Setenv("SUPABASE_JWT_SECRET", testJWTSecret)
+	r := newTestRouter(New(nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/me/notifications", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestNotifications_GenerateReadAndPreferences(t *testing.T) {
+	setupPGTx(t)
+	pgMatchEnv(t)
+
+	fanToken := signJWT(t, "uid-notify-fan")
+	posterToken := signJWT(t, "uid-notify-poster")
+	r := newTestRouter(New(database.DB))
+	for _, tc := range []struct{ token, username string }{{fanToken, "NotifyFan"}, {posterToken, "NotifyPoster"}} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": tc.username}), tc.token))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/me/follows/teams/1", nil, fanToken))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	pgMatch(t, 1)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/matches/1/thread/posts", jsonBody(t, map[string]string{"body": "@NotifyFan called it"}), posterToken))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	worker := services.NewNotificationWorker(store.NewGormNotificationStore(database.DB), services.DefaultNotifyWorkerConfig())
+	for range 2 {
+		_, err := worker.RunOnce(context.Background())
+		require.NoError(t, err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/me/notifications", nil, fanToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	var inbox map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &inbox))
+	items := inbox["data"].([]any)
+	require.Len(t, items, 2, "one match and one mention, not duplicated by the second pass")
+	assert.EqualValues(t, 2, inbox["unread"])
+	kinds := map[string]bool{}
+	for _, it := range items {
+		kinds[it.(map[string]any)["kind"].(string)] = true
+	}
+	assert.Equal(t, map[string]bool{"match": true, "mention": true}, kinds)
+
+	firstID := int(items[0].(map[string]any)["id"].(float64))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, fmt.Sprintf("/api/v1/me/notifications/%d/read", firstID), nil, posterToken))
+	assert.Equal(t, http.StatusNotFound, w.Code, "cannot mark someone else's notification")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, fmt.Sprintf("/api/v1/me/notifications/%d/read", firstID), nil, fanToken))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/me/notifications?unread=true", nil, fanToken))
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &inbox))
+	assert.Len(t, inbox["data"], 1)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/me/notifications/read-all", nil, fanToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	var marked map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &marked))
+	assert.EqualValues(t, 1, marked["marked"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/me/notification-preferences", jsonBody(t, map[string]any{"email_enabled": true}), fanToken))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/me/notification-preferences", jsonBody(t, map[string]any{"mentions": true, "webhook_enabled": true, "webhook_url": "https://bot.example.com/hook"}), fanToken))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/me/notification-preferences", nil, fanToken))
+	var pref map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &pref))
+	assert.Equal(t, false, pref["matches"])
+	assert.Equal(t, true, pref["webhook_enabled"])
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SubmitPick creates or replaces the caller's pick'em pick for a match that has not started.
+func (h *Handler) SubmitPick(c *gin.Context) {
+	matchID, err := validateID(c.Param("id"))
+	if err != nil || matchID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match ID"})
+		return
+	}
+	var body services.PickInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "winner_id, team1_score and team2_score are required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	pick, err := h.pickem.SubmitPick(ctx, user.ID, matchID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+		case errors.Is(err, services.ErrPicksLocked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidPick):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "winner_id must be one of the two teams, with a finished series score in their favour"})
+		default:
+			log.Printf("SubmitPick error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save pick"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, pick)
+}
+
+func (h *Handler) GetMyPicks(c *gin.Context) {
+	page, limit, _ := parsePagination(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+
+	picks, total, err := h.pickem.ListUserPicks(ctx, user.ID, page, limit)
+	if err != nil {
+		log.Printf("GetMyPicks error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch picks"})
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": picks, "pagination": buildMeta(page, limit, int(total))})
+}
+
+// GetPickemLeaderboard ranks pick'em users for ?tournament_id= or ?season_id= (default: active season).
+func (h *Handler) GetPickemLeaderboard(c *gin.Context) {
+	page, limit, _ := parsePagination(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	board, total, err := h.pickem.GetLeaderboard(ctx, c.Query("tournament_id"), c.Query("season_id"), page, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTournament):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament"})
+		case errors.Is(err, services.ErrInvalidSeason):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "No active season found"})
+		default:
+			log.Printf("GetPickemLeaderboard error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		}
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": board, "pagination": buildMeta(page, limit, int(total))})
+}
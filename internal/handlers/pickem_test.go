@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickem_InvalidTournament(t *testing.T) {
+	r := newTestRouter(New(nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/pickem/leaderboard?tournament_id=abc", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPickem_PickLockScoreLeaderboard(t *testing.T) {
+	setupPGTx(t)
+	pgMatchEnv(t)
+	require.NoError(t, database.DB.Create(&models.Match{
+		ID: 1, TournamentID: 1, Team1ID: 1, Team2ID: 2, Format: "BO5",
+		MatchDate: time.Now().Add(time.Hour),
+	}).Error)
+
+	token := signJWT(t, "uid-pickem")
+	r := newTestRouter(New(database.DB))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": "Picker"}), token))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/matches/1/pick", jsonBody(t, map[string]int{"winner_id": 1, "team1_score": 2, "team2_score": 3}), token))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	for _, score := range []int{0, 1} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/matches/1/pick", jsonBody(t, map[string]int{"winner_id": 1, "team1_score": 3, "team2_score": score}), token))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	require.NoError(t, database.DB.Model(&models.Match{}).Where("id = 1").Updates(map[string]any{
+		"winner_id": 1, "team1_score": 3, "team2_score": 1, "match_date": time.Now().Add(-time.Hour),
+	}).Error)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPut, "/api/v1/matches/1/pick", jsonBody(t, map[string]int{"winner_id": 2, "team1_score": 0, "team2_score": 3}), token))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/pickem/leaderboard?tournament_id=1", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var board map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &board))
+	users := board["data"].(map[string]any)["users"].([]any)
+	require.Len(t, users, 1)
+	top := users[0].(map[string]any)
+	assert.Equal(t, "Picker", top["username"])
+	assert.EqualValues(t, 1, top["rank"])
+	assert.EqualValues(t, 5, top["points"], "right winner and exact score")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, "/api/v1/me/picks", nil, token))
+	require.Equal(t, http.StatusOK, w.Code)
+	var history map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &history))
+	picks := history["data"].([]any)
+	require.Len(t, picks, 1, "resubmitting replaces the pick")
+	assert.EqualValues(t, 5, picks[0].(map[string]any)["points"])
+}
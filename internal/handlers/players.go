@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/corbynfang/CDL-Website/internal/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func (h *Handler) GetPlayers(c *gin.Context) {
@@ -122,3 +128,97 @@ func (h *Handler) GetPlayerFranchiseCareer(c *gin.Context) {
 	longCacheHeaders(c)
 	c.JSON(http.StatusOK, result)
 }
+
+func (h *Handler) GetPlayerComparison(c *gin.Context) {
+	var ids []int
+	for _, raw := range strings.Split(c.Query("ids"), ",") {
+		id, err := validateID(strings.TrimSpace(raw))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids must be a comma-separated list of player IDs"})
+			return
+		}
+		ids = append(ids, id)
+	}
+	seasonID := c.Query("season_id")
+	if _, err := validateID(seasonID); seasonID != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season_id"})
+		return
+	}
+	tournamentID := c.Query("tournament_id")
+	if _, err := validateID(tournamentID); tournamentID != "" && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tournament_id"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	result, err := h.players.Compare(ctx, ids, seasonID, tournamentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidComparison):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Player not found"})
+		default:
+			log.Printf("GetPlayerComparison error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare players"})
+		}
+		return
+	}
+	longCacheHeaders(c)
+	c.JSON(http.StatusOK, result)
+}
+
+// parseFormOptions reads the optional maps / series / days / points window sizes.
+func parseFormOptions(c *gin.Context) (services.FormOptions, bool) {
+	opts := services.DefaultFormOptions(time.Now().UTC())
+	for _, f := range []struct {
+		name string
+		dst  *int
+		max  int
+	}{
+		{"maps", &opts.Maps, 100},
+		{"series", &opts.Series, 50},
+		{"days", &opts.Days, 365},
+		{"points", &opts.Points, 1000},
+	} {
+		v := c.Query(f.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > f.max {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be between 1 and %d", f.name, f.max)})
+			return opts, false
+		}
+		*f.dst = n
+	}
+	return opts, true
+}
+
+func (h *Handler) GetPlayerForm(c *gin.Context) {
+	playerID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid player ID"})
+		return
+	}
+	opts, ok := parseFormOptions(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	form, err := h.players.GetForm(ctx, playerID, opts)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Player not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("GetPlayerForm error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch player form"})
+		return
+	}
+	shortCacheHeaders(c)
+	c.JSON(http.StatusOK, form)
+}
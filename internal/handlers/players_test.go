@@ -323,3 +323,29 @@ func TestGetPlayerFranchiseCareer_EmptyForExistingPlayer(t *testing.T) {
 	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &career))
 	assert.Equal(t, "Scump", career["gamertag"])
 }
+
+func TestGetPlayerComparison_InvalidIDs(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(nil, "ids=1,abc")
+	h.GetPlayerComparison(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, errBody(t, w.Body.Bytes()), "ids")
+}
+
+func TestGetPlayerComparison_TooFewPlayers(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(nil, "ids=7")
+	h.GetPlayerComparison(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetPlayerComparison_InvalidSeason(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(nil, "ids=1,2&season_id=x")
+	h.GetPlayerComparison(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid season_id", errBody(t, w.Body.Bytes()))
+}
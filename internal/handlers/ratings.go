@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) GetTeamRatingHistory(c *gin.Context) {
+	teamID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	if _, err := h.teams.GetByID(ctx, teamID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+			return
+		}
+		log.Printf("GetTeamRatingHistory error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rating history"})
+		return
+	}
+	result, err := h.ratings.GetTeamHistory(ctx, teamID)
+	if err != nil {
+		log.Printf("GetTeamRatingHistory error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rating history"})
+		return
+	}
+	shortCacheHeaders(c)
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) GetRankings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	rankings, err := h.ratings.GetRankings(ctx, c.Query("season_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSeason):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "No active season found"})
+		default:
+			log.Printf("GetRankings error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rankings"})
+		}
+		return
+	}
+	shortCacheHeaders(c)
+	c.JSON(http.StatusOK, rankings)
+}
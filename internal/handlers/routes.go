@@ -12,21 +12,90 @@ func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
 	auth.GET("/me", h.GetMe)
 	auth.DELETE("/me", h.DeleteMe)
 
+	me := rg.Group("/me")
+	me.Use(middleware.RequireAuth())
+	me.GET("/follows", h.GetFollows)
+	me.PUT("/follows/:type/:id", h.Follow)
+	me.DELETE("/follows/:type/:id", h.Unfollow)
+	me.GET("/feed", h.GetFeed)
+	me.GET("/picks", h.GetMyPicks)
+	me.GET("/notifications", h.GetNotifications)
+	me.POST("/notifications/read-all", h.MarkAllNotificationsRead)
+	me.POST("/notifications/:id/read", h.MarkNotificationRead)
+	me.GET("/notification-preferences", h.GetNotificationPreferences)
+	me.PUT("/notification-preferences", h.UpdateNotificationPreferences)
+
 	rg.GET("/matches/:id/thread", h.GetThread)
+	rg.GET("/matches/:id/thread/events", h.GetThreadEvents)
 	protected := rg.Group("/")
 	protected.Use(middleware.RequireAuth())
 	protected.POST("/matches/:id/thread/posts", h.CreatePost)
 	protected.PUT("/thread/posts/:id", h.EditPost)
 	protected.DELETE("/thread/posts/:id", h.DeletePost)
+	protected.POST("/thread/posts/:id/reactions", h.AddReaction)
+	protected.DELETE("/thread/posts/:id/reactions/:emoji", h.RemoveReaction)
+	protected.POST("/thread/posts/:id/report", h.ReportPost)
+	protected.PUT("/matches/:id/pick", h.SubmitPick)
+
+	fantasy := rg.Group("/fantasy")
+	fantasy.Use(middleware.RequireAuth())
+	fantasy.GET("/leagues", h.ListFantasyLeagues)
+	fantasy.POST("/leagues", h.CreateFantasyLeague)
+	fantasy.POST("/leagues/join", h.JoinFantasyLeague)
+	fantasy.GET("/leagues/:id", h.GetFantasyLeague)
+	fantasy.GET("/leagues/:id/players", h.GetFantasyPool)
+	fantasy.PUT("/leagues/:id/lineup", h.SetFantasyLineup)
+	fantasy.GET("/leagues/:id/standings", h.GetFantasyStandings)
+
+	mod := rg.Group("/mod")
+	mod.Use(middleware.RequireAuth())
+	mod.GET("/reports", h.ListReports)
+	mod.POST("/reports/:id/resolve", h.ResolveReport)
+	mod.POST("/posts/:id/hide", h.HidePost)
+	mod.POST("/posts/:id/restore", h.RestorePost)
+	mod.POST("/matches/:id/thread/lock", h.LockThread)
+	mod.POST("/matches/:id/thread/unlock", h.UnlockThread)
+	mod.POST("/users/:id/mute", h.MuteUser)
+	mod.POST("/users/:id/ban", h.BanUser)
+	mod.PUT("/users/:id/role", h.SetUserRole)
+	mod.GET("/log", h.GetModerationLog)
+
+	admin := rg.Group("/admin")
+	admin.Use(middleware.RequireAuth())
+	admin.GET("/webhooks", h.ListWebhooks)
+	admin.POST("/webhooks", h.CreateWebhook)
+	admin.DELETE("/webhooks/:id", h.DeleteWebhook)
+	admin.GET("/webhooks/:id/deliveries", h.GetWebhookDeliveries)
+	admin.POST("/webhooks/:id/replay", h.ReplayWebhook)
+	admin.POST("/matches", h.AdminCreateMatch)
+	admin.PUT("/matches/:id", h.AdminUpdateMatch)
+	admin.DELETE("/matches/:id", h.AdminDeleteMatch)
+	admin.PUT("/matches/:id/maps/:number", h.AdminSaveMap)
+	admin.DELETE("/matches/:id/maps/:number", h.AdminDeleteMap)
+	admin.PUT("/matches/:id/maps/:number/stats/:player", h.AdminSaveStatLine)
+	admin.DELETE("/matches/:id/maps/:number/stats/:player", h.AdminDeleteStatLine)
+	admin.GET("/changes", h.ListChanges)
+	admin.GET("/changes/:entity/:key", h.GetChangeHistory)
+	admin.POST("/changes/:id/revert", h.RevertChange)
+
+	ingest := rg.Group("/ingest")
+	ingest.Use(middleware.RequireIngestKey())
+	ingest.PUT("/matches/:id/maps/:number", h.IngestMap)
+	ingest.PUT("/matches/:id/maps/:number/stats", h.IngestMapStats)
 
 	rg.GET("/seasons", h.GetSeasons)
 	rg.GET("/seasons/:id", h.GetSeason)
 	rg.GET("/seasons/active", h.GetActiveSeason)
+	rg.GET("/seasons/:id/standings", h.GetSeasonStandings)
 
 	rg.GET("/teams", h.GetTeams)
 	rg.GET("/teams/:id", h.GetTeam)
 	rg.GET("/teams/:id/players", h.GetTeamPlayers)
 	rg.GET("/teams/:id/stats", h.GetTeamStats)
+	rg.GET("/teams/:id/map-pool", h.GetTeamMapPool)
+	rg.GET("/teams/:id/form", h.GetTeamForm)
+	rg.GET("/teams/:id/lineups", h.GetTeamLineups)
+	rg.GET("/teams/:id/rating-history", h.GetTeamRatingHistory)
 
 	rg.GET("/players", h.GetPlayers)
 	rg.GET("/players/:id", h.GetPlayer)
@@ -34,11 +103,17 @@ func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
 	rg.GET("/players/:id/kd", h.GetPlayerKDStats)
 	rg.GET("/players/:id/matches", h.GetPlayerMatches)
 	rg.GET("/players/:id/franchise-career", h.GetPlayerFranchiseCareer)
+	rg.GET("/players/:id/form", h.GetPlayerForm)
 	rg.GET("/players/top-kd", h.GetTopKDPlayers)
+	rg.GET("/players/compare", h.GetPlayerComparison)
+	rg.GET("/leaderboards", h.GetLeaderboard)
 
 	rg.GET("/stats/all-kd-by-tournament", h.GetAllPlayersKDStats)
 
 	rg.GET("/matches/:id", h.GetMatch)
+	rg.GET("/matches/:id/prediction", h.GetMatchPrediction)
+	rg.GET("/matches/:id/live", h.GetMatchLive)
+	rg.GET("/head-to-head", h.GetHeadToHead)
 
 	rg.GET("/franchises", h.GetFranchises)
 	rg.GET("/franchises/:key", h.GetFranchise)
@@ -52,4 +127,7 @@ func RegisterRoutes(rg *gin.RouterGroup, h *Handler) {
 	rg.GET("/tournaments/:id/stats", h.GetTournamentStats)
 
 	rg.GET("/transfers", h.GetTransfers)
+
+	rg.GET("/rankings", h.GetRankings)
+	rg.GET("/pickem/leaderboard", h.GetPickemLeaderboard)
 }
@@ -34,19 +34,30 @@ func TestRegisterRoutes_AllRoutesPresent(t *testing.T) {
 		"GET /api/v1/seasons",
 		"GET /api/v1/seasons/:id",
 		"GET /api/v1/seasons/active",
+		"GET /api/v1/seasons/:id/standings",
 		"GET /api/v1/teams",
 		"GET /api/v1/teams/:id",
 		"GET /api/v1/teams/:id/players",
 		"GET /api/v1/teams/:id/stats",
+		"GET /api/v1/teams/:id/map-pool",
+		"GET /api/v1/teams/:id/form",
+		"GET /api/v1/teams/:id/lineups",
+		"GET /api/v1/teams/:id/rating-history",
 		"GET /api/v1/players",
 		"GET /api/v1/players/:id",
 		"GET /api/v1/players/:id/stats",
 		"GET /api/v1/players/:id/kd",
 		"GET /api/v1/players/:id/matches",
 		"GET /api/v1/players/:id/franchise-career",
+		"GET /api/v1/players/:id/form",
 		"GET /api/v1/players/top-kd",
+		"GET /api/v1/players/compare",
+		"GET /api/v1/leaderboards",
 		"GET /api/v1/stats/all-kd-by-tournament",
 		"GET /api/v1/matches/:id",
+		"GET /api/v1/matches/:id/prediction",
+		"GET /api/v1/matches/:id/live",
+		"GET /api/v1/head-to-head",
 		"GET /api/v1/franchises",
 		"GET /api/v1/franchises/:key",
 		"GET /api/v1/tournaments",
@@ -57,13 +68,64 @@ func TestRegisterRoutes_AllRoutesPresent(t *testing.T) {
 		"GET /api/v1/tournaments/:id/teams",
 		"GET /api/v1/tournaments/:id/stats",
 		"GET /api/v1/transfers",
+		"GET /api/v1/rankings",
+		"GET /api/v1/pickem/leaderboard",
 		"POST /api/v1/auth/profile",
 		"GET /api/v1/auth/me",
 		"DELETE /api/v1/auth/me",
+		"GET /api/v1/me/follows",
+		"PUT /api/v1/me/follows/:type/:id",
+		"DELETE /api/v1/me/follows/:type/:id",
+		"GET /api/v1/me/feed",
+		"GET /api/v1/me/picks",
+		"GET /api/v1/me/notifications",
+		"POST /api/v1/me/notifications/read-all",
+		"POST /api/v1/me/notifications/:id/read",
+		"GET /api/v1/me/notification-preferences",
+		"PUT /api/v1/me/notification-preferences",
 		"GET /api/v1/matches/:id/thread",
+		"GET /api/v1/matches/:id/thread/events",
 		"POST /api/v1/matches/:id/thread/posts",
+		"PUT /api/v1/ingest/matches/:id/maps/:number",
+		"PUT /api/v1/ingest/matches/:id/maps/:number/stats",
 		"PUT /api/v1/thread/posts/:id",
 		"DELETE /api/v1/thread/posts/:id",
+		"POST /api/v1/thread/posts/:id/reactions",
+		"DELETE /api/v1/thread/posts/:id/reactions/:emoji",
+		"POST /api/v1/thread/posts/:id/report",
+		"PUT /api/v1/matches/:id/pick",
+		"GET /api/v1/fantasy/leagues",
+		"POST /api/v1/fantasy/leagues",
+		"POST /api/v1/fantasy/leagues/join",
+		"GET /api/v1/fantasy/leagues/:id",
+		"GET /api/v1/fantasy/leagues/:id/players",
+		"PUT /api/v1/fantasy/leagues/:id/lineup",
+		"GET /api/v1/fantasy/leagues/:id/standings",
+		"GET /api/v1/mod/reports",
+		"POST /api/v1/mod/reports/:id/resolve",
+		"POST /api/v1/mod/posts/:id/hide",
+		"POST /api/v1/mod/posts/:id/restore",
+		"POST /api/v1/mod/matches/:id/thread/lock",
+		"POST /api/v1/mod/matches/:id/thread/unlock",
+		"POST /api/v1/mod/users/:id/mute",
+		"POST /api/v1/mod/users/:id/ban",
+		"PUT /api/v1/mod/users/:id/role",
+		"GET /api/v1/mod/log",
+		"GET /api/v1/admin/webhooks",
+		"POST /api/v1/admin/webhooks",
+		"DELETE /api/v1/admin/webhooks/:id",
+		"GET /api/v1/admin/webhooks/:id/deliveries",
+		"POST /api/v1/admin/webhooks/:id/replay",
+		"POST /api/v1/admin/matches",
+		"PUT /api/v1/admin/matches/:id",
+		"DELETE /api/v1/admin/matches/:id",
+		"PUT /api/v1/admin/matches/:id/maps/:number",
+		"DELETE /api/v1/admin/matches/:id/maps/:number",
+		"PUT /api/v1/admin/matches/:id/maps/:number/stats/:player",
+		"DELETE /api/v1/admin/matches/:id/maps/:number/stats/:player",
+		"GET /api/v1/admin/changes",
+		"GET /api/v1/admin/changes/:entity/:key",
+		"POST /api/v1/admin/changes/:id/revert",
 	}
 
 	for _, w := range want {
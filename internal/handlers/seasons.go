@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func (h *Handler) GetSeasons(c *gin.Context) {
@@ -53,3 +55,26 @@ func (h *Handler) GetActiveSeason(c *gin.Context) {
 	shortCacheHeaders(c)
 	c.JSON(http.StatusOK, season)
 }
+
+func (h *Handler) GetSeasonStandings(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	standings, err := h.standings.GetStandings(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Season not found"})
+			return
+		}
+		log.Printf("GetSeasonStandings error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch standings"})
+		return
+	}
+	shortCacheHeaders(c)
+	c.JSON(http.StatusOK, standings)
+}
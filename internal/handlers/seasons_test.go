@@ -88,3 +88,25 @@ func TestGetActiveSeason_NotFound(t *testing.T) {
 	assert.Equal(t, "No active season found", body["error"])
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetSeasonStandings_InvalidID(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "abc"}}, "")
+	h.GetSeasonStandings(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid season ID", errBody(t, w.Body.Bytes()))
+}
+
+func TestGetSeasonStandings_NotFound(t *testing.T) {
+	mock := setupMockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "seasons"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "999"}}, "")
+	h.GetSeasonStandings(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Season not found", errBody(t, w.Body.Bytes()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -68,3 +69,53 @@ func (h *Handler) GetAllPlayersKDStats(c *gin.Context) {
 		"count":   len(enriched),
 	})
 }
+
+func (h *Handler) GetLeaderboard(c *gin.Context) {
+	params := services.LeaderboardParams{
+		Stat:    c.DefaultQuery("stat", "kd"),
+		Mode:    c.Query("mode"),
+		MinMaps: -1,
+		Limit:   100,
+	}
+	for name, dst := range map[string]*string{
+		"season_id":     &params.SeasonID,
+		"tournament_id": &params.TournamentID,
+		"team_id":       &params.TeamID,
+	} {
+		v := c.Query(name)
+		if _, err := validateID(v); v != "" && err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+			return
+		}
+		*dst = v
+	}
+	if v := c.Query("min_maps"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_maps"})
+			return
+		}
+		params.MinMaps = n
+	}
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 500 {
+			params.Limit = parsed
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	board, err := h.stats.GetLeaderboard(ctx, params)
+	if errors.Is(err, services.ErrInvalidLeaderboard) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("GetLeaderboard error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch leaderboard"})
+		return
+	}
+	shortCacheHeaders(c)
+	c.JSON(http.StatusOK, board)
+}
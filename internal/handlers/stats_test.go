@@ -229,3 +229,63 @@ func TestGetPlayerStats_Empty(t *testing.T) {
 	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
 	assert.Empty(t, stats)
 }
+
+func TestGetLeaderboard_UnknownStat(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(nil, "stat=bogus")
+	h.GetLeaderboard(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, errBody(t, w.Body.Bytes()), "bogus")
+}
+
+func TestGetLeaderboard_ModeOnTournamentStat(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(nil, "stat=snd_k_per_map&mode=hp")
+	h.GetLeaderboard(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetLeaderboard_InvalidFilters(t *testing.T) {
+	h := newTestHandler(t)
+	for _, q := range []string{"team_id=x", "season_id=1.5", "min_maps=-3"} {
+		c, w := newCtx(nil, q)
+		h.GetLeaderboard(c)
+		assert.Equal(t, http.StatusBadRequest, w.Code, q)
+	}
+}
+
+func TestGetLeaderboard_RanksTies(t *testing.T) {
+	mock := setupMockDB(t)
+	mock.ExpectQuery(`SELECT.*FROM player_map_stats pms.*HAVING`).WillReturnRows(
+		sqlmock.NewRows([]string{"player_id", "gamertag", "avatar_url", "team_abbr", "maps", "value"}).
+			AddRow(1, "Shotzzy", "", "OTX", 40, 260.5).
+			AddRow(2, "Simp", "", "ATL", 38, 250.0).
+			AddRow(3, "aBeZy", "", "ATL", 38, 250.0).
+			AddRow(4, "Dashy", "", "OTX", 40, 240.0))
+
+	h := newTestHandler(t)
+	c, w := newCtx(nil, "stat=damage_per_map&mode=hp&min_maps=20")
+	h.GetLeaderboard(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Mode    string `json:"mode"`
+		MinMaps int    `json:"min_maps"`
+		Players []struct {
+			Rank       int     `json:"rank"`
+			Percentile float64 `json:"percentile"`
+			Gamertag   string  `json:"gamertag"`
+		} `json:"players"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "hp", body.Mode)
+	assert.Equal(t, 20, body.MinMaps)
+	require.Len(t, body.Players, 4)
+	assert.Equal(t, []int{1, 2, 2, 4}, []int{body.Players[0].Rank, body.Players[1].Rank, body.Players[2].Rank, body.Players[3].Rank})
+	assert.InDelta(t, 100.0, body.Players[0].Percentile, 0.01)
+	assert.InDelta(t, 100.0/3, body.Players[1].Percentile, 0.01)
+	assert.InDelta(t, 0.0, body.Players[3].Percentile, 0.01)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"github.com/corbynfang/CDL-Website/internal/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func (h *Handler) GetTeams(c *gin.Context) {
@@ -106,3 +107,100 @@ func (h *Handler) GetTeamStats(c *gin.Context) {
 	longCacheHeaders(c)
 	c.JSON(http.StatusOK, stats)
 }
+
+func (h *Handler) GetTeamMapPool(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	pool, err := h.teams.GetMapPool(ctx, id, c.Query("season_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSeason):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		default:
+			log.Printf("GetTeamMapPool error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team map pool"})
+		}
+		return
+	}
+	longCacheHeaders(c)
+	c.JSON(http.StatusOK, pool)
+}
+
+func (h *Handler) GetTeamForm(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+	opts, ok := parseFormOptions(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	form, err := h.teams.GetForm(ctx, id, opts)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("GetTeamForm error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team form"})
+		return
+	}
+	shortCacheHeaders(c)
+	c.JSON(http.StatusOK, form)
+}
+
+func (h *Handler) GetTeamLineups(c *gin.Context) {
+	id, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+	minMaps := 1
+	if v := c.Query("min_maps"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_maps"})
+			return
+		}
+		minMaps = n
+	}
+	core := 0
+	if v := c.Query("core"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 2 || n > services.MaxLineupCore {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid core"})
+			return
+		}
+		core = n
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	lineups, err := h.teams.GetLineups(ctx, id, c.Query("season_id"), minMaps, core)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSeason):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid season"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		default:
+			log.Printf("GetTeamLineups error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team lineups"})
+		}
+		return
+	}
+	longCacheHeaders(c)
+	c.JSON(http.StatusOK, lineups)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,6 +42,21 @@ func (f *fakeTeamStore) GetByID(context.Context, int) (*models.Team, error) { re
 func (f *fakeTeamStore) GetStats(context.Context, int) ([]models.TeamTournamentStats, error) {
 	return nil, nil
 }
+func (f *fakeTeamStore) ListByFranchiseKey(context.Context, string) ([]models.Team, error) {
+	return nil, nil
+}
+func (f *fakeTeamStore) ListMapPoolRows(context.Context, int, uint) ([]store.MapPoolRow, error) {
+	return nil, nil
+}
+func (f *fakeTeamStore) ListFormMaps(context.Context, int) ([]store.FormMapRow, error) {
+	return nil, nil
+}
+func (f *fakeTeamStore) ListFormSeries(context.Context, int) ([]store.FormSeriesRow, error) {
+	return nil, nil
+}
+func (f *fakeTeamStore) ListLineupMaps(context.Context, int, string) ([]store.LineupMapRow, error) {
+	return nil, nil
+}
 
 func handlerWithFakeTeams(f *fakeTeamStore) *Handler {
 	return &Handler{teams: services.NewTeamService(f, nil)}
@@ -124,6 +140,15 @@ func TestGetTeamPlayers_ScopeAllUsesStints(t *testing.T) {
 	assert.Equal(t, "2", f.calledSeason)
 }
 
+func TestGetTeamMapPool_InvalidTeamID(t *testing.T) {
+	h := handlerWithFakeTeams(&fakeTeamStore{})
+	c, w := newCtx(gin.Params{{Key: "id", Value: "abc"}}, "")
+	h.GetTeamMapPool(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid team ID", errBody(t, w.Body.Bytes()))
+}
+
 func TestGetTeam_InvalidID(t *testing.T) {
 	h := newTestHandler(t)
 	c, w := newCtx(gin.Params{{Key: "id", Value: "notanumber"}}, "")
@@ -147,6 +172,20 @@ func TestGetTeam_NotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestGetTeamRatingHistory_NotFound(t *testing.T) {
+	mock := setupMockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "teams"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "999"}}, "")
+	h.GetTeamRatingHistory(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Team not found", errBody(t, w.Body.Bytes()))
+	assert.NoError(t, mock.ExpectationsWereMet(), "history must not be queried for an unknown team")
+}
+
 func TestGetTeam_Success(t *testing.T) {
 	mock := setupMockDB(t)
 	rows := sqlmock.NewRows([]string{"id", "name", "abbreviation", "city", "logo_url",
@@ -198,3 +237,32 @@ func TestGetTeams_ScopeAll_Empty(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetTeamForm_InvalidWindow(t *testing.T) {
+	h := handlerWithFakeTeams(&fakeTeamStore{})
+	c, w := newCtx(gin.Params{{Key: "id", Value: "1"}}, "maps=0")
+	h.GetTeamForm(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "maps must be between 1 and 100", errBody(t, w.Body.Bytes()))
+}
+
+func TestGetTeamLineups_InvalidMinMaps(t *testing.T) {
+	h := handlerWithFakeTeams(&fakeTeamStore{})
+	c, w := newCtx(gin.Params{{Key: "id", Value: "1"}}, "min_maps=zero")
+	h.GetTeamLineups(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid min_maps", errBody(t, w.Body.Bytes()))
+}
+
+func TestGetTeamLineups_InvalidCore(t *testing.T) {
+	for _, core := range []string{"x", "1", "6"} {
+		h := handlerWithFakeTeams(&fakeTeamStore{})
+		c, w := newCtx(gin.Params{{Key: "id", Value: "1"}}, "core="+core)
+		h.GetTeamLineups(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, core)
+		assert.Equal(t, "Invalid core", errBody(t, w.Body.Bytes()))
+	}
+}
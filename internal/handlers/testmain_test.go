@@ -58,26 +58,8 @@ func runTests(m *testing.M) int {
 		return m.Run()
 	}
 
-	if err = db.AutoMigrate(
-		&models.Franchise{},
-		&models.Player{},
-		&models.Season{},
-		&models.Team{},
-		&models.TeamRoster{},
-		&models.Tournament{},
-		&models.Match{},
-		&models.MatchMap{},
-		&models.PlayerMapStats{},
-		&models.PlayerMatchStats{},
-		&models.PlayerTournamentStats{},
-		&models.TeamTournamentStats{},
-		&models.Coach{},
-		&models.PlayerTransfer{},
-		&models.User{},
-		&models.MatchThread{},
-		&models.ThreadPost{},
-	); err != nil {
-		log.Println("gorm: automigrate failed:", err)
+	if err = database.Migrate(db); err != nil {
+		log.Println("migrate failed:", err)
 		return m.Run()
 	}
 
@@ -5,37 +5,79 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/corbynfang/CDL-Website/internal/models"
 	"github.com/corbynfang/CDL-Website/internal/services"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
+// GetThread lists a match thread's posts. ?order=tree nests replies under their
+// parents and paginates top-level posts; the default, flat, lists every post oldest first.
 func (h *Handler) GetThread(c *gin.Context) {
 	matchID, err := validateID(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match ID"})
 		return
 	}
+	order, err := services.ParsePostOrder(c.Query("order"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	page, limit, _ := parsePagination(c)
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
 	defer cancel()
 
-	posts, total, threadID, err := h.threads.GetThread(ctx, uint(matchID), page, limit)
+	posts, total, thread, err := h.threads.GetThread(ctx, uint(matchID), order, page, limit)
 	if err != nil {
 		log.Printf("GetThread error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch thread"})
 		return
 	}
+	var threadID uint
+	locked := false
+	if thread != nil {
+		threadID, locked = thread.ID, thread.Locked
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"thread_id":  threadID,
+		"locked":     locked,
+		"order":      order,
 		"data":       posts,
 		"pagination": buildMeta(page, limit, int(total)),
 	})
 }
 
+// GetThreadEvents streams a match thread's post_created / post_edited / post_deleted /
+// reactions_updated events as Server-Sent Events. The first event, "ready", means the subscription is
+// live, so a client that loads the thread with GetThread after it misses nothing.
+func (h *Handler) GetThreadEvents(c *gin.Context) {
+	matchID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid match ID"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	events, unsubscribe, err := h.threads.Subscribe(ctx, uint(matchID))
+	cancel()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "match not found"})
+			return
+		}
+		log.Printf("GetThreadEvents error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load match"})
+		return
+	}
+	defer unsubscribe()
+
+	streamEvents(c, "ready", gin.H{"type": "ready", "match_id": matchID}, events)
+}
+
 func (h *Handler) CreatePost(c *gin.Context) {
 	matchID, err := validateID(c.Param("id"))
 	if err != nil {
@@ -64,20 +106,36 @@ func (h *Handler) CreatePost(c *gin.Context) {
 	}
 
 	var body struct {
-		Body string `json:"body" binding:"required"`
+		Body     string `json:"body" binding:"required"`
+		ParentID *uint  `json:"parent_id"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "body is required"})
 		return
 	}
 
-	post, err := h.threads.CreatePost(ctx, threadID, user.ID, body.Body)
+	var post *models.ThreadPost
+	if body.ParentID != nil {
+		post, err = h.threads.CreateReply(ctx, threadID, user.ID, *body.ParentID, body.Body)
+	} else {
+		post, err = h.threads.CreatePost(ctx, threadID, user.ID, body.Body)
+	}
 	if err != nil {
+		var limited *services.RateLimitError
 		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.Seconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDuplicatePost), errors.Is(err, services.ErrTooManyLinks), errors.Is(err, services.ErrBlockedContent):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		case errors.Is(err, services.ErrPostEmpty):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		case errors.Is(err, services.ErrPostTooLong):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrParentNotFound), errors.Is(err, services.ErrReplyTooDeep):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrThreadLocked), errors.Is(err, services.ErrMuted), errors.Is(err, services.ErrBanned):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create post"})
 		}
@@ -117,9 +175,17 @@ func (h *Handler) EditPost(c *gin.Context) {
 
 	err = h.threads.EditPost(ctx, uint(postID), user.ID, body.Body)
 	if err != nil {
+		var limited *services.RateLimitError
 		switch {
+		case errors.As(err, &limited):
+			c.Header("Retry-After", strconv.Itoa(limited.Seconds()))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrDuplicatePost), errors.Is(err, services.ErrTooManyLinks), errors.Is(err, services.ErrBlockedContent):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
 		case errors.Is(err, services.ErrNotOwner):
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrThreadLocked), errors.Is(err, services.ErrMuted), errors.Is(err, services.ErrBanned):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		case errors.Is(err, services.ErrPostEmpty), errors.Is(err, services.ErrPostTooLong):
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		case errors.Is(err, gorm.ErrRecordNotFound):
@@ -167,3 +233,68 @@ func (h *Handler) DeletePost(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "post deleted"})
 }
+
+// AddReaction reacts to a post with the emoji in the body and returns its reaction counts.
+func (h *Handler) AddReaction(c *gin.Context) {
+	postID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return
+	}
+
+	var body struct {
+		Emoji string `json:"emoji" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "emoji is required"})
+		return
+	}
+
+	h.changeReaction(c, uint(postID), body.Emoji, h.threads.React)
+}
+
+// RemoveReaction takes back the caller's :emoji reaction and returns the post's reaction counts.
+func (h *Handler) RemoveReaction(c *gin.Context) {
+	postID, err := validateID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return
+	}
+	h.changeReaction(c, uint(postID), c.Param("emoji"), h.threads.Unreact)
+}
+
+// reactionChange is ThreadService.React or ThreadService.Unreact.
+type reactionChange func(ctx context.Context, postID, userID uint, emoji string) ([]models.ReactionCount, error)
+
+func (h *Handler) changeReaction(c *gin.Context, postID uint, emoji string, change reactionChange) {
+	uid := c.GetString("supabase_uid")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.users.GetBySupabaseUID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "complete profile setup first"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user"})
+		}
+		return
+	}
+
+	counts, err := change(ctx, postID, user.ID, emoji)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidEmoji):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrThreadLocked), errors.Is(err, services.ErrMuted), errors.Is(err, services.ErrBanned):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+		default:
+			log.Printf("reaction error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update reaction"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"post_id": postID, "reactions": counts})
+}
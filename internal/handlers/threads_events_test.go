@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/pubsub"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readEvent reads one SSE event (up to the blank line) and returns its name and data.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if name != "" || data != "" {
+				return name, data
+			}
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+}
+
+func TestGetThreadEvents_RelaysPublishedEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mock := setupMockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "matches"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+	hub := pubsub.NewMemoryHub(pubsub.DefaultBuffer)
+	h := &Handler{threads: services.NewThreadService(store.NewGormThreadStore(database.DB), hub, nil)}
+	r := gin.New()
+	r.GET("/matches/:id/thread/events", h.GetThreadEvents)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/matches/5/thread/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	body := bufio.NewReader(resp.Body)
+	name, data := readEvent(t, body)
+	assert.Equal(t, "ready", name)
+	assert.Contains(t, data, `"match_id":5`)
+
+	payload := `{"type":"post_deleted","match_id":5,"thread_id":1,"post_id":9}`
+	require.NoError(t, hub.Publish(ctx, "match.5.thread", []byte(payload)))
+	require.NoError(t, hub.Publish(ctx, "match.6.thread", []byte(`{"type":"post_deleted"}`)))
+	name, data = readEvent(t, body)
+	assert.Equal(t, services.ThreadPostDeleted, name)
+	assert.JSONEq(t, payload, data)
+
+	cancel()
+	assert.Eventually(t, func() bool { return hub.Subscribers("match.5.thread") == 0 },
+		time.Second, 10*time.Millisecond, "subscription ends when the client leaves")
+}
+
+func TestGetThreadEvents_InvalidID(t *testing.T) {
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "abc"}}, "")
+	h.GetThreadEvents(c)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetThreadEvents_MatchNotFound(t *testing.T) {
+	mock := setupMockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "matches"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	h := newTestHandler(t)
+	c, w := newCtx(gin.Params{{Key: "id", Value: "404"}}, "")
+	h.GetThreadEvents(c)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -76,3 +76,80 @@ func TestDeleteAccount_CascadesToPosts(t *testing.T) {
 	posts, _ := resp["data"].([]any)
 	assert.Empty(t, posts, "posts should be soft-deleted when account is deleted")
 }
+
+func TestThread_RepliesReactionsMentions(t *testing.T) {
+	setupPGTx(t)
+	pgMatchEnv(t)
+	pgMatch(t, 1)
+
+	authorToken := signJWT(t, "uid-tree-author")
+	fanToken := signJWT(t, "uid-tree-fan")
+	r := newTestRouter(New(database.DB))
+	for _, tc := range []struct{ token, username string }{{authorToken, "TreeAuthor"}, {fanToken, "TreeFan"}} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": tc.username}), tc.token))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	create := func(token string, body map[string]any) map[string]any {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/matches/1/thread/posts", jsonBody(t, body), token))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var post map[string]any
+		require.NoError(t, decodeJSON(w.Body.Bytes(), &post))
+		return post
+	}
+
+	root := create(authorToken, map[string]any{"body": "map 1 was wild, right @treefan?"})
+	rootID := int(root["id"].(float64))
+	mentions := root["mentions"].([]any)
+	require.Len(t, mentions, 1)
+	assert.Equal(t, "TreeFan", mentions[0].(map[string]any)["username"])
+
+	reply := create(fanToken, map[string]any{"body": "insane", "parent_id": rootID})
+	assert.EqualValues(t, 1, reply["depth"])
+	create(authorToken, map[string]any{"body": "second topic"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/matches/1/thread/posts", jsonBody(t, map[string]any{"body": "x", "parent_id": 999999}), fanToken))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	for _, token := range []string{authorToken, fanToken, fanToken} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPost, postPath(rootID)+"/reactions", jsonBody(t, map[string]string{"emoji": "🔥"}), token))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	var reacted map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &reacted))
+	assert.Equal(t, []any{map[string]any{"emoji": "🔥", "count": float64(2)}}, reacted["reactions"])
+
+	// Deleting the root leaves a tombstone in tree order because its reply is live.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodDelete, postPath(rootID), nil, authorToken))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/matches/1/thread?order=tree", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var tree map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &tree))
+	posts := tree["data"].([]any)
+	require.Len(t, posts, 2)
+	first := posts[0].(map[string]any)
+	assert.Equal(t, true, first["deleted"])
+	assert.Equal(t, "", first["body"])
+	assert.Empty(t, first["reactions"])
+	replies := first["replies"].([]any)
+	require.Len(t, replies, 1)
+	assert.Equal(t, "insane", replies[0].(map[string]any)["body"])
+	assert.EqualValues(t, 2, tree["pagination"].(map[string]any)["total"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/matches/1/thread", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var flat map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &flat))
+	assert.Len(t, flat["data"].([]any), 2, "flat order lists live posts only")
+	assert.EqualValues(t, 2, flat["pagination"].(map[string]any)["total"])
+}
@@ -117,3 +117,10 @@ func TestDeletePost_OnlyOwnerCanDelete(t *testing.T) {
 	r.ServeHTTP(w, authReq(http.MethodDelete, postPath(postID), nil, ownerToken))
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestGetThread_InvalidOrder(t *testing.T) {
+	r := newTestRouter(New(nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/matches/1/thread?order=newest", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
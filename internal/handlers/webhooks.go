@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Outbound webhook admin endpoints. Handlers only resolve the caller; WebhookService
+// checks the admin role, so anyone else gets 403 from the service.
+
+// webhookError maps WebhookService errors to responses; failed describes the action for 500s.
+func webhookError(c *gin.Context, err error, failed string) {
+	switch {
+	case errors.Is(err, services.ErrAdminRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidWebhook), errors.Is(err, services.ErrInvalidReplay):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+	default:
+		log.Printf("webhook error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + failed})
+	}
+}
+
+// webhookID reads /admin/webhooks/:id, writing the error response if it can't.
+func webhookID(c *gin.Context) (uint, bool) {
+	id, err := validateID(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	subs, err := h.webhooks.ListSubscriptions(ctx, user)
+	if err != nil {
+		webhookError(c, err, "fetch webhooks")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": subs})
+}
+
+// CreateWebhook registers a subscription. The response carries the signing secret,
+// which is not shown again.
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var body services.WebhookInput
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	created, err := h.webhooks.CreateSubscription(ctx, user, body)
+	if err != nil {
+		webhookError(c, err, "create webhook")
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// DeleteWebhook disables a subscription; its delivery log is kept.
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	if err := h.webhooks.DisableSubscription(ctx, user, id); err != nil {
+		webhookError(c, err, "disable webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "active": false})
+}
+
+// GetWebhookDeliveries pages through a subscription's deliveries with their attempt
+// log; ?status= filters to pending, sent or failed.
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	page, limit, _ := parsePagination(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	deliveries, total, err := h.webhooks.ListDeliveries(ctx, user, id, c.Query("status"), page, limit)
+	if err != nil {
+		webhookError(c, err, "fetch deliveries")
+		return
+	}
+	noCacheHeaders(c)
+	c.JSON(http.StatusOK, gin.H{"data": deliveries, "pagination": buildMeta(page, limit, int(total))})
+}
+
+// ReplayWebhook re-queues every event recorded between since and until (default now)
+// for the subscription; the worker sends them on its next pass.
+func (h *Handler) ReplayWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		Since time.Time `json:"since" binding:"required"`
+		Until time.Time `json:"until"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since is required (RFC 3339)"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	queued, err := h.webhooks.Replay(ctx, user, id, body.Since, body.Until)
+	if err != nil {
+		webhookError(c, err, "replay webhook")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"id": id, "queued": queued})
+}
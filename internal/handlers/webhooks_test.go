@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/database"
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/notify"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks_RequireAuth(t *testing.T) {
+	t.Setenv("SUPABASE_JWT_SECRET", testJWTSecret)
+	r := newTestRouter(New(nil))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestWebhooks_DeliverLogAndReplay(t *testing.T) {
+	setupPGTx(t)
+	pgMatchEnv(t)
+
+	adminToken := signJWT(t, "uid-webhook-admin")
+	fanToken := signJWT(t, "uid-webhook-fan")
+	r := newTestRouter(New(database.DB))
+	for _, tc := range []struct{ token, username string }{{adminToken, "HookAdmin"}, {fanToken, "HookFan"}} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/auth/profile", jsonBody(t, map[string]string{"username": tc.username}), tc.token))
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	require.NoError(t, database.DB.Model(&models.User{}).Where("username = ?", "HookAdmin").Update("role", models.RoleAdmin).Error)
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	consumer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		mu.Lock()
+		received, bodies = append(received, req), append(bodies, body)
+		mu.Unlock()
+	}))
+	defer consumer.Close()
+
+	create := map[string]any{"url": consumer.URL, "events": []string{models.EventMatchCompleted}}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/admin/webhooks", jsonBody(t, create), fanToken))
+	assert.Equal(t, http.StatusForbidden, w.Code, "admins only")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, "/api/v1/admin/webhooks", jsonBody(t, create), adminToken))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var sub map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &sub))
+	secret := sub["secret"].(string)
+	base := fmt.Sprintf("/api/v1/admin/webhooks/%d", int(sub["id"].(float64)))
+
+	pgMatch(t, 1)
+	worker := services.NewWebhookWorker(store.NewGormWebhookStore(database.DB), notify.NewPoster(5*time.Second, true), services.DefaultWebhookWorkerConfig())
+	for range 2 {
+		_, err := worker.RunOnce(context.Background())
+		require.NoError(t, err)
+	}
+	require.Len(t, received, 1, "one match.completed, not repeated by the second pass")
+	ts, err := strconv.ParseInt(received[0].Header.Get(services.WebhookTimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, models.EventMatchCompleted, received[0].Header.Get(services.WebhookEventHeader))
+	assert.Equal(t, services.SignWebhook(secret, ts, bodies[0]), received[0].Header.Get(services.WebhookSignatureHeader))
+	var event map[string]any
+	require.NoError(t, decodeJSON(bodies[0], &event))
+	assert.EqualValues(t, 1, event["data"].(map[string]any)["match_id"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodGet, base+"/deliveries", nil, adminToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	var deliveries map[string]any
+	require.NoError(t, decodeJSON(w.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries["data"], 1)
+	delivery := deliveries["data"].([]any)[0].(map[string]any)
+	assert.Equal(t, models.DeliverySent, delivery["status"])
+	require.Len(t, delivery["log"], 1)
+	assert.EqualValues(t, 200, delivery["log"].([]any)[0].(map[string]any)["status_code"])
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, base+"/replay", jsonBody(t, map[string]any{"since": time.Now().Add(-time.Hour)}), adminToken))
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	_, err = worker.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, received, 2, "replay re-sends the event")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodDelete, base, nil, adminToken))
+	require.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, authReq(http.MethodPost, base+"/replay", jsonBody(t, map[string]any{"since": time.Now().Add(-time.Hour)}), adminToken))
+	assert.Equal(t, http.StatusBadRequest, w.Code, "disabled subscriptions cannot replay")
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIngestKey guards the live ingestion API with a shared key sent as
+// "Authorization: Bearer <key>". The key comes from INGEST_API_KEY; when it is unset
+// ingestion is disabled rather than open.
+func RequireIngestKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := os.Getenv("INGEST_API_KEY")
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "ingestion disabled"})
+			return
+		}
+		raw := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if raw == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing ingest key"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(raw), []byte(key)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid ingest key"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func fireIngest(authorization string) int {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/api/v1/ingest/matches/1/maps/1", nil)
+	if authorization != "" {
+		c.Request.Header.Set("Authorization", authorization)
+	}
+
+	RequireIngestKey()(c)
+
+	if !c.IsAborted() {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.Code
+}
+
+func TestRequireIngestKey_DisabledWithoutKey(t *testing.T) {
+	t.Setenv("INGEST_API_KEY", "")
+	assert.Equal(t, http.StatusServiceUnavailable, fireIngest("Bearer anything"))
+}
+
+func TestRequireIngestKey(t *testing.T) {
+	t.Setenv("INGEST_API_KEY", "s3cret")
+	assert.Equal(t, http.StatusUnauthorized, fireIngest(""))
+	assert.Equal(t, http.StatusUnauthorized, fireIngest("Bearer wrong"))
+	assert.Equal(t, http.StatusOK, fireIngest("Bearer s3cret"))
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Entities recorded in the change log. Keys are the row's natural key joined with
+// ":" — "12" for match 12, "12:2" for its second map, "12:2:40" for player 40's line
+// on that map, "7" for team 7. The rest are keyed by row ID: players, roster stints,
+// coaches, transfers and the imported team and player tournament totals.
+const (
+	ChangeMatch        = "match"
+	ChangeMap          = "map"
+	ChangeStatLine     = "stat_line"
+	ChangeTeam         = "team"
+	ChangePlayer       = "player"
+	ChangeRoster       = "roster"
+	ChangeCoach        = "coach"
+	ChangeTransfer     = "transfer"
+	ChangeTeamTotals   = "team_totals"
+	ChangePlayerTotals = "player_totals"
+)
+
+// Change log actions, by their effect on the row.
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// ChangeLog is the revision history of the core tables: one row per write to a match,
+// map, player stat line, team, player, roster stint, coach, transfer or tournament
+// totals row, with the row (as Postgres to_jsonb renders it) before and
+// after, written in the same transaction as the change. Before is null for a create
+// and After for a delete. Actor is the admin's username or the writer (live, patch,
+// seed); RevertOf points at the change a revert undid.
+type ChangeLog struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	Entity    string          `json:"entity" gorm:"size:16;not null;index:idx_change_entity"`
+	EntityKey string          `json:"entity_key" gorm:"size:64;not null;index:idx_change_entity"`
+	Action    string          `json:"action" gorm:"size:16;not null"`
+	Before    json.RawMessage `json:"before" gorm:"type:jsonb"`
+	After     json.RawMessage `json:"after" gorm:"type:jsonb"`
+	ActorID   *uint           `json:"actor_id" gorm:"index"`
+	Actor     string          `json:"actor" gorm:"size:64;not null"`
+	Reason    string          `json:"reason" gorm:"size:500"`
+	RevertOf  *uint           `json:"revert_of,omitempty"`
+	CreatedAt time.Time       `json:"created_at" gorm:"index"`
+}
+
+func (ChangeLog) TableName() string { return "change_log" }
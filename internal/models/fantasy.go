@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// FantasyLeague is a private fantasy league for one tournament. Members join with
+// InviteCode and pick a lineup of RosterSize players whose salaries fit SalaryCap;
+// lineups lock at LockAt.
+type FantasyLeague struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name" gorm:"not null;size:100"`
+	TournamentID uint      `json:"tournament_id" gorm:"not null;index"`
+	OwnerID      uint      `json:"owner_id" gorm:"not null;index"`
+	InviteCode   string    `json:"invite_code" gorm:"not null;size:12;uniqueIndex"`
+	SalaryCap    int       `json:"salary_cap" gorm:"not null"`
+	RosterSize   int       `json:"roster_size" gorm:"not null"`
+	LockAt       time.Time `json:"lock_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	Tournament Tournament `json:"tournament" gorm:"foreignKey:TournamentID"`
+}
+
+func (FantasyLeague) TableName() string { return "fantasy_leagues" }
+
+type FantasyMember struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	LeagueID  uint      `json:"league_id" gorm:"not null;uniqueIndex:idx_fantasy_member"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_fantasy_member;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (FantasyMember) TableName() string { return "fantasy_members" }
+
+// FantasyLineupPlayer is one player in a member's lineup, at the salary they cost
+// when picked.
+type FantasyLineupPlayer struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	MemberID  uint      `json:"member_id" gorm:"not null;uniqueIndex:idx_fantasy_lineup"`
+	PlayerID  uint      `json:"player_id" gorm:"not null;uniqueIndex:idx_fantasy_lineup"`
+	Salary    int       `json:"salary"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Player Player `json:"player" gorm:"foreignKey:PlayerID"`
+}
+
+func (FantasyLineupPlayer) TableName() string { return "fantasy_lineup_players" }
+
+// FantasyPrice is a player's salary for a tournament, set from their recent form.
+type FantasyPrice struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	TournamentID uint      `json:"tournament_id" gorm:"not null;uniqueIndex:idx_fantasy_price"`
+	PlayerID     uint      `json:"player_id" gorm:"not null;uniqueIndex:idx_fantasy_price"`
+	TeamID       uint      `json:"team_id"`
+	Salary       int       `json:"salary"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (FantasyPrice) TableName() string { return "fantasy_prices" }
+
+// FantasyPlayerScore is a player's fantasy points over one tournament. The scoring
+// job rewrites a tournament's rows wholesale, so corrected stats are picked up on
+// the next run.
+type FantasyPlayerScore struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	TournamentID uint      `json:"tournament_id" gorm:"not null;uniqueIndex:idx_fantasy_score"`
+	PlayerID     uint      `json:"player_id" gorm:"not null;uniqueIndex:idx_fantasy_score"`
+	Maps         int       `json:"maps"`
+	Points       float64   `json:"points"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (FantasyPlayerScore) TableName() string { return "fantasy_player_scores" }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Follow targets.
+const (
+	FollowPlayer    = "player"
+	FollowTeam      = "team"
+	FollowFranchise = "franchise"
+)
+
+// Follow is a user following a player, team or franchise for their feed.
+type Follow struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_follow"`
+	TargetType string    `json:"target_type" gorm:"size:16;not null;uniqueIndex:idx_follow"`
+	TargetID   uint      `json:"target_id" gorm:"not null;uniqueIndex:idx_follow"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Follow) TableName() string { return "follows" }
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Report statuses.
+const (
+	ReportOpen      = "open"
+	ReportActioned  = "actioned"
+	ReportDismissed = "dismissed"
+)
+
+// PostReport is a user flagging a post for the moderator queue; one per user per post.
+type PostReport struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	PostID     uint        `json:"post_id" gorm:"not null;uniqueIndex:idx_post_report"`
+	ReporterID uint        `json:"reporter_id" gorm:"not null;uniqueIndex:idx_post_report"`
+	Reason     string      `json:"reason" gorm:"size:500;not null"`
+	Status     string      `json:"status" gorm:"size:16;not null;default:open;index"`
+	ResolvedBy *uint       `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time  `json:"resolved_at,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Post       *ThreadPost `json:"post,omitempty" gorm:"foreignKey:PostID"`
+	Reporter   *User       `json:"reporter,omitempty" gorm:"foreignKey:ReporterID"`
+}
+
+func (PostReport) TableName() string { return "post_reports" }
+
+// Moderation log actions.
+const (
+	ModHidePost      = "hide_post"
+	ModRestorePost   = "restore_post"
+	ModLockThread    = "lock_thread"
+	ModUnlockThread  = "unlock_thread"
+	ModMuteUser      = "mute_user"
+	ModBanUser       = "ban_user"
+	ModSetRole       = "set_role"
+	ModDismissReport = "dismiss_report"
+)
+
+// ModerationLog is the audit trail: one row per moderator action, written in the
+// same transaction as the change it records. TargetType is post, thread, user or report.
+type ModerationLog struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ModeratorID uint      `json:"moderator_id" gorm:"not null;index"`
+	Action      string    `json:"action" gorm:"size:32;not null"`
+	TargetType  string    `json:"target_type" gorm:"size:16;not null;index:idx_moderation_target"`
+	TargetID    uint      `json:"target_id" gorm:"not null;index:idx_moderation_target"`
+	Reason      string    `json:"reason" gorm:"size:500"`
+	Detail      string    `json:"detail,omitempty" gorm:"size:200"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+	Moderator   *User     `json:"moderator,omitempty" gorm:"foreignKey:ModeratorID"`
+}
+
+func (ModerationLog) TableName() string { return "moderation_log" }
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Notification kinds.
+const (
+	NotifyMatch    = "match"
+	NotifyMention  = "mention"
+	NotifyTransfer = "transfer"
+)
+
+// Notification is one in-app notification. RefID is the match, post or transfer it
+// is about; the unique index keeps the generator from notifying twice.
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_ref;index:idx_notification_user_created,priority:1"`
+	Kind      string     `json:"kind" gorm:"size:16;not null;uniqueIndex:idx_notification_ref"`
+	RefID     uint       `json:"ref_id" gorm:"not null;uniqueIndex:idx_notification_ref"`
+	Title     string     `json:"title" gorm:"size:200;not null"`
+	Body      string     `json:"body" gorm:"size:500"`
+	Link      string     `json:"link" gorm:"size:200"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index:idx_notification_user_created,priority:2"`
+}
+
+func (Notification) TableName() string { return "notifications" }
+
+// NotificationPreference is a user's notification settings. Users without a row get
+// every kind in-app and no external delivery.
+type NotificationPreference struct {
+	ID             uint      `json:"-" gorm:"primaryKey"`
+	UserID         uint      `json:"-" gorm:"not null;uniqueIndex"`
+	Matches        bool      `json:"matches" gorm:"not null"`
+	Mentions       bool      `json:"mentions" gorm:"not null"`
+	Transfers      bool      `json:"transfers" gorm:"not null"`
+	EmailEnabled   bool      `json:"email_enabled" gorm:"not null"`
+	Email          string    `json:"email" gorm:"size:254"`
+	WebhookEnabled bool      `json:"webhook_enabled" gorm:"not null"`
+	WebhookURL     string    `json:"webhook_url" gorm:"size:500"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (NotificationPreference) TableName() string { return "notification_preferences" }
+
+// Notification delivery statuses.
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// NotificationDelivery is one notification queued for one external channel, with its
+// retry state.
+type NotificationDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	NotificationID uint       `json:"notification_id" gorm:"not null;uniqueIndex:idx_notification_delivery"`
+	Channel        string     `json:"channel" gorm:"size:16;not null;uniqueIndex:idx_notification_delivery"`
+	Status         string     `json:"status" gorm:"size:16;not null;index:idx_delivery_due,priority:1"`
+	Attempts       int        `json:"attempts" gorm:"not null"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_delivery_due,priority:2"`
+	LastError      string     `json:"last_error" gorm:"size:500"`
+	SentAt         *time.Time `json:"sent_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (NotificationDelivery) TableName() string { return "notification_deliveries" }
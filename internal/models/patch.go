@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AppliedPatch records a data patch file (cmd/patch) that has been applied, so
+// re-running the patch directory skips it. Checksum is taken over the parsed patch;
+// a file edited after it was applied is refused rather than re-run.
+type AppliedPatch struct {
+	ID          string    `json:"id" gorm:"primaryKey;size:100"`
+	Checksum    string    `json:"checksum" gorm:"size:64;not null"`
+	Description string    `json:"description" gorm:"size:500"`
+	Changes     int       `json:"changes"`
+	AppliedAt   time.Time `json:"applied_at"`
+}
+
+func (AppliedPatch) TableName() string { return "applied_patches" }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Pick is a user's pick'em prediction for one match: the series winner and the map
+// score. Points stays nil until the match is decided and the pick has been scored;
+// ScoredAt lets a later correction to the result trigger a rescore.
+type Pick struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_pick_user_match"`
+	MatchID    uint       `json:"match_id" gorm:"not null;uniqueIndex:idx_pick_user_match;index"`
+	WinnerID   uint       `json:"winner_id" gorm:"not null"`
+	Team1Score int        `json:"team1_score"`
+	Team2Score int        `json:"team2_score"`
+	Points     *int       `json:"points"`
+	ScoredAt   *time.Time `json:"scored_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Match Match `json:"match" gorm:"foreignKey:MatchID"`
+}
+
+func (Pick) TableName() string { return "picks" }
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// TeamRating is the current Glicko-2 state for one team, as of the last rated match.
+type TeamRating struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	TeamID        uint      `json:"team_id" gorm:"uniqueIndex;not null"`
+	FranchiseID   *uint     `json:"franchise_id" gorm:"index"`
+	GameCode      string    `json:"game_code" gorm:"size:10"`
+	SeasonID      uint      `json:"season_id" gorm:"index"`
+	Rating        float64   `json:"rating"`
+	RD            float64   `json:"rd"`
+	Volatility    float64   `json:"volatility"`
+	MatchesPlayed int       `json:"matches_played" gorm:"default:0"`
+	Wins          int       `json:"wins" gorm:"default:0"`
+	Losses        int       `json:"losses" gorm:"default:0"`
+	LastMatchID   uint      `json:"last_match_id"`
+	LastMatchDate time.Time `json:"last_match_date"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (TeamRating) TableName() string { return "team_ratings" }
+
+// TeamRatingHistory is one team's rating movement across a single rated match.
+// Sequence is the global processing order, so "latest" never depends on match_date quality.
+type TeamRatingHistory struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	TeamID       uint      `json:"team_id" gorm:"not null;uniqueIndex:idx_team_rating_match"`
+	MatchID      uint      `json:"match_id" gorm:"not null;uniqueIndex:idx_team_rating_match;index"`
+	OpponentID   uint      `json:"opponent_id"`
+	SeasonID     uint      `json:"season_id" gorm:"index"`
+	GameCode     string    `json:"game_code" gorm:"size:10"`
+	Sequence     int       `json:"sequence" gorm:"index"`
+	MatchDate    time.Time `json:"match_date"`
+	Won          bool      `json:"won"`
+	RatingBefore float64   `json:"rating_before"`
+	RatingAfter  float64   `json:"rating_after"`
+	RDBefore     float64   `json:"rd_before"`
+	RDAfter      float64   `json:"rd_after"`
+	Volatility   float64   `json:"volatility"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (TeamRatingHistory) TableName() string { return "team_rating_history" }
+
+// PerformanceBaseline is one stat's era (and mode) average and weight from the last
+// full performance rating run. Rebuilding a match's derived stats rates its lines
+// against these, so they stay on the same scale until the next full run.
+type PerformanceBaseline struct {
+	GameCode  string    `json:"game_code" gorm:"primaryKey;size:10"`
+	Mode      string    `json:"mode" gorm:"primaryKey;size:16"`
+	Stat      string    `json:"stat" gorm:"primaryKey;size:32"`
+	Average   float64   `json:"average" gorm:"not null"`
+	Weight    float64   `json:"weight" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (PerformanceBaseline) TableName() string { return "performance_baselines" }
+
+// PendingRollup queues a tournament whose player and team totals are behind its
+// matches. Live ingestion rebuilds only the match it writes to and queues the
+// tournament; the notify worker rolls the totals up on its next pass.
+type PendingRollup struct {
+	TournamentID uint      `json:"tournament_id" gorm:"primaryKey"`
+	QueuedAt     time.Time `json:"queued_at"`
+}
+
+func (PendingRollup) TableName() string { return "pending_rollups" }
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Points rule bases.
+const (
+	PointsBasisPlacement = "placement" // Points for finishing PlacementFrom..PlacementTo at an event
+	PointsBasisMatchWin  = "match_win" // Points for every series won at an event
+)
+
+// SeasonPointsRule is one row of a season's CDL points schema. Rules with a SeasonID
+// apply to that season only and replace the era defaults entirely; rules without one
+// are the defaults for every season of GameCode. TournamentType matches
+// tournaments.tournament_type, so event types without a rule award no points.
+type SeasonPointsRule struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SeasonID       *uint     `json:"season_id" gorm:"index"`
+	GameCode       string    `json:"game_code" gorm:"size:10;index"`
+	TournamentType string    `json:"tournament_type" gorm:"size:50;not null"`
+	Basis          string    `json:"basis" gorm:"size:20;not null"`
+	PlacementFrom  int       `json:"placement_from" gorm:"default:0"`
+	PlacementTo    int       `json:"placement_to" gorm:"default:0"`
+	Points         int       `json:"points" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (SeasonPointsRule) TableName() string { return "season_points_rules" }
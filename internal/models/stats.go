@@ -15,6 +15,7 @@ type PlayerMapStats struct {
 	Assists int     `json:"assists" gorm:"default:0"`
 
 	BPRating float64 `json:"bp_rating" gorm:"type:decimal(10,6);default:0"` // Only used for BreakingPoint Stats if found BPRating within database.
+	Rating   float64 `json:"rating" gorm:"type:decimal(8,4);default:0"`    // In-house performance rating (1.0 = era/mode average, 0 = not rated). See services/perfrating.go.
 
 	HillTime             int `json:"hill_time" gorm:"default:0"`
 	SndRounds            int `json:"snd_rounds" gorm:"default:0"`
@@ -55,6 +56,7 @@ type PlayerMatchStats struct {
 	KDRatio      float64   `json:"kd_ratio" gorm:"type:decimal(4,2);default:0"`
 	KDARatio     float64   `json:"kda_ratio" gorm:"type:decimal(4,2);default:0"`
 	ADR          float64   `json:"adr" gorm:"type:decimal(6,2);default:0"`
+	Rating       float64   `json:"rating" gorm:"type:decimal(8,4);default:0"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 
@@ -76,6 +78,7 @@ type PlayerTournamentStats struct {
 	TotalDamage  int     `json:"total_damage"`
 	KDRatio      float64 `json:"kd_ratio"`
 	KDARatio     float64 `json:"kda_ratio"`
+	Rating       float64 `json:"rating" gorm:"type:decimal(8,4);default:0"`
 
 	Rank             *int    `json:"rank"`
 	OverallPlusMinus int     `json:"overall_plus_minus" gorm:"default:0"`
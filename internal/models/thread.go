@@ -5,6 +5,7 @@ import "time"
 type MatchThread struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
 	MatchID   uint      `json:"match_id" gorm:"uniqueIndex;not null"`
+	Locked    bool      `json:"locked" gorm:"not null;default:false"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Match     Match     `json:"match,omitempty" gorm:"foreignKey:MatchID"`
@@ -12,16 +13,66 @@ type MatchThread struct {
 
 func (MatchThread) TableName() string { return "match_threads" }
 
+// ThreadPost is one post in a match thread. Top-level posts have no ParentID and
+// Depth 0; a reply sits one level below its parent.
+//
+// A post is live until its author deletes it (DeletedAt) or a moderator hides it
+// (HiddenAt); public reads only ever show live posts.
+//
+// The fields after User are filled in on read and never stored. Deleted marks a
+// tombstone: a deleted or hidden post still returned in tree order because live
+// replies hang below it, with its body and author blanked.
 type ThreadPost struct {
-	ID        uint       `json:"id" gorm:"primaryKey"`
-	ThreadID  uint       `json:"thread_id" gorm:"index;not null"`
-	UserID    uint       `json:"user_id" gorm:"index;not null"`
-	Body      string     `json:"body" gorm:"type:text;not null"`
-	Edited    bool       `json:"edited" gorm:"default:false"`
-	DeletedAt *time.Time `json:"-" gorm:"index"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	User      User       `json:"user" gorm:"foreignKey:UserID"`
+	ID         uint            `json:"id" gorm:"primaryKey"`
+	ThreadID   uint            `json:"thread_id" gorm:"index;not null"`
+	UserID     uint            `json:"user_id" gorm:"index;not null"`
+	ParentID   *uint           `json:"parent_id" gorm:"index"`
+	Depth      int             `json:"depth" gorm:"default:0"`
+	Body       string          `json:"body" gorm:"type:text;not null"`
+	Edited     bool            `json:"edited" gorm:"default:false"`
+	DeletedAt  *time.Time      `json:"-" gorm:"index"`
+	HiddenAt   *time.Time      `json:"hidden_at,omitempty" gorm:"index"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	User       User            `json:"user" gorm:"foreignKey:UserID"`
+	Deleted    bool            `json:"deleted,omitempty" gorm:"-"`
+	ReplyCount int             `json:"reply_count" gorm:"-"`
+	Reactions  []ReactionCount `json:"reactions" gorm:"-"`
+	Mentions   []MentionedUser `json:"mentions" gorm:"-"`
+	Replies    []ThreadPost    `json:"replies,omitempty" gorm:"-"`
 }
 
 func (ThreadPost) TableName() string { return "thread_posts" }
+
+// PostReaction is one user's emoji on a post; a user can use each emoji once per post.
+type PostReaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_post_reaction"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_post_reaction;index"`
+	Emoji     string    `json:"emoji" gorm:"not null;size:32;uniqueIndex:idx_post_reaction"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (PostReaction) TableName() string { return "post_reactions" }
+
+// PostMention records that a post @mentions a user.
+type PostMention struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_post_mention"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_post_mention;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (PostMention) TableName() string { return "post_mentions" }
+
+// ReactionCount is how many users reacted to a post with one emoji.
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// MentionedUser is a user an @mention in a post resolved to.
+type MentionedUser struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+}
@@ -2,13 +2,44 @@ package models
 
 import "time"
 
+// User roles, lowest to highest.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	ID          uint       `json:"id" gorm:"primaryKey"`
 	SupabaseUID string     `json:"-" gorm:"uniqueIndex;not null;size:36"`
 	Username    string     `json:"username" gorm:"uniqueIndex;not null;size:30"`
+	Role        string     `json:"role" gorm:"size:16;not null;default:user"`
+	MutedUntil  *time.Time `json:"-"`
+	BannedUntil *time.Time `json:"-"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 	DeletedAt   *time.Time `json:"-" gorm:"index"`
 }
 
 func (User) TableName() string { return "users" }
+
+// RoleRank orders roles so a moderator can act on users but not on other moderators.
+// Unknown roles rank as RoleUser.
+func RoleRank(role string) int {
+	switch role {
+	case RoleAdmin:
+		return 2
+	case RoleModerator:
+		return 1
+	}
+	return 0
+}
+
+// IsModerator reports whether u can use the moderation tools; admins can.
+func (u *User) IsModerator() bool { return RoleRank(u.Role) >= RoleRank(RoleModerator) }
+
+// MutedAt reports whether u is muted at t.
+func (u *User) MutedAt(t time.Time) bool { return u.MutedUntil != nil && t.Before(*u.MutedUntil) }
+
+// BannedAt reports whether u is banned at t.
+func (u *User) BannedAt(t time.Time) bool { return u.BannedUntil != nil && t.Before(*u.BannedUntil) }
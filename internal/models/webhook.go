@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbound webhook event types.
+const (
+	EventMatchCompleted    = "match.completed"
+	EventMapCompleted      = "map.completed"
+	EventTransferCreated   = "transfer.created"
+	EventTournamentUpdated = "tournament.updated"
+)
+
+// WebhookEventTypes lists every event type a subscription can ask for.
+var WebhookEventTypes = []string{EventMatchCompleted, EventMapCompleted, EventTransferCreated, EventTournamentUpdated}
+
+// WebhookSubscription is an admin-registered consumer endpoint. Events is a
+// comma-separated list of event types; Secret signs every delivery and is only
+// shown when the subscription is created.
+type WebhookSubscription struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	URL         string    `json:"url" gorm:"size:500;not null"`
+	Description string    `json:"description" gorm:"size:200"`
+	Events      string    `json:"events" gorm:"size:200;not null"`
+	Secret      string    `json:"-" gorm:"size:100;not null"`
+	Active      bool      `json:"active" gorm:"not null"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (WebhookSubscription) TableName() string { return "webhook_subscriptions" }
+
+// WebhookEvent is one row of the outbox. DedupeKey identifies the source row and its
+// state (e.g. the final score), so a corrected result is emitted again but an
+// unchanged one never is.
+type WebhookEvent struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	Type      string          `json:"type" gorm:"size:32;not null;index"`
+	DedupeKey string          `json:"-" gorm:"size:200;not null;uniqueIndex"`
+	Payload   json.RawMessage `json:"data" gorm:"type:jsonb;not null"`
+	CreatedAt time.Time       `json:"created_at" gorm:"index"`
+}
+
+func (WebhookEvent) TableName() string { return "webhook_events" }
+
+// WebhookDelivery is one event queued for one subscription, with its retry state.
+// It reuses the notification delivery statuses.
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint       `json:"subscription_id" gorm:"not null;uniqueIndex:idx_webhook_delivery"`
+	EventID        uint       `json:"event_id" gorm:"not null;uniqueIndex:idx_webhook_delivery"`
+	Status         string     `json:"status" gorm:"size:16;not null;index:idx_webhook_delivery_due,priority:1"`
+	Attempts       int        `json:"attempts" gorm:"not null"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due,priority:2"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error" gorm:"size:500"`
+	SentAt         *time.Time `json:"sent_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Event WebhookEvent     `json:"event" gorm:"foreignKey:EventID"`
+	Log   []WebhookAttempt `json:"log" gorm:"foreignKey:DeliveryID"`
+}
+
+func (WebhookDelivery) TableName() string { return "webhook_deliveries" }
+
+// WebhookAttempt is the delivery log: one row per HTTP attempt. StatusCode is 0 when
+// no response was received.
+type WebhookAttempt struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DeliveryID uint      `json:"delivery_id" gorm:"not null;index"`
+	Attempt    int       `json:"attempt" gorm:"not null"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error" gorm:"size:500"`
+	DurationMS int       `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (WebhookAttempt) TableName() string { return "webhook_attempts" }
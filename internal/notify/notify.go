@@ -0,0 +1,43 @@
+// Package notify delivers user notifications outside the app.
+//
+// A Channel sends one Message to one recipient. SMTP and Webhook are the built-in
+// channels; both can be pointed at local stand-ins (a throwaway SMTP listener or an
+// httptest server) so delivery can be exercised without real mail or endpoints.
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Channel names, as stored on notification_deliveries.channel.
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Recipient is where a user wants external notifications sent.
+type Recipient struct {
+	UserID     uint   `json:"user_id"`
+	Username   string `json:"username"`
+	Email      string `json:"-"`
+	WebhookURL string `json:"-"`
+}
+
+// Message is one notification addressed to one recipient. Link is a site path.
+type Message struct {
+	NotificationID uint      `json:"notification_id"`
+	Kind           string    `json:"kind"`
+	Title          string    `json:"title"`
+	Body           string    `json:"body"`
+	Link           string    `json:"link"`
+	CreatedAt      time.Time `json:"created_at"`
+	To             Recipient `json:"to"`
+}
+
+type Channel interface {
+	// Name is the channel name deliveries are queued under.
+	Name() string
+	// Send delivers msg. Any error is retried by the caller until it gives up.
+	Send(ctx context.Context, msg Message) error
+}
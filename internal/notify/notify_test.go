@@ -0,0 +1,139 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage(to Recipient) Message {
+	return Message{
+		NotificationID: 12,
+		Kind:           "match",
+		Title:          "OpTic Texas 3-1 Atlanta FaZe",
+		Body:           "Final · CDL Major 1",
+		Link:           "/matches/7",
+		To:             to,
+	}
+}
+
+func TestWebhook_Send(t *testing.T) {
+	var got Message
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(time.Second, true)
+	msg := testMessage(Recipient{UserID: 3, Username: "fan", WebhookURL: srv.URL, Email: "fan@example.com"})
+	require.NoError(t, wh.Send(context.Background(), msg))
+	assert.Equal(t, "OpTic Texas 3-1 Atlanta FaZe", got.Title)
+	assert.Equal(t, "fan", got.To.Username)
+	assert.Empty(t, got.To.Email, "contact details are not echoed to the webhook")
+
+	status = http.StatusBadGateway
+	assert.ErrorContains(t, wh.Send(context.Background(), msg), "502")
+}
+
+func TestWebhook_RefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Fatal("request reached a loopback server")
+	}))
+	defer srv.Close()
+
+	err := NewWebhook(time.Second, false).Send(context.Background(), testMessage(Recipient{WebhookURL: srv.URL}))
+	assert.ErrorIs(t, err, errPrivateAddress)
+}
+
+// smtpStandIn accepts one message over a minimal SMTP dialogue and returns its DATA.
+func smtpStandIn(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	data := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+		reply("220 stand-in ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 stand-in")
+			case strings.HasPrefix(cmd, "DATA"):
+				reply("354 go ahead")
+				var b strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				data <- b.String()
+				reply("250 queued")
+			case strings.HasPrefix(cmd, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), data
+}
+
+func TestSMTP_Send(t *testing.T) {
+	addr, data := smtpStandIn(t)
+	s := &SMTP{Addr: addr, From: "alerts@cdlytics.com", SiteURL: "https://cdlytics.com"}
+
+	msg := testMessage(Recipient{UserID: 3, Email: "fan@example.com"})
+	msg.Title = "Injected\r\nBcc: victim@example.com"
+	require.NoError(t, s.Send(context.Background(), msg))
+
+	mail := <-data
+	assert.Contains(t, mail, "To: fan@example.com\r\n")
+	assert.Contains(t, mail, "Subject: Injected Bcc: victim@example.com\r\n", "newlines in the subject are flattened")
+	assert.NotContains(t, mail, "\r\nBcc:")
+	assert.Contains(t, mail, "https://cdlytics.com/matches/7")
+}
+
+func TestSMTP_NeedsAddress(t *testing.T) {
+	s := &SMTP{Addr: "127.0.0.1:1", From: "alerts@cdlytics.com"}
+	assert.Error(t, s.Send(context.Background(), testMessage(Recipient{UserID: 3})))
+}
+
+func TestSMTPFromEnv(t *testing.T) {
+	t.Setenv("SMTP_ADDR", "")
+	t.Setenv("SMTP_FROM", "")
+	assert.Nil(t, SMTPFromEnv("https://cdlytics.com"))
+
+	t.Setenv("SMTP_ADDR", "mail.example.com:587")
+	t.Setenv("SMTP_FROM", "alerts@cdlytics.com")
+	t.Setenv("SMTP_USERNAME", "alerts")
+	s := SMTPFromEnv("https://cdlytics.com")
+	require.NotNil(t, s)
+	assert.NotNil(t, s.Auth)
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// SMTP sends notifications as plain-text email through one relay.
+type SMTP struct {
+	Addr    string // host:port
+	From    string
+	Auth    smtp.Auth
+	SiteURL string // prefixed to message links
+}
+
+// SMTPFromEnv configures SMTP from SMTP_ADDR, SMTP_FROM and, when set, SMTP_USERNAME
+// and SMTP_PASSWORD (PLAIN auth). It returns nil when SMTP_ADDR or SMTP_FROM is unset.
+func SMTPFromEnv(siteURL string) *SMTP {
+	addr, from := os.Getenv("SMTP_ADDR"), os.Getenv("SMTP_FROM")
+	if addr == "" || from == "" {
+		return nil
+	}
+	s := &SMTP{Addr: addr, From: from, SiteURL: siteURL}
+	if user := os.Getenv("SMTP_USERNAME"); user != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		s.Auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return s
+}
+
+func (s *SMTP) Name() string { return ChannelEmail }
+
+// headerSafe drops CR and LF so user-influenced text cannot inject headers.
+func headerSafe(v string) string {
+	return strings.NewReplacer("\r", "", "\n", " ").Replace(v)
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if msg.To.Email == "" {
+		return fmt.Errorf("user %d has no email address", msg.To.UserID)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerSafe(s.From))
+	fmt.Fprintf(&b, "To: %s\r\n", headerSafe(msg.To.Email))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerSafe(msg.Title))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Body)
+	if msg.Link != "" {
+		fmt.Fprintf(&b, "\r\n\r\n%s%s", s.SiteURL, msg.Link)
+	}
+	b.WriteString("\r\n")
+
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(s.Addr, s.Auth, s.From, []string{msg.To.Email}, []byte(b.String())) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var errPrivateAddress = errors.New("webhook address is not public")
+
+// Poster POSTs bodies to arbitrary URLs. Unless built with allowPrivate it refuses to
+// connect to loopback, private and link-local addresses, so user- or admin-supplied
+// URLs cannot reach the internal network; allowPrivate exists for local stand-ins.
+// Redirects are not followed.
+type Poster struct {
+	client *http.Client
+}
+
+func NewPoster(timeout time.Duration, allowPrivate bool) *Poster {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+				ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{DialContext: dialer.DialContext, Proxy: nil}
+	return &Poster{client: &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Post sends body as JSON with the extra headers and returns the response status.
+// A non-2xx status is returned together with an error.
+func (p *Poster) Post(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cdlytics-notify/1")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Webhook is the notification channel that POSTs each message as JSON to the
+// recipient's webhook URL.
+type Webhook struct {
+	poster *Poster
+}
+
+// NewWebhook builds the webhook channel on a Poster; see NewPoster for allowPrivate.
+func NewWebhook(timeout time.Duration, allowPrivate bool) *Webhook {
+	return &Webhook{poster: NewPoster(timeout, allowPrivate)}
+}
+
+func (w *Webhook) Name() string { return ChannelWebhook }
+
+func (w *Webhook) Send(ctx context.Context, msg Message) error {
+	if msg.To.WebhookURL == "" {
+		return fmt.Errorf("user %d has no webhook URL", msg.To.UserID)
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.poster.Post(ctx, msg.To.WebhookURL, nil, payload)
+	return err
+}
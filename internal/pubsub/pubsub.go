@@ -0,0 +1,86 @@
+// Package pubsub is the publish/subscribe bus behind the live streams.
+//
+// Payloads are opaque bytes so a Hub can carry them between processes. MemoryHub only
+// reaches subscribers in the same process; a Postgres LISTEN/NOTIFY Hub can replace it
+// for multi-instance deployments without touching publishers or subscribers.
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+type Hub interface {
+	// Publish delivers payload to every current subscriber of topic. It never blocks on
+	// a slow subscriber.
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe returns a channel of topic's payloads and a func that ends the
+	// subscription. The channel is closed when the subscription ends, including when
+	// the hub drops a subscriber that fell too far behind; it should resync and
+	// subscribe again.
+	Subscribe(topic string) (<-chan []byte, func())
+}
+
+// DefaultBuffer is how many payloads a MemoryHub subscriber may fall behind before it is dropped.
+const DefaultBuffer = 16
+
+type MemoryHub struct {
+	buffer int
+	mu     sync.Mutex
+	subs   map[string]map[chan []byte]struct{}
+}
+
+func NewMemoryHub(buffer int) *MemoryHub {
+	if buffer < 1 {
+		buffer = DefaultBuffer
+	}
+	return &MemoryHub{buffer: buffer, subs: map[string]map[chan []byte]struct{}{}}
+}
+
+func (h *MemoryHub) Subscribe(topic string) (<-chan []byte, func()) {
+	ch := make(chan []byte, h.buffer)
+	h.mu.Lock()
+	if h.subs[topic] == nil {
+		h.subs[topic] = map[chan []byte]struct{}{}
+	}
+	h.subs[topic][ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(topic, ch)
+	}
+}
+
+// remove must be called with mu held.
+func (h *MemoryHub) remove(topic string, ch chan []byte) {
+	subs := h.subs[topic]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(h.subs, topic)
+	}
+}
+
+func (h *MemoryHub) Publish(_ context.Context, topic string, payload []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[topic] {
+		select {
+		case ch <- payload:
+		default:
+			h.remove(topic, ch)
+		}
+	}
+	return nil
+}
+
+// Subscribers reports how many subscriptions topic currently has.
+func (h *MemoryHub) Subscribers(topic string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[topic])
+}
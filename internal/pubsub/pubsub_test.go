@@ -0,0 +1,70 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, ch <-chan []byte) string {
+	t.Helper()
+	select {
+	case p, ok := <-ch:
+		require.True(t, ok, "channel closed")
+		return string(p)
+	case <-time.After(time.Second):
+		t.Fatal("nothing published")
+		return ""
+	}
+}
+
+func TestMemoryHub_TopicsAreIsolated(t *testing.T) {
+	h := NewMemoryHub(4)
+	a, cancelA := h.Subscribe("a")
+	defer cancelA()
+	b, cancelB := h.Subscribe("b")
+	defer cancelB()
+
+	require.NoError(t, h.Publish(context.Background(), "a", []byte("one")))
+	require.NoError(t, h.Publish(context.Background(), "b", []byte("two")))
+	assert.Equal(t, "one", receive(t, a))
+	assert.Equal(t, "two", receive(t, b))
+	assert.Empty(t, a)
+}
+
+func TestMemoryHub_UnsubscribeClosesAndForgets(t *testing.T) {
+	h := NewMemoryHub(4)
+	ch, cancel := h.Subscribe("a")
+	assert.Equal(t, 1, h.Subscribers("a"))
+	cancel()
+	cancel() // idempotent
+	_, ok := <-ch
+	assert.False(t, ok)
+	assert.Equal(t, 0, h.Subscribers("a"))
+	assert.NoError(t, h.Publish(context.Background(), "a", []byte("nobody")))
+}
+
+func TestMemoryHub_DropsSlowSubscriber(t *testing.T) {
+	h := NewMemoryHub(2)
+	slow, cancel := h.Subscribe("a")
+	defer cancel()
+	fast, cancelFast := h.Subscribe("a")
+	defer cancelFast()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, h.Publish(context.Background(), "a", []byte{byte('0' + i)}))
+		if i < 2 {
+			receive(t, fast)
+		}
+	}
+	n := 0
+	for range slow {
+		n++
+	}
+	assert.Equal(t, 2, n, "buffered payloads drain, then the channel is closed")
+	assert.Equal(t, "2", receive(t, fast))
+	assert.Equal(t, 1, h.Subscribers("a"))
+}
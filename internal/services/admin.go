@@ -0,0 +1,654 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidMatch = errors.New("invalid match")
+var ErrInvalidMap = errors.New("invalid map")
+var ErrInvalidStatLine = errors.New("invalid stat line")
+var ErrMatchInUse = errors.New("match has picks or a discussion thread and cannot be deleted")
+var ErrInvalidChangeFilter = errors.New("invalid change filter")
+var ErrChangeConflict = errors.New("change cannot be reverted")
+
+// AdminSource tags match_maps / player_map_stats rows written through the admin API.
+const AdminSource = "admin"
+
+// AdminMatchInput is the body of POST /admin/matches and PUT /admin/matches/:id.
+// Scores are only taken as given for matches without played maps; otherwise they
+// must equal the map tally.
+type AdminMatchInput struct {
+	TournamentID    uint      `json:"tournament_id"`
+	Team1ID         uint      `json:"team1_id"`
+	Team2ID         uint      `json:"team2_id"`
+	MatchDate       time.Time `json:"match_date"`
+	Format          string    `json:"format"`
+	MatchType       string    `json:"match_type"`
+	BracketRound    string    `json:"bracket_round"`
+	BracketPosition int       `json:"bracket_position"`
+	Team1Score      int       `json:"team1_score"`
+	Team2Score      int       `json:"team2_score"`
+	VodURL          string    `json:"vod_url"`
+	Reason          string    `json:"reason"`
+}
+
+// AdminMapInput is the body of PUT /admin/matches/:id/maps/:number. A played map is
+// won by the side with the higher score.
+type AdminMapInput struct {
+	MapName     string `json:"map_name"`
+	Mode        string `json:"mode"`
+	Score1      int    `json:"score_1"`
+	Score2      int    `json:"score_2"`
+	Played      bool   `json:"played"`
+	DurationSec int    `json:"duration_sec"`
+	Reason      string `json:"reason"`
+}
+
+// AdminStatLineInput is the body of PUT /admin/matches/:id/maps/:number/stats/:player_id.
+type AdminStatLineInput struct {
+	TeamID               uint   `json:"team_id"`
+	Kills                int    `json:"kills"`
+	Deaths               int    `json:"deaths"`
+	Damage               int    `json:"damage"`
+	Assists              int    `json:"assists"`
+	HillTime             int    `json:"hill_time"`
+	SndRounds            int    `json:"snd_rounds"`
+	PlantCount           int    `json:"plant_count"`
+	DefuseCount          int    `json:"defuse_count"`
+	FirstBloodCount      int    `json:"first_blood_count"`
+	FirstDeathCount      int    `json:"first_death_count"`
+	ZoneTierCaptureCount int    `json:"zone_tier_capture_count"`
+	NonTradedKills       int    `json:"non_traded_kills"`
+	HighestStreak        int    `json:"highest_streak"`
+	Reason               string `json:"reason"`
+}
+
+// AdminMapResult is a saved map with the match header after the series was recounted.
+type AdminMapResult struct {
+	Map   *models.MatchMap `json:"map"`
+	Match *models.Match    `json:"match"`
+}
+
+var seriesFormat = regexp.MustCompile(`^BO([1-9])$`)
+
+// parseSeriesFormat accepts "BO1".."BO9" in any case; the series length must be odd.
+func parseSeriesFormat(format string) (string, int, bool) {
+	f := strings.ToUpper(strings.TrimSpace(format))
+	m := seriesFormat.FindStringSubmatch(f)
+	if m == nil {
+		return "", 0, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	return f, n, n%2 == 1
+}
+
+// seriesWinner returns the team that has reached a majority of bestOf, or nil. It
+// fails when a score is impossible for the series length.
+func seriesWinner(team1, team2 uint, score1, score2, bestOf int) (*uint, error) {
+	need := bestOf/2 + 1
+	if score1 < 0 || score2 < 0 || score1 > need || score2 > need || (score1 == need && score2 == need) {
+		return nil, fmt.Errorf("%w: %d-%d is not a possible BO%d score", ErrInvalidMatch, score1, score2, bestOf)
+	}
+	switch {
+	case score1 == need:
+		return &team1, nil
+	case score2 == need:
+		return &team2, nil
+	}
+	return nil, nil
+}
+
+// mapTally counts the played maps each side won, in map order, and fails if a map
+// was played after the series was already decided.
+func mapTally(maps []models.MatchMap, team1, team2 uint, bestOf int) (int, int, error) {
+	need := bestOf/2 + 1
+	slices.SortFunc(maps, func(a, b models.MatchMap) int { return a.MapNumber - b.MapNumber })
+	var s1, s2 int
+	for _, mm := range maps {
+		if !mm.Played {
+			continue
+		}
+		if s1 == need || s2 == need {
+			return 0, 0, fmt.Errorf("%w: map %d was played after the series was decided", ErrInvalidMap, mm.MapNumber)
+		}
+		switch {
+		case mm.WinnerID != nil && *mm.WinnerID == team1:
+			s1++
+		case mm.WinnerID != nil && *mm.WinnerID == team2:
+			s2++
+		default:
+			return 0, 0, fmt.Errorf("%w: map %d has no winner from this match", ErrInvalidMap, mm.MapNumber)
+		}
+	}
+	return s1, s2, nil
+}
+
+// checkRestoredMatch checks that the match row a revert would restore still agrees
+// with the maps and stat lines recorded since: the same teams, a format long enough
+// for the maps, and a score and winner equal to the played maps' tally.
+func checkRestoredMatch(before []byte, m *models.Match, maps []models.MatchMap, lines []models.PlayerMapStats) error {
+	if len(maps) == 0 && len(lines) == 0 {
+		return nil
+	}
+	var restored models.Match
+	if err := json.Unmarshal(before, &restored); err != nil {
+		return err
+	}
+	if restored.Team1ID != m.Team1ID || restored.Team2ID != m.Team2ID {
+		return fmt.Errorf("%w: teams cannot change once maps or stats are recorded", ErrChangeConflict)
+	}
+	if len(maps) == 0 {
+		return nil
+	}
+	n := bestOf(restored.Format)
+	if last := slices.MaxFunc(maps, func(a, b models.MatchMap) int { return a.MapNumber - b.MapNumber }).MapNumber; last > n {
+		return fmt.Errorf("%w: BO%d is too short for map %d", ErrChangeConflict, n, last)
+	}
+	if !slices.ContainsFunc(maps, func(mm models.MatchMap) bool { return mm.Played }) {
+		return nil
+	}
+	s1, s2, err := mapTally(maps, m.Team1ID, m.Team2ID, n)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrChangeConflict, err)
+	}
+	winner, _ := seriesWinner(m.Team1ID, m.Team2ID, s1, s2, n)
+	if restored.Team1Score != s1 || restored.Team2Score != s2 || (restored.WinnerID == nil) != (winner == nil) ||
+		(winner != nil && *restored.WinnerID != *winner) {
+		return fmt.Errorf("%w: the restored score must match the played maps (%d-%d)", ErrChangeConflict, s1, s2)
+	}
+	return nil
+}
+
+// AdminService is the admin write API for matches, maps and player stat lines, and
+// the change log those writes leave behind. Every method is admin-only.
+type AdminService struct {
+	store       store.AdminStore
+	tournaments store.TournamentStore
+	teams       store.TeamStore
+	now         func() time.Time
+}
+
+func NewAdminService(s store.AdminStore, tournaments store.TournamentStore, teams store.TeamStore) *AdminService {
+	return &AdminService{store: s, tournaments: tournaments, teams: teams, now: time.Now}
+}
+
+// checkMatchInput validates the header and fills m from it. played is the match's
+// current played-map tally, or nil for a match without played maps.
+func (as *AdminService) checkMatchInput(ctx context.Context, in AdminMatchInput, m *models.Match, played *[2]int) error {
+	format, n, ok := parseSeriesFormat(in.Format)
+	if !ok {
+		return fmt.Errorf("%w: format must be BO1, BO3, BO5, BO7 or BO9", ErrInvalidMatch)
+	}
+	if in.Team1ID == 0 || in.Team2ID == 0 || in.Team1ID == in.Team2ID {
+		return fmt.Errorf("%w: team1_id and team2_id must be two different teams", ErrInvalidMatch)
+	}
+	if in.MatchDate.IsZero() {
+		return fmt.Errorf("%w: match_date is required", ErrInvalidMatch)
+	}
+	tournament, err := as.tournaments.GetByID(ctx, int(in.TournamentID))
+	if err != nil {
+		return fmt.Errorf("%w: tournament %d not found", ErrInvalidMatch, in.TournamentID)
+	}
+	if tournament.TournamentType == "season_summary" {
+		return fmt.Errorf("%w: %s holds season totals, not matches", ErrInvalidMatch, tournament.Name)
+	}
+	for _, id := range []uint{in.Team1ID, in.Team2ID} {
+		team, err := as.teams.GetByID(ctx, int(id))
+		if err != nil {
+			return fmt.Errorf("%w: team %d not found", ErrInvalidMatch, id)
+		}
+		if team.GameCode != "" && tournament.Season.GameCode != "" && team.GameCode != tournament.Season.GameCode {
+			return fmt.Errorf("%w: %s is a %s team, not %s", ErrInvalidMatch, team.Name, team.GameCode, tournament.Season.GameCode)
+		}
+	}
+	if played != nil && (in.Team1Score != played[0] || in.Team2Score != played[1]) {
+		return fmt.Errorf("%w: score must match the played maps (%d-%d)", ErrInvalidMatch, played[0], played[1])
+	}
+	winner, err := seriesWinner(in.Team1ID, in.Team2ID, in.Team1Score, in.Team2Score, n)
+	if err != nil {
+		return err
+	}
+
+	m.TournamentID = in.TournamentID
+	m.Team1ID, m.Team2ID = in.Team1ID, in.Team2ID
+	m.MatchDate = in.MatchDate
+	m.Format = format
+	m.MatchType = strings.TrimSpace(in.MatchType)
+	m.BracketRound = strings.TrimSpace(in.BracketRound)
+	m.BracketPosition = in.BracketPosition
+	m.Team1Score, m.Team2Score, m.WinnerID = in.Team1Score, in.Team2Score, winner
+	m.VodURL = strings.TrimSpace(in.VodURL)
+	m.UpdatedAt = as.now()
+	return nil
+}
+
+func (as *AdminService) CreateMatch(ctx context.Context, admin *models.User, in AdminMatchInput) (*models.Match, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	entry, err := changeEntry(admin, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	m := &models.Match{CreatedAt: as.now()}
+	if err := as.checkMatchInput(ctx, in, m, nil); err != nil {
+		return nil, err
+	}
+	if err := as.store.CreateMatch(ctx, m, entry); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// UpdateMatch replaces the match header. Teams cannot change once the match has
+// maps or stat lines, since those are recorded against the old teams.
+func (as *AdminService) UpdateMatch(ctx context.Context, admin *models.User, id uint, in AdminMatchInput) (*models.Match, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	entry, err := changeEntry(admin, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	m, err := as.store.GetMatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	maps, err := as.store.ListMatchMaps(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := as.store.ListMatchLines(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if (len(maps) > 0 || len(lines) > 0) && (in.Team1ID != m.Team1ID || in.Team2ID != m.Team2ID) {
+		return nil, fmt.Errorf("%w: teams cannot change once maps or stats are recorded", ErrInvalidMatch)
+	}
+
+	var played *[2]int
+	if _, n, ok := parseSeriesFormat(in.Format); ok && len(maps) > 0 {
+		if last := maps[len(maps)-1].MapNumber; last > n {
+			return nil, fmt.Errorf("%w: BO%d is too short for map %d", ErrInvalidMatch, n, last)
+		}
+		if slices.ContainsFunc(maps, func(mm models.MatchMap) bool { return mm.Played }) {
+			s1, s2, err := mapTally(maps, m.Team1ID, m.Team2ID, n)
+			if err != nil {
+				return nil, err
+			}
+			played = &[2]int{s1, s2}
+		}
+	}
+
+	prevTournament := m.TournamentID
+	if err := as.checkMatchInput(ctx, in, m, played); err != nil {
+		return nil, err
+	}
+	if err := as.store.UpdateMatch(ctx, m, prevTournament, entry); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DeleteMatch removes a match with its maps and stat lines. Matches users have
+// picked or discussed are refused.
+func (as *AdminService) DeleteMatch(ctx context.Context, admin *models.User, id uint, reason string) error {
+	if err := requireAdmin(admin); err != nil {
+		return err
+	}
+	entry, err := changeEntry(admin, reason)
+	if err != nil {
+		return err
+	}
+	m, err := as.store.GetMatch(ctx, id)
+	if err != nil {
+		return err
+	}
+	refs, err := as.store.CountMatchReferences(ctx, id)
+	if err != nil {
+		return err
+	}
+	if refs > 0 {
+		return ErrMatchInUse
+	}
+	return as.store.DeleteMatch(ctx, m, entry)
+}
+
+// SaveMap creates or replaces one map and recounts the series from the played maps.
+func (as *AdminService) SaveMap(ctx context.Context, admin *models.User, matchID uint, mapNumber int, in AdminMapInput) (*AdminMapResult, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	entry, err := changeEntry(admin, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	m, err := as.store.GetMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	n := bestOf(m.Format)
+	if mapNumber < 1 || mapNumber > n {
+		return nil, fmt.Errorf("%w: map_number must be between 1 and %d", ErrInvalidMap, n)
+	}
+	if in.Score1 < 0 || in.Score2 < 0 || in.DurationSec < 0 {
+		return nil, fmt.Errorf("%w: scores and duration must not be negative", ErrInvalidMap)
+	}
+	mode, ok := parseModeParam(in.Mode)
+	if !ok {
+		return nil, fmt.Errorf("%w: mode must be hp, snd or control", ErrInvalidMap)
+	}
+
+	mm := models.MatchMap{
+		MatchID:     m.ID,
+		MapNumber:   mapNumber,
+		MapName:     strings.TrimSpace(in.MapName),
+		Mode:        liveModeNames[mode],
+		Score1:      in.Score1,
+		Score2:      in.Score2,
+		Played:      in.Played,
+		DurationSec: in.DurationSec,
+		Source:      AdminSource,
+		UpdatedAt:   as.now(),
+	}
+	if in.Played {
+		switch {
+		case in.Score1 > in.Score2:
+			mm.WinnerID = &m.Team1ID
+		case in.Score2 > in.Score1:
+			mm.WinnerID = &m.Team2ID
+		default:
+			return nil, fmt.Errorf("%w: a played map cannot be tied", ErrInvalidMap)
+		}
+	}
+
+	maps, err := as.store.ListMatchMaps(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	maps = slices.DeleteFunc(maps, func(x models.MatchMap) bool { return x.MapNumber == mapNumber })
+	if _, _, err := mapTally(append(maps, mm), m.Team1ID, m.Team2ID, n); err != nil {
+		return nil, err
+	}
+
+	match, err := as.store.SaveMap(ctx, &mm, m.TournamentID, n, entry)
+	if err != nil {
+		return nil, err
+	}
+	return &AdminMapResult{Map: &mm, Match: match}, nil
+}
+
+// DeleteMap removes one map with its stat lines and recounts the series.
+func (as *AdminService) DeleteMap(ctx context.Context, admin *models.User, matchID uint, mapNumber int, reason string) (*models.Match, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	entry, err := changeEntry(admin, reason)
+	if err != nil {
+		return nil, err
+	}
+	m, err := as.store.GetMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	n := bestOf(m.Format)
+	maps, err := as.store.ListMatchMaps(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	maps = slices.DeleteFunc(maps, func(x models.MatchMap) bool { return x.MapNumber == mapNumber })
+	if _, _, err := mapTally(maps, m.Team1ID, m.Team2ID, n); err != nil {
+		return nil, err
+	}
+	return as.store.DeleteMap(ctx, matchID, mapNumber, m.TournamentID, n, entry)
+}
+
+// SaveStatLine creates or replaces one player's line for a map. The player must be
+// on one of the two teams (by roster or by earlier maps that season) and on the same
+// side for every map of the match.
+func (as *AdminService) SaveStatLine(ctx context.Context, admin *models.User, matchID uint, mapNumber int, playerID uint, in AdminStatLineInput) (*models.PlayerMapStats, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	entry, err := changeEntry(admin, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+	m, err := as.store.GetMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	maps, err := as.store.ListMatchMaps(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(maps, func(mm models.MatchMap) bool { return mm.MapNumber == mapNumber }) {
+		return nil, fmt.Errorf("%w: match %d has no map %d", ErrInvalidStatLine, matchID, mapNumber)
+	}
+	if in.TeamID != m.Team1ID && in.TeamID != m.Team2ID {
+		return nil, fmt.Errorf("%w: team_id %d is not playing this match", ErrInvalidStatLine, in.TeamID)
+	}
+	for _, v := range []int{in.Kills, in.Deaths, in.Damage, in.Assists, in.HillTime, in.SndRounds, in.PlantCount,
+		in.DefuseCount, in.FirstBloodCount, in.FirstDeathCount, in.ZoneTierCaptureCount, in.NonTradedKills, in.HighestStreak} {
+		if v < 0 {
+			return nil, fmt.Errorf("%w: stat values must not be negative", ErrInvalidStatLine)
+		}
+	}
+
+	lines, err := as.store.ListMatchLines(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		if l.PlayerID == playerID && l.MapNumber != mapNumber && l.TeamID != in.TeamID {
+			return nil, fmt.Errorf("%w: player %d played map %d for team %d", ErrInvalidStatLine, playerID, l.MapNumber, l.TeamID)
+		}
+	}
+	tournament, err := as.tournaments.GetByID(ctx, int(m.TournamentID))
+	if err != nil {
+		return nil, err
+	}
+	onTeam, err := as.store.PlayerOnTeam(ctx, playerID, in.TeamID, tournament.SeasonID, m.MatchDate)
+	if err != nil {
+		return nil, err
+	}
+	if !onTeam {
+		return nil, fmt.Errorf("%w: player %d is not on team %d's roster", ErrInvalidStatLine, playerID, in.TeamID)
+	}
+
+	line := &models.PlayerMapStats{
+		MatchID:              m.ID,
+		MapNumber:            mapNumber,
+		PlayerID:             playerID,
+		TeamID:               in.TeamID,
+		Kills:                in.Kills,
+		Deaths:               in.Deaths,
+		KDRatio:              CalculateKD(in.Kills, in.Deaths),
+		Damage:               in.Damage,
+		Assists:              in.Assists,
+		HillTime:             in.HillTime,
+		SndRounds:            in.SndRounds,
+		PlantCount:           in.PlantCount,
+		DefuseCount:          in.DefuseCount,
+		FirstBloodCount:      in.FirstBloodCount,
+		FirstDeathCount:      in.FirstDeathCount,
+		ZoneTierCaptureCount: in.ZoneTierCaptureCount,
+		NonTradedKills:       in.NonTradedKills,
+		HighestStreak:        in.HighestStreak,
+		Source:               AdminSource,
+		UpdatedAt:            as.now(),
+	}
+	if err := as.store.SaveStatLine(ctx, line, m.TournamentID, entry); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+func (as *AdminService) DeleteStatLine(ctx context.Context, admin *models.User, matchID uint, mapNumber int, playerID uint, reason string) error {
+	if err := requireAdmin(admin); err != nil {
+		return err
+	}
+	entry, err := changeEntry(admin, reason)
+	if err != nil {
+		return err
+	}
+	m, err := as.store.GetMatch(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	return as.store.DeleteStatLine(ctx, matchID, mapNumber, playerID, m.TournamentID, entry)
+}
+
+// changeEntry is the change-log entry every row touched by one admin write shares.
+func changeEntry(admin *models.User, reason string) (*models.ChangeLog, error) {
+	reason, err := cleanReason(reason, false)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ChangeLog{ActorID: &admin.ID, Actor: admin.Username, Reason: reason}, nil
+}
+
+var changeEntities = []string{
+	models.ChangeMatch, models.ChangeMap, models.ChangeStatLine, models.ChangeTeam, models.ChangePlayer,
+	models.ChangeRoster, models.ChangeCoach, models.ChangeTransfer, models.ChangeTeamTotals, models.ChangePlayerTotals,
+}
+
+// ListChanges pages through the change log, newest first. An entity key needs its
+// entity, since keys are only unique within one.
+func (as *AdminService) ListChanges(ctx context.Context, admin *models.User, f store.ChangeFilter, page, limit int) ([]models.ChangeLog, int64, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, 0, err
+	}
+	if f.Entity != "" && !slices.Contains(changeEntities, f.Entity) {
+		return nil, 0, fmt.Errorf("%w: entity must be one of %s", ErrInvalidChangeFilter, strings.Join(changeEntities, ", "))
+	}
+	if f.EntityKey != "" {
+		if f.Entity == "" {
+			return nil, 0, fmt.Errorf("%w: key needs an entity", ErrInvalidChangeFilter)
+		}
+		if _, err := store.ParseChangeKey(f.Entity, f.EntityKey); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalidChangeFilter, err)
+		}
+	}
+	if !f.Since.IsZero() && !f.Until.IsZero() && !f.Since.Before(f.Until) {
+		return nil, 0, fmt.Errorf("%w: since must be before until", ErrInvalidChangeFilter)
+	}
+	return as.store.ListChanges(ctx, f, limit, (page-1)*limit)
+}
+
+// RevertChange puts a row back the way it was before one change, as long as nothing
+// has written to it since. The revert is itself a change, so it can be reverted too.
+func (as *AdminService) RevertChange(ctx context.Context, admin *models.User, id uint, reason string) (*models.ChangeLog, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	reason, err := cleanReason(reason, true)
+	if err != nil {
+		return nil, err
+	}
+	change, err := as.store.GetChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := store.ParseChangeKey(change.Entity, change.EntityKey)
+	if err != nil {
+		return nil, err
+	}
+	if change.Entity == models.ChangeTeam && change.Action != models.ChangeUpdate {
+		return nil, fmt.Errorf("%w: only team renames can be reverted", ErrChangeConflict)
+	}
+	if !store.IsMatchEntity(change.Entity) && change.Action == models.ChangeCreate {
+		return nil, fmt.Errorf("%w: creating a %s row can't be reverted", ErrChangeConflict, change.Entity)
+	}
+	var m *models.Match
+	matchID := parts[0]
+	if store.IsMatchEntity(change.Entity) {
+		m, err = as.store.GetMatch(ctx, matchID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if m == nil && change.Before != nil && change.Entity != models.ChangeMatch {
+			return nil, fmt.Errorf("%w: match %d no longer exists; revert its deletion first", ErrChangeConflict, matchID)
+		}
+	}
+
+	n := 5
+	if m != nil {
+		n = bestOf(m.Format)
+		maps, err := as.store.ListMatchMaps(ctx, matchID)
+		if err != nil {
+			return nil, err
+		}
+		lines, err := as.store.ListMatchLines(ctx, matchID)
+		if err != nil {
+			return nil, err
+		}
+		switch change.Entity {
+		case models.ChangeMatch:
+			if change.Before == nil {
+				if len(maps) > 0 || len(lines) > 0 {
+					return nil, fmt.Errorf("%w: match %d has maps; delete them first", ErrChangeConflict, matchID)
+				}
+				refs, err := as.store.CountMatchReferences(ctx, matchID)
+				if err != nil {
+					return nil, err
+				}
+				if refs > 0 {
+					return nil, ErrMatchInUse
+				}
+			} else if err := checkRestoredMatch(change.Before, m, maps, lines); err != nil {
+				return nil, err
+			}
+		case models.ChangeMap:
+			mapNumber := int(parts[1])
+			if change.Before == nil && slices.ContainsFunc(lines, func(l models.PlayerMapStats) bool { return l.MapNumber == mapNumber }) {
+				return nil, fmt.Errorf("%w: map %d has stat lines; delete them first", ErrChangeConflict, mapNumber)
+			}
+			maps = slices.DeleteFunc(maps, func(x models.MatchMap) bool { return x.MapNumber == mapNumber })
+			if change.Before != nil {
+				var restored models.MatchMap
+				if err := json.Unmarshal(change.Before, &restored); err != nil {
+					return nil, err
+				}
+				maps = append(maps, restored)
+			}
+			if _, _, err := mapTally(maps, m.Team1ID, m.Team2ID, n); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrChangeConflict, err)
+			}
+		case models.ChangeStatLine:
+			mapNumber := int(parts[1])
+			if change.Before != nil && !slices.ContainsFunc(maps, func(x models.MatchMap) bool { return x.MapNumber == mapNumber }) {
+				return nil, fmt.Errorf("%w: map %d no longer exists; revert its deletion first", ErrChangeConflict, mapNumber)
+			}
+		}
+	}
+
+	entry, err := changeEntry(admin, reason)
+	if err != nil {
+		return nil, err
+	}
+	entry.RevertOf = &change.ID
+	reverted, err := as.store.RevertChange(ctx, change, n, entry)
+	if errors.Is(err, store.ErrChangeSuperseded) {
+		return nil, fmt.Errorf("%w: %s %s has been changed since", ErrChangeConflict, change.Entity, change.EntityKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reverted == nil {
+		return nil, fmt.Errorf("%w: nothing to revert", ErrChangeConflict)
+	}
+	return reverted, nil
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockAdminStore struct {
+	matches map[uint]*models.Match
+	maps    []models.MatchMap
+	lines   []models.PlayerMapStats
+	refs    int64
+	onTeam  bool
+	created *models.Match
+	saved   *models.MatchMap
+	line    *models.PlayerMapStats
+	entry   *models.ChangeLog
+	changes map[uint]*models.ChangeLog
+	stale   bool
+}
+
+func (m *mockAdminStore) GetMatch(_ context.Context, id uint) (*models.Match, error) {
+	if match, ok := m.matches[id]; ok {
+		cp := *match
+		return &cp, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (m *mockAdminStore) ListMatchMaps(context.Context, uint) ([]models.MatchMap, error) {
+	return append([]models.MatchMap(nil), m.maps...), nil
+}
+func (m *mockAdminStore) ListMatchLines(context.Context, uint) ([]models.PlayerMapStats, error) {
+	return m.lines, nil
+}
+func (m *mockAdminStore) CountMatchReferences(context.Context, uint) (int64, error) {
+	return m.refs, nil
+}
+func (m *mockAdminStore) PlayerOnTeam(context.Context, uint, uint, uint, time.Time) (bool, error) {
+	return m.onTeam, nil
+}
+func (m *mockAdminStore) CreateMatch(_ context.Context, match *models.Match, entry *models.ChangeLog) error {
+	match.ID = 9
+	m.created, m.entry = match, entry
+	return nil
+}
+func (m *mockAdminStore) UpdateMatch(_ context.Context, _ *models.Match, _ uint, entry *models.ChangeLog) error {
+	m.entry = entry
+	return nil
+}
+func (m *mockAdminStore) DeleteMatch(_ context.Context, _ *models.Match, entry *models.ChangeLog) error {
+	m.entry = entry
+	return nil
+}
+func (m *mockAdminStore) SaveMap(_ context.Context, mm *models.MatchMap, _ uint, _ int, entry *models.ChangeLog) (*models.Match, error) {
+	m.saved, m.entry = mm, entry
+	return m.matches[mm.MatchID], nil
+}
+func (m *mockAdminStore) DeleteMap(_ context.Context, matchID uint, _ int, _ uint, _ int, entry *models.ChangeLog) (*models.Match, error) {
+	m.entry = entry
+	return m.matches[matchID], nil
+}
+func (m *mockAdminStore) SaveStatLine(_ context.Context, line *models.PlayerMapStats, _ uint, entry *models.ChangeLog) error {
+	m.line, m.entry = line, entry
+	return nil
+}
+func (m *mockAdminStore) DeleteStatLine(_ context.Context, _ uint, _ int, _, _ uint, entry *models.ChangeLog) error {
+	m.entry = entry
+	return nil
+}
+func (m *mockAdminStore) GetChange(_ context.Context, id uint) (*models.ChangeLog, error) {
+	if c, ok := m.changes[id]; ok {
+		return c, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (m *mockAdminStore) ListChanges(context.Context, store.ChangeFilter, int, int) ([]models.ChangeLog, int64, error) {
+	return nil, 0, nil
+}
+func (m *mockAdminStore) RevertChange(_ context.Context, change *models.ChangeLog, _ int, entry *models.ChangeLog) (*models.ChangeLog, error) {
+	if m.stale {
+		return nil, store.ErrChangeSuperseded
+	}
+	m.entry = entry
+	return &models.ChangeLog{ID: 100, Entity: change.Entity, EntityKey: change.EntityKey, RevertOf: entry.RevertOf}, nil
+}
+
+type mockTournamentStore struct {
+	tournaments map[int]models.Tournament
+}
+
+func (m *mockTournamentStore) List(context.Context, string) ([]models.Tournament, error) {
+	return nil, nil
+}
+func (m *mockTournamentStore) GetBySlug(context.Context, string) (*models.Tournament, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (m *mockTournamentStore) GetByID(_ context.Context, id int) (*models.Tournament, error) {
+	t, ok := m.tournaments[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &t, nil
+}
+func (m *mockTournamentStore) GetTeamCount(context.Context, int) (int64, error) { return 0, nil }
+func (m *mockTournamentStore) GetBracketMatches(context.Context, int) ([]models.Match, error) {
+	return nil, nil
+}
+func (m *mockTournamentStore) GetMatches(context.Context, int) ([]models.Match, error) {
+	return nil, nil
+}
+func (m *mockTournamentStore) GetTeamIDs(context.Context, int) ([]uint, error) { return nil, nil }
+func (m *mockTournamentStore) GetTeams(context.Context, []uint) ([]models.Team, error) {
+	return nil, nil
+}
+func (m *mockTournamentStore) GetTeamStats(context.Context, int) ([]models.TeamTournamentStats, error) {
+	return nil, nil
+}
+func (m *mockTournamentStore) GetPlayerStats(context.Context, int) ([]models.PlayerTournamentStats, error) {
+	return nil, nil
+}
+
+var _ store.TournamentStore = (*mockTournamentStore)(nil)
+
+func newTestAdminService(ms *mockAdminStore) *AdminService {
+	tournaments := &mockTournamentStore{tournaments: map[int]models.Tournament{
+		1: {ID: 1, SeasonID: 6, Name: "Major 1", Season: models.Season{GameCode: "BO6"}},
+		2: {ID: 2, SeasonID: 6, Name: "Season totals", TournamentType: "season_summary"},
+	}}
+	teams := &mockTeamStore{teams: map[int]models.Team{
+		1: {ID: 1, Name: "OpTic Texas", GameCode: "BO6"},
+		2: {ID: 2, Name: "Atlanta FaZe", GameCode: "BO6"},
+		3: {ID: 3, Name: "Dallas Empire", GameCode: "MW"},
+	}}
+	as := NewAdminService(ms, tournaments, teams)
+	as.now = func() time.Time { return time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC) }
+	return as
+}
+
+func TestSeriesWinner(t *testing.T) {
+	w, err := seriesWinner(1, 2, 3, 1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), *w)
+	w, err = seriesWinner(1, 2, 1, 1, 5)
+	require.NoError(t, err)
+	assert.Nil(t, w, "series still in progress")
+	for _, s := range [][2]int{{4, 0}, {3, 3}, {-1, 0}} {
+		_, err := seriesWinner(1, 2, s[0], s[1], 5)
+		assert.ErrorIs(t, err, ErrInvalidMatch, "%d-%d", s[0], s[1])
+	}
+}
+
+func TestAdminService_CreateMatch(t *testing.T) {
+	ms := &mockAdminStore{}
+	as := newTestAdminService(ms)
+	ctx := context.Background()
+	valid := AdminMatchInput{TournamentID: 1, Team1ID: 1, Team2ID: 2, Format: "bo5",
+		MatchDate: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), Team1Score: 3, Team2Score: 2}
+
+	_, err := as.CreateMatch(ctx, &models.User{ID: 2, Role: models.RoleModerator}, valid)
+	assert.ErrorIs(t, err, ErrAdminRequired)
+
+	for name, mutate := range map[string]func(*AdminMatchInput){
+		"even format":      func(in *AdminMatchInput) { in.Format = "BO4" },
+		"same team":        func(in *AdminMatchInput) { in.Team2ID = 1 },
+		"no date":          func(in *AdminMatchInput) { in.MatchDate = time.Time{} },
+		"unknown event":    func(in *AdminMatchInput) { in.TournamentID = 99 },
+		"season summary":   func(in *AdminMatchInput) { in.TournamentID = 2 },
+		"other game team":  func(in *AdminMatchInput) { in.Team2ID = 3 },
+		"impossible score": func(in *AdminMatchInput) { in.Team1Score = 4 },
+	} {
+		in := valid
+		mutate(&in)
+		_, err := as.CreateMatch(ctx, webhookAdmin, in)
+		assert.ErrorIs(t, err, ErrInvalidMatch, name)
+	}
+	assert.Nil(t, ms.created)
+
+	m, err := as.CreateMatch(ctx, webhookAdmin, valid)
+	require.NoError(t, err)
+	assert.Equal(t, "BO5", m.Format)
+	require.NotNil(t, m.WinnerID)
+	assert.Equal(t, uint(1), *m.WinnerID)
+	assert.Same(t, m, ms.created)
+}
+
+func TestAdminService_UpdateMatch(t *testing.T) {
+	one, two := uint(1), uint(2)
+	ms := &mockAdminStore{
+		matches: map[uint]*models.Match{5: {ID: 5, TournamentID: 1, Team1ID: 1, Team2ID: 2, Format: "BO5"}},
+		maps: []models.MatchMap{
+			{MatchID: 5, MapNumber: 1, Played: true, WinnerID: &one},
+			{MatchID: 5, MapNumber: 2, Played: true, WinnerID: &two},
+			{MatchID: 5, MapNumber: 4},
+		},
+	}
+	as := newTestAdminService(ms)
+	ctx := context.Background()
+	in := AdminMatchInput{TournamentID: 1, Team1ID: 1, Team2ID: 2, Format: "BO5",
+		MatchDate: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), Team1Score: 1, Team2Score: 1}
+
+	swapped := in
+	swapped.Team1ID, swapped.Team2ID = 2, 1
+	_, err := as.UpdateMatch(ctx, webhookAdmin, 5, swapped)
+	assert.ErrorIs(t, err, ErrInvalidMatch, "teams are fixed once maps exist")
+
+	short := in
+	short.Format = "BO3"
+	_, err = as.UpdateMatch(ctx, webhookAdmin, 5, short)
+	assert.ErrorIs(t, err, ErrInvalidMatch, "BO3 has no map 4")
+
+	wrong := in
+	wrong.Team1Score = 2
+	_, err = as.UpdateMatch(ctx, webhookAdmin, 5, wrong)
+	assert.ErrorIs(t, err, ErrInvalidMatch, "score must equal the map tally")
+
+	_, err = as.UpdateMatch(ctx, webhookAdmin, 6, in)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	m, err := as.UpdateMatch(ctx, webhookAdmin, 5, in)
+	require.NoError(t, err)
+	assert.Nil(t, m.WinnerID)
+}
+
+func TestAdminService_DeleteMatchInUse(t *testing.T) {
+	ms := &mockAdminStore{matches: map[uint]*models.Match{5: {ID: 5}}, refs: 2}
+	as := newTestAdminService(ms)
+	assert.ErrorIs(t, as.DeleteMatch(context.Background(), webhookAdmin, 5, ""), ErrMatchInUse)
+	ms.refs = 0
+	assert.NoError(t, as.DeleteMatch(context.Background(), webhookAdmin, 5, "duplicate of match 4"))
+	assert.Equal(t, "duplicate of match 4", ms.entry.Reason)
+}
+
+func TestAdminService_SaveMap(t *testing.T) {
+	one := uint(1)
+	ms := &mockAdminStore{
+		matches: map[uint]*models.Match{5: {ID: 5, TournamentID: 1, Team1ID: 1, Team2ID: 2, Format: "BO3"}},
+		maps: []models.MatchMap{
+			{MatchID: 5, MapNumber: 1, Played: true, WinnerID: &one},
+			{MatchID: 5, MapNumber: 2, Played: true, WinnerID: &one},
+		},
+	}
+	as := newTestAdminService(ms)
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		number int
+		in     AdminMapInput
+	}{
+		"past the format": {4, AdminMapInput{Mode: "hp", Played: true, Score1: 250, Score2: 100}},
+		"negative score":  {2, AdminMapInput{Mode: "hp", Score1: -1}},
+		"unknown mode":    {2, AdminMapInput{Mode: "gunfight"}},
+		"tied":            {2, AdminMapInput{Mode: "snd", Played: true, Score1: 5, Score2: 5}},
+		"after decided":   {3, AdminMapInput{Mode: "control", Played: true, Score1: 3, Score2: 1}},
+	} {
+		_, err := as.SaveMap(ctx, webhookAdmin, 5, tc.number, tc.in)
+		assert.ErrorIs(t, err, ErrInvalidMap, name)
+	}
+	assert.Nil(t, ms.saved)
+
+	res, err := as.SaveMap(ctx, webhookAdmin, 5, 2, AdminMapInput{MapName: "Rewind", Mode: "snd", Played: true, Score1: 3, Score2: 6})
+	require.NoError(t, err)
+	assert.Equal(t, "Search & Destroy", res.Map.Mode)
+	require.NotNil(t, res.Map.WinnerID)
+	assert.Equal(t, uint(2), *res.Map.WinnerID)
+	assert.Equal(t, AdminSource, ms.saved.Source)
+}
+
+func TestAdminService_SaveStatLine(t *testing.T) {
+	ms := &mockAdminStore{
+		matches: map[uint]*models.Match{5: {ID: 5, TournamentID: 1, Team1ID: 1, Team2ID: 2, Format: "BO5"}},
+		maps:    []models.MatchMap{{MatchID: 5, MapNumber: 1}, {MatchID: 5, MapNumber: 2}},
+		lines:   []models.PlayerMapStats{{MatchID: 5, MapNumber: 1, PlayerID: 40, TeamID: 1}},
+	}
+	as := newTestAdminService(ms)
+	ctx := context.Background()
+	in := AdminStatLineInput{TeamID: 1, Kills: 30, Deaths: 20}
+
+	_, err := as.SaveStatLine(ctx, webhookAdmin, 5, 3, 40, in)
+	assert.ErrorIs(t, err, ErrInvalidStatLine, "no map 3")
+	_, err = as.SaveStatLine(ctx, webhookAdmin, 5, 2, 40, AdminStatLineInput{TeamID: 3})
+	assert.ErrorIs(t, err, ErrInvalidStatLine, "team not in the match")
+	_, err = as.SaveStatLine(ctx, webhookAdmin, 5, 2, 40, AdminStatLineInput{TeamID: 2})
+	assert.ErrorIs(t, err, ErrInvalidStatLine, "switched sides mid-series")
+	_, err = as.SaveStatLine(ctx, webhookAdmin, 5, 2, 40, in)
+	assert.ErrorIs(t, err, ErrInvalidStatLine, "not on the roster")
+	assert.Nil(t, ms.line)
+
+	ms.onTeam = true
+	line, err := as.SaveStatLine(ctx, webhookAdmin, 5, 2, 40, in)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, line.KDRatio)
+	assert.Equal(t, AdminSource, line.Source)
+}
+
+func TestAdminService_ListChangesFilter(t *testing.T) {
+	as := newTestAdminService(&mockAdminStore{})
+	ctx := context.Background()
+	for name, f := range map[string]store.ChangeFilter{
+		"unknown entity":  {Entity: "season"},
+		"key alone":       {EntityKey: "12"},
+		"short key":       {Entity: models.ChangeStatLine, EntityKey: "12:2"},
+		"non-numeric key": {Entity: models.ChangeMap, EntityKey: "12:x"},
+		"inverted window": {Since: time.Now(), Until: time.Now().Add(-time.Hour)},
+	} {
+		_, _, err := as.ListChanges(ctx, webhookAdmin, f, 1, 25)
+		assert.ErrorIs(t, err, ErrInvalidChangeFilter, name)
+	}
+	_, _, err := as.ListChanges(ctx, webhookAdmin, store.ChangeFilter{Entity: models.ChangeMap, EntityKey: "12:2"}, 1, 25)
+	assert.NoError(t, err)
+}
+
+func TestAdminService_RevertChange(t *testing.T) {
+	one := uint(1)
+	ms := &mockAdminStore{
+		matches: map[uint]*models.Match{5: {ID: 5, TournamentID: 1, Team1ID: 1, Team2ID: 2, Format: "BO3"}},
+		maps: []models.MatchMap{
+			{MatchID: 5, MapNumber: 1, Played: true, WinnerID: &one},
+			{MatchID: 5, MapNumber: 2, Played: true, WinnerID: &one},
+		},
+		lines: []models.PlayerMapStats{{MatchID: 5, MapNumber: 2, PlayerID: 40, TeamID: 1}},
+		changes: map[uint]*models.ChangeLog{
+			1: {ID: 1, Entity: models.ChangeMatch, EntityKey: "5", After: json.RawMessage(`{"id":5}`)},
+			2: {ID: 2, Entity: models.ChangeMap, EntityKey: "5:2", After: json.RawMessage(`{"match_id":5}`)},
+			3: {ID: 3, Entity: models.ChangeMap, EntityKey: "5:3", Before: json.RawMessage(`{"match_id":5,"map_number":3,"played":true,"winner_id":2}`)},
+			4: {ID: 4, Entity: models.ChangeStatLine, EntityKey: "6:1:40", Before: json.RawMessage(`{"match_id":6}`)},
+			5: {ID: 5, Entity: models.ChangeStatLine, EntityKey: "5:2:40", Before: json.RawMessage(`{"kills":30}`), After: json.RawMessage(`{"kills":25}`)},
+			6: {ID: 6, Entity: models.ChangeTeam, EntityKey: "9", Action: models.ChangeCreate, After: json.RawMessage(`{"id":9}`)},
+			7: {ID: 7, Entity: models.ChangeMatch, EntityKey: "5", Action: models.ChangeUpdate,
+				Before: json.RawMessage(`{"id":5,"team1_id":1,"team2_id":2,"format":"BO3","team1_score":1,"team2_score":2,"winner_id":2}`)},
+			8: {ID: 8, Entity: models.ChangeMatch, EntityKey: "5", Action: models.ChangeUpdate,
+				Before: json.RawMessage(`{"id":5,"team1_id":1,"team2_id":2,"format":"BO1","team1_score":1,"team2_score":0,"winner_id":1}`)},
+			9: {ID: 9, Entity: models.ChangeMatch, EntityKey: "5", Action: models.ChangeUpdate,
+				Before: json.RawMessage(`{"id":5,"team1_id":1,"team2_id":2,"format":"BO3","team1_score":2,"team2_score":0,"winner_id":1,"vod_url":"old"}`)},
+			10: {ID: 10, Entity: models.ChangeRoster, EntityKey: "5", Action: models.ChangeCreate, After: json.RawMessage(`{"id":5}`)},
+			11: {ID: 11, Entity: models.ChangeRoster, EntityKey: "5", Action: models.ChangeUpdate,
+				Before: json.RawMessage(`{"id":5,"team_id":1}`), After: json.RawMessage(`{"id":5,"team_id":2}`)},
+		},
+	}
+	as := newTestAdminService(ms)
+	ctx := context.Background()
+
+	_, err := as.RevertChange(ctx, webhookAdmin, 5, "")
+	assert.ErrorIs(t, err, ErrInvalidReason, "a revert needs a reason")
+	_, err = as.RevertChange(ctx, webhookAdmin, 99, "typo")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	for id, why := range map[uint]string{
+		1:  "undoing a create of a match that has maps",
+		2:  "undoing a create of a map that has stat lines",
+		3:  "restoring a map played after the series was decided",
+		4:  "restoring a line for a deleted match",
+		6:  "undoing a team created by a merge",
+		7:  "restoring a score the played maps disagree with",
+		8:  "restoring a format too short for the played maps",
+		10: "undoing a roster stint the seeder created",
+	} {
+		_, err := as.RevertChange(ctx, webhookAdmin, id, "typo")
+		assert.ErrorIs(t, err, ErrChangeConflict, why)
+	}
+
+	ms.stale = true
+	_, err = as.RevertChange(ctx, webhookAdmin, 5, "typo")
+	assert.ErrorIs(t, err, ErrChangeConflict, "the line was written since")
+	ms.stale = false
+	reverted, err := as.RevertChange(ctx, webhookAdmin, 5, "typo")
+	require.NoError(t, err)
+	require.NotNil(t, reverted.RevertOf)
+	assert.Equal(t, uint(5), *reverted.RevertOf)
+	assert.Equal(t, "typo", ms.entry.Reason)
+	assert.Equal(t, uint(1), *ms.entry.ActorID)
+
+	_, err = as.RevertChange(ctx, webhookAdmin, 9, "typo")
+	assert.NoError(t, err, "a restored score that agrees with the played maps is fine")
+	_, err = as.RevertChange(ctx, webhookAdmin, 11, "typo")
+	assert.NoError(t, err, "a roster stint moved by a merge goes back, whatever match 5 looks like")
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+var ErrInvalidComparison = errors.New("compare needs between 2 and 5 distinct player IDs")
+
+const maxComparePlayers = 5
+
+// compareModes is the fixed mode order every compared player is aligned to.
+var compareModes = []string{"hp", "snd", "control"}
+
+// PlayerComparison lines up several players on identical season and mode buckets,
+// so row i of every player's Seasons (or Modes) describes the same slice of play.
+type PlayerComparison struct {
+	SeasonID     string           `json:"season_id,omitempty"`
+	TournamentID string           `json:"tournament_id,omitempty"`
+	Players      []ComparedPlayer `json:"players"`
+	FacedMaps    []FacedMap       `json:"faced_maps"`
+}
+
+type ComparedPlayer struct {
+	PlayerID  uint          `json:"player_id"`
+	Gamertag  string        `json:"gamertag"`
+	AvatarURL string        `json:"avatar_url"`
+	Overall   CompareLine   `json:"overall"`
+	Modes     []CompareLine `json:"modes"`
+	Seasons   []CompareLine `json:"seasons"`
+}
+
+// CompareLine is one bucket of per-map totals plus the rates derived from them.
+// Mode or SeasonID identifies the bucket; both are empty on the overall line.
+type CompareLine struct {
+	Mode           string  `json:"mode,omitempty"`
+	SeasonID       uint    `json:"season_id,omitempty"`
+	SeasonName     string  `json:"season_name,omitempty"`
+	Maps           int     `json:"maps"`
+	Kills          int     `json:"kills"`
+	Deaths         int     `json:"deaths"`
+	KD             float64 `json:"kd"`
+	KillsPer10Min  float64 `json:"kills_per_10min"`
+	DamagePerMap   float64 `json:"damage_per_map"`
+	FirstBloods    int     `json:"first_bloods"`
+	FirstDeaths    int     `json:"first_deaths"`
+	Plants         int     `json:"plants"`
+	Defuses        int     `json:"defuses"`
+	NonTradedKills int     `json:"non_traded_kills"`
+
+	timedKills  int
+	durationSec int
+}
+
+func (l *CompareLine) add(r store.PlayerCompareRow) {
+	l.Maps += r.Maps
+	l.Kills += r.Kills
+	l.Deaths += r.Deaths
+	l.DamagePerMap += float64(r.Damage) // summed here, divided in finish
+	l.FirstBloods += r.FirstBloods
+	l.FirstDeaths += r.FirstDeaths
+	l.Plants += r.Plants
+	l.Defuses += r.Defuses
+	l.NonTradedKills += r.NonTradedKills
+	l.timedKills += r.TimedKills
+	l.durationSec += r.DurationSec
+}
+
+func (l *CompareLine) finish() {
+	l.KD = CalculateKD(l.Kills, l.Deaths)
+	if l.Maps > 0 {
+		l.DamagePerMap /= float64(l.Maps)
+	}
+	if l.durationSec > 0 {
+		l.KillsPer10Min = float64(l.timedKills) / (float64(l.durationSec) / 600)
+	}
+}
+
+// FacedMap is a map on which at least two compared players were on opposing teams.
+type FacedMap struct {
+	MatchID   uint        `json:"match_id"`
+	MapNumber int         `json:"map_number"`
+	MapName   string      `json:"map_name"`
+	Mode      string      `json:"mode"`
+	MatchDate time.Time   `json:"match_date"`
+	Lines     []FacedLine `json:"lines"`
+}
+
+type FacedLine struct {
+	PlayerID uint    `json:"player_id"`
+	TeamID   uint    `json:"team_id"`
+	Kills    int     `json:"kills"`
+	Deaths   int     `json:"deaths"`
+	KD       float64 `json:"kd"`
+	Damage   int     `json:"damage"`
+}
+
+// Compare builds a side-by-side view of the given players, optionally scoped to a
+// season or tournament (empty strings mean all data).
+func (ps *PlayerService) Compare(ctx context.Context, playerIDs []int, seasonID, tournamentID string) (*PlayerComparison, error) {
+	if len(playerIDs) < 2 || len(playerIDs) > maxComparePlayers {
+		return nil, ErrInvalidComparison
+	}
+	ids := make([]uint, 0, len(playerIDs))
+	seen := map[int]bool{}
+	for _, id := range playerIDs {
+		if id <= 0 || seen[id] {
+			return nil, ErrInvalidComparison
+		}
+		seen[id] = true
+		ids = append(ids, uint(id))
+	}
+
+	players := make([]ComparedPlayer, len(playerIDs))
+	index := make(map[uint]int, len(playerIDs))
+	for i, id := range playerIDs {
+		p, err := ps.store.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		players[i] = ComparedPlayer{PlayerID: p.ID, Gamertag: p.Gamertag, AvatarURL: p.AvatarURL}
+		index[p.ID] = i
+	}
+
+	rows, err := ps.store.ListCompareRows(ctx, ids, seasonID, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	faced, err := ps.store.ListFacedMapRows(ctx, ids, seasonID, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Season buckets in the order the store returns them (newest first), shared by every player.
+	var seasonOrder []uint
+	seasonNames := map[uint]string{}
+	for _, r := range rows {
+		if _, ok := seasonNames[r.SeasonID]; !ok {
+			seasonNames[r.SeasonID] = r.SeasonName
+			seasonOrder = append(seasonOrder, r.SeasonID)
+		}
+	}
+	seasonPos := make(map[uint]int, len(seasonOrder))
+	for i, sid := range seasonOrder {
+		seasonPos[sid] = i
+	}
+	modePos := make(map[string]int, len(compareModes))
+	for i, m := range compareModes {
+		modePos[m] = i
+	}
+
+	for i := range players {
+		players[i].Modes = make([]CompareLine, len(compareModes))
+		for j, m := range compareModes {
+			players[i].Modes[j].Mode = m
+		}
+		players[i].Seasons = make([]CompareLine, len(seasonOrder))
+		for j, sid := range seasonOrder {
+			players[i].Seasons[j].SeasonID = sid
+			players[i].Seasons[j].SeasonName = seasonNames[sid]
+		}
+	}
+
+	for _, r := range rows {
+		p := &players[index[r.PlayerID]]
+		p.Overall.add(r)
+		p.Seasons[seasonPos[r.SeasonID]].add(r)
+		if j, ok := modePos[r.Mode]; ok {
+			p.Modes[j].add(r)
+		}
+	}
+	for i := range players {
+		players[i].Overall.finish()
+		for j := range players[i].Modes {
+			players[i].Modes[j].finish()
+		}
+		for j := range players[i].Seasons {
+			players[i].Seasons[j].finish()
+		}
+	}
+
+	facedMaps := make([]FacedMap, 0)
+	for _, r := range faced {
+		n := len(facedMaps)
+		if n == 0 || facedMaps[n-1].MatchID != r.MatchID || facedMaps[n-1].MapNumber != r.MapNumber {
+			facedMaps = append(facedMaps, FacedMap{
+				MatchID:   r.MatchID,
+				MapNumber: r.MapNumber,
+				MapName:   r.MapName,
+				Mode:      normalizeMode(r.Mode),
+				MatchDate: r.MatchDate,
+			})
+			n++
+		}
+		facedMaps[n-1].Lines = append(facedMaps[n-1].Lines, FacedLine{
+			PlayerID: r.PlayerID,
+			TeamID:   r.TeamID,
+			Kills:    r.Kills,
+			Deaths:   r.Deaths,
+			KD:       CalculateKD(r.Kills, r.Deaths),
+			Damage:   r.Damage,
+		})
+	}
+
+	return &PlayerComparison{
+		SeasonID:     seasonID,
+		TournamentID: tournamentID,
+		Players:      players,
+		FacedMaps:    facedMaps,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockPlayerStore struct {
+	players map[int]models.Player
+	compare []store.PlayerCompareRow
+	faced   []store.FacedMapRow
+	form    []store.FormMapRow
+}
+
+func (m *mockPlayerStore) List(context.Context, string, int, int) ([]models.Player, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockPlayerStore) GetByID(_ context.Context, id int) (*models.Player, error) {
+	p, ok := m.players[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &p, nil
+}
+
+func (m *mockPlayerStore) ListMatchStats(context.Context, int) ([]models.PlayerMatchStats, error) {
+	return nil, nil
+}
+
+func (m *mockPlayerStore) ListTournamentStats(context.Context, int) ([]models.PlayerTournamentStats, error) {
+	return nil, nil
+}
+
+func (m *mockPlayerStore) ListModeKDSplits(context.Context, int) ([]store.ModeKDSplit, error) {
+	return nil, nil
+}
+
+func (m *mockPlayerStore) ListMatchHistoryRows(context.Context, int) ([]models.PlayerMatchStats, error) {
+	return nil, nil
+}
+
+func (m *mockPlayerStore) ListCareerRows(context.Context, int) ([]store.PlayerCareerRow, error) {
+	return nil, nil
+}
+
+func (m *mockPlayerStore) ListCompareRows(context.Context, []uint, string, string) ([]store.PlayerCompareRow, error) {
+	return m.compare, nil
+}
+
+func (m *mockPlayerStore) ListFacedMapRows(context.Context, []uint, string, string) ([]store.FacedMapRow, error) {
+	return m.faced, nil
+}
+
+func (m *mockPlayerStore) ListFormMaps(context.Context, int) ([]store.FormMapRow, error) {
+	return m.form, nil
+}
+
+func comparePlayers() map[int]models.Player {
+	return map[int]models.Player{
+		1: {ID: 1, Gamertag: "Simp"},
+		2: {ID: 2, Gamertag: "aBeZy"},
+	}
+}
+
+func TestCompare_RejectsBadIDSets(t *testing.T) {
+	ps := NewPlayerService(&mockPlayerStore{players: comparePlayers()})
+	for _, ids := range [][]int{{1}, {1, 1}, {1, 2, 3, 4, 5, 6}, {1, 0}} {
+		_, err := ps.Compare(context.Background(), ids, "", "")
+		assert.ErrorIs(t, err, ErrInvalidComparison, "ids %v", ids)
+	}
+}
+
+func TestCompare_UnknownPlayer(t *testing.T) {
+	ps := NewPlayerService(&mockPlayerStore{players: comparePlayers()})
+	_, err := ps.Compare(context.Background(), []int{1, 9}, "", "")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestCompare_AlignsSeasonsAndModes(t *testing.T) {
+	s := &mockPlayerStore{
+		players: comparePlayers(),
+		compare: []store.PlayerCompareRow{
+			// Season 5 only has player 1; player 2 must still get a zeroed season 5 row.
+			{PlayerID: 1, SeasonID: 5, SeasonName: "CDL 2025", Mode: "hp", Maps: 2, Kills: 60, Deaths: 40, Damage: 16000, TimedKills: 60, DurationSec: 1200},
+			{PlayerID: 1, SeasonID: 4, SeasonName: "CDL 2024", Mode: "snd", Maps: 1, Kills: 10, Deaths: 5, Damage: 2000, FirstBloods: 3, Plants: 2},
+			{PlayerID: 2, SeasonID: 4, SeasonName: "CDL 2024", Mode: "control", Maps: 1, Kills: 25, Deaths: 25, Damage: 5000},
+		},
+	}
+	ps := NewPlayerService(s)
+
+	res, err := ps.Compare(context.Background(), []int{1, 2}, "", "")
+	require.NoError(t, err)
+	require.Len(t, res.Players, 2)
+
+	p1, p2 := res.Players[0], res.Players[1]
+	assert.Equal(t, "Simp", p1.Gamertag)
+
+	require.Len(t, p1.Seasons, 2)
+	require.Len(t, p2.Seasons, 2)
+	assert.Equal(t, uint(5), p1.Seasons[0].SeasonID)
+	assert.Equal(t, uint(5), p2.Seasons[0].SeasonID)
+	assert.Equal(t, 0, p2.Seasons[0].Maps)
+	assert.Equal(t, 1, p2.Seasons[1].Maps)
+
+	require.Len(t, p1.Modes, 3)
+	assert.Equal(t, []string{"hp", "snd", "control"}, []string{p1.Modes[0].Mode, p1.Modes[1].Mode, p1.Modes[2].Mode})
+	assert.Equal(t, 3, p1.Modes[1].FirstBloods)
+	assert.Equal(t, 1, p2.Modes[2].Maps)
+
+	assert.Equal(t, 3, p1.Overall.Maps)
+	assert.InDelta(t, 70.0/45.0, p1.Overall.KD, 0.01)
+	assert.InDelta(t, 6000.0, p1.Overall.DamagePerMap, 0.01)
+	// Only the hp bucket had durations: 60 kills over 20 minutes.
+	assert.InDelta(t, 30.0, p1.Modes[0].KillsPer10Min, 0.01)
+	assert.InDelta(t, 30.0, p1.Overall.KillsPer10Min, 0.01)
+	assert.Equal(t, 0.0, p1.Modes[1].KillsPer10Min)
+}
+
+func TestCompare_GroupsFacedMaps(t *testing.T) {
+	played := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	s := &mockPlayerStore{
+		players: comparePlayers(),
+		faced: []store.FacedMapRow{
+			{MatchID: 10, MapNumber: 1, MapName: "Protocol", Mode: "Hardpoint", MatchDate: played, PlayerID: 1, TeamID: 3, Kills: 30, Deaths: 20},
+			{MatchID: 10, MapNumber: 1, MapName: "Protocol", Mode: "Hardpoint", MatchDate: played, PlayerID: 2, TeamID: 4, Kills: 20, Deaths: 30},
+			{MatchID: 10, MapNumber: 2, MapName: "Vault", Mode: "Search and Destroy", MatchDate: played, PlayerID: 1, TeamID: 3, Kills: 8, Deaths: 6},
+		},
+	}
+	res, err := NewPlayerService(s).Compare(context.Background(), []int{1, 2}, "", "")
+	require.NoError(t, err)
+
+	require.Len(t, res.FacedMaps, 2)
+	assert.Equal(t, "hp", res.FacedMaps[0].Mode)
+	assert.Len(t, res.FacedMaps[0].Lines, 2)
+	assert.Equal(t, "snd", res.FacedMaps[1].Mode)
+	assert.InDelta(t, 1.5, res.FacedMaps[0].Lines[0].KD, 0.001)
+}
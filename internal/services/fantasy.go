@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidLeague = errors.New("league needs a name of at most 100 characters and a tournament")
+var ErrLineupLocked = errors.New("lineups for this league are locked")
+var ErrNotLeagueMember = errors.New("you are not in this league")
+var ErrInvalidLineup = errors.New("invalid lineup")
+
+// FantasyRules are the points one map line earns. Objective stats only score in the
+// mode that records them, so one rule set works across modes.
+type FantasyRules struct {
+	Kill              float64 `json:"kill"`
+	Death             float64 `json:"death"`
+	Assist            float64 `json:"assist"`
+	FirstBlood        float64 `json:"first_blood"`
+	NonTradedKill     float64 `json:"non_traded_kill"`
+	HillTimePerMinute float64 `json:"hill_time_per_minute"`
+	Plant             float64 `json:"plant"`
+	Defuse            float64 `json:"defuse"`
+	Capture           float64 `json:"capture"`
+	MapWin            float64 `json:"map_win"`
+}
+
+// FantasyRuleSet picks the rules for a map by the GameCode of its season, falling
+// back to Default for eras without an override.
+type FantasyRuleSet struct {
+	Default    FantasyRules            `json:"default"`
+	ByGameCode map[string]FantasyRules `json:"by_game_code"`
+}
+
+func (rs FantasyRuleSet) For(gameCode string) FantasyRules {
+	if r, ok := rs.ByGameCode[gameCode]; ok {
+		return r
+	}
+	return rs.Default
+}
+
+func DefaultFantasyRuleSet() FantasyRuleSet {
+	base := FantasyRules{
+		Kill:              1,
+		Death:             -0.5,
+		Assist:            0.25,
+		FirstBlood:        1,
+		NonTradedKill:     0.25,
+		HillTimePerMinute: 0.5,
+		Plant:             2,
+		Defuse:            2,
+		Capture:           1,
+		MapWin:            3,
+	}
+	return FantasyRuleSet{Default: base}
+}
+
+// FantasyConfig sets the league shape and how salaries are priced: a player's average
+// points over their last PricingMaps maps maps linearly onto MinSalary..MaxSalary
+// across the tournament's pool. Players with no history cost MinSalary.
+type FantasyConfig struct {
+	Rules       FantasyRuleSet
+	SalaryCap   int
+	RosterSize  int
+	MinSalary   int
+	MaxSalary   int
+	PricingMaps int
+}
+
+func DefaultFantasyConfig() FantasyConfig {
+	return FantasyConfig{
+		Rules:       DefaultFantasyRuleSet(),
+		SalaryCap:   100,
+		RosterSize:  4,
+		MinSalary:   10,
+		MaxSalary:   40,
+		PricingMaps: 20,
+	}
+}
+
+// FantasyLeagueInput is the body of POST /fantasy/leagues.
+type FantasyLeagueInput struct {
+	Name         string `json:"name"`
+	TournamentID uint   `json:"tournament_id"`
+}
+
+// FantasyLeagueDetail is the /fantasy/leagues/:id response: the league, the caller's
+// lineup and the standings.
+type FantasyLeagueDetail struct {
+	League    *models.FantasyLeague        `json:"league"`
+	Locked    bool                         `json:"locked"`
+	Lineup    []models.FantasyLineupPlayer `json:"lineup"`
+	Standings []store.FantasyStandingRow   `json:"standings"`
+}
+
+type FantasyService struct {
+	store       store.FantasyStore
+	tournaments store.TournamentStore
+	cfg         FantasyConfig
+	now         func() time.Time
+}
+
+func NewFantasyService(s store.FantasyStore, tournaments store.TournamentStore, cfg FantasyConfig) *FantasyService {
+	return &FantasyService{store: s, tournaments: tournaments, cfg: cfg, now: time.Now}
+}
+
+// scoreLine returns the fantasy points one map line earns under r.
+func scoreLine(r FantasyRules, l store.FantasyStatLine) float64 {
+	points := r.Kill*float64(l.Kills) +
+		r.Death*float64(l.Deaths) +
+		r.Assist*float64(l.Assists) +
+		r.FirstBlood*float64(l.FirstBloods) +
+		r.NonTradedKill*float64(l.NonTradedKills) +
+		r.HillTimePerMinute*float64(l.HillTime)/60 +
+		r.Plant*float64(l.Plants) +
+		r.Defuse*float64(l.Defuses) +
+		r.Capture*float64(l.Captures)
+	if l.Won {
+		points += r.MapWin
+	}
+	return points
+}
+
+// inviteAlphabet leaves out 0/O and 1/I so codes survive being read aloud.
+const inviteAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func newInviteCode() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = inviteAlphabet[int(b)%len(inviteAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// CreateLeague creates a league for a tournament that has not started, prices the
+// tournament's player pool if that has not been done, and makes the owner a member.
+func (fs *FantasyService) CreateLeague(ctx context.Context, ownerID uint, in FantasyLeagueInput) (*models.FantasyLeague, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 || in.TournamentID == 0 {
+		return nil, ErrInvalidLeague
+	}
+	tournament, err := fs.tournaments.GetByID(ctx, int(in.TournamentID))
+	if err != nil {
+		return nil, err
+	}
+	if !fs.now().Before(tournament.StartDate) {
+		return nil, ErrLineupLocked
+	}
+
+	n, err := fs.store.CountPrices(ctx, tournament.ID)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		if _, err := fs.PriceTournament(ctx, tournament); err != nil {
+			return nil, err
+		}
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+	league := &models.FantasyLeague{
+		Name:         name,
+		TournamentID: tournament.ID,
+		OwnerID:      ownerID,
+		InviteCode:   code,
+		SalaryCap:    fs.cfg.SalaryCap,
+		RosterSize:   fs.cfg.RosterSize,
+		LockAt:       tournament.StartDate,
+	}
+	if err := fs.store.CreateLeague(ctx, league); err != nil {
+		return nil, err
+	}
+	league.Tournament = *tournament
+	return league, nil
+}
+
+// JoinLeague adds the user to the league with the invite code; joining twice is not
+// an error. Leagues cannot be joined once lineups lock.
+func (fs *FantasyService) JoinLeague(ctx context.Context, userID uint, code string) (*models.FantasyLeague, error) {
+	league, err := fs.store.GetLeagueByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, err
+	}
+	if !fs.now().Before(league.LockAt) {
+		return nil, ErrLineupLocked
+	}
+	if err := fs.store.AddMember(ctx, &models.FantasyMember{LeagueID: league.ID, UserID: userID}); err != nil {
+		return nil, err
+	}
+	return league, nil
+}
+
+func (fs *FantasyService) ListLeagues(ctx context.Context, userID uint) ([]models.FantasyLeague, error) {
+	return fs.store.ListUserLeagues(ctx, userID)
+}
+
+// memberOf loads the league and the caller's membership in it.
+func (fs *FantasyService) memberOf(ctx context.Context, userID uint, leagueID int) (*models.FantasyLeague, *models.FantasyMember, error) {
+	league, err := fs.store.GetLeague(ctx, leagueID)
+	if err != nil {
+		return nil, nil, err
+	}
+	member, err := fs.store.GetMember(ctx, league.ID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrNotLeagueMember
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return league, member, nil
+}
+
+func (fs *FantasyService) GetLeague(ctx context.Context, userID uint, leagueID int) (*FantasyLeagueDetail, error) {
+	league, member, err := fs.memberOf(ctx, userID, leagueID)
+	if err != nil {
+		return nil, err
+	}
+	lineup, err := fs.store.GetLineup(ctx, member.ID)
+	if err != nil {
+		return nil, err
+	}
+	standings, err := fs.store.ListStandings(ctx, league)
+	if err != nil {
+		return nil, err
+	}
+	return &FantasyLeagueDetail{
+		League:    league,
+		Locked:    !fs.now().Before(league.LockAt),
+		Lineup:    lineup,
+		Standings: standings,
+	}, nil
+}
+
+// GetPool lists the players a member can draft, with salaries and points so far.
+func (fs *FantasyService) GetPool(ctx context.Context, userID uint, leagueID int) ([]store.FantasyPoolPlayer, error) {
+	league, _, err := fs.memberOf(ctx, userID, leagueID)
+	if err != nil {
+		return nil, err
+	}
+	return fs.store.ListPool(ctx, league.TournamentID)
+}
+
+func (fs *FantasyService) GetStandings(ctx context.Context, userID uint, leagueID int) ([]store.FantasyStandingRow, error) {
+	league, _, err := fs.memberOf(ctx, userID, leagueID)
+	if err != nil {
+		return nil, err
+	}
+	return fs.store.ListStandings(ctx, league)
+}
+
+// SetLineup replaces the caller's lineup before the league locks. The lineup must be
+// exactly RosterSize distinct players from the pool with salaries within SalaryCap.
+func (fs *FantasyService) SetLineup(ctx context.Context, userID uint, leagueID int, playerIDs []uint) ([]models.FantasyLineupPlayer, error) {
+	league, member, err := fs.memberOf(ctx, userID, leagueID)
+	if err != nil {
+		return nil, err
+	}
+	if !fs.now().Before(league.LockAt) {
+		return nil, ErrLineupLocked
+	}
+	if len(playerIDs) != league.RosterSize {
+		return nil, fmt.Errorf("%w: pick exactly %d players", ErrInvalidLineup, league.RosterSize)
+	}
+
+	pool, err := fs.store.ListPool(ctx, league.TournamentID)
+	if err != nil {
+		return nil, err
+	}
+	salary := make(map[uint]int, len(pool))
+	for _, p := range pool {
+		salary[p.PlayerID] = p.Salary
+	}
+
+	lineup := make([]models.FantasyLineupPlayer, 0, len(playerIDs))
+	seen := make(map[uint]bool, len(playerIDs))
+	total := 0
+	for _, id := range playerIDs {
+		cost, ok := salary[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: player %d is not in this tournament's pool", ErrInvalidLineup, id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: player %d picked twice", ErrInvalidLineup, id)
+		}
+		seen[id] = true
+		total += cost
+		lineup = append(lineup, models.FantasyLineupPlayer{MemberID: member.ID, PlayerID: id, Salary: cost})
+	}
+	if total > league.SalaryCap {
+		return nil, fmt.Errorf("%w: salaries total %d, cap is %d", ErrInvalidLineup, total, league.SalaryCap)
+	}
+
+	if err := fs.store.SetLineup(ctx, member.ID, lineup); err != nil {
+		return nil, err
+	}
+	return fs.store.GetLineup(ctx, member.ID)
+}
+
+// priceSalaries turns each eligible player's recent lines into a salary.
+func (fs *FantasyService) priceSalaries(eligible []store.FantasyEligible, lines []store.FantasyStatLine) map[uint]int {
+	sum := map[uint]float64{}
+	maps := map[uint]int{}
+	for _, l := range lines {
+		sum[l.PlayerID] += scoreLine(fs.cfg.Rules.For(l.GameCode), l)
+		maps[l.PlayerID]++
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	avg := make(map[uint]float64, len(maps))
+	for id, n := range maps {
+		a := sum[id] / float64(n)
+		avg[id] = a
+		lo, hi = math.Min(lo, a), math.Max(hi, a)
+	}
+
+	spread := float64(fs.cfg.MaxSalary - fs.cfg.MinSalary)
+	salaries := make(map[uint]int, len(eligible))
+	for _, e := range eligible {
+		a, ok := avg[e.PlayerID]
+		switch {
+		case !ok:
+			salaries[e.PlayerID] = fs.cfg.MinSalary
+		case hi == lo:
+			salaries[e.PlayerID] = fs.cfg.MinSalary + int(math.Round(spread/2))
+		default:
+			salaries[e.PlayerID] = fs.cfg.MinSalary + int(math.Round((a-lo)/(hi-lo)*spread))
+		}
+	}
+	return salaries
+}
+
+// PriceTournament sets salaries for every player eligible for the tournament from
+// their form before it starts, replacing any earlier pool, and returns the pool size.
+func (fs *FantasyService) PriceTournament(ctx context.Context, tournament *models.Tournament) (int, error) {
+	eligible, lines, err := fs.store.ListPricingLines(ctx, tournament.ID, tournament.StartDate, fs.cfg.PricingMaps)
+	if err != nil {
+		return 0, err
+	}
+	salaries := fs.priceSalaries(eligible, lines)
+	prices := make([]models.FantasyPrice, len(eligible))
+	for i, e := range eligible {
+		prices[i] = models.FantasyPrice{TournamentID: tournament.ID, PlayerID: e.PlayerID, TeamID: e.TeamID, Salary: salaries[e.PlayerID]}
+	}
+	if err := fs.store.SavePrices(ctx, tournament.ID, prices); err != nil {
+		return 0, err
+	}
+	return len(prices), nil
+}
+
+// ScoreTournament recomputes every player's fantasy points for the tournament from
+// player_map_stats and returns how many players scored. It is safe to re-run after
+// stats are corrected.
+func (fs *FantasyService) ScoreTournament(ctx context.Context, tournamentID uint) (int, error) {
+	lines, err := fs.store.ListScoringLines(ctx, tournamentID)
+	if err != nil {
+		return 0, err
+	}
+	byPlayer := map[uint]*models.FantasyPlayerScore{}
+	order := make([]uint, 0)
+	for _, l := range lines {
+		s, ok := byPlayer[l.PlayerID]
+		if !ok {
+			s = &models.FantasyPlayerScore{TournamentID: tournamentID, PlayerID: l.PlayerID}
+			byPlayer[l.PlayerID] = s
+			order = append(order, l.PlayerID)
+		}
+		s.Maps++
+		s.Points += scoreLine(fs.cfg.Rules.For(l.GameCode), l)
+	}
+	scores := make([]models.FantasyPlayerScore, len(order))
+	for i, id := range order {
+		scores[i] = *byPlayer[id]
+		scores[i].Points = math.Round(scores[i].Points*100) / 100
+	}
+	if err := fs.store.ReplaceScores(ctx, tournamentID, scores); err != nil {
+		return 0, err
+	}
+	return len(scores), nil
+}
+
+// ScoreAll rescores every tournament that has at least one league and returns how
+// many tournaments it scored.
+func (fs *FantasyService) ScoreAll(ctx context.Context) (int, error) {
+	ids, err := fs.store.ListLeagueTournaments(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if _, err := fs.ScoreTournament(ctx, id); err != nil {
+			return 0, fmt.Errorf("tournament %d: %w", id, err)
+		}
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockFantasyStore struct {
+	league  *models.FantasyLeague
+	members map[uint]*models.FantasyMember
+	pool    []store.FantasyPoolPlayer
+	lineup  []models.FantasyLineupPlayer
+	lines   []store.FantasyStatLine
+	scores  []models.FantasyPlayerScore
+}
+
+func (m *mockFantasyStore) CreateLeague(context.Context, *models.FantasyLeague) error { return nil }
+func (m *mockFantasyStore) GetLeague(context.Context, int) (*models.FantasyLeague, error) {
+	if m.league == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return m.league, nil
+}
+func (m *mockFantasyStore) GetLeagueByCode(context.Context, string) (*models.FantasyLeague, error) {
+	return m.league, nil
+}
+func (m *mockFantasyStore) ListUserLeagues(context.Context, uint) ([]models.FantasyLeague, error) {
+	return nil, nil
+}
+func (m *mockFantasyStore) AddMember(context.Context, *models.FantasyMember) error { return nil }
+func (m *mockFantasyStore) GetMember(_ context.Context, _, userID uint) (*models.FantasyMember, error) {
+	if mem, ok := m.members[userID]; ok {
+		return mem, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (m *mockFantasyStore) GetLineup(context.Context, uint) ([]models.FantasyLineupPlayer, error) {
+	return m.lineup, nil
+}
+func (m *mockFantasyStore) SetLineup(_ context.Context, _ uint, players []models.FantasyLineupPlayer) error {
+	m.lineup = players
+	return nil
+}
+func (m *mockFantasyStore) CountPrices(context.Context, uint) (int64, error) { return 0, nil }
+func (m *mockFantasyStore) ListPool(context.Context, uint) ([]store.FantasyPoolPlayer, error) {
+	return m.pool, nil
+}
+func (m *mockFantasyStore) ListPricingLines(context.Context, uint, time.Time, int) ([]store.FantasyEligible, []store.FantasyStatLine, error) {
+	return nil, nil, nil
+}
+func (m *mockFantasyStore) SavePrices(context.Context, uint, []models.FantasyPrice) error { return nil }
+func (m *mockFantasyStore) ListLeagueTournaments(context.Context) ([]uint, error)         { return nil, nil }
+func (m *mockFantasyStore) ListScoringLines(context.Context, uint) ([]store.FantasyStatLine, error) {
+	return m.lines, nil
+}
+func (m *mockFantasyStore) ReplaceScores(_ context.Context, _ uint, scores []models.FantasyPlayerScore) error {
+	m.scores = scores
+	return nil
+}
+func (m *mockFantasyStore) ListStandings(context.Context, *models.FantasyLeague) ([]store.FantasyStandingRow, error) {
+	return nil, nil
+}
+
+func TestScoreLine(t *testing.T) {
+	r := DefaultFantasyRuleSet().Default
+	hp := store.FantasyStatLine{Kills: 30, Deaths: 24, Assists: 4, HillTime: 120, Won: true}
+	assert.InDelta(t, 30-12+1+1+3, scoreLine(r, hp), 1e-9)
+
+	snd := store.FantasyStatLine{Kills: 8, Deaths: 6, FirstBloods: 2, Plants: 1, Defuses: 1, NonTradedKills: 4}
+	assert.InDelta(t, 8-3+2+2+2+1, scoreLine(r, snd), 1e-9)
+}
+
+func TestFantasyRuleSet_For(t *testing.T) {
+	rs := DefaultFantasyRuleSet()
+	cw := rs.Default
+	cw.MapWin = 5
+	rs.ByGameCode = map[string]FantasyRules{"CW": cw}
+	assert.Equal(t, 5.0, rs.For("CW").MapWin)
+	assert.Equal(t, 3.0, rs.For("BO6").MapWin)
+}
+
+func TestPriceSalaries(t *testing.T) {
+	fs := NewFantasyService(&mockFantasyStore{}, nil, DefaultFantasyConfig())
+	eligible := []store.FantasyEligible{{PlayerID: 1}, {PlayerID: 2}, {PlayerID: 3}, {PlayerID: 4}}
+	lines := []store.FantasyStatLine{
+		{PlayerID: 1, Kills: 30}, {PlayerID: 1, Kills: 20}, // avg 25
+		{PlayerID: 2, Kills: 10}, // avg 10
+		{PlayerID: 3, Kills: 16}, // avg 16: 40% of the way from 10 to 25
+	}
+	assert.Equal(t, map[uint]int{1: 40, 2: 10, 3: 22, 4: 10}, fs.priceSalaries(eligible, lines))
+
+	flat := fs.priceSalaries(eligible[:1], lines[:1])
+	assert.Equal(t, 25, flat[1], "a pool with one form level prices at the midpoint")
+}
+
+func TestSetLineup(t *testing.T) {
+	lock := time.Date(2025, 6, 1, 18, 0, 0, 0, time.UTC)
+	ms := &mockFantasyStore{
+		league:  &models.FantasyLeague{ID: 1, TournamentID: 9, SalaryCap: 100, RosterSize: 3, LockAt: lock},
+		members: map[uint]*models.FantasyMember{7: {ID: 70, LeagueID: 1, UserID: 7}},
+		pool: []store.FantasyPoolPlayer{
+			{PlayerID: 1, Salary: 40}, {PlayerID: 2, Salary: 35}, {PlayerID: 3, Salary: 30}, {PlayerID: 4, Salary: 20},
+		},
+	}
+	fs := NewFantasyService(ms, nil, DefaultFantasyConfig())
+	fs.now = func() time.Time { return lock.Add(-time.Hour) }
+	ctx := context.Background()
+
+	_, err := fs.SetLineup(ctx, 8, 1, []uint{1, 2, 4})
+	assert.ErrorIs(t, err, ErrNotLeagueMember)
+
+	for name, ids := range map[string][]uint{
+		"wrong size":  {1, 2},
+		"over cap":    {1, 2, 3},
+		"not in pool": {1, 2, 99},
+		"duplicate":   {4, 4, 3},
+	} {
+		_, err := fs.SetLineup(ctx, 7, 1, ids)
+		assert.ErrorIs(t, err, ErrInvalidLineup, name)
+	}
+	assert.Empty(t, ms.lineup)
+
+	_, err = fs.SetLineup(ctx, 7, 1, []uint{1, 2, 4})
+	require.NoError(t, err)
+	assert.Equal(t, []models.FantasyLineupPlayer{
+		{MemberID: 70, PlayerID: 1, Salary: 40},
+		{MemberID: 70, PlayerID: 2, Salary: 35},
+		{MemberID: 70, PlayerID: 4, Salary: 20},
+	}, ms.lineup)
+
+	fs.now = func() time.Time { return lock }
+	_, err = fs.SetLineup(ctx, 7, 1, []uint{1, 3, 4})
+	assert.ErrorIs(t, err, ErrLineupLocked)
+}
+
+func TestScoreTournament(t *testing.T) {
+	ms := &mockFantasyStore{lines: []store.FantasyStatLine{
+		{PlayerID: 1, GameCode: "BO6", Kills: 20, Deaths: 20, Won: true},
+		{PlayerID: 1, GameCode: "BO6", Kills: 10, Deaths: 5},
+		{PlayerID: 2, GameCode: "BO6", Kills: 5, Deaths: 10},
+	}}
+	fs := NewFantasyService(ms, nil, DefaultFantasyConfig())
+
+	n, err := fs.ScoreTournament(context.Background(), 9)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []models.FantasyPlayerScore{
+		{TournamentID: 9, PlayerID: 1, Maps: 2, Points: 10 + 3 + 7.5},
+		{TournamentID: 9, PlayerID: 2, Maps: 1, Points: 0},
+	}, ms.scores)
+}
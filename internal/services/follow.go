@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidFollowType = errors.New("can only follow players, teams or franchises")
+
+// FeedConfig sets how far back (and, for matches, ahead) the feed looks.
+type FeedConfig struct {
+	MatchesBack   time.Duration
+	MatchesAhead  time.Duration
+	TransfersBack time.Duration
+	ThreadsBack   time.Duration
+}
+
+func DefaultFeedConfig() FeedConfig {
+	return FeedConfig{
+		MatchesBack:   30 * 24 * time.Hour,
+		MatchesAhead:  14 * 24 * time.Hour,
+		TransfersBack: 90 * 24 * time.Hour,
+		ThreadsBack:   7 * 24 * time.Hour,
+	}
+}
+
+// Feed item types.
+const (
+	FeedMatch    = "match"
+	FeedTransfer = "transfer"
+	FeedThread   = "thread"
+)
+
+// FeedItem is one entry in /me/feed; exactly one of Match, Transfer and Thread is set,
+// matching Type. Upcoming is set on matches that have not been decided yet.
+type FeedItem struct {
+	Type     string                 `json:"type"`
+	At       time.Time              `json:"at"`
+	Upcoming bool                   `json:"upcoming,omitempty"`
+	Match    *models.Match          `json:"match,omitempty"`
+	Transfer *models.PlayerTransfer `json:"transfer,omitempty"`
+	Thread   *FeedThreadActivity    `json:"thread,omitempty"`
+}
+
+// FeedThreadActivity summarises recent posts in a followed match's thread.
+type FeedThreadActivity struct {
+	ThreadID uint          `json:"thread_id"`
+	MatchID  uint          `json:"match_id"`
+	NewPosts int           `json:"new_posts"`
+	Match    *models.Match `json:"match,omitempty"`
+}
+
+type FollowService struct {
+	store store.FollowStore
+	cfg   FeedConfig
+	now   func() time.Time
+}
+
+func NewFollowService(s store.FollowStore, cfg FeedConfig) *FollowService {
+	return &FollowService{store: s, cfg: cfg, now: time.Now}
+}
+
+// ParseFollowType maps the plural path segment (players, teams, franchises) to a
+// models.Follow target type.
+func ParseFollowType(segment string) (string, error) {
+	switch segment {
+	case "players":
+		return models.FollowPlayer, nil
+	case "teams":
+		return models.FollowTeam, nil
+	case "franchises":
+		return models.FollowFranchise, nil
+	}
+	return "", ErrInvalidFollowType
+}
+
+// Follow adds a follow; following something twice is not an error. An unknown target
+// is gorm.ErrRecordNotFound.
+func (fs *FollowService) Follow(ctx context.Context, userID uint, targetType string, targetID uint) error {
+	exists, err := fs.store.TargetExists(ctx, targetType, targetID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+	return fs.store.Follow(ctx, &models.Follow{UserID: userID, TargetType: targetType, TargetID: targetID})
+}
+
+func (fs *FollowService) Unfollow(ctx context.Context, userID uint, targetType string, targetID uint) error {
+	return fs.store.Unfollow(ctx, userID, targetType, targetID)
+}
+
+func (fs *FollowService) ListFollows(ctx context.Context, userID uint) ([]store.FollowedEntity, error) {
+	return fs.store.ListFollows(ctx, userID)
+}
+
+// GetFeed returns one page of the user's feed: matches, transfers and thread activity
+// touching anything they follow, newest first with upcoming matches on top.
+func (fs *FollowService) GetFeed(ctx context.Context, userID uint, page, limit int) ([]FeedItem, int64, error) {
+	now := fs.now()
+	window := store.FeedWindow{
+		MatchesFrom:   now.Add(-fs.cfg.MatchesBack),
+		MatchesTo:     now.Add(fs.cfg.MatchesAhead),
+		TransfersFrom: now.Add(-fs.cfg.TransfersBack),
+		ThreadsFrom:   now.Add(-fs.cfg.ThreadsBack),
+	}
+	rows, total, err := fs.store.ListFeed(ctx, userID, window, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matchIDs, transferIDs, threadIDs []uint
+	for _, r := range rows {
+		switch r.Kind {
+		case FeedMatch:
+			matchIDs = append(matchIDs, r.RefID)
+		case FeedTransfer:
+			transferIDs = append(transferIDs, r.RefID)
+		case FeedThread:
+			threadIDs = append(threadIDs, r.RefID)
+		}
+	}
+	matches, err := fs.store.GetFeedMatches(ctx, matchIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	transfers, err := fs.store.GetFeedTransfers(ctx, transferIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	threads, err := fs.store.GetFeedThreads(ctx, threadIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	matchByID := make(map[uint]*models.Match, len(matches))
+	for i := range matches {
+		matchByID[matches[i].ID] = &matches[i]
+	}
+	transferByID := make(map[uint]*models.PlayerTransfer, len(transfers))
+	for i := range transfers {
+		transferByID[transfers[i].ID] = &transfers[i]
+	}
+	threadByID := make(map[uint]*models.MatchThread, len(threads))
+	for i := range threads {
+		threadByID[threads[i].ID] = &threads[i]
+	}
+
+	items := make([]FeedItem, 0, len(rows))
+	for _, r := range rows {
+		item := FeedItem{Type: r.Kind, At: r.At}
+		switch r.Kind {
+		case FeedMatch:
+			m, ok := matchByID[r.RefID]
+			if !ok {
+				continue
+			}
+			item.Match = m
+			item.Upcoming = m.WinnerID == nil && m.MatchDate.After(now)
+		case FeedTransfer:
+			t, ok := transferByID[r.RefID]
+			if !ok {
+				continue
+			}
+			item.Transfer = t
+		case FeedThread:
+			t, ok := threadByID[r.RefID]
+			if !ok {
+				continue
+			}
+			match := t.Match
+			item.Thread = &FeedThreadActivity{ThreadID: t.ID, MatchID: t.MatchID, NewPosts: r.Posts, Match: &match}
+		default:
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockFollowStore struct {
+	follows   []models.Follow
+	targets   map[string]bool
+	rows      []store.FeedRow
+	window    store.FeedWindow
+	offset    int
+	matches   []models.Match
+	transfers []models.PlayerTransfer
+	threads   []models.MatchThread
+}
+
+func (m *mockFollowStore) Follow(_ context.Context, f *models.Follow) error {
+	m.follows = append(m.follows, *f)
+	return nil
+}
+func (m *mockFollowStore) Unfollow(context.Context, uint, string, uint) error { return nil }
+func (m *mockFollowStore) ListFollows(context.Context, uint) ([]store.FollowedEntity, error) {
+	return nil, nil
+}
+func (m *mockFollowStore) TargetExists(_ context.Context, targetType string, _ uint) (bool, error) {
+	return m.targets[targetType], nil
+}
+func (m *mockFollowStore) ListFeed(_ context.Context, _ uint, w store.FeedWindow, _, offset int) ([]store.FeedRow, int64, error) {
+	m.window, m.offset = w, offset
+	return m.rows, int64(len(m.rows)), nil
+}
+func (m *mockFollowStore) GetFeedMatches(context.Context, []uint) ([]models.Match, error) {
+	return m.matches, nil
+}
+func (m *mockFollowStore) GetFeedTransfers(context.Context, []uint) ([]models.PlayerTransfer, error) {
+	return m.transfers, nil
+}
+func (m *mockFollowStore) GetFeedThreads(context.Context, []uint) ([]models.MatchThread, error) {
+	return m.threads, nil
+}
+
+func TestParseFollowType(t *testing.T) {
+	for segment, want := range map[string]string{
+		"players":    models.FollowPlayer,
+		"teams":      models.FollowTeam,
+		"franchises": models.FollowFranchise,
+	} {
+		got, err := ParseFollowType(segment)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseFollowType("team")
+	assert.ErrorIs(t, err, ErrInvalidFollowType)
+}
+
+func TestFollow_UnknownTarget(t *testing.T) {
+	ms := &mockFollowStore{targets: map[string]bool{models.FollowTeam: true}}
+	fs := NewFollowService(ms, DefaultFeedConfig())
+
+	err := fs.Follow(context.Background(), 1, models.FollowPlayer, 9)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Empty(t, ms.follows)
+
+	require.NoError(t, fs.Follow(context.Background(), 1, models.FollowTeam, 4))
+	assert.Equal(t, []models.Follow{{UserID: 1, TargetType: models.FollowTeam, TargetID: 4}}, ms.follows)
+}
+
+func TestGetFeed_HydratesInStoreOrder(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	ms := &mockFollowStore{
+		rows: []store.FeedRow{
+			{Kind: FeedMatch, RefID: 2, At: now.Add(48 * time.Hour)},
+			{Kind: FeedThread, RefID: 5, At: now.Add(-time.Hour), Posts: 3},
+			{Kind: FeedTransfer, RefID: 8, At: now.Add(-24 * time.Hour)},
+			{Kind: FeedMatch, RefID: 1, At: now.Add(-72 * time.Hour)},
+			{Kind: FeedMatch, RefID: 99, At: now.Add(-96 * time.Hour)}, // deleted since the page was built
+		},
+		matches: []models.Match{
+			{ID: 1, MatchDate: now.Add(-72 * time.Hour), WinnerID: uptr(3)},
+			{ID: 2, MatchDate: now.Add(48 * time.Hour)},
+		},
+		transfers: []models.PlayerTransfer{{ID: 8, PlayerID: 4}},
+		threads:   []models.MatchThread{{ID: 5, MatchID: 1, Match: models.Match{ID: 1}}},
+	}
+	fs := NewFollowService(ms, DefaultFeedConfig())
+	fs.now = func() time.Time { return now }
+
+	items, total, err := fs.GetFeed(context.Background(), 1, 2, 20)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, total)
+	assert.Equal(t, 20, ms.offset)
+	assert.Equal(t, now.Add(-30*24*time.Hour), ms.window.MatchesFrom)
+	assert.Equal(t, now.Add(14*24*time.Hour), ms.window.MatchesTo)
+
+	require.Len(t, items, 4)
+	assert.Equal(t, FeedMatch, items[0].Type)
+	assert.True(t, items[0].Upcoming)
+	assert.EqualValues(t, 2, items[0].Match.ID)
+
+	assert.Equal(t, FeedThread, items[1].Type)
+	assert.Equal(t, 3, items[1].Thread.NewPosts)
+	assert.EqualValues(t, 1, items[1].Thread.MatchID)
+
+	assert.Equal(t, FeedTransfer, items[2].Type)
+	assert.EqualValues(t, 8, items[2].Transfer.ID)
+
+	assert.False(t, items[3].Upcoming)
+	assert.EqualValues(t, 1, items[3].Match.ID)
+}
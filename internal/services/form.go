@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+// FormOptions sizes the rolling windows. AsOf anchors the day window (normally now).
+// Points caps how many of the most recent maps/series are returned as a time series.
+type FormOptions struct {
+	Maps   int
+	Series int
+	Days   int
+	Points int
+	AsOf   time.Time
+}
+
+func DefaultFormOptions(asOf time.Time) FormOptions {
+	return FormOptions{Maps: 10, Series: 5, Days: 30, Points: 100, AsOf: asOf}
+}
+
+// FormWindow aggregates the maps inside one window. Rating averages rated maps only.
+type FormWindow struct {
+	Window     string  `json:"window"`
+	Maps       int     `json:"maps"`
+	MapsWon    int     `json:"maps_won"`
+	MapWinRate float64 `json:"map_win_rate"`
+	Kills      int     `json:"kills"`
+	Deaths     int     `json:"deaths"`
+	KD         float64 `json:"kd"`
+	Rating     float64 `json:"rating"`
+
+	decided   int
+	ratingSum float64
+	ratedMaps int
+}
+
+func (w *FormWindow) add(r store.FormMapRow) {
+	w.Maps++
+	w.Kills += r.Kills
+	w.Deaths += r.Deaths
+	if won, decided := sideWon(r.TeamID, r.TeamScore, r.OpponentScore, r.WinnerID); decided {
+		w.decided++
+		if won {
+			w.MapsWon++
+		}
+	}
+	if r.Rating > 0 {
+		w.ratingSum += r.Rating
+		w.ratedMaps++
+	}
+}
+
+func (w *FormWindow) finish() {
+	w.KD = CalculateKD(w.Kills, w.Deaths)
+	if w.decided > 0 {
+		w.MapWinRate = float64(w.MapsWon) / float64(w.decided)
+	}
+	if w.ratedMaps > 0 {
+		w.Rating = w.ratingSum / float64(w.ratedMaps)
+	}
+}
+
+// FormPoint is one map in the time series; the Rolling* fields cover the last
+// FormOptions.Maps maps up to and including this one.
+type FormPoint struct {
+	MatchID        uint      `json:"match_id"`
+	MapNumber      int       `json:"map_number"`
+	PlayedAt       time.Time `json:"played_at"`
+	Mode           string    `json:"mode"`
+	Won            bool      `json:"won"`
+	Kills          int       `json:"kills"`
+	Deaths         int       `json:"deaths"`
+	KD             float64   `json:"kd"`
+	Rating         float64   `json:"rating"`
+	RollingKD      float64   `json:"rolling_kd"`
+	RollingRating  float64   `json:"rolling_rating"`
+	RollingWinRate float64   `json:"rolling_win_rate"`
+}
+
+// SeriesPoint is one decided series; Streak is the signed run after it (+3 = three wins in a row).
+type SeriesPoint struct {
+	MatchID       uint      `json:"match_id"`
+	PlayedAt      time.Time `json:"played_at"`
+	OpponentID    uint      `json:"opponent_id"`
+	OpponentName  string    `json:"opponent_name"`
+	TeamScore     int       `json:"team_score"`
+	OpponentScore int       `json:"opponent_score"`
+	Won           bool      `json:"won"`
+	Streak        int       `json:"streak"`
+}
+
+type SeriesStreaks struct {
+	Current          int       `json:"current"`
+	CurrentWinStreak int       `json:"current_win_streak"`
+	LongestWinStreak int       `json:"longest_win_streak"`
+	LongestFrom      time.Time `json:"longest_from"`
+	LongestTo        time.Time `json:"longest_to"`
+}
+
+type PlayerForm struct {
+	PlayerID int          `json:"player_id"`
+	AsOf     time.Time    `json:"as_of"`
+	Windows  []FormWindow `json:"windows"`
+	Maps     []FormPoint  `json:"maps"`
+}
+
+type TeamForm struct {
+	TeamID  int           `json:"team_id"`
+	AsOf    time.Time     `json:"as_of"`
+	Windows []FormWindow  `json:"windows"`
+	Streaks SeriesStreaks `json:"streaks"`
+	Maps    []FormPoint   `json:"maps"`
+	Series  []SeriesPoint `json:"series"`
+}
+
+// formWindows builds the last-N-maps, last-N-series, last-N-days and career windows
+// over maps sorted oldest first.
+func formWindows(rows []store.FormMapRow, opts FormOptions) []FormWindow {
+	lastMaps := FormWindow{Window: fmt.Sprintf("last_%d_maps", opts.Maps)}
+	lastSeries := FormWindow{Window: fmt.Sprintf("last_%d_series", opts.Series)}
+	lastDays := FormWindow{Window: fmt.Sprintf("last_%d_days", opts.Days)}
+	career := FormWindow{Window: "career"}
+
+	cutoff := opts.AsOf.AddDate(0, 0, -opts.Days)
+	seriesSeen := 0
+	var lastMatch uint
+	for i := len(rows) - 1; i >= 0; i-- {
+		r := rows[i]
+		career.add(r)
+		if len(rows)-i <= opts.Maps {
+			lastMaps.add(r)
+		}
+		if r.MatchID != lastMatch {
+			seriesSeen++
+			lastMatch = r.MatchID
+		}
+		if seriesSeen <= opts.Series {
+			lastSeries.add(r)
+		}
+		if !r.PlayedAt.Before(cutoff) && !r.PlayedAt.After(opts.AsOf) {
+			lastDays.add(r)
+		}
+	}
+
+	windows := []FormWindow{lastMaps, lastSeries, lastDays, career}
+	for i := range windows {
+		windows[i].finish()
+	}
+	return windows
+}
+
+// formPoints returns the time series for the last opts.Points maps, each carrying the
+// rolling window of the opts.Maps maps ending at it.
+func formPoints(rows []store.FormMapRow, opts FormOptions) []FormPoint {
+	start := max(0, len(rows)-opts.Points)
+	points := make([]FormPoint, 0, len(rows)-start)
+	for i := start; i < len(rows); i++ {
+		r := rows[i]
+		var rolling FormWindow
+		for j := max(0, i-opts.Maps+1); j <= i; j++ {
+			rolling.add(rows[j])
+		}
+		rolling.finish()
+		won, _ := sideWon(r.TeamID, r.TeamScore, r.OpponentScore, r.WinnerID)
+		points = append(points, FormPoint{
+			MatchID:        r.MatchID,
+			MapNumber:      r.MapNumber,
+			PlayedAt:       r.PlayedAt,
+			Mode:           normalizeMode(r.Mode),
+			Won:            won,
+			Kills:          r.Kills,
+			Deaths:         r.Deaths,
+			KD:             CalculateKD(r.Kills, r.Deaths),
+			Rating:         r.Rating,
+			RollingKD:      rolling.KD,
+			RollingRating:  rolling.Rating,
+			RollingWinRate: rolling.MapWinRate,
+		})
+	}
+	return points
+}
+
+// seriesStreaks walks decided series oldest first, tagging each with the signed
+// streak after it and tracking the longest run of wins.
+func seriesStreaks(teamID uint, rows []store.FormSeriesRow) ([]SeriesPoint, SeriesStreaks) {
+	var st SeriesStreaks
+	points := make([]SeriesPoint, len(rows))
+	var runStart time.Time
+	for i, r := range rows {
+		won := r.WinnerID == teamID
+		switch {
+		case won && st.Current > 0:
+			st.Current++
+		case won:
+			st.Current = 1
+			runStart = r.PlayedAt
+		case st.Current < 0:
+			st.Current--
+		default:
+			st.Current = -1
+		}
+		if st.Current > st.LongestWinStreak {
+			st.LongestWinStreak = st.Current
+			st.LongestFrom = runStart
+			st.LongestTo = r.PlayedAt
+		}
+		points[i] = SeriesPoint{
+			MatchID:       r.MatchID,
+			PlayedAt:      r.PlayedAt,
+			OpponentID:    r.OpponentID,
+			OpponentName:  r.OpponentName,
+			TeamScore:     r.TeamScore,
+			OpponentScore: r.OpponentScore,
+			Won:           won,
+			Streak:        st.Current,
+		}
+	}
+	st.CurrentWinStreak = max(0, st.Current)
+	return points, st
+}
+
+func (ps *PlayerService) GetForm(ctx context.Context, playerID int, opts FormOptions) (*PlayerForm, error) {
+	if _, err := ps.store.GetByID(ctx, playerID); err != nil {
+		return nil, err
+	}
+	rows, err := ps.store.ListFormMaps(ctx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	return &PlayerForm{
+		PlayerID: playerID,
+		AsOf:     opts.AsOf,
+		Windows:  formWindows(rows, opts),
+		Maps:     formPoints(rows, opts),
+	}, nil
+}
+
+func (ts *TeamService) GetForm(ctx context.Context, teamID int, opts FormOptions) (*TeamForm, error) {
+	if _, err := ts.teams.GetByID(ctx, teamID); err != nil {
+		return nil, err
+	}
+	maps, err := ts.teams.ListFormMaps(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	series, err := ts.teams.ListFormSeries(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	points, streaks := seriesStreaks(uint(teamID), series)
+	if len(points) > opts.Points {
+		points = points[len(points)-opts.Points:]
+	}
+	return &TeamForm{
+		TeamID:  teamID,
+		AsOf:    opts.AsOf,
+		Windows: formWindows(maps, opts),
+		Streaks: streaks,
+		Maps:    formPoints(maps, opts),
+		Series:  points,
+	}, nil
+}
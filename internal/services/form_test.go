@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+
+func formMap(matchID uint, mapNum, d, kills, deaths int, won bool) store.FormMapRow {
+	winner := uint(2)
+	if won {
+		winner = 1
+	}
+	return store.FormMapRow{MatchID: matchID, MapNumber: mapNum, PlayedAt: day(d), Mode: "Hardpoint",
+		TeamID: 1, WinnerID: &winner, Kills: kills, Deaths: deaths, Rating: float64(kills) / float64(deaths)}
+}
+
+func TestPlayerService_GetForm_Windows(t *testing.T) {
+	s := &mockPlayerStore{
+		players: comparePlayers(),
+		form: []store.FormMapRow{
+			formMap(1, 1, 1, 10, 20, false),
+			formMap(1, 2, 1, 10, 20, false),
+			formMap(2, 1, 20, 30, 20, true),
+			formMap(2, 2, 20, 20, 20, true),
+			formMap(3, 1, 25, 25, 25, false),
+		},
+	}
+	opts := FormOptions{Maps: 2, Series: 2, Days: 10, Points: 3, AsOf: day(28)}
+	form, err := NewPlayerService(s).GetForm(context.Background(), 1, opts)
+	require.NoError(t, err)
+
+	require.Len(t, form.Windows, 4)
+	lastMaps, lastSeries, lastDays, career := form.Windows[0], form.Windows[1], form.Windows[2], form.Windows[3]
+	assert.Equal(t, "last_2_maps", lastMaps.Window)
+	assert.Equal(t, 2, lastMaps.Maps)
+	assert.InDelta(t, 45.0/45.0, lastMaps.KD, 0.001)
+	assert.Equal(t, 3, lastSeries.Maps, "last 2 series = match 3 + both maps of match 2")
+	assert.Equal(t, 3, lastDays.Maps, "day 1 is outside the 10-day window")
+	assert.Equal(t, 5, career.Maps)
+	assert.Equal(t, 2, career.MapsWon)
+	assert.InDelta(t, 0.4, career.MapWinRate, 0.001)
+
+	require.Len(t, form.Maps, 3, "time series is capped at Points")
+	last := form.Maps[2]
+	assert.Equal(t, uint(3), last.MatchID)
+	assert.False(t, last.Won)
+	assert.InDelta(t, 0.5, last.RollingWinRate, 0.001)
+	assert.InDelta(t, 1.0, last.RollingKD, 0.001)
+}
+
+func TestTeamService_GetForm_Streaks(t *testing.T) {
+	series := func(id uint, d int, winner uint) store.FormSeriesRow {
+		return store.FormSeriesRow{MatchID: id, PlayedAt: day(d), OpponentID: 2, WinnerID: winner}
+	}
+	ts := &mockTeamStore{
+		teams: map[int]models.Team{1: {ID: 1}},
+		formSeries: []store.FormSeriesRow{
+			series(1, 1, 1), series(2, 2, 1), series(3, 3, 1),
+			series(4, 4, 2),
+			series(5, 5, 1), series(6, 6, 1),
+		},
+	}
+	form, err := NewTeamService(ts, nil).GetForm(context.Background(), 1, DefaultFormOptions(day(28)))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, form.Streaks.Current)
+	assert.Equal(t, 2, form.Streaks.CurrentWinStreak)
+	assert.Equal(t, 3, form.Streaks.LongestWinStreak)
+	assert.Equal(t, day(1), form.Streaks.LongestFrom)
+	assert.Equal(t, day(3), form.Streaks.LongestTo)
+	require.Len(t, form.Series, 6)
+	assert.Equal(t, -1, form.Series[3].Streak)
+}
@@ -0,0 +1,115 @@
+package services
+
+// glicko.go — Glicko-2 rating maths (Glickman, "Example of the Glicko-2 system").
+// Pure functions only; RatingService decides what a rating period is and where state lives.
+
+import "math"
+
+const (
+	glickoScale   = 173.7178
+	glickoBase    = 1500.0
+	glickoEpsilon = 0.000001
+)
+
+type glickoRating struct {
+	Rating     float64
+	RD         float64
+	Volatility float64
+}
+
+// glickoResult is one game inside a rating period. Score is 1 for a win, 0 for a loss.
+type glickoResult struct {
+	Opponent glickoRating
+	Score    float64
+}
+
+func glickoG(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func glickoE(mu, muj, phij float64) float64 {
+	return 1 / (1 + math.Exp(-glickoG(phij)*(mu-muj)))
+}
+
+// glickoExpected is the probability that a beats b under the Glicko-2 model.
+func glickoExpected(a, b glickoRating) float64 {
+	mu := (a.Rating - glickoBase) / glickoScale
+	muj := (b.Rating - glickoBase) / glickoScale
+	phi := math.Hypot(a.RD, b.RD) / glickoScale
+	return glickoE(mu, muj, phi)
+}
+
+// glicko2Update applies one rating period to p. A period with no results only
+// inflates the deviation, as the system specifies for inactive competitors.
+func glicko2Update(p glickoRating, results []glickoResult, tau float64) glickoRating {
+	mu := (p.Rating - glickoBase) / glickoScale
+	phi := p.RD / glickoScale
+	sigma := p.Volatility
+
+	if len(results) == 0 {
+		return glickoRating{
+			Rating:     p.Rating,
+			RD:         math.Sqrt(phi*phi+sigma*sigma) * glickoScale,
+			Volatility: sigma,
+		}
+	}
+
+	var vInv, deltaSum float64
+	for _, r := range results {
+		muj := (r.Opponent.Rating - glickoBase) / glickoScale
+		phij := r.Opponent.RD / glickoScale
+		g := glickoG(phij)
+		e := glickoE(mu, muj, phij)
+		vInv += g * g * e * (1 - e)
+		deltaSum += g * (r.Score - e)
+	}
+	v := 1 / vInv
+	delta := v * deltaSum
+
+	newSigma := glickoVolatility(phi, sigma, v, delta, tau)
+	phiStar := math.Sqrt(phi*phi + newSigma*newSigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*deltaSum
+
+	return glickoRating{
+		Rating:     newMu*glickoScale + glickoBase,
+		RD:         newPhi * glickoScale,
+		Volatility: newSigma,
+	}
+}
+
+// glickoVolatility solves for the new volatility with the Illinois algorithm (step 5 of the paper).
+func glickoVolatility(phi, sigma, v, delta, tau float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		num := ex * (delta*delta - phi*phi - v - ex)
+		den := 2 * (phi*phi + v + ex) * (phi*phi + v + ex)
+		return num/den - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > glickoEpsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Worked example from Glickman's "Example of the Glicko-2 system".
+func TestGlicko2Update_PaperExample(t *testing.T) {
+	player := glickoRating{Rating: 1500, RD: 200, Volatility: 0.06}
+	results := []glickoResult{
+		{Opponent: glickoRating{Rating: 1400, RD: 30}, Score: 1},
+		{Opponent: glickoRating{Rating: 1550, RD: 100}, Score: 0},
+		{Opponent: glickoRating{Rating: 1700, RD: 300}, Score: 0},
+	}
+
+	got := glicko2Update(player, results, 0.5)
+
+	assert.InDelta(t, 1464.06, got.Rating, 0.01)
+	assert.InDelta(t, 151.52, got.RD, 0.01)
+	assert.InDelta(t, 0.05999, got.Volatility, 0.00001)
+}
+
+func TestGlicko2Update_NoResultsOnlyInflatesRD(t *testing.T) {
+	player := glickoRating{Rating: 1620, RD: 80, Volatility: 0.06}
+	got := glicko2Update(player, nil, 0.5)
+
+	assert.Equal(t, 1620.0, got.Rating)
+	assert.Greater(t, got.RD, 80.0)
+	assert.Equal(t, 0.06, got.Volatility)
+}
+
+func TestGlickoExpected(t *testing.T) {
+	even := glickoRating{Rating: 1500, RD: 100}
+	assert.InDelta(t, 0.5, glickoExpected(even, even), 1e-9)
+
+	strong := glickoRating{Rating: 1800, RD: 60}
+	weak := glickoRating{Rating: 1400, RD: 60}
+	p := glickoExpected(strong, weak)
+	assert.Greater(t, p, 0.5)
+	assert.InDelta(t, 1.0, p+glickoExpected(weak, strong), 1e-9, "probabilities must be complementary")
+}
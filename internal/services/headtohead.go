@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+)
+
+var ErrInvalidHeadToHead = errors.New("head-to-head needs two distinct sides")
+var ErrFranchiseNotFound = errors.New("franchise not found")
+
+// HeadToHeadSide selects one side of a comparison: a single team ID, or every era
+// team under a franchise key. Exactly one field should be set.
+type HeadToHeadSide struct {
+	TeamID       int
+	FranchiseKey string
+}
+
+type HeadToHead struct {
+	SideA   H2HSide     `json:"side_a"`
+	SideB   H2HSide     `json:"side_b"`
+	Series  H2HRecord   `json:"series"`
+	Maps    H2HRecord   `json:"maps"`
+	ByMode  []H2HRecord `json:"by_mode"`
+	ByMap   []H2HRecord `json:"by_map"`
+	Matches []H2HSeries `json:"matches"`
+}
+
+type H2HSide struct {
+	Name         string `json:"name"`
+	FranchiseKey string `json:"franchise_key,omitempty"`
+	TeamIDs      []uint `json:"team_ids"`
+}
+
+// H2HRecord is a win/loss tally from side A's perspective. Mode and MapName are
+// only set on the by_mode / by_map breakdowns.
+type H2HRecord struct {
+	Mode    string `json:"mode,omitempty"`
+	MapName string `json:"map_name,omitempty"`
+	Played  int    `json:"played"`
+	AWins   int    `json:"a_wins"`
+	BWins   int    `json:"b_wins"`
+}
+
+type H2HSeries struct {
+	MatchID        uint      `json:"match_id"`
+	MatchDate      time.Time `json:"match_date"`
+	TournamentID   uint      `json:"tournament_id"`
+	TournamentName string    `json:"tournament_name"`
+	GameCode       string    `json:"game_code"`
+	TeamAID        uint      `json:"team_a_id"`
+	TeamAName      string    `json:"team_a_name"`
+	TeamBID        uint      `json:"team_b_id"`
+	TeamBName      string    `json:"team_b_name"`
+	ScoreA         int       `json:"score_a"`
+	ScoreB         int       `json:"score_b"`
+	WinnerID       *uint     `json:"winner_id"`
+}
+
+func (ms *MatchService) resolveSide(ctx context.Context, side HeadToHeadSide) (*H2HSide, error) {
+	if side.FranchiseKey != "" {
+		teams, err := ms.teams.ListByFranchiseKey(ctx, side.FranchiseKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(teams) == 0 {
+			return nil, ErrFranchiseNotFound
+		}
+		out := &H2HSide{Name: teams[len(teams)-1].Name, FranchiseKey: side.FranchiseKey}
+		for _, t := range teams {
+			out.TeamIDs = append(out.TeamIDs, t.ID)
+		}
+		return out, nil
+	}
+	if side.TeamID <= 0 {
+		return nil, ErrInvalidHeadToHead
+	}
+	team, err := ms.teams.GetByID(ctx, side.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	return &H2HSide{Name: team.Name, TeamIDs: []uint{team.ID}}, nil
+}
+
+// GetHeadToHead aggregates every series and played map between the two sides.
+func (ms *MatchService) GetHeadToHead(ctx context.Context, a, b HeadToHeadSide) (*HeadToHead, error) {
+	sideA, err := ms.resolveSide(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	sideB, err := ms.resolveSide(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	inA := make(map[uint]bool, len(sideA.TeamIDs))
+	for _, id := range sideA.TeamIDs {
+		inA[id] = true
+	}
+	for _, id := range sideB.TeamIDs {
+		if inA[id] {
+			return nil, ErrInvalidHeadToHead
+		}
+	}
+
+	matches, err := ms.matches.ListBetweenTeams(ctx, sideA.TeamIDs, sideB.TeamIDs)
+	if err != nil {
+		return nil, err
+	}
+	matchIDs := make([]uint, len(matches))
+	for i, m := range matches {
+		matchIDs[i] = m.ID
+	}
+	maps, err := ms.matches.GetMapsForMatches(ctx, matchIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HeadToHead{SideA: *sideA, SideB: *sideB, Matches: make([]H2HSeries, 0, len(matches))}
+	aIsTeam1 := make(map[uint]bool, len(matches))
+	matchByID := make(map[uint]models.Match, len(matches))
+
+	for _, m := range matches {
+		matchByID[m.ID] = m
+		aIsTeam1[m.ID] = inA[m.Team1ID]
+		s := H2HSeries{
+			MatchID:        m.ID,
+			MatchDate:      m.MatchDate,
+			TournamentID:   m.TournamentID,
+			TournamentName: m.Tournament.Name,
+			GameCode:       m.Tournament.Season.GameCode,
+			WinnerID:       m.WinnerID,
+		}
+		if aIsTeam1[m.ID] {
+			s.TeamAID, s.TeamAName, s.ScoreA = m.Team1ID, m.Team1.Name, m.Team1Score
+			s.TeamBID, s.TeamBName, s.ScoreB = m.Team2ID, m.Team2.Name, m.Team2Score
+		} else {
+			s.TeamAID, s.TeamAName, s.ScoreA = m.Team2ID, m.Team2.Name, m.Team2Score
+			s.TeamBID, s.TeamBName, s.ScoreB = m.Team1ID, m.Team1.Name, m.Team1Score
+		}
+		result.Matches = append(result.Matches, s)
+
+		aWon, decided := sideWon(s.TeamAID, s.ScoreA, s.ScoreB, m.WinnerID)
+		if !decided {
+			continue
+		}
+		result.Series.Played++
+		if aWon {
+			result.Series.AWins++
+		} else {
+			result.Series.BWins++
+		}
+	}
+
+	byMode := map[string]*H2HRecord{}
+	byMap := map[[2]string]*H2HRecord{}
+	for _, mm := range maps {
+		m := matchByID[mm.MatchID]
+		teamA, scoreA, scoreB := m.Team2ID, mm.Score2, mm.Score1
+		if aIsTeam1[mm.MatchID] {
+			teamA, scoreA, scoreB = m.Team1ID, mm.Score1, mm.Score2
+		}
+		aWon, decided := sideWon(teamA, scoreA, scoreB, mm.WinnerID)
+		if !decided {
+			continue
+		}
+
+		mode := normalizeMode(mm.Mode)
+		if byMode[mode] == nil {
+			byMode[mode] = &H2HRecord{Mode: mode}
+		}
+		key := [2]string{mm.MapName, mode}
+		if byMap[key] == nil {
+			byMap[key] = &H2HRecord{Mode: mode, MapName: mm.MapName}
+		}
+		for _, rec := range []*H2HRecord{&result.Maps, byMode[mode], byMap[key]} {
+			rec.Played++
+			if aWon {
+				rec.AWins++
+			} else {
+				rec.BWins++
+			}
+		}
+	}
+
+	result.ByMode = sortedRecords(byMode)
+	result.ByMap = sortedRecords(byMap)
+	return result, nil
+}
+
+// sideWon reports whether teamID won, preferring an explicit winner and falling back
+// to the score. decided is false for draws and unscored rows.
+func sideWon(teamID uint, score, oppScore int, winnerID *uint) (won, decided bool) {
+	if winnerID != nil {
+		return *winnerID == teamID, true
+	}
+	if score == oppScore {
+		return false, false
+	}
+	return score > oppScore, true
+}
+
+func sortedRecords[K comparable](m map[K]*H2HRecord) []H2HRecord {
+	out := make([]H2HRecord, 0, len(m))
+	for _, r := range m {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Played != out[j].Played {
+			return out[i].Played > out[j].Played
+		}
+		if out[i].Mode != out[j].Mode {
+			return out[i].Mode < out[j].Mode
+		}
+		return out[i].MapName < out[j].MapName
+	})
+	return out
+}
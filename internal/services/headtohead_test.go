@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockMatchStore struct {
+	match     *models.Match // returned by GetByID
+	matches   []models.Match
+	maps      []models.MatchMap
+	matchMaps []models.MatchMap // returned by GetMaps
+}
+
+func (m *mockMatchStore) GetByID(context.Context, int) (*models.Match, error) {
+	if m.match == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return m.match, nil
+}
+func (m *mockMatchStore) GetMaps(context.Context, int) ([]models.MatchMap, error) {
+	return m.matchMaps, nil
+}
+func (m *mockMatchStore) GetStatRows(context.Context, int) ([]store.MatchStatRow, error) {
+	return nil, nil
+}
+func (m *mockMatchStore) ListBetweenTeams(context.Context, []uint, []uint) ([]models.Match, error) {
+	return m.matches, nil
+}
+func (m *mockMatchStore) GetMapsForMatches(context.Context, []uint) ([]models.MatchMap, error) {
+	return m.maps, nil
+}
+
+type mockTeamStore struct {
+	teams      map[int]models.Team
+	franchises map[string][]models.Team
+	mapPool    []store.MapPoolRow
+	formMaps   []store.FormMapRow
+	formSeries []store.FormSeriesRow
+	lineups    []store.LineupMapRow
+}
+
+func (m *mockTeamStore) ListActiveCDL(context.Context) ([]models.Team, error) { return nil, nil }
+func (m *mockTeamStore) ListForSeason(context.Context, string, string) ([]models.Team, error) {
+	return nil, nil
+}
+func (m *mockTeamStore) ListAll(context.Context) ([]models.Team, error) { return nil, nil }
+func (m *mockTeamStore) GetByID(_ context.Context, id int) (*models.Team, error) {
+	t, ok := m.teams[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &t, nil
+}
+func (m *mockTeamStore) GetPlayers(context.Context, int, string) ([]models.Player, error) {
+	return nil, nil
+}
+func (m *mockTeamStore) GetLatestMatchRoster(context.Context, int, string) ([]models.Player, error) {
+	return nil, nil
+}
+func (m *mockTeamStore) GetStats(context.Context, int) ([]models.TeamTournamentStats, error) {
+	return nil, nil
+}
+func (m *mockTeamStore) ListByFranchiseKey(_ context.Context, key string) ([]models.Team, error) {
+	return m.franchises[key], nil
+}
+func (m *mockTeamStore) ListMapPoolRows(context.Context, int, uint) ([]store.MapPoolRow, error) {
+	return m.mapPool, nil
+}
+func (m *mockTeamStore) ListFormMaps(context.Context, int) ([]store.FormMapRow, error) {
+	return m.formMaps, nil
+}
+func (m *mockTeamStore) ListFormSeries(context.Context, int) ([]store.FormSeriesRow, error) {
+	return m.formSeries, nil
+}
+func (m *mockTeamStore) ListLineupMaps(context.Context, int, string) ([]store.LineupMapRow, error) {
+	return m.lineups, nil
+}
+
+func uptr(v uint) *uint { return &v }
+
+func TestMatchService_GetHeadToHead_FranchiseUnion(t *testing.T) {
+	ts := &mockTeamStore{franchises: map[string][]models.Team{
+		"optic": {{ID: 1, Name: "OpTic Chicago"}, {ID: 3, Name: "OpTic Texas"}},
+		"faze":  {{ID: 2, Name: "Atlanta FaZe"}},
+	}}
+	ms := &mockMatchStore{
+		matches: []models.Match{
+			{ID: 10, Team1ID: 3, Team2ID: 2, Team1Score: 3, Team2Score: 1, WinnerID: uptr(3)},
+			{ID: 11, Team1ID: 2, Team2ID: 1, Team1Score: 3, Team2Score: 2, WinnerID: uptr(2)},
+		},
+		maps: []models.MatchMap{
+			{MatchID: 10, MapNumber: 1, MapName: "Rewind", Mode: "Hardpoint", Score1: 250, Score2: 200, WinnerID: uptr(3)},
+			{MatchID: 11, MapNumber: 1, MapName: "Rewind", Mode: "Hardpoint", Score1: 250, Score2: 180},
+			{MatchID: 11, MapNumber: 2, MapName: "Protocol", Mode: "Search & Destroy", Score1: 4, Score2: 6},
+		},
+	}
+	svc := NewMatchService(ms, ts)
+
+	h2h, err := svc.GetHeadToHead(context.Background(),
+		HeadToHeadSide{FranchiseKey: "optic"}, HeadToHeadSide{FranchiseKey: "faze"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "OpTic Texas", h2h.SideA.Name, "franchise side is labelled with its latest era team")
+	assert.Equal(t, []uint{1, 3}, h2h.SideA.TeamIDs)
+	assert.Equal(t, H2HRecord{Played: 2, AWins: 1, BWins: 1}, h2h.Series)
+	assert.Equal(t, H2HRecord{Played: 3, AWins: 2, BWins: 1}, h2h.Maps)
+
+	require.Len(t, h2h.Matches, 2)
+	assert.Equal(t, uint(1), h2h.Matches[1].TeamAID, "series are re-oriented so side A is always team A")
+	assert.Equal(t, 2, h2h.Matches[1].ScoreA)
+
+	require.Len(t, h2h.ByMode, 2)
+	assert.Equal(t, H2HRecord{Mode: "hp", Played: 2, AWins: 1, BWins: 1}, h2h.ByMode[0])
+	assert.Equal(t, H2HRecord{Mode: "snd", Played: 1, AWins: 1}, h2h.ByMode[1])
+	assert.Equal(t, "Rewind", h2h.ByMap[0].MapName)
+}
+
+func TestMatchService_GetHeadToHead_Validation(t *testing.T) {
+	ts := &mockTeamStore{
+		teams:      map[int]models.Team{1: {ID: 1, Name: "OpTic Texas"}},
+		franchises: map[string][]models.Team{"optic": {{ID: 1}}},
+	}
+	svc := NewMatchService(&mockMatchStore{}, ts)
+	ctx := context.Background()
+
+	_, err := svc.GetHeadToHead(ctx, HeadToHeadSide{TeamID: 1}, HeadToHeadSide{FranchiseKey: "optic"})
+	assert.ErrorIs(t, err, ErrInvalidHeadToHead, "overlapping sides must be rejected")
+
+	_, err = svc.GetHeadToHead(ctx, HeadToHeadSide{TeamID: 1}, HeadToHeadSide{FranchiseKey: "nope"})
+	assert.ErrorIs(t, err, ErrFranchiseNotFound)
+
+	_, err = svc.GetHeadToHead(ctx, HeadToHeadSide{TeamID: 1}, HeadToHeadSide{TeamID: 99})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
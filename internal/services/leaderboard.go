@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard query")
+
+// maxLeaderboardMinMaps caps min_maps: a long career is around a thousand maps, so
+// anything past this leaves every board empty anyway.
+const maxLeaderboardMinMaps = 2000
+
+// leaderboardCacheSize caps the cached boards. Every filter combination is an entry
+// of its own, so without a cap anonymous requests could grow the cache forever.
+const leaderboardCacheSize = 500
+
+// LeaderboardParams are the raw /leaderboards filters. MinMaps < 0 means "use the
+// stat's default threshold".
+type LeaderboardParams struct {
+	Stat         string
+	Mode         string
+	SeasonID     string
+	TournamentID string
+	TeamID       string
+	MinMaps      int
+	Limit        int
+}
+
+type Leaderboard struct {
+	Stat    store.LeaderboardStat `json:"stat"`
+	Mode    string                `json:"mode,omitempty"`
+	MinMaps int                   `json:"min_maps"`
+	Total   int                   `json:"total"`
+	Players []LeaderboardEntry    `json:"players"`
+}
+
+// LeaderboardEntry is one ranked player. Ties share a rank (1, 2, 2, 4) and
+// Percentile is the share of the other qualifying players ranked strictly below,
+// so a sole leader is 100 and the last place is 0.
+type LeaderboardEntry struct {
+	Rank       int     `json:"rank"`
+	Percentile float64 `json:"percentile"`
+	store.LeaderboardRow
+}
+
+func (ss *StatsService) GetLeaderboard(ctx context.Context, p LeaderboardParams) (*Leaderboard, error) {
+	stat, ok := store.LookupLeaderboardStat(p.Stat)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown stat %q", ErrInvalidLeaderboard, p.Stat)
+	}
+	mode := ""
+	if p.Mode != "" {
+		if mode, ok = parseModeParam(p.Mode); !ok {
+			return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidLeaderboard, p.Mode)
+		}
+		if stat.Source != store.LeaderboardSourceMap {
+			return nil, fmt.Errorf("%w: %s is already mode-specific", ErrInvalidLeaderboard, stat.Key)
+		}
+	}
+	minMaps := p.MinMaps
+	if minMaps < 0 {
+		minMaps = stat.DefaultMinMaps
+	}
+	minMaps = min(minMaps, maxLeaderboardMinMaps)
+	for _, id := range []struct {
+		name string
+		v    *string
+	}{{"season_id", &p.SeasonID}, {"tournament_id", &p.TournamentID}, {"team_id", &p.TeamID}} {
+		if *id.v == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(*id.v, 10, 32)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("%w: invalid %s %q", ErrInvalidLeaderboard, id.name, *id.v)
+		}
+		*id.v = strconv.FormatUint(n, 10) // "007" and "7" share a cache entry
+	}
+
+	key := fmt.Sprintf("%s:%s:%s:%s:%s:%d", stat.Key, mode, p.SeasonID, p.TournamentID, p.TeamID, minMaps)
+	ss.mu.RLock()
+	entry, cached := ss.boards[key]
+	ss.mu.RUnlock()
+
+	board := entry.board
+	if !cached || !time.Now().Before(entry.exp) {
+		rows, err := ss.store.ListLeaderboardRows(ctx, store.LeaderboardQuery{
+			Stat:         stat,
+			Mode:         mode,
+			SeasonID:     p.SeasonID,
+			TournamentID: p.TournamentID,
+			TeamID:       p.TeamID,
+			MinMaps:      minMaps,
+		})
+		if err != nil {
+			return nil, err
+		}
+		board = &Leaderboard{
+			Stat:    stat,
+			Mode:    mode,
+			MinMaps: minMaps,
+			Total:   len(rows),
+			Players: rankLeaderboard(rows),
+		}
+		ss.cacheBoard(key, board)
+	}
+
+	if p.Limit <= 0 || p.Limit >= len(board.Players) {
+		return board, nil
+	}
+	out := *board
+	out.Players = board.Players[:p.Limit]
+	return &out, nil
+}
+
+// cacheBoard stores a board, first dropping expired boards when the cache is full
+// and then, if it still is, an arbitrary one.
+func (ss *StatsService) cacheBoard(key string, board *Leaderboard) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	now := time.Now()
+	if _, ok := ss.boards[key]; !ok && len(ss.boards) >= leaderboardCacheSize {
+		for k, e := range ss.boards {
+			if !now.Before(e.exp) {
+				delete(ss.boards, k)
+			}
+		}
+		for k := range ss.boards {
+			if len(ss.boards) < leaderboardCacheSize {
+				break
+			}
+			delete(ss.boards, k)
+		}
+	}
+	ss.boards[key] = leaderboardCacheEntry{board: board, exp: now.Add(statsCacheTTL)}
+}
+
+// rankLeaderboard assigns competition ranks and percentiles to rows already sorted by value.
+func rankLeaderboard(rows []store.LeaderboardRow) []LeaderboardEntry {
+	n := len(rows)
+	out := make([]LeaderboardEntry, n)
+	for start := 0; start < n; {
+		end := start + 1
+		for end < n && rows[end].Value == rows[start].Value {
+			end++
+		}
+		pct := 100.0
+		if n > 1 {
+			pct = float64(n-end) * 100 / float64(n-1)
+		}
+		for i := start; i < end; i++ {
+			out[i] = LeaderboardEntry{Rank: start + 1, Percentile: pct, LeaderboardRow: rows[i]}
+		}
+		start = end
+	}
+	return out
+}
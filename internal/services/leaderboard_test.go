@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockStatsStore struct {
+	rows  []store.LeaderboardRow
+	calls int
+	last  store.LeaderboardQuery
+}
+
+func (m *mockStatsStore) GetTopKDRows(context.Context, int) ([]store.KDRow, error) { return nil, nil }
+func (m *mockStatsStore) GetAllKDRows(context.Context, int, string) ([]store.KDRow, error) {
+	return nil, nil
+}
+func (m *mockStatsStore) ListLeaderboardRows(_ context.Context, q store.LeaderboardQuery) ([]store.LeaderboardRow, error) {
+	m.calls++
+	m.last = q
+	return m.rows, nil
+}
+
+func TestGetLeaderboard_CachesAndLimits(t *testing.T) {
+	ms := &mockStatsStore{rows: []store.LeaderboardRow{
+		{PlayerID: 1, Value: 3}, {PlayerID: 2, Value: 2}, {PlayerID: 3, Value: 1},
+	}}
+	ss := NewStatsService(ms)
+	ctx := context.Background()
+
+	board, err := ss.GetLeaderboard(ctx, LeaderboardParams{Stat: "kd", Mode: "Search & Destroy", MinMaps: -1, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "snd", ms.last.Mode)
+	assert.Equal(t, 10, ms.last.MinMaps, "negative MinMaps falls back to the stat default")
+	assert.Equal(t, 3, board.Total)
+	assert.Len(t, board.Players, 2)
+
+	board, err = ss.GetLeaderboard(ctx, LeaderboardParams{Stat: "kd", Mode: "snd", MinMaps: -1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, ms.calls, "same filters must be served from cache")
+	assert.Len(t, board.Players, 3, "a limited response must not truncate the cached board")
+}
+
+func TestGetLeaderboard_NormalisesFilters(t *testing.T) {
+	ms := &mockStatsStore{}
+	ss := NewStatsService(ms)
+	ctx := context.Background()
+
+	_, err := ss.GetLeaderboard(ctx, LeaderboardParams{Stat: "kd", SeasonID: "007", MinMaps: 1_000_000})
+	require.NoError(t, err)
+	assert.Equal(t, "7", ms.last.SeasonID)
+	assert.Equal(t, maxLeaderboardMinMaps, ms.last.MinMaps)
+	_, err = ss.GetLeaderboard(ctx, LeaderboardParams{Stat: "kd", SeasonID: "7", MinMaps: maxLeaderboardMinMaps + 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, ms.calls, "equivalent filters share a cache entry")
+
+	for _, p := range []LeaderboardParams{
+		{Stat: "kd", TeamID: "x"},
+		{Stat: "kd", TournamentID: "-1"},
+		{Stat: "kd", SeasonID: "0"},
+		{Stat: "kd", SeasonID: "99999999999"},
+	} {
+		_, err := ss.GetLeaderboard(ctx, p)
+		assert.ErrorIs(t, err, ErrInvalidLeaderboard, "%+v", p)
+	}
+}
+
+func TestGetLeaderboard_CacheIsCapped(t *testing.T) {
+	ss := NewStatsService(&mockStatsStore{})
+	ctx := context.Background()
+	for team := 1; team <= leaderboardCacheSize+50; team++ {
+		_, err := ss.GetLeaderboard(ctx, LeaderboardParams{Stat: "kd", TeamID: strconv.Itoa(team), MinMaps: -1})
+		require.NoError(t, err)
+	}
+	assert.Len(t, ss.boards, leaderboardCacheSize)
+}
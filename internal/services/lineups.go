@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LineupPlayer struct {
+	ID       uint   `json:"id"`
+	Gamertag string `json:"gamertag"`
+}
+
+// Lineup is one exact set of players that started maps together for a team, or,
+// when grouping by core, a smaller group of players that was part of the lineup.
+// A series with a mid-series substitution counts towards every lineup that played in it.
+type Lineup struct {
+	Players       []LineupPlayer `json:"players"`
+	Maps          int            `json:"maps"`
+	MapsWon       int            `json:"maps_won"`
+	MapWinRate    float64        `json:"map_win_rate"`
+	Series        int            `json:"series"`
+	SeriesWon     int            `json:"series_won"`
+	SeriesWinRate float64        `json:"series_win_rate"`
+	// WinRateDelta is MapWinRate minus the team's MapWinRate over every map in the
+	// response (the season's, or all of them), not just the lineup's date span.
+	WinRateDelta float64      `json:"win_rate_delta"`
+	ByMode       []ModeRecord `json:"by_mode"`
+	FirstPlayed  time.Time    `json:"first_played"`
+	LastPlayed   time.Time    `json:"last_played"`
+	// Lineups is how many exact lineups a core played in; omitted for exact lineups.
+	Lineups int `json:"lineups,omitempty"`
+
+	decided int
+	series  map[uint]bool
+	modes   map[string]*ModeRecord
+	exact   map[string]bool
+}
+
+type ModeRecord struct {
+	Mode   string `json:"mode"`
+	Played int    `json:"played"`
+	Won    int    `json:"won"`
+	Lost   int    `json:"lost"`
+}
+
+type TeamLineups struct {
+	TeamID     int      `json:"team_id"`
+	SeasonID   string   `json:"season_id,omitempty"`
+	Core       int      `json:"core,omitempty"`
+	Maps       int      `json:"maps"`
+	MapWinRate float64  `json:"map_win_rate"`
+	Lineups    []Lineup `json:"lineups"`
+}
+
+// MaxLineupCore is the largest core GetLineups groups by.
+const MaxLineupCore = 5
+
+// GetLineups groups a team's maps by the exact set of players with a stat line on
+// each, optionally within one season. With core > 0 it groups by every core-sized
+// subset of each map's lineup instead, so a four-man core is followed through its
+// substitutes; maps with fewer players than core are left out. Lineups with fewer
+// than minMaps maps are dropped.
+func (ts *TeamService) GetLineups(ctx context.Context, teamID int, seasonID string, minMaps, core int) (*TeamLineups, error) {
+	if _, err := ts.teams.GetByID(ctx, teamID); err != nil {
+		return nil, err
+	}
+	if seasonID != "" {
+		if _, err := strconv.Atoi(seasonID); err != nil {
+			return nil, ErrInvalidSeason
+		}
+	}
+	rows, err := ts.teams.ListLineupMaps(ctx, teamID, seasonID)
+	if err != nil {
+		return nil, err
+	}
+
+	team := uint(teamID)
+	byKey := map[string]*Lineup{}
+	var order []string
+	var teamDecided, teamWon int
+	for _, r := range rows {
+		var players []LineupPlayer
+		if err := json.Unmarshal([]byte(r.Players), &players); err != nil {
+			return nil, err
+		}
+		won, decided := sideWon(team, r.TeamScore, r.OpponentScore, r.WinnerID)
+		if decided {
+			teamDecided++
+			if won {
+				teamWon++
+			}
+		}
+		exact := lineupKey(players)
+		groups := [][]LineupPlayer{players}
+		if core > 0 {
+			groups = lineupCores(players, core)
+		}
+		for _, group := range groups {
+			key := lineupKey(group)
+			l := byKey[key]
+			if l == nil {
+				l = &Lineup{Players: group, FirstPlayed: r.PlayedAt, series: map[uint]bool{}, modes: map[string]*ModeRecord{}, exact: map[string]bool{}}
+				byKey[key] = l
+				order = append(order, key)
+			}
+			l.Maps++
+			l.LastPlayed = r.PlayedAt
+			if core > 0 && !l.exact[exact] {
+				l.exact[exact] = true
+				l.Lineups++
+			}
+
+			if !l.series[r.MatchID] {
+				l.series[r.MatchID] = true
+				l.Series++
+				if r.MatchWinnerID != nil && *r.MatchWinnerID == team {
+					l.SeriesWon++
+				}
+			}
+
+			if !decided {
+				continue
+			}
+			l.decided++
+			mode := normalizeMode(r.Mode)
+			if l.modes[mode] == nil {
+				l.modes[mode] = &ModeRecord{Mode: mode}
+			}
+			l.modes[mode].Played++
+			if won {
+				l.MapsWon++
+				l.modes[mode].Won++
+			} else {
+				l.modes[mode].Lost++
+			}
+		}
+	}
+
+	result := &TeamLineups{TeamID: teamID, SeasonID: seasonID, Core: core, Maps: len(rows), Lineups: make([]Lineup, 0, len(order))}
+	if teamDecided > 0 {
+		result.MapWinRate = float64(teamWon) / float64(teamDecided)
+	}
+	for _, key := range order {
+		l := byKey[key]
+		if l.Maps < minMaps {
+			continue
+		}
+		if l.decided > 0 {
+			l.MapWinRate = float64(l.MapsWon) / float64(l.decided)
+			l.WinRateDelta = l.MapWinRate - result.MapWinRate
+		}
+		if l.Series > 0 {
+			l.SeriesWinRate = float64(l.SeriesWon) / float64(l.Series)
+		}
+		l.ByMode = make([]ModeRecord, 0, len(l.modes))
+		for _, m := range l.modes {
+			l.ByMode = append(l.ByMode, *m)
+		}
+		sort.Slice(l.ByMode, func(i, j int) bool { return l.ByMode[i].Mode < l.ByMode[j].Mode })
+		result.Lineups = append(result.Lineups, *l)
+	}
+	sort.SliceStable(result.Lineups, func(i, j int) bool {
+		return result.Lineups[i].Maps > result.Lineups[j].Maps
+	})
+	return result, nil
+}
+
+func lineupKey(players []LineupPlayer) string {
+	ids := make([]string, len(players))
+	for i, p := range players {
+		ids[i] = strconv.FormatUint(uint64(p.ID), 10)
+	}
+	return strings.Join(ids, ",")
+}
+
+// lineupCores returns every size-n subset of players, keeping their order.
+func lineupCores(players []LineupPlayer, n int) [][]LineupPlayer {
+	var cores [][]LineupPlayer
+	var walk func(start int, picked []LineupPlayer)
+	walk = func(start int, picked []LineupPlayer) {
+		if len(picked) == n {
+			cores = append(cores, slices.Clone(picked))
+			return
+		}
+		for i := start; i <= len(players)-(n-len(picked)); i++ {
+			walk(i+1, append(picked, players[i]))
+		}
+	}
+	walk(0, make([]LineupPlayer, 0, n))
+	return cores
+}
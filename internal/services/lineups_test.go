@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamService_GetLineups(t *testing.T) {
+	core := `[{"id":1,"gamertag":"Shotzzy"},{"id":2,"gamertag":"Dashy"},{"id":3,"gamertag":"Pred"},{"id":4,"gamertag":"Kenny"}]`
+	sub := `[{"id":1,"gamertag":"Shotzzy"},{"id":2,"gamertag":"Dashy"},{"id":3,"gamertag":"Pred"},{"id":5,"gamertag":"Hydra"}]`
+	line := func(match uint, mapNum int, mode string, us, them int, players string) store.LineupMapRow {
+		return store.LineupMapRow{MatchID: match, MapNumber: mapNum, PlayedAt: day(int(match)), Mode: mode,
+			TeamScore: us, OpponentScore: them, MatchWinnerID: uptr(9), Players: players}
+	}
+	rows := []store.LineupMapRow{
+		line(1, 1, "Hardpoint", 250, 200, core),
+		line(1, 2, "Search & Destroy", 6, 3, core),
+		line(1, 3, "Control", 3, 2, core),
+		line(2, 1, "Hardpoint", 180, 250, core),
+		line(2, 2, "Search & Destroy", 6, 5, sub), // mid-series substitution
+		line(2, 3, "Control", 1, 3, sub),
+	}
+	rows[0].MatchWinnerID, rows[1].MatchWinnerID, rows[2].MatchWinnerID = uptr(7), uptr(7), uptr(7)
+
+	ts := &mockTeamStore{teams: map[int]models.Team{7: {ID: 7}}, lineups: rows}
+	res, err := NewTeamService(ts, nil).GetLineups(context.Background(), 7, "", 1, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, res.Maps)
+	assert.InDelta(t, 4.0/6.0, res.MapWinRate, 0.001)
+	require.Len(t, res.Lineups, 2)
+
+	main := res.Lineups[0]
+	assert.Equal(t, "Kenny", main.Players[3].Gamertag)
+	assert.Equal(t, 4, main.Maps)
+	assert.Equal(t, 3, main.MapsWon)
+	assert.Equal(t, 2, main.Series)
+	assert.Equal(t, 1, main.SeriesWon)
+	assert.InDelta(t, 0.75-4.0/6.0, main.WinRateDelta, 0.001)
+	assert.Equal(t, day(1), main.FirstPlayed)
+	assert.Equal(t, day(2), main.LastPlayed)
+	assert.Equal(t, ModeRecord{Mode: "hp", Played: 2, Won: 1, Lost: 1}, main.ByMode[1])
+
+	subbed := res.Lineups[1]
+	assert.Equal(t, uint(5), subbed.Players[3].ID)
+	assert.Equal(t, 1, subbed.Series, "a series is counted for every lineup that played in it")
+
+	res, err = NewTeamService(ts, nil).GetLineups(context.Background(), 7, "", 3, 0)
+	require.NoError(t, err)
+	assert.Len(t, res.Lineups, 1)
+}
+
+func TestTeamService_GetLineupsByCore(t *testing.T) {
+	main := `[{"id":1,"gamertag":"Shotzzy"},{"id":2,"gamertag":"Dashy"},{"id":3,"gamertag":"Pred"},{"id":4,"gamertag":"Kenny"}]`
+	sub := `[{"id":1,"gamertag":"Shotzzy"},{"id":2,"gamertag":"Dashy"},{"id":3,"gamertag":"Pred"},{"id":5,"gamertag":"Hydra"}]`
+	short := `[{"id":1,"gamertag":"Shotzzy"},{"id":2,"gamertag":"Dashy"}]`
+	rows := []store.LineupMapRow{
+		{MatchID: 1, MapNumber: 1, PlayedAt: day(1), Mode: "Hardpoint", TeamScore: 250, OpponentScore: 200, MatchWinnerID: uptr(7), Players: main},
+		{MatchID: 2, MapNumber: 1, PlayedAt: day(2), Mode: "Hardpoint", TeamScore: 180, OpponentScore: 250, MatchWinnerID: uptr(9), Players: sub},
+		{MatchID: 2, MapNumber: 2, PlayedAt: day(2), Mode: "Control", TeamScore: 3, OpponentScore: 1, MatchWinnerID: uptr(9), Players: short},
+	}
+	ts := &mockTeamStore{teams: map[int]models.Team{7: {ID: 7}}, lineups: rows}
+
+	res, err := NewTeamService(ts, nil).GetLineups(context.Background(), 7, "", 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Core)
+	assert.InDelta(t, 2.0/3.0, res.MapWinRate, 0.001, "the team's record still counts every map")
+	require.Len(t, res.Lineups, 1, "only the Shotzzy/Dashy/Pred core played two maps")
+	core := res.Lineups[0]
+	assert.Equal(t, []uint{1, 2, 3}, []uint{core.Players[0].ID, core.Players[1].ID, core.Players[2].ID})
+	assert.Equal(t, 2, core.Maps)
+	assert.Equal(t, 1, core.MapsWon)
+	assert.Equal(t, 2, core.Series)
+	assert.Equal(t, 2, core.Lineups, "the core played with both Kenny and Hydra")
+
+	assert.Len(t, lineupCores([]LineupPlayer{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}, 4), 5)
+	assert.Empty(t, lineupCores([]LineupPlayer{{ID: 1}, {ID: 2}}, 4))
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/pubsub"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+var ErrInvalidLiveUpdate = errors.New("invalid live update")
+
+// Live stream event types.
+const (
+	LiveEventSnapshot = "snapshot" // Detail is the whole MatchDetail
+	LiveEventUpdate   = "update"   // Match is the new header, Map the one map that changed
+)
+
+// LiveEvent is one message on a match's live stream. Clients load a snapshot, then
+// replace detail.match with Match and the entry of detail.maps with Map's map_number.
+type LiveEvent struct {
+	Type   string       `json:"type"`
+	Match  *MatchInfo   `json:"match,omitempty"`
+	Map    *MapInfo     `json:"map,omitempty"`
+	Detail *MatchDetail `json:"detail,omitempty"`
+}
+
+// liveTopic is the pub/sub topic a match's live events go out on.
+func liveTopic(matchID uint) string { return fmt.Sprintf("match.%d.live", matchID) }
+
+// LiveMapUpdate is a map score pushed by the feeder. Mode takes the raw name or a
+// short key. Final marks the map over; the higher score wins it.
+type LiveMapUpdate struct {
+	MapName     string `json:"map_name"`
+	Mode        string `json:"mode"`
+	Score1      int    `json:"score_1"`
+	Score2      int    `json:"score_2"`
+	DurationSec int    `json:"duration_sec"`
+	Final       bool   `json:"final"`
+}
+
+// LiveStatLine is one player's running line for a map. Later pushes replace earlier ones.
+type LiveStatLine struct {
+	PlayerID        uint `json:"player_id"`
+	TeamID          uint `json:"team_id"`
+	Kills           int  `json:"kills"`
+	Deaths          int  `json:"deaths"`
+	Damage          int  `json:"damage"`
+	Assists         int  `json:"assists"`
+	HillTime        int  `json:"hill_time"`
+	SndRounds       int  `json:"snd_rounds"`
+	PlantCount      int  `json:"plant_count"`
+	DefuseCount     int  `json:"defuse_count"`
+	FirstBloodCount int  `json:"first_blood_count"`
+	FirstDeathCount int  `json:"first_death_count"`
+	NonTradedKills  int  `json:"non_traded_kills"`
+	HighestStreak   int  `json:"highest_streak"`
+}
+
+// liveModeNames are the match_maps.mode values ingested maps are stored under.
+var liveModeNames = map[string]string{"hp": "Hardpoint", "snd": "Search & Destroy", "control": "Control"}
+
+// bestOf reads a series length from matches.format ("BO5", "bo3", ...), defaulting to 5.
+func bestOf(format string) int { return store.BestOf(format) }
+
+type LiveService struct {
+	store   store.LiveStore
+	matches *MatchService
+	hub     pubsub.Hub
+}
+
+func NewLiveService(s store.LiveStore, matches *MatchService, hub pubsub.Hub) *LiveService {
+	return &LiveService{store: s, matches: matches, hub: hub}
+}
+
+// Subscribe opens a stream of JSON-encoded LiveEvents on a match and returns it with
+// the snapshot to send first. Subscribing before loading the snapshot means no update
+// between the two is lost; at worst one is applied twice, which is harmless as updates
+// are whole-map replacements.
+func (ls *LiveService) Subscribe(ctx context.Context, matchID int) (*MatchDetail, <-chan []byte, func(), error) {
+	events, cancel := ls.hub.Subscribe(liveTopic(uint(matchID)))
+	detail, err := ls.matches.GetMatchDetail(ctx, matchID)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+	return detail, events, cancel, nil
+}
+
+func (ls *LiveService) UpdateMap(ctx context.Context, matchID, mapNumber int, u LiveMapUpdate) (*LiveEvent, error) {
+	match, err := ls.store.GetMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	n := bestOf(match.Format)
+	if mapNumber < 1 || mapNumber > n {
+		return nil, fmt.Errorf("%w: map_number must be between 1 and %d", ErrInvalidLiveUpdate, n)
+	}
+	if u.Score1 < 0 || u.Score2 < 0 || u.DurationSec < 0 {
+		return nil, fmt.Errorf("%w: scores and duration must not be negative", ErrInvalidLiveUpdate)
+	}
+	mode, ok := parseModeParam(u.Mode)
+	if !ok {
+		return nil, fmt.Errorf("%w: mode must be hp, snd or control", ErrInvalidLiveUpdate)
+	}
+
+	mm := &models.MatchMap{
+		MatchID:     match.ID,
+		MapNumber:   mapNumber,
+		MapName:     strings.TrimSpace(u.MapName),
+		Mode:        liveModeNames[mode],
+		Score1:      u.Score1,
+		Score2:      u.Score2,
+		DurationSec: u.DurationSec,
+		Played:      u.Final,
+		Source:      store.LiveSource,
+	}
+	if u.Final {
+		switch {
+		case u.Score1 > u.Score2:
+			mm.WinnerID = &match.Team1ID
+		case u.Score2 > u.Score1:
+			mm.WinnerID = &match.Team2ID
+		default:
+			return nil, fmt.Errorf("%w: a final map cannot be tied", ErrInvalidLiveUpdate)
+		}
+	}
+	if _, err := ls.store.SaveMap(ctx, mm, n); err != nil {
+		return nil, err
+	}
+	return ls.publish(ctx, matchID, mapNumber)
+}
+
+func (ls *LiveService) UpdateMapStats(ctx context.Context, matchID, mapNumber int, lines []LiveStatLine) (*LiveEvent, error) {
+	match, err := ls.store.GetMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	n := bestOf(match.Format)
+	if mapNumber < 1 || mapNumber > n {
+		return nil, fmt.Errorf("%w: map_number must be between 1 and %d", ErrInvalidLiveUpdate, n)
+	}
+
+	seen := map[uint]bool{}
+	stats := make([]models.PlayerMapStats, 0, len(lines))
+	for _, l := range lines {
+		if l.PlayerID == 0 || seen[l.PlayerID] {
+			return nil, fmt.Errorf("%w: every line needs a distinct player_id", ErrInvalidLiveUpdate)
+		}
+		seen[l.PlayerID] = true
+		if l.TeamID != match.Team1ID && l.TeamID != match.Team2ID {
+			return nil, fmt.Errorf("%w: team_id %d is not playing this match", ErrInvalidLiveUpdate, l.TeamID)
+		}
+		if l.Kills < 0 || l.Deaths < 0 || l.Damage < 0 || l.Assists < 0 {
+			return nil, fmt.Errorf("%w: stat values must not be negative", ErrInvalidLiveUpdate)
+		}
+		stats = append(stats, models.PlayerMapStats{
+			MatchID:         match.ID,
+			MapNumber:       mapNumber,
+			PlayerID:        l.PlayerID,
+			TeamID:          l.TeamID,
+			Kills:           l.Kills,
+			Deaths:          l.Deaths,
+			KDRatio:         CalculateKD(l.Kills, l.Deaths),
+			Damage:          l.Damage,
+			Assists:         l.Assists,
+			HillTime:        l.HillTime,
+			SndRounds:       l.SndRounds,
+			PlantCount:      l.PlantCount,
+			DefuseCount:     l.DefuseCount,
+			FirstBloodCount: l.FirstBloodCount,
+			FirstDeathCount: l.FirstDeathCount,
+			NonTradedKills:  l.NonTradedKills,
+			HighestStreak:   l.HighestStreak,
+			Source:          store.LiveSource,
+		})
+	}
+	if err := ls.store.SaveMapStats(ctx, match.ID, mapNumber, stats); err != nil {
+		return nil, err
+	}
+	return ls.publish(ctx, matchID, mapNumber)
+}
+
+// publish rebuilds the match the same way GetMatch does and broadcasts the header and
+// the changed map, so stream clients stay byte-for-byte in line with the REST payload.
+// Broadcasting is best-effort: the write is already saved, and a client that missed
+// an event resyncs from the snapshot when it reconnects.
+// RollupTournaments rebuilds the player and team totals of the tournaments live
+// pushes have queued. The notify worker runs it on every pass.
+func (ls *LiveService) RollupTournaments(ctx context.Context) (int, error) {
+	return ls.store.RollupTournaments(ctx)
+}
+
+func (ls *LiveService) publish(ctx context.Context, matchID, mapNumber int) (*LiveEvent, error) {
+	detail, err := ls.matches.GetMatchDetail(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	ev := LiveEvent{Type: LiveEventUpdate, Match: &detail.Match}
+	for i := range detail.Maps {
+		if detail.Maps[i].MapNumber == mapNumber {
+			ev.Map = &detail.Maps[i]
+			break
+		}
+	}
+	if payload, err := json.Marshal(ev); err == nil {
+		_ = ls.hub.Publish(ctx, liveTopic(uint(matchID)), payload)
+	}
+	return &ev, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// mockLiveStore writes straight into the mockMatchStore GetMatchDetail reads from.
+type mockLiveStore struct {
+	ms    *mockMatchStore
+	stats [][]models.PlayerMapStats
+}
+
+func (m *mockLiveStore) GetMatch(context.Context, int) (*models.Match, error) {
+	if m.ms.match == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return m.ms.match, nil
+}
+func (m *mockLiveStore) SaveMap(_ context.Context, mm *models.MatchMap, _ int) (*models.Match, error) {
+	for i := range m.ms.matchMaps {
+		if m.ms.matchMaps[i].MapNumber == mm.MapNumber {
+			m.ms.matchMaps[i] = *mm
+			return m.ms.match, nil
+		}
+	}
+	m.ms.matchMaps = append(m.ms.matchMaps, *mm)
+	return m.ms.match, nil
+}
+func (m *mockLiveStore) SaveMapStats(_ context.Context, _ uint, _ int, stats []models.PlayerMapStats) error {
+	m.stats = append(m.stats, stats)
+	return nil
+}
+func (m *mockLiveStore) RollupTournaments(context.Context) (int, error) { return 0, nil }
+
+func liveFixture() (*LiveService, *mockLiveStore, *pubsub.MemoryHub) {
+	ms := &mockMatchStore{match: &models.Match{ID: 7, Team1ID: 1, Team2ID: 2, Format: "BO5",
+		Team1: models.Team{Name: "OpTic Texas"}, Team2: models.Team{Name: "Atlanta FaZe"}}}
+	ls := &mockLiveStore{ms: ms}
+	hub := pubsub.NewMemoryHub(pubsub.DefaultBuffer)
+	return NewLiveService(ls, NewMatchService(ms, &mockTeamStore{}), hub), ls, hub
+}
+
+func nextEvent(t *testing.T, ch <-chan []byte) LiveEvent {
+	t.Helper()
+	select {
+	case payload := <-ch:
+		var ev LiveEvent
+		require.NoError(t, json.Unmarshal(payload, &ev))
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no live event published")
+		return LiveEvent{}
+	}
+}
+
+func TestLiveService_UpdateMapPublishes(t *testing.T) {
+	svc, _, _ := liveFixture()
+	detail, events, cancel, err := svc.Subscribe(context.Background(), 7)
+	require.NoError(t, err)
+	defer cancel()
+	assert.Empty(t, detail.Maps)
+
+	_, err = svc.UpdateMap(context.Background(), 7, 1, LiveMapUpdate{MapName: "Skyline", Mode: "hp", Score1: 120, Score2: 95})
+	require.NoError(t, err)
+	ev := nextEvent(t, events)
+	assert.Equal(t, LiveEventUpdate, ev.Type)
+	require.NotNil(t, ev.Map)
+	assert.Equal(t, "Hardpoint", ev.Map.Mode)
+	assert.Equal(t, 120, ev.Map.Score1)
+	assert.False(t, ev.Map.Played)
+	assert.Nil(t, ev.Map.WinnerID)
+	assert.Equal(t, "OpTic Texas", ev.Match.Team1Name)
+
+	_, err = svc.UpdateMap(context.Background(), 7, 1, LiveMapUpdate{MapName: "Skyline", Mode: "Hardpoint", Score1: 250, Score2: 201, Final: true})
+	require.NoError(t, err)
+	ev = nextEvent(t, events)
+	assert.True(t, ev.Map.Played)
+	require.NotNil(t, ev.Map.WinnerID)
+	assert.Equal(t, uint(1), *ev.Map.WinnerID)
+}
+
+func TestLiveService_UpdateMapValidation(t *testing.T) {
+	svc, _, _ := liveFixture()
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		number int
+		u      LiveMapUpdate
+	}{
+		"map number past best-of": {6, LiveMapUpdate{Mode: "hp"}},
+		"unknown mode":            {1, LiveMapUpdate{Mode: "gunfight"}},
+		"negative score":          {1, LiveMapUpdate{Mode: "snd", Score1: -1}},
+		"tied final":              {1, LiveMapUpdate{Mode: "snd", Score1: 5, Score2: 5, Final: true}},
+	} {
+		_, err := svc.UpdateMap(ctx, 7, tc.number, tc.u)
+		assert.ErrorIs(t, err, ErrInvalidLiveUpdate, name)
+	}
+
+	missing, ls, _ := liveFixture()
+	ls.ms.match = nil
+	_, err := missing.UpdateMap(ctx, 99, 1, LiveMapUpdate{Mode: "hp"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestLiveService_UpdateMapStats(t *testing.T) {
+	svc, ls, _ := liveFixture()
+	ctx := context.Background()
+
+	_, err := svc.UpdateMapStats(ctx, 7, 2, []LiveStatLine{{PlayerID: 10, TeamID: 3}})
+	assert.ErrorIs(t, err, ErrInvalidLiveUpdate, "team not in the match")
+	_, err = svc.UpdateMapStats(ctx, 7, 2, []LiveStatLine{{PlayerID: 10, TeamID: 1}, {PlayerID: 10, TeamID: 1}})
+	assert.ErrorIs(t, err, ErrInvalidLiveUpdate, "duplicate player")
+
+	_, err = svc.UpdateMapStats(ctx, 7, 2, []LiveStatLine{{PlayerID: 10, TeamID: 1, Kills: 12, Deaths: 8}})
+	require.NoError(t, err)
+	require.Len(t, ls.stats, 1)
+	line := ls.stats[0][0]
+	assert.Equal(t, uint(7), line.MatchID)
+	assert.Equal(t, 2, line.MapNumber)
+	assert.InDelta(t, 1.5, line.KDRatio, 1e-9)
+	assert.Equal(t, "live", line.Source)
+}
+
+func TestBestOf(t *testing.T) {
+	assert.Equal(t, 5, bestOf("BO5"))
+	assert.Equal(t, 3, bestOf("bo3"))
+	assert.Equal(t, 5, bestOf(""))
+	assert.Equal(t, 5, bestOf("group"))
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+// TeamMapPool is the /teams/:id/map-pool response: one team's record on every
+// map+mode it played in a season, plus per-mode totals.
+type TeamMapPool struct {
+	TeamID     int            `json:"team_id"`
+	SeasonID   uint           `json:"season_id"`
+	SeasonName string         `json:"season_name"`
+	Maps       []MapPoolEntry `json:"maps"`
+	ByMode     []MapPoolEntry `json:"by_mode"`
+}
+
+// MapPoolEntry is a team's record in one bucket. MapName is empty on by_mode rows.
+//
+// The opponent-adjusted fields only use maps whose series has been rated:
+// ExpectedWins sums the Glicko-2 win probability against each opponent's
+// pre-series rating, and AdjustedWinRate re-centres the surplus over that
+// expectation on 50% — a team that beats exactly the opponents it should is 0.5
+// regardless of schedule strength.
+type MapPoolEntry struct {
+	MapName        string  `json:"map_name,omitempty"`
+	Mode           string  `json:"mode"`
+	Played         int     `json:"played"`
+	Won            int     `json:"won"`
+	Lost           int     `json:"lost"`
+	WinRate        float64 `json:"win_rate"`
+	AvgScoreDiff   float64 `json:"avg_score_diff"`
+	AvgDurationSec float64 `json:"avg_duration_sec"`
+
+	RatedMaps         int     `json:"rated_maps"`
+	AvgOpponentRating float64 `json:"avg_opponent_rating"`
+	ExpectedWins      float64 `json:"expected_wins"`
+	WinsAboveExpected float64 `json:"wins_above_expected"`
+	AdjustedWinRate   float64 `json:"adjusted_win_rate"`
+
+	scoreDiff   int
+	timedMaps   int
+	durationSec int
+	ratedWins   int
+	oppRating   float64
+}
+
+func (e *MapPoolEntry) add(r store.MapPoolRow, won bool) {
+	e.Played++
+	if won {
+		e.Won++
+	} else {
+		e.Lost++
+	}
+	e.scoreDiff += r.TeamScore - r.OpponentScore
+	if r.DurationSec > 0 {
+		e.timedMaps++
+		e.durationSec += r.DurationSec
+	}
+	if r.TeamRating == nil || r.OpponentRating == nil {
+		return
+	}
+	team := glickoRating{Rating: *r.TeamRating, RD: derefOr(r.TeamRD, 0)}
+	opp := glickoRating{Rating: *r.OpponentRating, RD: derefOr(r.OpponentRD, 0)}
+	e.RatedMaps++
+	e.oppRating += opp.Rating
+	e.ExpectedWins += glickoExpected(team, opp)
+	if won {
+		e.ratedWins++
+	}
+}
+
+func (e *MapPoolEntry) finish() {
+	if e.Played > 0 {
+		e.WinRate = float64(e.Won) / float64(e.Played)
+		e.AvgScoreDiff = float64(e.scoreDiff) / float64(e.Played)
+	}
+	if e.timedMaps > 0 {
+		e.AvgDurationSec = float64(e.durationSec) / float64(e.timedMaps)
+	}
+	if e.RatedMaps > 0 {
+		e.AvgOpponentRating = e.oppRating / float64(e.RatedMaps)
+		e.WinsAboveExpected = float64(e.ratedWins) - e.ExpectedWins
+		e.AdjustedWinRate = math.Max(0, math.Min(1, 0.5+e.WinsAboveExpected/float64(e.RatedMaps)))
+	}
+}
+
+func derefOr(v *float64, fallback float64) float64 {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
+
+// GetMapPool aggregates a team's played maps for a season; an empty seasonID means the active season.
+func (ts *TeamService) GetMapPool(ctx context.Context, teamID int, seasonID string) (*TeamMapPool, error) {
+	if _, err := ts.teams.GetByID(ctx, teamID); err != nil {
+		return nil, err
+	}
+
+	season, err := resolveSeason(ctx, ts.seasons, seasonID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := ts.teams.ListMapPoolRows(ctx, teamID, season.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	type mapKey struct{ name, mode string }
+	byMap := map[mapKey]*MapPoolEntry{}
+	byMode := map[string]*MapPoolEntry{}
+	for _, r := range rows {
+		mode := normalizeMode(r.Mode)
+		won, decided := sideWon(uint(teamID), r.TeamScore, r.OpponentScore, r.WinnerID)
+		if !decided {
+			continue
+		}
+		k := mapKey{r.MapName, mode}
+		if byMap[k] == nil {
+			byMap[k] = &MapPoolEntry{MapName: r.MapName, Mode: mode}
+		}
+		byMap[k].add(r, won)
+		if byMode[mode] == nil {
+			byMode[mode] = &MapPoolEntry{Mode: mode}
+		}
+		byMode[mode].add(r, won)
+	}
+
+	result := &TeamMapPool{
+		TeamID:     teamID,
+		SeasonID:   season.ID,
+		SeasonName: season.Name,
+		Maps:       make([]MapPoolEntry, 0, len(byMap)),
+		ByMode:     make([]MapPoolEntry, 0, len(byMode)),
+	}
+	for _, e := range byMap {
+		e.finish()
+		result.Maps = append(result.Maps, *e)
+	}
+	for _, e := range byMode {
+		e.finish()
+		result.ByMode = append(result.ByMode, *e)
+	}
+	sort.Slice(result.Maps, func(i, j int) bool {
+		a, b := result.Maps[i], result.Maps[j]
+		if a.Mode != b.Mode {
+			return a.Mode < b.Mode
+		}
+		if a.Played != b.Played {
+			return a.Played > b.Played
+		}
+		return a.MapName < b.MapName
+	})
+	sort.Slice(result.ByMode, func(i, j int) bool { return result.ByMode[i].Mode < result.ByMode[j].Mode })
+	return result, nil
+}
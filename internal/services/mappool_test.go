@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockSeasonStore struct {
+	seasons map[int]models.Season
+	active  int
+	err     error
+}
+
+func (m *mockSeasonStore) List(context.Context) ([]models.Season, error) { return nil, nil }
+func (m *mockSeasonStore) GetByID(_ context.Context, id int) (*models.Season, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	s, ok := m.seasons[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &s, nil
+}
+func (m *mockSeasonStore) GetActive(ctx context.Context) (*models.Season, error) {
+	return m.GetByID(ctx, m.active)
+}
+
+func fptr(v float64) *float64 { return &v }
+
+func TestTeamService_GetMapPool(t *testing.T) {
+	ts := &mockTeamStore{
+		teams: map[int]models.Team{1: {ID: 1, Name: "OpTic Texas"}},
+		mapPool: []store.MapPoolRow{
+			{MatchID: 10, MapNumber: 1, MapName: "Rewind", Mode: "Hardpoint", TeamScore: 250, OpponentScore: 200, WinnerID: uptr(1), DurationSec: 600,
+				TeamRating: fptr(1500), TeamRD: fptr(0), OpponentRating: fptr(1500), OpponentRD: fptr(0)},
+			{MatchID: 11, MapNumber: 1, MapName: "Rewind", Mode: "Hardpoint", TeamScore: 190, OpponentScore: 250, WinnerID: uptr(2)},
+			{MatchID: 11, MapNumber: 2, MapName: "Protocol", Mode: "Search & Destroy", TeamScore: 6, OpponentScore: 2},
+			// Unplayed tie with no winner is ignored.
+			{MatchID: 12, MapNumber: 3, MapName: "Vault", Mode: "Control", TeamScore: 0, OpponentScore: 0},
+		},
+	}
+	ss := &mockSeasonStore{seasons: map[int]models.Season{4: {ID: 4, Name: "CDL 2025"}}, active: 4}
+	svc := NewTeamService(ts, ss)
+
+	pool, err := svc.GetMapPool(context.Background(), 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint(4), pool.SeasonID)
+
+	require.Len(t, pool.Maps, 2)
+	rewind := pool.Maps[0]
+	assert.Equal(t, "Rewind", rewind.MapName)
+	assert.Equal(t, "hp", rewind.Mode)
+	assert.Equal(t, 2, rewind.Played)
+	assert.Equal(t, 1, rewind.Won)
+	assert.InDelta(t, 0.5, rewind.WinRate, 0.001)
+	assert.InDelta(t, -5.0, rewind.AvgScoreDiff, 0.001)
+	assert.InDelta(t, 600.0, rewind.AvgDurationSec, 0.001, "maps without a duration are excluded from the average")
+
+	// One rated map, an even matchup that was won: 1 win vs 0.5 expected.
+	assert.Equal(t, 1, rewind.RatedMaps)
+	assert.InDelta(t, 0.5, rewind.ExpectedWins, 0.001)
+	assert.InDelta(t, 0.5, rewind.WinsAboveExpected, 0.001)
+	assert.InDelta(t, 1.0, rewind.AdjustedWinRate, 0.001)
+
+	require.Len(t, pool.ByMode, 2)
+	assert.Equal(t, "hp", pool.ByMode[0].Mode)
+	assert.Equal(t, "snd", pool.ByMode[1].Mode)
+	assert.Equal(t, 0, pool.ByMode[1].RatedMaps)
+}
+
+func TestTeamService_GetMapPool_InvalidSeason(t *testing.T) {
+	ts := &mockTeamStore{teams: map[int]models.Team{1: {ID: 1}}}
+	svc := NewTeamService(ts, &mockSeasonStore{})
+
+	_, err := svc.GetMapPool(context.Background(), 1, "abc")
+	assert.ErrorIs(t, err, ErrInvalidSeason)
+	_, err = svc.GetMapPool(context.Background(), 1, "99")
+	assert.ErrorIs(t, err, ErrInvalidSeason)
+	_, err = svc.GetMapPool(context.Background(), 2, "")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -58,6 +58,7 @@ type PlayerStat struct {
 	Damage          int     `json:"damage"`
 	Assists         int     `json:"assists"`
 	BPRating        float64 `json:"bp_rating"`
+	Rating          float64 `json:"rating"`
 	HillTime        int     `json:"hill_time"`
 	SndRounds       int     `json:"snd_rounds"`
 	PlantCount      int     `json:"plant_count"`
@@ -72,10 +73,11 @@ type PlayerStat struct {
 // TournamentDetail is the enriched tournament response including derived team count and format.
 type MatchService struct {
 	matches store.MatchStore
+	teams   store.TeamStore
 }
 
-func NewMatchService(matches store.MatchStore) *MatchService {
-	return &MatchService{matches: matches}
+func NewMatchService(matches store.MatchStore, teams store.TeamStore) *MatchService {
+	return &MatchService{matches: matches, teams: teams}
 }
 
 func (ms *MatchService) GetMatchDetail(ctx context.Context, id int) (*MatchDetail, error) {
@@ -123,6 +125,7 @@ func (ms *MatchService) GetMatchDetail(ctx context.Context, id int) (*MatchDetai
 				Damage:          s.Damage,
 				Assists:         s.Assists,
 				BPRating:        s.BPRating,
+				Rating:          s.Rating,
 				HillTime:        s.HillTime,
 				SndRounds:       s.SndRounds,
 				PlantCount:      s.PlantCount,
@@ -0,0 +1,312 @@
+package services
+
+// moderation.go — roles, sanctions, reports and the audit log for match threads.
+// Everything here is a ThreadService method so the same checks guard every caller,
+// and every moderator action is written to moderation_log with the change it records.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+)
+
+var ErrForbidden = errors.New("moderator role required")
+var ErrAdminRequired = errors.New("admin role required")
+var ErrOutranked = errors.New("cannot moderate a user with an equal or higher role")
+var ErrThreadLocked = errors.New("thread is locked")
+var ErrMuted = errors.New("you are muted")
+var ErrBanned = errors.New("you are banned")
+var ErrAlreadyReported = errors.New("you already reported this post")
+var ErrReportClosed = errors.New("report is already resolved")
+var ErrInvalidReason = errors.New("reason must be at most 500 characters")
+var ErrInvalidDuration = errors.New("duration must be between 0 and 365 days")
+var ErrInvalidRole = errors.New("role must be user, moderator or admin")
+var ErrInvalidReportStatus = errors.New("status must be open, actioned or dismissed")
+var ErrInvalidReportAction = errors.New("action must be hide or dismiss")
+
+// MaxSanction is the longest mute or ban a moderator can hand out.
+const MaxSanction = 365 * 24 * time.Hour
+
+// Thread stream event types for moderator actions. Hidden posts should be removed
+// like deleted ones; restored posts are back in the next GetThread.
+const (
+	ThreadPostHidden   = "post_hidden"
+	ThreadPostRestored = "post_restored"
+	ThreadLocked       = "thread_locked"
+	ThreadUnlocked     = "thread_unlocked"
+)
+
+// canWrite checks userID may post, edit or react in a thread: not banned or muted,
+// and the thread is unlocked unless they moderate. It returns the user.
+func (ts *ThreadService) canWrite(ctx context.Context, userID, threadID uint) (*models.User, error) {
+	user, err := ts.store.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if user.BannedAt(now) {
+		return nil, fmt.Errorf("%w until %s", ErrBanned, user.BannedUntil.UTC().Format(time.RFC3339))
+	}
+	if user.MutedAt(now) {
+		return nil, fmt.Errorf("%w until %s", ErrMuted, user.MutedUntil.UTC().Format(time.RFC3339))
+	}
+	if user.IsModerator() {
+		return user, nil
+	}
+	thread, err := ts.store.GetThreadByID(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.Locked {
+		return nil, ErrThreadLocked
+	}
+	return user, nil
+}
+
+// moderator loads the acting user and fails with ErrForbidden unless they moderate.
+func (ts *ThreadService) moderator(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := ts.store.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsModerator() {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+func cleanReason(reason string, required bool) (string, error) {
+	reason = strings.TrimSpace(stripHTML(reason))
+	if (required && reason == "") || utf8.RuneCountInString(reason) > 500 {
+		return "", ErrInvalidReason
+	}
+	return reason, nil
+}
+
+// HidePost takes a post out of public view and closes its open reports. Hiding a
+// hidden post changes nothing and logs nothing.
+func (ts *ThreadService) HidePost(ctx context.Context, moderatorID, postID uint, reason string) error {
+	return ts.setPostHidden(ctx, moderatorID, postID, true, reason)
+}
+
+// RestorePost undoes HidePost. Reports it closed stay closed.
+func (ts *ThreadService) RestorePost(ctx context.Context, moderatorID, postID uint, reason string) error {
+	return ts.setPostHidden(ctx, moderatorID, postID, false, reason)
+}
+
+func (ts *ThreadService) setPostHidden(ctx context.Context, moderatorID, postID uint, hidden bool, reason string) error {
+	if _, err := ts.moderator(ctx, moderatorID); err != nil {
+		return err
+	}
+	reason, err := cleanReason(reason, false)
+	if err != nil {
+		return err
+	}
+	post, err := ts.store.GetPostForModeration(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if (post.HiddenAt != nil) == hidden {
+		return nil
+	}
+
+	action, event := models.ModRestorePost, ThreadPostRestored
+	if hidden {
+		action, event = models.ModHidePost, ThreadPostHidden
+	}
+	entry := &models.ModerationLog{ModeratorID: moderatorID, Action: action, TargetType: "post", TargetID: postID, Reason: reason}
+	if err := ts.store.SetPostHidden(ctx, postID, hidden, entry); err != nil {
+		return err
+	}
+	ts.publish(ctx, ThreadEvent{Type: event, ThreadID: post.ThreadID, PostID: postID})
+	return nil
+}
+
+// SetThreadLock locks or unlocks a match's thread, creating it if nobody has posted
+// yet. Only moderators can post in a locked thread.
+func (ts *ThreadService) SetThreadLock(ctx context.Context, moderatorID, matchID uint, locked bool, reason string) (*models.MatchThread, error) {
+	if _, err := ts.moderator(ctx, moderatorID); err != nil {
+		return nil, err
+	}
+	reason, err := cleanReason(reason, false)
+	if err != nil {
+		return nil, err
+	}
+	thread, err := ts.store.GetOrCreateThread(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if thread.Locked == locked {
+		return thread, nil
+	}
+
+	action, event := models.ModUnlockThread, ThreadUnlocked
+	if locked {
+		action, event = models.ModLockThread, ThreadLocked
+	}
+	entry := &models.ModerationLog{ModeratorID: moderatorID, Action: action, TargetType: "thread", TargetID: thread.ID, Reason: reason}
+	if err := ts.store.SetThreadLocked(ctx, thread.ID, locked, entry); err != nil {
+		return nil, err
+	}
+	thread.Locked = locked
+	ts.publish(ctx, ThreadEvent{Type: event, ThreadID: thread.ID})
+	return thread, nil
+}
+
+// MuteUser stops a user posting, editing and reacting for d; 0 lifts a mute.
+func (ts *ThreadService) MuteUser(ctx context.Context, moderatorID, userID uint, d time.Duration, reason string) (*time.Time, error) {
+	return ts.sanction(ctx, moderatorID, userID, "muted_until", models.ModMuteUser, d, reason)
+}
+
+// BanUser is a mute that also stops the user reporting posts; 0 lifts a ban.
+func (ts *ThreadService) BanUser(ctx context.Context, moderatorID, userID uint, d time.Duration, reason string) (*time.Time, error) {
+	return ts.sanction(ctx, moderatorID, userID, "banned_until", models.ModBanUser, d, reason)
+}
+
+func (ts *ThreadService) sanction(ctx context.Context, moderatorID, userID uint, column, action string, d time.Duration, reason string) (*time.Time, error) {
+	mod, err := ts.moderator(ctx, moderatorID)
+	if err != nil {
+		return nil, err
+	}
+	if d < 0 || d > MaxSanction {
+		return nil, ErrInvalidDuration
+	}
+	reason, err = cleanReason(reason, false)
+	if err != nil {
+		return nil, err
+	}
+	target, err := ts.store.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if models.RoleRank(target.Role) >= models.RoleRank(mod.Role) {
+		return nil, ErrOutranked
+	}
+
+	var until *time.Time
+	detail := "lifted"
+	if d > 0 {
+		t := time.Now().Add(d).UTC()
+		until = &t
+		detail = "until " + t.Format(time.RFC3339)
+	}
+	entry := &models.ModerationLog{ModeratorID: moderatorID, Action: action, TargetType: "user", TargetID: userID, Reason: reason, Detail: detail}
+	if err := ts.store.UpdateUserModeration(ctx, userID, map[string]any{column: until}, entry); err != nil {
+		return nil, err
+	}
+	return until, nil
+}
+
+// SetRole changes a user's role. Only admins can, and not their own.
+func (ts *ThreadService) SetRole(ctx context.Context, adminID, userID uint, role, reason string) error {
+	admin, err := ts.store.GetUser(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if admin.Role != models.RoleAdmin {
+		return ErrAdminRequired
+	}
+	if role != models.RoleUser && role != models.RoleModerator && role != models.RoleAdmin {
+		return ErrInvalidRole
+	}
+	if adminID == userID {
+		return ErrOutranked
+	}
+	reason, err = cleanReason(reason, false)
+	if err != nil {
+		return err
+	}
+	target, err := ts.store.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if target.Role == role {
+		return nil
+	}
+	entry := &models.ModerationLog{
+		ModeratorID: adminID, Action: models.ModSetRole, TargetType: "user", TargetID: userID,
+		Reason: reason, Detail: target.Role + " -> " + role,
+	}
+	return ts.store.UpdateUserModeration(ctx, userID, map[string]any{"role": role}, entry)
+}
+
+// ReportPost puts a live post in the moderator queue. Each user can report a post once.
+func (ts *ThreadService) ReportPost(ctx context.Context, userID, postID uint, reason string) (*models.PostReport, error) {
+	user, err := ts.store.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.BannedAt(time.Now()) {
+		return nil, ErrBanned
+	}
+	reason, err = cleanReason(reason, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ts.store.GetPost(ctx, postID); err != nil {
+		return nil, err
+	}
+	report := &models.PostReport{PostID: postID, ReporterID: userID, Reason: reason, Status: models.ReportOpen}
+	created, err := ts.store.CreateReport(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrAlreadyReported
+	}
+	return report, nil
+}
+
+// ListReports pages through the moderator queue, oldest first. An empty status lists
+// every report.
+func (ts *ThreadService) ListReports(ctx context.Context, moderatorID uint, status string, page, limit int) ([]models.PostReport, int64, error) {
+	if _, err := ts.moderator(ctx, moderatorID); err != nil {
+		return nil, 0, err
+	}
+	switch status {
+	case "", models.ReportOpen, models.ReportActioned, models.ReportDismissed:
+	default:
+		return nil, 0, ErrInvalidReportStatus
+	}
+	return ts.store.ListReports(ctx, status, limit, (page-1)*limit)
+}
+
+// ResolveReport closes an open report: "hide" hides the post, which closes every open
+// report on it, and "dismiss" closes just this one.
+func (ts *ThreadService) ResolveReport(ctx context.Context, moderatorID, reportID uint, action, reason string) error {
+	if _, err := ts.moderator(ctx, moderatorID); err != nil {
+		return err
+	}
+	if action != "hide" && action != "dismiss" {
+		return ErrInvalidReportAction
+	}
+	report, err := ts.store.GetReport(ctx, reportID)
+	if err != nil {
+		return err
+	}
+	if report.Status != models.ReportOpen {
+		return ErrReportClosed
+	}
+	if action == "hide" {
+		return ts.HidePost(ctx, moderatorID, report.PostID, reason)
+	}
+	reason, err = cleanReason(reason, false)
+	if err != nil {
+		return err
+	}
+	entry := &models.ModerationLog{ModeratorID: moderatorID, Action: models.ModDismissReport, TargetType: "report", TargetID: reportID, Reason: reason}
+	return ts.store.DismissReport(ctx, reportID, moderatorID, entry)
+}
+
+// ListModerationLog pages through the audit trail, newest first.
+func (ts *ThreadService) ListModerationLog(ctx context.Context, moderatorID uint, page, limit int) ([]models.ModerationLog, int64, error) {
+	if _, err := ts.moderator(ctx, moderatorID); err != nil {
+		return nil, 0, err
+	}
+	return ts.store.ListModerationLog(ctx, limit, (page-1)*limit)
+}
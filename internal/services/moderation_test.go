@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// Users not listed in mockThreadStore.users exist as plain users.
+func (m *mockThreadStore) GetUser(_ context.Context, id uint) (*models.User, error) {
+	for i := range m.users {
+		if m.users[i].ID == id {
+			return &m.users[i], nil
+		}
+	}
+	return &models.User{ID: id, Role: models.RoleUser}, nil
+}
+
+func (m *mockThreadStore) GetPostForModeration(_ context.Context, id uint) (*models.ThreadPost, error) {
+	for i := range m.posts {
+		if m.posts[i].ID == id {
+			return &m.posts[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *mockThreadStore) SetPostHidden(_ context.Context, postID uint, hidden bool, entry *models.ModerationLog) error {
+	for i := range m.posts {
+		if m.posts[i].ID != postID {
+			continue
+		}
+		m.posts[i].HiddenAt = nil
+		if hidden {
+			now := time.Now()
+			m.posts[i].HiddenAt = &now
+			for j := range m.reports {
+				if m.reports[j].PostID == postID && m.reports[j].Status == models.ReportOpen {
+					m.reports[j].Status = models.ReportActioned
+				}
+			}
+		}
+	}
+	m.audit = append(m.audit, *entry)
+	return nil
+}
+
+func (m *mockThreadStore) SetThreadLocked(_ context.Context, _ uint, locked bool, entry *models.ModerationLog) error {
+	if m.thread != nil {
+		m.thread.Locked = locked
+	}
+	m.audit = append(m.audit, *entry)
+	return nil
+}
+
+func (m *mockThreadStore) UpdateUserModeration(_ context.Context, userID uint, updates map[string]any, entry *models.ModerationLog) error {
+	for i := range m.users {
+		if m.users[i].ID != userID {
+			continue
+		}
+		for col, v := range updates {
+			switch col {
+			case "role":
+				m.users[i].Role = v.(string)
+			case "muted_until":
+				m.users[i].MutedUntil = v.(*time.Time)
+			case "banned_until":
+				m.users[i].BannedUntil = v.(*time.Time)
+			}
+		}
+	}
+	m.audit = append(m.audit, *entry)
+	return nil
+}
+
+func (m *mockThreadStore) CreateReport(_ context.Context, r *models.PostReport) (bool, error) {
+	for _, have := range m.reports {
+		if have.PostID == r.PostID && have.ReporterID == r.ReporterID {
+			return false, nil
+		}
+	}
+	r.ID = uint(len(m.reports) + 1)
+	m.reports = append(m.reports, *r)
+	return true, nil
+}
+
+func (m *mockThreadStore) GetReport(_ context.Context, id uint) (*models.PostReport, error) {
+	for i := range m.reports {
+		if m.reports[i].ID == id {
+			return &m.reports[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *mockThreadStore) ListReports(_ context.Context, status string, _, _ int) ([]models.PostReport, int64, error) {
+	var out []models.PostReport
+	for _, r := range m.reports {
+		if status == "" || r.Status == status {
+			out = append(out, r)
+		}
+	}
+	return out, int64(len(out)), nil
+}
+
+func (m *mockThreadStore) DismissReport(_ context.Context, id, _ uint, entry *models.ModerationLog) error {
+	for i := range m.reports {
+		if m.reports[i].ID == id {
+			m.reports[i].Status = models.ReportDismissed
+		}
+	}
+	m.audit = append(m.audit, *entry)
+	return nil
+}
+
+func (m *mockThreadStore) ListModerationLog(_ context.Context, _, _ int) ([]models.ModerationLog, int64, error) {
+	return m.audit, int64(len(m.audit)), nil
+}
+
+func modStore() *mockThreadStore {
+	return &mockThreadStore{
+		thread: &models.MatchThread{ID: 1, MatchID: 42},
+		posts:  []models.ThreadPost{{ID: 10, ThreadID: 1, UserID: 3, Body: "spam"}},
+		users: []models.User{
+			{ID: 1, Username: "Admin", Role: models.RoleAdmin},
+			{ID: 2, Username: "Mod", Role: models.RoleModerator},
+			{ID: 3, Username: "Poster", Role: models.RoleUser},
+			{ID: 4, Username: "OtherMod", Role: models.RoleModerator},
+		},
+	}
+}
+
+func TestThreadService_ModeratorActionsRequireRole(t *testing.T) {
+	ctx := context.Background()
+	ms := modStore()
+	svc := NewThreadService(ms, nil, nil)
+
+	assert.ErrorIs(t, svc.HidePost(ctx, 3, 10, ""), ErrForbidden)
+	_, err := svc.SetThreadLock(ctx, 3, 42, true, "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = svc.MuteUser(ctx, 3, 4, time.Hour, "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, _, err = svc.ListReports(ctx, 3, "", 1, 25)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, _, err = svc.ListModerationLog(ctx, 3, 1, 25)
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ErrorIs(t, svc.SetRole(ctx, 2, 3, models.RoleModerator, ""), ErrAdminRequired)
+	assert.Empty(t, ms.audit)
+}
+
+func TestThreadService_HideAndRestorePost(t *testing.T) {
+	ctx := context.Background()
+	ms := modStore()
+	hub := pubsub.NewMemoryHub(pubsub.DefaultBuffer)
+	svc := NewThreadService(ms, hub, nil)
+	events, cancel, err := svc.Subscribe(ctx, 42)
+	require.NoError(t, err)
+	defer cancel()
+
+	_, err = svc.ReportPost(ctx, 5, 10, "spam link")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.HidePost(ctx, 2, 10, "spam"))
+	assert.Equal(t, ThreadPostHidden, nextThreadEvent(t, events).Type)
+	assert.Equal(t, models.ReportActioned, ms.reports[0].Status, "hiding closes open reports")
+	_, err = svc.React(ctx, 10, 5, "🔥")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "hidden posts are gone for users")
+	assert.ErrorIs(t, svc.EditPost(ctx, 10, 3, "not spam"), gorm.ErrRecordNotFound)
+
+	require.NoError(t, svc.HidePost(ctx, 2, 10, "again"), "hiding twice is a no-op")
+	require.NoError(t, svc.RestorePost(ctx, 2, 10, ""))
+	assert.Equal(t, ThreadPostRestored, nextThreadEvent(t, events).Type)
+	assert.Nil(t, ms.posts[0].HiddenAt)
+
+	require.Len(t, ms.audit, 2)
+	assert.Equal(t, models.ModHidePost, ms.audit[0].Action)
+	assert.Equal(t, "spam", ms.audit[0].Reason)
+	assert.Equal(t, uint(2), ms.audit[0].ModeratorID)
+	assert.Equal(t, models.ModRestorePost, ms.audit[1].Action)
+}
+
+func TestThreadService_LockedThread(t *testing.T) {
+	ctx := context.Background()
+	ms := modStore()
+	svc := NewThreadService(ms, nil, nil)
+
+	thread, err := svc.SetThreadLock(ctx, 2, 42, true, "heated")
+	require.NoError(t, err)
+	assert.True(t, thread.Locked)
+
+	_, err = svc.CreatePost(ctx, 1, 3, "let me in")
+	assert.ErrorIs(t, err, ErrThreadLocked)
+	assert.ErrorIs(t, svc.EditPost(ctx, 10, 3, "edited"), ErrThreadLocked)
+	_, err = svc.React(ctx, 10, 3, "🔥")
+	assert.ErrorIs(t, err, ErrThreadLocked)
+	assert.NoError(t, svc.DeletePost(ctx, 10, 3), "authors can still delete")
+
+	_, err = svc.CreatePost(ctx, 1, 2, "thread locked, take it to DMs")
+	assert.NoError(t, err, "moderators can post in locked threads")
+
+	_, err = svc.SetThreadLock(ctx, 2, 42, false, "")
+	require.NoError(t, err)
+	_, err = svc.CreatePost(ctx, 1, 3, "thanks")
+	assert.NoError(t, err)
+
+	require.Len(t, ms.audit, 2)
+	assert.Equal(t, models.ModLockThread, ms.audit[0].Action)
+	assert.Equal(t, models.ModUnlockThread, ms.audit[1].Action)
+}
+
+func TestThreadService_MuteAndBan(t *testing.T) {
+	ctx := context.Background()
+	ms := modStore()
+	svc := NewThreadService(ms, nil, nil)
+
+	until, err := svc.MuteUser(ctx, 2, 3, time.Hour, "flaming")
+	require.NoError(t, err)
+	require.NotNil(t, until)
+	_, err = svc.CreatePost(ctx, 1, 3, "hello?")
+	assert.ErrorIs(t, err, ErrMuted)
+	_, err = svc.ReportPost(ctx, 3, 10, "muted users can still report")
+	assert.NoError(t, err)
+
+	until, err = svc.MuteUser(ctx, 2, 3, 0, "")
+	require.NoError(t, err)
+	assert.Nil(t, until)
+	_, err = svc.CreatePost(ctx, 1, 3, "I'm back")
+	assert.NoError(t, err)
+
+	_, err = svc.BanUser(ctx, 2, 3, 24*time.Hour, "")
+	require.NoError(t, err)
+	_, err = svc.CreatePost(ctx, 1, 3, "hello?")
+	assert.ErrorIs(t, err, ErrBanned)
+	_, err = svc.ReportPost(ctx, 3, 10, "banned users cannot")
+	assert.ErrorIs(t, err, ErrBanned)
+
+	_, err = svc.MuteUser(ctx, 2, 4, time.Hour, "")
+	assert.ErrorIs(t, err, ErrOutranked, "moderators cannot mute moderators")
+	_, err = svc.MuteUser(ctx, 1, 4, time.Hour, "")
+	assert.NoError(t, err, "admins can")
+	_, err = svc.BanUser(ctx, 2, 3, MaxSanction+time.Hour, "")
+	assert.ErrorIs(t, err, ErrInvalidDuration)
+
+	require.Len(t, ms.audit, 4)
+	assert.Equal(t, "lifted", ms.audit[1].Detail)
+	assert.Equal(t, models.ModBanUser, ms.audit[2].Action)
+}
+
+func TestThreadService_SetRole(t *testing.T) {
+	ctx := context.Background()
+	ms := modStore()
+	svc := NewThreadService(ms, nil, nil)
+
+	require.NoError(t, svc.SetRole(ctx, 1, 3, models.RoleModerator, "trusted"))
+	assert.Equal(t, models.RoleModerator, ms.users[2].Role)
+	require.Len(t, ms.audit, 1)
+	assert.Equal(t, "user -> moderator", ms.audit[0].Detail)
+
+	assert.ErrorIs(t, svc.SetRole(ctx, 1, 3, "owner", ""), ErrInvalidRole)
+	assert.ErrorIs(t, svc.SetRole(ctx, 1, 1, models.RoleUser, ""), ErrOutranked)
+}
+
+func TestThreadService_Reports(t *testing.T) {
+	ctx := context.Background()
+	ms := modStore()
+	svc := NewThreadService(ms, nil, nil)
+
+	_, err := svc.ReportPost(ctx, 5, 10, "  ")
+	assert.ErrorIs(t, err, ErrInvalidReason)
+	report, err := svc.ReportPost(ctx, 5, 10, "off topic")
+	require.NoError(t, err)
+	_, err = svc.ReportPost(ctx, 5, 10, "really off topic")
+	assert.ErrorIs(t, err, ErrAlreadyReported)
+	_, err = svc.ReportPost(ctx, 5, 404, "missing")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	queue, total, err := svc.ListReports(ctx, 2, models.ReportOpen, 1, 25)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	assert.Equal(t, report.ID, queue[0].ID)
+	_, _, err = svc.ListReports(ctx, 2, "closed", 1, 25)
+	assert.ErrorIs(t, err, ErrInvalidReportStatus)
+
+	assert.ErrorIs(t, svc.ResolveReport(ctx, 2, report.ID, "delete", ""), ErrInvalidReportAction)
+	require.NoError(t, svc.ResolveReport(ctx, 2, report.ID, "dismiss", "fine"))
+	assert.Equal(t, models.ReportDismissed, ms.reports[0].Status)
+	assert.ErrorIs(t, svc.ResolveReport(ctx, 2, report.ID, "hide", ""), ErrReportClosed)
+
+	second, err := svc.ReportPost(ctx, 6, 10, "spam")
+	require.NoError(t, err)
+	require.NoError(t, svc.ResolveReport(ctx, 2, second.ID, "hide", "spam"))
+	assert.NotNil(t, ms.posts[0].HiddenAt)
+	assert.Equal(t, models.ReportActioned, ms.reports[1].Status)
+
+	log, _, err := svc.ListModerationLog(ctx, 2, 1, 25)
+	require.NoError(t, err)
+	require.Len(t, log, 2)
+	assert.Equal(t, models.ModDismissReport, log[0].Action)
+	assert.Equal(t, models.ModHidePost, log[1].Action)
+}
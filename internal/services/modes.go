@@ -0,0 +1,33 @@
+package services
+
+import "strings"
+
+// normalizeMode maps a raw match_maps.mode value to the short keys used across the
+// API ("hp", "snd", "control"), matching the CASE in PlayerStore.ListModeKDSplits.
+func normalizeMode(mode string) string {
+	switch mode {
+	case "Search and Destroy", "Search & Destroy":
+		return "snd"
+	case "Hardpoint":
+		return "hp"
+	case "Control":
+		return "control"
+	default:
+		return "other"
+	}
+}
+
+// parseModeParam reads a mode query parameter, which may be a short key or a raw
+// mode name in any case. ok is false for anything that is not hp, snd or control.
+func parseModeParam(mode string) (key string, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "hp", "hardpoint":
+		return "hp", true
+	case "snd", "search and destroy", "search & destroy":
+		return "snd", true
+	case "control":
+		return "control", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/notify"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+var ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+// NotificationPreferencesInput is the body of PUT /me/notification-preferences.
+type NotificationPreferencesInput struct {
+	Matches        bool   `json:"matches"`
+	Mentions       bool   `json:"mentions"`
+	Transfers      bool   `json:"transfers"`
+	EmailEnabled   bool   `json:"email_enabled"`
+	Email          string `json:"email"`
+	WebhookEnabled bool   `json:"webhook_enabled"`
+	WebhookURL     string `json:"webhook_url"`
+}
+
+type NotificationService struct {
+	store store.NotificationStore
+	now   func() time.Time
+}
+
+func NewNotificationService(s store.NotificationStore) *NotificationService {
+	return &NotificationService{store: s, now: time.Now}
+}
+
+// List pages through the user's notifications, newest first, and returns the unread count.
+func (ns *NotificationService) List(ctx context.Context, userID uint, unreadOnly bool, page, limit int) ([]models.Notification, int64, int64, error) {
+	items, total, err := ns.store.List(ctx, userID, unreadOnly, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	unread, err := ns.store.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return items, total, unread, nil
+}
+
+func (ns *NotificationService) MarkRead(ctx context.Context, userID, id uint) error {
+	return ns.store.MarkRead(ctx, userID, id, ns.now())
+}
+
+func (ns *NotificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	return ns.store.MarkAllRead(ctx, userID, ns.now())
+}
+
+func (ns *NotificationService) GetPreferences(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	return ns.store.GetPreferences(ctx, userID)
+}
+
+// UpdatePreferences replaces the user's preferences. An address is required for each
+// enabled channel: a valid email, and an absolute http(s) webhook URL.
+func (ns *NotificationService) UpdatePreferences(ctx context.Context, userID uint, in NotificationPreferencesInput) (*models.NotificationPreference, error) {
+	if in.Email != "" {
+		addr, err := mail.ParseAddress(in.Email)
+		if err != nil || addr.Address != in.Email || len(in.Email) > 254 {
+			return nil, fmt.Errorf("%w: email is not a valid address", ErrInvalidPreferences)
+		}
+	}
+	if in.EmailEnabled && in.Email == "" {
+		return nil, fmt.Errorf("%w: email is required to enable email", ErrInvalidPreferences)
+	}
+	if in.WebhookURL != "" {
+		u, err := url.Parse(in.WebhookURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(in.WebhookURL) > 500 {
+			return nil, fmt.Errorf("%w: webhook_url must be an http(s) URL", ErrInvalidPreferences)
+		}
+	}
+	if in.WebhookEnabled && in.WebhookURL == "" {
+		return nil, fmt.Errorf("%w: webhook_url is required to enable webhooks", ErrInvalidPreferences)
+	}
+
+	pref := &models.NotificationPreference{
+		UserID:         userID,
+		Matches:        in.Matches,
+		Mentions:       in.Mentions,
+		Transfers:      in.Transfers,
+		EmailEnabled:   in.EmailEnabled,
+		Email:          in.Email,
+		WebhookEnabled: in.WebhookEnabled,
+		WebhookURL:     in.WebhookURL,
+	}
+	if err := ns.store.SavePreferences(ctx, pref); err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+// NotifyWorkerConfig tunes the delivery worker. Lookback bounds how far back each run
+// looks for new matches, transfers and mentions; it must comfortably exceed the run
+// interval. A failed delivery is retried after Backoff, doubling each time up to
+// MaxBackoff, and given up after MaxAttempts.
+type NotifyWorkerConfig struct {
+	Lookback    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultNotifyWorkerConfig() NotifyWorkerConfig {
+	return NotifyWorkerConfig{
+		Lookback:    24 * time.Hour,
+		BatchSize:   100,
+		MaxAttempts: 6,
+		Backoff:     time.Minute,
+		MaxBackoff:  6 * time.Hour,
+	}
+}
+
+// NotifyRun counts what one worker pass did.
+type NotifyRun struct {
+	Created int64 `json:"created"`
+	Queued  int64 `json:"queued"`
+	Sent    int   `json:"sent"`
+	Retried int   `json:"retried"`
+	Failed  int   `json:"failed"`
+}
+
+// NotificationWorker generates notifications and delivers them over its channels.
+// Only one worker should run against a database at a time.
+type NotificationWorker struct {
+	store    store.NotificationStore
+	channels map[string]notify.Channel
+	cfg      NotifyWorkerConfig
+	now      func() time.Time
+}
+
+func NewNotificationWorker(s store.NotificationStore, cfg NotifyWorkerConfig, channels ...notify.Channel) *NotificationWorker {
+	byName := make(map[string]notify.Channel, len(channels))
+	for _, ch := range channels {
+		byName[ch.Name()] = ch
+	}
+	return &NotificationWorker{store: s, channels: byName, cfg: cfg, now: time.Now}
+}
+
+// backoff is the wait before the next attempt after the given number of failed attempts.
+func (w *NotificationWorker) backoff(attempts int) time.Duration {
+	return retryBackoff(w.cfg.Backoff, w.cfg.MaxBackoff, attempts)
+}
+
+// retryBackoff doubles base for every failed attempt after the first, capped at ceiling.
+func retryBackoff(base, ceiling time.Duration, attempts int) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < ceiling; i++ {
+		d *= 2
+	}
+	return min(d, ceiling)
+}
+
+// RunOnce generates new notifications, queues them on the users' channels and sends
+// one batch of due deliveries.
+func (w *NotificationWorker) RunOnce(ctx context.Context) (NotifyRun, error) {
+	var run NotifyRun
+	now := w.now()
+	since := now.Add(-w.cfg.Lookback)
+
+	created, err := w.store.Generate(ctx, since, now)
+	if err != nil {
+		return run, err
+	}
+	run.Created = created
+
+	names := make([]string, 0, len(w.channels))
+	for name := range w.channels {
+		names = append(names, name)
+	}
+	queued, err := w.store.QueueDeliveries(ctx, since, now, names)
+	if err != nil {
+		return run, err
+	}
+	run.Queued = queued
+
+	due, err := w.store.ListDueDeliveries(ctx, now, w.cfg.BatchSize)
+	if err != nil {
+		return run, err
+	}
+	for _, d := range due {
+		attempts := d.Attempts + 1
+		ch, ok := w.channels[d.Channel]
+		if !ok || !d.Enabled {
+			if err := w.store.MarkFailed(ctx, d.DeliveryID, d.Attempts, "channel disabled"); err != nil {
+				return run, err
+			}
+			run.Failed++
+			continue
+		}
+
+		sendErr := ch.Send(ctx, notify.Message{
+			NotificationID: d.NotificationID,
+			Kind:           d.Kind,
+			Title:          d.Title,
+			Body:           d.Body,
+			Link:           d.Link,
+			CreatedAt:      d.CreatedAt,
+			To:             notify.Recipient{UserID: d.UserID, Username: d.Username, Email: d.Email, WebhookURL: d.WebhookURL},
+		})
+		switch {
+		case sendErr == nil:
+			err = w.store.MarkDelivered(ctx, d.DeliveryID, attempts, w.now())
+			run.Sent++
+		case attempts >= w.cfg.MaxAttempts:
+			err = w.store.MarkFailed(ctx, d.DeliveryID, attempts, sendErr.Error())
+			run.Failed++
+		default:
+			err = w.store.MarkRetry(ctx, d.DeliveryID, attempts, w.now().Add(w.backoff(attempts)), sendErr.Error())
+			run.Retried++
+		}
+		if err != nil {
+			return run, err
+		}
+	}
+	return run, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/notify"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deliveryUpdate struct {
+	status   string
+	attempts int
+	next     time.Time
+	reason   string
+}
+
+type mockNotificationStore struct {
+	channels []string
+	due      []store.DueDelivery
+	updates  map[uint]deliveryUpdate
+	saved    *models.NotificationPreference
+}
+
+func (m *mockNotificationStore) Generate(context.Context, time.Time, time.Time) (int64, error) {
+	return 2, nil
+}
+func (m *mockNotificationStore) QueueDeliveries(_ context.Context, _, _ time.Time, channels []string) (int64, error) {
+	m.channels = channels
+	return int64(len(m.due)), nil
+}
+func (m *mockNotificationStore) ListDueDeliveries(context.Context, time.Time, int) ([]store.DueDelivery, error) {
+	return m.due, nil
+}
+func (m *mockNotificationStore) MarkDelivered(_ context.Context, id uint, attempts int, _ time.Time) error {
+	m.updates[id] = deliveryUpdate{status: models.DeliverySent, attempts: attempts}
+	return nil
+}
+func (m *mockNotificationStore) MarkRetry(_ context.Context, id uint, attempts int, next time.Time, reason string) error {
+	m.updates[id] = deliveryUpdate{status: models.DeliveryPending, attempts: attempts, next: next, reason: reason}
+	return nil
+}
+func (m *mockNotificationStore) MarkFailed(_ context.Context, id uint, attempts int, reason string) error {
+	m.updates[id] = deliveryUpdate{status: models.DeliveryFailed, attempts: attempts, reason: reason}
+	return nil
+}
+func (m *mockNotificationStore) List(context.Context, uint, bool, int, int) ([]models.Notification, int64, error) {
+	return nil, 0, nil
+}
+func (m *mockNotificationStore) CountUnread(context.Context, uint) (int64, error)      { return 0, nil }
+func (m *mockNotificationStore) MarkRead(context.Context, uint, uint, time.Time) error { return nil }
+func (m *mockNotificationStore) MarkAllRead(context.Context, uint, time.Time) (int64, error) {
+	return 0, nil
+}
+func (m *mockNotificationStore) GetPreferences(context.Context, uint) (*models.NotificationPreference, error) {
+	return nil, nil
+}
+func (m *mockNotificationStore) SavePreferences(_ context.Context, p *models.NotificationPreference) error {
+	m.saved = p
+	return nil
+}
+
+// fakeChannel fails for any recipient listed in failFor.
+type fakeChannel struct {
+	name    string
+	failFor map[uint]bool
+	sent    []notify.Message
+}
+
+func (f *fakeChannel) Name() string { return f.name }
+func (f *fakeChannel) Send(_ context.Context, msg notify.Message) error {
+	if f.failFor[msg.To.UserID] {
+		return errors.New("connection refused")
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func TestNotificationWorker_RunOnce(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	ms := &mockNotificationStore{
+		updates: map[uint]deliveryUpdate{},
+		due: []store.DueDelivery{
+			{DeliveryID: 1, Channel: notify.ChannelWebhook, Enabled: true, UserID: 1, Title: "OTX 3-1 ATL", WebhookURL: "https://bot.example.com"},
+			{DeliveryID: 2, Channel: notify.ChannelWebhook, Enabled: true, UserID: 2, Attempts: 2},
+			{DeliveryID: 3, Channel: notify.ChannelWebhook, Enabled: true, UserID: 2, Attempts: 5},
+			{DeliveryID: 4, Channel: notify.ChannelWebhook, Enabled: false, UserID: 1},
+			{DeliveryID: 5, Channel: notify.ChannelEmail, Enabled: true, UserID: 1},
+		},
+	}
+	ch := &fakeChannel{name: notify.ChannelWebhook, failFor: map[uint]bool{2: true}}
+	w := NewNotificationWorker(ms, DefaultNotifyWorkerConfig(), ch)
+	w.now = func() time.Time { return now }
+
+	run, err := w.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, NotifyRun{Created: 2, Queued: 5, Sent: 1, Retried: 1, Failed: 3}, run)
+	assert.Equal(t, []string{notify.ChannelWebhook}, ms.channels, "only configured channels are queued")
+
+	require.Len(t, ch.sent, 1)
+	assert.Equal(t, "OTX 3-1 ATL", ch.sent[0].Title)
+	assert.Equal(t, "https://bot.example.com", ch.sent[0].To.WebhookURL)
+
+	assert.Equal(t, deliveryUpdate{status: models.DeliverySent, attempts: 1}, ms.updates[1])
+	assert.Equal(t, deliveryUpdate{status: models.DeliveryPending, attempts: 3, next: now.Add(4 * time.Minute), reason: "connection refused"}, ms.updates[2])
+	assert.Equal(t, deliveryUpdate{status: models.DeliveryFailed, attempts: 6, reason: "connection refused"}, ms.updates[3])
+	assert.Equal(t, models.DeliveryFailed, ms.updates[4].status)
+	assert.Equal(t, models.DeliveryFailed, ms.updates[5].status, "a channel the worker does not run fails")
+}
+
+func TestNotificationWorker_Backoff(t *testing.T) {
+	w := NewNotificationWorker(&mockNotificationStore{}, DefaultNotifyWorkerConfig())
+	assert.Equal(t, time.Minute, w.backoff(1))
+	assert.Equal(t, 2*time.Minute, w.backoff(2))
+	assert.Equal(t, 32*time.Minute, w.backoff(6))
+	assert.Equal(t, 6*time.Hour, w.backoff(40))
+}
+
+func TestUpdatePreferences_Validation(t *testing.T) {
+	ms := &mockNotificationStore{}
+	ns := NewNotificationService(ms)
+	ctx := context.Background()
+
+	for name, in := range map[string]NotificationPreferencesInput{
+		"email on without address":  {EmailEnabled: true},
+		"bad email":                 {Email: "not-an-email"},
+		"display name email":        {Email: "Fan <fan@example.com>"},
+		"webhook on without url":    {WebhookEnabled: true},
+		"webhook with other scheme": {WebhookURL: "ftp://bot.example.com/hook"},
+		"webhook without host":      {WebhookURL: "https:///hook"},
+	} {
+		_, err := ns.UpdatePreferences(ctx, 1, in)
+		assert.ErrorIs(t, err, ErrInvalidPreferences, name)
+	}
+	assert.Nil(t, ms.saved)
+
+	pref, err := ns.UpdatePreferences(ctx, 1, NotificationPreferencesInput{
+		Matches: true, EmailEnabled: true, Email: "fan@example.com", WebhookURL: "https://bot.example.com/hook",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, pref, ms.saved)
+	assert.True(t, pref.Matches)
+	assert.False(t, pref.Mentions)
+	assert.False(t, pref.WebhookEnabled)
+}
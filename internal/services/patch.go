@@ -0,0 +1,232 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidPatch = errors.New("invalid patch")
+var ErrPatchChanged = errors.New("patch has changed since it was applied")
+
+var patchID = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+var patchFormat = regexp.MustCompile(`^(?i)bo[1-9]$`)
+
+// ParsePatch reads a patch file, YAML or JSON by its extension, and validates it.
+// Unknown fields are errors so a misspelt key can't silently do nothing.
+func ParsePatch(name string, data []byte) (*store.Patch, error) {
+	var p store.Patch
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPatch, name, err)
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&p); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPatch, name, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s: patches are .yaml, .yml or .json", ErrInvalidPatch, name)
+	}
+	if err := validatePatch(&p); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPatch, name, err)
+	}
+	return &p, nil
+}
+
+// validatePatch checks that every operation has the fields it needs. It doesn't look
+// anything up; names that don't resolve fail when the patch is applied.
+func validatePatch(p *store.Patch) error {
+	if !patchID.MatchString(p.ID) {
+		return errors.New("id must be 1-100 lowercase letters, digits, '.', '_' or '-'")
+	}
+	if len(p.Ops) == 0 {
+		return errors.New("no ops")
+	}
+	for i, op := range p.Ops {
+		if err := validatePatchOp(op); err != nil {
+			return fmt.Errorf("op %d (%s): %v", i+1, op.Op, err)
+		}
+	}
+	return nil
+}
+
+func validatePatchOp(op store.PatchOp) error {
+	switch op.Op {
+	case store.PatchMatchUpsert, store.PatchMatchDelete, store.PatchBracket:
+		if err := validateMatchRef(op.Match); err != nil {
+			return err
+		}
+	case store.PatchTeamRename:
+		if op.Team == "" || strings.TrimSpace(op.Name) == "" {
+			return errors.New("team and name are required")
+		}
+		if op.Name == op.Team {
+			return errors.New("name is the team's current name")
+		}
+	case store.PatchTeamMerge:
+		if op.Team == "" || op.Into == "" {
+			return errors.New("team and into are required")
+		}
+		if op.Team == op.Into {
+			return errors.New("a team can't be merged into itself")
+		}
+	default:
+		return fmt.Errorf("unknown op; use %s", strings.Join(patchOps, ", "))
+	}
+
+	switch op.Op {
+	case store.PatchMatchUpsert:
+		if op.Set == nil {
+			return errors.New("set is required")
+		}
+		if op.Set.Format != nil && !patchFormat.MatchString(*op.Set.Format) {
+			return fmt.Errorf("format %q is not BO1-BO9", *op.Set.Format)
+		}
+		for _, score := range []*int{op.Set.Team1Score, op.Set.Team2Score} {
+			if score != nil && *score < 0 {
+				return errors.New("scores can't be negative")
+			}
+		}
+		if op.Set.BracketPosition != nil && *op.Set.BracketPosition < 0 {
+			return errors.New("bracket_position can't be negative")
+		}
+	case store.PatchBracket:
+		if strings.TrimSpace(op.Round) == "" || op.Position < 0 {
+			return errors.New("round and a non-negative position are required")
+		}
+	}
+	if len(op.Abbreviation) > 10 {
+		return errors.New("abbreviation is at most 10 characters")
+	}
+	return nil
+}
+
+var patchOps = []string{store.PatchMatchUpsert, store.PatchMatchDelete, store.PatchBracket, store.PatchTeamRename, store.PatchTeamMerge}
+
+func validateMatchRef(ref *store.PatchMatchRef) error {
+	if ref == nil || ref.Tournament == "" || ref.Team1 == "" || ref.Team2 == "" {
+		return errors.New("match needs tournament, team1 and team2")
+	}
+	if ref.Team1 == ref.Team2 {
+		return errors.New("a team can't play itself")
+	}
+	if ref.Date != "" {
+		if _, err := time.Parse(time.DateOnly, ref.Date); err != nil {
+			return fmt.Errorf("date %q is not YYYY-MM-DD", ref.Date)
+		}
+	}
+	return nil
+}
+
+// PatchChecksum identifies a patch's content: the SHA-256 of its canonical JSON, so
+// reformatting the file or switching between YAML and JSON doesn't change it.
+func PatchChecksum(p *store.Patch) string {
+	data, _ := json.Marshal(p)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// PatchRun is the outcome of running one patch. Skipped means it had already been
+// applied; DryRun results were rolled back.
+type PatchRun struct {
+	ID      string
+	Skipped bool
+	DryRun  bool
+	Changes []models.ChangeLog
+	Notes   []string
+}
+
+// PatchService applies patch files, each at most once.
+type PatchService struct {
+	store store.PatchStore
+}
+
+func NewPatchService(s store.PatchStore) *PatchService {
+	return &PatchService{store: s}
+}
+
+// Run applies a parsed patch unless applied_patches already has it. A patch that was
+// applied with a different checksum is refused with ErrPatchChanged: applied patches
+// are history, and a follow-up fix belongs in a new patch.
+func (ps *PatchService) Run(ctx context.Context, p *store.Patch, dryRun bool) (*PatchRun, error) {
+	checksum := PatchChecksum(p)
+	applied, err := ps.store.GetAppliedPatch(ctx, p.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if applied != nil {
+		if applied.Checksum != checksum {
+			return nil, fmt.Errorf("%w: %s (applied %s)", ErrPatchChanged, p.ID, applied.AppliedAt.Format(time.DateOnly))
+		}
+		return &PatchRun{ID: p.ID, Skipped: true}, nil
+	}
+	result, err := ps.store.ApplyPatch(ctx, p, checksum, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.ID, err)
+	}
+	return &PatchRun{ID: p.ID, DryRun: dryRun, Changes: result.Changes, Notes: result.Notes}, nil
+}
+
+// diffIgnored are columns every write touches.
+var diffIgnored = []string{"created_at", "updated_at"}
+
+// DiffChange renders one change-log row as diff lines: "+ match 12 {...}" for a
+// create, "- map 12:3 {...}" for a delete, and "~ match 12: team1_score 2 -> 3" per
+// changed column for an update.
+func DiffChange(c models.ChangeLog) []string {
+	head := c.Entity + " " + c.EntityKey
+	switch c.Action {
+	case models.ChangeCreate:
+		return []string{"+ " + head + " " + diffSummary(c.After)}
+	case models.ChangeDelete:
+		return []string{"- " + head + " " + diffSummary(c.Before)}
+	}
+	var before, after map[string]json.RawMessage
+	if json.Unmarshal(c.Before, &before) != nil || json.Unmarshal(c.After, &after) != nil {
+		return []string{"~ " + head}
+	}
+	cols := make([]string, 0, len(after))
+	for col := range after {
+		if !slices.Contains(diffIgnored, col) && !bytes.Equal(before[col], after[col]) {
+			cols = append(cols, col)
+		}
+	}
+	slices.Sort(cols)
+	lines := make([]string, 0, len(cols))
+	for _, col := range cols {
+		lines = append(lines, fmt.Sprintf("~ %s: %s %s -> %s", head, col, before[col], after[col]))
+	}
+	return lines
+}
+
+// diffSummary is a created or deleted row on one line, without its timestamps.
+func diffSummary(row json.RawMessage) string {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(row, &fields) != nil {
+		return string(row)
+	}
+	for _, col := range diffIgnored {
+		delete(fields, col)
+	}
+	out, _ := json.Marshal(fields)
+	return string(out)
+}
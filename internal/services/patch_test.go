@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const samplePatchYAML = `
+id: 2024-ewc-koi
+description: Toronto played EWC as Toronto Koi
+ops:
+  - op: team_merge
+    team: Toronto Ultra
+    into: Toronto Koi
+    abbreviation: TK
+    game: MW3
+    create: true
+    tournaments: [esports-world-cup-2024]
+  - op: bracket
+    match: {tournament: esports-world-cup-2024, team1: Toronto Koi, team2: Team Falcons}
+    round: group_play_c_opening_match
+    position: 3
+  - op: match_upsert
+    match: {tournament: esports-world-cup-2024, team1: Toronto Koi, team2: Team Falcons, date: 2024-08-22}
+    set: {format: BO5, team1_score: 3, team2_score: 1}
+`
+
+const samplePatchJSON = `{
+  "id": "2024-ewc-koi",
+  "description": "Toronto played EWC as Toronto Koi",
+  "ops": [
+    {"op": "team_merge", "team": "Toronto Ultra", "into": "Toronto Koi", "abbreviation": "TK",
+     "game": "MW3", "create": true, "tournaments": ["esports-world-cup-2024"]},
+    {"op": "bracket", "match": {"tournament": "esports-world-cup-2024", "team1": "Toronto Koi", "team2": "Team Falcons"},
+     "round": "group_play_c_opening_match", "position": 3},
+    {"op": "match_upsert", "match": {"tournament": "esports-world-cup-2024", "team1": "Toronto Koi", "team2": "Team Falcons", "date": "2024-08-22"},
+     "set": {"format": "BO5", "team1_score": 3, "team2_score": 1}}
+  ]
+}`
+
+func TestParsePatch(t *testing.T) {
+	y, err := ParsePatch("0001-koi.yaml", []byte(samplePatchYAML))
+	require.NoError(t, err)
+	require.Len(t, y.Ops, 3)
+	assert.Equal(t, []string{"esports-world-cup-2024"}, y.Ops[0].Tournaments)
+	assert.Equal(t, 3, *y.Ops[2].Set.Team1Score)
+
+	j, err := ParsePatch("0001-koi.json", []byte(samplePatchJSON))
+	require.NoError(t, err)
+	assert.Equal(t, y, j)
+	assert.Equal(t, PatchChecksum(y), PatchChecksum(j), "the checksum is over content, not syntax")
+
+	j.Ops[1].Position = 4
+	assert.NotEqual(t, PatchChecksum(y), PatchChecksum(j))
+}
+
+func TestParsePatch_Invalid(t *testing.T) {
+	for name, src := range map[string]string{
+		"unknown field":    "id: x\nops:\n  - op: bracket\n    positon: 2\n",
+		"bad id":           "id: Fix Toronto\nops:\n  - op: team_rename\n    team: A\n    name: B\n",
+		"no ops":           "id: empty\n",
+		"unknown op":       "id: x\nops:\n  - op: team_delete\n    team: A\n",
+		"match ref":        "id: x\nops:\n  - op: match_delete\n    match: {tournament: t, team1: A}\n",
+		"bad date":         "id: x\nops:\n  - op: match_delete\n    match: {tournament: t, team1: A, team2: B, date: 22/08/2024}\n",
+		"upsert no set":    "id: x\nops:\n  - op: match_upsert\n    match: {tournament: t, team1: A, team2: B}\n",
+		"bad format":       "id: x\nops:\n  - op: match_upsert\n    match: {tournament: t, team1: A, team2: B}\n    set: {format: bestof5}\n",
+		"negative score":   "id: x\nops:\n  - op: match_upsert\n    match: {tournament: t, team1: A, team2: B}\n    set: {team1_score: -1}\n",
+		"bracket no round": "id: x\nops:\n  - op: bracket\n    match: {tournament: t, team1: A, team2: B}\n",
+		"self merge":       "id: x\nops:\n  - op: team_merge\n    team: A\n    into: A\n",
+	} {
+		_, err := ParsePatch("p.yaml", []byte(src))
+		assert.ErrorIs(t, err, ErrInvalidPatch, name)
+	}
+	_, err := ParsePatch("p.toml", []byte(samplePatchYAML))
+	assert.ErrorIs(t, err, ErrInvalidPatch, "unknown extension")
+}
+
+type mockPatchStore struct {
+	applied map[string]*models.AppliedPatch
+	runs    int
+}
+
+func (m *mockPatchStore) GetAppliedPatch(_ context.Context, id string) (*models.AppliedPatch, error) {
+	if a, ok := m.applied[id]; ok {
+		return a, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *mockPatchStore) ApplyPatch(_ context.Context, p *store.Patch, checksum string, dryRun bool) (*store.PatchResult, error) {
+	m.runs++
+	if !dryRun {
+		m.applied[p.ID] = &models.AppliedPatch{ID: p.ID, Checksum: checksum, AppliedAt: time.Now()}
+	}
+	return &store.PatchResult{Changes: []models.ChangeLog{{Entity: models.ChangeTeam, EntityKey: "9", Action: models.ChangeUpdate}}}, nil
+}
+
+func TestPatchService_RunOnce(t *testing.T) {
+	ms := &mockPatchStore{applied: map[string]*models.AppliedPatch{}}
+	ps := NewPatchService(ms)
+	ctx := context.Background()
+	p, err := ParsePatch("0001-koi.yaml", []byte(samplePatchYAML))
+	require.NoError(t, err)
+
+	run, err := ps.Run(ctx, p, true)
+	require.NoError(t, err)
+	assert.True(t, run.DryRun)
+	assert.Len(t, run.Changes, 1)
+	assert.Empty(t, ms.applied, "a dry run isn't recorded")
+
+	_, err = ps.Run(ctx, p, false)
+	require.NoError(t, err)
+	run, err = ps.Run(ctx, p, false)
+	require.NoError(t, err)
+	assert.True(t, run.Skipped)
+	assert.Equal(t, 2, ms.runs, "an applied patch isn't run again")
+
+	p.Ops[1].Position = 4
+	_, err = ps.Run(ctx, p, false)
+	assert.ErrorIs(t, err, ErrPatchChanged)
+}
+
+func TestDiffChange(t *testing.T) {
+	update := models.ChangeLog{
+		Entity: models.ChangeMatch, EntityKey: "12", Action: models.ChangeUpdate,
+		Before: json.RawMessage(`{"id":12,"team1_id":4,"bracket_position":0,"updated_at":"2024-01-01T00:00:00"}`),
+		After:  json.RawMessage(`{"id":12,"team1_id":9,"bracket_position":3,"updated_at":"2025-01-01T00:00:00"}`),
+	}
+	assert.Equal(t, []string{
+		"~ match 12: bracket_position 0 -> 3",
+		"~ match 12: team1_id 4 -> 9",
+	}, DiffChange(update))
+
+	del := models.ChangeLog{
+		Entity: models.ChangeMap, EntityKey: "12:3", Action: models.ChangeDelete,
+		Before: json.RawMessage(`{"map_number":3,"created_at":"2024-01-01T00:00:00"}`),
+	}
+	assert.Equal(t, []string{`- map 12:3 {"map_number":3}`}, DiffChange(del))
+}
@@ -0,0 +1,238 @@
+package services
+
+// perfrating.go — in-house per-map player performance rating.
+//
+// Every stat is compared to the average for the same era (GameCode) and, unless
+// disabled, the same mode, so 1.0 is an average map in that context and a 30-kill
+// Hardpoint in one title is comparable to a 30-kill Hardpoint in another. Stats an
+// era never recorded (average 0, e.g. damage or first bloods in older CSVs) drop out
+// and the remaining weights are rescaled, which keeps every era on the same scale.
+
+import (
+	"context"
+	"math"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+// PerformanceWeights sets how much each stat moves the rating. Weights are relative;
+// only their proportions matter. Deaths and FirstDeaths count against the player.
+// Objective is hill time in Hardpoint, plants + defuses in SnD and zone captures in Control.
+type PerformanceWeights struct {
+	Kills          float64 `json:"kills"`
+	Deaths         float64 `json:"deaths"`
+	Damage         float64 `json:"damage"`
+	Assists        float64 `json:"assists"`
+	FirstBloods    float64 `json:"first_bloods"`
+	FirstDeaths    float64 `json:"first_deaths"`
+	NonTradedKills float64 `json:"non_traded_kills"`
+	Objective      float64 `json:"objective"`
+	// NormalizeByMode compares each map to its era+mode average instead of the era average.
+	NormalizeByMode bool `json:"normalize_by_mode"`
+}
+
+func DefaultPerformanceWeights() PerformanceWeights {
+	return PerformanceWeights{
+		Kills:           0.25,
+		Deaths:          0.20,
+		Damage:          0.15,
+		Assists:         0.05,
+		FirstBloods:     0.10,
+		FirstDeaths:     0.05,
+		NonTradedKills:  0.10,
+		Objective:       0.10,
+		NormalizeByMode: true,
+	}
+}
+
+const (
+	perfKills = iota
+	perfDeaths
+	perfDamage
+	perfAssists
+	perfFirstBloods
+	perfFirstDeaths
+	perfNonTraded
+	perfObjective
+	perfStatCount
+)
+
+type perfVector [perfStatCount]float64
+
+// perfStatNames name each stat in performance_baselines. They match the weights' JSON
+// names, and the store's SQL that rates a rebuilt match relies on them.
+var perfStatNames = [perfStatCount]string{
+	perfKills:       "kills",
+	perfDeaths:      "deaths",
+	perfDamage:      "damage",
+	perfAssists:     "assists",
+	perfFirstBloods: "first_bloods",
+	perfFirstDeaths: "first_deaths",
+	perfNonTraded:   "non_traded_kills",
+	perfObjective:   "objective",
+}
+
+// perfModes are the mode keys normalizeMode produces.
+var perfModes = []string{"hp", "snd", "control", "other"}
+
+func perfStats(l store.MapStatLine, mode string) perfVector {
+	var objective int
+	switch mode {
+	case "hp":
+		objective = l.HillTime
+	case "snd":
+		objective = l.Plants + l.Defuses
+	case "control":
+		objective = l.Captures
+	}
+	return perfVector{
+		perfKills:       float64(l.Kills),
+		perfDeaths:      float64(l.Deaths),
+		perfDamage:      float64(l.Damage),
+		perfAssists:     float64(l.Assists),
+		perfFirstBloods: float64(l.FirstBloods),
+		perfFirstDeaths: float64(l.FirstDeaths),
+		perfNonTraded:   float64(l.NonTradedKills),
+		perfObjective:   float64(objective),
+	}
+}
+
+func (w PerformanceWeights) vector() perfVector {
+	return perfVector{
+		perfKills:       w.Kills,
+		perfDeaths:      -w.Deaths,
+		perfDamage:      w.Damage,
+		perfAssists:     w.Assists,
+		perfFirstBloods: w.FirstBloods,
+		perfFirstDeaths: -w.FirstDeaths,
+		perfNonTraded:   w.NonTradedKills,
+		perfObjective:   w.Objective,
+	}
+}
+
+// perfRatingFloor keeps a disastrous map rated (0 is reserved for "not rated").
+const perfRatingFloor = 0.01
+
+type perfBaselineKey struct{ gameCode, mode string }
+
+// perfRated reports whether a line carries any data; empty lines (0 kills and 0
+// deaths) are data gaps and are neither rated nor counted in baselines.
+func perfRated(l store.MapStatLine) bool {
+	return l.Kills > 0 || l.Deaths > 0
+}
+
+func (w PerformanceWeights) baselineKey(l store.MapStatLine) (perfBaselineKey, string) {
+	mode := normalizeMode(l.Mode)
+	if !w.NormalizeByMode {
+		return perfBaselineKey{gameCode: l.GameCode}, mode
+	}
+	return perfBaselineKey{gameCode: l.GameCode, mode: mode}, mode
+}
+
+// perfBaselines averages every stat per era (and mode) over rated lines.
+func perfBaselines(lines []store.MapStatLine, w PerformanceWeights) map[perfBaselineKey]perfVector {
+	sums := map[perfBaselineKey]*perfVector{}
+	counts := map[perfBaselineKey]int{}
+	for _, l := range lines {
+		if !perfRated(l) {
+			continue
+		}
+		key, mode := w.baselineKey(l)
+		if sums[key] == nil {
+			sums[key] = &perfVector{}
+		}
+		v := perfStats(l, mode)
+		for i := range v {
+			sums[key][i] += v[i]
+		}
+		counts[key]++
+	}
+	out := make(map[perfBaselineKey]perfVector, len(sums))
+	for key, sum := range sums {
+		var avg perfVector
+		for i := range sum {
+			avg[i] = sum[i] / float64(counts[key])
+		}
+		out[key] = avg
+	}
+	return out
+}
+
+// ratePerformance scores one line against its baseline: 1 plus the weighted mean of
+// each stat's relative deviation from average, floored at perfRatingFloor. It returns
+// 0 when the baseline has no usable stat at all.
+func ratePerformance(stats, baseline perfVector, w PerformanceWeights) float64 {
+	weights := w.vector()
+	var sum, used float64
+	for i := range stats {
+		if baseline[i] <= 0 || weights[i] == 0 {
+			continue
+		}
+		sum += weights[i] * (stats[i]/baseline[i] - 1)
+		used += math.Abs(weights[i])
+	}
+	if used == 0 {
+		return 0
+	}
+	return math.Max(perfRatingFloor, 1+sum/used)
+}
+
+type PerformanceRatingService struct {
+	store   store.PerformanceStore
+	weights PerformanceWeights
+}
+
+func NewPerformanceRatingService(s store.PerformanceStore, w PerformanceWeights) *PerformanceRatingService {
+	return &PerformanceRatingService{store: s, weights: w}
+}
+
+// Recompute rates every map line and rolls the ratings up to match and tournament
+// level. It returns the number of map lines rated.
+func (ps *PerformanceRatingService) Recompute(ctx context.Context) (int, error) {
+	lines, err := ps.store.ListMapStatLines(ctx)
+	if err != nil {
+		return 0, err
+	}
+	baselines := perfBaselines(lines, ps.weights)
+
+	ratings := make([]store.MapRating, 0, len(lines))
+	for _, l := range lines {
+		if !perfRated(l) {
+			continue
+		}
+		key, mode := ps.weights.baselineKey(l)
+		r := ratePerformance(perfStats(l, mode), baselines[key], ps.weights)
+		if r == 0 {
+			continue
+		}
+		ratings = append(ratings, store.MapRating{ID: l.ID, Rating: math.Round(r*10000) / 10000})
+	}
+	if err := ps.store.SaveRatings(ctx, ratings, ps.weights.baselineRows(baselines)); err != nil {
+		return 0, err
+	}
+	return len(ratings), nil
+}
+
+// baselineRows flattens the baselines into performance_baselines rows, one per era,
+// mode and stat. An era-wide baseline is repeated under every mode so the store can
+// always look lines up by era and mode.
+func (w PerformanceWeights) baselineRows(baselines map[perfBaselineKey]perfVector) []models.PerformanceBaseline {
+	weights := w.vector()
+	rows := make([]models.PerformanceBaseline, 0, len(baselines)*perfStatCount)
+	for key, avg := range baselines {
+		modes := []string{key.mode}
+		if !w.NormalizeByMode {
+			modes = perfModes
+		}
+		for _, mode := range modes {
+			for i := range avg {
+				rows = append(rows, models.PerformanceBaseline{
+					GameCode: key.gameCode, Mode: mode, Stat: perfStatNames[i],
+					Average: avg[i], Weight: weights[i],
+				})
+			}
+		}
+	}
+	return rows
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPerformanceStore struct {
+	lines     []store.MapStatLine
+	saved     []store.MapRating
+	baselines []models.PerformanceBaseline
+}
+
+func (m *mockPerformanceStore) ListMapStatLines(context.Context) ([]store.MapStatLine, error) {
+	return m.lines, nil
+}
+
+func (m *mockPerformanceStore) SaveRatings(_ context.Context, ratings []store.MapRating, baselines []models.PerformanceBaseline) error {
+	m.saved, m.baselines = ratings, baselines
+	return nil
+}
+
+func TestRatePerformance_AverageIsOne(t *testing.T) {
+	w := DefaultPerformanceWeights()
+	avg := perfVector{20, 20, 3000, 5, 2, 2, 10, 60}
+	assert.InDelta(t, 1.0, ratePerformance(avg, avg, w), 1e-9)
+
+	better := avg
+	better[perfKills] = 30
+	assert.Greater(t, ratePerformance(better, avg, w), 1.0)
+
+	worse := avg
+	worse[perfDeaths] = 30
+	assert.Less(t, ratePerformance(worse, avg, w), 1.0, "deaths count against the player")
+}
+
+func TestRatePerformance_MissingStatsDropOut(t *testing.T) {
+	w := DefaultPerformanceWeights()
+	// An era with only kills/deaths recorded: the other weights must not drag the rating.
+	baseline := perfVector{perfKills: 20, perfDeaths: 20}
+	stats := perfVector{perfKills: 20, perfDeaths: 20}
+	assert.InDelta(t, 1.0, ratePerformance(stats, baseline, w), 1e-9)
+
+	assert.Equal(t, 0.0, ratePerformance(stats, perfVector{}, w), "no usable baseline means unrated")
+}
+
+func TestPerformanceRecompute_NormalisesByEraAndMode(t *testing.T) {
+	s := &mockPerformanceStore{lines: []store.MapStatLine{
+		// Two eras with very different kill volumes: the average player in each rates 1.0.
+		{ID: 1, GameCode: "IW", Mode: "Hardpoint", Kills: 30, Deaths: 30},
+		{ID: 2, GameCode: "IW", Mode: "Hardpoint", Kills: 20, Deaths: 20},
+		{ID: 3, GameCode: "BO6", Mode: "Hardpoint", Kills: 25, Deaths: 25, Damage: 4000},
+		{ID: 4, GameCode: "BO6", Mode: "Search and Destroy", Kills: 8, Deaths: 6, Plants: 2},
+		{ID: 5, GameCode: "BO6", Mode: "Search and Destroy", Kills: 4, Deaths: 6},
+		// Data gap: neither rated nor part of the baseline.
+		{ID: 6, GameCode: "BO6", Mode: "Hardpoint"},
+	}}
+	n, err := NewPerformanceRatingService(s, DefaultPerformanceWeights()).Recompute(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	byID := map[uint]float64{}
+	for _, r := range s.saved {
+		byID[r.ID] = r.Rating
+	}
+	assert.NotContains(t, byID, uint(6))
+	assert.Greater(t, byID[1], 1.0)
+	assert.Less(t, byID[2], 1.0)
+	assert.InDelta(t, 1.0, byID[3], 1e-9, "sole BO6 HP line is exactly the BO6 HP average")
+	assert.Greater(t, byID[4], byID[5])
+}
+
+func TestPerformanceRecompute_SavesBaselines(t *testing.T) {
+	lines := []store.MapStatLine{
+		{ID: 1, GameCode: "BO6", Mode: "Hardpoint", Kills: 30, Deaths: 20, HillTime: 60},
+		{ID: 2, GameCode: "BO6", Mode: "Hardpoint", Kills: 20, Deaths: 20, HillTime: 40},
+	}
+	baseline := func(rows []models.PerformanceBaseline, mode, stat string) (models.PerformanceBaseline, bool) {
+		for _, b := range rows {
+			if b.GameCode == "BO6" && b.Mode == mode && b.Stat == stat {
+				return b, true
+			}
+		}
+		return models.PerformanceBaseline{}, false
+	}
+
+	s := &mockPerformanceStore{lines: lines}
+	_, err := NewPerformanceRatingService(s, DefaultPerformanceWeights()).Recompute(context.Background())
+	require.NoError(t, err)
+	require.Len(t, s.baselines, perfStatCount, "one row per stat for the single era+mode")
+	kills, ok := baseline(s.baselines, "hp", "kills")
+	require.True(t, ok)
+	assert.InDelta(t, 25.0, kills.Average, 1e-9)
+	assert.InDelta(t, 0.25, kills.Weight, 1e-9)
+	deaths, _ := baseline(s.baselines, "hp", "deaths")
+	assert.InDelta(t, -0.20, deaths.Weight, 1e-9, "deaths count against the player")
+	objective, _ := baseline(s.baselines, "hp", "objective")
+	assert.InDelta(t, 50.0, objective.Average, 1e-9)
+
+	w := DefaultPerformanceWeights()
+	w.NormalizeByMode = false
+	s = &mockPerformanceStore{lines: lines}
+	_, err = NewPerformanceRatingService(s, w).Recompute(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, s.baselines, len(perfModes)*perfStatCount, "an era-wide baseline is stored under every mode")
+	snd, ok := baseline(s.baselines, "snd", "kills")
+	require.True(t, ok)
+	assert.InDelta(t, 25.0, snd.Average, 1e-9)
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
+)
+
+var ErrPicksLocked = errors.New("picks for this match are locked")
+var ErrInvalidPick = errors.New("invalid pick")
+var ErrInvalidTournament = errors.New("invalid tournament")
+
+// PickemConfig sets how many points a decided pick earns: WinnerPoints for the right
+// series winner, plus ExactScorePoints when the map score is also right.
+type PickemConfig struct {
+	WinnerPoints     int
+	ExactScorePoints int
+}
+
+func DefaultPickemConfig() PickemConfig {
+	return PickemConfig{WinnerPoints: 3, ExactScorePoints: 2}
+}
+
+// PickInput is the body of PUT /matches/:id/pick.
+type PickInput struct {
+	WinnerID   uint `json:"winner_id"`
+	Team1Score int  `json:"team1_score"`
+	Team2Score int  `json:"team2_score"`
+}
+
+// PickemLeaderboard is the /pickem/leaderboard response; exactly one of Tournament
+// and Season is set.
+type PickemLeaderboard struct {
+	Tournament *models.Tournament `json:"tournament,omitempty"`
+	Season     *models.Season     `json:"season,omitempty"`
+	Users      []store.PickemRow  `json:"users"`
+}
+
+type PickemService struct {
+	store       store.PickemStore
+	seasons     store.SeasonStore
+	tournaments store.TournamentStore
+	cfg         PickemConfig
+	now         func() time.Time
+}
+
+func NewPickemService(s store.PickemStore, seasons store.SeasonStore, tournaments store.TournamentStore, cfg PickemConfig) *PickemService {
+	return &PickemService{store: s, seasons: seasons, tournaments: tournaments, cfg: cfg, now: time.Now}
+}
+
+// picksOpen reports whether m still takes picks at t: it is undecided and has not
+// started. Matches seeded without a date are never open.
+func picksOpen(m *models.Match, t time.Time) bool {
+	return m.WinnerID == nil && t.Before(m.MatchDate)
+}
+
+// validPick checks the pick names one of the two teams and a finished series score
+// for the match format, with the picked winner on the winning side.
+func validPick(m *models.Match, in PickInput) bool {
+	need := bestOf(m.Format)/2 + 1
+	switch in.WinnerID {
+	case m.Team1ID:
+		return in.Team1Score == need && in.Team2Score >= 0 && in.Team2Score < need
+	case m.Team2ID:
+		return in.Team2Score == need && in.Team1Score >= 0 && in.Team1Score < need
+	}
+	return false
+}
+
+// scorePick returns the points a pick earns against a decided match, or nil when
+// the match has lost its winner and the pick is unscored again.
+func (ps *PickemService) scorePick(p store.ScorablePick) *int {
+	if p.MatchWinnerID == 0 {
+		return nil
+	}
+	points := 0
+	if p.PickWinnerID == p.MatchWinnerID {
+		points = ps.cfg.WinnerPoints
+		if p.PickTeam1Score == p.MatchTeam1Score && p.PickTeam2Score == p.MatchTeam2Score {
+			points += ps.cfg.ExactScorePoints
+		}
+	}
+	return &points
+}
+
+// SubmitPick creates or replaces the user's pick for a match that is still open.
+func (ps *PickemService) SubmitPick(ctx context.Context, userID uint, matchID int, in PickInput) (*models.Pick, error) {
+	match, err := ps.store.GetMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if !picksOpen(match, ps.now()) {
+		return nil, ErrPicksLocked
+	}
+	if !validPick(match, in) {
+		return nil, ErrInvalidPick
+	}
+	pick := &models.Pick{
+		UserID:     userID,
+		MatchID:    match.ID,
+		WinnerID:   in.WinnerID,
+		Team1Score: in.Team1Score,
+		Team2Score: in.Team2Score,
+	}
+	if err := ps.store.SavePick(ctx, pick); err != nil {
+		return nil, err
+	}
+	return pick, nil
+}
+
+// ScorePicks scores every pick whose match has been decided (or whose result changed
+// since it was scored), unscores picks whose match lost its winner, and returns how
+// many it scored or unscored. The notification worker
+// (cmd/notify) runs it every pass, so boards catch up within one -interval.
+func (ps *PickemService) ScorePicks(ctx context.Context) (int, error) {
+	pending, err := ps.store.ListScorablePicks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	scores := make([]store.PickScore, len(pending))
+	for i, p := range pending {
+		scores[i] = store.PickScore{PickID: p.PickID, Points: ps.scorePick(p)}
+	}
+	if err := ps.store.SavePickScores(ctx, scores, ps.now()); err != nil {
+		return 0, err
+	}
+	return len(scores), nil
+}
+
+// ListUserPicks pages through the user's picks, newest match first.
+func (ps *PickemService) ListUserPicks(ctx context.Context, userID uint, page, limit int) ([]models.Pick, int64, error) {
+	return ps.store.ListUserPicks(ctx, userID, limit, (page-1)*limit)
+}
+
+// GetLeaderboard ranks users by points in a tournament, or in a season when
+// tournamentID is empty; an empty seasonID as well means the active season.
+func (ps *PickemService) GetLeaderboard(ctx context.Context, tournamentID, seasonID string, page, limit int) (*PickemLeaderboard, int64, error) {
+	board := &PickemLeaderboard{}
+	var scope store.PickemScope
+	if tournamentID != "" {
+		id, err := strconv.Atoi(tournamentID)
+		if err != nil {
+			return nil, 0, ErrInvalidTournament
+		}
+		tournament, err := ps.tournaments.GetByID(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrInvalidTournament
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("tournament %d: %w", id, err)
+		}
+		board.Tournament = tournament
+		scope.TournamentID = tournament.ID
+	} else {
+		season, err := resolveSeason(ctx, ps.seasons, seasonID)
+		if err != nil {
+			return nil, 0, err
+		}
+		board.Season = season
+		scope.SeasonID = season.ID
+	}
+
+	rows, total, err := ps.store.ListLeaderboard(ctx, scope, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	board.Users = rows
+	return board, total, nil
+}
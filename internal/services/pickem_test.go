@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPickemStore struct {
+	match    *models.Match
+	saved    []models.Pick
+	scorable []store.ScorablePick
+	scores   []store.PickScore
+}
+
+func (m *mockPickemStore) GetMatch(context.Context, int) (*models.Match, error) { return m.match, nil }
+func (m *mockPickemStore) SavePick(_ context.Context, p *models.Pick) error {
+	m.saved = append(m.saved, *p)
+	return nil
+}
+func (m *mockPickemStore) ListScorablePicks(context.Context) ([]store.ScorablePick, error) {
+	return m.scorable, nil
+}
+func (m *mockPickemStore) SavePickScores(_ context.Context, scores []store.PickScore, _ time.Time) error {
+	m.scores = scores
+	return nil
+}
+func (m *mockPickemStore) ListUserPicks(context.Context, uint, int, int) ([]models.Pick, int64, error) {
+	return nil, 0, nil
+}
+func (m *mockPickemStore) ListLeaderboard(context.Context, store.PickemScope, int, int) ([]store.PickemRow, int64, error) {
+	return nil, 0, nil
+}
+
+func TestValidPick(t *testing.T) {
+	bo5 := &models.Match{Team1ID: 1, Team2ID: 2, Format: "BO5"}
+	tests := []struct {
+		name string
+		in   PickInput
+		want bool
+	}{
+		{"team1 sweep", PickInput{WinnerID: 1, Team1Score: 3, Team2Score: 0}, true},
+		{"team2 in five", PickInput{WinnerID: 2, Team1Score: 2, Team2Score: 3}, true},
+		{"score favours the other team", PickInput{WinnerID: 1, Team1Score: 2, Team2Score: 3}, false},
+		{"unfinished series", PickInput{WinnerID: 1, Team1Score: 2, Team2Score: 1}, false},
+		{"too many maps", PickInput{WinnerID: 1, Team1Score: 4, Team2Score: 0}, false},
+		{"negative loser score", PickInput{WinnerID: 1, Team1Score: 3, Team2Score: -1}, false},
+		{"team not in match", PickInput{WinnerID: 9, Team1Score: 3, Team2Score: 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, validPick(bo5, tt.in))
+		})
+	}
+	assert.True(t, validPick(&models.Match{Team1ID: 1, Team2ID: 2, Format: "BO3"}, PickInput{WinnerID: 2, Team1Score: 1, Team2Score: 2}))
+}
+
+func TestSubmitPick_LocksAtStart(t *testing.T) {
+	start := time.Date(2025, 4, 5, 18, 0, 0, 0, time.UTC)
+	ms := &mockPickemStore{match: &models.Match{ID: 7, Team1ID: 1, Team2ID: 2, Format: "BO5", MatchDate: start}}
+	ps := NewPickemService(ms, nil, nil, DefaultPickemConfig())
+	in := PickInput{WinnerID: 2, Team1Score: 1, Team2Score: 3}
+
+	ps.now = func() time.Time { return start.Add(-time.Minute) }
+	pick, err := ps.SubmitPick(context.Background(), 4, 7, in)
+	require.NoError(t, err)
+	assert.Equal(t, models.Pick{UserID: 4, MatchID: 7, WinnerID: 2, Team1Score: 1, Team2Score: 3}, *pick)
+
+	ps.now = func() time.Time { return start }
+	_, err = ps.SubmitPick(context.Background(), 4, 7, in)
+	assert.ErrorIs(t, err, ErrPicksLocked)
+
+	ps.now = func() time.Time { return start.Add(-time.Hour) }
+	ms.match.WinnerID = uptr(1)
+	_, err = ps.SubmitPick(context.Background(), 4, 7, in)
+	assert.ErrorIs(t, err, ErrPicksLocked, "a decided match is locked even if its date is in the future")
+
+	ms.match.WinnerID = nil
+	_, err = ps.SubmitPick(context.Background(), 4, 7, PickInput{WinnerID: 2, Team1Score: 3, Team2Score: 1})
+	assert.ErrorIs(t, err, ErrInvalidPick)
+	assert.Len(t, ms.saved, 1)
+}
+
+func TestScorePicks(t *testing.T) {
+	ms := &mockPickemStore{scorable: []store.ScorablePick{
+		{PickID: 1, PickWinnerID: 1, PickTeam1Score: 3, PickTeam2Score: 1, MatchWinnerID: 1, MatchTeam1Score: 3, MatchTeam2Score: 1},
+		{PickID: 2, PickWinnerID: 1, PickTeam1Score: 3, PickTeam2Score: 0, MatchWinnerID: 1, MatchTeam1Score: 3, MatchTeam2Score: 1},
+		{PickID: 3, PickWinnerID: 2, PickTeam1Score: 1, PickTeam2Score: 3, MatchWinnerID: 1, MatchTeam1Score: 3, MatchTeam2Score: 1},
+		{PickID: 4, PickWinnerID: 1, PickTeam1Score: 3, PickTeam2Score: 1, MatchTeam1Score: 2, MatchTeam2Score: 1},
+	}}
+	ps := NewPickemService(ms, nil, nil, DefaultPickemConfig())
+
+	n, err := ps.ScorePicks(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	points := func(n int) *int { return &n }
+	assert.Equal(t, []store.PickScore{
+		{PickID: 1, Points: points(5)}, {PickID: 2, Points: points(3)}, {PickID: 3, Points: points(0)},
+		{PickID: 4, Points: nil},
+	}, ms.scores, "a match that lost its winner takes its picks' points back")
+}
+
+type failingTournamentStore struct {
+	mockTournamentStore
+	err error
+}
+
+func (m *failingTournamentStore) GetByID(context.Context, int) (*models.Tournament, error) {
+	return nil, m.err
+}
+
+func TestGetLeaderboard_TournamentErrors(t *testing.T) {
+	ctx := context.Background()
+	ms := &mockPickemStore{scorable: []store.ScorablePick{{PickID: 1, PickWinnerID: 1, MatchWinnerID: 1}}}
+	ps := NewPickemService(ms, nil, &mockTournamentStore{tournaments: map[int]models.Tournament{3: {ID: 3}}}, DefaultPickemConfig())
+
+	board, _, err := ps.GetLeaderboard(ctx, "3", "", 1, 25)
+	require.NoError(t, err)
+	assert.Equal(t, uint(3), board.Tournament.ID)
+	assert.Nil(t, ms.scores, "reading the board does not score picks")
+
+	_, _, err = ps.GetLeaderboard(ctx, "4", "", 1, 25)
+	assert.ErrorIs(t, err, ErrInvalidTournament)
+	_, _, err = ps.GetLeaderboard(ctx, "x", "", 1, 25)
+	assert.ErrorIs(t, err, ErrInvalidTournament)
+
+	dbErr := errors.New("connection reset")
+	ps.tournaments = &failingTournamentStore{err: dbErr}
+	_, _, err = ps.GetLeaderboard(ctx, "3", "", 1, 25)
+	assert.ErrorIs(t, err, dbErr)
+	assert.NotErrorIs(t, err, ErrInvalidTournament, "a database failure is not the caller's fault")
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+// PredictionConfig tunes how series-level ratings become map probabilities.
+//
+// A map is closer to a coin flip than a best-of-five, so the series log-odds from the
+// Glicko-2 ratings are scaled by MapLogitScale. Each team then carries a per-mode
+// residual — maps won minus maps expected, shrunk toward zero by ModeShrinkage
+// pseudo-maps — and the difference of the two residuals moves the map log-odds by
+// ModeWeight per unit.
+type PredictionConfig struct {
+	MapLogitScale float64 `json:"map_logit_scale"`
+	ModeShrinkage float64 `json:"mode_shrinkage"`
+	ModeWeight    float64 `json:"mode_weight"`
+}
+
+func DefaultPredictionConfig() PredictionConfig {
+	return PredictionConfig{
+		MapLogitScale: 0.6,
+		ModeShrinkage: 10,
+		ModeWeight:    4,
+	}
+}
+
+// predictionModes are the modes the model keeps residuals for, in API order.
+var predictionModes = []string{"hp", "snd", "control"}
+
+// probClamp keeps predictions away from 0 and 1 so a single upset can't blow up log loss.
+const probClamp = 0.01
+
+func logit(p float64) float64 {
+	p = math.Min(math.Max(p, 1e-9), 1-1e-9)
+	return math.Log(p / (1 - p))
+}
+
+func logistic(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+
+func clampProb(p float64) float64 { return math.Min(math.Max(p, probClamp), 1-probClamp) }
+
+type modeResidual struct {
+	maps     int
+	residual float64
+}
+
+// predictor holds the running per-team, per-mode residuals. Teams are per era, so
+// keying on team ID alone keeps eras apart.
+type predictor struct {
+	cfg   PredictionConfig
+	teams map[uint]map[string]*modeResidual
+}
+
+func newPredictor(cfg PredictionConfig) *predictor {
+	return &predictor{cfg: cfg, teams: map[uint]map[string]*modeResidual{}}
+}
+
+func (p *predictor) record(teamID uint, mode string) *modeResidual {
+	modes := p.teams[teamID]
+	if modes == nil {
+		modes = map[string]*modeResidual{}
+		p.teams[teamID] = modes
+	}
+	r := modes[mode]
+	if r == nil {
+		r = &modeResidual{}
+		modes[mode] = r
+	}
+	return r
+}
+
+// adjustment is the team's shrunk residual in a mode.
+func (p *predictor) adjustment(teamID uint, mode string) float64 {
+	r := p.teams[teamID][mode]
+	if r == nil {
+		return 0
+	}
+	return r.residual / (float64(r.maps) + p.cfg.ModeShrinkage)
+}
+
+func (p *predictor) baseMap(series float64) float64 {
+	return logistic(p.cfg.MapLogitScale * logit(series))
+}
+
+// mapProb is the probability team1 wins a map of the given mode when its series win
+// probability is series.
+func (p *predictor) mapProb(series float64, team1, team2 uint, mode string) float64 {
+	x := p.cfg.MapLogitScale*logit(series) +
+		p.cfg.ModeWeight*(p.adjustment(team1, mode)-p.adjustment(team2, mode))
+	return clampProb(logistic(x))
+}
+
+// observe folds a played map into both teams' residuals. Residuals are measured against
+// the rating-only map probability so they don't feed back into themselves.
+func (p *predictor) observe(series float64, team1, team2 uint, mode string, team1Won bool) {
+	base := p.baseMap(series)
+	won := 0.0
+	if team1Won {
+		won = 1
+	}
+	r1, r2 := p.record(team1, mode), p.record(team2, mode)
+	r1.maps++
+	r1.residual += won - base
+	r2.maps++
+	r2.residual += base - won
+}
+
+// PredictedTeam is one side of a prediction. RatingSource says where Rating came from:
+// "pre_series", "current" or "initial".
+type PredictedTeam struct {
+	TeamID         uint    `json:"team_id"`
+	Name           string  `json:"name"`
+	Abbreviation   string  `json:"abbreviation"`
+	Rating         float64 `json:"rating"`
+	RD             float64 `json:"rd"`
+	RatingSource   string  `json:"rating_source"`
+	WinProbability float64 `json:"win_probability"`
+}
+
+// ModePrediction is the map win probability in one mode, with the prior map counts the
+// mode adjustment was fitted from.
+type ModePrediction struct {
+	Mode                string  `json:"mode"`
+	Team1WinProbability float64 `json:"team1_win_probability"`
+	Team2WinProbability float64 `json:"team2_win_probability"`
+	Team1PriorMaps      int     `json:"team1_prior_maps"`
+	Team2PriorMaps      int     `json:"team2_prior_maps"`
+}
+
+type MapPrediction struct {
+	MapNumber           int     `json:"map_number"`
+	MapName             string  `json:"map_name"`
+	Mode                string  `json:"mode"`
+	Team1WinProbability float64 `json:"team1_win_probability"`
+	Team2WinProbability float64 `json:"team2_win_probability"`
+	WinnerID            *uint   `json:"winner_id"`
+}
+
+// MatchPrediction is the /matches/:id/prediction response. Everything is computed from
+// data before the series; WinnerID is the actual result, when there is one.
+type MatchPrediction struct {
+	MatchID  uint             `json:"match_id"`
+	GameCode string           `json:"game_code"`
+	PlayedAt time.Time        `json:"played_at"`
+	Team1    PredictedTeam    `json:"team1"`
+	Team2    PredictedTeam    `json:"team2"`
+	Modes    []ModePrediction `json:"modes"`
+	Maps     []MapPrediction  `json:"maps"`
+	WinnerID *uint            `json:"winner_id"`
+}
+
+type PredictionService struct {
+	store   store.PredictionStore
+	matches store.MatchStore
+	rating  RatingConfig
+	cfg     PredictionConfig
+}
+
+func NewPredictionService(s store.PredictionStore, matches store.MatchStore, rating RatingConfig, cfg PredictionConfig) *PredictionService {
+	return &PredictionService{store: s, matches: matches, rating: rating, cfg: cfg}
+}
+
+func (ps *PredictionService) predictedTeam(id uint, name, abbr string, rating, rd *float64, source string) PredictedTeam {
+	t := PredictedTeam{TeamID: id, Name: name, Abbreviation: abbr, RatingSource: source,
+		Rating: ps.rating.InitialRating, RD: ps.rating.InitialRD}
+	if rating != nil && rd != nil {
+		t.Rating, t.RD = *rating, *rd
+	}
+	return t
+}
+
+func (ps *PredictionService) Predict(ctx context.Context, matchID int) (*MatchPrediction, error) {
+	m, err := ps.store.GetPredictionMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+	prior, err := ps.store.ListPriorMaps(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	maps, err := ps.matches.GetMaps(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	t1 := ps.predictedTeam(m.Team1ID, m.Team1Name, m.Team1Abbr, m.Team1Rating, m.Team1RD, m.Team1RatingSource)
+	t2 := ps.predictedTeam(m.Team2ID, m.Team2Name, m.Team2Abbr, m.Team2Rating, m.Team2RD, m.Team2RatingSource)
+	series := glickoExpected(glickoRating{Rating: t1.Rating, RD: t1.RD}, glickoRating{Rating: t2.Rating, RD: t2.RD})
+	t1.WinProbability, t2.WinProbability = series, 1-series
+
+	pred := newPredictor(ps.cfg)
+	for _, r := range prior {
+		mode := normalizeMode(r.Mode)
+		if mode == "other" || r.Team1Rating == nil || r.Team2Rating == nil {
+			continue
+		}
+		p := glickoExpected(
+			glickoRating{Rating: *r.Team1Rating, RD: *r.Team1RD},
+			glickoRating{Rating: *r.Team2Rating, RD: *r.Team2RD},
+		)
+		pred.observe(p, r.Team1ID, r.Team2ID, mode, *r.WinnerID == r.Team1ID)
+	}
+
+	result := &MatchPrediction{
+		MatchID:  m.MatchID,
+		GameCode: m.GameCode,
+		PlayedAt: m.PlayedAt,
+		Team1:    t1,
+		Team2:    t2,
+		Modes:    make([]ModePrediction, 0, len(predictionModes)),
+		Maps:     make([]MapPrediction, 0, len(maps)),
+		WinnerID: m.WinnerID,
+	}
+	for _, mode := range predictionModes {
+		p := pred.mapProb(series, m.Team1ID, m.Team2ID, mode)
+		mp := ModePrediction{Mode: mode, Team1WinProbability: p, Team2WinProbability: 1 - p}
+		if r := pred.teams[m.Team1ID][mode]; r != nil {
+			mp.Team1PriorMaps = r.maps
+		}
+		if r := pred.teams[m.Team2ID][mode]; r != nil {
+			mp.Team2PriorMaps = r.maps
+		}
+		result.Modes = append(result.Modes, mp)
+	}
+	for _, mm := range maps {
+		mode := normalizeMode(mm.Mode)
+		p := pred.baseMap(series)
+		if mode != "other" {
+			p = pred.mapProb(series, m.Team1ID, m.Team2ID, mode)
+		}
+		result.Maps = append(result.Maps, MapPrediction{
+			MapNumber:           mm.MapNumber,
+			MapName:             mm.MapName,
+			Mode:                mode,
+			Team1WinProbability: p,
+			Team2WinProbability: 1 - p,
+			WinnerID:            mm.WinnerID,
+		})
+	}
+	return result, nil
+}
+
+// CalibrationBin groups predictions whose probability fell in [Lower, Upper).
+type CalibrationBin struct {
+	Lower         float64 `json:"lower"`
+	Upper         float64 `json:"upper"`
+	Count         int     `json:"count"`
+	MeanPredicted float64 `json:"mean_predicted"`
+	ObservedRate  float64 `json:"observed_rate"`
+}
+
+// ScoreCard is how well a set of probabilistic predictions matched outcomes. The
+// baselines are what always predicting 0.5 would score.
+type ScoreCard struct {
+	Count           int              `json:"count"`
+	Brier           float64          `json:"brier"`
+	LogLoss         float64          `json:"log_loss"`
+	Accuracy        float64          `json:"accuracy"`
+	BaselineBrier   float64          `json:"baseline_brier"`
+	BaselineLogLoss float64          `json:"baseline_log_loss"`
+	Calibration     []CalibrationBin `json:"calibration"`
+}
+
+// BacktestReport scores every historical series and map predicted from data before it.
+type BacktestReport struct {
+	Series ScoreCard            `json:"series"`
+	Maps   ScoreCard            `json:"maps"`
+	ByMode map[string]ScoreCard `json:"by_mode"`
+}
+
+type scoreAccumulator struct {
+	n, correct       int
+	brier, logLoss   float64
+	binN             []int
+	binP, binOutcome []float64
+}
+
+func newScoreAccumulator(bins int) *scoreAccumulator {
+	return &scoreAccumulator{binN: make([]int, bins), binP: make([]float64, bins), binOutcome: make([]float64, bins)}
+}
+
+func (a *scoreAccumulator) add(p float64, won bool) {
+	o := 0.0
+	if won {
+		o = 1
+	}
+	a.n++
+	a.brier += (p - o) * (p - o)
+	q := math.Min(math.Max(p, 1e-15), 1-1e-15)
+	a.logLoss -= o*math.Log(q) + (1-o)*math.Log(1-q)
+	if (p >= 0.5) == won {
+		a.correct++
+	}
+	b := int(p * float64(len(a.binN)))
+	if b >= len(a.binN) {
+		b = len(a.binN) - 1
+	}
+	a.binN[b]++
+	a.binP[b] += p
+	a.binOutcome[b] += o
+}
+
+func (a *scoreAccumulator) card() ScoreCard {
+	c := ScoreCard{Count: a.n, BaselineBrier: 0.25, BaselineLogLoss: math.Ln2, Calibration: []CalibrationBin{}}
+	if a.n == 0 {
+		return c
+	}
+	n := float64(a.n)
+	c.Brier = a.brier / n
+	c.LogLoss = a.logLoss / n
+	c.Accuracy = float64(a.correct) / n
+	width := 1 / float64(len(a.binN))
+	for i, count := range a.binN {
+		if count == 0 {
+			continue
+		}
+		c.Calibration = append(c.Calibration, CalibrationBin{
+			Lower:         float64(i) * width,
+			Upper:         float64(i+1) * width,
+			Count:         count,
+			MeanPredicted: a.binP[i] / float64(count),
+			ObservedRate:  a.binOutcome[i] / float64(count),
+		})
+	}
+	return c
+}
+
+// Backtest replays every series in rating order, predicting it and its maps from the
+// ratings and residuals built so far before folding its result in, so no prediction
+// sees its own outcome. matches must be in ListRatableMatches order; maps are matched
+// up by MatchID and their stored ratings ignored.
+func Backtest(matches []store.RatableMatch, maps []store.PredictionMapRow, rating RatingConfig, cfg PredictionConfig, bins int) *BacktestReport {
+	if bins < 1 {
+		bins = 10
+	}
+	byMatch := make(map[uint][]store.PredictionMapRow)
+	for _, r := range maps {
+		byMatch[r.MatchID] = append(byMatch[r.MatchID], r)
+	}
+
+	engine := newRatingEngine(rating, nil, 0)
+	pred := newPredictor(cfg)
+	seriesAcc, mapAcc := newScoreAccumulator(bins), newScoreAccumulator(bins)
+	modeAcc := map[string]*scoreAccumulator{}
+	for _, mode := range predictionModes {
+		modeAcc[mode] = newScoreAccumulator(bins)
+	}
+
+	for _, m := range matches {
+		s1 := engine.stateFor(m.Team1ID, m.Team1Franchise, m)
+		s2 := engine.stateFor(m.Team2ID, m.Team2Franchise, m)
+		series := glickoExpected(
+			glickoRating{Rating: s1.Rating, RD: s1.RD},
+			glickoRating{Rating: s2.Rating, RD: s2.RD},
+		)
+		seriesAcc.add(series, m.WinnerID == m.Team1ID)
+
+		played := byMatch[m.MatchID]
+		for _, r := range played {
+			mode := normalizeMode(r.Mode)
+			if mode == "other" {
+				continue
+			}
+			won := *r.WinnerID == m.Team1ID
+			p := pred.mapProb(series, m.Team1ID, m.Team2ID, mode)
+			mapAcc.add(p, won)
+			modeAcc[mode].add(p, won)
+		}
+		for _, r := range played {
+			if mode := normalizeMode(r.Mode); mode != "other" {
+				pred.observe(series, m.Team1ID, m.Team2ID, mode, *r.WinnerID == m.Team1ID)
+			}
+		}
+		engine.apply(m)
+	}
+
+	report := &BacktestReport{Series: seriesAcc.card(), Maps: mapAcc.card(), ByMode: map[string]ScoreCard{}}
+	for mode, acc := range modeAcc {
+		report.ByMode[mode] = acc.card()
+	}
+	return report
+}
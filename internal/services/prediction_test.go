@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPredictionStore struct {
+	match *store.PredictionMatch
+	prior []store.PredictionMapRow
+}
+
+func (m *mockPredictionStore) GetPredictionMatch(context.Context, int) (*store.PredictionMatch, error) {
+	return m.match, nil
+}
+func (m *mockPredictionStore) ListPriorMaps(context.Context, *store.PredictionMatch) ([]store.PredictionMapRow, error) {
+	return m.prior, nil
+}
+func (m *mockPredictionStore) ListAllMaps(context.Context) ([]store.PredictionMapRow, error) {
+	return nil, nil
+}
+
+func TestPredictor_ModeResidualShiftsMapProbability(t *testing.T) {
+	p := newPredictor(DefaultPredictionConfig())
+	for i := 0; i < 8; i++ {
+		p.observe(0.5, 1, 2, "snd", true)
+	}
+	base := p.baseMap(0.5)
+	assert.InDelta(t, 0.5, base, 1e-9)
+	assert.Greater(t, p.mapProb(0.5, 1, 2, "snd"), 0.8)
+	assert.InDelta(t, 0.5, p.mapProb(0.5, 1, 2, "hp"), 1e-9, "other modes are untouched")
+	assert.InDelta(t, 1-p.mapProb(0.5, 1, 2, "snd"), p.mapProb(0.5, 2, 1, "snd"), 1e-9)
+
+	// A map is less decisive than a series.
+	assert.Less(t, p.baseMap(0.8), 0.8)
+	assert.Greater(t, p.baseMap(0.8), 0.5)
+}
+
+func TestPredictionService_Predict(t *testing.T) {
+	ps := &mockPredictionStore{
+		match: &store.PredictionMatch{
+			MatchID: 50, GameCode: "BO6", Team1ID: 1, Team1Name: "OpTic Texas",
+			Team1Rating: fptr(1700), Team1RD: fptr(60), Team1RatingSource: "pre_series",
+			Team2ID: 2, Team2Name: "Atlanta FaZe", Team2RatingSource: "initial",
+		},
+		prior: []store.PredictionMapRow{
+			{MatchID: 40, MapNumber: 1, Mode: "Hardpoint", Team1ID: 2, Team2ID: 3, WinnerID: uptr(2),
+				Team1Rating: fptr(1500), Team1RD: fptr(100), Team2Rating: fptr(1500), Team2RD: fptr(100)},
+			{MatchID: 40, MapNumber: 2, Mode: "Hardpoint", Team1ID: 2, Team2ID: 3, WinnerID: uptr(2),
+				Team1Rating: fptr(1500), Team1RD: fptr(100), Team2Rating: fptr(1500), Team2RD: fptr(100)},
+			{MatchID: 41, MapNumber: 1, Mode: "Hardpoint", Team1ID: 1, Team2ID: 3, WinnerID: uptr(1)}, // unrated: skipped
+		},
+	}
+	ms := &mockMatchStore{matchMaps: []models.MatchMap{
+		{MatchID: 50, MapNumber: 1, MapName: "Skyline", Mode: "Hardpoint", WinnerID: uptr(1)},
+		{MatchID: 50, MapNumber: 2, MapName: "Rewind", Mode: "Search & Destroy"},
+	}}
+	svc := NewPredictionService(ps, ms, DefaultRatingConfig(), DefaultPredictionConfig())
+
+	res, err := svc.Predict(context.Background(), 50)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1500.0, res.Team2.Rating, "unrated team falls back to the initial rating")
+	assert.Equal(t, 350.0, res.Team2.RD)
+	assert.Greater(t, res.Team1.WinProbability, 0.5)
+	assert.InDelta(t, 1, res.Team1.WinProbability+res.Team2.WinProbability, 1e-9)
+
+	require.Len(t, res.Modes, 3)
+	hp, snd := res.Modes[0], res.Modes[1]
+	assert.Equal(t, "hp", hp.Mode)
+	assert.Equal(t, 0, hp.Team1PriorMaps)
+	assert.Equal(t, 2, hp.Team2PriorMaps)
+	assert.Less(t, hp.Team1WinProbability, snd.Team1WinProbability, "Team2's Hardpoint form narrows the gap")
+
+	require.Len(t, res.Maps, 2)
+	assert.Equal(t, "hp", res.Maps[0].Mode)
+	assert.Equal(t, hp.Team1WinProbability, res.Maps[0].Team1WinProbability)
+	assert.Equal(t, snd.Team1WinProbability, res.Maps[1].Team1WinProbability)
+}
+
+func TestBacktest_ScoresBeatBaselineOnConsistentResults(t *testing.T) {
+	start := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	var matches []store.RatableMatch
+	var maps []store.PredictionMapRow
+	for i := 0; i < 40; i++ {
+		id := uint(i + 1)
+		matches = append(matches, store.RatableMatch{
+			MatchID: id, Team1ID: 1, Team2ID: 2, WinnerID: 1, Team1Score: 3, Team2Score: 1,
+			SeasonID: 1, GameCode: "BO6", SeasonStart: start, PlayedAt: start.AddDate(0, 0, i),
+		})
+		for n, mode := range []string{"Hardpoint", "Search & Destroy", "Control", "Hardpoint"} {
+			winner := uint(1)
+			if n == 1 {
+				winner = 2 // Team 2 always takes the SnD
+			}
+			maps = append(maps, store.PredictionMapRow{MatchID: id, MapNumber: n + 1, Mode: mode,
+				Team1ID: 1, Team2ID: 2, WinnerID: uptr(winner)})
+		}
+	}
+
+	r := Backtest(matches, maps, DefaultRatingConfig(), DefaultPredictionConfig(), 10)
+
+	assert.Equal(t, 40, r.Series.Count)
+	assert.Equal(t, 160, r.Maps.Count)
+	assert.Equal(t, 40, r.ByMode["snd"].Count)
+	assert.Less(t, r.Series.Brier, r.Series.BaselineBrier)
+	assert.Less(t, r.Series.LogLoss, r.Series.BaselineLogLoss)
+	assert.InDelta(t, math.Ln2, r.Series.BaselineLogLoss, 1e-12)
+	assert.Less(t, r.ByMode["snd"].Brier, 0.25, "the mode residual learns Team 2's SnD edge")
+
+	total := 0
+	for _, b := range r.Series.Calibration {
+		total += b.Count
+		assert.GreaterOrEqual(t, b.MeanPredicted, b.Lower)
+		assert.Less(t, b.MeanPredicted, b.Upper)
+	}
+	assert.Equal(t, 40, total)
+}
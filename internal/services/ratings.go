@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
+)
+
+// RatingConfig tunes the Glicko-2 engine. Every series is its own rating period.
+//
+// CarryOver controls cross-era continuity: when a team plays its first series of a
+// new GameCode era, it starts from the franchise's (or its own) last rating with
+// CarryOver of the distance from InitialRating kept, and its RD pulled back toward
+// InitialRD by the same fraction. 0 starts every era from scratch.
+type RatingConfig struct {
+	InitialRating     float64
+	InitialRD         float64
+	InitialVolatility float64
+	Tau               float64
+	CarryOver         float64
+}
+
+func DefaultRatingConfig() RatingConfig {
+	return RatingConfig{
+		InitialRating:     glickoBase,
+		InitialRD:         350,
+		InitialVolatility: 0.06,
+		Tau:               0.5,
+		CarryOver:         0.5,
+	}
+}
+
+// ratingEngine walks decided series in order and keeps the running state per team.
+type ratingEngine struct {
+	cfg       RatingConfig
+	teams     map[uint]*models.TeamRating
+	franchise map[uint]*models.TeamRating // latest state seen for each franchise, any era
+	touched   map[uint]bool
+	seq       int
+}
+
+func newRatingEngine(cfg RatingConfig, current []models.TeamRating, seq int) *ratingEngine {
+	e := &ratingEngine{
+		cfg:       cfg,
+		teams:     make(map[uint]*models.TeamRating, len(current)),
+		franchise: map[uint]*models.TeamRating{},
+		touched:   map[uint]bool{},
+		seq:       seq,
+	}
+	for i := range current {
+		r := current[i]
+		r.ID = 0
+		e.teams[r.TeamID] = &r
+		e.trackFranchise(&r)
+	}
+	return e
+}
+
+func (e *ratingEngine) trackFranchise(r *models.TeamRating) {
+	if r.FranchiseID == nil {
+		return
+	}
+	prev, ok := e.franchise[*r.FranchiseID]
+	if !ok || r.LastMatchDate.After(prev.LastMatchDate) {
+		e.franchise[*r.FranchiseID] = r
+	}
+}
+
+// stateFor returns the team's rating entering a series in the given era, seeding a
+// fresh (or carried-over) state on the team's first series of that era.
+func (e *ratingEngine) stateFor(teamID uint, franchiseID *uint, m store.RatableMatch) *models.TeamRating {
+	cur, ok := e.teams[teamID]
+	if ok && cur.GameCode == m.GameCode {
+		return cur
+	}
+
+	prior := cur
+	if prior == nil && franchiseID != nil {
+		if f, ok := e.franchise[*franchiseID]; ok && f.GameCode != m.GameCode {
+			prior = f
+		}
+	}
+
+	next := &models.TeamRating{
+		TeamID:      teamID,
+		FranchiseID: franchiseID,
+		GameCode:    m.GameCode,
+		SeasonID:    m.SeasonID,
+		Rating:      e.cfg.InitialRating,
+		RD:          e.cfg.InitialRD,
+		Volatility:  e.cfg.InitialVolatility,
+	}
+	if prior != nil && e.cfg.CarryOver > 0 {
+		w := e.cfg.CarryOver
+		next.Rating = e.cfg.InitialRating + w*(prior.Rating-e.cfg.InitialRating)
+		next.RD = math.Min(e.cfg.InitialRD, prior.RD+(1-w)*(e.cfg.InitialRD-prior.RD))
+	}
+	e.teams[teamID] = next
+	return next
+}
+
+// apply rates one series and returns the two history rows it produced.
+func (e *ratingEngine) apply(m store.RatableMatch) []models.TeamRatingHistory {
+	t1 := e.stateFor(m.Team1ID, m.Team1Franchise, m)
+	t2 := e.stateFor(m.Team2ID, m.Team2Franchise, m)
+
+	r1 := glickoRating{Rating: t1.Rating, RD: t1.RD, Volatility: t1.Volatility}
+	r2 := glickoRating{Rating: t2.Rating, RD: t2.RD, Volatility: t2.Volatility}
+
+	s1 := 0.0
+	if m.WinnerID == m.Team1ID {
+		s1 = 1
+	}
+	n1 := glicko2Update(r1, []glickoResult{{Opponent: r2, Score: s1}}, e.cfg.Tau)
+	n2 := glicko2Update(r2, []glickoResult{{Opponent: r1, Score: 1 - s1}}, e.cfg.Tau)
+
+	rows := make([]models.TeamRatingHistory, 0, 2)
+	for _, side := range []struct {
+		state    *models.TeamRating
+		before   glickoRating
+		after    glickoRating
+		opponent uint
+		won      bool
+	}{
+		{t1, r1, n1, m.Team2ID, s1 == 1},
+		{t2, r2, n2, m.Team1ID, s1 == 0},
+	} {
+		e.seq++
+		rows = append(rows, models.TeamRatingHistory{
+			TeamID:       side.state.TeamID,
+			MatchID:      m.MatchID,
+			OpponentID:   side.opponent,
+			SeasonID:     m.SeasonID,
+			GameCode:     m.GameCode,
+			Sequence:     e.seq,
+			MatchDate:    m.PlayedAt,
+			Won:          side.won,
+			RatingBefore: side.before.Rating,
+			RatingAfter:  side.after.Rating,
+			RDBefore:     side.before.RD,
+			RDAfter:      side.after.RD,
+			Volatility:   side.after.Volatility,
+		})
+
+		st := side.state
+		st.Rating, st.RD, st.Volatility = side.after.Rating, side.after.RD, side.after.Volatility
+		st.SeasonID = m.SeasonID
+		st.MatchesPlayed++
+		if side.won {
+			st.Wins++
+		} else {
+			st.Losses++
+		}
+		st.LastMatchID = m.MatchID
+		st.LastMatchDate = m.PlayedAt
+		e.touched[st.TeamID] = true
+		e.trackFranchise(st)
+	}
+	return rows
+}
+
+// changed returns the current state of every team rated since the engine was built.
+func (e *ratingEngine) changed() []models.TeamRating {
+	out := make([]models.TeamRating, 0, len(e.touched))
+	for id := range e.touched {
+		out = append(out, *e.teams[id])
+	}
+	return out
+}
+
+// TeamRatingHistoryResult is the /teams/:id/rating-history response.
+type TeamRatingHistoryResult struct {
+	TeamID  int                        `json:"team_id"`
+	Current *models.TeamRating         `json:"current"`
+	History []models.TeamRatingHistory `json:"history"`
+}
+
+type RankedTeam struct {
+	Rank int `json:"rank"`
+	store.RankingRow
+}
+
+// SeasonRankings is the /rankings response: each team's rating after its last series of the season.
+type SeasonRankings struct {
+	SeasonID   uint         `json:"season_id"`
+	SeasonName string       `json:"season_name"`
+	GameCode   string       `json:"game_code"`
+	Teams      []RankedTeam `json:"teams"`
+}
+
+type RatingService struct {
+	store   store.RatingStore
+	seasons store.SeasonStore
+	cfg     RatingConfig
+}
+
+func NewRatingService(s store.RatingStore, seasons store.SeasonStore, cfg RatingConfig) *RatingService {
+	return &RatingService{store: s, seasons: seasons, cfg: cfg}
+}
+
+// Recompute rebuilds every rating from scratch and returns the number of series rated.
+func (rs *RatingService) Recompute(ctx context.Context) (int, error) {
+	matches, err := rs.store.ListRatableMatches(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	engine := newRatingEngine(rs.cfg, nil, 0)
+	history := make([]models.TeamRatingHistory, 0, 2*len(matches))
+	for _, m := range matches {
+		history = append(history, engine.apply(m)...)
+	}
+	if err := rs.store.ReplaceAll(ctx, engine.changed(), history); err != nil {
+		return 0, err
+	}
+	return len(matches), nil
+}
+
+// UpdateIncremental rates only series that have no history yet, continuing from the
+// stored state. If any of them sorts before the last rated series the chronology is
+// broken, so it falls back to a full Recompute.
+func (rs *RatingService) UpdateIncremental(ctx context.Context) (int, error) {
+	pending, err := rs.store.ListRatableMatches(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	latest, err := rs.store.GetLatestRatedMatch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if latest != nil && ratableBefore(pending[0], *latest) {
+		return rs.Recompute(ctx)
+	}
+
+	current, err := rs.store.ListCurrentRatings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := rs.store.GetMaxSequence(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	engine := newRatingEngine(rs.cfg, current, seq)
+	history := make([]models.TeamRatingHistory, 0, 2*len(pending))
+	for _, m := range pending {
+		history = append(history, engine.apply(m)...)
+	}
+	if err := rs.store.Append(ctx, engine.changed(), history); err != nil {
+		return 0, err
+	}
+	return len(pending), nil
+}
+
+// ratableBefore mirrors the ORDER BY of RatingStore.ListRatableMatches.
+func ratableBefore(a, b store.RatableMatch) bool {
+	if !a.SeasonStart.Equal(b.SeasonStart) {
+		return a.SeasonStart.Before(b.SeasonStart)
+	}
+	if !a.PlayedAt.Equal(b.PlayedAt) {
+		return a.PlayedAt.Before(b.PlayedAt)
+	}
+	return a.MatchID < b.MatchID
+}
+
+func (rs *RatingService) GetTeamHistory(ctx context.Context, teamID int) (*TeamRatingHistoryResult, error) {
+	current, err := rs.store.GetTeamRating(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := rs.store.ListTeamHistory(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	return &TeamRatingHistoryResult{TeamID: teamID, Current: current, History: history}, nil
+}
+
+// GetRankings ranks teams by rating at the end of a season; an empty seasonID means the active season.
+func (rs *RatingService) GetRankings(ctx context.Context, seasonID string) (*SeasonRankings, error) {
+	season, err := resolveSeason(ctx, rs.seasons, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := rs.store.ListSeasonRankings(ctx, season.ID)
+	if err != nil {
+		return nil, err
+	}
+	teams := make([]RankedTeam, len(rows))
+	for i, r := range rows {
+		teams[i] = RankedTeam{Rank: i + 1, RankingRow: r}
+	}
+	return &SeasonRankings{
+		SeasonID:   season.ID,
+		SeasonName: season.Name,
+		GameCode:   season.GameCode,
+		Teams:      teams,
+	}, nil
+}
+
+// resolveSeason maps an optional season_id query value to a season: empty means the
+// active season, anything unparseable or unknown is ErrInvalidSeason.
+func resolveSeason(ctx context.Context, seasons store.SeasonStore, seasonID string) (*models.Season, error) {
+	if seasonID == "" {
+		return seasons.GetActive(ctx)
+	}
+	id, err := strconv.Atoi(seasonID)
+	if err != nil {
+		return nil, ErrInvalidSeason
+	}
+	season, err := seasons.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidSeason
+	}
+	if err != nil {
+		return nil, fmt.Errorf("season %d: %w", id, err)
+	}
+	return season, nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRatingStore struct {
+	matches  []store.RatableMatch
+	unrated  []store.RatableMatch
+	latest   *store.RatableMatch
+	current  []models.TeamRating
+	maxSeq   int
+	replaced bool
+	ratings  []models.TeamRating
+	history  []models.TeamRatingHistory
+}
+
+func (m *mockRatingStore) ListRatableMatches(_ context.Context, unratedOnly bool) ([]store.RatableMatch, error) {
+	if unratedOnly {
+		return m.unrated, nil
+	}
+	return m.matches, nil
+}
+func (m *mockRatingStore) GetLatestRatedMatch(context.Context) (*store.RatableMatch, error) {
+	return m.latest, nil
+}
+func (m *mockRatingStore) ListCurrentRatings(context.Context) ([]models.TeamRating, error) {
+	return m.current, nil
+}
+func (m *mockRatingStore) GetMaxSequence(context.Context) (int, error) { return m.maxSeq, nil }
+func (m *mockRatingStore) GetTeamRating(context.Context, int) (*models.TeamRating, error) {
+	return nil, nil
+}
+func (m *mockRatingStore) ListTeamHistory(context.Context, int) ([]models.TeamRatingHistory, error) {
+	return nil, nil
+}
+func (m *mockRatingStore) ListSeasonRankings(context.Context, uint) ([]store.RankingRow, error) {
+	return nil, nil
+}
+func (m *mockRatingStore) ReplaceAll(_ context.Context, r []models.TeamRating, h []models.TeamRatingHistory) error {
+	m.replaced, m.ratings, m.history = true, r, h
+	return nil
+}
+func (m *mockRatingStore) Append(_ context.Context, r []models.TeamRating, h []models.TeamRatingHistory) error {
+	m.ratings, m.history = r, h
+	return nil
+}
+
+func ratable(id, t1, t2, winner uint, code string, day int) store.RatableMatch {
+	return store.RatableMatch{
+		MatchID: id, Team1ID: t1, Team2ID: t2, WinnerID: winner, GameCode: code,
+		SeasonStart: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		PlayedAt:    time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func ratingFor(rows []models.TeamRating, teamID uint) models.TeamRating {
+	for _, r := range rows {
+		if r.TeamID == teamID {
+			return r
+		}
+	}
+	return models.TeamRating{}
+}
+
+func TestRatingService_RecomputeWinnerGains(t *testing.T) {
+	ms := &mockRatingStore{matches: []store.RatableMatch{ratable(1, 1, 2, 1, "BO6", 1)}}
+	svc := NewRatingService(ms, nil, DefaultRatingConfig())
+
+	n, err := svc.Recompute(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, ms.replaced)
+	require.Len(t, ms.history, 2)
+	assert.Equal(t, []int{1, 2}, []int{ms.history[0].Sequence, ms.history[1].Sequence})
+
+	winner, loser := ratingFor(ms.ratings, 1), ratingFor(ms.ratings, 2)
+	assert.Greater(t, winner.Rating, 1500.0)
+	assert.Less(t, loser.Rating, 1500.0)
+	assert.Equal(t, 1, winner.Wins)
+	assert.Equal(t, 1, loser.Losses)
+}
+
+func TestRatingService_CarryOverAcrossEras(t *testing.T) {
+	franchise := uint(7)
+	old := ratable(1, 1, 2, 1, "MW3", 1)
+	old.Team1Franchise = &franchise
+	next := ratable(2, 3, 4, 4, "BO6", 2)
+	next.Team1Franchise = &franchise // same franchise, new era team row
+
+	for _, tc := range []struct {
+		name      string
+		carryOver float64
+		wantFresh bool
+	}{
+		{"carry-over seeds from prior era", 0.5, false},
+		{"zero carry-over starts fresh", 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultRatingConfig()
+			cfg.CarryOver = tc.carryOver
+			ms := &mockRatingStore{matches: []store.RatableMatch{old, next}}
+			_, err := NewRatingService(ms, nil, cfg).Recompute(context.Background())
+			require.NoError(t, err)
+
+			entering := ms.history[2] // team 3's row for match 2
+			require.Equal(t, uint(3), entering.TeamID)
+			if tc.wantFresh {
+				assert.Equal(t, cfg.InitialRating, entering.RatingBefore)
+				assert.Equal(t, cfg.InitialRD, entering.RDBefore)
+			} else {
+				assert.Greater(t, entering.RatingBefore, cfg.InitialRating)
+				assert.Less(t, entering.RDBefore, cfg.InitialRD)
+			}
+		})
+	}
+}
+
+func TestRatingService_IncrementalContinuesFromStoredState(t *testing.T) {
+	prev := ratable(1, 1, 2, 1, "BO6", 1)
+	ms := &mockRatingStore{
+		unrated: []store.RatableMatch{ratable(2, 1, 2, 2, "BO6", 2)},
+		latest:  &prev,
+		current: []models.TeamRating{
+			{ID: 11, TeamID: 1, GameCode: "BO6", Rating: 1600, RD: 200, Volatility: 0.06, MatchesPlayed: 1, Wins: 1},
+			{ID: 12, TeamID: 2, GameCode: "BO6", Rating: 1400, RD: 200, Volatility: 0.06, MatchesPlayed: 1, Losses: 1},
+		},
+		maxSeq: 2,
+	}
+	n, err := NewRatingService(ms, nil, DefaultRatingConfig()).UpdateIncremental(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.False(t, ms.replaced)
+
+	require.Len(t, ms.history, 2)
+	assert.Equal(t, 3, ms.history[0].Sequence)
+	assert.Equal(t, 1600.0, ms.history[0].RatingBefore)
+
+	upset := ratingFor(ms.ratings, 2)
+	assert.Zero(t, upset.ID, "upserts key on team_id, not the stored primary key")
+	assert.Equal(t, 2, upset.MatchesPlayed)
+	assert.Greater(t, upset.Rating, 1400.0)
+}
+
+func TestRatingService_IncrementalOutOfOrderRecomputes(t *testing.T) {
+	prev := ratable(5, 1, 2, 1, "BO6", 10)
+	early := ratable(6, 1, 2, 2, "BO6", 3) // inserted later, played earlier
+	ms := &mockRatingStore{
+		matches: []store.RatableMatch{early, prev},
+		unrated: []store.RatableMatch{early},
+		latest:  &prev,
+	}
+	n, err := NewRatingService(ms, nil, DefaultRatingConfig()).UpdateIncremental(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, ms.replaced, "out-of-order insert must trigger a full rebuild")
+}
+
+func TestRatingService_GetRankings_SeasonLookup(t *testing.T) {
+	svc := NewRatingService(&mockRatingStore{}, &mockSeasonStore{}, DefaultRatingConfig())
+	_, err := svc.GetRankings(context.Background(), "99")
+	assert.ErrorIs(t, err, ErrInvalidSeason)
+
+	// A failing lookup is a server error, not a bad season_id.
+	dbErr := errors.New("connection reset")
+	svc = NewRatingService(&mockRatingStore{}, &mockSeasonStore{err: dbErr}, DefaultRatingConfig())
+	_, err = svc.GetRankings(context.Background(), "4")
+	assert.ErrorIs(t, err, dbErr)
+	assert.NotErrorIs(t, err, ErrInvalidSeason)
+}
@@ -0,0 +1,257 @@
+package services
+
+// spam.go — per-user posting limits and content checks for match threads.
+// The per-IP middleware.RateLimit guards the API as a whole; SpamGuard stops one
+// account flooding a thread from behind it. State is in memory, per process.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var ErrRateLimited = errors.New("you are posting too fast")
+var ErrDuplicatePost = errors.New("you already posted that")
+var ErrTooManyLinks = errors.New("post contains too many links")
+var ErrBlockedContent = errors.New("post contains blocked language")
+
+// RateLimitError is ErrRateLimited with how long until the next post or edit is allowed.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s, try again in %ds", ErrRateLimited, e.Seconds())
+}
+
+func (e *RateLimitError) Unwrap() error { return ErrRateLimited }
+
+// Seconds is RetryAfter rounded up, for a Retry-After header.
+func (e *RateLimitError) Seconds() int { return int(math.Ceil(e.RetryAfter.Seconds())) }
+
+// SpamConfig tunes SpamGuard. A user gets PostBurst posts straight away and one more
+// every PostEvery; edits work the same way on their own budget. Zero MaxLinks or
+// DuplicateWindow turns that check off.
+type SpamConfig struct {
+	PostEvery       time.Duration
+	PostBurst       int
+	EditEvery       time.Duration
+	EditBurst       int
+	DuplicateWindow time.Duration
+	MaxLinks        int
+}
+
+func DefaultSpamConfig() SpamConfig {
+	return SpamConfig{
+		PostEvery:       12 * time.Second,
+		PostBurst:       3,
+		EditEvery:       10 * time.Second,
+		EditBurst:       5,
+		DuplicateWindow: 10 * time.Minute,
+		MaxLinks:        2,
+	}
+}
+
+// WordFilter decides whether a post body is acceptable. Match returns the first
+// blocked term it finds.
+type WordFilter interface {
+	Match(body string) (string, bool)
+}
+
+// Blocklist is a WordFilter that blocks whole words, ignoring case.
+type Blocklist struct {
+	pattern *regexp.Regexp
+}
+
+// NewBlocklist builds a Blocklist; blank words are skipped, and with none left every
+// body passes.
+func NewBlocklist(words []string) *Blocklist {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) == 0 {
+		return &Blocklist{}
+	}
+	return &Blocklist{pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+// BlocklistFromEnv reads a comma-separated list of blocked words from THREAD_BLOCKED_WORDS.
+func BlocklistFromEnv() *Blocklist {
+	return NewBlocklist(strings.Split(os.Getenv("THREAD_BLOCKED_WORDS"), ","))
+}
+
+func (b *Blocklist) Match(body string) (string, bool) {
+	if b.pattern == nil {
+		return "", false
+	}
+	term := b.pattern.FindString(body)
+	return term, term != ""
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)`)
+
+type recentPost struct {
+	key string
+	at  time.Time
+}
+
+type posterState struct {
+	posts    *rate.Limiter
+	edits    *rate.Limiter
+	recent   []recentPost
+	lastSeen time.Time
+}
+
+// SpamGuard applies SpamConfig and a WordFilter per user. filter may be nil.
+type SpamGuard struct {
+	cfg    SpamConfig
+	filter WordFilter
+	now    func() time.Time
+
+	mu      sync.Mutex
+	posters map[uint]*posterState
+	pruned  time.Time
+}
+
+func NewSpamGuard(cfg SpamConfig, filter WordFilter) *SpamGuard {
+	return &SpamGuard{cfg: cfg, filter: filter, now: time.Now, posters: map[uint]*posterState{}}
+}
+
+// checkAndRemember runs the content checks, then duplicate detection and the post
+// budget, and records the body for duplicate detection in the same critical section,
+// so two concurrent posts of the same body cannot both pass. Exempt users (moderators)
+// skip the last two. If the post is not saved after all, forget drops the record.
+func (g *SpamGuard) checkAndRemember(userID uint, body string, exempt bool) (forget func(), err error) {
+	forget = func() {}
+	if err := g.checkContent(body); err != nil {
+		return forget, err
+	}
+	if exempt {
+		return forget, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	st := g.poster(userID, now)
+	key := duplicateKey(body)
+	if g.cfg.DuplicateWindow > 0 {
+		for _, r := range st.recent {
+			if r.key == key && now.Sub(r.at) < g.cfg.DuplicateWindow {
+				return forget, ErrDuplicatePost
+			}
+		}
+	}
+	if err := reserve(st.posts, now); err != nil {
+		return forget, err
+	}
+	if g.cfg.DuplicateWindow <= 0 {
+		return forget, nil
+	}
+	st.recent = append(st.recent, recentPost{key: key, at: now})
+	return func() { g.forget(userID, key, now) }, nil
+}
+
+// CheckEdit runs the content checks and the edit budget.
+func (g *SpamGuard) CheckEdit(userID uint, body string, exempt bool) error {
+	if err := g.checkContent(body); err != nil {
+		return err
+	}
+	if exempt {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	return reserve(g.poster(userID, now).edits, now)
+}
+
+// forget drops the duplicate record checkAndRemember made for a post that was not saved.
+func (g *SpamGuard) forget(userID uint, key string, at time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	st, ok := g.posters[userID]
+	if !ok {
+		return
+	}
+	for i, r := range st.recent {
+		if r.key == key && r.at.Equal(at) {
+			st.recent = append(st.recent[:i], st.recent[i+1:]...)
+			return
+		}
+	}
+}
+
+func (g *SpamGuard) checkContent(body string) error {
+	if g.cfg.MaxLinks > 0 && len(linkPattern.FindAllStringIndex(body, -1)) > g.cfg.MaxLinks {
+		return fmt.Errorf("%w: at most %d allowed", ErrTooManyLinks, g.cfg.MaxLinks)
+	}
+	if g.filter != nil {
+		if _, blocked := g.filter.Match(body); blocked {
+			return ErrBlockedContent
+		}
+	}
+	return nil
+}
+
+// poster returns userID's state, pruning idle users and expired duplicates at most
+// once a minute. Callers hold g.mu.
+func (g *SpamGuard) poster(userID uint, now time.Time) *posterState {
+	if now.Sub(g.pruned) > time.Minute {
+		g.prune(now)
+	}
+	st, ok := g.posters[userID]
+	if !ok {
+		st = &posterState{
+			posts: rate.NewLimiter(rate.Every(g.cfg.PostEvery), g.cfg.PostBurst),
+			edits: rate.NewLimiter(rate.Every(g.cfg.EditEvery), g.cfg.EditBurst),
+		}
+		g.posters[userID] = st
+	}
+	st.lastSeen = now
+	return st
+}
+
+// prune drops users whose budgets have refilled and who have no duplicate window
+// left open, since a fresh state for them would be identical.
+func (g *SpamGuard) prune(now time.Time) {
+	g.pruned = now
+	idle := max(g.cfg.PostEvery*time.Duration(g.cfg.PostBurst), g.cfg.EditEvery*time.Duration(g.cfg.EditBurst), g.cfg.DuplicateWindow)
+	for id, st := range g.posters {
+		if now.Sub(st.lastSeen) > idle {
+			delete(g.posters, id)
+			continue
+		}
+		kept := st.recent[:0]
+		for _, r := range st.recent {
+			if now.Sub(r.at) < g.cfg.DuplicateWindow {
+				kept = append(kept, r)
+			}
+		}
+		st.recent = kept
+	}
+}
+
+// reserve takes one token from lim or reports how long until one is free.
+func reserve(lim *rate.Limiter, now time.Time) error {
+	r := lim.ReserveN(now, 1)
+	if d := r.DelayFrom(now); d > 0 {
+		r.CancelAt(now)
+		return &RateLimitError{RetryAfter: d}
+	}
+	return nil
+}
+
+// duplicateKey normalises case and whitespace so trivial variations still match.
+func duplicateKey(body string) string {
+	return strings.ToLower(strings.Join(strings.Fields(body), " "))
+}
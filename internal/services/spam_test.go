@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+func newTestGuard(cfg SpamConfig, filter WordFilter) (*SpamGuard, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	g := NewSpamGuard(cfg, filter)
+	g.now = clock.now
+	return g, clock
+}
+
+// post runs a post through the guard as createPost does when the insert succeeds.
+func post(g *SpamGuard, userID uint, body string, exempt bool) error {
+	_, err := g.checkAndRemember(userID, body, exempt)
+	return err
+}
+
+func TestSpamGuard_PostBudget(t *testing.T) {
+	g, clock := newTestGuard(SpamConfig{PostEvery: 10 * time.Second, PostBurst: 2, EditEvery: time.Second, EditBurst: 1}, nil)
+
+	require.NoError(t, post(g, 1, "one", false))
+	require.NoError(t, post(g, 1, "two", false))
+	err := post(g, 1, "three", false)
+	var limited *RateLimitError
+	require.True(t, errors.As(err, &limited))
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, 10, limited.Seconds())
+
+	assert.NoError(t, post(g, 2, "other users have their own budget", false))
+	assert.NoError(t, post(g, 1, "moderators are exempt", true))
+
+	clock.advance(10 * time.Second)
+	assert.NoError(t, post(g, 1, "three", false), "a rejected attempt does not spend a token")
+	assert.ErrorIs(t, post(g, 1, "four", false), ErrRateLimited)
+
+	require.NoError(t, g.CheckEdit(1, "edit", false), "edits have a separate budget")
+	assert.ErrorIs(t, g.CheckEdit(1, "edit again", false), ErrRateLimited)
+}
+
+func TestSpamGuard_Duplicates(t *testing.T) {
+	g, clock := newTestGuard(SpamConfig{PostEvery: time.Millisecond, PostBurst: 100, DuplicateWindow: 5 * time.Minute}, nil)
+
+	require.NoError(t, post(g, 1, "OpTic in 4", false))
+	assert.ErrorIs(t, post(g, 1, "optic   IN 4", false), ErrDuplicatePost, "case and spacing are ignored")
+	assert.NoError(t, post(g, 2, "OpTic in 4", false), "other users can say the same thing")
+
+	clock.advance(5 * time.Minute)
+	assert.NoError(t, post(g, 1, "OpTic in 4", false))
+
+	clock.advance(time.Hour)
+	_ = post(g, 3, "prune", false)
+	assert.NotContains(t, g.posters, uint(1), "idle users are pruned")
+}
+
+func TestSpamGuard_ConcurrentDuplicates(t *testing.T) {
+	g, _ := newTestGuard(SpamConfig{PostEvery: time.Millisecond, PostBurst: 100, DuplicateWindow: 5 * time.Minute}, nil)
+
+	var wg sync.WaitGroup
+	var passed atomic.Int32
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if post(g, 1, "same body", false) == nil {
+				passed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), passed.Load(), "only one of several simultaneous identical posts gets through")
+}
+
+func TestSpamGuard_ForgetUnsavedPost(t *testing.T) {
+	g, _ := newTestGuard(SpamConfig{PostEvery: time.Millisecond, PostBurst: 100, DuplicateWindow: 5 * time.Minute}, nil)
+
+	forget, err := g.checkAndRemember(1, "GG", false)
+	require.NoError(t, err)
+	forget()
+	assert.NoError(t, post(g, 1, "GG", false), "a post that failed to save can be retried")
+	assert.ErrorIs(t, post(g, 1, "GG", false), ErrDuplicatePost)
+}
+
+func TestSpamGuard_Content(t *testing.T) {
+	g, _ := newTestGuard(SpamConfig{PostEvery: time.Millisecond, PostBurst: 100, MaxLinks: 2}, NewBlocklist([]string{"scam", " ", "free camos"}))
+
+	assert.NoError(t, post(g, 1, "vod: https://youtu.be/x and www.cdl.gg", false))
+	assert.ErrorIs(t, post(g, 1, "http://a.com http://b.com https://c.com", false), ErrTooManyLinks)
+	assert.ErrorIs(t, post(g, 1, "total SCAM", false), ErrBlockedContent)
+	assert.ErrorIs(t, post(g, 1, "get FREE CAMOS here", false), ErrBlockedContent)
+	assert.NoError(t, post(g, 1, "scampering is fine", false), "only whole words match")
+	assert.ErrorIs(t, post(g, 1, "scam", true), ErrBlockedContent, "moderators still go through the filter")
+}
+
+func TestBlocklist_Empty(t *testing.T) {
+	_, blocked := NewBlocklist([]string{"", "  "}).Match("anything")
+	assert.False(t, blocked)
+	t.Setenv("THREAD_BLOCKED_WORDS", "foo, bar")
+	term, blocked := BlocklistFromEnv().Match("well BAR then")
+	assert.True(t, blocked)
+	assert.Equal(t, "BAR", term)
+}
+
+func TestThreadService_SpamGuard(t *testing.T) {
+	ctx := context.Background()
+	ms := &mockThreadStore{
+		posts: []models.ThreadPost{{ID: 10, ThreadID: 1, UserID: 7, Body: "original"}},
+		users: []models.User{{ID: 7, Role: models.RoleUser}, {ID: 8, Role: models.RoleModerator}},
+	}
+	guard, _ := newTestGuard(SpamConfig{PostEvery: time.Minute, PostBurst: 2, EditEvery: time.Minute, EditBurst: 1, DuplicateWindow: time.Minute}, NewBlocklist([]string{"scam"}))
+	svc := NewThreadService(ms, nil, guard)
+
+	_, err := svc.CreatePost(ctx, 1, 7, "gg")
+	require.NoError(t, err)
+	_, err = svc.CreatePost(ctx, 1, 7, "GG")
+	assert.ErrorIs(t, err, ErrDuplicatePost)
+	_, err = svc.CreatePost(ctx, 1, 7, "scam")
+	assert.ErrorIs(t, err, ErrBlockedContent)
+	_, err = svc.CreatePost(ctx, 1, 7, "ok")
+	require.NoError(t, err)
+	_, err = svc.CreatePost(ctx, 1, 7, "one too many")
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	require.NoError(t, svc.EditPost(ctx, 10, 7, "edited"))
+	assert.ErrorIs(t, svc.EditPost(ctx, 10, 7, "edited again"), ErrRateLimited)
+
+	for _, body := range []string{"mod 1", "mod 2", "mod 3"} {
+		_, err = svc.CreatePost(ctx, 1, 8, body)
+		assert.NoError(t, err, "moderators skip the post budget")
+	}
+}
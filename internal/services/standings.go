@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+// SeasonStandings is the /seasons/:id/standings response.
+type SeasonStandings struct {
+	SeasonID   uint                      `json:"season_id"`
+	SeasonName string                    `json:"season_name"`
+	GameCode   string                    `json:"game_code"`
+	Rules      []models.SeasonPointsRule `json:"rules"`
+	Teams      []Standing                `json:"teams"`
+}
+
+// Standing is one team's row. Tiebreaker names what separated it from the team
+// directly above when both had the same points ("map_differential", "head_to_head").
+type Standing struct {
+	Rank         int             `json:"rank"`
+	TeamID       uint            `json:"team_id"`
+	TeamName     string          `json:"team_name"`
+	TeamAbbr     string          `json:"team_abbr"`
+	TeamLogo     string          `json:"team_logo"`
+	Points       int             `json:"points"`
+	SeriesWins   int             `json:"series_wins"`
+	SeriesLosses int             `json:"series_losses"`
+	MapWins      int             `json:"map_wins"`
+	MapLosses    int             `json:"map_losses"`
+	MapDiff      int             `json:"map_diff"`
+	Tiebreaker   string          `json:"tiebreaker,omitempty"`
+	Events       []StandingEvent `json:"events"`
+
+	h2hWins int
+}
+
+type StandingEvent struct {
+	TournamentID   uint   `json:"tournament_id"`
+	TournamentName string `json:"tournament_name,omitempty"`
+	TournamentType string `json:"tournament_type"`
+	Placement      *int   `json:"placement"`
+	SeriesWins     int    `json:"series_wins"`
+	Points         int    `json:"points"`
+}
+
+type StandingsService struct {
+	store   store.StandingsStore
+	seasons store.SeasonStore
+}
+
+func NewStandingsService(s store.StandingsStore, seasons store.SeasonStore) *StandingsService {
+	return &StandingsService{store: s, seasons: seasons}
+}
+
+// pointsTable indexes a season's rules by tournament type.
+type pointsTable struct {
+	perWin    map[string]int
+	placement map[string][]models.SeasonPointsRule
+}
+
+func newPointsTable(rules []models.SeasonPointsRule) pointsTable {
+	t := pointsTable{perWin: map[string]int{}, placement: map[string][]models.SeasonPointsRule{}}
+	for _, r := range rules {
+		switch r.Basis {
+		case models.PointsBasisMatchWin:
+			t.perWin[r.TournamentType] += r.Points
+		case models.PointsBasisPlacement:
+			t.placement[r.TournamentType] = append(t.placement[r.TournamentType], r)
+		}
+	}
+	return t
+}
+
+// covers reports whether an event type counts towards the table. With no rules at
+// all every event counts, so the table degrades to a plain win/loss record.
+func (t pointsTable) covers(tournamentType string) bool {
+	if len(t.perWin) == 0 && len(t.placement) == 0 {
+		return true
+	}
+	_, win := t.perWin[tournamentType]
+	_, place := t.placement[tournamentType]
+	return win || place
+}
+
+func (t pointsTable) forPlacement(tournamentType string, placement int) int {
+	for _, r := range t.placement[tournamentType] {
+		to := r.PlacementTo
+		if to < r.PlacementFrom {
+			to = r.PlacementFrom
+		}
+		if placement >= r.PlacementFrom && placement <= to {
+			return r.Points
+		}
+	}
+	return 0
+}
+
+func (ss *StandingsService) GetStandings(ctx context.Context, seasonID int) (*SeasonStandings, error) {
+	season, err := ss.seasons.GetByID(ctx, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ss.store.ListPointsRules(ctx, season)
+	if err != nil {
+		return nil, err
+	}
+	series, err := ss.store.ListSeasonSeries(ctx, season.ID)
+	if err != nil {
+		return nil, err
+	}
+	placements, err := ss.store.ListSeasonPlacements(ctx, season.ID)
+	if err != nil {
+		return nil, err
+	}
+	table := newPointsTable(rules)
+
+	standings := map[uint]*Standing{}
+	events := map[uint]map[uint]*StandingEvent{}
+	var order []uint
+	event := func(teamID, tournamentID uint, tournamentType string) *StandingEvent {
+		if standings[teamID] == nil {
+			standings[teamID] = &Standing{TeamID: teamID}
+			events[teamID] = map[uint]*StandingEvent{}
+			order = append(order, teamID)
+		}
+		e := events[teamID][tournamentID]
+		if e == nil {
+			e = &StandingEvent{TournamentID: tournamentID, TournamentType: tournamentType}
+			events[teamID][tournamentID] = e
+		}
+		return e
+	}
+
+	for _, p := range placements {
+		if !table.covers(p.TournamentType) {
+			continue
+		}
+		e := event(p.TeamID, p.TournamentID, p.TournamentType)
+		e.TournamentName = p.TournamentName
+		placement := p.Placement
+		e.Placement = &placement
+		e.Points += table.forPlacement(p.TournamentType, p.Placement)
+	}
+
+	counted := make([]store.StandingsSeriesRow, 0, len(series))
+	for _, m := range series {
+		if !table.covers(m.TournamentType) {
+			continue
+		}
+		counted = append(counted, m)
+		for _, side := range []struct {
+			team, own, opp uint
+			ownMaps        int
+			oppMaps        int
+		}{
+			{m.Team1ID, m.Team1ID, m.Team2ID, m.Team1Score, m.Team2Score},
+			{m.Team2ID, m.Team2ID, m.Team1ID, m.Team2Score, m.Team1Score},
+		} {
+			e := event(side.team, m.TournamentID, m.TournamentType)
+			st := standings[side.team]
+			st.MapWins += side.ownMaps
+			st.MapLosses += side.oppMaps
+			if m.WinnerID == side.team {
+				st.SeriesWins++
+				e.SeriesWins++
+				e.Points += table.perWin[m.TournamentType]
+			} else {
+				st.SeriesLosses++
+			}
+		}
+	}
+
+	teams, err := ss.store.ListTeamsByIDs(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		st := standings[t.ID]
+		st.TeamName, st.TeamAbbr, st.TeamLogo = t.Name, t.Abbreviation, t.LogoURL
+	}
+
+	rows := make([]*Standing, 0, len(order))
+	for _, id := range order {
+		st := standings[id]
+		st.MapDiff = st.MapWins - st.MapLosses
+		st.Events = make([]StandingEvent, 0, len(events[id]))
+		for _, e := range events[id] {
+			st.Points += e.Points
+			st.Events = append(st.Events, *e)
+		}
+		sort.Slice(st.Events, func(i, j int) bool { return st.Events[i].TournamentID < st.Events[j].TournamentID })
+		rows = append(rows, st)
+	}
+
+	rankStandings(rows, counted)
+
+	result := &SeasonStandings{
+		SeasonID:   season.ID,
+		SeasonName: season.Name,
+		GameCode:   season.GameCode,
+		Rules:      rules,
+		Teams:      make([]Standing, len(rows)),
+	}
+	for i, st := range rows {
+		result.Teams[i] = *st
+	}
+	return result, nil
+}
+
+// rankStandings orders by points, then map differential, then series wins against
+// the other teams still level on both, then name, and fills Rank and Tiebreaker.
+func rankStandings(rows []*Standing, series []store.StandingsSeriesRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Points != rows[j].Points {
+			return rows[i].Points > rows[j].Points
+		}
+		return rows[i].MapDiff > rows[j].MapDiff
+	})
+
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end].Points == rows[start].Points && rows[end].MapDiff == rows[start].MapDiff {
+			end++
+		}
+		if end-start > 1 {
+			group := rows[start:end]
+			in := map[uint]*Standing{}
+			for _, st := range group {
+				st.h2hWins = 0
+				in[st.TeamID] = st
+			}
+			for _, m := range series {
+				if in[m.Team1ID] == nil || in[m.Team2ID] == nil {
+					continue
+				}
+				// A winner that is neither side (bad data) decides nothing.
+				if m.WinnerID == m.Team1ID || m.WinnerID == m.Team2ID {
+					in[m.WinnerID].h2hWins++
+				}
+			}
+			sort.SliceStable(group, func(i, j int) bool {
+				if group[i].h2hWins != group[j].h2hWins {
+					return group[i].h2hWins > group[j].h2hWins
+				}
+				return group[i].TeamName < group[j].TeamName
+			})
+		}
+		start = end
+	}
+
+	for i, st := range rows {
+		st.Rank = i + 1
+		st.Tiebreaker = ""
+		if i == 0 || rows[i-1].Points != st.Points {
+			continue
+		}
+		prev := rows[i-1]
+		switch {
+		case prev.MapDiff != st.MapDiff:
+			st.Tiebreaker = "map_differential"
+		case prev.h2hWins != st.h2hWins:
+			st.Tiebreaker = "head_to_head"
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockStandingsStore struct {
+	rules      []models.SeasonPointsRule
+	series     []store.StandingsSeriesRow
+	placements []store.PlacementRow
+	teams      []models.Team
+}
+
+func (m *mockStandingsStore) ListPointsRules(context.Context, *models.Season) ([]models.SeasonPointsRule, error) {
+	return m.rules, nil
+}
+func (m *mockStandingsStore) ListSeasonSeries(context.Context, uint) ([]store.StandingsSeriesRow, error) {
+	return m.series, nil
+}
+func (m *mockStandingsStore) ListSeasonPlacements(context.Context, uint) ([]store.PlacementRow, error) {
+	return m.placements, nil
+}
+func (m *mockStandingsStore) ListTeamsByIDs(context.Context, []uint) ([]models.Team, error) {
+	return m.teams, nil
+}
+
+var standingsRules = []models.SeasonPointsRule{
+	{TournamentType: "qualifier", Basis: models.PointsBasisMatchWin, Points: 10},
+	{TournamentType: "major_tournament", Basis: models.PointsBasisPlacement, PlacementFrom: 1, Points: 100},
+	{TournamentType: "major_tournament", Basis: models.PointsBasisPlacement, PlacementFrom: 2, Points: 80},
+	{TournamentType: "major_tournament", Basis: models.PointsBasisPlacement, PlacementFrom: 3, PlacementTo: 4, Points: 60},
+}
+
+var standingsTeams = []models.Team{{ID: 1, Name: "Alpha"}, {ID: 2, Name: "Bravo"}, {ID: 3, Name: "Charlie"}, {ID: 4, Name: "Delta"}}
+
+func standingsService(s *mockStandingsStore) *StandingsService {
+	seasons := &mockSeasonStore{seasons: map[int]models.Season{1: {ID: 1, Name: "BO6", GameCode: "BO6"}}}
+	return NewStandingsService(s, seasons)
+}
+
+func standingsSeries(tournament uint, tournamentType string, t1, t2 uint, s1, s2 int) store.StandingsSeriesRow {
+	winner := t1
+	if s2 > s1 {
+		winner = t2
+	}
+	return store.StandingsSeriesRow{TournamentID: tournament, TournamentType: tournamentType,
+		Team1ID: t1, Team2ID: t2, Team1Score: s1, Team2Score: s2, WinnerID: winner}
+}
+
+func TestStandingsService_Points(t *testing.T) {
+	s := &mockStandingsStore{
+		rules: standingsRules,
+		series: []store.StandingsSeriesRow{
+			standingsSeries(10, "qualifier", 1, 2, 3, 1),
+			standingsSeries(10, "qualifier", 1, 3, 3, 2),
+			standingsSeries(20, "major_tournament", 2, 1, 3, 0),
+			standingsSeries(30, "championship", 3, 1, 3, 0), // no rules: ignored entirely
+		},
+		placements: []store.PlacementRow{
+			{TournamentID: 20, TournamentType: "major_tournament", TeamID: 2, Placement: 1},
+			{TournamentID: 20, TournamentType: "major_tournament", TeamID: 1, Placement: 2},
+			{TournamentID: 20, TournamentType: "major_tournament", TeamID: 3, Placement: 4},
+			{TournamentID: 30, TournamentType: "championship", TeamID: 3, Placement: 1},
+		},
+		teams: standingsTeams[:3],
+	}
+	res, err := standingsService(s).GetStandings(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, res.Teams, 3)
+	assert.Equal(t, "BO6", res.GameCode)
+
+	a, b, c := res.Teams[0], res.Teams[1], res.Teams[2]
+	assert.Equal(t, "Bravo", a.TeamName)
+	assert.Equal(t, 100, a.Points)
+	assert.Equal(t, "Alpha", b.TeamName)
+	assert.Equal(t, 100, b.Points) // 2 qualifier wins + 2nd place
+	assert.Equal(t, 2, b.SeriesWins)
+	assert.Equal(t, 1, b.SeriesLosses)
+	assert.Equal(t, 0, b.MapDiff) // 6-6
+	assert.Equal(t, "map_differential", b.Tiebreaker)
+	assert.Equal(t, "Charlie", c.TeamName)
+	assert.Equal(t, 60, c.Points, "4th falls in the 3-4 band")
+	assert.Equal(t, 3, c.Rank)
+	require.Len(t, b.Events, 2)
+	assert.Equal(t, 20, b.Events[0].Points)
+	assert.Equal(t, 80, b.Events[1].Points)
+	require.NotNil(t, b.Events[1].Placement)
+	assert.Equal(t, 2, *b.Events[1].Placement)
+}
+
+func TestStandingsService_HeadToHeadTiebreak(t *testing.T) {
+	// Everyone wins once. Charlie leads on maps; Alpha and Bravo are level on maps
+	// too, and Bravo won their meeting, so it goes above Alpha despite the name.
+	s := &mockStandingsStore{
+		rules: standingsRules,
+		series: []store.StandingsSeriesRow{
+			standingsSeries(10, "qualifier", 2, 1, 3, 2),
+			standingsSeries(10, "qualifier", 1, 4, 3, 2),
+			standingsSeries(10, "qualifier", 4, 2, 3, 2),
+			standingsSeries(10, "qualifier", 3, 4, 3, 0),
+		},
+		teams: standingsTeams,
+	}
+	res, err := standingsService(s).GetStandings(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, res.Teams, 4)
+
+	names := []string{res.Teams[0].TeamName, res.Teams[1].TeamName, res.Teams[2].TeamName, res.Teams[3].TeamName}
+	assert.Equal(t, []string{"Charlie", "Bravo", "Alpha", "Delta"}, names)
+	for _, st := range res.Teams {
+		assert.Equal(t, 10, st.Points)
+	}
+	assert.Equal(t, "", res.Teams[0].Tiebreaker)
+	assert.Equal(t, "map_differential", res.Teams[1].Tiebreaker)
+	assert.Equal(t, "head_to_head", res.Teams[2].Tiebreaker)
+	assert.Equal(t, "map_differential", res.Teams[3].Tiebreaker)
+}
+
+func TestStandingsService_HeadToHeadIgnoresStrayWinner(t *testing.T) {
+	// Alpha and Bravo end level; their meeting names a winner that is neither side
+	// (e.g. a pre-split team row), which must not panic or decide the tie.
+	stray := standingsSeries(10, "qualifier", 1, 2, 3, 3)
+	stray.WinnerID = 99
+	s := &mockStandingsStore{
+		rules: standingsRules,
+		series: []store.StandingsSeriesRow{
+			stray,
+			standingsSeries(10, "qualifier", 1, 3, 3, 0),
+			standingsSeries(10, "qualifier", 2, 4, 3, 0),
+		},
+		teams: standingsTeams,
+	}
+	res, err := standingsService(s).GetStandings(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, res.Teams, 4)
+	assert.Equal(t, "Alpha", res.Teams[0].TeamName)
+	assert.Equal(t, "Bravo", res.Teams[1].TeamName)
+	assert.Empty(t, res.Teams[1].Tiebreaker)
+}
+
+func TestStandingsService_SeasonNotFound(t *testing.T) {
+	_, err := standingsService(&mockStandingsStore{}).GetStandings(context.Background(), 9)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
@@ -20,18 +20,25 @@ type kdCacheEntry struct {
 	exp  time.Time
 }
 
+type leaderboardCacheEntry struct {
+	board *Leaderboard
+	exp   time.Time
+}
+
 type StatsService struct {
-	store store.StatsStore
-	mu    sync.RWMutex
-	topKD map[int]kdCacheEntry
-	allKD map[string]kdCacheEntry
+	store  store.StatsStore
+	mu     sync.RWMutex
+	topKD  map[int]kdCacheEntry
+	allKD  map[string]kdCacheEntry
+	boards map[string]leaderboardCacheEntry
 }
 
 func NewStatsService(s store.StatsStore) *StatsService {
 	return &StatsService{
-		store: s,
-		topKD: make(map[int]kdCacheEntry),
-		allKD: make(map[string]kdCacheEntry),
+		store:  s,
+		topKD:  make(map[int]kdCacheEntry),
+		allKD:  make(map[string]kdCacheEntry),
+		boards: make(map[string]leaderboardCacheEntry),
 	}
 }
 
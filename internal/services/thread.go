@@ -2,37 +2,163 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/pubsub"
 	"github.com/corbynfang/CDL-Website/internal/store"
+	"gorm.io/gorm"
 )
 
 var ErrPostTooLong = errors.New("post body exceeds 2000 characters")
 var ErrPostEmpty = errors.New("post body cannot be empty")
 var ErrNotOwner = errors.New("you can only edit your own posts")
+var ErrParentNotFound = errors.New("parent post not found in this thread")
+var ErrReplyTooDeep = errors.New("replies cannot be nested any deeper")
+var ErrInvalidEmoji = errors.New("reaction must be a single emoji")
+var ErrInvalidPostOrder = errors.New("order must be flat or tree")
 
+// MaxReplyDepth is the deepest a reply can sit; top-level posts are depth 0.
+const MaxReplyDepth = 4
+
+// maxMentions caps how many distinct @usernames one post resolves.
+const maxMentions = 10
+
+// Thread stream event types.
+const (
+	ThreadPostCreated = "post_created"
+	ThreadPostEdited  = "post_edited"
+	ThreadPostDeleted = "post_deleted"
+	ThreadReactions   = "reactions_updated"
+)
+
+// ThreadEvent is one message on a match thread's stream. Created events carry the post
+// as GetThread returns it, edited events the new body, deleted events just the post ID,
+// and reactions_updated events the post's new reaction counts.
+type ThreadEvent struct {
+	Type      string                 `json:"type"`
+	MatchID   uint                   `json:"match_id"`
+	ThreadID  uint                   `json:"thread_id"`
+	PostID    uint                   `json:"post_id"`
+	Post      *models.ThreadPost     `json:"post,omitempty"`
+	Body      string                 `json:"body,omitempty"`
+	Reactions []models.ReactionCount `json:"reactions,omitempty"`
+}
+
+// threadTopic is the pub/sub topic a match thread's events go out on.
+func threadTopic(matchID uint) string { return fmt.Sprintf("match.%d.thread", matchID) }
+
+// ThreadService owns match threads. hub may be nil, in which case no events are
+// published, and guard may be nil, in which case posts skip the spam checks.
 type ThreadService struct {
 	store store.ThreadStore
+	hub   pubsub.Hub
+	guard *SpamGuard
+}
+
+func NewThreadService(s store.ThreadStore, hub pubsub.Hub, guard *SpamGuard) *ThreadService {
+	return &ThreadService{store: s, hub: hub, guard: guard}
 }
 
-func NewThreadService(s store.ThreadStore) *ThreadService {
-	return &ThreadService{store: s}
+// Subscribe opens a stream of JSON-encoded ThreadEvents on a match's thread. It works
+// before the thread exists; the first post creates it. It returns gorm.ErrRecordNotFound
+// for an unknown match, and with no hub the stream is already closed.
+func (ts *ThreadService) Subscribe(ctx context.Context, matchID uint) (<-chan []byte, func(), error) {
+	if _, err := ts.store.GetMatch(ctx, matchID); err != nil {
+		return nil, nil, err
+	}
+	if ts.hub == nil {
+		closed := make(chan []byte)
+		close(closed)
+		return closed, func() {}, nil
+	}
+	events, cancel := ts.hub.Subscribe(threadTopic(matchID))
+	return events, cancel, nil
 }
 
-func (ts *ThreadService) GetThread(ctx context.Context, matchID uint, page, limit int) ([]models.ThreadPost, int64, uint, error) {
+// publish is best-effort: the change is already saved, and a client that missed an
+// event catches up from GetThread when it reconnects.
+func (ts *ThreadService) publish(ctx context.Context, ev ThreadEvent) {
+	if ts.hub == nil {
+		return
+	}
+	thread, err := ts.store.GetThreadByID(ctx, ev.ThreadID)
+	if err != nil {
+		return
+	}
+	ev.MatchID = thread.MatchID
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	_ = ts.hub.Publish(ctx, threadTopic(thread.MatchID), payload)
+}
+
+// ParsePostOrder maps the ?order= query value to a store.PostOrder; empty means flat.
+func ParsePostOrder(v string) (store.PostOrder, error) {
+	switch store.PostOrder(v) {
+	case "", store.PostOrderFlat:
+		return store.PostOrderFlat, nil
+	case store.PostOrderTree:
+		return store.PostOrderTree, nil
+	}
+	return "", ErrInvalidPostOrder
+}
+
+// GetThread returns one page of a match's thread and the thread itself, nil if nobody
+// has posted yet. In tree order the page is a list of top-level posts with their
+// replies nested under them, and total counts top-level posts.
+func (ts *ThreadService) GetThread(ctx context.Context, matchID uint, order store.PostOrder, page, limit int) ([]models.ThreadPost, int64, *models.MatchThread, error) {
 	thread, err := ts.store.FindThread(ctx, matchID)
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, 0, nil, err
 	}
 	if thread == nil {
-		return []models.ThreadPost{}, 0, 0, nil
+		return []models.ThreadPost{}, 0, nil, nil
 	}
 	offset := (page - 1) * limit
-	posts, total, err := ts.store.GetPostsByThreadID(ctx, thread.ID, limit, offset)
-	return posts, total, thread.ID, err
+	posts, total, err := ts.store.GetPostsByThreadID(ctx, thread.ID, order, limit, offset)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if order == store.PostOrderTree {
+		posts = nestPosts(posts)
+	}
+	return posts, total, thread, nil
+}
+
+// nestPosts turns a depth-first list of posts into top-level posts with Replies set.
+func nestPosts(flat []models.ThreadPost) []models.ThreadPost {
+	children := map[uint][]int{}
+	var roots []int
+	seen := make(map[uint]bool, len(flat))
+	for i, p := range flat {
+		if p.ParentID != nil && seen[*p.ParentID] {
+			children[*p.ParentID] = append(children[*p.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+		seen[p.ID] = true
+	}
+	var build func(i int) models.ThreadPost
+	build = func(i int) models.ThreadPost {
+		p := flat[i]
+		for _, c := range children[p.ID] {
+			p.Replies = append(p.Replies, build(c))
+		}
+		return p
+	}
+	out := make([]models.ThreadPost, len(roots))
+	for n, i := range roots {
+		out[n] = build(i)
+	}
+	return out
 }
 
 func (ts *ThreadService) EnsureThread(ctx context.Context, matchID uint) (uint, error) {
@@ -44,17 +170,52 @@ func (ts *ThreadService) EnsureThread(ctx context.Context, matchID uint) (uint,
 }
 
 func (ts *ThreadService) CreatePost(ctx context.Context, threadID, userID uint, body string) (*models.ThreadPost, error) {
-	body = strings.TrimSpace(stripHTML(body))
-	if body == "" {
-		return nil, ErrPostEmpty
+	return ts.createPost(ctx, &models.ThreadPost{ThreadID: threadID, UserID: userID}, body)
+}
+
+// CreateReply posts under parentID, which must be a live post in the same thread and
+// shallower than MaxReplyDepth.
+func (ts *ThreadService) CreateReply(ctx context.Context, threadID, userID, parentID uint, body string) (*models.ThreadPost, error) {
+	parent, err := ts.store.GetPost(ctx, parentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && parent.ThreadID != threadID) {
+		return nil, ErrParentNotFound
 	}
-	if utf8.RuneCountInString(body) > 2000 {
-		return nil, ErrPostTooLong
+	if err != nil {
+		return nil, err
+	}
+	if parent.Depth >= MaxReplyDepth {
+		return nil, fmt.Errorf("%w: maximum depth is %d", ErrReplyTooDeep, MaxReplyDepth)
 	}
-	post := &models.ThreadPost{ThreadID: threadID, UserID: userID, Body: body}
-	if err := ts.store.CreatePost(ctx, post); err != nil {
+	post := &models.ThreadPost{ThreadID: threadID, UserID: userID, ParentID: &parent.ID, Depth: parent.Depth + 1}
+	return ts.createPost(ctx, post, body)
+}
+
+func (ts *ThreadService) createPost(ctx context.Context, post *models.ThreadPost, body string) (*models.ThreadPost, error) {
+	author, err := ts.canWrite(ctx, post.UserID, post.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	body, err = cleanBody(body)
+	if err != nil {
 		return nil, err
 	}
+	forget := func() {}
+	if ts.guard != nil {
+		if forget, err = ts.guard.checkAndRemember(author.ID, body, author.IsModerator()); err != nil {
+			return nil, err
+		}
+	}
+	post.Body = body
+	mentions, err := ts.resolveMentions(ctx, body, post.UserID)
+	if err != nil {
+		forget()
+		return nil, err
+	}
+	if err := ts.store.CreatePost(ctx, post, mentions); err != nil {
+		forget()
+		return nil, err
+	}
+	ts.publish(ctx, ThreadEvent{Type: ThreadPostCreated, ThreadID: post.ThreadID, PostID: post.ID, Post: post})
 	return post, nil
 }
 
@@ -66,14 +227,28 @@ func (ts *ThreadService) EditPost(ctx context.Context, postID, userID uint, body
 	if post.UserID != userID {
 		return ErrNotOwner
 	}
-	body = strings.TrimSpace(stripHTML(body))
-	if body == "" {
-		return ErrPostEmpty
+	editor, err := ts.canWrite(ctx, userID, post.ThreadID)
+	if err != nil {
+		return err
 	}
-	if utf8.RuneCountInString(body) > 2000 {
-		return ErrPostTooLong
+	body, err = cleanBody(body)
+	if err != nil {
+		return err
+	}
+	if ts.guard != nil {
+		if err := ts.guard.CheckEdit(userID, body, editor.IsModerator()); err != nil {
+			return err
+		}
+	}
+	mentions, err := ts.resolveMentions(ctx, body, userID)
+	if err != nil {
+		return err
+	}
+	if err := ts.store.UpdatePost(ctx, postID, body, mentions); err != nil {
+		return err
 	}
-	return ts.store.UpdatePost(ctx, postID, body)
+	ts.publish(ctx, ThreadEvent{Type: ThreadPostEdited, ThreadID: post.ThreadID, PostID: postID, Body: body})
+	return nil
 }
 
 func (ts *ThreadService) DeletePost(ctx context.Context, postID, userID uint) error {
@@ -84,7 +259,155 @@ func (ts *ThreadService) DeletePost(ctx context.Context, postID, userID uint) er
 	if post.UserID != userID {
 		return ErrNotOwner
 	}
-	return ts.store.SoftDeletePost(ctx, postID)
+	if err := ts.store.SoftDeletePost(ctx, postID); err != nil {
+		return err
+	}
+	ts.publish(ctx, ThreadEvent{Type: ThreadPostDeleted, ThreadID: post.ThreadID, PostID: postID})
+	return nil
+}
+
+// React adds userID's emoji to a live post and returns the post's reaction counts.
+// Reacting again with the same emoji changes nothing.
+func (ts *ThreadService) React(ctx context.Context, postID, userID uint, emoji string) ([]models.ReactionCount, error) {
+	if !validEmoji(emoji) {
+		return nil, ErrInvalidEmoji
+	}
+	post, err := ts.store.GetPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ts.canWrite(ctx, userID, post.ThreadID); err != nil {
+		return nil, err
+	}
+	if err := ts.store.AddReaction(ctx, &models.PostReaction{PostID: postID, UserID: userID, Emoji: emoji}); err != nil {
+		return nil, err
+	}
+	return ts.reactionsChanged(ctx, post)
+}
+
+// Unreact removes userID's emoji from a live post, if it was there.
+func (ts *ThreadService) Unreact(ctx context.Context, postID, userID uint, emoji string) ([]models.ReactionCount, error) {
+	if !validEmoji(emoji) {
+		return nil, ErrInvalidEmoji
+	}
+	post, err := ts.store.GetPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ts.canWrite(ctx, userID, post.ThreadID); err != nil {
+		return nil, err
+	}
+	if err := ts.store.RemoveReaction(ctx, postID, userID, emoji); err != nil {
+		return nil, err
+	}
+	return ts.reactionsChanged(ctx, post)
+}
+
+func (ts *ThreadService) reactionsChanged(ctx context.Context, post *models.ThreadPost) ([]models.ReactionCount, error) {
+	counts, err := ts.store.GetReactionCounts(ctx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	ts.publish(ctx, ThreadEvent{Type: ThreadReactions, ThreadID: post.ThreadID, PostID: post.ID, Reactions: counts})
+	return counts, nil
+}
+
+// resolveMentions returns the IDs of the users a body @mentions, leaving out the author
+// and names that match no live account.
+func (ts *ThreadService) resolveMentions(ctx context.Context, body string, authorID uint) ([]uint, error) {
+	names := parseMentions(body)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	users, err := ts.store.ResolveUsernames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(users))
+	for _, u := range users {
+		if u.ID != authorID {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_.\-]{3,30})`)
+
+// parseMentions returns the distinct @usernames in a body, in order of first use and
+// lowercased, capped at maxMentions. Trailing dots and dashes are sentence punctuation.
+func parseMentions(body string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if utf8.RuneCountInString(name) < 3 || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
+
+// validEmoji accepts one emoji as typed on a phone keyboard: pictographs joined by
+// ZWJ, with variation selectors, skin tones, flag pairs and keycaps allowed. It counts
+// graphemes rather than pictographs, so "🔥🔥" or two flags in a row are rejected.
+func validEmoji(s string) bool {
+	if s == "" || len(s) > 32 {
+		return false
+	}
+	graphemes := 0
+	joined := false   // the previous rune was a ZWJ, so the next pictograph extends its grapheme
+	openFlag := false // one regional indicator seen; the next one completes the flag
+	for _, r := range s {
+		switch {
+		case r == 0x200D:
+			if graphemes == 0 || joined {
+				return false
+			}
+			joined = true
+			continue
+		case r == 0xFE0F, r == 0x20E3, r >= 0x1F3FB && r <= 0x1F3FF:
+			continue
+		case r >= 0xE0020 && r <= 0xE007F: // tag sequences (subdivision flags)
+			continue
+		case r >= 0x1F1E6 && r <= 0x1F1FF: // regional indicators pair into one flag
+			if openFlag {
+				openFlag = false
+				continue
+			}
+			openFlag = true
+		case unicode.Is(unicode.So, r):
+			openFlag = false
+		case r == '#' || r == '*' || (r >= '0' && r <= '9'):
+			if !strings.ContainsRune(s, 0x20E3) {
+				return false
+			}
+			openFlag = false
+		default:
+			return false
+		}
+		if !joined {
+			graphemes++
+		}
+		joined = false
+	}
+	return graphemes == 1 && !joined && !openFlag
+}
+
+func cleanBody(body string) (string, error) {
+	body = strings.TrimSpace(stripHTML(body))
+	if body == "" {
+		return "", ErrPostEmpty
+	}
+	if utf8.RuneCountInString(body) > 2000 {
+		return "", ErrPostTooLong
+	}
+	return body, nil
 }
 
 func stripHTML(s string) string {
@@ -2,10 +2,15 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/pubsub"
+	"github.com/corbynfang/CDL-Website/internal/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
@@ -18,6 +23,20 @@ type mockThreadStore struct {
 	createErr error
 	updateErr error
 	deleteErr error
+	users     []models.User
+	order     store.PostOrder
+	mentions  []uint
+	reactions []models.PostReaction
+	reports   []models.PostReport
+	audit     []models.ModerationLog
+	noMatch   bool
+}
+
+func (m *mockThreadStore) GetMatch(_ context.Context, id uint) (*models.Match, error) {
+	if m.noMatch {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.Match{ID: id}, nil
 }
 
 func (m *mockThreadStore) FindThread(_ context.Context, matchID uint) (*models.MatchThread, error) {
@@ -27,6 +46,13 @@ func (m *mockThreadStore) FindThread(_ context.Context, matchID uint) (*models.M
 	return &models.MatchThread{ID: 1, MatchID: matchID}, nil
 }
 
+func (m *mockThreadStore) GetThreadByID(_ context.Context, id uint) (*models.MatchThread, error) {
+	if m.thread != nil {
+		return m.thread, nil
+	}
+	return &models.MatchThread{ID: id, MatchID: 5}, nil
+}
+
 func (m *mockThreadStore) GetOrCreateThread(_ context.Context, matchID uint) (*models.MatchThread, error) {
 	if m.thread != nil {
 		return m.thread, nil
@@ -34,29 +60,88 @@ func (m *mockThreadStore) GetOrCreateThread(_ context.Context, matchID uint) (*m
 	return &models.MatchThread{ID: 1, MatchID: matchID}, nil
 }
 
-func (m *mockThreadStore) GetPostsByThreadID(_ context.Context, _ uint, _, _ int) ([]models.ThreadPost, int64, error) {
+func (m *mockThreadStore) GetPostsByThreadID(_ context.Context, _ uint, order store.PostOrder, _, _ int) ([]models.ThreadPost, int64, error) {
+	m.order = order
 	return m.posts, m.total, nil
 }
 
-func (m *mockThreadStore) CreatePost(_ context.Context, post *models.ThreadPost) error {
+func (m *mockThreadStore) CreatePost(_ context.Context, post *models.ThreadPost, mentions []uint) error {
 	if m.createErr != nil {
 		return m.createErr
 	}
 	post.ID = 99
+	m.mentions = mentions
 	return nil
 }
 
 func (m *mockThreadStore) GetPost(_ context.Context, id uint) (*models.ThreadPost, error) {
 	for i := range m.posts {
-		if m.posts[i].ID == id {
+		if m.posts[i].ID == id && m.posts[i].HiddenAt == nil {
 			return &m.posts[i], nil
 		}
 	}
 	return nil, gorm.ErrRecordNotFound
 }
 
-func (m *mockThreadStore) UpdatePost(_ context.Context, _ uint, _ string) error { return m.updateErr }
-func (m *mockThreadStore) SoftDeletePost(_ context.Context, _ uint) error       { return m.deleteErr }
+func (m *mockThreadStore) UpdatePost(_ context.Context, _ uint, _ string, mentions []uint) error {
+	m.mentions = mentions
+	return m.updateErr
+}
+
+func (m *mockThreadStore) SoftDeletePost(_ context.Context, _ uint) error { return m.deleteErr }
+
+func (m *mockThreadStore) ResolveUsernames(_ context.Context, names []string) ([]models.User, error) {
+	var out []models.User
+	for _, u := range m.users {
+		for _, n := range names {
+			if strings.EqualFold(u.Username, n) {
+				out = append(out, u)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (m *mockThreadStore) AddReaction(_ context.Context, r *models.PostReaction) error {
+	for _, have := range m.reactions {
+		if have.PostID == r.PostID && have.UserID == r.UserID && have.Emoji == r.Emoji {
+			return nil
+		}
+	}
+	m.reactions = append(m.reactions, *r)
+	return nil
+}
+
+func (m *mockThreadStore) RemoveReaction(_ context.Context, postID, userID uint, emoji string) error {
+	kept := m.reactions[:0]
+	for _, r := range m.reactions {
+		if r.PostID != postID || r.UserID != userID || r.Emoji != emoji {
+			kept = append(kept, r)
+		}
+	}
+	m.reactions = kept
+	return nil
+}
+
+func (m *mockThreadStore) GetReactionCounts(_ context.Context, postID uint) ([]models.ReactionCount, error) {
+	counts := []models.ReactionCount{}
+	for _, r := range m.reactions {
+		if r.PostID != postID {
+			continue
+		}
+		found := false
+		for i := range counts {
+			if counts[i].Emoji == r.Emoji {
+				counts[i].Count++
+				found = true
+			}
+		}
+		if !found {
+			counts = append(counts, models.ReactionCount{Emoji: r.Emoji, Count: 1})
+		}
+	}
+	return counts, nil
+}
 
 func TestStripHTML(t *testing.T) {
 	cases := []struct{ in, want string }{
@@ -74,7 +159,7 @@ func TestThreadService_CreatePost(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("valid body creates post", func(t *testing.T) {
-		svc := NewThreadService(&mockThreadStore{})
+		svc := NewThreadService(&mockThreadStore{}, nil, nil)
 		post, err := svc.CreatePost(ctx, 1, 7, "Great match!")
 		require.NoError(t, err)
 		assert.Equal(t, "Great match!", post.Body)
@@ -82,25 +167,25 @@ func TestThreadService_CreatePost(t *testing.T) {
 	})
 
 	t.Run("whitespace-only body returns ErrPostEmpty", func(t *testing.T) {
-		svc := NewThreadService(&mockThreadStore{})
+		svc := NewThreadService(&mockThreadStore{}, nil, nil)
 		_, err := svc.CreatePost(ctx, 1, 7, "   ")
 		assert.ErrorIs(t, err, ErrPostEmpty)
 	})
 
 	t.Run("body over 2000 chars returns ErrPostTooLong", func(t *testing.T) {
-		svc := NewThreadService(&mockThreadStore{})
+		svc := NewThreadService(&mockThreadStore{}, nil, nil)
 		_, err := svc.CreatePost(ctx, 1, 7, strings.Repeat("a", 2001))
 		assert.ErrorIs(t, err, ErrPostTooLong)
 	})
 
 	t.Run("exactly 2000 chars is accepted", func(t *testing.T) {
-		svc := NewThreadService(&mockThreadStore{})
+		svc := NewThreadService(&mockThreadStore{}, nil, nil)
 		_, err := svc.CreatePost(ctx, 1, 7, strings.Repeat("a", 2000))
 		assert.NoError(t, err)
 	})
 
 	t.Run("HTML is stripped from body", func(t *testing.T) {
-		svc := NewThreadService(&mockThreadStore{})
+		svc := NewThreadService(&mockThreadStore{}, nil, nil)
 		post, err := svc.CreatePost(ctx, 1, 7, "<b>sick play</b>")
 		require.NoError(t, err)
 		assert.Equal(t, "sick play", post.Body)
@@ -112,24 +197,24 @@ func TestThreadService_EditPost(t *testing.T) {
 
 	t.Run("owner can edit their post", func(t *testing.T) {
 		ms := &mockThreadStore{posts: []models.ThreadPost{{ID: 10, UserID: 3, Body: "original"}}}
-		svc := NewThreadService(ms)
+		svc := NewThreadService(ms, nil, nil)
 		assert.NoError(t, svc.EditPost(ctx, 10, 3, "updated"))
 	})
 
 	t.Run("non-owner gets ErrNotOwner", func(t *testing.T) {
 		ms := &mockThreadStore{posts: []models.ThreadPost{{ID: 10, UserID: 3, Body: "original"}}}
-		svc := NewThreadService(ms)
+		svc := NewThreadService(ms, nil, nil)
 		assert.ErrorIs(t, svc.EditPost(ctx, 10, 99, "hacked"), ErrNotOwner)
 	})
 
 	t.Run("empty edit body returns ErrPostEmpty", func(t *testing.T) {
 		ms := &mockThreadStore{posts: []models.ThreadPost{{ID: 10, UserID: 3, Body: "original"}}}
-		svc := NewThreadService(ms)
+		svc := NewThreadService(ms, nil, nil)
 		assert.ErrorIs(t, svc.EditPost(ctx, 10, 3, "  "), ErrPostEmpty)
 	})
 
 	t.Run("post not found returns an error", func(t *testing.T) {
-		svc := NewThreadService(&mockThreadStore{})
+		svc := NewThreadService(&mockThreadStore{}, nil, nil)
 		assert.Error(t, svc.EditPost(ctx, 999, 1, "body"))
 	})
 }
@@ -139,13 +224,13 @@ func TestThreadService_DeletePost(t *testing.T) {
 
 	t.Run("owner can delete their post", func(t *testing.T) {
 		ms := &mockThreadStore{posts: []models.ThreadPost{{ID: 5, UserID: 2}}}
-		svc := NewThreadService(ms)
+		svc := NewThreadService(ms, nil, nil)
 		assert.NoError(t, svc.DeletePost(ctx, 5, 2))
 	})
 
 	t.Run("non-owner gets ErrNotOwner", func(t *testing.T) {
 		ms := &mockThreadStore{posts: []models.ThreadPost{{ID: 5, UserID: 2}}}
-		svc := NewThreadService(ms)
+		svc := NewThreadService(ms, nil, nil)
 		assert.ErrorIs(t, svc.DeletePost(ctx, 5, 99), ErrNotOwner)
 	})
 }
@@ -156,10 +241,233 @@ func TestThreadService_GetThread(t *testing.T) {
 		posts: []models.ThreadPost{{ID: 1, Body: "first"}, {ID: 2, Body: "second"}},
 		total: 2,
 	}
-	svc := NewThreadService(ms)
-	posts, total, threadID, err := svc.GetThread(ctx, 42, 1, 25)
+	svc := NewThreadService(ms, nil, nil)
+	posts, total, thread, err := svc.GetThread(ctx, 42, store.PostOrderFlat, 1, 25)
 	require.NoError(t, err)
 	assert.EqualValues(t, 2, total)
 	assert.Len(t, posts, 2)
-	assert.EqualValues(t, 1, threadID)
+	assert.EqualValues(t, 1, thread.ID)
+}
+
+func TestThreadService_GetThreadTree(t *testing.T) {
+	// Depth-first, as the store returns tree order.
+	ms := &mockThreadStore{
+		posts: []models.ThreadPost{
+			{ID: 1, Body: "root"},
+			{ID: 3, ParentID: uptr(1), Depth: 1, Body: "reply"},
+			{ID: 4, ParentID: uptr(3), Depth: 2, Body: "reply to reply"},
+			{ID: 5, ParentID: uptr(1), Depth: 1, Body: "second reply"},
+			{ID: 2, Body: "other root"},
+		},
+		total: 2,
+	}
+	svc := NewThreadService(ms, nil, nil)
+	posts, total, _, err := svc.GetThread(context.Background(), 42, store.PostOrderTree, 1, 25)
+	require.NoError(t, err)
+	assert.Equal(t, store.PostOrderTree, ms.order)
+	assert.EqualValues(t, 2, total)
+
+	require.Len(t, posts, 2)
+	assert.Equal(t, uint(1), posts[0].ID)
+	require.Len(t, posts[0].Replies, 2)
+	assert.Equal(t, uint(3), posts[0].Replies[0].ID)
+	require.Len(t, posts[0].Replies[0].Replies, 1)
+	assert.Equal(t, uint(4), posts[0].Replies[0].Replies[0].ID)
+	assert.Equal(t, uint(5), posts[0].Replies[1].ID)
+	assert.Equal(t, uint(2), posts[1].ID)
+	assert.Empty(t, posts[1].Replies)
+}
+
+func TestParsePostOrder(t *testing.T) {
+	for in, want := range map[string]store.PostOrder{"": store.PostOrderFlat, "flat": store.PostOrderFlat, "tree": store.PostOrderTree} {
+		got, err := ParsePostOrder(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParsePostOrder("newest")
+	assert.ErrorIs(t, err, ErrInvalidPostOrder)
+}
+
+func TestThreadService_CreateReply(t *testing.T) {
+	ctx := context.Background()
+	posts := []models.ThreadPost{
+		{ID: 10, ThreadID: 1, Depth: 0},
+		{ID: 11, ThreadID: 1, Depth: MaxReplyDepth},
+		{ID: 12, ThreadID: 2, Depth: 0},
+	}
+
+	t.Run("reply sits one level below its parent", func(t *testing.T) {
+		svc := NewThreadService(&mockThreadStore{posts: posts}, nil, nil)
+		post, err := svc.CreateReply(ctx, 1, 7, 10, "agreed")
+		require.NoError(t, err)
+		require.NotNil(t, post.ParentID)
+		assert.Equal(t, uint(10), *post.ParentID)
+		assert.Equal(t, 1, post.Depth)
+	})
+
+	t.Run("missing parent", func(t *testing.T) {
+		svc := NewThreadService(&mockThreadStore{posts: posts}, nil, nil)
+		_, err := svc.CreateReply(ctx, 1, 7, 404, "hello?")
+		assert.ErrorIs(t, err, ErrParentNotFound)
+	})
+
+	t.Run("parent in another thread", func(t *testing.T) {
+		svc := NewThreadService(&mockThreadStore{posts: posts}, nil, nil)
+		_, err := svc.CreateReply(ctx, 1, 7, 12, "wrong match")
+		assert.ErrorIs(t, err, ErrParentNotFound)
+	})
+
+	t.Run("parent at max depth", func(t *testing.T) {
+		svc := NewThreadService(&mockThreadStore{posts: posts}, nil, nil)
+		_, err := svc.CreateReply(ctx, 1, 7, 11, "too deep")
+		assert.ErrorIs(t, err, ErrReplyTooDeep)
+	})
+}
+
+func TestParseMentions(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"gg @Corbyn", []string{"corbyn"}},
+		{"@Scump and @scump again, @Shotzzy.", []string{"scump", "shotzzy"}},
+		{"mail me at fan@example.com", nil},
+		{"@ab is too short", nil},
+		{"(@dashy-guy) nice", []string{"dashy-guy"}},
+		{"no mentions here", nil},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, parseMentions(c.in), "input: %q", c.in)
+	}
+
+	many := ""
+	for i := 0; i < maxMentions+5; i++ {
+		many += fmt.Sprintf("@user%02d ", i)
+	}
+	assert.Len(t, parseMentions(many), maxMentions)
+}
+
+func TestThreadService_Mentions(t *testing.T) {
+	ctx := context.Background()
+	ms := &mockThreadStore{
+		posts: []models.ThreadPost{{ID: 10, UserID: 7, Body: "original"}},
+		users: []models.User{{ID: 7, Username: "Author"}, {ID: 8, Username: "Corbyn"}, {ID: 9, Username: "Scump"}},
+	}
+	svc := NewThreadService(ms, nil, nil)
+
+	_, err := svc.CreatePost(ctx, 1, 7, "@corbyn @nobody @author what a map")
+	require.NoError(t, err)
+	assert.Equal(t, []uint{8}, ms.mentions, "unknown names and the author are dropped")
+
+	require.NoError(t, svc.EditPost(ctx, 10, 7, "actually @SCUMP"))
+	assert.Equal(t, []uint{9}, ms.mentions)
+}
+
+func TestValidEmoji(t *testing.T) {
+	for _, ok := range []string{"🔥", "❤️", "👍🏽", "👨‍👩‍👧", "🇺🇸", "1️⃣", "🐐", "❤️‍🔥", "🏴󠁧󠁢󠁳󠁣󠁴󠁿"} {
+		assert.True(t, validEmoji(ok), "%q should be accepted", ok)
+	}
+	for _, bad := range []string{"", "lol", "🔥x", "1", "<b>", strings.Repeat("🔥", 9),
+		"🔥🔥", "👍👎", "🇺🇸🇨🇦", "🇺", "🔥‍", "‍🔥", "1️⃣2️⃣"} {
+		assert.False(t, validEmoji(bad), "%q should be rejected", bad)
+	}
+}
+
+func TestThreadService_Reactions(t *testing.T) {
+	ctx := context.Background()
+	ms := &mockThreadStore{posts: []models.ThreadPost{{ID: 10, ThreadID: 1, UserID: 3}}}
+	svc := NewThreadService(ms, nil, nil)
+
+	_, err := svc.React(ctx, 10, 7, "🔥")
+	require.NoError(t, err)
+	counts, err := svc.React(ctx, 10, 7, "🔥")
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReactionCount{{Emoji: "🔥", Count: 1}}, counts, "one reaction per user and emoji")
+
+	counts, err = svc.React(ctx, 10, 8, "🔥")
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReactionCount{{Emoji: "🔥", Count: 2}}, counts)
+
+	counts, err = svc.Unreact(ctx, 10, 7, "🔥")
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReactionCount{{Emoji: "🔥", Count: 1}}, counts)
+
+	_, err = svc.React(ctx, 10, 7, "nice")
+	assert.ErrorIs(t, err, ErrInvalidEmoji)
+	_, err = svc.Unreact(ctx, 10, 7, "🔥🔥")
+	assert.ErrorIs(t, err, ErrInvalidEmoji)
+	_, err = svc.React(ctx, 404, 7, "🔥")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func nextThreadEvent(t *testing.T, ch <-chan []byte) ThreadEvent {
+	t.Helper()
+	select {
+	case payload := <-ch:
+		var ev ThreadEvent
+		require.NoError(t, json.Unmarshal(payload, &ev))
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no thread event published")
+		return ThreadEvent{}
+	}
+}
+
+func TestThreadService_PublishesEvents(t *testing.T) {
+	ctx := context.Background()
+	ms := &mockThreadStore{
+		thread: &models.MatchThread{ID: 3, MatchID: 42},
+		posts:  []models.ThreadPost{{ID: 10, ThreadID: 3, UserID: 7, Body: "original"}},
+	}
+	hub := pubsub.NewMemoryHub(pubsub.DefaultBuffer)
+	svc := NewThreadService(ms, hub, nil)
+	events, cancel, err := svc.Subscribe(ctx, 42)
+	require.NoError(t, err)
+	defer cancel()
+
+	_, err = svc.CreatePost(ctx, 3, 7, "first!")
+	require.NoError(t, err)
+	ev := nextThreadEvent(t, events)
+	assert.Equal(t, ThreadPostCreated, ev.Type)
+	assert.Equal(t, uint(42), ev.MatchID)
+	assert.Equal(t, uint(99), ev.PostID)
+	require.NotNil(t, ev.Post)
+	assert.Equal(t, "first!", ev.Post.Body)
+
+	require.NoError(t, svc.EditPost(ctx, 10, 7, "<i>edited</i>"))
+	ev = nextThreadEvent(t, events)
+	assert.Equal(t, ThreadPostEdited, ev.Type)
+	assert.Equal(t, uint(10), ev.PostID)
+	assert.Equal(t, "edited", ev.Body)
+	assert.Nil(t, ev.Post)
+
+	require.NoError(t, svc.DeletePost(ctx, 10, 7))
+	ev = nextThreadEvent(t, events)
+	assert.Equal(t, ThreadPostDeleted, ev.Type)
+	assert.Equal(t, uint(10), ev.PostID)
+
+	// Rejected changes publish nothing.
+	assert.ErrorIs(t, svc.DeletePost(ctx, 10, 8), ErrNotOwner)
+	other, cancelOther, err := svc.Subscribe(ctx, 43)
+	require.NoError(t, err)
+	defer cancelOther()
+	_, err = svc.CreatePost(ctx, 3, 7, "   ")
+	assert.ErrorIs(t, err, ErrPostEmpty)
+	assert.Empty(t, events)
+	assert.Empty(t, other)
+}
+
+func TestThreadService_SubscribeUnknownMatch(t *testing.T) {
+	svc := NewThreadService(&mockThreadStore{noMatch: true}, pubsub.NewMemoryHub(pubsub.DefaultBuffer), nil)
+	_, _, err := svc.Subscribe(context.Background(), 42)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestThreadService_SubscribeWithoutHub(t *testing.T) {
+	svc := NewThreadService(&mockThreadStore{}, nil, nil)
+	events, cancel, err := svc.Subscribe(context.Background(), 42)
+	require.NoError(t, err)
+	defer cancel()
+	_, open := <-events
+	assert.False(t, open, "with no hub the stream is already closed")
 }
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+)
+
+var ErrInvalidWebhook = errors.New("invalid webhook subscription")
+var ErrInvalidReplay = errors.New("replay needs since before until")
+
+// Headers sent with every webhook delivery. The signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	WebhookEventHeader     = "X-CDL-Event"
+	WebhookDeliveryHeader  = "X-CDL-Delivery"
+	WebhookTimestampHeader = "X-CDL-Timestamp"
+	WebhookSignatureHeader = "X-CDL-Signature"
+)
+
+// SignWebhook returns the X-CDL-Signature value for a body sent at the given Unix time.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// requireAdmin fails with ErrAdminRequired unless the user is an admin.
+func requireAdmin(user *models.User) error {
+	if user == nil || user.Role != models.RoleAdmin {
+		return ErrAdminRequired
+	}
+	return nil
+}
+
+// WebhookInput is the body of POST /admin/webhooks.
+type WebhookInput struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+}
+
+// CreatedWebhook is a new subscription together with its signing secret, which is
+// never returned again.
+type CreatedWebhook struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhookService manages outbound webhook subscriptions. Every method is admin-only.
+type WebhookService struct {
+	store store.WebhookStore
+	now   func() time.Time
+}
+
+func NewWebhookService(s store.WebhookStore) *WebhookService {
+	return &WebhookService{store: s, now: time.Now}
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// CreateSubscription registers an http(s) endpoint for one or more event types.
+func (ws *WebhookService) CreateSubscription(ctx context.Context, admin *models.User, in WebhookInput) (*CreatedWebhook, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || len(in.URL) > 500 {
+		return nil, fmt.Errorf("%w: url must be an http(s) URL", ErrInvalidWebhook)
+	}
+	description := strings.TrimSpace(in.Description)
+	if utf8.RuneCountInString(description) > 200 {
+		return nil, fmt.Errorf("%w: description is limited to 200 characters", ErrInvalidWebhook)
+	}
+	events := make([]string, 0, len(in.Events))
+	for _, e := range in.Events {
+		if !slices.Contains(models.WebhookEventTypes, e) {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, e)
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: at least one event is required", ErrInvalidWebhook)
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	now := ws.now()
+	sub := models.WebhookSubscription{
+		URL:         in.URL,
+		Description: description,
+		Events:      strings.Join(events, ","),
+		Secret:      secret,
+		Active:      true,
+		CreatedBy:   admin.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := ws.store.CreateSubscription(ctx, &sub); err != nil {
+		return nil, err
+	}
+	return &CreatedWebhook{WebhookSubscription: sub, Secret: secret}, nil
+}
+
+func (ws *WebhookService) ListSubscriptions(ctx context.Context, admin *models.User) ([]models.WebhookSubscription, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, err
+	}
+	return ws.store.ListSubscriptions(ctx)
+}
+
+// DisableSubscription stops new deliveries to a subscription and fails its pending
+// ones on the worker's next pass. The delivery log is kept.
+func (ws *WebhookService) DisableSubscription(ctx context.Context, admin *models.User, id uint) error {
+	if err := requireAdmin(admin); err != nil {
+		return err
+	}
+	return ws.store.SetSubscriptionActive(ctx, id, false, ws.now())
+}
+
+// ListDeliveries pages through a subscription's delivery log; status optionally
+// filters to pending, sent or failed.
+func (ws *WebhookService) ListDeliveries(ctx context.Context, admin *models.User, id uint, status string, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	if err := requireAdmin(admin); err != nil {
+		return nil, 0, err
+	}
+	if status != "" && status != models.DeliveryPending && status != models.DeliverySent && status != models.DeliveryFailed {
+		return nil, 0, fmt.Errorf("%w: unknown status %q", ErrInvalidWebhook, status)
+	}
+	if _, err := ws.store.GetSubscription(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return ws.store.ListDeliveries(ctx, id, status, limit, (page-1)*limit)
+}
+
+// Replay re-sends every event recorded in [since, until) to an active subscription;
+// a zero until means now.
+func (ws *WebhookService) Replay(ctx context.Context, admin *models.User, id uint, since, until time.Time) (int64, error) {
+	if err := requireAdmin(admin); err != nil {
+		return 0, err
+	}
+	now := ws.now()
+	if until.IsZero() {
+		until = now
+	}
+	if since.IsZero() || !since.Before(until) {
+		return 0, ErrInvalidReplay
+	}
+	sub, err := ws.store.GetSubscription(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if !sub.Active {
+		return 0, fmt.Errorf("%w: subscription is disabled", ErrInvalidWebhook)
+	}
+	return ws.store.Replay(ctx, id, since, until, now)
+}
+
+// WebhookSender POSTs a signed body; notify.Poster implements it.
+type WebhookSender interface {
+	Post(ctx context.Context, url string, headers map[string]string, body []byte) (int, error)
+}
+
+// WebhookWorkerConfig tunes the webhook worker the same way NotifyWorkerConfig tunes
+// the notification worker.
+type WebhookWorkerConfig struct {
+	Lookback    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+func DefaultWebhookWorkerConfig() WebhookWorkerConfig {
+	return WebhookWorkerConfig{
+		Lookback:    24 * time.Hour,
+		BatchSize:   100,
+		MaxAttempts: 8,
+		Backoff:     30 * time.Second,
+		MaxBackoff:  12 * time.Hour,
+	}
+}
+
+// WebhookRun counts what one worker pass did.
+type WebhookRun struct {
+	Collected int64 `json:"collected"`
+	Queued    int64 `json:"queued"`
+	Sent      int   `json:"sent"`
+	Retried   int   `json:"retried"`
+	Failed    int   `json:"failed"`
+}
+
+// webhookEnvelope is the JSON body of every delivery.
+type webhookEnvelope struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookWorker fills the outbox, fans events out to subscriptions and delivers
+// them. Only one worker should run against a database at a time.
+type WebhookWorker struct {
+	store  store.WebhookStore
+	sender WebhookSender
+	cfg    WebhookWorkerConfig
+	now    func() time.Time
+}
+
+func NewWebhookWorker(s store.WebhookStore, sender WebhookSender, cfg WebhookWorkerConfig) *WebhookWorker {
+	return &WebhookWorker{store: s, sender: sender, cfg: cfg, now: time.Now}
+}
+
+// RunOnce collects new events, queues them for their subscribers and sends one
+// batch of due deliveries, logging every attempt.
+func (w *WebhookWorker) RunOnce(ctx context.Context) (WebhookRun, error) {
+	var run WebhookRun
+	now := w.now()
+	since := now.Add(-w.cfg.Lookback)
+
+	collected, err := w.store.CollectEvents(ctx, since, now)
+	if err != nil {
+		return run, err
+	}
+	run.Collected = collected
+
+	queued, err := w.store.QueueDeliveries(ctx, since, now)
+	if err != nil {
+		return run, err
+	}
+	run.Queued = queued
+
+	due, err := w.store.ListDueDeliveries(ctx, now, w.cfg.BatchSize)
+	if err != nil {
+		return run, err
+	}
+	for _, d := range due {
+		attempt := &models.WebhookAttempt{DeliveryID: d.DeliveryID, Attempt: d.Attempts + 1}
+		var update store.WebhookDeliveryUpdate
+
+		if !d.Active {
+			attempt.Error = "subscription disabled"
+		} else {
+			start := w.now()
+			attempt.StatusCode, err = w.send(ctx, d)
+			attempt.DurationMS = int(w.now().Sub(start).Milliseconds())
+			if err != nil {
+				attempt.Error = err.Error()
+			}
+		}
+		attempt.CreatedAt = w.now()
+		update.StatusCode, update.Error = attempt.StatusCode, attempt.Error
+
+		switch {
+		case d.Active && attempt.Error == "":
+			update.Status, update.SentAt = models.DeliverySent, &attempt.CreatedAt
+			run.Sent++
+		case !d.Active || attempt.Attempt >= w.cfg.MaxAttempts:
+			update.Status = models.DeliveryFailed
+			run.Failed++
+		default:
+			update.Status = models.DeliveryPending
+			update.NextAttemptAt = attempt.CreatedAt.Add(retryBackoff(w.cfg.Backoff, w.cfg.MaxBackoff, attempt.Attempt))
+			run.Retried++
+		}
+		if err := w.store.RecordAttempt(ctx, attempt, update); err != nil {
+			return run, err
+		}
+	}
+	return run, nil
+}
+
+func (w *WebhookWorker) send(ctx context.Context, d store.DueWebhook) (int, error) {
+	body, err := json.Marshal(webhookEnvelope{ID: d.EventID, Type: d.Type, CreatedAt: d.CreatedAt, Data: d.Payload})
+	if err != nil {
+		return 0, err
+	}
+	ts := w.now().Unix()
+	return w.sender.Post(ctx, d.URL, map[string]string{
+		WebhookEventHeader:     d.Type,
+		WebhookDeliveryHeader:  strconv.FormatUint(uint64(d.DeliveryID), 10),
+		WebhookTimestampHeader: strconv.FormatInt(ts, 10),
+		WebhookSignatureHeader: SignWebhook(d.Secret, ts, body),
+	}, body)
+}
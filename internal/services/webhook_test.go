@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type recordedAttempt struct {
+	attempt models.WebhookAttempt
+	update  store.WebhookDeliveryUpdate
+}
+
+type mockWebhookStore struct {
+	subs     map[uint]*models.WebhookSubscription
+	created  *models.WebhookSubscription
+	due      []store.DueWebhook
+	attempts map[uint]recordedAttempt
+	replayed []time.Time
+}
+
+func (m *mockWebhookStore) CreateSubscription(_ context.Context, sub *models.WebhookSubscription) error {
+	sub.ID = 7
+	m.created = sub
+	return nil
+}
+func (m *mockWebhookStore) ListSubscriptions(context.Context) ([]models.WebhookSubscription, error) {
+	return nil, nil
+}
+func (m *mockWebhookStore) GetSubscription(_ context.Context, id uint) (*models.WebhookSubscription, error) {
+	if sub, ok := m.subs[id]; ok {
+		return sub, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (m *mockWebhookStore) SetSubscriptionActive(context.Context, uint, bool, time.Time) error {
+	return nil
+}
+func (m *mockWebhookStore) CollectEvents(context.Context, time.Time, time.Time) (int64, error) {
+	return 3, nil
+}
+func (m *mockWebhookStore) QueueDeliveries(context.Context, time.Time, time.Time) (int64, error) {
+	return int64(len(m.due)), nil
+}
+func (m *mockWebhookStore) ListDueDeliveries(context.Context, time.Time, int) ([]store.DueWebhook, error) {
+	return m.due, nil
+}
+func (m *mockWebhookStore) RecordAttempt(_ context.Context, a *models.WebhookAttempt, u store.WebhookDeliveryUpdate) error {
+	m.attempts[a.DeliveryID] = recordedAttempt{attempt: *a, update: u}
+	return nil
+}
+func (m *mockWebhookStore) ListDeliveries(context.Context, uint, string, int, int) ([]models.WebhookDelivery, int64, error) {
+	return nil, 0, nil
+}
+func (m *mockWebhookStore) Replay(_ context.Context, _ uint, since, until, _ time.Time) (int64, error) {
+	m.replayed = []time.Time{since, until}
+	return 4, nil
+}
+
+type sentWebhook struct {
+	url     string
+	headers map[string]string
+	body    []byte
+}
+
+// fakeSender answers with the status configured for each URL (200 when unset).
+type fakeSender struct {
+	status map[string]int
+	sent   []sentWebhook
+}
+
+func (f *fakeSender) Post(_ context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	f.sent = append(f.sent, sentWebhook{url: url, headers: headers, body: body})
+	if code, ok := f.status[url]; ok {
+		return code, errors.New("webhook responded " + strconv.Itoa(code))
+	}
+	return 200, nil
+}
+
+var webhookAdmin = &models.User{ID: 1, Role: models.RoleAdmin}
+
+func TestSignWebhook(t *testing.T) {
+	// printf '1700000000.{"id":1}' | openssl dgst -sha256 -hmac whsec_test
+	assert.Equal(t,
+		"sha256=2f441ba4b3b2d50d28a9ab9d9fd8880376ecd1eb5d0435401553f5d8d0a5dcf8",
+		SignWebhook("whsec_test", 1700000000, []byte(`{"id":1}`)))
+}
+
+func TestWebhookWorker_RunOnce(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	ms := &mockWebhookStore{
+		attempts: map[uint]recordedAttempt{},
+		due: []store.DueWebhook{
+			{DeliveryID: 1, URL: "https://bot.example.com", Secret: "whsec_a", Active: true,
+				EventID: 10, Type: models.EventMatchCompleted, Payload: json.RawMessage(`{"match_id":1}`), CreatedAt: now},
+			{DeliveryID: 2, URL: "https://down.example.com", Secret: "whsec_b", Active: true, Attempts: 2,
+				EventID: 11, Type: models.EventMapCompleted, Payload: json.RawMessage(`{}`)},
+			{DeliveryID: 3, URL: "https://down.example.com", Secret: "whsec_b", Active: true, Attempts: 7,
+				EventID: 12, Type: models.EventMapCompleted, Payload: json.RawMessage(`{}`)},
+			{DeliveryID: 4, URL: "https://bot.example.com", Active: false, EventID: 13, Payload: json.RawMessage(`{}`)},
+		},
+	}
+	sender := &fakeSender{status: map[string]int{"https://down.example.com": 503}}
+	w := NewWebhookWorker(ms, sender, DefaultWebhookWorkerConfig())
+	w.now = func() time.Time { return now }
+
+	run, err := w.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, WebhookRun{Collected: 3, Queued: 4, Sent: 1, Retried: 1, Failed: 2}, run)
+	require.Len(t, sender.sent, 3, "a disabled subscription is not called")
+
+	first := sender.sent[0]
+	assert.Equal(t, models.EventMatchCompleted, first.headers[WebhookEventHeader])
+	assert.Equal(t, "1", first.headers[WebhookDeliveryHeader])
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), first.headers[WebhookTimestampHeader])
+	assert.Equal(t, SignWebhook("whsec_a", now.Unix(), first.body), first.headers[WebhookSignatureHeader])
+	var envelope map[string]any
+	require.NoError(t, json.Unmarshal(first.body, &envelope))
+	assert.EqualValues(t, 10, envelope["id"])
+	assert.Equal(t, map[string]any{"match_id": float64(1)}, envelope["data"])
+
+	sent := ms.attempts[1]
+	assert.Equal(t, models.DeliverySent, sent.update.Status)
+	assert.Equal(t, 200, sent.attempt.StatusCode)
+	assert.Equal(t, 1, sent.attempt.Attempt)
+	require.NotNil(t, sent.update.SentAt)
+
+	retry := ms.attempts[2]
+	assert.Equal(t, models.DeliveryPending, retry.update.Status)
+	assert.Equal(t, 3, retry.attempt.Attempt)
+	assert.Equal(t, 503, retry.update.StatusCode)
+	assert.Equal(t, now.Add(2*time.Minute), retry.update.NextAttemptAt, "30s doubled twice")
+
+	assert.Equal(t, models.DeliveryFailed, ms.attempts[3].update.Status, "gives up after MaxAttempts")
+	assert.Equal(t, models.DeliveryFailed, ms.attempts[4].update.Status)
+	assert.Equal(t, "subscription disabled", ms.attempts[4].attempt.Error)
+}
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	ms := &mockWebhookStore{}
+	ws := NewWebhookService(ms)
+	ctx := context.Background()
+
+	_, err := ws.CreateSubscription(ctx, &models.User{ID: 2, Role: models.RoleModerator},
+		WebhookInput{URL: "https://bot.example.com", Events: []string{models.EventMatchCompleted}})
+	assert.ErrorIs(t, err, ErrAdminRequired)
+
+	for name, in := range map[string]WebhookInput{
+		"no events":     {URL: "https://bot.example.com"},
+		"unknown event": {URL: "https://bot.example.com", Events: []string{"match.started"}},
+		"bad scheme":    {URL: "ftp://bot.example.com", Events: []string{models.EventMatchCompleted}},
+		"no host":       {URL: "https:///hook", Events: []string{models.EventMatchCompleted}},
+	} {
+		_, err := ws.CreateSubscription(ctx, webhookAdmin, in)
+		assert.ErrorIs(t, err, ErrInvalidWebhook, name)
+	}
+	assert.Nil(t, ms.created)
+
+	created, err := ws.CreateSubscription(ctx, webhookAdmin, WebhookInput{
+		URL:    "https://bot.example.com/cdl",
+		Events: []string{models.EventTransferCreated, models.EventMatchCompleted, models.EventTransferCreated},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "transfer.created,match.completed", ms.created.Events)
+	assert.True(t, ms.created.Active)
+	assert.Regexp(t, `^whsec_[0-9a-f]{48}$`, created.Secret)
+	assert.Equal(t, created.Secret, ms.created.Secret)
+}
+
+func TestWebhookService_Replay(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	ms := &mockWebhookStore{subs: map[uint]*models.WebhookSubscription{
+		1: {ID: 1, Active: true},
+		2: {ID: 2, Active: false},
+	}}
+	ws := NewWebhookService(ms)
+	ws.now = func() time.Time { return now }
+	ctx := context.Background()
+	since := now.Add(-time.Hour)
+
+	_, err := ws.Replay(ctx, webhookAdmin, 1, now, time.Time{})
+	assert.ErrorIs(t, err, ErrInvalidReplay, "since must be before until")
+	_, err = ws.Replay(ctx, webhookAdmin, 2, since, time.Time{})
+	assert.ErrorIs(t, err, ErrInvalidWebhook, "disabled subscriptions cannot replay")
+	_, err = ws.Replay(ctx, webhookAdmin, 3, since, time.Time{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	n, err := ws.Replay(ctx, webhookAdmin, 1, since, time.Time{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, n)
+	assert.Equal(t, []time.Time{since, now}, ms.replayed, "until defaults to now")
+}
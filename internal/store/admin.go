@@ -0,0 +1,417 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AdminStore writes matches, maps and player stat lines for the admin API. Every
+// write rebuilds the derived player_match_stats, player_tournament_stats and
+// team_tournament_stats rows it affects in the same transaction, and records each
+// changed row in change_log with the entry's actor and reason.
+type AdminStore interface {
+	GetMatch(ctx context.Context, id uint) (*models.Match, error)
+	ListMatchMaps(ctx context.Context, matchID uint) ([]models.MatchMap, error)
+	ListMatchLines(ctx context.Context, matchID uint) ([]models.PlayerMapStats, error)
+	CountMatchReferences(ctx context.Context, matchID uint) (int64, error)
+	PlayerOnTeam(ctx context.Context, playerID, teamID, seasonID uint, at time.Time) (bool, error)
+	CreateMatch(ctx context.Context, m *models.Match, entry *models.ChangeLog) error
+	UpdateMatch(ctx context.Context, m *models.Match, prevTournamentID uint, entry *models.ChangeLog) error
+	DeleteMatch(ctx context.Context, m *models.Match, entry *models.ChangeLog) error
+	SaveMap(ctx context.Context, mm *models.MatchMap, tournamentID uint, bestOf int, entry *models.ChangeLog) (*models.Match, error)
+	DeleteMap(ctx context.Context, matchID uint, mapNumber int, tournamentID uint, bestOf int, entry *models.ChangeLog) (*models.Match, error)
+	SaveStatLine(ctx context.Context, line *models.PlayerMapStats, tournamentID uint, entry *models.ChangeLog) error
+	DeleteStatLine(ctx context.Context, matchID uint, mapNumber int, playerID, tournamentID uint, entry *models.ChangeLog) error
+	GetChange(ctx context.Context, id uint) (*models.ChangeLog, error)
+	ListChanges(ctx context.Context, f ChangeFilter, limit, offset int) ([]models.ChangeLog, int64, error)
+	RevertChange(ctx context.Context, change *models.ChangeLog, bestOf int, entry *models.ChangeLog) (*models.ChangeLog, error)
+}
+
+type gormAdminStore struct{ db *gorm.DB }
+
+func NewGormAdminStore(db *gorm.DB) AdminStore { return &gormAdminStore{db: db} }
+
+func (s *gormAdminStore) GetMatch(ctx context.Context, id uint) (*models.Match, error) {
+	var match models.Match
+	if err := s.db.WithContext(ctx).First(&match, id).Error; err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+func (s *gormAdminStore) ListMatchMaps(ctx context.Context, matchID uint) ([]models.MatchMap, error) {
+	maps := make([]models.MatchMap, 0)
+	err := s.db.WithContext(ctx).Where("match_id = ?", matchID).Order("map_number ASC").Find(&maps).Error
+	return maps, err
+}
+
+func (s *gormAdminStore) ListMatchLines(ctx context.Context, matchID uint) ([]models.PlayerMapStats, error) {
+	lines := make([]models.PlayerMapStats, 0)
+	err := s.db.WithContext(ctx).Where("match_id = ?", matchID).Order("map_number ASC, player_id ASC").Find(&lines).Error
+	return lines, err
+}
+
+// CountMatchReferences counts user data hanging off a match (pick'em picks and a
+// discussion thread) that deleting it would orphan.
+func (s *gormAdminStore) CountMatchReferences(ctx context.Context, matchID uint) (int64, error) {
+	var n int64
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT (SELECT COUNT(*) FROM picks WHERE match_id = @match)
+		     + (SELECT COUNT(*) FROM match_threads WHERE match_id = @match)
+	`, map[string]any{"match": matchID}).Scan(&n).Error
+	return n, err
+}
+
+// PlayerOnTeam reports whether the player was on the team at the given time: either
+// a roster entry for the season covers it, or the player has already logged maps for
+// the team that season (older eras have stats but no roster rows).
+func (s *gormAdminStore) PlayerOnTeam(ctx context.Context, playerID, teamID, seasonID uint, at time.Time) (bool, error) {
+	var ok bool
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM team_rosters r
+			WHERE r.player_id = @player AND r.team_id = @team AND r.season_id = @season
+			  AND r.start_date <= @at AND (r.end_date IS NULL OR r.end_date >= @at)
+		) OR EXISTS (
+			SELECT 1 FROM player_map_stats pms
+			JOIN matches m      ON m.id = pms.match_id
+			JOIN tournaments tn ON tn.id = m.tournament_id
+			WHERE pms.player_id = @player AND pms.team_id = @team AND tn.season_id = @season
+		)
+	`, map[string]any{"player": playerID, "team": teamID, "season": seasonID, "at": at}).Scan(&ok).Error
+	return ok, err
+}
+
+func (s *gormAdminStore) CreateMatch(ctx context.Context, m *models.Match, entry *models.ChangeLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(m).Error; err != nil {
+			return err
+		}
+		key := ChangeKey(m.ID)
+		after, err := snapshot(tx, models.ChangeMatch, key)
+		if err != nil {
+			return err
+		}
+		if _, err := recordChange(tx, entry, models.ChangeMatch, key, nil, after); err != nil {
+			return err
+		}
+		return rebuildDerived(tx, 0, m.TournamentID)
+	})
+}
+
+// UpdateMatch saves the match header. When the match moved tournament both the old
+// and the new tournament's aggregates are rebuilt.
+func (s *gormAdminStore) UpdateMatch(ctx context.Context, m *models.Match, prevTournamentID uint, entry *models.ChangeLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := trackChange(tx, entry, models.ChangeMatch, ChangeKey(m.ID), func() error {
+			return tx.Omit(clause.Associations).Save(m).Error
+		})
+		if err != nil {
+			return err
+		}
+		if prevTournamentID != m.TournamentID {
+			if err := rebuildDerived(tx, 0, prevTournamentID); err != nil {
+				return err
+			}
+		}
+		return rebuildDerived(tx, m.ID, m.TournamentID)
+	})
+}
+
+// DeleteMatch removes the match with its maps and stat lines.
+func (s *gormAdminStore) DeleteMatch(ctx context.Context, m *models.Match, entry *models.ChangeLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteMatchTracked(tx, entry, m.ID); err != nil {
+			return err
+		}
+		return rebuildDerived(tx, 0, m.TournamentID)
+	})
+}
+
+// deleteMatchTracked deletes a match's stat lines, maps and then the match, recording
+// each row; the caller rebuilds the tournament.
+func deleteMatchTracked(tx *gorm.DB, entry *models.ChangeLog, matchID uint) error {
+	if err := deleteLines(tx, entry, matchID, 0); err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM player_match_stats WHERE match_id = ?", matchID).Error; err != nil {
+		return err
+	}
+	var maps []models.MatchMap
+	if err := tx.Select("match_id, map_number").Where("match_id = ?", matchID).Find(&maps).Error; err != nil {
+		return err
+	}
+	for _, mm := range maps {
+		if err := deleteRow(tx, entry, models.ChangeMap, ChangeKey(mm.MatchID, mm.MapNumber)); err != nil {
+			return err
+		}
+	}
+	return deleteRow(tx, entry, models.ChangeMatch, ChangeKey(matchID))
+}
+
+// SaveMap upserts one map and recounts the series score from the finished maps.
+func (s *gormAdminStore) SaveMap(ctx context.Context, mm *models.MatchMap, tournamentID uint, bestOf int, entry *models.ChangeLog) (*models.Match, error) {
+	var match models.Match
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := trackChange(tx, entry, models.ChangeMap, ChangeKey(mm.MatchID, mm.MapNumber), func() error {
+			return tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "match_id"}, {Name: "map_number"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"map_name", "mode", "score1", "score2", "winner_id", "played", "duration_sec", "source", "updated_at",
+				}),
+			}).Omit(clause.Associations).Create(mm).Error
+		})
+		if err != nil {
+			return err
+		}
+		if err := recountTracked(tx, entry, &match, mm.MatchID, bestOf); err != nil {
+			return err
+		}
+		return rebuildDerived(tx, mm.MatchID, tournamentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// DeleteMap removes one map with its stat lines and recounts the series score.
+func (s *gormAdminStore) DeleteMap(ctx context.Context, matchID uint, mapNumber int, tournamentID uint, bestOf int, entry *models.ChangeLog) (*models.Match, error) {
+	var match models.Match
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteLines(tx, entry, matchID, mapNumber); err != nil {
+			return err
+		}
+		if err := deleteRow(tx, entry, models.ChangeMap, ChangeKey(matchID, mapNumber)); err != nil {
+			return err
+		}
+		if err := recountTracked(tx, entry, &match, matchID, bestOf); err != nil {
+			return err
+		}
+		return rebuildDerived(tx, matchID, tournamentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+func (s *gormAdminStore) SaveStatLine(ctx context.Context, line *models.PlayerMapStats, tournamentID uint, entry *models.ChangeLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		key := ChangeKey(line.MatchID, line.MapNumber, line.PlayerID)
+		err := trackChange(tx, entry, models.ChangeStatLine, key, func() error {
+			return tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "match_id"}, {Name: "map_number"}, {Name: "player_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"team_id", "kills", "deaths", "kd_ratio", "damage", "assists",
+					"hill_time", "snd_rounds", "plant_count", "defuse_count",
+					"first_blood_count", "first_death_count", "zone_tier_capture_count",
+					"non_traded_kills", "highest_streak", "source", "updated_at",
+				}),
+			}).Omit(clause.Associations).Create(line).Error
+		})
+		if err != nil {
+			return err
+		}
+		return rebuildDerived(tx, line.MatchID, tournamentID)
+	})
+}
+
+func (s *gormAdminStore) DeleteStatLine(ctx context.Context, matchID uint, mapNumber int, playerID, tournamentID uint, entry *models.ChangeLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteRow(tx, entry, models.ChangeStatLine, ChangeKey(matchID, mapNumber, playerID)); err != nil {
+			return err
+		}
+		return rebuildDerived(tx, matchID, tournamentID)
+	})
+}
+
+// rebuildMatchStatsSQL replaces a match's player_match_stats with sums of its map
+// lines. A player's team is the one they played the most maps for.
+const rebuildMatchStatsSQL = `
+INSERT INTO player_match_stats
+	(match_id, player_id, team_id, maps_played, total_kills, total_deaths, total_assists,
+	 total_damage, kd_ratio, kda_ratio, rating, created_at, updated_at)
+SELECT @match, t.player_id, dt.team_id, t.maps, t.k, t.d, t.a, t.dmg,
+	CASE WHEN t.d > 0 THEN LEAST(ROUND(t.k::decimal / t.d, 2), 99.99) ELSE 0 END,
+	CASE WHEN t.d > 0 THEN LEAST(ROUND((t.k + t.a)::decimal / t.d, 2), 99.99) ELSE 0 END,
+	COALESCE(ROUND(t.r, 4), 0),
+	@now, @now
+FROM (
+	SELECT player_id,
+		SUM(kills) AS k, SUM(deaths) AS d, SUM(assists) AS a, SUM(damage) AS dmg,
+		COUNT(*) AS maps, AVG(rating) FILTER (WHERE rating > 0) AS r
+	FROM player_map_stats
+	WHERE match_id = @match
+	GROUP BY player_id
+) t
+JOIN (
+	SELECT DISTINCT ON (player_id) player_id, team_id
+	FROM player_map_stats
+	WHERE match_id = @match
+	GROUP BY player_id, team_id
+	ORDER BY player_id, COUNT(*) DESC, team_id
+) dt ON dt.player_id = t.player_id`
+
+// rebuildTournamentPlayerStatsSQL replaces a tournament's player_tournament_stats with
+// totals and mode splits over its played maps. season_summary tournaments have no
+// matches of their own, so their imported rows are never touched; season readers
+// use those and skip these per-tournament rows.
+const rebuildTournamentPlayerStatsSQL = `
+WITH lines AS (
+	SELECT pms.*,
+		CASE
+			WHEN mm.mode IN ('Search and Destroy', 'Search & Destroy') THEN 'snd'
+			WHEN mm.mode = 'Hardpoint' THEN 'hp'
+			WHEN mm.mode = 'Control'   THEN 'control'
+			ELSE 'other'
+		END AS mode_key
+	FROM player_map_stats pms
+	JOIN matches m ON m.id = pms.match_id
+	LEFT JOIN match_maps mm ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number
+	WHERE m.tournament_id = @tournament AND (mm.id IS NULL OR mm.played = true)
+),
+dom_team AS (
+	SELECT DISTINCT ON (player_id) player_id, team_id
+	FROM lines
+	GROUP BY player_id, team_id
+	ORDER BY player_id, COUNT(*) DESC, team_id
+),
+t AS (
+	SELECT player_id,
+		SUM(kills) AS k, SUM(deaths) AS d, SUM(assists) AS a, SUM(damage) AS dmg,
+		COUNT(*) AS maps, AVG(rating) FILTER (WHERE rating > 0) AS r,
+		COALESCE(SUM(kills)  FILTER (WHERE mode_key = 'snd'), 0)     AS snd_k,
+		COALESCE(SUM(deaths) FILTER (WHERE mode_key = 'snd'), 0)     AS snd_d,
+		COALESCE(SUM(first_blood_count) FILTER (WHERE mode_key = 'snd'), 0) AS snd_fb,
+		COUNT(*) FILTER (WHERE mode_key = 'snd')                     AS snd_maps,
+		COALESCE(SUM(kills)  FILTER (WHERE mode_key = 'hp'), 0)      AS hp_k,
+		COALESCE(SUM(deaths) FILTER (WHERE mode_key = 'hp'), 0)      AS hp_d,
+		COUNT(*) FILTER (WHERE mode_key = 'hp')                      AS hp_maps,
+		COALESCE(SUM(kills)  FILTER (WHERE mode_key = 'control'), 0) AS ctl_k,
+		COALESCE(SUM(deaths) FILTER (WHERE mode_key = 'control'), 0) AS ctl_d,
+		COALESCE(SUM(zone_tier_capture_count) FILTER (WHERE mode_key = 'control'), 0) AS ctl_caps,
+		COUNT(*) FILTER (WHERE mode_key = 'control')                 AS ctl_maps
+	FROM lines
+	GROUP BY player_id
+)
+INSERT INTO player_tournament_stats
+	(player_id, team_id, tournament_id, total_kills, total_deaths, total_assists, total_damage,
+	 kd_ratio, kda_ratio, rating, overall_plus_minus, overall_maps,
+	 snd_kills, snd_deaths, snd_kd_ratio, snd_plus_minus, snd_k_per_map, snd_first_kills, snd_maps,
+	 hp_kills, hp_deaths, hp_kd_ratio, hp_plus_minus, hp_k_per_map, hp_maps,
+	 control_kills, control_deaths, control_kd_ratio, control_plus_minus, control_k_per_map,
+	 control_captures, control_maps, created_at, updated_at)
+SELECT t.player_id, dt.team_id, @tournament, t.k, t.d, t.a, t.dmg,
+	CASE WHEN t.d > 0 THEN ROUND(t.k::decimal / t.d, 3) ELSE 0 END,
+	CASE WHEN t.d > 0 THEN ROUND((t.k + t.a)::decimal / t.d, 3) ELSE 0 END,
+	COALESCE(ROUND(t.r, 4), 0),
+	t.k - t.d, t.maps,
+	t.snd_k, t.snd_d,
+	CASE WHEN t.snd_d > 0 THEN ROUND(t.snd_k::decimal / t.snd_d, 3) ELSE 0 END,
+	t.snd_k - t.snd_d,
+	CASE WHEN t.snd_maps > 0 THEN ROUND(t.snd_k::decimal / t.snd_maps, 2) ELSE 0 END,
+	t.snd_fb, t.snd_maps,
+	t.hp_k, t.hp_d,
+	CASE WHEN t.hp_d > 0 THEN ROUND(t.hp_k::decimal / t.hp_d, 3) ELSE 0 END,
+	t.hp_k - t.hp_d,
+	CASE WHEN t.hp_maps > 0 THEN ROUND(t.hp_k::decimal / t.hp_maps, 2) ELSE 0 END,
+	t.hp_maps,
+	t.ctl_k, t.ctl_d,
+	CASE WHEN t.ctl_d > 0 THEN ROUND(t.ctl_k::decimal / t.ctl_d, 3) ELSE 0 END,
+	t.ctl_k - t.ctl_d,
+	CASE WHEN t.ctl_maps > 0 THEN ROUND(t.ctl_k::decimal / t.ctl_maps, 2) ELSE 0 END,
+	t.ctl_caps, t.ctl_maps,
+	@now, @now
+FROM t
+JOIN dom_team dt ON dt.player_id = t.player_id`
+
+// teamTournamentTotalsSQL counts decided series and played maps for every team that
+// plays in the tournament or already has a row for it (so a team moved out of its
+// last match drops to zero rather than keeping stale counts).
+const teamTournamentTotalsSQL = `
+SELECT tm.team_id,
+	COUNT(DISTINCT m.id) FILTER (WHERE m.winner_id IS NOT NULL)  AS matches_played,
+	COUNT(DISTINCT m.id) FILTER (WHERE m.winner_id = tm.team_id) AS matches_won,
+	COUNT(mm.id)                                                 AS maps_played,
+	COUNT(mm.id) FILTER (WHERE mm.winner_id = tm.team_id)        AS maps_won
+FROM (
+	SELECT team1_id AS team_id FROM matches WHERE tournament_id = @tournament
+	UNION SELECT team2_id FROM matches WHERE tournament_id = @tournament
+	UNION SELECT team_id FROM team_tournament_stats WHERE tournament_id = @tournament
+) tm
+LEFT JOIN matches m     ON m.tournament_id = @tournament AND tm.team_id IN (m.team1_id, m.team2_id)
+LEFT JOIN match_maps mm ON mm.match_id = m.id AND mm.played = true AND mm.winner_id IS NOT NULL
+GROUP BY tm.team_id`
+
+// rebuildTeamTournamentStatsSQL updates the counts on existing rows, keeping their
+// placement and prize money, then adds rows for teams that have none.
+var rebuildTeamTournamentStatsSQL = []string{`
+UPDATE team_tournament_stats tts SET
+	matches_played = t.matches_played,
+	matches_won    = t.matches_won,
+	matches_lost   = t.matches_played - t.matches_won,
+	maps_played    = t.maps_played,
+	maps_won       = t.maps_won,
+	maps_lost      = t.maps_played - t.maps_won,
+	updated_at     = @now
+FROM (` + teamTournamentTotalsSQL + `) t
+WHERE tts.tournament_id = @tournament AND tts.team_id = t.team_id`, `
+INSERT INTO team_tournament_stats
+	(tournament_id, team_id, matches_played, matches_won, matches_lost,
+	 maps_played, maps_won, maps_lost, prize_money, created_at, updated_at)
+SELECT @tournament, t.team_id, t.matches_played, t.matches_won, t.matches_played - t.matches_won,
+	t.maps_played, t.maps_won, t.maps_played - t.maps_won, 0, @now, @now
+FROM (` + teamTournamentTotalsSQL + `) t
+WHERE NOT EXISTS (
+	SELECT 1 FROM team_tournament_stats x WHERE x.tournament_id = @tournament AND x.team_id = t.team_id
+)`}
+
+// rebuildDerived recomputes the aggregates that depend on one match's map lines and
+// on its tournament's results. matchID 0 skips the per-match rows (the match is gone
+// or has no lines yet).
+func rebuildDerived(tx *gorm.DB, matchID, tournamentID uint) error {
+	if err := rebuildMatchDerived(tx, matchID); err != nil {
+		return err
+	}
+	return rebuildTournamentDerived(tx, tournamentID)
+}
+
+// rebuildMatchDerived re-rates one match's map lines and recomputes its
+// player_match_stats rows.
+func rebuildMatchDerived(tx *gorm.DB, matchID uint) error {
+	args := map[string]any{"match": matchID, "now": time.Now()}
+	if err := tx.Exec("DELETE FROM player_match_stats WHERE match_id = @match", args).Error; err != nil {
+		return err
+	}
+	if matchID == 0 {
+		return nil
+	}
+	if err := rateMatchLines(tx, matchID); err != nil {
+		return err
+	}
+	return tx.Exec(rebuildMatchStatsSQL, args).Error
+}
+
+// rebuildTournamentDerived recomputes a tournament's player and team totals from its
+// player_match_stats rows and match results, and the ratings of its season's
+// season_summary rows.
+func rebuildTournamentDerived(tx *gorm.DB, tournamentID uint) error {
+	args := map[string]any{"tournament": tournamentID, "now": time.Now()}
+	if err := tx.Exec("DELETE FROM player_tournament_stats WHERE tournament_id = @tournament", args).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(rebuildTournamentPlayerStatsSQL, args).Error; err != nil {
+		return err
+	}
+	for _, q := range rebuildTeamTournamentStatsSQL {
+		if err := tx.Exec(q, args).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Exec(rollupSeasonSummaryRatingsSQL, args).Error
+}
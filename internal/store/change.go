@@ -0,0 +1,382 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrChangeSuperseded is returned by RevertChange when the row no longer looks the way
+// the change left it.
+var ErrChangeSuperseded = errors.New("row has been changed since")
+
+// ChangeFilter narrows the change log; zero fields don't filter.
+type ChangeFilter struct {
+	Entity    string
+	EntityKey string
+	ActorID   uint
+	Since     time.Time
+	Until     time.Time
+}
+
+// changeTable is where a change-log entity lives and the columns of its natural key,
+// in the order they appear in the entity key.
+type changeTable struct {
+	name string
+	keys []string
+}
+
+var changeTables = map[string]changeTable{
+	models.ChangeMatch:    {name: "matches", keys: []string{"id"}},
+	models.ChangeMap:      {name: "match_maps", keys: []string{"match_id", "map_number"}},
+	models.ChangeStatLine: {name: "player_map_stats", keys: []string{"match_id", "map_number", "player_id"}},
+	models.ChangeTeam:     {name: "teams", keys: []string{"id"}},
+
+	models.ChangePlayer:       {name: "players", keys: []string{"id"}},
+	models.ChangeRoster:       {name: "team_rosters", keys: []string{"id"}},
+	models.ChangeCoach:        {name: "coaches", keys: []string{"id"}},
+	models.ChangeTransfer:     {name: "player_transfers", keys: []string{"id"}},
+	models.ChangeTeamTotals:   {name: "team_tournament_stats", keys: []string{"id"}},
+	models.ChangePlayerTotals: {name: "player_tournament_stats", keys: []string{"id"}},
+}
+
+// IsMatchEntity reports whether entity's rows belong to a match: their keys start
+// with the match ID, and writes to them change the match's derived stats.
+func IsMatchEntity(entity string) bool {
+	switch entity {
+	case models.ChangeMatch, models.ChangeMap, models.ChangeStatLine:
+		return true
+	}
+	return false
+}
+
+// ChangeKey formats a natural key the way the change log stores it ("12:2:40").
+func ChangeKey(parts ...any) string {
+	s := make([]string, len(parts))
+	for i, p := range parts {
+		s[i] = fmt.Sprint(p)
+	}
+	return strings.Join(s, ":")
+}
+
+// ParseChangeKey splits an entity key into its numeric parts, checking it has one
+// per key column of the entity.
+func ParseChangeKey(entity, key string) ([]uint, error) {
+	t, ok := changeTables[entity]
+	if !ok {
+		return nil, fmt.Errorf("unknown entity %q", entity)
+	}
+	fields := strings.Split(key, ":")
+	if len(fields) != len(t.keys) {
+		return nil, fmt.Errorf("%s keys have %d parts", entity, len(t.keys))
+	}
+	parts := make([]uint, len(fields))
+	for i, f := range fields {
+		n, err := strconv.ParseUint(f, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s key %q", entity, key)
+		}
+		parts[i] = uint(n)
+	}
+	return parts, nil
+}
+
+// where builds the WHERE clause selecting the row with the given key.
+func (t changeTable) where(entity, key string) (string, map[string]any, error) {
+	parts, err := ParseChangeKey(entity, key)
+	if err != nil {
+		return "", nil, err
+	}
+	conds := make([]string, len(parts))
+	args := make(map[string]any, len(parts))
+	for i, col := range t.keys {
+		name := "k" + strconv.Itoa(i)
+		conds[i] = col + " = @" + name
+		args[name] = parts[i]
+	}
+	return strings.Join(conds, " AND "), args, nil
+}
+
+// snapshot returns the row as to_jsonb renders it, locking it for the rest of the
+// transaction, or nil when there is no such row.
+func snapshot(tx *gorm.DB, entity, key string) (json.RawMessage, error) {
+	t := changeTables[entity]
+	where, args, err := t.where(entity, key)
+	if err != nil {
+		return nil, err
+	}
+	var row []byte
+	err = tx.Raw("SELECT to_jsonb(t) FROM "+t.name+" t WHERE "+where+" FOR UPDATE", args).Row().Scan(&row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return row, err
+}
+
+// recordChange writes one change-log row from the entry's actor and reason, unless
+// the row came out of the write unchanged.
+func recordChange(tx *gorm.DB, entry *models.ChangeLog, entity, key string, before, after json.RawMessage) (*models.ChangeLog, error) {
+	if bytes.Equal(before, after) {
+		return nil, nil
+	}
+	row := changeRow(entry, entity, key, before, after)
+	if err := tx.Create(&row).Error; err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// changeRow builds the change-log row for one write from the entry's actor and reason.
+func changeRow(entry *models.ChangeLog, entity, key string, before, after json.RawMessage) models.ChangeLog {
+	row := *entry
+	row.ID = 0
+	row.Entity, row.EntityKey = entity, key
+	row.Before, row.After = before, after
+	switch {
+	case before == nil:
+		row.Action = models.ChangeCreate
+	case after == nil:
+		row.Action = models.ChangeDelete
+	default:
+		row.Action = models.ChangeUpdate
+	}
+	return row
+}
+
+// trackChange runs write between two snapshots of the row and records the difference.
+func trackChange(tx *gorm.DB, entry *models.ChangeLog, entity, key string, write func() error) error {
+	before, err := snapshot(tx, entity, key)
+	if err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	after, err := snapshot(tx, entity, key)
+	if err != nil {
+		return err
+	}
+	_, err = recordChange(tx, entry, entity, key, before, after)
+	return err
+}
+
+// deleteRow deletes one row by its change key and records the delete; it returns
+// gorm.ErrRecordNotFound when there was nothing to delete.
+func deleteRow(tx *gorm.DB, entry *models.ChangeLog, entity, key string) error {
+	t := changeTables[entity]
+	where, args, err := t.where(entity, key)
+	if err != nil {
+		return err
+	}
+	before, err := snapshot(tx, entity, key)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return gorm.ErrRecordNotFound
+	}
+	if err := tx.Exec("DELETE FROM "+t.name+" WHERE "+where, args).Error; err != nil {
+		return err
+	}
+	_, err = recordChange(tx, entry, entity, key, before, nil)
+	return err
+}
+
+// deleteLines deletes a match's stat lines, or one map's when mapNumber is set,
+// recording each.
+func deleteLines(tx *gorm.DB, entry *models.ChangeLog, matchID uint, mapNumber int) error {
+	q := tx.Model(&models.PlayerMapStats{}).Select("match_id, map_number, player_id").Where("match_id = ?", matchID)
+	if mapNumber > 0 {
+		q = q.Where("map_number = ?", mapNumber)
+	}
+	var lines []models.PlayerMapStats
+	if err := q.Find(&lines).Error; err != nil {
+		return err
+	}
+	for _, l := range lines {
+		if err := deleteRow(tx, entry, models.ChangeStatLine, ChangeKey(l.MatchID, l.MapNumber, l.PlayerID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recountTracked is recountSeries with the match's score change recorded.
+func recountTracked(tx *gorm.DB, entry *models.ChangeLog, match *models.Match, matchID uint, bestOf int) error {
+	return trackChange(tx, entry, models.ChangeMatch, ChangeKey(matchID), func() error {
+		return recountSeries(tx, match, matchID, bestOf)
+	})
+}
+
+var columnName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// restoreRow writes a snapshot back with jsonb_populate_record, updating the row
+// when it exists and inserting it (with its old id) when it doesn't.
+func restoreRow(tx *gorm.DB, entity, key string, row json.RawMessage, exists bool) error {
+	t := changeTables[entity]
+	where, args, err := t.where(entity, key)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(row, &fields); err != nil {
+		return err
+	}
+	cols := make([]string, 0, len(fields))
+	for col := range fields {
+		if !columnName.MatchString(col) {
+			return fmt.Errorf("bad column %q in %s snapshot", col, entity)
+		}
+		if exists && col == "id" {
+			continue
+		}
+		cols = append(cols, `"`+col+`"`)
+	}
+	list := strings.Join(cols, ", ")
+	args["row"] = string(row)
+	record := "jsonb_populate_record(NULL::" + t.name + ", CAST(@row AS jsonb)) r"
+	if exists {
+		return tx.Exec("UPDATE "+t.name+" SET ("+list+") = (SELECT "+list+" FROM "+record+") WHERE "+where, args).Error
+	}
+	return tx.Exec("INSERT INTO "+t.name+" ("+list+") SELECT "+list+" FROM "+record, args).Error
+}
+
+// sameJSON compares two snapshots by value; nil only equals nil.
+func sameJSON(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func (s *gormAdminStore) GetChange(ctx context.Context, id uint) (*models.ChangeLog, error) {
+	var change models.ChangeLog
+	if err := s.db.WithContext(ctx).First(&change, id).Error; err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+func (s *gormAdminStore) ListChanges(ctx context.Context, f ChangeFilter, limit, offset int) ([]models.ChangeLog, int64, error) {
+	base := s.db.WithContext(ctx).Model(&models.ChangeLog{})
+	if f.Entity != "" {
+		base = base.Where("entity = ?", f.Entity)
+	}
+	if f.EntityKey != "" {
+		base = base.Where("entity_key = ?", f.EntityKey)
+	}
+	if f.ActorID != 0 {
+		base = base.Where("actor_id = ?", f.ActorID)
+	}
+	if !f.Since.IsZero() {
+		base = base.Where("created_at >= ?", f.Since)
+	}
+	if !f.Until.IsZero() {
+		base = base.Where("created_at < ?", f.Until)
+	}
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	changes := make([]models.ChangeLog, 0)
+	err := base.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&changes).Error
+	return changes, total, err
+}
+
+// RevertChange puts the row back the way it was before change and recounts and
+// rebuilds what depends on it, recording the revert (and any series recount) as
+// changes of their own; nothing is derived from the rows of entities outside a match. bestOf is the match's
+// series length, used when a map is reverted. It returns ErrChangeSuperseded when
+// the row has been written since.
+func (s *gormAdminStore) RevertChange(ctx context.Context, change *models.ChangeLog, bestOf int, entry *models.ChangeLog) (*models.ChangeLog, error) {
+	var reverted *models.ChangeLog
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		t, ok := changeTables[change.Entity]
+		if !ok {
+			return fmt.Errorf("unknown entity %q", change.Entity)
+		}
+		parts, err := ParseChangeKey(change.Entity, change.EntityKey)
+		if err != nil {
+			return err
+		}
+		matchID := parts[0]
+		var prev models.Match
+		if IsMatchEntity(change.Entity) {
+			if err := tx.Select("id, tournament_id").Where("id = ?", matchID).Limit(1).Find(&prev).Error; err != nil {
+				return err
+			}
+		}
+
+		current, err := snapshot(tx, change.Entity, change.EntityKey)
+		if err != nil {
+			return err
+		}
+		if !sameJSON(current, change.After) {
+			return ErrChangeSuperseded
+		}
+		if change.Before == nil {
+			if change.Entity == models.ChangeMatch {
+				if err := tx.Exec("DELETE FROM player_match_stats WHERE match_id = ?", matchID).Error; err != nil {
+					return err
+				}
+			}
+			where, args, err := t.where(change.Entity, change.EntityKey)
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM "+t.name+" WHERE "+where, args).Error; err != nil {
+				return err
+			}
+		} else if err := restoreRow(tx, change.Entity, change.EntityKey, change.Before, current != nil); err != nil {
+			return err
+		}
+		after, err := snapshot(tx, change.Entity, change.EntityKey)
+		if err != nil {
+			return err
+		}
+		if reverted, err = recordChange(tx, entry, change.Entity, change.EntityKey, current, after); err != nil {
+			return err
+		}
+		if !IsMatchEntity(change.Entity) {
+			return nil
+		}
+
+		var match models.Match
+		if err := tx.Where("id = ?", matchID).Limit(1).Find(&match).Error; err != nil {
+			return err
+		}
+		if match.ID != 0 && change.Entity == models.ChangeMap {
+			if err := recountTracked(tx, entry, &match, matchID, bestOf); err != nil {
+				return err
+			}
+		}
+		if prev.ID != 0 && prev.TournamentID != match.TournamentID {
+			if err := rebuildDerived(tx, 0, prev.TournamentID); err != nil {
+				return err
+			}
+		}
+		if match.ID != 0 {
+			return rebuildDerived(tx, matchID, match.TournamentID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
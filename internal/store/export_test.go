@@ -0,0 +1,4 @@
+package store
+
+// StoreTx lets the external store_test package share the test database.
+var StoreTx = storeTx
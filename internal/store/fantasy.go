@@ -0,0 +1,320 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FantasyStore keeps fantasy leagues, members and lineups, the per-tournament salary
+// pool and the scores the scoring job writes from player_map_stats.
+type FantasyStore interface {
+	CreateLeague(ctx context.Context, league *models.FantasyLeague) error
+	GetLeague(ctx context.Context, id int) (*models.FantasyLeague, error)
+	GetLeagueByCode(ctx context.Context, code string) (*models.FantasyLeague, error)
+	ListUserLeagues(ctx context.Context, userID uint) ([]models.FantasyLeague, error)
+	AddMember(ctx context.Context, member *models.FantasyMember) error
+	GetMember(ctx context.Context, leagueID, userID uint) (*models.FantasyMember, error)
+	GetLineup(ctx context.Context, memberID uint) ([]models.FantasyLineupPlayer, error)
+	SetLineup(ctx context.Context, memberID uint, players []models.FantasyLineupPlayer) error
+	CountPrices(ctx context.Context, tournamentID uint) (int64, error)
+	ListPool(ctx context.Context, tournamentID uint) ([]FantasyPoolPlayer, error)
+	ListPricingLines(ctx context.Context, tournamentID uint, before time.Time, perPlayer int) ([]FantasyEligible, []FantasyStatLine, error)
+	SavePrices(ctx context.Context, tournamentID uint, prices []models.FantasyPrice) error
+	ListLeagueTournaments(ctx context.Context) ([]uint, error)
+	ListScoringLines(ctx context.Context, tournamentID uint) ([]FantasyStatLine, error)
+	ReplaceScores(ctx context.Context, tournamentID uint, scores []models.FantasyPlayerScore) error
+	ListStandings(ctx context.Context, league *models.FantasyLeague) ([]FantasyStandingRow, error)
+}
+
+// FantasyStatLine is one player_map_stats row with what fantasy scoring needs: the
+// era it was played in and whether the player's team won the map.
+type FantasyStatLine struct {
+	PlayerID       uint
+	TeamID         uint
+	GameCode       string
+	Kills          int
+	Deaths         int
+	Assists        int
+	FirstBloods    int
+	NonTradedKills int
+	HillTime       int
+	Plants         int
+	Defuses        int
+	Captures       int
+	Won            bool
+}
+
+// FantasyEligible is a player who can be drafted for a tournament and the team they
+// play for in it.
+type FantasyEligible struct {
+	PlayerID uint
+	TeamID   uint
+}
+
+// FantasyPoolPlayer is a draftable player with their salary and points so far.
+type FantasyPoolPlayer struct {
+	PlayerID uint    `json:"player_id"`
+	Gamertag string  `json:"gamertag"`
+	TeamID   uint    `json:"team_id"`
+	TeamAbbr string  `json:"team_abbr"`
+	Salary   int     `json:"salary"`
+	Points   float64 `json:"points"`
+}
+
+// FantasyStandingRow is one member in a league's standings. Rank is shared on equal points.
+type FantasyStandingRow struct {
+	Rank     int     `json:"rank"`
+	UserID   uint    `json:"user_id"`
+	Username string  `json:"username"`
+	Players  int     `json:"players"`
+	Salary   int     `json:"salary"`
+	Points   float64 `json:"points"`
+}
+
+type gormFantasyStore struct{ db *gorm.DB }
+
+func NewGormFantasyStore(db *gorm.DB) FantasyStore { return &gormFantasyStore{db: db} }
+
+// CreateLeague inserts the league and makes its owner the first member.
+func (s *gormFantasyStore) CreateLeague(ctx context.Context, league *models.FantasyLeague) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Tournament").Create(league).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.FantasyMember{LeagueID: league.ID, UserID: league.OwnerID}).Error
+	})
+}
+
+func (s *gormFantasyStore) GetLeague(ctx context.Context, id int) (*models.FantasyLeague, error) {
+	var league models.FantasyLeague
+	if err := s.db.WithContext(ctx).Preload("Tournament").First(&league, id).Error; err != nil {
+		return nil, err
+	}
+	return &league, nil
+}
+
+func (s *gormFantasyStore) GetLeagueByCode(ctx context.Context, code string) (*models.FantasyLeague, error) {
+	var league models.FantasyLeague
+	if err := s.db.WithContext(ctx).Where("invite_code = ?", code).First(&league).Error; err != nil {
+		return nil, err
+	}
+	return &league, nil
+}
+
+func (s *gormFantasyStore) ListUserLeagues(ctx context.Context, userID uint) ([]models.FantasyLeague, error) {
+	leagues := make([]models.FantasyLeague, 0)
+	err := s.db.WithContext(ctx).
+		Joins("JOIN fantasy_members fm ON fm.league_id = fantasy_leagues.id").
+		Where("fm.user_id = ?", userID).
+		Preload("Tournament").
+		Order("fantasy_leagues.lock_at DESC, fantasy_leagues.id DESC").
+		Find(&leagues).Error
+	return leagues, err
+}
+
+// AddMember is idempotent: joining twice keeps one row.
+func (s *gormFantasyStore) AddMember(ctx context.Context, member *models.FantasyMember) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error
+}
+
+func (s *gormFantasyStore) GetMember(ctx context.Context, leagueID, userID uint) (*models.FantasyMember, error) {
+	var member models.FantasyMember
+	err := s.db.WithContext(ctx).Where("league_id = ? AND user_id = ?", leagueID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (s *gormFantasyStore) GetLineup(ctx context.Context, memberID uint) ([]models.FantasyLineupPlayer, error) {
+	players := make([]models.FantasyLineupPlayer, 0)
+	err := s.db.WithContext(ctx).
+		Where("member_id = ?", memberID).
+		Preload("Player").
+		Order("salary DESC, player_id ASC").
+		Find(&players).Error
+	return players, err
+}
+
+// SetLineup replaces the member's whole lineup.
+func (s *gormFantasyStore) SetLineup(ctx context.Context, memberID uint, players []models.FantasyLineupPlayer) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("member_id = ?", memberID).Delete(&models.FantasyLineupPlayer{}).Error; err != nil {
+			return err
+		}
+		if len(players) == 0 {
+			return nil
+		}
+		return tx.Omit("Player").Create(&players).Error
+	})
+}
+
+func (s *gormFantasyStore) CountPrices(ctx context.Context, tournamentID uint) (int64, error) {
+	var n int64
+	err := s.db.WithContext(ctx).Model(&models.FantasyPrice{}).Where("tournament_id = ?", tournamentID).Count(&n).Error
+	return n, err
+}
+
+func (s *gormFantasyStore) ListPool(ctx context.Context, tournamentID uint) ([]FantasyPoolPlayer, error) {
+	rows := make([]FantasyPoolPlayer, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT fp.player_id, p.gamertag, fp.team_id,
+			COALESCE(t.abbreviation, '') AS team_abbr,
+			fp.salary,
+			COALESCE(fps.points, 0)      AS points
+		FROM fantasy_prices fp
+		JOIN players p              ON p.id = fp.player_id
+		LEFT JOIN teams t           ON t.id = fp.team_id
+		LEFT JOIN fantasy_player_scores fps
+			ON fps.tournament_id = fp.tournament_id AND fps.player_id = fp.player_id
+		WHERE fp.tournament_id = ?
+		ORDER BY fp.salary DESC, p.gamertag ASC
+	`, tournamentID).Scan(&rows).Error
+	return rows, err
+}
+
+// fantasyLineSelect reads a FantasyStatLine from player_map_stats pms joined to
+// matches m, match_maps mm and seasons s.
+const fantasyLineSelect = `pms.player_id, pms.team_id, COALESCE(s.game_code, '') AS game_code,
+	pms.kills, pms.deaths, pms.assists,
+	pms.first_blood_count       AS first_bloods,
+	pms.non_traded_kills,
+	pms.hill_time,
+	pms.plant_count             AS plants,
+	pms.defuse_count            AS defuses,
+	pms.zone_tier_capture_count AS captures,
+	COALESCE(mm.winner_id = pms.team_id, false) AS won`
+
+// ListPricingLines returns the players eligible for a tournament — on the roster of
+// one of its teams for its season, or having played a map for one that season — and
+// each one's last perPlayer map lines before the given time, across every era.
+func (s *gormFantasyStore) ListPricingLines(ctx context.Context, tournamentID uint, before time.Time, perPlayer int) ([]FantasyEligible, []FantasyStatLine, error) {
+	eligible := make([]FantasyEligible, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		WITH tour AS (
+			SELECT id, season_id FROM tournaments WHERE id = @tournament
+		),
+		tour_teams AS (
+			SELECT team1_id AS team_id FROM matches WHERE tournament_id = @tournament
+			UNION
+			SELECT team2_id FROM matches WHERE tournament_id = @tournament
+		),
+		candidates AS (
+			SELECT tr.player_id, tr.team_id
+			FROM team_rosters tr
+			JOIN tour ON tour.season_id = tr.season_id
+			WHERE tr.team_id IN (SELECT team_id FROM tour_teams)
+			  AND (tr.end_date IS NULL OR tr.end_date >= @before)
+			UNION
+			SELECT pms.player_id, pms.team_id
+			FROM player_map_stats pms
+			JOIN matches m      ON m.id = pms.match_id
+			JOIN tournaments t2 ON t2.id = m.tournament_id
+			JOIN tour           ON tour.season_id = t2.season_id
+			WHERE pms.team_id IN (SELECT team_id FROM tour_teams)
+		)
+		SELECT DISTINCT ON (player_id) player_id, team_id
+		FROM candidates
+		ORDER BY player_id, team_id
+	`, map[string]any{"tournament": tournamentID, "before": before}).Scan(&eligible).Error
+	if err != nil || len(eligible) == 0 {
+		return eligible, nil, err
+	}
+
+	ids := make([]uint, len(eligible))
+	for i, e := range eligible {
+		ids[i] = e.PlayerID
+	}
+	lines := make([]FantasyStatLine, 0)
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT * FROM (
+			SELECT `+fantasyLineSelect+`,
+				ROW_NUMBER() OVER (PARTITION BY pms.player_id ORDER BY m.match_date DESC, pms.match_id DESC, pms.map_number DESC) AS n
+			FROM player_map_stats pms
+			JOIN matches m        ON m.id = pms.match_id
+			JOIN tournaments tour ON tour.id = m.tournament_id
+			JOIN seasons s        ON s.id = tour.season_id
+			LEFT JOIN match_maps mm ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number
+			WHERE pms.player_id IN ? AND m.match_date < ?
+			  AND (mm.id IS NULL OR mm.played = true)
+		) recent
+		WHERE n <= ?
+	`, ids, before, perPlayer).Scan(&lines).Error
+	return eligible, lines, err
+}
+
+// SavePrices replaces a tournament's salary pool.
+func (s *gormFantasyStore) SavePrices(ctx context.Context, tournamentID uint, prices []models.FantasyPrice) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tournament_id = ?", tournamentID).Delete(&models.FantasyPrice{}).Error; err != nil {
+			return err
+		}
+		if len(prices) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(prices, 500).Error
+	})
+}
+
+func (s *gormFantasyStore) ListLeagueTournaments(ctx context.Context) ([]uint, error) {
+	ids := make([]uint, 0)
+	err := s.db.WithContext(ctx).Model(&models.FantasyLeague{}).
+		Distinct("tournament_id").Order("tournament_id ASC").
+		Pluck("tournament_id", &ids).Error
+	return ids, err
+}
+
+func (s *gormFantasyStore) ListScoringLines(ctx context.Context, tournamentID uint) ([]FantasyStatLine, error) {
+	lines := make([]FantasyStatLine, 0)
+	err := s.db.WithContext(ctx).
+		Table("player_map_stats pms").
+		Select(fantasyLineSelect).
+		Joins("JOIN matches m ON m.id = pms.match_id").
+		Joins("JOIN tournaments tour ON tour.id = m.tournament_id").
+		Joins("JOIN seasons s ON s.id = tour.season_id").
+		Joins("LEFT JOIN match_maps mm ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number").
+		Where("m.tournament_id = ?", tournamentID).
+		Where("mm.id IS NULL OR mm.played = true").
+		Order("pms.player_id ASC, pms.match_id ASC, pms.map_number ASC").
+		Scan(&lines).Error
+	return lines, err
+}
+
+// ReplaceScores rewrites a tournament's fantasy scores in one transaction.
+func (s *gormFantasyStore) ReplaceScores(ctx context.Context, tournamentID uint, scores []models.FantasyPlayerScore) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tournament_id = ?", tournamentID).Delete(&models.FantasyPlayerScore{}).Error; err != nil {
+			return err
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(scores, 500).Error
+	})
+}
+
+func (s *gormFantasyStore) ListStandings(ctx context.Context, league *models.FantasyLeague) ([]FantasyStandingRow, error) {
+	rows := make([]FantasyStandingRow, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT RANK() OVER (ORDER BY points DESC) AS rank, *
+		FROM (
+			SELECT fm.user_id, u.username,
+				COUNT(flp.id)                 AS players,
+				COALESCE(SUM(flp.salary), 0)  AS salary,
+				COALESCE(SUM(fps.points), 0)  AS points
+			FROM fantasy_members fm
+			JOIN users u ON u.id = fm.user_id AND u.deleted_at IS NULL
+			LEFT JOIN fantasy_lineup_players flp ON flp.member_id = fm.id
+			LEFT JOIN fantasy_player_scores fps
+				ON fps.player_id = flp.player_id AND fps.tournament_id = ?
+			WHERE fm.league_id = ?
+			GROUP BY fm.user_id, u.username
+		) board
+		ORDER BY points DESC, username ASC
+	`, league.TournamentID, league.ID).Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FollowStore keeps users' follows and builds the /me/feed page from them.
+type FollowStore interface {
+	Follow(ctx context.Context, follow *models.Follow) error
+	Unfollow(ctx context.Context, userID uint, targetType string, targetID uint) error
+	ListFollows(ctx context.Context, userID uint) ([]FollowedEntity, error)
+	TargetExists(ctx context.Context, targetType string, targetID uint) (bool, error)
+	ListFeed(ctx context.Context, userID uint, window FeedWindow, limit, offset int) ([]FeedRow, int64, error)
+	GetFeedMatches(ctx context.Context, ids []uint) ([]models.Match, error)
+	GetFeedTransfers(ctx context.Context, ids []uint) ([]models.PlayerTransfer, error)
+	GetFeedThreads(ctx context.Context, ids []uint) ([]models.MatchThread, error)
+}
+
+// FollowedEntity is a follow with the target's display name.
+type FollowedEntity struct {
+	TargetType string    `json:"target_type"`
+	TargetID   uint      `json:"target_id"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// FeedWindow bounds each kind of feed item in time: matches played since MatchesFrom
+// or scheduled before MatchesTo, transfers since TransfersFrom, and thread posts since
+// ThreadsFrom.
+type FeedWindow struct {
+	MatchesFrom   time.Time
+	MatchesTo     time.Time
+	TransfersFrom time.Time
+	ThreadsFrom   time.Time
+}
+
+// FeedRow is one feed item before hydration. Kind is match, transfer or thread; RefID
+// is the match, transfer or thread ID; Posts counts new thread posts.
+type FeedRow struct {
+	Kind  string
+	RefID uint
+	At    time.Time
+	Posts int
+}
+
+type gormFollowStore struct{ db *gorm.DB }
+
+func NewGormFollowStore(db *gorm.DB) FollowStore { return &gormFollowStore{db: db} }
+
+// Follow is idempotent: following twice keeps one row.
+func (s *gormFollowStore) Follow(ctx context.Context, follow *models.Follow) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(follow).Error
+}
+
+func (s *gormFollowStore) Unfollow(ctx context.Context, userID uint, targetType string, targetID uint) error {
+	return s.db.WithContext(ctx).
+		Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		Delete(&models.Follow{}).Error
+}
+
+func (s *gormFollowStore) ListFollows(ctx context.Context, userID uint) ([]FollowedEntity, error) {
+	rows := make([]FollowedEntity, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT f.target_type, f.target_id, f.created_at,
+			COALESCE(p.gamertag, t.name, fr.name, '') AS name
+		FROM follows f
+		LEFT JOIN players p     ON f.target_type = 'player'    AND p.id = f.target_id
+		LEFT JOIN teams t       ON f.target_type = 'team'      AND t.id = f.target_id
+		LEFT JOIN franchises fr ON f.target_type = 'franchise' AND fr.id = f.target_id
+		WHERE f.user_id = ?
+		ORDER BY f.created_at DESC, f.id DESC
+	`, userID).Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormFollowStore) TargetExists(ctx context.Context, targetType string, targetID uint) (bool, error) {
+	var model any
+	switch targetType {
+	case models.FollowPlayer:
+		model = &models.Player{}
+	case models.FollowTeam:
+		model = &models.Team{}
+	case models.FollowFranchise:
+		model = &models.Franchise{}
+	default:
+		return false, nil
+	}
+	var n int64
+	err := s.db.WithContext(ctx).Model(model).Where("id = ?", targetID).Count(&n).Error
+	return n > 0, err
+}
+
+// feedItems is the CTE ListFeed selects from. A followed franchise counts as following
+// each of its teams, and a followed player brings in the matches of whatever team they
+// were rostered on at the time.
+const feedItems = `
+		WITH f AS (
+			SELECT target_type, target_id FROM follows WHERE user_id = @user
+		),
+		followed_teams AS (
+			SELECT target_id AS team_id FROM f WHERE target_type = 'team'
+			UNION
+			SELECT t.id FROM teams t JOIN f ON f.target_type = 'franchise' AND t.franchise_id = f.target_id
+		),
+		followed_players AS (
+			SELECT target_id AS player_id FROM f WHERE target_type = 'player'
+		),
+		relevant_matches AS (
+			SELECT m.id, m.match_date FROM matches m
+			WHERE m.team1_id IN (SELECT team_id FROM followed_teams)
+				OR m.team2_id IN (SELECT team_id FROM followed_teams)
+				OR EXISTS (
+					SELECT 1 FROM team_rosters r
+					WHERE r.player_id IN (SELECT player_id FROM followed_players)
+						AND r.team_id IN (m.team1_id, m.team2_id)
+						AND r.start_date <= m.match_date
+						AND (r.end_date IS NULL OR r.end_date >= m.match_date)
+				)
+		),
+		items AS (
+			SELECT 'match' AS kind, id AS ref_id, match_date AS at, 0 AS posts
+			FROM relevant_matches
+			WHERE match_date >= @matches_from AND match_date <= @matches_to
+			UNION ALL
+			SELECT 'transfer', pt.id, pt.transfer_date, 0
+			FROM player_transfers pt
+			WHERE pt.transfer_date >= @transfers_from
+				AND (pt.player_id IN (SELECT player_id FROM followed_players)
+					OR pt.from_team_id IN (SELECT team_id FROM followed_teams)
+					OR pt.to_team_id IN (SELECT team_id FROM followed_teams))
+			UNION ALL
+			SELECT 'thread', mt.id, MAX(tp.created_at), COUNT(*)
+			FROM match_threads mt
+			JOIN relevant_matches rm ON rm.id = mt.match_id
+			JOIN thread_posts tp ON tp.thread_id = mt.id
+			WHERE tp.created_at >= @threads_from AND tp.deleted_at IS NULL AND tp.hidden_at IS NULL
+			GROUP BY mt.id
+		)
+`
+
+// ListFeed merges the user's feed items newest first; scheduled matches sort ahead
+// of everything already played.
+func (s *gormFollowStore) ListFeed(ctx context.Context, userID uint, window FeedWindow, limit, offset int) ([]FeedRow, int64, error) {
+	args := map[string]any{
+		"user":           userID,
+		"matches_from":   window.MatchesFrom,
+		"matches_to":     window.MatchesTo,
+		"transfers_from": window.TransfersFrom,
+		"threads_from":   window.ThreadsFrom,
+		"limit":          limit,
+		"offset":         offset,
+	}
+	var total int64
+	if err := s.db.WithContext(ctx).Raw(feedItems+"SELECT COUNT(*) FROM items", args).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	rows := make([]FeedRow, 0)
+	err := s.db.WithContext(ctx).Raw(feedItems+`
+		SELECT kind, ref_id, at, posts FROM items
+		ORDER BY at DESC, kind ASC, ref_id DESC
+		LIMIT @limit OFFSET @offset
+	`, args).Scan(&rows).Error
+	return rows, total, err
+}
+
+func (s *gormFollowStore) GetFeedMatches(ctx context.Context, ids []uint) ([]models.Match, error) {
+	matches := make([]models.Match, 0, len(ids))
+	if len(ids) == 0 {
+		return matches, nil
+	}
+	err := s.db.WithContext(ctx).
+		Preload("Tournament").
+		Preload("Team1").
+		Preload("Team2").
+		Preload("Winner").
+		Where("id IN ?", ids).
+		Find(&matches).Error
+	return matches, err
+}
+
+func (s *gormFollowStore) GetFeedTransfers(ctx context.Context, ids []uint) ([]models.PlayerTransfer, error) {
+	transfers := make([]models.PlayerTransfer, 0, len(ids))
+	if len(ids) == 0 {
+		return transfers, nil
+	}
+	err := s.db.WithContext(ctx).
+		Preload("Player").
+		Preload("FromTeam").
+		Preload("ToTeam").
+		Where("id IN ?", ids).
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (s *gormFollowStore) GetFeedThreads(ctx context.Context, ids []uint) ([]models.MatchThread, error) {
+	threads := make([]models.MatchThread, 0, len(ids))
+	if len(ids) == 0 {
+		return threads, nil
+	}
+	err := s.db.WithContext(ctx).
+		Preload("Match").
+		Preload("Match.Team1").
+		Preload("Match.Team2").
+		Where("id IN ?", ids).
+		Find(&threads).Error
+	return threads, err
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// FormMapRow is one played map from a player's or team's perspective, oldest first.
+// For a team, Kills/Deaths are summed and Rating averaged over its players' rated lines.
+type FormMapRow struct {
+	MatchID       uint
+	MapNumber     int
+	PlayedAt      time.Time
+	Mode          string
+	TeamID        uint
+	TeamScore     int
+	OpponentScore int
+	WinnerID      *uint
+	Kills         int
+	Deaths        int
+	Rating        float64
+}
+
+// FormSeriesRow is one decided series from a team's perspective, oldest first.
+type FormSeriesRow struct {
+	MatchID       uint
+	PlayedAt      time.Time
+	OpponentID    uint
+	OpponentName  string
+	TeamScore     int
+	OpponentScore int
+	WinnerID      uint
+}
+
+// playedAtSQL is a match's date, falling back to its tournament start for rows seeded
+// without a real match date (same rule as the rating feed).
+const playedAtSQL = `CASE WHEN m.match_date <= '0001-01-02 00:00:00+00'::timestamptz
+				THEN tour.start_date ELSE m.match_date END`
+
+func (s *gormPlayerStore) ListFormMaps(ctx context.Context, playerID int) ([]FormMapRow, error) {
+	rows := make([]FormMapRow, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT pms.match_id, pms.map_number, `+playedAtSQL+` AS played_at,
+			mm.mode, pms.team_id,
+			CASE WHEN m.team1_id = pms.team_id THEN mm.score1 ELSE mm.score2 END AS team_score,
+			CASE WHEN m.team1_id = pms.team_id THEN mm.score2 ELSE mm.score1 END AS opponent_score,
+			mm.winner_id, pms.kills, pms.deaths, pms.rating
+		FROM player_map_stats pms
+		JOIN match_maps mm    ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number
+		JOIN matches m        ON m.id = pms.match_id
+		JOIN tournaments tour ON tour.id = m.tournament_id
+		WHERE pms.player_id = ? AND mm.played = true
+		ORDER BY played_at ASC, m.id ASC, pms.map_number ASC
+	`, playerID).Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormTeamStore) ListFormMaps(ctx context.Context, teamID int) ([]FormMapRow, error) {
+	rows := make([]FormMapRow, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT mm.match_id, mm.map_number, `+playedAtSQL+` AS played_at,
+			mm.mode, CAST(@team AS bigint) AS team_id,
+			CASE WHEN m.team1_id = @team THEN mm.score1 ELSE mm.score2 END AS team_score,
+			CASE WHEN m.team1_id = @team THEN mm.score2 ELSE mm.score1 END AS opponent_score,
+			mm.winner_id,
+			COALESCE(ps.kills, 0)  AS kills,
+			COALESCE(ps.deaths, 0) AS deaths,
+			COALESCE(ps.rating, 0) AS rating
+		FROM match_maps mm
+		JOIN matches m        ON m.id = mm.match_id
+		JOIN tournaments tour ON tour.id = m.tournament_id
+		LEFT JOIN (
+			SELECT match_id, map_number,
+				SUM(kills) AS kills, SUM(deaths) AS deaths, AVG(NULLIF(rating, 0)) AS rating
+			FROM player_map_stats
+			WHERE team_id = @team
+			GROUP BY match_id, map_number
+		) ps ON ps.match_id = mm.match_id AND ps.map_number = mm.map_number
+		WHERE mm.played = true
+		  AND (m.team1_id = @team OR m.team2_id = @team)
+		  AND m.team1_id <> m.team2_id
+		ORDER BY played_at ASC, m.id ASC, mm.map_number ASC
+	`, map[string]any{"team": teamID}).Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormTeamStore) ListFormSeries(ctx context.Context, teamID int) ([]FormSeriesRow, error) {
+	rows := make([]FormSeriesRow, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT m.id AS match_id, `+playedAtSQL+` AS played_at,
+			o.id AS opponent_id, o.name AS opponent_name,
+			CASE WHEN m.team1_id = @team THEN m.team1_score ELSE m.team2_score END AS team_score,
+			CASE WHEN m.team1_id = @team THEN m.team2_score ELSE m.team1_score END AS opponent_score,
+			m.winner_id
+		FROM matches m
+		JOIN tournaments tour ON tour.id = m.tournament_id
+		JOIN teams o ON o.id = CASE WHEN m.team1_id = @team THEN m.team2_id ELSE m.team1_id END
+		WHERE (m.team1_id = @team OR m.team2_id = @team)
+		  AND m.team1_id <> m.team2_id
+		  AND m.winner_id IS NOT NULL
+		  AND tour.tournament_type <> 'season_summary'
+		ORDER BY played_at ASC, m.id ASC
+	`, map[string]any{"team": teamID}).Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Leaderboard stat sources: per-map rows (filterable by mode) or the tournament /
+// season-summary aggregates, which are the only source for eras without map data.
+const (
+	LeaderboardSourceMap        = "map"
+	LeaderboardSourceTournament = "tournament"
+)
+
+// LeaderboardStat is one rankable stat. ValueSQL is evaluated over the grouped
+// player_map_stats (pms) or player_tournament_stats (pts) rows of a player; rows
+// where it is NULL (e.g. a rate with a zero denominator) are left off the board.
+type LeaderboardStat struct {
+	Key            string `json:"key"`
+	Label          string `json:"label"`
+	Source         string `json:"source"`
+	DefaultMinMaps int    `json:"default_min_maps"`
+	valueSQL       string
+}
+
+// LeaderboardStats lists every stat /leaderboards can rank by, in display order.
+var LeaderboardStats = []LeaderboardStat{
+	{Key: "kd", Label: "K/D", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "SUM(pms.kills)::decimal / NULLIF(SUM(pms.deaths), 0)"},
+	{Key: "rating", Label: "Rating", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "AVG(NULLIF(pms.rating, 0))"},
+	{Key: "kills", Label: "Kills", Source: LeaderboardSourceMap,
+		valueSQL: "SUM(pms.kills)"},
+	{Key: "plus_minus", Label: "+/-", Source: LeaderboardSourceMap,
+		valueSQL: "SUM(pms.kills - pms.deaths)"},
+	{Key: "kills_per_map", Label: "Kills / map", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "SUM(pms.kills)::decimal / COUNT(*)"},
+	{Key: "kills_per_10min", Label: "Kills / 10 min", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "SUM(CASE WHEN mm.duration_sec > 0 THEN pms.kills ELSE 0 END) * 600.0 / NULLIF(SUM(mm.duration_sec), 0)"},
+	{Key: "damage_per_map", Label: "Damage / map", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "SUM(pms.damage)::decimal / NULLIF(COUNT(*) FILTER (WHERE pms.damage > 0), 0)"},
+	{Key: "assists_per_map", Label: "Assists / map", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "SUM(pms.assists)::decimal / COUNT(*)"},
+	{Key: "non_traded_kills_per_map", Label: "Non-traded kills / map", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "SUM(pms.non_traded_kills)::decimal / NULLIF(COUNT(*) FILTER (WHERE pms.non_traded_kills > 0), 0)"},
+	{Key: "hill_time_per_map", Label: "Hill time / map (s)", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "SUM(pms.hill_time)::decimal / NULLIF(COUNT(*) FILTER (WHERE " + modeCaseSQL + " = 'hp'), 0)"},
+	{Key: "first_bloods", Label: "First bloods", Source: LeaderboardSourceMap,
+		valueSQL: "SUM(pms.first_blood_count)"},
+	{Key: "first_blood_pct", Label: "First blood %", Source: LeaderboardSourceMap, DefaultMinMaps: 10,
+		valueSQL: "SUM(pms.first_blood_count) * 100.0 / NULLIF(SUM(pms.first_blood_count + pms.first_death_count), 0)"},
+	{Key: "plants", Label: "Plants", Source: LeaderboardSourceMap,
+		valueSQL: "SUM(pms.plant_count)"},
+	{Key: "defuses", Label: "Defuses", Source: LeaderboardSourceMap,
+		valueSQL: "SUM(pms.defuse_count)"},
+
+	{Key: "overall_plus_minus", Label: "Overall +/-", Source: LeaderboardSourceTournament,
+		valueSQL: "SUM(pts.overall_plus_minus)"},
+	{Key: "snd_k_per_map", Label: "SnD K/map", Source: LeaderboardSourceTournament, DefaultMinMaps: 10,
+		valueSQL: "SUM(pts.snd_kills)::decimal / NULLIF(SUM(pts.snd_maps), 0)"},
+	{Key: "snd_plus_minus", Label: "SnD +/-", Source: LeaderboardSourceTournament,
+		valueSQL: "SUM(pts.snd_plus_minus)"},
+	{Key: "snd_first_kills", Label: "SnD first kills", Source: LeaderboardSourceTournament,
+		valueSQL: "SUM(pts.snd_first_kills)"},
+	{Key: "hp_k_per_map", Label: "HP K/map", Source: LeaderboardSourceTournament, DefaultMinMaps: 10,
+		valueSQL: "SUM(pts.hp_kills)::decimal / NULLIF(SUM(pts.hp_maps), 0)"},
+	{Key: "hp_plus_minus", Label: "HP +/-", Source: LeaderboardSourceTournament,
+		valueSQL: "SUM(pts.hp_plus_minus)"},
+	{Key: "hp_time_per_map", Label: "HP time / map (s)", Source: LeaderboardSourceTournament, DefaultMinMaps: 10,
+		valueSQL: "SUM(pts.hp_time_milliseconds) / 1000.0 / NULLIF(SUM(pts.hp_maps), 0)"},
+	{Key: "control_k_per_map", Label: "Control K/map", Source: LeaderboardSourceTournament, DefaultMinMaps: 10,
+		valueSQL: "SUM(pts.control_kills)::decimal / NULLIF(SUM(pts.control_maps), 0)"},
+	{Key: "control_plus_minus", Label: "Control +/-", Source: LeaderboardSourceTournament,
+		valueSQL: "SUM(pts.control_plus_minus)"},
+	{Key: "control_captures", Label: "Control captures", Source: LeaderboardSourceTournament,
+		valueSQL: "SUM(pts.control_captures)"},
+}
+
+// LookupLeaderboardStat returns the stat registered under key.
+func LookupLeaderboardStat(key string) (LeaderboardStat, bool) {
+	for _, st := range LeaderboardStats {
+		if st.Key == key {
+			return st, true
+		}
+	}
+	return LeaderboardStat{}, false
+}
+
+// LeaderboardQuery filters a leaderboard. Empty strings mean "no filter"; Mode is
+// already normalised (hp / snd / control) and only applies to map-sourced stats.
+type LeaderboardQuery struct {
+	Stat         LeaderboardStat
+	Mode         string
+	SeasonID     string
+	TournamentID string
+	TeamID       string
+	MinMaps      int
+}
+
+// LeaderboardRow is one qualifying player, sorted by Value descending.
+type LeaderboardRow struct {
+	PlayerID  uint    `json:"player_id"`
+	Gamertag  string  `json:"gamertag"`
+	AvatarURL string  `json:"avatar_url"`
+	TeamAbbr  string  `json:"team_abbr"`
+	Maps      int     `json:"maps"`
+	Value     float64 `json:"value"`
+}
+
+func (s *gormStatsStore) ListLeaderboardRows(ctx context.Context, q LeaderboardQuery) ([]LeaderboardRow, error) {
+	var query *gorm.DB
+	if q.Stat.Source == LeaderboardSourceTournament {
+		query = s.db.WithContext(ctx).
+			Table("player_tournament_stats pts").
+			Select(`pts.player_id, MAX(p.gamertag) AS gamertag,
+				COALESCE(MAX(p.avatar_url), '') AS avatar_url,
+				COALESCE(MAX(t.abbreviation), '') AS team_abbr,
+				SUM(pts.overall_maps) AS maps,
+				`+q.Stat.valueSQL+` AS value`).
+			Joins("JOIN players p ON p.id = pts.player_id").
+			Joins("LEFT JOIN teams t ON t.id = pts.team_id").
+			Joins("JOIN tournaments tour ON tour.id = pts.tournament_id").
+			Group("pts.player_id").
+			Having("SUM(pts.overall_maps) >= ?", q.MinMaps)
+		if q.TeamID != "" {
+			query = query.Where("pts.team_id = ?", q.TeamID)
+		}
+		// Without a tournament, read the season_summary rows only: the
+		// per-tournament rows cover the same maps.
+		if q.TournamentID != "" {
+			query = query.Where("pts.tournament_id = ?", q.TournamentID)
+		} else {
+			query = query.Where("tour.tournament_type = ?", "season_summary")
+		}
+	} else {
+		query = s.db.WithContext(ctx).
+			Table("player_map_stats pms").
+			Select(`pms.player_id, MAX(p.gamertag) AS gamertag,
+				COALESCE(MAX(p.avatar_url), '') AS avatar_url,
+				COALESCE(MAX(t.abbreviation), '') AS team_abbr,
+				COUNT(*) AS maps,
+				`+q.Stat.valueSQL+` AS value`).
+			Joins("JOIN players p ON p.id = pms.player_id").
+			Joins("LEFT JOIN teams t ON t.id = pms.team_id").
+			Joins("JOIN match_maps mm ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number").
+			Joins("JOIN matches m ON m.id = pms.match_id").
+			Joins("JOIN tournaments tour ON tour.id = m.tournament_id").
+			Where("mm.played = true").
+			Group("pms.player_id").
+			Having("COUNT(*) >= ?", q.MinMaps)
+		if q.Mode != "" {
+			query = query.Where(modeCaseSQL+" = ?", q.Mode)
+		}
+		if q.TeamID != "" {
+			query = query.Where("pms.team_id = ?", q.TeamID)
+		}
+		if q.TournamentID != "" {
+			query = query.Where("m.tournament_id = ?", q.TournamentID)
+		}
+	}
+	if q.SeasonID != "" {
+		query = query.Where("tour.season_id = ?", q.SeasonID)
+	}
+
+	rows := make([]LeaderboardRow, 0)
+	err := query.
+		Having(q.Stat.valueSQL + " IS NOT NULL").
+		Order("value DESC, gamertag ASC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,190 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LiveSource tags match_maps / player_map_stats rows written by the ingestion API.
+const LiveSource = "live"
+
+// BestOf reads a series length from matches.format ("BO5", "bo3", ...), defaulting to 5.
+func BestOf(format string) int {
+	f := strings.ToLower(strings.TrimSpace(format))
+	if n, err := strconv.Atoi(strings.TrimPrefix(f, "bo")); err == nil && n > 0 {
+		return n
+	}
+	return 5
+}
+
+// liveChange is the change-log entry for writes made through the ingestion API.
+func liveChange() *models.ChangeLog { return &models.ChangeLog{Actor: LiveSource} }
+
+// LiveStore persists in-progress series pushed through the ingestion API. Changed
+// rows are recorded in change_log under the live actor. A push rebuilds only its
+// match's derived stats and queues the tournament for RollupTournaments.
+type LiveStore interface {
+	GetMatch(ctx context.Context, id int) (*models.Match, error)
+	SaveMap(ctx context.Context, m *models.MatchMap, bestOf int) (*models.Match, error)
+	SaveMapStats(ctx context.Context, matchID uint, mapNumber int, stats []models.PlayerMapStats) error
+	RollupTournaments(ctx context.Context) (int, error)
+}
+
+type gormLiveStore struct{ db *gorm.DB }
+
+func NewGormLiveStore(db *gorm.DB) LiveStore { return &gormLiveStore{db: db} }
+
+func (s *gormLiveStore) GetMatch(ctx context.Context, id int) (*models.Match, error) {
+	var match models.Match
+	if err := s.db.WithContext(ctx).First(&match, id).Error; err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// SaveMap upserts one map and, in the same transaction, recounts the series score
+// from its finished maps (see recountSeries) and rebuilds the match's derived stats.
+func (s *gormLiveStore) SaveMap(ctx context.Context, m *models.MatchMap, bestOf int) (*models.Match, error) {
+	var match models.Match
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := trackChange(tx, liveChange(), models.ChangeMap, ChangeKey(m.MatchID, m.MapNumber), func() error {
+			return tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "match_id"}, {Name: "map_number"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"map_name", "mode", "score1", "score2", "winner_id", "played", "duration_sec", "source", "updated_at",
+				}),
+			}).Omit(clause.Associations).Create(m).Error
+		})
+		if err != nil {
+			return err
+		}
+		if err := recountTracked(tx, liveChange(), &match, m.MatchID, bestOf); err != nil {
+			return err
+		}
+		if err := rebuildMatchDerived(tx, match.ID); err != nil {
+			return err
+		}
+		return queueRollup(tx, match.TournamentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// recountSeries locks the match, recounts its series score from its finished maps and
+// stores it in match. The winner is set once a team reaches a majority of bestOf and
+// cleared again if a correction takes it back below.
+func recountSeries(tx *gorm.DB, match *models.Match, matchID uint, bestOf int) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(match, matchID).Error; err != nil {
+		return err
+	}
+	var score struct{ Team1, Team2 int }
+	err := tx.Raw(`
+		SELECT COUNT(*) FILTER (WHERE winner_id = ?) AS team1,
+		       COUNT(*) FILTER (WHERE winner_id = ?) AS team2
+		FROM match_maps WHERE match_id = ? AND played = true
+	`, match.Team1ID, match.Team2ID, match.ID).Scan(&score).Error
+	if err != nil {
+		return err
+	}
+
+	var winner *uint
+	switch need := bestOf/2 + 1; {
+	case score.Team1 >= need:
+		winner = &match.Team1ID
+	case score.Team2 >= need:
+		winner = &match.Team2ID
+	}
+	match.Team1Score, match.Team2Score, match.WinnerID = score.Team1, score.Team2, winner
+	return tx.Model(&models.Match{}).Where("id = ?", match.ID).Updates(map[string]any{
+		"team1_score": score.Team1,
+		"team2_score": score.Team2,
+		"winner_id":   winner,
+		"updated_at":  time.Now(),
+	}).Error
+}
+
+// SaveMapStats upserts player lines for one map, creating an unplayed placeholder map
+// row first if the stats arrive before the map's score does, and rebuilds the
+// match's derived stats.
+func (s *gormLiveStore) SaveMapStats(ctx context.Context, matchID uint, mapNumber int, stats []models.PlayerMapStats) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry := liveChange()
+		placeholder := models.MatchMap{MatchID: matchID, MapNumber: mapNumber, Source: LiveSource}
+		err := trackChange(tx, entry, models.ChangeMap, ChangeKey(matchID, mapNumber), func() error {
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&placeholder).Error
+		})
+		if err != nil {
+			return err
+		}
+		for i := range stats {
+			line := &stats[i]
+			err := trackChange(tx, entry, models.ChangeStatLine, ChangeKey(line.MatchID, line.MapNumber, line.PlayerID), func() error {
+				return tx.Clauses(clause.OnConflict{
+					Columns: []clause.Column{{Name: "match_id"}, {Name: "map_number"}, {Name: "player_id"}},
+					DoUpdates: clause.AssignmentColumns([]string{
+						"team_id", "kills", "deaths", "kd_ratio", "damage", "assists",
+						"hill_time", "snd_rounds", "plant_count", "defuse_count",
+						"first_blood_count", "first_death_count", "non_traded_kills", "highest_streak",
+						"source", "updated_at",
+					}),
+				}).Omit(clause.Associations).Create(line).Error
+			})
+			if err != nil {
+				return err
+			}
+		}
+		var tournamentID uint
+		if err := tx.Model(&models.Match{}).Where("id = ?", matchID).Select("tournament_id").Scan(&tournamentID).Error; err != nil {
+			return err
+		}
+		if err := rebuildMatchDerived(tx, matchID); err != nil {
+			return err
+		}
+		return queueRollup(tx, tournamentID)
+	})
+}
+
+// queueRollup leaves the tournament's totals for the next RollupTournaments pass.
+func queueRollup(tx *gorm.DB, tournamentID uint) error {
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.PendingRollup{TournamentID: tournamentID, QueuedAt: time.Now()}).Error
+}
+
+// RollupTournaments rebuilds the totals of every queued tournament, each in its own
+// transaction, and returns how many it rebuilt. A push that lands during a rebuild
+// queues its tournament again.
+func (s *gormLiveStore) RollupTournaments(ctx context.Context) (int, error) {
+	var ids []uint
+	err := s.db.WithContext(ctx).Model(&models.PendingRollup{}).Order("tournament_id").Pluck("tournament_id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for _, id := range ids {
+		rebuilt := false
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			res := tx.Where("tournament_id = ?", id).Delete(&models.PendingRollup{})
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error // still being queued, or another pass took it
+			}
+			rebuilt = true
+			return rebuildTournamentDerived(tx, id)
+		})
+		if err != nil {
+			return done, fmt.Errorf("tournament %d: %w", id, err)
+		}
+		if rebuilt {
+			done++
+		}
+	}
+	return done, nil
+}
@@ -12,6 +12,8 @@ type MatchStore interface {
 	GetByID(ctx context.Context, id int) (*models.Match, error)
 	GetMaps(ctx context.Context, matchID int) ([]models.MatchMap, error)
 	GetStatRows(ctx context.Context, matchID int) ([]MatchStatRow, error)
+	ListBetweenTeams(ctx context.Context, sideA, sideB []uint) ([]models.Match, error)
+	GetMapsForMatches(ctx context.Context, matchIDs []uint) ([]models.MatchMap, error)
 }
 
 // MatchStatRow is the raw scan target for the per-map player-stat query.
@@ -26,6 +28,7 @@ type MatchStatRow struct {
 	Damage          int
 	Assists         int
 	BPRating        float64
+	Rating          float64
 	HillTime        int
 	SndRounds       int
 	PlantCount      int
@@ -71,7 +74,7 @@ func (s *gormMatchStore) GetStatRows(ctx context.Context, matchID int) ([]MatchS
 		Table("player_map_stats pms").
 		Select(`pms.map_number, pms.player_id, p.gamertag, pms.team_id,
 			pms.kills, pms.deaths, pms.kd_ratio, pms.damage, pms.assists,
-			pms.bp_rating, pms.hill_time, pms.snd_rounds, pms.plant_count,
+			pms.bp_rating, pms.rating, pms.hill_time, pms.snd_rounds, pms.plant_count,
 			pms.defuse_count, pms.first_blood_count, pms.first_death_count,
 			pms.non_traded_kills, pms.highest_streak, pms.data_quality_note`).
 		Joins("JOIN players p ON p.id = pms.player_id").
@@ -80,3 +83,30 @@ func (s *gormMatchStore) GetStatRows(ctx context.Context, matchID int) ([]MatchS
 		Scan(&rows).Error
 	return rows, err
 }
+
+// ListBetweenTeams returns every series where one side is in sideA and the other in sideB, newest first.
+func (s *gormMatchStore) ListBetweenTeams(ctx context.Context, sideA, sideB []uint) ([]models.Match, error) {
+	var matches []models.Match
+	err := s.db.WithContext(ctx).
+		Where("(team1_id IN ? AND team2_id IN ?) OR (team1_id IN ? AND team2_id IN ?)",
+			sideA, sideB, sideB, sideA).
+		Preload("Team1").
+		Preload("Team2").
+		Preload("Tournament").
+		Preload("Tournament.Season").
+		Order("match_date DESC, id DESC").
+		Find(&matches).Error
+	return matches, err
+}
+
+func (s *gormMatchStore) GetMapsForMatches(ctx context.Context, matchIDs []uint) ([]models.MatchMap, error) {
+	maps := make([]models.MatchMap, 0)
+	if len(matchIDs) == 0 {
+		return maps, nil
+	}
+	err := s.db.WithContext(ctx).
+		Where("match_id IN ? AND played = true", matchIDs).
+		Order("match_id ASC, map_number ASC").
+		Find(&maps).Error
+	return maps, err
+}
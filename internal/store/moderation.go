@@ -0,0 +1,154 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ModerationStore is the moderation half of ThreadStore. Every method that changes
+// state takes the audit entry for it and writes both in one transaction.
+type ModerationStore interface {
+	GetUser(ctx context.Context, id uint) (*models.User, error)
+	GetPostForModeration(ctx context.Context, id uint) (*models.ThreadPost, error)
+	SetPostHidden(ctx context.Context, postID uint, hidden bool, entry *models.ModerationLog) error
+	SetThreadLocked(ctx context.Context, threadID uint, locked bool, entry *models.ModerationLog) error
+	UpdateUserModeration(ctx context.Context, userID uint, updates map[string]any, entry *models.ModerationLog) error
+	CreateReport(ctx context.Context, report *models.PostReport) (bool, error)
+	GetReport(ctx context.Context, id uint) (*models.PostReport, error)
+	ListReports(ctx context.Context, status string, limit, offset int) ([]models.PostReport, int64, error)
+	DismissReport(ctx context.Context, id, moderatorID uint, entry *models.ModerationLog) error
+	ListModerationLog(ctx context.Context, limit, offset int) ([]models.ModerationLog, int64, error)
+}
+
+func (s *gormThreadStore) GetUser(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetPostForModeration returns a post whether or not it is hidden; author-deleted
+// posts are gone for moderators too.
+func (s *gormThreadStore) GetPostForModeration(ctx context.Context, id uint) (*models.ThreadPost, error) {
+	var post models.ThreadPost
+	err := s.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&post).Error
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// SetPostHidden hides or restores a post. Hiding also closes the post's open reports
+// as actioned by the same moderator.
+func (s *gormThreadStore) SetPostHidden(ctx context.Context, postID uint, hidden bool, entry *models.ModerationLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var hiddenAt any
+		if hidden {
+			now := time.Now()
+			hiddenAt = now
+			if err := tx.Model(&models.PostReport{}).
+				Where("post_id = ? AND status = ?", postID, models.ReportOpen).
+				Updates(map[string]any{
+					"status":      models.ReportActioned,
+					"resolved_by": entry.ModeratorID,
+					"resolved_at": now,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.ThreadPost{}).Where("id = ?", postID).Update("hidden_at", hiddenAt).Error; err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (s *gormThreadStore) SetThreadLocked(ctx context.Context, threadID uint, locked bool, entry *models.ModerationLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.MatchThread{}).Where("id = ?", threadID).Update("locked", locked).Error; err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (s *gormThreadStore) UpdateUserModeration(ctx context.Context, userID uint, updates map[string]any, entry *models.ModerationLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// CreateReport files a report and reports false if the user already reported the post.
+func (s *gormThreadStore) CreateReport(ctx context.Context, report *models.PostReport) (bool, error) {
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (s *gormThreadStore) GetReport(ctx context.Context, id uint) (*models.PostReport, error) {
+	var report models.PostReport
+	if err := s.db.WithContext(ctx).First(&report, id).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports is the moderator queue, oldest first, with the reported post (hidden or
+// not) and both users loaded.
+func (s *gormThreadStore) ListReports(ctx context.Context, status string, limit, offset int) ([]models.PostReport, int64, error) {
+	base := s.db.WithContext(ctx).Model(&models.PostReport{})
+	if status != "" {
+		base = base.Where("status = ?", status)
+	}
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	reports := make([]models.PostReport, 0)
+	err := base.
+		Preload("Post").
+		Preload("Post.User").
+		Preload("Reporter").
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&reports).Error
+	return reports, total, err
+}
+
+func (s *gormThreadStore) DismissReport(ctx context.Context, id, moderatorID uint, entry *models.ModerationLog) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PostReport{}).Where("id = ?", id).Updates(map[string]any{
+			"status":      models.ReportDismissed,
+			"resolved_by": moderatorID,
+			"resolved_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// ListModerationLog returns the audit trail, newest first.
+func (s *gormThreadStore) ListModerationLog(ctx context.Context, limit, offset int) ([]models.ModerationLog, int64, error) {
+	var total int64
+	if err := s.db.WithContext(ctx).Model(&models.ModerationLog{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	entries := make([]models.ModerationLog, 0)
+	err := s.db.WithContext(ctx).
+		Preload("Moderator").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error
+	return entries, total, err
+}
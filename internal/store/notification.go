@@ -0,0 +1,279 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationStore generates notifications from follows and mentions, serves the
+// in-app inbox and keeps the external delivery queue.
+type NotificationStore interface {
+	Generate(ctx context.Context, since, now time.Time) (int64, error)
+	QueueDeliveries(ctx context.Context, since, now time.Time, channels []string) (int64, error)
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]DueDelivery, error)
+	MarkDelivered(ctx context.Context, id uint, attempts int, at time.Time) error
+	MarkRetry(ctx context.Context, id uint, attempts int, next time.Time, reason string) error
+	MarkFailed(ctx context.Context, id uint, attempts int, reason string) error
+	List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	MarkRead(ctx context.Context, userID, id uint, at time.Time) error
+	MarkAllRead(ctx context.Context, userID uint, at time.Time) (int64, error)
+	GetPreferences(ctx context.Context, userID uint) (*models.NotificationPreference, error)
+	SavePreferences(ctx context.Context, pref *models.NotificationPreference) error
+}
+
+// DueDelivery is a pending delivery whose next attempt is due, with everything needed
+// to send it. Enabled is false when the user has since turned the channel off or
+// deleted their account.
+type DueDelivery struct {
+	DeliveryID     uint
+	Channel        string
+	Attempts       int
+	Enabled        bool
+	NotificationID uint
+	Kind           string
+	Title          string
+	Body           string
+	Link           string
+	CreatedAt      time.Time
+	UserID         uint
+	Username       string
+	Email          string
+	WebhookURL     string
+}
+
+type gormNotificationStore struct{ db *gorm.DB }
+
+func NewGormNotificationStore(db *gorm.DB) NotificationStore { return &gormNotificationStore{db: db} }
+
+// generateMatchesSQL notifies followers of either team, either team's franchise or a
+// player who played in the match once it is decided.
+const generateMatchesSQL = `
+INSERT INTO notifications (user_id, kind, ref_id, title, body, link, created_at)
+SELECT DISTINCT ON (f.user_id, m.id)
+	f.user_id, 'match', m.id,
+	LEFT(t1.name || ' ' || m.team1_score || '-' || m.team2_score || ' ' || t2.name, 200),
+	LEFT('Final · ' || tour.name, 500),
+	'/matches/' || m.id,
+	@now
+FROM matches m
+JOIN teams t1         ON t1.id = m.team1_id
+JOIN teams t2         ON t2.id = m.team2_id
+JOIN tournaments tour ON tour.id = m.tournament_id
+JOIN follows f ON
+	(f.target_type = 'team' AND f.target_id IN (m.team1_id, m.team2_id))
+	OR (f.target_type = 'franchise' AND f.target_id IN (t1.franchise_id, t2.franchise_id))
+	OR (f.target_type = 'player' AND EXISTS (
+		SELECT 1 FROM player_map_stats pms WHERE pms.match_id = m.id AND pms.player_id = f.target_id))
+JOIN users u ON u.id = f.user_id AND u.deleted_at IS NULL
+LEFT JOIN notification_preferences np ON np.user_id = f.user_id
+WHERE m.winner_id IS NOT NULL AND m.updated_at >= @since
+  AND f.created_at <= m.updated_at
+  AND COALESCE(np.matches, true)
+ORDER BY f.user_id, m.id
+ON CONFLICT (user_id, kind, ref_id) DO NOTHING`
+
+// generateTransfersSQL notifies followers of the player, either team or either
+// team's franchise when a transfer is added.
+const generateTransfersSQL = `
+INSERT INTO notifications (user_id, kind, ref_id, title, body, link, created_at)
+SELECT DISTINCT ON (f.user_id, pt.id)
+	f.user_id, 'transfer', pt.id,
+	LEFT(p.gamertag || ': ' ||
+		COALESCE(ft.name, NULLIF(pt.raw_from_team_name, ''), 'free agent') || ' → ' ||
+		COALESCE(tt.name, NULLIF(pt.raw_to_team_name, ''), 'free agent'), 200),
+	LEFT(COALESCE(pt.description, ''), 500),
+	'/players/' || p.id,
+	@now
+FROM player_transfers pt
+JOIN players p    ON p.id = pt.player_id
+LEFT JOIN teams ft ON ft.id = pt.from_team_id
+LEFT JOIN teams tt ON tt.id = pt.to_team_id
+JOIN follows f ON
+	(f.target_type = 'player' AND f.target_id = pt.player_id)
+	OR (f.target_type = 'team' AND f.target_id IN (pt.from_team_id, pt.to_team_id))
+	OR (f.target_type = 'franchise' AND f.target_id IN (ft.franchise_id, tt.franchise_id))
+JOIN users u ON u.id = f.user_id AND u.deleted_at IS NULL
+LEFT JOIN notification_preferences np ON np.user_id = f.user_id
+WHERE pt.created_at >= @since
+  AND f.created_at <= pt.created_at
+  AND COALESCE(np.transfers, true)
+ORDER BY f.user_id, pt.id
+ON CONFLICT (user_id, kind, ref_id) DO NOTHING`
+
+// generateMentionsSQL notifies users @mentioned in a live post by someone else.
+const generateMentionsSQL = `
+INSERT INTO notifications (user_id, kind, ref_id, title, body, link, created_at)
+SELECT pm.user_id, 'mention', tp.id,
+	LEFT(author.username || ' mentioned you', 200),
+	LEFT(tp.body, 500),
+	'/matches/' || mt.match_id,
+	@now
+FROM post_mentions pm
+JOIN thread_posts tp  ON tp.id = pm.post_id AND tp.deleted_at IS NULL AND tp.hidden_at IS NULL
+JOIN match_threads mt ON mt.id = tp.thread_id
+JOIN users author     ON author.id = tp.user_id
+JOIN users u          ON u.id = pm.user_id AND u.deleted_at IS NULL
+LEFT JOIN notification_preferences np ON np.user_id = pm.user_id
+WHERE pm.created_at >= @since
+  AND pm.user_id <> tp.user_id
+  AND COALESCE(np.mentions, true)
+ON CONFLICT (user_id, kind, ref_id) DO NOTHING`
+
+// Generate creates notifications for every match, transfer and mention since the
+// given time. It is idempotent: anything already notified is skipped.
+func (s *gormNotificationStore) Generate(ctx context.Context, since, now time.Time) (int64, error) {
+	var created int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		args := map[string]any{"since": since, "now": now}
+		for _, q := range []string{generateMatchesSQL, generateTransfersSQL, generateMentionsSQL} {
+			res := tx.Exec(q, args)
+			if res.Error != nil {
+				return res.Error
+			}
+			created += res.RowsAffected
+		}
+		return nil
+	})
+	return created, err
+}
+
+// QueueDeliveries queues unread notifications created since the given time on every
+// listed channel the user has turned on.
+func (s *gormNotificationStore) QueueDeliveries(ctx context.Context, since, now time.Time, channels []string) (int64, error) {
+	if len(channels) == 0 {
+		return 0, nil
+	}
+	res := s.db.WithContext(ctx).Exec(`
+		INSERT INTO notification_deliveries
+			(notification_id, channel, status, attempts, next_attempt_at, last_error, created_at, updated_at)
+		SELECT n.id, ch.channel, 'pending', 0, @now, '', @now, @now
+		FROM notifications n
+		JOIN notification_preferences np ON np.user_id = n.user_id
+		CROSS JOIN LATERAL (VALUES
+			('email',   np.email_enabled AND np.email <> ''),
+			('webhook', np.webhook_enabled AND np.webhook_url <> '')
+		) AS ch(channel, enabled)
+		WHERE n.created_at >= @since AND n.read_at IS NULL
+		  AND ch.enabled AND ch.channel IN @channels
+		ON CONFLICT (notification_id, channel) DO NOTHING
+	`, map[string]any{"since": since, "now": now, "channels": channels})
+	return res.RowsAffected, res.Error
+}
+
+func (s *gormNotificationStore) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]DueDelivery, error) {
+	rows := make([]DueDelivery, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT d.id AS delivery_id, d.channel, d.attempts,
+			u.deleted_at IS NULL AND CASE d.channel
+				WHEN 'email'   THEN COALESCE(np.email_enabled AND np.email <> '', false)
+				WHEN 'webhook' THEN COALESCE(np.webhook_enabled AND np.webhook_url <> '', false)
+				ELSE false END AS enabled,
+			n.id AS notification_id, n.kind, n.title, n.body, n.link, n.created_at,
+			u.id AS user_id, u.username,
+			COALESCE(np.email, '')       AS email,
+			COALESCE(np.webhook_url, '') AS webhook_url
+		FROM notification_deliveries d
+		JOIN notifications n ON n.id = d.notification_id
+		JOIN users u         ON u.id = n.user_id
+		LEFT JOIN notification_preferences np ON np.user_id = n.user_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at ASC, d.id ASC
+		LIMIT ?
+	`, now, limit).Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormNotificationStore) MarkDelivered(ctx context.Context, id uint, attempts int, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&models.NotificationDelivery{}).Where("id = ?", id).
+		Updates(map[string]any{"status": models.DeliverySent, "attempts": attempts, "sent_at": at, "last_error": ""}).Error
+}
+
+func (s *gormNotificationStore) MarkRetry(ctx context.Context, id uint, attempts int, next time.Time, reason string) error {
+	return s.db.WithContext(ctx).Model(&models.NotificationDelivery{}).Where("id = ?", id).
+		Updates(map[string]any{"attempts": attempts, "next_attempt_at": next, "last_error": truncate(reason, 500)}).Error
+}
+
+func (s *gormNotificationStore) MarkFailed(ctx context.Context, id uint, attempts int, reason string) error {
+	return s.db.WithContext(ctx).Model(&models.NotificationDelivery{}).Where("id = ?", id).
+		Updates(map[string]any{"status": models.DeliveryFailed, "attempts": attempts, "last_error": truncate(reason, 500)}).Error
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func (s *gormNotificationStore) List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error) {
+	base := s.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		base = base.Where("read_at IS NULL")
+	}
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	notifications := make([]models.Notification, 0)
+	err := base.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (s *gormNotificationStore) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var n int64
+	err := s.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).Count(&n).Error
+	return n, err
+}
+
+// MarkRead marks one of the user's notifications read; someone else's or a missing
+// one is gorm.ErrRecordNotFound. Marking a read notification again keeps its ReadAt.
+func (s *gormNotificationStore) MarkRead(ctx context.Context, userID, id uint, at time.Time) error {
+	res := s.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", at))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *gormNotificationStore) MarkAllRead(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
+
+// GetPreferences returns the user's preferences, or the defaults (every kind in-app,
+// no external channel) when they have never saved any.
+func (s *gormNotificationStore) GetPreferences(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	var pref models.NotificationPreference
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.NotificationPreference{UserID: userID, Matches: true, Mentions: true, Transfers: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
+
+func (s *gormNotificationStore) SavePreferences(ctx context.Context, pref *models.NotificationPreference) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"matches", "mentions", "transfers", "email_enabled", "email",
+			"webhook_enabled", "webhook_url", "updated_at",
+		}),
+	}).Create(pref).Error
+}
@@ -0,0 +1,649 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PatchActor is the change-log actor for rows written by data patches.
+const PatchActor = "patch"
+
+// Patch operations.
+const (
+	PatchMatchUpsert = "match_upsert"
+	PatchMatchDelete = "match_delete"
+	PatchBracket     = "bracket"
+	PatchTeamRename  = "team_rename"
+	PatchTeamMerge   = "team_merge"
+)
+
+// Patch is one data patch file: a list of operations applied in order, in one
+// transaction. Everything is addressed by natural keys — tournament slugs, team names
+// and dates — so a patch reads the same on any copy of the database.
+type Patch struct {
+	ID          string    `json:"id" yaml:"id"`
+	Description string    `json:"description" yaml:"description"`
+	Ops         []PatchOp `json:"ops" yaml:"ops"`
+}
+
+// PatchMatchRef finds one match: the two teams (either order) in a tournament, and
+// the day it was played when they met more than once. Team names are looked up among
+// the teams of the tournament's game.
+type PatchMatchRef struct {
+	Tournament string `json:"tournament" yaml:"tournament"`
+	Team1      string `json:"team1" yaml:"team1"`
+	Team2      string `json:"team2" yaml:"team2"`
+	Date       string `json:"date,omitempty" yaml:"date,omitempty"` // YYYY-MM-DD, UTC
+}
+
+// PatchMatchFields are the match columns a match_upsert sets; nil fields are left
+// alone. Scores are in the order of the ref's teams, not the row's.
+type PatchMatchFields struct {
+	Format          *string `json:"format,omitempty" yaml:"format,omitempty"`
+	MatchType       *string `json:"match_type,omitempty" yaml:"match_type,omitempty"`
+	BracketRound    *string `json:"bracket_round,omitempty" yaml:"bracket_round,omitempty"`
+	BracketPosition *int    `json:"bracket_position,omitempty" yaml:"bracket_position,omitempty"`
+	Team1Score      *int    `json:"team1_score,omitempty" yaml:"team1_score,omitempty"`
+	Team2Score      *int    `json:"team2_score,omitempty" yaml:"team2_score,omitempty"`
+	VodURL          *string `json:"vod_url,omitempty" yaml:"vod_url,omitempty"`
+}
+
+// PatchOp is one operation. Which fields apply depends on Op:
+//
+//   - match_upsert: Match, Set. Inserts the match when there is none (Match.Date is
+//     then required), otherwise updates it.
+//   - match_delete: Match. Deletes it with its maps and stat lines; a match that is
+//     already gone is left alone.
+//   - bracket: Match, Round, Position.
+//   - team_rename: Team, Game, Name and optionally Abbreviation.
+//   - team_merge: Team (merged away), Into, Game and optionally Tournaments. Moves the
+//     team's matches, maps and stat lines — in the listed tournaments, or everywhere
+//     along with its rosters and transfers — onto Into; Create makes Into (a copy of
+//     Team under the new name) when it doesn't exist yet. The old team row is kept.
+type PatchOp struct {
+	Op           string            `json:"op" yaml:"op"`
+	Match        *PatchMatchRef    `json:"match,omitempty" yaml:"match,omitempty"`
+	Set          *PatchMatchFields `json:"set,omitempty" yaml:"set,omitempty"`
+	Round        string            `json:"round,omitempty" yaml:"round,omitempty"`
+	Position     int               `json:"position,omitempty" yaml:"position,omitempty"`
+	Team         string            `json:"team,omitempty" yaml:"team,omitempty"`
+	Game         string            `json:"game,omitempty" yaml:"game,omitempty"`
+	Name         string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Abbreviation string            `json:"abbreviation,omitempty" yaml:"abbreviation,omitempty"`
+	Into         string            `json:"into,omitempty" yaml:"into,omitempty"`
+	Tournaments  []string          `json:"tournaments,omitempty" yaml:"tournaments,omitempty"`
+	Create       bool              `json:"create,omitempty" yaml:"create,omitempty"`
+}
+
+// PatchResult is what applying a patch did: the change-log rows it wrote, in order,
+// and notes on operations that had nothing to do or side effects outside the log.
+type PatchResult struct {
+	Changes []models.ChangeLog
+	Notes   []string
+}
+
+// PatchStore applies data patches and keeps the applied_patches record.
+type PatchStore interface {
+	GetAppliedPatch(ctx context.Context, id string) (*models.AppliedPatch, error)
+	ApplyPatch(ctx context.Context, p *Patch, checksum string, dryRun bool) (*PatchResult, error)
+}
+
+type gormPatchStore struct{ db *gorm.DB }
+
+func NewGormPatchStore(db *gorm.DB) PatchStore { return &gormPatchStore{db: db} }
+
+func (s *gormPatchStore) GetAppliedPatch(ctx context.Context, id string) (*models.AppliedPatch, error) {
+	var applied models.AppliedPatch
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&applied).Error; err != nil {
+		return nil, err
+	}
+	return &applied, nil
+}
+
+// errPatchDryRun rolls back a dry run once its changes have been read.
+var errPatchDryRun = errors.New("dry run")
+
+// ApplyPatch runs the patch's operations in one transaction, recording every changed
+// row in change_log under the patch actor and rebuilding the derived stats of the
+// matches and tournaments it touched. Unless dryRun is set it then records the patch
+// in applied_patches; a dry run rolls everything back and only reports.
+func (s *gormPatchStore) ApplyPatch(ctx context.Context, p *Patch, checksum string, dryRun bool) (*PatchResult, error) {
+	reason := "patch " + p.ID
+	if p.Description != "" {
+		reason += ": " + p.Description
+	}
+	if utf8.RuneCountInString(reason) > 500 {
+		reason = string([]rune(reason)[:500])
+	}
+	result := &PatchResult{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var start uint
+		if err := tx.Model(&models.ChangeLog{}).Select("COALESCE(MAX(id), 0)").Scan(&start).Error; err != nil {
+			return err
+		}
+		a := &patchApplier{
+			tx:                 tx,
+			entry:              &models.ChangeLog{Actor: PatchActor, Reason: reason},
+			tournaments:        map[string]patchTournament{},
+			rebuildMatches:     map[uint]bool{},
+			rebuildTournaments: map[uint]bool{},
+		}
+		for i, op := range p.Ops {
+			if err := a.apply(op); err != nil {
+				return fmt.Errorf("op %d (%s): %w", i+1, op.Op, err)
+			}
+		}
+		for _, id := range sortedKeys(a.rebuildMatches) {
+			if err := rebuildMatchDerived(tx, id); err != nil {
+				return err
+			}
+		}
+		for _, id := range sortedKeys(a.rebuildTournaments) {
+			if err := rebuildTournamentDerived(tx, id); err != nil {
+				return err
+			}
+		}
+		result.Notes = a.notes
+		err := tx.Where("id > ? AND actor = ? AND reason = ?", start, PatchActor, reason).
+			Order("id ASC").Find(&result.Changes).Error
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return errPatchDryRun
+		}
+		return tx.Create(&models.AppliedPatch{
+			ID: p.ID, Checksum: checksum, Description: p.Description,
+			Changes: len(result.Changes), AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil && !errors.Is(err, errPatchDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+func sortedKeys(m map[uint]bool) []uint {
+	keys := make([]uint, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+type patchTournament struct {
+	ID       uint
+	GameCode string
+}
+
+// patchApplier carries one patch's transaction and what its operations touched.
+type patchApplier struct {
+	tx                 *gorm.DB
+	entry              *models.ChangeLog
+	tournaments        map[string]patchTournament // by slug
+	rebuildMatches     map[uint]bool              // matches whose player_match_stats to rebuild
+	rebuildTournaments map[uint]bool              // tournaments whose totals to rebuild
+	notes              []string
+}
+
+func (a *patchApplier) apply(op PatchOp) error {
+	switch op.Op {
+	case PatchMatchUpsert:
+		return a.upsertMatch(op)
+	case PatchMatchDelete:
+		return a.deleteMatch(op)
+	case PatchBracket:
+		return a.setBracket(op)
+	case PatchTeamRename:
+		return a.renameTeam(op)
+	case PatchTeamMerge:
+		return a.mergeTeam(op)
+	}
+	return fmt.Errorf("unknown op %q", op.Op)
+}
+
+func (a *patchApplier) tournament(slug string) (patchTournament, error) {
+	if t, ok := a.tournaments[slug]; ok {
+		return t, nil
+	}
+	var t patchTournament
+	err := a.tx.Raw(`
+		SELECT t.id, COALESCE(s.game_code, '') AS game_code
+		FROM tournaments t LEFT JOIN seasons s ON s.id = t.season_id
+		WHERE t.slug = ?
+		ORDER BY t.id LIMIT 1
+	`, slug).Scan(&t).Error
+	if err != nil {
+		return t, err
+	}
+	if t.ID == 0 {
+		return t, fmt.Errorf("tournament %q: %w", slug, gorm.ErrRecordNotFound)
+	}
+	a.tournaments[slug] = t
+	return t, nil
+}
+
+// team finds a team by name. Names repeat across game eras, so with a game only that
+// game's row (or an untagged one) matches; a name that is still ambiguous is an error.
+func (a *patchApplier) team(name, game string) (*models.Team, error) {
+	var teams []models.Team
+	if err := a.tx.Where("name = ?", name).Order("id ASC").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	if game != "" {
+		tagged := slices.DeleteFunc(slices.Clone(teams), func(t models.Team) bool { return !strings.EqualFold(t.GameCode, game) })
+		if len(tagged) == 0 {
+			tagged = slices.DeleteFunc(teams, func(t models.Team) bool { return t.GameCode != "" })
+		}
+		teams = tagged
+	}
+	switch len(teams) {
+	case 0:
+		return nil, fmt.Errorf("team %q: %w", name, gorm.ErrRecordNotFound)
+	case 1:
+		return &teams[0], nil
+	}
+	return nil, fmt.Errorf("team name %q matches %d teams; give a game", name, len(teams))
+}
+
+// patchMatch is a resolved match ref; match is nil when there is no such match.
+type patchMatch struct {
+	tournament   patchTournament
+	team1, team2 uint
+	date         *time.Time
+	match        *models.Match
+	swapped      bool // the row has the ref's teams the other way round
+	label        string
+}
+
+func (a *patchApplier) findMatch(ref *PatchMatchRef) (*patchMatch, error) {
+	t, err := a.tournament(ref.Tournament)
+	if err != nil {
+		return nil, err
+	}
+	team1, err := a.team(ref.Team1, t.GameCode)
+	if err != nil {
+		return nil, err
+	}
+	team2, err := a.team(ref.Team2, t.GameCode)
+	if err != nil {
+		return nil, err
+	}
+	pm := &patchMatch{
+		tournament: t, team1: team1.ID, team2: team2.ID,
+		label: fmt.Sprintf("%s vs %s in %s", ref.Team1, ref.Team2, ref.Tournament),
+	}
+	q := a.tx.Where("tournament_id = ? AND ((team1_id = ? AND team2_id = ?) OR (team1_id = ? AND team2_id = ?))",
+		t.ID, team1.ID, team2.ID, team2.ID, team1.ID)
+	if ref.Date != "" {
+		day, err := time.Parse(time.DateOnly, ref.Date)
+		if err != nil {
+			return nil, fmt.Errorf("bad date %q", ref.Date)
+		}
+		pm.date = &day
+		pm.label += " on " + ref.Date
+		q = q.Where("match_date >= ? AND match_date < ?", day, day.AddDate(0, 0, 1))
+	}
+	var matches []models.Match
+	if err := q.Order("id ASC").Limit(2).Find(&matches).Error; err != nil {
+		return nil, err
+	}
+	switch len(matches) {
+	case 0:
+	case 1:
+		pm.match = &matches[0]
+		pm.swapped = pm.match.Team1ID != team1.ID
+	default:
+		return nil, fmt.Errorf("%s matches more than one match; give a date", pm.label)
+	}
+	return pm, nil
+}
+
+// winner is the side that has taken a majority of the series, if either has.
+func winner(m *models.Match) *uint {
+	need := BestOf(m.Format)/2 + 1
+	switch {
+	case m.Team1Score >= need && m.Team1Score > m.Team2Score:
+		return &m.Team1ID
+	case m.Team2Score >= need && m.Team2Score > m.Team1Score:
+		return &m.Team2ID
+	}
+	return nil
+}
+
+func (a *patchApplier) upsertMatch(op PatchOp) error {
+	pm, err := a.findMatch(op.Match)
+	if err != nil {
+		return err
+	}
+	set := op.Set
+	if set == nil {
+		set = &PatchMatchFields{}
+	}
+	m := pm.match
+	if m == nil {
+		if pm.date == nil {
+			return fmt.Errorf("%s does not exist; a new match needs a date", pm.label)
+		}
+		m = &models.Match{TournamentID: pm.tournament.ID, Team1ID: pm.team1, Team2ID: pm.team2, MatchDate: *pm.date, Format: "BO5"}
+	}
+
+	s1, s2 := set.Team1Score, set.Team2Score
+	if pm.swapped {
+		s1, s2 = s2, s1
+	}
+	if s1 != nil {
+		m.Team1Score = *s1
+	}
+	if s2 != nil {
+		m.Team2Score = *s2
+	}
+	for _, f := range []struct {
+		dst *string
+		src *string
+	}{{&m.Format, set.Format}, {&m.MatchType, set.MatchType}, {&m.BracketRound, set.BracketRound}, {&m.VodURL, set.VodURL}} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	if set.BracketPosition != nil {
+		m.BracketPosition = *set.BracketPosition
+	}
+	m.WinnerID = winner(m)
+
+	if pm.match == nil {
+		if err := a.tx.Omit(clause.Associations).Create(m).Error; err != nil {
+			return err
+		}
+		key := ChangeKey(m.ID)
+		after, err := snapshot(a.tx, models.ChangeMatch, key)
+		if err != nil {
+			return err
+		}
+		if _, err := recordChange(a.tx, a.entry, models.ChangeMatch, key, nil, after); err != nil {
+			return err
+		}
+		a.rebuildTournaments[m.TournamentID] = true
+		return nil
+	}
+
+	var tally struct{ Played, Team1, Team2 int }
+	err = a.tx.Raw(`
+		SELECT COUNT(*) AS played,
+		       COUNT(*) FILTER (WHERE winner_id = ?) AS team1,
+		       COUNT(*) FILTER (WHERE winner_id = ?) AS team2
+		FROM match_maps WHERE match_id = ? AND played = true
+	`, m.Team1ID, m.Team2ID, m.ID).Scan(&tally).Error
+	if err != nil {
+		return err
+	}
+	if tally.Played > 0 && (tally.Team1 != m.Team1Score || tally.Team2 != m.Team2Score) {
+		return fmt.Errorf("%s: score %d-%d disagrees with its maps (%d-%d)", pm.label, m.Team1Score, m.Team2Score, tally.Team1, tally.Team2)
+	}
+	err = trackChange(a.tx, a.entry, models.ChangeMatch, ChangeKey(m.ID), func() error {
+		return a.tx.Omit(clause.Associations).Save(m).Error
+	})
+	if err != nil {
+		return err
+	}
+	a.rebuildTournaments[m.TournamentID] = true
+	return nil
+}
+
+func (a *patchApplier) deleteMatch(op PatchOp) error {
+	pm, err := a.findMatch(op.Match)
+	if err != nil {
+		return err
+	}
+	if pm.match == nil {
+		a.notes = append(a.notes, pm.label+": already gone")
+		return nil
+	}
+	var refs int64
+	err = a.tx.Raw(`
+		SELECT (SELECT COUNT(*) FROM picks WHERE match_id = @match)
+		     + (SELECT COUNT(*) FROM match_threads WHERE match_id = @match)
+	`, map[string]any{"match": pm.match.ID}).Scan(&refs).Error
+	if err != nil {
+		return err
+	}
+	if refs > 0 {
+		return fmt.Errorf("%s has picks or a discussion thread", pm.label)
+	}
+	if err := deleteMatchTracked(a.tx, a.entry, pm.match.ID); err != nil {
+		return err
+	}
+	a.rebuildTournaments[pm.match.TournamentID] = true
+	return nil
+}
+
+func (a *patchApplier) setBracket(op PatchOp) error {
+	pm, err := a.findMatch(op.Match)
+	if err != nil {
+		return err
+	}
+	if pm.match == nil {
+		return fmt.Errorf("%s: %w", pm.label, gorm.ErrRecordNotFound)
+	}
+	return trackChange(a.tx, a.entry, models.ChangeMatch, ChangeKey(pm.match.ID), func() error {
+		return a.tx.Model(&models.Match{}).Where("id = ?", pm.match.ID).Updates(map[string]any{
+			"bracket_round":    op.Round,
+			"bracket_position": op.Position,
+			"updated_at":       time.Now(),
+		}).Error
+	})
+}
+
+func (a *patchApplier) renameTeam(op PatchOp) error {
+	team, err := a.team(op.Team, op.Game)
+	if err != nil {
+		return err
+	}
+	var taken int64
+	err = a.tx.Model(&models.Team{}).
+		Where("name = ? AND game_code = ? AND id <> ?", op.Name, team.GameCode, team.ID).Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return fmt.Errorf("a %s team is already called %q; merge instead", team.GameCode, op.Name)
+	}
+	updates := map[string]any{"name": op.Name, "updated_at": time.Now()}
+	if op.Abbreviation != "" {
+		updates["abbreviation"] = op.Abbreviation
+	}
+	return trackChange(a.tx, a.entry, models.ChangeTeam, ChangeKey(team.ID), func() error {
+		return a.tx.Model(&models.Team{}).Where("id = ?", team.ID).Updates(updates).Error
+	})
+}
+
+func (a *patchApplier) mergeTeam(op PatchOp) error {
+	from, err := a.team(op.Team, op.Game)
+	if err != nil {
+		return err
+	}
+	into, err := a.team(op.Into, op.Game)
+	if errors.Is(err, gorm.ErrRecordNotFound) && op.Create {
+		into, err = a.createTeam(from, op.Into, op.Abbreviation)
+	}
+	if err != nil {
+		return err
+	}
+	if from.ID == into.ID {
+		return fmt.Errorf("%q and %q are the same team", op.Team, op.Into)
+	}
+
+	args := map[string]any{"from": from.ID, "into": into.ID, "now": time.Now()}
+	scope := ""
+	if len(op.Tournaments) > 0 {
+		ids := make([]uint, len(op.Tournaments))
+		for i, slug := range op.Tournaments {
+			t, err := a.tournament(slug)
+			if err != nil {
+				return err
+			}
+			ids[i] = t.ID
+		}
+		args["scope"] = ids
+		scope = " AND tournament_id IN @scope"
+	}
+
+	var matches []models.Match
+	err = a.tx.Raw("SELECT * FROM matches WHERE (team1_id = @from OR team2_id = @from)"+scope+" ORDER BY id", args).
+		Scan(&matches).Error
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if m.Team1ID == into.ID || m.Team2ID == into.ID {
+			return fmt.Errorf("%q and %q played each other in match %d", op.Team, op.Into, m.ID)
+		}
+		if err := a.repointMatch(m.ID, args); err != nil {
+			return err
+		}
+		a.rebuildMatches[m.ID] = true
+		a.rebuildTournaments[m.TournamentID] = true
+	}
+
+	// Derived rows follow: team totals move over (or give way to Into's own row),
+	// and imported player totals, which have no lines to rebuild from, are repointed.
+	// Rows are moved one at a time so that each move is recorded.
+	type rowMove struct{ entity, where, set string }
+	moves := []rowMove{
+		{models.ChangeTeamTotals, "team_id = @from" + scope + ` AND NOT EXISTS (
+			SELECT 1 FROM team_tournament_stats x WHERE x.team_id = @into AND x.tournament_id = t.tournament_id)`,
+			"team_id = @into, updated_at = @now"},
+		{models.ChangeTeamTotals, "team_id = @from" + scope, ""},
+		{models.ChangePlayerTotals, "team_id = @from" + scope +
+			" AND tournament_id IN (SELECT id FROM tournaments WHERE tournament_type = 'season_summary')",
+			"team_id = @into, updated_at = @now"},
+	}
+	if scope == "" {
+		moves = append(moves,
+			rowMove{models.ChangeRoster, "team_id = @from", "team_id = @into, updated_at = @now"},
+			rowMove{models.ChangeCoach, "team_id = @from", "team_id = @into, updated_at = @now"},
+			rowMove{models.ChangeTransfer, "from_team_id = @from", "from_team_id = @into"},
+			rowMove{models.ChangeTransfer, "to_team_id = @from", "to_team_id = @into"},
+		)
+	}
+	for _, mv := range moves {
+		if err := a.moveRows(mv.entity, mv.where, mv.set, args); err != nil {
+			return err
+		}
+	}
+	note := fmt.Sprintf("merged %q into %q: %d matches", op.Team, op.Into, len(matches))
+	if scope == "" {
+		note += ", rosters and transfers"
+	}
+	a.notes = append(a.notes, note)
+	return nil
+}
+
+// moveRows applies set to each row of entity matching where, or deletes it when set
+// is empty, recording every row it changes.
+func (a *patchApplier) moveRows(entity, where, set string, args map[string]any) error {
+	t := changeTables[entity]
+	var ids []uint
+	if err := a.tx.Raw("SELECT t.id FROM "+t.name+" t WHERE "+where+" ORDER BY t.id", args).Scan(&ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		key := ChangeKey(id)
+		if set == "" {
+			if err := deleteRow(a.tx, a.entry, entity, key); err != nil {
+				return err
+			}
+			continue
+		}
+		args["row"] = id
+		err := trackChange(a.tx, a.entry, entity, key, func() error {
+			return a.tx.Exec("UPDATE "+t.name+" SET "+set+" WHERE id = @row", args).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repointMatch swaps From for Into on a match, its map winners and stat lines, and
+// the winners picked for it.
+func (a *patchApplier) repointMatch(matchID uint, args map[string]any) error {
+	args["match"] = matchID
+	err := trackChange(a.tx, a.entry, models.ChangeMatch, ChangeKey(matchID), func() error {
+		return a.tx.Exec(`
+			UPDATE matches SET
+				team1_id   = CASE WHEN team1_id = @from THEN @into ELSE team1_id END,
+				team2_id   = CASE WHEN team2_id = @from THEN @into ELSE team2_id END,
+				winner_id  = CASE WHEN winner_id = @from THEN @into ELSE winner_id END,
+				updated_at = @now
+			WHERE id = @match`, args).Error
+	})
+	if err != nil {
+		return err
+	}
+	var maps []models.MatchMap
+	if err := a.tx.Select("match_id, map_number").Where("match_id = ? AND winner_id = ?", matchID, args["from"]).Find(&maps).Error; err != nil {
+		return err
+	}
+	for _, mm := range maps {
+		err := trackChange(a.tx, a.entry, models.ChangeMap, ChangeKey(mm.MatchID, mm.MapNumber), func() error {
+			return a.tx.Model(&models.MatchMap{}).Where("match_id = ? AND map_number = ?", mm.MatchID, mm.MapNumber).
+				Updates(map[string]any{"winner_id": args["into"], "updated_at": args["now"]}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	var lines []models.PlayerMapStats
+	err = a.tx.Select("match_id, map_number, player_id").Where("match_id = ? AND team_id = ?", matchID, args["from"]).Find(&lines).Error
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		err := trackChange(a.tx, a.entry, models.ChangeStatLine, ChangeKey(l.MatchID, l.MapNumber, l.PlayerID), func() error {
+			return a.tx.Model(&models.PlayerMapStats{}).
+				Where("match_id = ? AND map_number = ? AND player_id = ?", l.MatchID, l.MapNumber, l.PlayerID).
+				Updates(map[string]any{"team_id": args["into"], "updated_at": args["now"]}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return a.tx.Exec("UPDATE picks SET winner_id = @into, updated_at = @now WHERE match_id = @match AND winner_id = @from", args).Error
+}
+
+// createTeam adds the merge target as a copy of the team merged into it.
+func (a *patchApplier) createTeam(from *models.Team, name, abbreviation string) (*models.Team, error) {
+	team := *from
+	team.ID = 0
+	team.Name = name
+	if abbreviation != "" {
+		team.Abbreviation = abbreviation
+	}
+	team.Source = PatchActor
+	team.CreatedAt, team.UpdatedAt = time.Time{}, time.Time{}
+	team.Franchise, team.Players = nil, nil
+	if err := a.tx.Omit(clause.Associations).Create(&team).Error; err != nil {
+		return nil, err
+	}
+	key := ChangeKey(team.ID)
+	after, err := snapshot(a.tx, models.ChangeTeam, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := recordChange(a.tx, a.entry, models.ChangeTeam, key, nil, after); err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+// A team merge records every row it moves, and each move can be reverted alone.
+func TestApplyPatch_TeamMergeRecordsMovedRows(t *testing.T) {
+	db := storeTx(t)
+	ctx := context.Background()
+
+	mkSeasonAt(t, db, 1, "BO6", time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC))
+	mkTeamRow(t, db, 1, "Old Name", "OLD")
+	mkTeamRow(t, db, 2, "New Name", "NEW")
+	mkPlayerRow(t, db, 1, "Dashy")
+	mkTour(t, db, 11, 1, "Major 1")
+	require.NoError(t, db.Create(&models.Tournament{
+		ID: 10, SeasonID: 1, Name: "BO6 Season Stats", Slug: "BO6-season-stats",
+		TournamentType: "season_summary", StartDate: time.Now(),
+	}).Error)
+	roster := models.TeamRoster{TeamID: 1, PlayerID: 1, SeasonID: 1}
+	require.NoError(t, db.Create(&roster).Error)
+	totals := models.PlayerTournamentStats{PlayerID: 1, TeamID: 1, TournamentID: 10, TotalKills: 300}
+	require.NoError(t, db.Create(&totals).Error)
+	placement := 1
+	result := models.TeamTournamentStats{TeamID: 1, TournamentID: 11, Placement: &placement}
+	require.NoError(t, db.Create(&result).Error)
+
+	_, err := NewGormPatchStore(db).ApplyPatch(ctx, &Patch{
+		ID:  "merge-old-name",
+		Ops: []PatchOp{{Op: PatchTeamMerge, Team: "Old Name", Into: "New Name"}},
+	}, "sum", false)
+	require.NoError(t, err)
+
+	var changes []models.ChangeLog
+	require.NoError(t, db.Where("actor = ?", PatchActor).Order("id").Find(&changes).Error)
+	moved := map[string]models.ChangeLog{}
+	for _, c := range changes {
+		moved[c.Entity+" "+c.EntityKey] = c
+	}
+	for _, key := range []string{
+		models.ChangeRoster + " " + fmt.Sprint(roster.ID),
+		models.ChangePlayerTotals + " " + fmt.Sprint(totals.ID),
+		models.ChangeTeamTotals + " " + fmt.Sprint(result.ID),
+	} {
+		require.Contains(t, moved, key)
+		require.Equal(t, models.ChangeUpdate, moved[key].Action, key)
+	}
+
+	admin := NewGormAdminStore(db)
+	rosterMove := moved[models.ChangeRoster+" "+fmt.Sprint(roster.ID)]
+	_, err = admin.RevertChange(ctx, &rosterMove, 5, &models.ChangeLog{Actor: "admin", Reason: "undo"})
+	require.NoError(t, err)
+	require.NoError(t, db.First(&roster, roster.ID).Error)
+	require.Equal(t, uint(1), roster.TeamID)
+	require.NoError(t, db.First(&totals, totals.ID).Error)
+	require.Equal(t, uint(2), totals.TeamID, "the other moves stand")
+}
@@ -0,0 +1,219 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+)
+
+// PerformanceStore reads every per-map stat line the performance rating is computed
+// from and writes the ratings back, rolled up to match and tournament level, along
+// with the baselines they were rated against.
+type PerformanceStore interface {
+	ListMapStatLines(ctx context.Context) ([]MapStatLine, error)
+	SaveRatings(ctx context.Context, ratings []MapRating, baselines []models.PerformanceBaseline) error
+}
+
+// MapStatLine is one player_map_stats row with the era and mode it was played in.
+// Mode is raw match_maps.mode; empty when the map row is missing.
+type MapStatLine struct {
+	ID             uint
+	GameCode       string
+	Mode           string
+	Kills          int
+	Deaths         int
+	Damage         int
+	Assists        int
+	FirstBloods    int
+	FirstDeaths    int
+	NonTradedKills int
+	HillTime       int
+	Plants         int
+	Defuses        int
+	Captures       int
+}
+
+type MapRating struct {
+	ID     uint
+	Rating float64
+}
+
+type gormPerformanceStore struct{ db *gorm.DB }
+
+func NewGormPerformanceStore(db *gorm.DB) PerformanceStore { return &gormPerformanceStore{db: db} }
+
+func (s *gormPerformanceStore) ListMapStatLines(ctx context.Context) ([]MapStatLine, error) {
+	rows := make([]MapStatLine, 0)
+	err := s.db.WithContext(ctx).
+		Table("player_map_stats pms").
+		Select(`pms.id, COALESCE(s.game_code, '') AS game_code, COALESCE(mm.mode, '') AS mode,
+			pms.kills, pms.deaths, pms.damage, pms.assists,
+			pms.first_blood_count       AS first_bloods,
+			pms.first_death_count       AS first_deaths,
+			pms.non_traded_kills,
+			pms.hill_time,
+			pms.plant_count             AS plants,
+			pms.defuse_count            AS defuses,
+			pms.zone_tier_capture_count AS captures`).
+		Joins("JOIN matches m ON m.id = pms.match_id").
+		Joins("JOIN tournaments tour ON tour.id = m.tournament_id").
+		Joins("JOIN seasons s ON s.id = tour.season_id").
+		Joins("LEFT JOIN match_maps mm ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number").
+		Where("mm.id IS NULL OR mm.played = true").
+		Order("pms.id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// rollupMatchRatingsSQL averages rated maps into player_match_stats.
+const rollupMatchRatingsSQL = `
+UPDATE player_match_stats pm
+SET rating = ROUND(sub.r, 4)
+FROM (
+	SELECT match_id, player_id, AVG(rating) AS r
+	FROM player_map_stats
+	WHERE rating > 0
+	GROUP BY match_id, player_id
+) sub
+WHERE pm.match_id = sub.match_id AND pm.player_id = sub.player_id`
+
+// rollupTournamentRatingsSQL averages rated maps into player_tournament_stats. A
+// season_summary row covers every map its player played in that season.
+const rollupTournamentRatingsSQL = `
+UPDATE player_tournament_stats pts
+SET rating = ROUND(sub.r, 4)
+FROM (
+	SELECT pts2.id, AVG(pms.rating) AS r
+	FROM player_tournament_stats pts2
+	JOIN tournaments t          ON t.id = pts2.tournament_id
+	JOIN player_map_stats pms   ON pms.player_id = pts2.player_id AND pms.rating > 0
+	JOIN matches m              ON m.id = pms.match_id
+	JOIN tournaments mt         ON mt.id = m.tournament_id
+	WHERE (t.tournament_type = 'season_summary' AND mt.season_id = t.season_id)
+	   OR (t.tournament_type <> 'season_summary' AND m.tournament_id = t.id)
+	GROUP BY pts2.id
+) sub
+WHERE pts.id = sub.id`
+
+// rollupSeasonSummaryRatingsSQL refreshes the season_summary rows in @tournament's
+// season, which average every rated map their player played that season.
+const rollupSeasonSummaryRatingsSQL = `
+UPDATE player_tournament_stats pts
+SET rating = COALESCE((
+	SELECT ROUND(AVG(pms.rating), 4)
+	FROM player_map_stats pms
+	JOIN matches m      ON m.id = pms.match_id
+	JOIN tournaments mt ON mt.id = m.tournament_id
+	WHERE pms.player_id = pts.player_id AND pms.rating > 0 AND mt.season_id = t.season_id
+), 0)
+FROM tournaments t
+WHERE t.id = pts.tournament_id AND t.tournament_type = 'season_summary'
+	AND t.season_id = (SELECT season_id FROM tournaments WHERE id = @tournament)`
+
+// rateMatchLinesSQL rates one match's lines the way PerformanceRatingService does:
+// 1 plus the weighted mean of each stat's relative deviation from its baseline,
+// floored at 0.01, skipping stats whose baseline or weight is 0. The stat names and
+// the objective per mode match services/perfrating.go, and
+// TestRebuildRatesLinesLikePerformanceRating checks both give the same ratings.
+const rateMatchLinesSQL = `
+WITH lines AS (
+	SELECT pms.*, COALESCE(s.game_code, '') AS game_code,
+		CASE
+			WHEN mm.mode IN ('Search and Destroy', 'Search & Destroy') THEN 'snd'
+			WHEN mm.mode = 'Hardpoint' THEN 'hp'
+			WHEN mm.mode = 'Control'   THEN 'control'
+			ELSE 'other'
+		END AS mode_key
+	FROM player_map_stats pms
+	JOIN matches m        ON m.id = pms.match_id
+	JOIN tournaments tour ON tour.id = m.tournament_id
+	JOIN seasons s        ON s.id = tour.season_id
+	LEFT JOIN match_maps mm ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number
+	WHERE pms.match_id = @match AND (mm.id IS NULL OR mm.played = true)
+		AND (pms.kills > 0 OR pms.deaths > 0)
+),
+scored AS (
+	SELECT l.id, SUM(b.weight * (v.value / b.average - 1)) / SUM(ABS(b.weight)) AS r
+	FROM lines l
+	CROSS JOIN LATERAL (VALUES
+		('kills', l.kills::float8), ('deaths', l.deaths), ('damage', l.damage), ('assists', l.assists),
+		('first_bloods', l.first_blood_count), ('first_deaths', l.first_death_count),
+		('non_traded_kills', l.non_traded_kills),
+		('objective', CASE l.mode_key
+			WHEN 'hp'      THEN l.hill_time
+			WHEN 'snd'     THEN l.plant_count + l.defuse_count
+			WHEN 'control' THEN l.zone_tier_capture_count
+			ELSE 0 END)
+	) v(stat, value)
+	JOIN performance_baselines b ON b.game_code = l.game_code AND b.mode = l.mode_key
+		AND b.stat = v.stat AND b.average > 0 AND b.weight <> 0
+	GROUP BY l.id
+)
+UPDATE player_map_stats p
+SET rating = ROUND(GREATEST(0.01, 1 + scored.r)::numeric, 4)
+FROM scored
+WHERE p.id = scored.id`
+
+// rateMatchLines re-rates one match's map lines against the stored baselines. Lines
+// that can't be rated (data gaps, unplayed maps, no baselines yet) are set to 0.
+func rateMatchLines(tx *gorm.DB, matchID uint) error {
+	args := map[string]any{"match": matchID}
+	if err := tx.Exec("UPDATE player_map_stats SET rating = 0 WHERE match_id = @match AND rating <> 0", args).Error; err != nil {
+		return err
+	}
+	return tx.Exec(rateMatchLinesSQL, args).Error
+}
+
+// SaveRatings replaces every map rating and the baselines, and recomputes both
+// rollups in one transaction. Rows not in ratings are reset to 0 (unrated).
+func (s *gormPerformanceStore) SaveRatings(ctx context.Context, ratings []MapRating, baselines []models.PerformanceBaseline) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM performance_baselines").Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for i := range baselines {
+			baselines[i].UpdatedAt = now
+		}
+		if len(baselines) > 0 {
+			if err := tx.CreateInBatches(baselines, 500).Error; err != nil {
+				return err
+			}
+		}
+		for _, stmt := range []string{
+			"UPDATE player_map_stats SET rating = 0 WHERE rating <> 0",
+			"UPDATE player_match_stats SET rating = 0 WHERE rating <> 0",
+			"UPDATE player_tournament_stats SET rating = 0 WHERE rating <> 0",
+		} {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+
+		const batch = 1000
+		for start := 0; start < len(ratings); start += batch {
+			end := min(start+batch, len(ratings))
+			chunk := ratings[start:end]
+			values := make([]string, len(chunk))
+			args := make([]any, 0, 2*len(chunk))
+			for i, r := range chunk {
+				values[i] = "(?::bigint, ?::numeric)"
+				args = append(args, r.ID, r.Rating)
+			}
+			err := tx.Exec(`UPDATE player_map_stats p SET rating = v.rating
+				FROM (VALUES `+strings.Join(values, ", ")+`) AS v(id, rating)
+				WHERE p.id = v.id`, args...).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec(rollupMatchRatingsSQL).Error; err != nil {
+			return err
+		}
+		return tx.Exec(rollupTournamentRatingsSQL).Error
+	})
+}
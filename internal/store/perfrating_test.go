@@ -0,0 +1,85 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/corbynfang/CDL-Website/internal/services"
+	"github.com/corbynfang/CDL-Website/internal/store"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// mapRatings reads the ratings of one match's lines by line ID.
+func mapRatings(t *testing.T, db *gorm.DB, matchID uint) map[uint]float64 {
+	t.Helper()
+	var lines []models.PlayerMapStats
+	require.NoError(t, db.Where("match_id = ?", matchID).Order("id").Find(&lines).Error)
+	out := make(map[uint]float64, len(lines))
+	for _, l := range lines {
+		out[l.ID] = l.Rating
+	}
+	return out
+}
+
+// A rebuilt match is rated in SQL against the baselines of the last full run; the
+// ratings must be the ones the full run's Go formula gives the same lines.
+func TestRebuildRatesLinesLikePerformanceRating(t *testing.T) {
+	byEra := services.DefaultPerformanceWeights()
+	byEra.NormalizeByMode = false
+	for name, w := range map[string]services.PerformanceWeights{
+		"by mode": services.DefaultPerformanceWeights(),
+		"by era":  byEra,
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := store.StoreTx(t)
+			ctx := context.Background()
+
+			require.NoError(t, db.Create(&models.Season{ID: 1, Name: "BO6", GameTitle: "BO6", GameCode: "BO6",
+				StartDate: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)}).Error)
+			require.NoError(t, db.Create(&models.Tournament{ID: 1, SeasonID: 1, Name: "Major 1", Slug: "major-1",
+				TournamentType: "major", StartDate: time.Now()}).Error)
+			for id, tag := range map[uint]string{1: "OpTic Texas", 2: "Atlanta FaZe"} {
+				require.NoError(t, db.Create(&models.Team{ID: id, Name: tag}).Error)
+			}
+			for id := uint(1); id <= 5; id++ {
+				require.NoError(t, db.Create(&models.Player{ID: id, Gamertag: string(rune('A' + id))}).Error)
+			}
+			for id := uint(1); id <= 2; id++ {
+				require.NoError(t, db.Create(&models.Match{ID: id, TournamentID: 1, Team1ID: 1, Team2ID: 2,
+					MatchDate: time.Now()}).Error)
+				for n, mode := range []string{"Hardpoint", "Search & Destroy", "Control"} {
+					require.NoError(t, db.Create(&models.MatchMap{MatchID: id, MapNumber: n + 1, Mode: mode, Played: true}).Error)
+					for p := uint(1); p <= 4; p++ {
+						k := int(id*7+p*5) + n*3
+						require.NoError(t, db.Create(&models.PlayerMapStats{
+							MatchID: id, MapNumber: n + 1, PlayerID: p, TeamID: (p + 1) / 2,
+							Kills: k, Deaths: 40 - k/2, Damage: k * 110, Assists: int(p) + n,
+							FirstBloodCount: int(p) % 3, FirstDeathCount: int(id+p) % 2, NonTradedKills: k / 3,
+							HillTime: k * 4, PlantCount: int(p) % 2, DefuseCount: n % 2, ZoneTierCaptureCount: int(p),
+						}).Error)
+					}
+				}
+			}
+			// A data gap is never rated.
+			require.NoError(t, db.Create(&models.PlayerMapStats{MatchID: 1, MapNumber: 1, PlayerID: 5, TeamID: 1}).Error)
+
+			_, err := services.NewPerformanceRatingService(store.NewGormPerformanceStore(db), w).Recompute(ctx)
+			require.NoError(t, err)
+			want := mapRatings(t, db, 1)
+
+			require.NoError(t, db.Exec("UPDATE player_map_stats SET rating = 0").Error)
+			var line models.PlayerMapStats
+			require.NoError(t, db.Where("match_id = ?", 1).Order("id").First(&line).Error)
+			require.NoError(t, store.NewGormAdminStore(db).SaveStatLine(ctx, &line, 1, &models.ChangeLog{Actor: "admin"}))
+			got := mapRatings(t, db, 1)
+
+			require.Len(t, got, len(want))
+			for id, r := range want {
+				require.InDelta(t, r, got[id], 1e-4, "line %d", id)
+			}
+		})
+	}
+}
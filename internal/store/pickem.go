@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PickemStore keeps users' pick'em picks, scores them once their match is decided
+// and ranks users by points.
+type PickemStore interface {
+	GetMatch(ctx context.Context, matchID int) (*models.Match, error)
+	SavePick(ctx context.Context, pick *models.Pick) error
+	ListScorablePicks(ctx context.Context) ([]ScorablePick, error)
+	SavePickScores(ctx context.Context, scores []PickScore, at time.Time) error
+	ListUserPicks(ctx context.Context, userID uint, limit, offset int) ([]models.Pick, int64, error)
+	ListLeaderboard(ctx context.Context, scope PickemScope, limit, offset int) ([]PickemRow, int64, error)
+}
+
+// ScorablePick is a pick on a decided match that has not been scored against the
+// match's current result, or a scored pick on a match that no longer has a winner
+// (MatchWinnerID 0), whose score has to be taken back.
+type ScorablePick struct {
+	PickID          uint
+	PickWinnerID    uint
+	PickTeam1Score  int
+	PickTeam2Score  int
+	MatchWinnerID   uint
+	MatchTeam1Score int
+	MatchTeam2Score int
+}
+
+// PickScore is a pick's new score; nil Points puts the pick back to unscored.
+type PickScore struct {
+	PickID uint
+	Points *int
+}
+
+// PickemScope limits a leaderboard to one tournament or, when TournamentID is 0,
+// one season.
+type PickemScope struct {
+	TournamentID uint
+	SeasonID     uint
+}
+
+// PickemRow is one user on a pick'em leaderboard. Rank is shared on equal points.
+type PickemRow struct {
+	Rank     int    `json:"rank"`
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Points   int    `json:"points"`
+	Picks    int    `json:"picks"`
+	Correct  int    `json:"correct"`
+	Exact    int    `json:"exact"`
+}
+
+type gormPickemStore struct{ db *gorm.DB }
+
+func NewGormPickemStore(db *gorm.DB) PickemStore { return &gormPickemStore{db: db} }
+
+func (s *gormPickemStore) GetMatch(ctx context.Context, matchID int) (*models.Match, error) {
+	var match models.Match
+	if err := s.db.WithContext(ctx).First(&match, matchID).Error; err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// SavePick creates or replaces the user's pick for the match and clears any score.
+func (s *gormPickemStore) SavePick(ctx context.Context, pick *models.Pick) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "match_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"winner_id":   pick.WinnerID,
+			"team1_score": pick.Team1Score,
+			"team2_score": pick.Team2Score,
+			"points":      nil,
+			"scored_at":   nil,
+			"updated_at":  gorm.Expr("NOW()"),
+		}),
+	}).Create(pick).Error
+}
+
+// ListScorablePicks returns picks whose match has a winner and which were never
+// scored or were scored before the match row last changed, and scored picks whose
+// match has lost its winner to a correction or revert.
+func (s *gormPickemStore) ListScorablePicks(ctx context.Context) ([]ScorablePick, error) {
+	rows := make([]ScorablePick, 0)
+	err := s.db.WithContext(ctx).
+		Table("picks p").
+		Select(`p.id AS pick_id, p.winner_id AS pick_winner_id,
+			p.team1_score AS pick_team1_score, p.team2_score AS pick_team2_score,
+			COALESCE(m.winner_id, 0) AS match_winner_id,
+			m.team1_score AS match_team1_score, m.team2_score AS match_team2_score`).
+		Joins("JOIN matches m ON m.id = p.match_id").
+		Where(`(m.winner_id IS NOT NULL AND (p.scored_at IS NULL OR p.scored_at < m.updated_at))
+			OR (m.winner_id IS NULL AND (p.points IS NOT NULL OR p.scored_at IS NOT NULL))`).
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormPickemStore) SavePickScores(ctx context.Context, scores []PickScore, at time.Time) error {
+	if len(scores) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, sc := range scores {
+			var scoredAt *time.Time
+			if sc.Points != nil {
+				scoredAt = &at
+			}
+			err := tx.Model(&models.Pick{}).Where("id = ?", sc.PickID).
+				UpdateColumns(map[string]any{"points": sc.Points, "scored_at": scoredAt}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *gormPickemStore) ListUserPicks(ctx context.Context, userID uint, limit, offset int) ([]models.Pick, int64, error) {
+	query := s.db.WithContext(ctx).Model(&models.Pick{}).Where("picks.user_id = ?", userID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	picks := make([]models.Pick, 0)
+	err := query.
+		Joins("JOIN matches ON matches.id = picks.match_id").
+		Preload("Match.Team1").Preload("Match.Team2").Preload("Match.Tournament").
+		Order("matches.match_date DESC, picks.id DESC").
+		Limit(limit).Offset(offset).
+		Find(&picks).Error
+	return picks, total, err
+}
+
+func (s *gormPickemStore) ListLeaderboard(ctx context.Context, scope PickemScope, limit, offset int) ([]PickemRow, int64, error) {
+	filter, arg := "t.season_id = ?", scope.SeasonID
+	if scope.TournamentID != 0 {
+		filter, arg = "t.id = ?", scope.TournamentID
+	}
+	base := `
+		SELECT p.user_id, u.username,
+			SUM(p.points) AS points,
+			COUNT(*) AS picks,
+			COUNT(*) FILTER (WHERE p.winner_id = m.winner_id) AS correct,
+			COUNT(*) FILTER (WHERE p.winner_id = m.winner_id
+				AND p.team1_score = m.team1_score AND p.team2_score = m.team2_score) AS exact
+		FROM picks p
+		JOIN matches m     ON m.id = p.match_id
+		JOIN tournaments t ON t.id = m.tournament_id
+		JOIN users u       ON u.id = p.user_id AND u.deleted_at IS NULL
+		WHERE p.points IS NOT NULL AND ` + filter + `
+		GROUP BY p.user_id, u.username`
+
+	var total int64
+	if err := s.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM ("+base+") board", arg).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	rows := make([]PickemRow, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT RANK() OVER (ORDER BY points DESC) AS rank, *
+		FROM (`+base+`) board
+		ORDER BY points DESC, exact DESC, correct DESC, username ASC
+		LIMIT ? OFFSET ?
+	`, arg, limit, offset).Scan(&rows).Error
+	return rows, total, err
+}
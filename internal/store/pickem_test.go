@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+// A scored pick on a match whose winner was cleared is listed again, and saving a
+// nil score puts it back to unscored.
+func TestListScorablePicks_UndecidedMatchTakesPointsBack(t *testing.T) {
+	db := storeTx(t)
+	ctx := context.Background()
+
+	mkSeasonAt(t, db, 1, "BO6", time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC))
+	mkTeamRow(t, db, 1, "OpTic Texas", "OTX")
+	mkTeamRow(t, db, 2, "Atlanta FaZe", "ATL")
+	mkTour(t, db, 1, 1, "Major 1")
+	mkMatchRow(t, db, 1, 1, 1, 2, time.Now())
+	require.NoError(t, db.Create(&models.User{ID: 1, SupabaseUID: "u1", Username: "picker"}).Error)
+	points, scoredAt := 5, time.Now().Add(time.Hour)
+	pick := models.Pick{UserID: 1, MatchID: 1, WinnerID: 1, Team1Score: 3, Points: &points, ScoredAt: &scoredAt}
+	require.NoError(t, db.Omit("Match").Create(&pick).Error)
+
+	st := NewGormPickemStore(db)
+	picks, err := st.ListScorablePicks(ctx)
+	require.NoError(t, err)
+	require.Len(t, picks, 1)
+	require.Zero(t, picks[0].MatchWinnerID)
+
+	require.NoError(t, st.SavePickScores(ctx, []PickScore{{PickID: pick.ID}}, time.Now()))
+	require.NoError(t, db.First(&pick, pick.ID).Error)
+	require.Nil(t, pick.Points)
+	require.Nil(t, pick.ScoredAt)
+
+	picks, err = st.ListScorablePicks(ctx)
+	require.NoError(t, err)
+	require.Empty(t, picks, "an unscored pick on an undecided match waits for a winner")
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"gorm.io/gorm"
@@ -17,6 +18,9 @@ type PlayerStore interface {
 	ListModeKDSplits(ctx context.Context, playerID int) ([]ModeKDSplit, error)
 	ListMatchHistoryRows(ctx context.Context, playerID int) ([]models.PlayerMatchStats, error)
 	ListCareerRows(ctx context.Context, playerID int) ([]PlayerCareerRow, error)
+	ListCompareRows(ctx context.Context, playerIDs []uint, seasonID, tournamentID string) ([]PlayerCompareRow, error)
+	ListFacedMapRows(ctx context.Context, playerIDs []uint, seasonID, tournamentID string) ([]FacedMapRow, error)
+	ListFormMaps(ctx context.Context, playerID int) ([]FormMapRow, error)
 }
 
 // ModeKDSplit holds a player's kill/death totals for one game mode, aggregated
@@ -43,6 +47,50 @@ type PlayerCareerRow struct {
 	Deaths        int
 }
 
+// PlayerCompareRow is one player's per-map totals for a (season, mode) bucket.
+// Mode is normalised the same way as ModeKDSplit. TimedKills/DurationSec only
+// count maps with a recorded duration, so K/10min is not skewed by missing data.
+type PlayerCompareRow struct {
+	PlayerID       uint
+	SeasonID       uint
+	SeasonName     string
+	Mode           string
+	Maps           int
+	Kills          int
+	Deaths         int
+	Damage         int
+	FirstBloods    int
+	FirstDeaths    int
+	Plants         int
+	Defuses        int
+	NonTradedKills int
+	TimedKills     int
+	DurationSec    int
+}
+
+// FacedMapRow is one compared player's stat line on a map where at least two of
+// the compared players were on opposing teams.
+type FacedMapRow struct {
+	MatchID   uint
+	MapNumber int
+	MapName   string
+	Mode      string
+	MatchDate time.Time
+	PlayerID  uint
+	TeamID    uint
+	Kills     int
+	Deaths    int
+	Damage    int
+}
+
+// modeCaseSQL normalises match_maps.mode (aliased mm) to hp / snd / control / other.
+const modeCaseSQL = `CASE
+				WHEN mm.mode IN ('Search and Destroy', 'Search & Destroy') THEN 'snd'
+				WHEN mm.mode = 'Hardpoint' THEN 'hp'
+				WHEN mm.mode = 'Control'   THEN 'control'
+				ELSE 'other'
+			END`
+
 type gormPlayerStore struct{ db *gorm.DB }
 
 func NewGormPlayerStore(db *gorm.DB) PlayerStore { return &gormPlayerStore{db: db} }
@@ -157,3 +205,68 @@ func (s *gormPlayerStore) ListCareerRows(ctx context.Context, playerID int) ([]P
 	`, playerID).Scan(&rows).Error
 	return rows, err
 }
+
+// scopedMapStats is player_map_stats joined to its played map, match and tournament,
+// optionally narrowed to one season or tournament.
+func (s *gormPlayerStore) scopedMapStats(ctx context.Context, seasonID, tournamentID string) *gorm.DB {
+	query := s.db.WithContext(ctx).
+		Table("player_map_stats pms").
+		Joins("JOIN match_maps mm ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number").
+		Joins("JOIN matches m ON m.id = pms.match_id").
+		Joins("JOIN tournaments tour ON tour.id = m.tournament_id").
+		Where("mm.played = true")
+	if seasonID != "" {
+		query = query.Where("tour.season_id = ?", seasonID)
+	}
+	if tournamentID != "" {
+		query = query.Where("m.tournament_id = ?", tournamentID)
+	}
+	return query
+}
+
+func (s *gormPlayerStore) ListCompareRows(ctx context.Context, playerIDs []uint, seasonID, tournamentID string) ([]PlayerCompareRow, error) {
+	rows := make([]PlayerCompareRow, 0)
+	err := s.scopedMapStats(ctx, seasonID, tournamentID).
+		Select(`pms.player_id, tour.season_id, MAX(s.name) AS season_name,
+			`+modeCaseSQL+` AS mode,
+			COUNT(*)                         AS maps,
+			SUM(pms.kills)                   AS kills,
+			SUM(pms.deaths)                  AS deaths,
+			SUM(pms.damage)                  AS damage,
+			SUM(pms.first_blood_count)       AS first_bloods,
+			SUM(pms.first_death_count)       AS first_deaths,
+			SUM(pms.plant_count)             AS plants,
+			SUM(pms.defuse_count)            AS defuses,
+			SUM(pms.non_traded_kills)        AS non_traded_kills,
+			SUM(CASE WHEN mm.duration_sec > 0 THEN pms.kills ELSE 0 END) AS timed_kills,
+			SUM(mm.duration_sec)             AS duration_sec`).
+		Joins("JOIN seasons s ON s.id = tour.season_id").
+		Where("pms.player_id IN ?", playerIDs).
+		Group("pms.player_id, tour.season_id, 4").
+		Order("MAX(s.start_date) DESC, pms.player_id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormPlayerStore) ListFacedMapRows(ctx context.Context, playerIDs []uint, seasonID, tournamentID string) ([]FacedMapRow, error) {
+	rows := make([]FacedMapRow, 0)
+	err := s.scopedMapStats(ctx, seasonID, tournamentID).
+		Select(`pms.match_id, pms.map_number, mm.map_name, mm.mode, m.match_date,
+			pms.player_id, pms.team_id, pms.kills, pms.deaths, pms.damage`).
+		Where("pms.player_id IN ?", playerIDs).
+		Where(`EXISTS (
+			SELECT 1
+			FROM player_map_stats a
+			JOIN player_map_stats b
+				ON b.match_id = a.match_id
+				AND b.map_number = a.map_number
+				AND b.team_id <> a.team_id
+			WHERE a.match_id = pms.match_id
+				AND a.map_number = pms.map_number
+				AND a.player_id IN ?
+				AND b.player_id IN ?
+		)`, playerIDs, playerIDs).
+		Order("m.match_date DESC, pms.match_id DESC, pms.map_number ASC, pms.player_id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PredictionStore reads what the match prediction model is fitted from: the two
+// teams' ratings going into a series and the maps both have played before it.
+type PredictionStore interface {
+	GetPredictionMatch(ctx context.Context, matchID int) (*PredictionMatch, error)
+	ListPriorMaps(ctx context.Context, match *PredictionMatch) ([]PredictionMapRow, error)
+	ListAllMaps(ctx context.Context) ([]PredictionMapRow, error)
+}
+
+// PredictionMatch is a series with each team's rating going into it. RatingSource is
+// "pre_series" when the series has been rated (rating_before from its history row),
+// "current" when it has not (the team's latest rating) and "initial" when the team has
+// no rating at all, in which case Rating and RD are nil.
+type PredictionMatch struct {
+	MatchID           uint
+	GameCode          string
+	PlayedAt          time.Time
+	Team1ID           uint
+	Team1Name         string
+	Team1Abbr         string
+	Team1Rating       *float64
+	Team1RD           *float64
+	Team1RatingSource string
+	Team2ID           uint
+	Team2Name         string
+	Team2Abbr         string
+	Team2Rating       *float64
+	Team2RD           *float64
+	Team2RatingSource string
+	WinnerID          *uint
+}
+
+// PredictionMapRow is one played map with the pre-series ratings of its series, when
+// rated. The backtest ignores the stored ratings and derives its own as it goes.
+type PredictionMapRow struct {
+	MatchID     uint
+	MapNumber   int
+	Mode        string
+	Team1ID     uint
+	Team2ID     uint
+	WinnerID    *uint
+	Team1Rating *float64
+	Team1RD     *float64
+	Team2Rating *float64
+	Team2RD     *float64
+}
+
+type gormPredictionStore struct{ db *gorm.DB }
+
+func NewGormPredictionStore(db *gorm.DB) PredictionStore { return &gormPredictionStore{db: db} }
+
+func (s *gormPredictionStore) GetPredictionMatch(ctx context.Context, matchID int) (*PredictionMatch, error) {
+	var rows []PredictionMatch
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT m.id AS match_id, COALESCE(se.game_code, '') AS game_code,
+			`+playedAtSQL+` AS played_at,
+			m.team1_id, t1.name AS team1_name, t1.abbreviation AS team1_abbr,
+			COALESCE(h1.rating_before, r1.rating) AS team1_rating,
+			COALESCE(h1.rd_before, r1.rd)         AS team1_rd,
+			CASE WHEN h1.id IS NOT NULL THEN 'pre_series'
+			     WHEN r1.id IS NOT NULL THEN 'current' ELSE 'initial' END AS team1_rating_source,
+			m.team2_id, t2.name AS team2_name, t2.abbreviation AS team2_abbr,
+			COALESCE(h2.rating_before, r2.rating) AS team2_rating,
+			COALESCE(h2.rd_before, r2.rd)         AS team2_rd,
+			CASE WHEN h2.id IS NOT NULL THEN 'pre_series'
+			     WHEN r2.id IS NOT NULL THEN 'current' ELSE 'initial' END AS team2_rating_source,
+			m.winner_id
+		FROM matches m
+		JOIN tournaments tour ON tour.id = m.tournament_id
+		LEFT JOIN seasons se  ON se.id = tour.season_id
+		JOIN teams t1 ON t1.id = m.team1_id
+		JOIN teams t2 ON t2.id = m.team2_id
+		LEFT JOIN team_rating_history h1 ON h1.match_id = m.id AND h1.team_id = m.team1_id
+		LEFT JOIN team_rating_history h2 ON h2.match_id = m.id AND h2.team_id = m.team2_id
+		LEFT JOIN team_ratings r1 ON r1.team_id = m.team1_id
+		LEFT JOIN team_ratings r2 ON r2.team_id = m.team2_id
+		WHERE m.id = ?
+	`, matchID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rows[0], nil
+}
+
+// predictionMapsSQL selects played, decided maps of mode-bearing series, oldest first.
+const predictionMapsSQL = `
+		SELECT mm.match_id, mm.map_number, mm.mode, m.team1_id, m.team2_id, mm.winner_id,
+			h1.rating_before AS team1_rating, h1.rd_before AS team1_rd,
+			h2.rating_before AS team2_rating, h2.rd_before AS team2_rd
+		FROM match_maps mm
+		JOIN matches m        ON m.id = mm.match_id
+		JOIN tournaments tour ON tour.id = m.tournament_id
+		LEFT JOIN team_rating_history h1 ON h1.match_id = m.id AND h1.team_id = m.team1_id
+		LEFT JOIN team_rating_history h2 ON h2.match_id = m.id AND h2.team_id = m.team2_id
+		WHERE mm.played = true AND mm.winner_id IS NOT NULL
+		  AND m.team1_id <> m.team2_id
+		  AND tour.tournament_type <> 'season_summary'`
+
+// ListPriorMaps returns every map either team played strictly before the series, by
+// the same chronology the rating engine uses.
+func (s *gormPredictionStore) ListPriorMaps(ctx context.Context, match *PredictionMatch) ([]PredictionMapRow, error) {
+	rows := make([]PredictionMapRow, 0)
+	err := s.db.WithContext(ctx).Raw(predictionMapsSQL+`
+		  AND (m.team1_id IN @teams OR m.team2_id IN @teams)
+		  AND (`+playedAtSQL+` < @at OR (`+playedAtSQL+` = @at AND m.id < @match))
+		ORDER BY m.id ASC, mm.map_number ASC
+	`, map[string]any{
+		"teams": []uint{match.Team1ID, match.Team2ID},
+		"at":    match.PlayedAt,
+		"match": match.MatchID,
+	}).Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormPredictionStore) ListAllMaps(ctx context.Context) ([]PredictionMapRow, error) {
+	rows := make([]PredictionMapRow, 0)
+	err := s.db.WithContext(ctx).Raw(predictionMapsSQL + `
+		ORDER BY m.id ASC, mm.map_number ASC
+	`).Scan(&rows).Error
+	return rows, err
+}
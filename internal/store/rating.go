@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RatingStore reads the decided-series feed the rating engine walks and persists
+// the resulting team_ratings / team_rating_history rows.
+type RatingStore interface {
+	ListRatableMatches(ctx context.Context, unratedOnly bool) ([]RatableMatch, error)
+	GetLatestRatedMatch(ctx context.Context) (*RatableMatch, error)
+	ListCurrentRatings(ctx context.Context) ([]models.TeamRating, error)
+	GetMaxSequence(ctx context.Context) (int, error)
+	GetTeamRating(ctx context.Context, teamID int) (*models.TeamRating, error)
+	ListTeamHistory(ctx context.Context, teamID int) ([]models.TeamRatingHistory, error)
+	ListSeasonRankings(ctx context.Context, seasonID uint) ([]RankingRow, error)
+	ReplaceAll(ctx context.Context, ratings []models.TeamRating, history []models.TeamRatingHistory) error
+	Append(ctx context.Context, ratings []models.TeamRating, history []models.TeamRatingHistory) error
+}
+
+// RatableMatch is one decided series in the order the rating engine must process it.
+// PlayedAt falls back to the tournament start date for rows seeded without a real match date.
+type RatableMatch struct {
+	MatchID        uint
+	Team1ID        uint
+	Team2ID        uint
+	WinnerID       uint
+	Team1Score     int
+	Team2Score     int
+	SeasonID       uint
+	GameCode       string
+	SeasonStart    time.Time
+	PlayedAt       time.Time
+	Team1Franchise *uint
+	Team2Franchise *uint
+}
+
+// RankingRow is the raw scan target for the per-season rankings query.
+type RankingRow struct {
+	TeamID        uint      `json:"team_id"`
+	TeamName      string    `json:"team_name"`
+	TeamAbbr      string    `json:"team_abbr"`
+	TeamLogo      string    `json:"team_logo"`
+	Rating        float64   `json:"rating"`
+	RD            float64   `json:"rd"`
+	Volatility    float64   `json:"volatility"`
+	Matches       int       `json:"matches"`
+	Wins          int       `json:"wins"`
+	Losses        int       `json:"losses"`
+	LastMatchDate time.Time `json:"last_match_date"`
+}
+
+type gormRatingStore struct{ db *gorm.DB }
+
+func NewGormRatingStore(db *gorm.DB) RatingStore { return &gormRatingStore{db: db} }
+
+func (s *gormRatingStore) ratableBase(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).
+		Table("matches m").
+		Select(`m.id AS match_id, m.team1_id, m.team2_id, m.winner_id,
+			m.team1_score, m.team2_score,
+			tour.season_id, COALESCE(s.game_code, '') AS game_code,
+			s.start_date AS season_start,
+			CASE WHEN m.match_date <= '0001-01-02 00:00:00+00'::timestamptz
+				THEN tour.start_date ELSE m.match_date END AS played_at,
+			t1.franchise_id AS team1_franchise, t2.franchise_id AS team2_franchise`).
+		Joins("JOIN tournaments tour ON tour.id = m.tournament_id").
+		Joins("JOIN seasons s ON s.id = tour.season_id").
+		Joins("JOIN teams t1 ON t1.id = m.team1_id").
+		Joins("JOIN teams t2 ON t2.id = m.team2_id").
+		Where("m.winner_id IS NOT NULL AND m.team1_id <> m.team2_id").
+		Where("tour.tournament_type <> 'season_summary'")
+}
+
+func (s *gormRatingStore) ListRatableMatches(ctx context.Context, unratedOnly bool) ([]RatableMatch, error) {
+	query := s.ratableBase(ctx)
+	if unratedOnly {
+		query = query.Where("NOT EXISTS (SELECT 1 FROM team_rating_history h WHERE h.match_id = m.id)")
+	}
+	rows := make([]RatableMatch, 0)
+	err := query.Order("season_start ASC, played_at ASC, m.id ASC").Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormRatingStore) GetLatestRatedMatch(ctx context.Context) (*RatableMatch, error) {
+	var rows []RatableMatch
+	err := s.ratableBase(ctx).
+		Where("m.id = (SELECT match_id FROM team_rating_history ORDER BY sequence DESC LIMIT 1)").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+func (s *gormRatingStore) ListCurrentRatings(ctx context.Context) ([]models.TeamRating, error) {
+	var ratings []models.TeamRating
+	err := s.db.WithContext(ctx).Order("team_id ASC").Find(&ratings).Error
+	return ratings, err
+}
+
+func (s *gormRatingStore) GetMaxSequence(ctx context.Context) (int, error) {
+	var seq int
+	err := s.db.WithContext(ctx).Model(&models.TeamRatingHistory{}).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&seq).Error
+	return seq, err
+}
+
+func (s *gormRatingStore) GetTeamRating(ctx context.Context, teamID int) (*models.TeamRating, error) {
+	var rating models.TeamRating
+	err := s.db.WithContext(ctx).Where("team_id = ?", teamID).First(&rating).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rating, nil
+}
+
+func (s *gormRatingStore) ListTeamHistory(ctx context.Context, teamID int) ([]models.TeamRatingHistory, error) {
+	history := make([]models.TeamRatingHistory, 0)
+	err := s.db.WithContext(ctx).
+		Where("team_id = ?", teamID).
+		Order("sequence ASC").
+		Find(&history).Error
+	return history, err
+}
+
+func (s *gormRatingStore) ListSeasonRankings(ctx context.Context, seasonID uint) ([]RankingRow, error) {
+	rows := make([]RankingRow, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		WITH season_hist AS (
+			SELECT * FROM team_rating_history WHERE season_id = ?
+		),
+		latest AS (
+			SELECT DISTINCT ON (team_id) team_id, rating_after, rd_after, volatility, match_date
+			FROM season_hist
+			ORDER BY team_id, sequence DESC
+		),
+		record AS (
+			SELECT team_id,
+				COUNT(*)                                AS matches,
+				SUM(CASE WHEN won THEN 1 ELSE 0 END)    AS wins
+			FROM season_hist
+			GROUP BY team_id
+		)
+		SELECT l.team_id,
+			t.name                        AS team_name,
+			t.abbreviation                AS team_abbr,
+			COALESCE(t.logo_url, '')      AS team_logo,
+			l.rating_after                AS rating,
+			l.rd_after                    AS rd,
+			l.volatility,
+			r.matches,
+			r.wins,
+			r.matches - r.wins            AS losses,
+			l.match_date                  AS last_match_date
+		FROM latest l
+		JOIN record r ON r.team_id = l.team_id
+		JOIN teams t  ON t.id = l.team_id
+		ORDER BY l.rating_after DESC, l.team_id ASC
+	`, seasonID).Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormRatingStore) ReplaceAll(ctx context.Context, ratings []models.TeamRating, history []models.TeamRatingHistory) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM team_rating_history").Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM team_ratings").Error; err != nil {
+			return err
+		}
+		return insertRatings(tx, ratings, history)
+	})
+}
+
+func (s *gormRatingStore) Append(ctx context.Context, ratings []models.TeamRating, history []models.TeamRatingHistory) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return insertRatings(tx, ratings, history)
+	})
+}
+
+// insertRatings upserts current ratings on team_id and appends history rows.
+func insertRatings(tx *gorm.DB, ratings []models.TeamRating, history []models.TeamRatingHistory) error {
+	if len(ratings) > 0 {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "team_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"franchise_id", "game_code", "season_id", "rating", "rd", "volatility",
+				"matches_played", "wins", "losses", "last_match_id", "last_match_date", "updated_at",
+			}),
+		}).CreateInBatches(ratings, 500).Error
+		if err != nil {
+			return err
+		}
+	}
+	if len(history) > 0 {
+		return tx.CreateInBatches(history, 500).Error
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+)
+
+// SeedActor is the change-log actor for rows written by the CSV seeder.
+const SeedActor = "seed"
+
+// SeedLog records the seeder's writes to change-logged tables under the seed actor
+// and collects the matches and tournaments they touched for Rebuild. The seeder
+// writes in bulk, so rows are snapshotted by primary key, a batch at a time.
+type SeedLog struct {
+	entry       models.ChangeLog
+	matches     map[uint]bool
+	tournaments map[uint]bool
+}
+
+func NewSeedLog(reason string) *SeedLog {
+	return &SeedLog{
+		entry:       models.ChangeLog{Actor: SeedActor, Reason: reason},
+		matches:     map[uint]bool{},
+		tournaments: map[uint]bool{},
+	}
+}
+
+// SeedEntity returns the change-log entity whose rows live in table, if any.
+func SeedEntity(table string) (string, bool) {
+	for entity, t := range changeTables {
+		if t.name == table {
+			return entity, true
+		}
+	}
+	return "", false
+}
+
+// keyExpr is the SQL for the entity key of a row of t aliased "t".
+func (t changeTable) keyExpr() string {
+	cols := make([]string, len(t.keys))
+	for i, col := range t.keys {
+		cols[i] = "t." + col
+	}
+	return "concat_ws(':', " + strings.Join(cols, ", ") + ")"
+}
+
+// Overridden returns the rows among ids whose latest change was made by someone
+// other than the seeder or live ingestion — an admin correction or a data patch —
+// with that change's actor, by row ID.
+func (l *SeedLog) Overridden(tx *gorm.DB, entity string, ids []any) (map[uint]string, error) {
+	out := map[uint]string{}
+	if len(ids) == 0 {
+		return out, nil
+	}
+	t := changeTables[entity]
+	var rows []struct {
+		ID    uint
+		Actor string
+	}
+	err := tx.Raw(`
+		SELECT t.id, c.actor FROM `+t.name+` t
+		JOIN LATERAL (
+			SELECT actor FROM change_log c
+			WHERE c.entity = @entity AND c.entity_key = `+t.keyExpr()+`
+			ORDER BY c.id DESC LIMIT 1
+		) c ON c.actor NOT IN @writers
+		WHERE t.id IN @ids`,
+		map[string]any{"entity": entity, "ids": ids, "writers": []string{SeedActor, LiveSource}},
+	).Scan(&rows).Error
+	for _, r := range rows {
+		out[r.ID] = r.Actor
+	}
+	return out, err
+}
+
+type seedSnapshot struct {
+	EntityKey string
+	Row       json.RawMessage
+}
+
+// snapshots returns the rows among ids as to_jsonb renders them, by row ID.
+func (l *SeedLog) snapshots(tx *gorm.DB, entity string, ids []any) (map[uint]seedSnapshot, error) {
+	t := changeTables[entity]
+	var rows []struct {
+		ID        uint
+		EntityKey string
+		Snapshot  []byte
+	}
+	err := tx.Raw("SELECT t.id, "+t.keyExpr()+" AS entity_key, to_jsonb(t) AS snapshot FROM "+t.name+" t WHERE t.id IN ?", ids).
+		Scan(&rows).Error
+	out := make(map[uint]seedSnapshot, len(rows))
+	for _, r := range rows {
+		out[r.ID] = seedSnapshot{EntityKey: r.EntityKey, Row: r.Snapshot}
+	}
+	return out, err
+}
+
+// Track runs write and records a change for each row among ids that it changed or
+// deleted.
+func (l *SeedLog) Track(tx *gorm.DB, entity string, ids []any, write func() error) error {
+	if len(ids) == 0 {
+		return write()
+	}
+	before, err := l.snapshots(tx, entity, ids)
+	if err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	after, err := l.snapshots(tx, entity, ids)
+	if err != nil {
+		return err
+	}
+	return l.record(tx, entity, before, after)
+}
+
+// Created records the creation of the rows among ids.
+func (l *SeedLog) Created(tx *gorm.DB, entity string, ids []any) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	after, err := l.snapshots(tx, entity, ids)
+	if err != nil {
+		return err
+	}
+	return l.record(tx, entity, nil, after)
+}
+
+func (l *SeedLog) record(tx *gorm.DB, entity string, before, after map[uint]seedSnapshot) error {
+	var rows []models.ChangeLog
+	add := func(key string, b, a json.RawMessage) {
+		if bytes.Equal(b, a) {
+			return
+		}
+		rows = append(rows, changeRow(&l.entry, entity, key, b, a))
+		l.touch(entity, key, b, a)
+	}
+	for id, b := range before {
+		add(b.EntityKey, b.Row, after[id].Row)
+	}
+	for id, a := range after {
+		if _, ok := before[id]; !ok {
+			add(a.EntityKey, nil, a.Row)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	slices.SortFunc(rows, func(a, b models.ChangeLog) int { return strings.Compare(a.EntityKey, b.EntityKey) })
+	return tx.CreateInBatches(rows, 500).Error
+}
+
+// touch notes the match a changed row belongs to, and for a match the tournaments
+// it was and is in.
+func (l *SeedLog) touch(entity, key string, before, after json.RawMessage) {
+	if !IsMatchEntity(entity) {
+		return
+	}
+	parts, err := ParseChangeKey(entity, key)
+	if err != nil {
+		return
+	}
+	l.matches[parts[0]] = true
+	if entity != models.ChangeMatch {
+		return
+	}
+	for _, row := range []json.RawMessage{before, after} {
+		var m struct {
+			TournamentID uint `json:"tournament_id"`
+		}
+		if row != nil && json.Unmarshal(row, &m) == nil && m.TournamentID != 0 {
+			l.tournaments[m.TournamentID] = true
+		}
+	}
+}
+
+// TouchedMatches is the number of matches whose rows the recorded writes changed.
+func (l *SeedLog) TouchedMatches() int { return len(l.matches) }
+
+// Rebuild recomputes the derived stats of the touched matches that still exist and
+// of every tournament the touched matches were or are in.
+func (l *SeedLog) Rebuild(tx *gorm.DB) error {
+	ids := sortedKeys(l.matches)
+	var existing []uint
+	for start := 0; start < len(ids); start += 1000 {
+		var matches []models.Match
+		chunk := ids[start:min(start+1000, len(ids))]
+		if err := tx.Select("id, tournament_id").Where("id IN ?", chunk).Order("id").Find(&matches).Error; err != nil {
+			return err
+		}
+		for _, m := range matches {
+			existing = append(existing, m.ID)
+			l.tournaments[m.TournamentID] = true
+		}
+	}
+	for _, id := range existing {
+		if err := rebuildMatchDerived(tx, id); err != nil {
+			return err
+		}
+	}
+	for _, id := range sortedKeys(l.tournaments) {
+		if err := rebuildTournamentDerived(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
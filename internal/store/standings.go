@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+)
+
+// StandingsStore reads everything a season table is computed from.
+type StandingsStore interface {
+	ListPointsRules(ctx context.Context, season *models.Season) ([]models.SeasonPointsRule, error)
+	ListSeasonSeries(ctx context.Context, seasonID uint) ([]StandingsSeriesRow, error)
+	ListSeasonPlacements(ctx context.Context, seasonID uint) ([]PlacementRow, error)
+	ListTeamsByIDs(ctx context.Context, ids []uint) ([]models.Team, error)
+}
+
+// StandingsSeriesRow is one decided series of the season. Team scores are maps won.
+type StandingsSeriesRow struct {
+	MatchID        uint
+	TournamentID   uint
+	TournamentType string
+	Team1ID        uint
+	Team2ID        uint
+	Team1Score     int
+	Team2Score     int
+	WinnerID       uint
+}
+
+// PlacementRow is one team's final placement at one event of the season.
+type PlacementRow struct {
+	TournamentID   uint
+	TournamentName string
+	TournamentType string
+	StartDate      string
+	TeamID         uint
+	Placement      int
+}
+
+type gormStandingsStore struct{ db *gorm.DB }
+
+func NewGormStandingsStore(db *gorm.DB) StandingsStore { return &gormStandingsStore{db: db} }
+
+// ListPointsRules returns the season's own rules if it has any, otherwise the era defaults.
+func (s *gormStandingsStore) ListPointsRules(ctx context.Context, season *models.Season) ([]models.SeasonPointsRule, error) {
+	var rules []models.SeasonPointsRule
+	err := s.db.WithContext(ctx).
+		Where("season_id = ?", season.ID).
+		Order("tournament_type, basis, placement_from").
+		Find(&rules).Error
+	if err != nil || len(rules) > 0 {
+		return rules, err
+	}
+	err = s.db.WithContext(ctx).
+		Where("season_id IS NULL AND game_code = ?", season.GameCode).
+		Order("tournament_type, basis, placement_from").
+		Find(&rules).Error
+	return rules, err
+}
+
+func (s *gormStandingsStore) ListSeasonSeries(ctx context.Context, seasonID uint) ([]StandingsSeriesRow, error) {
+	rows := make([]StandingsSeriesRow, 0)
+	err := s.db.WithContext(ctx).
+		Table("matches m").
+		Select(`m.id AS match_id, m.tournament_id, tour.tournament_type,
+			m.team1_id, m.team2_id, m.team1_score, m.team2_score, m.winner_id`).
+		Joins("JOIN tournaments tour ON tour.id = m.tournament_id").
+		Where("tour.season_id = ? AND m.winner_id IS NOT NULL AND m.team1_id <> m.team2_id", seasonID).
+		Where("tour.tournament_type <> 'season_summary'").
+		Order("m.id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormStandingsStore) ListSeasonPlacements(ctx context.Context, seasonID uint) ([]PlacementRow, error) {
+	rows := make([]PlacementRow, 0)
+	err := s.db.WithContext(ctx).
+		Table("team_tournament_stats tts").
+		Select(`tts.tournament_id, tour.name AS tournament_name, tour.tournament_type,
+			TO_CHAR(tour.start_date, 'YYYY-MM-DD') AS start_date,
+			tts.team_id, tts.placement`).
+		Joins("JOIN tournaments tour ON tour.id = tts.tournament_id").
+		Where("tour.season_id = ? AND tts.placement IS NOT NULL", seasonID).
+		Order("tour.start_date ASC, tts.tournament_id ASC, tts.placement ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (s *gormStandingsStore) ListTeamsByIDs(ctx context.Context, ids []uint) ([]models.Team, error) {
+	teams := make([]models.Team, 0)
+	if len(ids) == 0 {
+		return teams, nil
+	}
+	err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&teams).Error
+	return teams, err
+}
@@ -15,17 +15,22 @@ type KDRow struct {
 	SeasonDeaths  int     `json:"season_deaths"`
 	SeasonAssists int     `json:"season_assists"`
 	SeasonKD      float64 `json:"season_kd"`
+	Rating        float64 `json:"rating"`
 }
 
 type StatsStore interface {
 	GetTopKDRows(ctx context.Context, limit int) ([]KDRow, error)
 	GetAllKDRows(ctx context.Context, limit int, seasonID string) ([]KDRow, error)
+	ListLeaderboardRows(ctx context.Context, q LeaderboardQuery) ([]LeaderboardRow, error)
 }
 
 type gormStatsStore struct{ db *gorm.DB }
 
 func NewGormStatsStore(db *gorm.DB) StatsStore { return &gormStatsStore{db: db} }
 
+// kdBase sums season totals. Only season_summary tournaments hold a whole season's
+// totals; the per-tournament rows rebuilt from map lines would count the same
+// maps again.
 func (s *gormStatsStore) kdBase(ctx context.Context) *gorm.DB {
 	return s.db.WithContext(ctx).
 		Table("player_tournament_stats pts").
@@ -34,9 +39,13 @@ func (s *gormStatsStore) kdBase(ctx context.Context) *gorm.DB {
 			COALESCE(MAX(t.abbreviation), '') as team_abbr,
 			SUM(pts.total_kills) as season_kills,
 			SUM(pts.total_deaths) as season_deaths,
-			SUM(pts.total_assists) as season_assists`).
+			SUM(pts.total_assists) as season_assists,
+			COALESCE(SUM(pts.rating * pts.overall_maps) /
+				NULLIF(SUM(CASE WHEN pts.rating > 0 THEN pts.overall_maps ELSE 0 END), 0), 0) as rating`).
 		Joins("JOIN players p ON pts.player_id = p.id").
 		Joins("LEFT JOIN teams t ON pts.team_id = t.id").
+		Joins("JOIN tournaments tour ON pts.tournament_id = tour.id").
+		Where("tour.tournament_type = ?", "season_summary").
 		Group("pts.player_id")
 }
 
@@ -51,8 +60,7 @@ func (s *gormStatsStore) GetTopKDRows(ctx context.Context, limit int) ([]KDRow,
 }
 
 func (s *gormStatsStore) GetAllKDRows(ctx context.Context, limit int, seasonID string) ([]KDRow, error) {
-	query := s.kdBase(ctx).
-		Joins("JOIN tournaments tour ON pts.tournament_id = tour.id")
+	query := s.kdBase(ctx)
 	if seasonID != "" {
 		query = query.Where("tour.season_id = ?", seasonID)
 	}
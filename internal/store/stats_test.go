@@ -0,0 +1,65 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+// Editing a stat line rebuilds its tournament's player_tournament_stats; the season
+// boards read the season_summary totals and must not count those maps again.
+func TestSeasonBoards_IgnoreRebuiltTournamentRows(t *testing.T) {
+	db := storeTx(t)
+	ctx := context.Background()
+
+	mkSeasonAt(t, db, 1, "BO6", time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC))
+	mkTeamRow(t, db, 1, "OpTic Texas", "OTX")
+	mkTeamRow(t, db, 2, "Atlanta FaZe", "ATL")
+	mkPlayerRow(t, db, 1, "Dashy")
+	require.NoError(t, db.Create(&models.Tournament{
+		ID: 10, SeasonID: 1, Name: "BO6 Season Stats", Slug: "BO6-season-stats",
+		TournamentType: "season_summary", StartDate: time.Now(),
+	}).Error)
+	require.NoError(t, db.Create(&models.PlayerTournamentStats{
+		PlayerID: 1, TeamID: 1, TournamentID: 10,
+		TotalKills: 300, TotalDeaths: 250, OverallPlusMinus: 50, OverallMaps: 20,
+	}).Error)
+	mkTour(t, db, 11, 1, "Major 1")
+	mkMatchRow(t, db, 1, 11, 1, 2, time.Now())
+	mkMapRow(t, db, 1, 1, true)
+
+	stats := NewGormStatsStore(db)
+	plusMinus, ok := LookupLeaderboardStat("overall_plus_minus")
+	require.True(t, ok)
+	board := LeaderboardQuery{Stat: plusMinus, SeasonID: "1"}
+
+	top, err := stats.GetTopKDRows(ctx, 10)
+	require.NoError(t, err)
+	rows, err := stats.ListLeaderboardRows(ctx, board)
+	require.NoError(t, err)
+
+	admin := NewGormAdminStore(db)
+	require.NoError(t, admin.SaveStatLine(ctx, &models.PlayerMapStats{
+		MatchID: 1, MapNumber: 1, PlayerID: 1, TeamID: 1, Kills: 30, Deaths: 20,
+	}, 11, &models.ChangeLog{Actor: "admin"}))
+
+	var rebuilt models.PlayerTournamentStats
+	require.NoError(t, db.Where("tournament_id = ? AND player_id = ?", 11, 1).First(&rebuilt).Error)
+	require.Equal(t, 30, rebuilt.TotalKills, "the edit rebuilds the tournament's own row")
+
+	topAfter, err := stats.GetTopKDRows(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, top, topAfter)
+	require.Len(t, topAfter, 1)
+	require.Equal(t, 300, topAfter[0].SeasonKills)
+
+	rowsAfter, err := stats.ListLeaderboardRows(ctx, board)
+	require.NoError(t, err)
+	require.Equal(t, rows, rowsAfter)
+	require.Len(t, rowsAfter, 1)
+	require.Equal(t, 50.0, rowsAfter[0].Value)
+	require.Equal(t, 20, rowsAfter[0].Maps)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"strconv"
+	"time"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
 	"gorm.io/gorm"
@@ -17,6 +18,30 @@ type TeamStore interface {
 	GetPlayers(ctx context.Context, teamID int, seasonID string) ([]models.Player, error)
 	GetLatestMatchRoster(ctx context.Context, teamID int, seasonID string) ([]models.Player, error)
 	GetStats(ctx context.Context, teamID int) ([]models.TeamTournamentStats, error)
+	ListByFranchiseKey(ctx context.Context, key string) ([]models.Team, error)
+	ListMapPoolRows(ctx context.Context, teamID int, seasonID uint) ([]MapPoolRow, error)
+	ListFormMaps(ctx context.Context, teamID int) ([]FormMapRow, error)
+	ListFormSeries(ctx context.Context, teamID int) ([]FormSeriesRow, error)
+	ListLineupMaps(ctx context.Context, teamID int, seasonID string) ([]LineupMapRow, error)
+}
+
+// MapPoolRow is one played map from the given team's perspective. TeamRating and
+// OpponentRating are each side's pre-series Glicko-2 rating from team_rating_history,
+// nil when the series has not been rated.
+type MapPoolRow struct {
+	MatchID        uint
+	MapNumber      int
+	MapName        string
+	Mode           string
+	TeamScore      int
+	OpponentScore  int
+	OpponentID     uint
+	WinnerID       *uint
+	DurationSec    int
+	TeamRating     *float64
+	TeamRD         *float64
+	OpponentRating *float64
+	OpponentRD     *float64
 }
 
 type gormTeamStore struct{ db *gorm.DB }
@@ -195,3 +220,84 @@ func (s *gormTeamStore) GetStats(ctx context.Context, teamID int) ([]models.Team
 		Find(&stats).Error
 	return stats, err
 }
+
+// ListByFranchiseKey returns every era team linked to the franchise, oldest first.
+// It follows the same teams.franchise_id linkage the franchise-career query uses.
+func (s *gormTeamStore) ListByFranchiseKey(ctx context.Context, key string) ([]models.Team, error) {
+	var teams []models.Team
+	err := s.db.WithContext(ctx).
+		Joins("JOIN franchises f ON f.id = teams.franchise_id").
+		Where("f.franchise_key = ?", key).
+		Order("teams.valid_from ASC NULLS FIRST, teams.id ASC").
+		Find(&teams).Error
+	return teams, err
+}
+
+func (s *gormTeamStore) ListMapPoolRows(ctx context.Context, teamID int, seasonID uint) ([]MapPoolRow, error) {
+	rows := make([]MapPoolRow, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT mm.match_id, mm.map_number, mm.map_name, mm.mode,
+			CASE WHEN m.team1_id = @team THEN mm.score1 ELSE mm.score2 END AS team_score,
+			CASE WHEN m.team1_id = @team THEN mm.score2 ELSE mm.score1 END AS opponent_score,
+			CASE WHEN m.team1_id = @team THEN m.team2_id ELSE m.team1_id END AS opponent_id,
+			mm.winner_id,
+			mm.duration_sec,
+			th.rating_before AS team_rating,
+			th.rd_before     AS team_rd,
+			oh.rating_before AS opponent_rating,
+			oh.rd_before     AS opponent_rd
+		FROM match_maps mm
+		JOIN matches m        ON m.id = mm.match_id
+		JOIN tournaments tour ON tour.id = m.tournament_id
+		LEFT JOIN team_rating_history th ON th.match_id = m.id AND th.team_id = @team
+		LEFT JOIN team_rating_history oh ON oh.match_id = m.id AND oh.team_id <> @team
+		WHERE mm.played = true
+		  AND (m.team1_id = @team OR m.team2_id = @team)
+		  AND m.team1_id <> m.team2_id
+		  AND tour.season_id = @season
+		ORDER BY mm.map_name ASC, mm.mode ASC, m.match_date ASC, mm.match_id ASC, mm.map_number ASC
+	`, map[string]any{"team": teamID, "season": seasonID}).Scan(&rows).Error
+	return rows, err
+}
+
+// LineupMapRow is one map a team played, with the exact set of its players who have a
+// stat line on it. Players is a JSON array of {id, gamertag} ordered by player id.
+// Score and winner fields are zero/nil when the map has no match_maps row.
+type LineupMapRow struct {
+	MatchID       uint
+	MapNumber     int
+	PlayedAt      time.Time
+	Mode          string
+	TeamScore     int
+	OpponentScore int
+	WinnerID      *uint
+	MatchWinnerID *uint
+	Players       string
+}
+
+// ListLineupMaps uses the same "who actually played" rule as latestMatchRoster:
+// player_map_stats rows on maps that were played (or have no match_maps row).
+func (s *gormTeamStore) ListLineupMaps(ctx context.Context, teamID int, seasonID string) ([]LineupMapRow, error) {
+	query := s.db.WithContext(ctx).
+		Table("player_map_stats pms").
+		Select(`pms.match_id, pms.map_number, `+playedAtSQL+` AS played_at,
+			COALESCE(MAX(mm.mode), '') AS mode,
+			COALESCE(MAX(CASE WHEN m.team1_id = pms.team_id THEN mm.score1 ELSE mm.score2 END), 0) AS team_score,
+			COALESCE(MAX(CASE WHEN m.team1_id = pms.team_id THEN mm.score2 ELSE mm.score1 END), 0) AS opponent_score,
+			MAX(mm.winner_id) AS winner_id,
+			m.winner_id AS match_winner_id,
+			json_agg(json_build_object('id', p.id, 'gamertag', p.gamertag) ORDER BY p.id)::text AS players`).
+		Joins("JOIN players p ON p.id = pms.player_id").
+		Joins("JOIN matches m ON m.id = pms.match_id").
+		Joins("JOIN tournaments tour ON tour.id = m.tournament_id").
+		Joins("LEFT JOIN match_maps mm ON mm.match_id = pms.match_id AND mm.map_number = pms.map_number").
+		Where("pms.team_id = ? AND (mm.id IS NULL OR mm.played = true)", teamID).
+		Group("pms.match_id, pms.map_number, m.id, tour.start_date").
+		Order("played_at ASC, pms.match_id ASC, pms.map_number ASC")
+	if seasonID != "" {
+		query = query.Where("tour.season_id = ?", seasonID)
+	}
+	rows := make([]LineupMapRow, 0)
+	err := query.Scan(&rows).Error
+	return rows, err
+}
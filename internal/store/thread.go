@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/corbynfang/CDL-Website/internal/models"
@@ -10,20 +11,74 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// livePost matches thread_posts rows that are neither deleted by their author nor
+// hidden by a moderator.
+const livePost = "deleted_at IS NULL AND hidden_at IS NULL"
+
+// PostOrder selects how GetPostsByThreadID lays a thread out and what it paginates.
+type PostOrder string
+
+const (
+	// PostOrderFlat pages through every live post, oldest first.
+	PostOrderFlat PostOrder = "flat"
+	// PostOrderTree pages through top-level posts, oldest first, each followed
+	// depth-first by its replies.
+	PostOrderTree PostOrder = "tree"
+)
+
+// PostNode is the skeleton of a post used to lay a thread out in tree order.
+type PostNode struct {
+	ID       uint
+	ParentID *uint
+	Deleted  bool
+}
+
+type reactionRow struct {
+	PostID uint
+	Emoji  string
+	Count  int
+}
+
+type mentionRow struct {
+	PostID   uint
+	UserID   uint
+	Username string
+}
+
+type replyCountRow struct {
+	ParentID uint
+	Count    int
+}
+
 type ThreadStore interface {
+	ModerationStore
+	GetMatch(ctx context.Context, id uint) (*models.Match, error)
 	FindThread(ctx context.Context, matchID uint) (*models.MatchThread, error)
+	GetThreadByID(ctx context.Context, id uint) (*models.MatchThread, error)
 	GetOrCreateThread(ctx context.Context, matchID uint) (*models.MatchThread, error)
-	GetPostsByThreadID(ctx context.Context, threadID uint, limit, offset int) ([]models.ThreadPost, int64, error)
-	CreatePost(ctx context.Context, post *models.ThreadPost) error
+	GetPostsByThreadID(ctx context.Context, threadID uint, order PostOrder, limit, offset int) ([]models.ThreadPost, int64, error)
+	CreatePost(ctx context.Context, post *models.ThreadPost, mentions []uint) error
 	GetPost(ctx context.Context, id uint) (*models.ThreadPost, error)
-	UpdatePost(ctx context.Context, id uint, body string) error
+	UpdatePost(ctx context.Context, id uint, body string, mentions []uint) error
 	SoftDeletePost(ctx context.Context, id uint) error
+	ResolveUsernames(ctx context.Context, names []string) ([]models.User, error)
+	AddReaction(ctx context.Context, reaction *models.PostReaction) error
+	RemoveReaction(ctx context.Context, postID, userID uint, emoji string) error
+	GetReactionCounts(ctx context.Context, postID uint) ([]models.ReactionCount, error)
 }
 
 type gormThreadStore struct{ db *gorm.DB }
 
 func NewGormThreadStore(db *gorm.DB) ThreadStore { return &gormThreadStore{db: db} }
 
+func (s *gormThreadStore) GetMatch(ctx context.Context, id uint) (*models.Match, error) {
+	var match models.Match
+	if err := s.db.WithContext(ctx).First(&match, id).Error; err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
 func (s *gormThreadStore) FindThread(ctx context.Context, matchID uint) (*models.MatchThread, error) {
 	var thread models.MatchThread
 	err := s.db.WithContext(ctx).Where("match_id = ?", matchID).First(&thread).Error
@@ -33,6 +88,14 @@ func (s *gormThreadStore) FindThread(ctx context.Context, matchID uint) (*models
 	return &thread, err
 }
 
+func (s *gormThreadStore) GetThreadByID(ctx context.Context, id uint) (*models.MatchThread, error) {
+	var thread models.MatchThread
+	if err := s.db.WithContext(ctx).First(&thread, id).Error; err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
 func (s *gormThreadStore) GetOrCreateThread(ctx context.Context, matchID uint) (*models.MatchThread, error) {
 	thread := models.MatchThread{MatchID: matchID}
 	err := s.db.WithContext(ctx).
@@ -50,42 +113,267 @@ func (s *gormThreadStore) GetOrCreateThread(ctx context.Context, matchID uint) (
 	return &thread, nil
 }
 
-func (s *gormThreadStore) GetPostsByThreadID(ctx context.Context, threadID uint, limit, offset int) ([]models.ThreadPost, int64, error) {
+// GetPostsByThreadID returns one page of a thread with reply counts, reactions and
+// mentions filled in. total counts what the order paginates: live posts for flat,
+// top-level posts for tree. Reactions and mentions by deleted accounts are left out.
+func (s *gormThreadStore) GetPostsByThreadID(ctx context.Context, threadID uint, order PostOrder, limit, offset int) ([]models.ThreadPost, int64, error) {
+	var (
+		posts []models.ThreadPost
+		total int64
+		err   error
+	)
+	if order == PostOrderTree {
+		posts, total, err = s.treePage(ctx, threadID, limit, offset)
+	} else {
+		posts, total, err = s.flatPage(ctx, threadID, limit, offset)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.hydrate(ctx, posts); err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+func (s *gormThreadStore) flatPage(ctx context.Context, threadID uint, limit, offset int) ([]models.ThreadPost, int64, error) {
 	var total int64
 	if err := s.db.WithContext(ctx).Model(&models.ThreadPost{}).
-		Where("thread_id = ? AND deleted_at IS NULL", threadID).
+		Where("thread_id = ? AND "+livePost, threadID).
 		Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	var posts []models.ThreadPost
 	err := s.db.WithContext(ctx).
-		Where("thread_id = ? AND deleted_at IS NULL", threadID).
+		Where("thread_id = ? AND "+livePost, threadID).
 		Preload("User").
-		Order("created_at ASC").
+		Order("created_at ASC, id ASC").
 		Limit(limit).
 		Offset(offset).
 		Find(&posts).Error
 	return posts, total, err
 }
 
-func (s *gormThreadStore) CreatePost(ctx context.Context, post *models.ThreadPost) error {
-	return s.db.WithContext(ctx).Create(post).Error
+func (s *gormThreadStore) treePage(ctx context.Context, threadID uint, limit, offset int) ([]models.ThreadPost, int64, error) {
+	var nodes []PostNode
+	err := s.db.WithContext(ctx).Model(&models.ThreadPost{}).
+		Select("id, parent_id, NOT ("+livePost+") AS deleted").
+		Where("thread_id = ?", threadID).
+		Order("created_at ASC, id ASC").
+		Scan(&nodes).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, total := treeOrder(nodes, limit, offset)
+	if len(ids) == 0 {
+		return []models.ThreadPost{}, total, nil
+	}
+
+	var found []models.ThreadPost
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Preload("User").Find(&found).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uint]models.ThreadPost, len(found))
+	for _, p := range found {
+		if p.DeletedAt != nil || p.HiddenAt != nil {
+			p = models.ThreadPost{
+				ID: p.ID, ThreadID: p.ThreadID, ParentID: p.ParentID, Depth: p.Depth,
+				DeletedAt: p.DeletedAt, CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt, Deleted: true,
+			}
+		}
+		byID[p.ID] = p
+	}
+	posts := make([]models.ThreadPost, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			posts = append(posts, p)
+		}
+	}
+	return posts, total, nil
+}
+
+// treeOrder lays out a thread given in chronological order: the IDs of one page of
+// top-level posts, each followed depth-first by its replies, plus the number of
+// top-level posts. A deleted or hidden post is kept only while a live post hangs below it.
+func treeOrder(nodes []PostNode, limit, offset int) ([]uint, int64) {
+	known := make(map[uint]bool, len(nodes))
+	deleted := make(map[uint]bool, len(nodes))
+	for _, n := range nodes {
+		known[n.ID] = true
+		deleted[n.ID] = n.Deleted
+	}
+	children := map[uint][]uint{}
+	var roots []uint
+	for _, n := range nodes {
+		if n.ParentID == nil || !known[*n.ParentID] {
+			roots = append(roots, n.ID)
+		} else {
+			children[*n.ParentID] = append(children[*n.ParentID], n.ID)
+		}
+	}
+
+	visible := map[uint]bool{}
+	var mark func(id uint) bool
+	mark = func(id uint) bool {
+		v := !deleted[id]
+		for _, c := range children[id] {
+			if mark(c) {
+				v = true
+			}
+		}
+		visible[id] = v
+		return v
+	}
+	live := roots[:0:0]
+	for _, id := range roots {
+		if mark(id) {
+			live = append(live, id)
+		}
+	}
+
+	total := int64(len(live))
+	if offset >= len(live) {
+		return nil, total
+	}
+	live = live[offset:min(offset+limit, len(live))]
+
+	var ids []uint
+	var walk func(id uint)
+	walk = func(id uint) {
+		ids = append(ids, id)
+		for _, c := range children[id] {
+			if visible[c] {
+				walk(c)
+			}
+		}
+	}
+	for _, id := range live {
+		walk(id)
+	}
+	return ids, total
+}
+
+// hydrate fills in the read-only counts and lists on live posts.
+func (s *gormThreadStore) hydrate(ctx context.Context, posts []models.ThreadPost) error {
+	ids := make([]uint, 0, len(posts))
+	index := make(map[uint]*models.ThreadPost, len(posts))
+	for i := range posts {
+		p := &posts[i]
+		p.Reactions = []models.ReactionCount{}
+		p.Mentions = []models.MentionedUser{}
+		ids = append(ids, p.ID)
+		index[p.ID] = p
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var replies []replyCountRow
+	if err := s.db.WithContext(ctx).Model(&models.ThreadPost{}).
+		Select("parent_id, COUNT(*) AS count").
+		Where("parent_id IN ? AND "+livePost, ids).
+		Group("parent_id").
+		Scan(&replies).Error; err != nil {
+		return err
+	}
+	for _, r := range replies {
+		index[r.ParentID].ReplyCount = r.Count
+	}
+
+	var reactions []reactionRow
+	if err := s.reactionCounts(ctx, ids).Scan(&reactions).Error; err != nil {
+		return err
+	}
+	for _, r := range reactions {
+		if p := index[r.PostID]; !p.Deleted {
+			p.Reactions = append(p.Reactions, models.ReactionCount{Emoji: r.Emoji, Count: r.Count})
+		}
+	}
+
+	var mentions []mentionRow
+	if err := s.db.WithContext(ctx).Table("post_mentions m").
+		Select("m.post_id, u.id AS user_id, u.username").
+		Joins("JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL").
+		Where("m.post_id IN ?", ids).
+		Order("m.post_id ASC, m.id ASC").
+		Scan(&mentions).Error; err != nil {
+		return err
+	}
+	for _, m := range mentions {
+		if p := index[m.PostID]; !p.Deleted {
+			p.Mentions = append(p.Mentions, models.MentionedUser{UserID: m.UserID, Username: m.Username})
+		}
+	}
+	return nil
+}
+
+// reactionCounts groups reactions by post and emoji, most used first, skipping
+// reactions from deleted accounts.
+func (s *gormThreadStore) reactionCounts(ctx context.Context, postIDs []uint) *gorm.DB {
+	return s.db.WithContext(ctx).Table("post_reactions r").
+		Select("r.post_id, r.emoji, COUNT(*) AS count").
+		Joins("JOIN users u ON u.id = r.user_id AND u.deleted_at IS NULL").
+		Where("r.post_id IN ?", postIDs).
+		Group("r.post_id, r.emoji").
+		Order("r.post_id ASC, count DESC, MIN(r.created_at) ASC")
+}
+
+// CreatePost inserts the post and its mentions, then reloads it with its author, so
+// the response and the post_created event match what GetPostsByThreadID returns.
+func (s *gormThreadStore) CreatePost(ctx context.Context, post *models.ThreadPost, mentions []uint) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		return replaceMentions(tx, post.ID, mentions)
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Preload("User").First(post, post.ID).Error; err != nil {
+		return err
+	}
+	posts := []models.ThreadPost{*post}
+	if err := s.hydrate(ctx, posts); err != nil {
+		return err
+	}
+	*post = posts[0]
+	return nil
 }
 
 func (s *gormThreadStore) GetPost(ctx context.Context, id uint) (*models.ThreadPost, error) {
 	var post models.ThreadPost
-	err := s.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&post).Error
+	err := s.db.WithContext(ctx).Where("id = ? AND "+livePost, id).First(&post).Error
 	if err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
-func (s *gormThreadStore) UpdatePost(ctx context.Context, id uint, body string) error {
-	return s.db.WithContext(ctx).Model(&models.ThreadPost{}).
-		Where("id = ?", id).
-		Updates(map[string]any{"body": body, "edited": true}).Error
+func (s *gormThreadStore) UpdatePost(ctx context.Context, id uint, body string, mentions []uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ThreadPost{}).
+			Where("id = ?", id).
+			Updates(map[string]any{"body": body, "edited": true}).Error; err != nil {
+			return err
+		}
+		return replaceMentions(tx, id, mentions)
+	})
+}
+
+func replaceMentions(tx *gorm.DB, postID uint, userIDs []uint) error {
+	if err := tx.Where("post_id = ?", postID).Delete(&models.PostMention{}).Error; err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	rows := make([]models.PostMention, len(userIDs))
+	for i, uid := range userIDs {
+		rows[i] = models.PostMention{PostID: postID, UserID: uid}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
 }
 
 func (s *gormThreadStore) SoftDeletePost(ctx context.Context, id uint) error {
@@ -93,3 +381,42 @@ func (s *gormThreadStore) SoftDeletePost(ctx context.Context, id uint) error {
 		Where("id = ?", id).
 		Update("deleted_at", time.Now()).Error
 }
+
+// ResolveUsernames returns the live users whose usernames match names, ignoring case.
+func (s *gormThreadStore) ResolveUsernames(ctx context.Context, names []string) ([]models.User, error) {
+	users := make([]models.User, 0)
+	if len(names) == 0 {
+		return users, nil
+	}
+	lower := make([]string, len(names))
+	for i, n := range names {
+		lower[i] = strings.ToLower(n)
+	}
+	err := s.db.WithContext(ctx).
+		Where("LOWER(username) IN ? AND deleted_at IS NULL", lower).
+		Find(&users).Error
+	return users, err
+}
+
+// AddReaction is idempotent: reacting twice with the same emoji keeps one row.
+func (s *gormThreadStore) AddReaction(ctx context.Context, reaction *models.PostReaction) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(reaction).Error
+}
+
+func (s *gormThreadStore) RemoveReaction(ctx context.Context, postID, userID uint, emoji string) error {
+	return s.db.WithContext(ctx).
+		Where("post_id = ? AND user_id = ? AND emoji = ?", postID, userID, emoji).
+		Delete(&models.PostReaction{}).Error
+}
+
+func (s *gormThreadStore) GetReactionCounts(ctx context.Context, postID uint) ([]models.ReactionCount, error) {
+	var rows []reactionRow
+	if err := s.reactionCounts(ctx, []uint{postID}).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make([]models.ReactionCount, len(rows))
+	for i, r := range rows {
+		counts[i] = models.ReactionCount{Emoji: r.Emoji, Count: r.Count}
+	}
+	return counts, nil
+}
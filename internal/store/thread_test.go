@@ -0,0 +1,49 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nodeParent(id uint) *uint { return &id }
+
+func TestTreeOrder(t *testing.T) {
+	// Chronological: 1 and 2 are top-level; 3 replies to 1, 4 to 3, 5 to 1, 6 to 2.
+	nodes := []PostNode{
+		{ID: 1},
+		{ID: 2},
+		{ID: 3, ParentID: nodeParent(1)},
+		{ID: 4, ParentID: nodeParent(3)},
+		{ID: 5, ParentID: nodeParent(1)},
+		{ID: 6, ParentID: nodeParent(2)},
+		{ID: 7},
+	}
+
+	ids, total := treeOrder(nodes, 25, 0)
+	assert.EqualValues(t, 3, total)
+	assert.Equal(t, []uint{1, 3, 4, 5, 2, 6, 7}, ids)
+
+	ids, total = treeOrder(nodes, 1, 1)
+	assert.EqualValues(t, 3, total)
+	assert.Equal(t, []uint{2, 6}, ids, "pages count top-level posts only")
+
+	ids, _ = treeOrder(nodes, 25, 5)
+	assert.Empty(t, ids)
+}
+
+func TestTreeOrder_SoftDeleted(t *testing.T) {
+	nodes := []PostNode{
+		{ID: 1, Deleted: true},                          // kept: live reply below
+		{ID: 2, Deleted: true},                          // dropped: nothing live below
+		{ID: 3, ParentID: nodeParent(1), Deleted: true}, // kept: 4 is live
+		{ID: 4, ParentID: nodeParent(3)},
+		{ID: 5, ParentID: nodeParent(2), Deleted: true},
+		{ID: 6},
+		{ID: 7, ParentID: nodeParent(6), Deleted: true},
+	}
+
+	ids, total := treeOrder(nodes, 25, 0)
+	assert.EqualValues(t, 2, total)
+	assert.Equal(t, []uint{1, 3, 4, 6}, ids)
+}
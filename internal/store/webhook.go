@@ -0,0 +1,290 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/corbynfang/CDL-Website/internal/models"
+	"gorm.io/gorm"
+)
+
+// WebhookStore keeps outbound webhook subscriptions, the event outbox that is filled
+// from match, map, transfer and tournament changes, and the per-subscription
+// delivery queue with its attempt log.
+type WebhookStore interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	SetSubscriptionActive(ctx context.Context, id uint, active bool, at time.Time) error
+	CollectEvents(ctx context.Context, since, now time.Time) (int64, error)
+	QueueDeliveries(ctx context.Context, since, now time.Time) (int64, error)
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]DueWebhook, error)
+	RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt, update WebhookDeliveryUpdate) error
+	ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit, offset int) ([]models.WebhookDelivery, int64, error)
+	Replay(ctx context.Context, subscriptionID uint, since, until, now time.Time) (int64, error)
+}
+
+// DueWebhook is a pending delivery whose next attempt is due, with the event and the
+// subscription it goes to. Active is false once the subscription has been disabled.
+type DueWebhook struct {
+	DeliveryID     uint
+	Attempts       int
+	SubscriptionID uint
+	URL            string
+	Secret         string
+	Active         bool
+	EventID        uint
+	Type           string
+	Payload        json.RawMessage
+	CreatedAt      time.Time
+}
+
+// WebhookDeliveryUpdate is the delivery state after an attempt. SentAt is set only
+// when the attempt succeeded.
+type WebhookDeliveryUpdate struct {
+	Status        string
+	NextAttemptAt time.Time
+	StatusCode    int
+	Error         string
+	SentAt        *time.Time
+}
+
+type gormWebhookStore struct{ db *gorm.DB }
+
+func NewGormWebhookStore(db *gorm.DB) WebhookStore { return &gormWebhookStore{db: db} }
+
+func (s *gormWebhookStore) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return s.db.WithContext(ctx).Create(sub).Error
+}
+
+func (s *gormWebhookStore) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	subs := make([]models.WebhookSubscription, 0)
+	err := s.db.WithContext(ctx).Order("id ASC").Find(&subs).Error
+	return subs, err
+}
+
+func (s *gormWebhookStore) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := s.db.WithContext(ctx).First(&sub, id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (s *gormWebhookStore) SetSubscriptionActive(ctx context.Context, id uint, active bool, at time.Time) error {
+	res := s.db.WithContext(ctx).Model(&models.WebhookSubscription{}).Where("id = ?", id).
+		Updates(map[string]any{"active": active, "updated_at": at})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// collectMatchesSQL emits match.completed for every decided match touched since the
+// given time. The score is part of the key, so a corrected result is a new event.
+const collectMatchesSQL = `
+INSERT INTO webhook_events (type, dedupe_key, payload, created_at)
+SELECT 'match.completed',
+	'match.completed:' || m.id || ':' || m.winner_id || ':' || m.team1_score || '-' || m.team2_score,
+	json_build_object(
+		'match_id', m.id,
+		'tournament_id', m.tournament_id,
+		'tournament', tour.name,
+		'match_date', m.match_date,
+		'format', m.format,
+		'team1', json_build_object('id', t1.id, 'name', t1.name, 'abbreviation', t1.abbreviation),
+		'team2', json_build_object('id', t2.id, 'name', t2.name, 'abbreviation', t2.abbreviation),
+		'team1_score', m.team1_score,
+		'team2_score', m.team2_score,
+		'winner_id', m.winner_id),
+	@now
+FROM matches m
+JOIN teams t1         ON t1.id = m.team1_id
+JOIN teams t2         ON t2.id = m.team2_id
+JOIN tournaments tour ON tour.id = m.tournament_id
+WHERE m.winner_id IS NOT NULL AND m.updated_at >= @since
+ON CONFLICT (dedupe_key) DO NOTHING`
+
+// collectMapsSQL emits map.completed for every played map with a winner.
+const collectMapsSQL = `
+INSERT INTO webhook_events (type, dedupe_key, payload, created_at)
+SELECT 'map.completed',
+	'map.completed:' || mm.id || ':' || mm.winner_id || ':' || mm.score1 || '-' || mm.score2,
+	json_build_object(
+		'match_id', mm.match_id,
+		'map_number', mm.map_number,
+		'map_name', mm.map_name,
+		'mode', mm.mode,
+		'team1_id', m.team1_id,
+		'team2_id', m.team2_id,
+		'score_1', mm.score1,
+		'score_2', mm.score2,
+		'winner_id', mm.winner_id),
+	@now
+FROM match_maps mm
+JOIN matches m ON m.id = mm.match_id
+WHERE mm.played AND mm.winner_id IS NOT NULL AND mm.updated_at >= @since
+ON CONFLICT (dedupe_key) DO NOTHING`
+
+// collectTransfersSQL emits transfer.created once per transfer row.
+const collectTransfersSQL = `
+INSERT INTO webhook_events (type, dedupe_key, payload, created_at)
+SELECT 'transfer.created',
+	'transfer.created:' || pt.id,
+	json_build_object(
+		'transfer_id', pt.id,
+		'player', json_build_object('id', p.id, 'gamertag', p.gamertag),
+		'from_team', CASE WHEN ft.id IS NULL THEN NULL
+			ELSE json_build_object('id', ft.id, 'name', ft.name, 'abbreviation', ft.abbreviation) END,
+		'to_team', CASE WHEN tt.id IS NULL THEN NULL
+			ELSE json_build_object('id', tt.id, 'name', tt.name, 'abbreviation', tt.abbreviation) END,
+		'raw_from_team_name', pt.raw_from_team_name,
+		'raw_to_team_name', pt.raw_to_team_name,
+		'transfer_date', pt.transfer_date,
+		'transfer_type', pt.transfer_type,
+		'role', pt.role,
+		'description', pt.description),
+	@now
+FROM player_transfers pt
+JOIN players p     ON p.id = pt.player_id
+LEFT JOIN teams ft ON ft.id = pt.from_team_id
+LEFT JOIN teams tt ON tt.id = pt.to_team_id
+WHERE pt.created_at >= @since
+ON CONFLICT (dedupe_key) DO NOTHING`
+
+// collectTournamentsSQL emits tournament.updated for every save of a tournament.
+const collectTournamentsSQL = `
+INSERT INTO webhook_events (type, dedupe_key, payload, created_at)
+SELECT 'tournament.updated',
+	'tournament.updated:' || tour.id || ':' || to_char(tour.updated_at AT TIME ZONE 'UTC', 'YYYYMMDDHH24MISSUS'),
+	json_build_object(
+		'tournament_id', tour.id,
+		'season_id', tour.season_id,
+		'name', tour.name,
+		'slug', tour.slug,
+		'tournament_type', tour.tournament_type,
+		'start_date', tour.start_date,
+		'end_date', tour.end_date,
+		'location', tour.location,
+		'is_lan', tour.is_lan,
+		'updated_at', tour.updated_at),
+	@now
+FROM tournaments tour
+WHERE tour.updated_at >= @since
+ON CONFLICT (dedupe_key) DO NOTHING`
+
+// CollectEvents writes outbox events for everything that changed since the given
+// time. It is idempotent: an unchanged source row never produces a second event.
+func (s *gormWebhookStore) CollectEvents(ctx context.Context, since, now time.Time) (int64, error) {
+	var created int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		args := map[string]any{"since": since, "now": now}
+		for _, q := range []string{collectMatchesSQL, collectMapsSQL, collectTransfersSQL, collectTournamentsSQL} {
+			res := tx.Exec(q, args)
+			if res.Error != nil {
+				return res.Error
+			}
+			created += res.RowsAffected
+		}
+		return nil
+	})
+	return created, err
+}
+
+// webhookWantsSQL is true when subscription s asked for the type of event e.
+const webhookWantsSQL = `position(',' || e.type || ',' in ',' || replace(s.events, ' ', '') || ',') > 0`
+
+// QueueDeliveries queues events created since the given time on every active
+// subscription that wants them and already existed when the event was recorded.
+func (s *gormWebhookStore) QueueDeliveries(ctx context.Context, since, now time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Exec(`
+		INSERT INTO webhook_deliveries
+			(subscription_id, event_id, status, attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at)
+		SELECT s.id, e.id, 'pending', 0, @now, 0, '', @now, @now
+		FROM webhook_events e
+		JOIN webhook_subscriptions s ON s.active AND s.created_at <= e.created_at
+		WHERE e.created_at >= @since AND `+webhookWantsSQL+`
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`, map[string]any{"since": since, "now": now})
+	return res.RowsAffected, res.Error
+}
+
+func (s *gormWebhookStore) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]DueWebhook, error) {
+	rows := make([]DueWebhook, 0)
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT d.id AS delivery_id, d.attempts,
+			s.id AS subscription_id, s.url, s.secret, s.active,
+			e.id AS event_id, e.type, e.payload, e.created_at
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		JOIN webhook_events e        ON e.id = d.event_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at ASC, d.id ASC
+		LIMIT ?
+	`, now, limit).Scan(&rows).Error
+	return rows, err
+}
+
+// RecordAttempt appends the attempt to the delivery log and moves the delivery to
+// its new state in one transaction.
+func (s *gormWebhookStore) RecordAttempt(ctx context.Context, attempt *models.WebhookAttempt, update WebhookDeliveryUpdate) error {
+	attempt.Error = truncate(attempt.Error, 500)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id = ?", attempt.DeliveryID).
+			Updates(map[string]any{
+				"status":           update.Status,
+				"attempts":         attempt.Attempt,
+				"next_attempt_at":  update.NextAttemptAt,
+				"last_status_code": update.StatusCode,
+				"last_error":       truncate(update.Error, 500),
+				"sent_at":          update.SentAt,
+				"updated_at":       attempt.CreatedAt,
+			}).Error
+	})
+}
+
+// ListDeliveries pages through a subscription's deliveries, newest first, with the
+// event and every logged attempt.
+func (s *gormWebhookStore) ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	base := s.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		base = base.Where("status = ?", status)
+	}
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	deliveries := make([]models.WebhookDelivery, 0)
+	err := base.
+		Preload("Event").
+		Preload("Log", func(db *gorm.DB) *gorm.DB { return db.Order("attempt ASC") }).
+		Order("created_at DESC, id DESC").
+		Limit(limit).Offset(offset).
+		Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// Replay (re)queues every event the subscription wants that was recorded in
+// [since, until), including ones from before the subscription existed. Deliveries
+// that already exist are reset to pending with a fresh attempt count; their log is kept.
+func (s *gormWebhookStore) Replay(ctx context.Context, subscriptionID uint, since, until, now time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Exec(`
+		INSERT INTO webhook_deliveries
+			(subscription_id, event_id, status, attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at)
+		SELECT s.id, e.id, 'pending', 0, @now, 0, '', @now, @now
+		FROM webhook_events e
+		JOIN webhook_subscriptions s ON s.id = @sub
+		WHERE e.created_at >= @since AND e.created_at < @until AND `+webhookWantsSQL+`
+		ON CONFLICT (subscription_id, event_id) DO UPDATE SET
+			status = 'pending', attempts = 0, next_attempt_at = @now,
+			last_error = '', sent_at = NULL, updated_at = @now
+	`, map[string]any{"sub": subscriptionID, "since": since, "until": until, "now": now})
+	return res.RowsAffected, res.Error
+}